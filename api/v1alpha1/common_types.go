@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // MongoDBVersion defines MongoDB version configuration
@@ -30,10 +31,23 @@ type MongoDBVersion struct {
 	// Image is the MongoDB container image
 	// +optional
 	Image string `json:"image,omitempty"`
+
+	// ImageDigest pins the image to a digest, e.g. "sha256:4f3e...", so rolling upgrades and
+	// rescheduled pods run the exact same image even when its tag is pushed again
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	// +optional
+	ImageDigest string `json:"imageDigest,omitempty"`
 }
 
 // StorageSpec defines storage configuration
 type StorageSpec struct {
+	// Type is persistent for PersistentVolumeClaims, or ephemeral for an emptyDir that is lost with
+	// the pod, with shorter probe delays and no default anti-affinity, for CI and preview environments
+	// +kubebuilder:validation:Enum=persistent;ephemeral
+	// +kubebuilder:default=persistent
+	// +optional
+	Type string `json:"type,omitempty"`
+
 	// StorageClassName is the name of the StorageClass
 	// If not specified, the default storage class will be used
 	// +optional
@@ -46,6 +60,44 @@ type StorageSpec struct {
 	// DataDirPath is the path for MongoDB data
 	// +kubebuilder:default="/data/db"
 	DataDirPath string `json:"dataDirPath,omitempty"`
+
+	// UsageWarningPercent is the filesystem usage of a member's dbPath, in percent,
+	// at which the StorageHealthy condition turns False
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +kubebuilder:default=80
+	// +optional
+	UsageWarningPercent int32 `json:"usageWarningPercent,omitempty"`
+}
+
+// MemberStorageStatus is the disk usage of a member, in bytes
+type MemberStorageStatus struct {
+	// Name is the pod name
+	Name string `json:"name"`
+
+	// DataSize is the uncompressed size of the data, summed over all databases
+	// +optional
+	DataSize int64 `json:"dataSize,omitempty"`
+
+	// StorageSize is the space allocated to collections on disk, summed over all databases
+	// +optional
+	StorageSize int64 `json:"storageSize,omitempty"`
+
+	// IndexSize is the space allocated to indexes on disk, summed over all databases
+	// +optional
+	IndexSize int64 `json:"indexSize,omitempty"`
+
+	// FsUsedSize is the used space of the filesystem holding the dbPath
+	// +optional
+	FsUsedSize int64 `json:"fsUsedSize,omitempty"`
+
+	// FsTotalSize is the capacity of the filesystem holding the dbPath
+	// +optional
+	FsTotalSize int64 `json:"fsTotalSize,omitempty"`
+
+	// UsedPercent is the filesystem usage of the dbPath in percent
+	// +optional
+	UsedPercent int32 `json:"usedPercent,omitempty"`
 }
 
 // ResourcesSpec defines resource requirements
@@ -112,12 +164,114 @@ type AuthSpec struct {
 	// +kubebuilder:default="SCRAM-SHA-256"
 	Mechanism string `json:"mechanism,omitempty"`
 
-	// AdminCredentialsSecretRef references the admin credentials secret
-	AdminCredentialsSecretRef corev1.LocalObjectReference `json:"adminCredentialsSecretRef"`
+	// AdminCredentialsSecretRef references the admin credentials secret.
+	// If not specified, the operator generates a random password and stores it
+	// in the <name>-admin secret.
+	// +optional
+	AdminCredentialsSecretRef CredentialsSecretRef `json:"adminCredentialsSecretRef,omitempty"`
+
+	// Bootstrap selects how the admin user of a new cluster is created. Exec runs mongosh in the
+	// primary through pods/exec. Job runs it from a one-shot Job authenticating with the keyfile,
+	// on the config servers of sharded clusters, and needs no pods/exec. It only covers the admin
+	// user, the other steps of the operator still run through pods/exec.
+	// +kubebuilder:validation:Enum=Exec;Job
+	// +kubebuilder:default="Exec"
+	// +optional
+	Bootstrap string `json:"bootstrap,omitempty"`
+
+	// KeyfileSecretRef references a user-provided internal authentication keyfile,
+	// e.g. one shared with a disaster recovery cluster. If not specified, the operator
+	// generates a keyfile and stores it in the <name>-keyfile secret.
+	// +optional
+	KeyfileSecretRef *KeyfileSecretRef `json:"keyfileSecretRef,omitempty"`
 
 	// Users defines additional users to create
 	// +optional
 	Users []MongoDBUser `json:"users,omitempty"`
+
+	// OIDC configures MongoDB OIDC / Workload Identity Federation authentication (MongoDB 7.0+)
+	// +optional
+	OIDC *OIDCSpec `json:"oidc,omitempty"`
+}
+
+// CredentialsSecretRef references a secret holding a username and password
+type CredentialsSecretRef struct {
+	// Name is the secret name
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// UsernameKey is the secret key containing the username
+	// +kubebuilder:default="username"
+	// +optional
+	UsernameKey string `json:"usernameKey,omitempty"`
+
+	// PasswordKey is the secret key containing the password
+	// +kubebuilder:default="password"
+	// +optional
+	PasswordKey string `json:"passwordKey,omitempty"`
+}
+
+// KeyfileSecretRef references a secret holding an internal authentication keyfile
+type KeyfileSecretRef struct {
+	// Name is the secret name
+	Name string `json:"name"`
+
+	// Key is the secret key holding the keyfile content
+	// +kubebuilder:default="keyfile"
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// OIDCSpec defines OIDC authentication configuration
+type OIDCSpec struct {
+	// Enabled enables the MONGODB-OIDC authentication mechanism
+	Enabled bool `json:"enabled"`
+
+	// Providers defines the OIDC identity providers trusted by the cluster
+	// +kubebuilder:validation:MinItems=1
+	Providers []OIDCProviderSpec `json:"providers"`
+}
+
+// OIDCProviderSpec defines a single OIDC identity provider
+type OIDCProviderSpec struct {
+	// Issuer is the issuer URI of the identity provider
+	// +kubebuilder:validation:Pattern=`^https://`
+	Issuer string `json:"issuer"`
+
+	// Audience is the expected audience (aud claim) of access tokens
+	Audience string `json:"audience"`
+
+	// AuthNamePrefix is the unique prefix applied to users and roles from this provider
+	AuthNamePrefix string `json:"authNamePrefix"`
+
+	// ClientID is the client ID used by human (interactive) flows
+	// +optional
+	ClientID string `json:"clientId,omitempty"`
+
+	// MatchPattern selects this provider based on the username (required with multiple providers)
+	// +optional
+	MatchPattern string `json:"matchPattern,omitempty"`
+
+	// PrincipalNameClaim is the claim used as the user principal name
+	// +kubebuilder:default="sub"
+	PrincipalNameClaim string `json:"principalNameClaim,omitempty"`
+
+	// AuthorizationClaim is the claim containing the user's group memberships
+	// +optional
+	AuthorizationClaim string `json:"authorizationClaim,omitempty"`
+
+	// UseAuthorizationClaim maps the authorization claim to MongoDB roles.
+	// When false, privileges are granted to users defined in the $external database.
+	// +kubebuilder:default=true
+	UseAuthorizationClaim *bool `json:"useAuthorizationClaim,omitempty"`
+
+	// SupportsHumanFlows enables interactive (human) authentication flows
+	// +kubebuilder:default=false
+	SupportsHumanFlows bool `json:"supportsHumanFlows,omitempty"`
+
+	// RequestScopes are additional scopes requested by human flows
+	// +optional
+	RequestScopes []string `json:"requestScopes,omitempty"`
 }
 
 // MongoDBUser defines a MongoDB user
@@ -133,6 +287,13 @@ type MongoDBUser struct {
 
 	// Roles defines user roles
 	Roles []MongoDBRole `json:"roles"`
+
+	// ConnectionSecretName is the name of the secret holding the connection details of this user.
+	// Defaults to <cluster>-<db>-<name>.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=253
+	// +optional
+	ConnectionSecretName string `json:"connectionSecretName,omitempty"`
 }
 
 // MongoDBRole defines a MongoDB role
@@ -197,6 +358,30 @@ type ExporterSpec struct {
 	// Resources defines exporter resource requirements
 	// +optional
 	Resources ResourcesSpec `json:"resources,omitempty"`
+
+	// Port is the port the exporter serves metrics on
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=9216
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Args are additional exporter arguments
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// CollStatsNamespaces limits the collstats collector to these <db>.<collection> namespaces
+	// +optional
+	CollStatsNamespaces []string `json:"collStatsNamespaces,omitempty"`
+
+	// IndexStatsNamespaces limits the indexstats collector to these <db>.<collection> namespaces
+	// +optional
+	IndexStatsNamespaces []string `json:"indexStatsNamespaces,omitempty"`
+
+	// Collectors enables (true) or disables (false) individual collectors such as dbstats or topmetrics.
+	// Collectors that are not listed stay enabled.
+	// +optional
+	Collectors map[string]bool `json:"collectors,omitempty"`
 }
 
 // BackupSpec defines backup configuration
@@ -270,9 +455,34 @@ type S3StorageSpec struct {
 	// +optional
 	Prefix string `json:"prefix,omitempty"`
 
-	// InsecureSkipTLS skips TLS verification
+	// InsecureSkipTLS skips the verification of the certificate of the endpoint
 	// +kubebuilder:default=false
 	InsecureSkipTLS bool `json:"insecureSkipTLS,omitempty"`
+
+	// ForcePathStyle addresses the bucket in the path of the URL instead of the host name, as
+	// Ceph RGW and MinIO endpoints without wildcard DNS need
+	// +optional
+	ForcePathStyle bool `json:"forcePathStyle,omitempty"`
+
+	// CASecretRef references the key of a secret holding the PEM CA bundle the certificate of the
+	// endpoint is verified with, for endpoints signed by a private CA
+	// +optional
+	CASecretRef *corev1.SecretKeySelector `json:"caSecretRef,omitempty"`
+
+	// ServerSideEncryption has the archives encrypted at rest by the S3 service
+	// +optional
+	ServerSideEncryption *S3ServerSideEncryptionSpec `json:"serverSideEncryption,omitempty"`
+}
+
+// S3ServerSideEncryptionSpec defines the server-side encryption of uploaded archives
+type S3ServerSideEncryptionSpec struct {
+	// Algorithm is AES256 for keys managed by the S3 service, aws:kms for KMS keys
+	// +kubebuilder:validation:Enum=AES256;aws:kms
+	Algorithm string `json:"algorithm"`
+
+	// KMSKeyID is the KMS key of the aws:kms algorithm, the default KMS key of the account when empty
+	// +optional
+	KMSKeyID string `json:"kmsKeyID,omitempty"`
 }
 
 // PVCStorageSpec defines PVC storage configuration
@@ -336,6 +546,12 @@ type PodSpec struct {
 	// +optional
 	ContainerSecurityContext *corev1.SecurityContext `json:"containerSecurityContext,omitempty"`
 
+	// ReadOnlyRootFilesystem mounts the root filesystem of the mongod, mongos and exporter
+	// containers read-only. /tmp and the mongosh home directory are backed by emptyDir volumes.
+	// +kubebuilder:default=true
+	// +optional
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+
 	// Affinity defines pod affinity rules
 	// +optional
 	Affinity *corev1.Affinity `json:"affinity,omitempty"`
@@ -352,13 +568,183 @@ type PodSpec struct {
 	// +optional
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 
-	// ServiceAccountName is the service account name
+	// TerminationGracePeriodSeconds is how long the pods may take to stop. It covers the primary
+	// step-down of the members and the connection drain of mongos.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TerminationGracePeriodSeconds *int64 `json:"terminationGracePeriodSeconds,omitempty"`
+
+	// StartupProbe overrides the timing of the startup probe of the mongod or mongos container,
+	// which holds back the other probes. Members get an hour to start by default, mongos five
+	// minutes.
+	// +optional
+	StartupProbe *ProbeSpec `json:"startupProbe,omitempty"`
+
+	// LivenessProbe overrides the timing of the liveness probe of the mongod or mongos container
+	// +optional
+	LivenessProbe *ProbeSpec `json:"livenessProbe,omitempty"`
+
+	// ReadinessProbe overrides the timing of the readiness probe of the mongod or mongos container
+	// +optional
+	ReadinessProbe *ProbeSpec `json:"readinessProbe,omitempty"`
+
+	// ServiceAccountName is the service account the pods run under. When empty the operator
+	// creates a ServiceAccount named after the cluster.
 	// +optional
 	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 
+	// AutomountServiceAccountToken mounts the service account token into the pods
+	// +kubebuilder:default=false
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
+
 	// TopologySpreadConstraints describes how pods are spread across topology
 	// +optional
 	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// AntiAffinityMode controls how strictly the members of a replica set are kept off the same
+	// node. Required refuses to schedule two members on one node, Preferred only avoids it.
+	// +kubebuilder:validation:Enum=Preferred;Required
+	// +kubebuilder:default=Preferred
+	// +optional
+	AntiAffinityMode string `json:"antiAffinityMode,omitempty"`
+
+	// ZoneSpread spreads the members of a replica set across topology.kubernetes.io/zone
+	// +optional
+	ZoneSpread *ZoneSpreadSpec `json:"zoneSpread,omitempty"`
+
+	// NodeTuning runs a privileged init container applying the kernel settings of the MongoDB
+	// production notes to the node. Ignored for mongos.
+	// +optional
+	NodeTuning *NodeTuningSpec `json:"nodeTuning,omitempty"`
+
+	// Labels are added to the pods. Labels set by the operator take precedence.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the pods and override the annotations set by the operator
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Sidecars are additional containers of the pods. A container named like one of the
+	// operator containers replaces it.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=array
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Sidecars []corev1.Container `json:"sidecars,omitempty"`
+
+	// InitContainers are additional init containers, run after the operator init containers.
+	// An init container named like one of the operator init containers replaces it.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=array
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+
+	// Volumes are additional pod volumes. A volume named like one of the operator volumes
+	// replaces it.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=array
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts are additional mounts of the database container. A mount on the path of
+	// one of the operator mounts replaces it.
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=array
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+}
+
+// ProbeSpec overrides the timing of a container probe. Unset fields keep the operator defaults.
+type ProbeSpec struct {
+	// InitialDelaySeconds is the delay between the start of the container and the first probe
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	InitialDelaySeconds *int32 `json:"initialDelaySeconds,omitempty"`
+
+	// PeriodSeconds is the interval between two probes
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	PeriodSeconds *int32 `json:"periodSeconds,omitempty"`
+
+	// TimeoutSeconds is how long a probe may take before it counts as failed
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed probes after which the container is
+	// restarted, or taken out of the Service endpoints for the readiness probe
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+}
+
+// ZoneSpreadSpec defines how the members of a replica set are spread across zones
+type ZoneSpreadSpec struct {
+	// Enabled adds a topology spread constraint over topology.kubernetes.io/zone
+	Enabled bool `json:"enabled"`
+
+	// MaxSkew is the maximum difference in the number of members between two zones
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	// +optional
+	MaxSkew int32 `json:"maxSkew,omitempty"`
+
+	// WhenUnsatisfiable tells the scheduler how to handle a member that cannot be spread
+	// +kubebuilder:validation:Enum=DoNotSchedule;ScheduleAnyway
+	// +kubebuilder:default=DoNotSchedule
+	// +optional
+	WhenUnsatisfiable string `json:"whenUnsatisfiable,omitempty"`
+}
+
+// NodeTuningSpec defines the kernel settings applied to the nodes running the members
+type NodeTuningSpec struct {
+	// Enabled disables transparent hugepages and sets vm.max_map_count on the node
+	Enabled bool `json:"enabled"`
+
+	// MaxMapCount is the vm.max_map_count set on the node
+	// +kubebuilder:validation:Minimum=65530
+	// +kubebuilder:default=262144
+	// +optional
+	MaxMapCount int64 `json:"maxMapCount,omitempty"`
+
+	// Image is the image of the tuning init container
+	// +kubebuilder:default="busybox:1.36"
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// ServiceMetadataSpec defines additional metadata of the Services
+type ServiceMetadataSpec struct {
+	// Labels are added to the Services. Labels set by the operator take precedence.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations are added to the Services
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ServiceMeshSpec defines service mesh integration
+type ServiceMeshSpec struct {
+	// Type is the service mesh the pods are enrolled in
+	// +kubebuilder:validation:Enum=Istio;Linkerd
+	Type string `json:"type"`
+
+	// InjectSidecar requests sidecar injection through pod annotations. Disable it when
+	// injection is already enabled for the namespace.
+	// +kubebuilder:default=true
+	// +optional
+	InjectSidecar *bool `json:"injectSidecar,omitempty"`
+
+	// ExcludeMemberPorts routes the traffic between MongoDB members around the proxy, for
+	// meshes whose mTLS conflicts with MongoDB TLS or keyfile authentication
+	// +optional
+	ExcludeMemberPorts bool `json:"excludeMemberPorts,omitempty"`
 }
 
 // ClusterReference references a MongoDB cluster
@@ -370,3 +756,174 @@ type ClusterReference struct {
 	// +kubebuilder:validation:Enum=MongoDB;MongoDBSharded
 	Kind string `json:"kind"`
 }
+
+// AppliedSettings are settings whose change the operator refuses to roll out without the
+// force-update annotation: a release series downgrade, smaller volumes, a new replica set name or
+// another authentication mechanism
+type AppliedSettings struct {
+	// Version is the MongoDB version
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// ReplicaSetName is the name of the replica set, empty for sharded clusters
+	// +optional
+	ReplicaSetName string `json:"replicaSetName,omitempty"`
+
+	// AuthMechanism is the authentication mechanism
+	// +optional
+	AuthMechanism string `json:"authMechanism,omitempty"`
+
+	// StorageSizes are the data volume sizes by component, without components on ephemeral storage
+	// +optional
+	StorageSizes map[string]resource.Quantity `json:"storageSizes,omitempty"`
+
+	// BlockCompressor is the default collection compressor of the data files, empty without
+	// persistent volumes
+	// +optional
+	BlockCompressor string `json:"blockCompressor,omitempty"`
+
+	// DirectoryPerDB is the directory layout of the data files, unset without persistent volumes
+	// +optional
+	DirectoryPerDB *bool `json:"directoryPerDB,omitempty"`
+}
+
+// MemberVersionStatus is the image and MongoDB version a member runs
+type MemberVersionStatus struct {
+	// Name is the pod name
+	Name string `json:"name"`
+
+	// Image is the image of the database container of the pod
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Version is the version mongod reports in buildInfo
+	// +optional
+	Version string `json:"version,omitempty"`
+}
+
+// DriftStatus is an out-of-band change to a resource owned by the operator, which the operator
+// reverted
+type DriftStatus struct {
+	// Resource is the kind and name of the changed resource, e.g. StatefulSet/my-cluster
+	Resource string `json:"resource"`
+
+	// DetectedAt is when the operator found and reverted the change
+	DetectedAt metav1.Time `json:"detectedAt"`
+}
+
+// KeyfileRotationStatus tracks an on-demand keyfile rotation
+type KeyfileRotationStatus struct {
+	// Requested is the value of the rotate-keyfile annotation being handled
+	Requested string `json:"requested,omitempty"`
+
+	// Phase is the rotation phase
+	// +kubebuilder:validation:Enum=Distributing;Finalizing;Completed
+	Phase string `json:"phase,omitempty"`
+
+	// LastRotationTime is when the last rotation completed
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+}
+
+// ProfilingSpec configures the database profiler of the members
+type ProfilingSpec struct {
+	// Mode is off, slowOp to profile the operations slower than SlowOpThresholdMs, or all
+	// +kubebuilder:validation:Enum=off;slowOp;all
+	// +kubebuilder:default=slowOp
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// SlowOpThresholdMs is the duration above which operations are slow. Slow operations are
+	// logged whatever the mode.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=100
+	// +optional
+	SlowOpThresholdMs *int32 `json:"slowOpThresholdMs,omitempty"`
+
+	// SampleRate is the fraction of slow operations profiled and logged, between 0 and 1
+	// +kubebuilder:validation:Pattern=`^(0(\.[0-9]+)?|1(\.0+)?)$`
+	// +optional
+	SampleRate string `json:"sampleRate,omitempty"`
+}
+
+// LoggingSpec configures the log verbosity of the members
+type LoggingSpec struct {
+	// Verbosity is the default log verbosity, from 0 to 5
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=5
+	// +optional
+	Verbosity int32 `json:"verbosity,omitempty"`
+
+	// Components sets the verbosity of log components, from 0 to 5, keyed by their dotted
+	// name such as query or replication.election. Other components use Verbosity.
+	// +optional
+	Components map[string]int32 `json:"components,omitempty"`
+}
+
+// TelemetrySpec controls the usage data MongoDB sends to MongoDB, Inc.
+type TelemetrySpec struct {
+	// Enabled leaves free monitoring and the mongosh telemetry at their defaults. When false,
+	// free monitoring is turned off on every mongod and mongosh telemetry in every container.
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+}
+
+// StorageEngineSpec configures the WiredTiger storage engine of the data-bearing members. The
+// block compressor and the directory layout are set when the data files are created, so they can
+// only be changed on new volumes.
+type StorageEngineSpec struct {
+	// BlockCompressor is the default compressor of the collections created by the members.
+	// Collections keep the compressor they were created with.
+	// +kubebuilder:validation:Enum=snappy;zlib;zstd
+	// +optional
+	BlockCompressor string `json:"blockCompressor,omitempty"`
+
+	// DirectoryPerDB stores each database in its own directory
+	// +optional
+	DirectoryPerDB bool `json:"directoryPerDB,omitempty"`
+
+	// Journal configures the write-ahead journal
+	// +optional
+	Journal *JournalSpec `json:"journal,omitempty"`
+}
+
+// JournalSpec configures the write-ahead journal of the members
+type JournalSpec struct {
+	// CommitIntervalMs is the maximum time between journal flushes
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=500
+	// +optional
+	CommitIntervalMs *int32 `json:"commitIntervalMs,omitempty"`
+
+	// Compressor compresses the journal records
+	// +kubebuilder:validation:Enum=none;snappy;zlib;zstd
+	// +optional
+	Compressor string `json:"compressor,omitempty"`
+}
+
+// InitScript is a JavaScript or JSON file the operator runs once, after the admin user is created
+type InitScript struct {
+	// Name identifies the script, recorded in status once it ran. Scripts run in the order listed,
+	// a script is never run again, even if its content changes.
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`
+	Name string `json:"name"`
+
+	// ConfigMapRef references the key of a ConfigMap holding the script
+	// +optional
+	ConfigMapRef *corev1.ConfigMapKeySelector `json:"configMapRef,omitempty"`
+
+	// SecretRef references the key of a Secret holding the script, for scripts holding
+	// credentials such as application users
+	// +optional
+	SecretRef *corev1.SecretKeySelector `json:"secretRef,omitempty"`
+
+	// Database is the database db points to in a JavaScript file, admin by default, and the
+	// database the documents of a JSON file are inserted into
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// Collection is the collection the documents of a JSON file, a key ending in .json, are
+	// inserted into. The file holds one document or an array of them, in extended JSON.
+	// +optional
+	Collection string `json:"collection,omitempty"`
+}
@@ -28,9 +28,29 @@ type MongoDBSpec struct {
 	// +kubebuilder:default=3
 	Members int32 `json:"members"`
 
+	// Mode deploys a replica set, or a single Standalone mongod without replication, keyfile or
+	// replica set initialization for development and tests. Standalone requires one member.
+	// +kubebuilder:validation:Enum=ReplicaSet;Standalone
+	// +kubebuilder:default=ReplicaSet
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
 	// Version defines MongoDB version configuration
 	Version MongoDBVersion `json:"version"`
 
+	// Architecture restricts the pods to nodes of one CPU architecture. By default they run on
+	// amd64 and arm64 nodes, the architectures the official mongo images are built for.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
+	// Port is the port the replica set members listen on
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default=27017
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
 	// Storage defines storage configuration
 	// +optional
 	Storage StorageSpec `json:"storage,omitempty"`
@@ -44,7 +64,8 @@ type MongoDBSpec struct {
 	TLS *TLSSpec `json:"tls,omitempty"`
 
 	// Auth defines authentication configuration
-	Auth AuthSpec `json:"auth"`
+	// +optional
+	Auth AuthSpec `json:"auth,omitempty"`
 
 	// Monitoring defines monitoring configuration
 	// +optional
@@ -66,13 +87,92 @@ type MongoDBSpec struct {
 	// +optional
 	Arbiter *ArbiterSpec `json:"arbiter,omitempty"`
 
+	// MemberOverrides sets the election priority, votes, visibility, replication delay and tags
+	// of individual members, applied through rs.reconfig. Members without an override use the
+	// replica set defaults.
+	// +optional
+	MemberOverrides []MemberOverride `json:"memberOverrides,omitempty"`
+
+	// ReplicaSetSettings are merged into the settings of the replica set configuration through
+	// rs.reconfig. Unset fields keep their current value.
+	// +optional
+	ReplicaSetSettings *ReplicaSetSettings `json:"replicaSetSettings,omitempty"`
+
+	// AutoResync wipes the data of members stuck in RECOVERING or ROLLBACK, or crash looping on a
+	// broken dbPath, so they run an initial sync from the other members
+	// +optional
+	AutoResync *AutoResyncSpec `json:"autoResync,omitempty"`
+
+	// ExternalAccess exposes each replica set member through its own Service
+	// +optional
+	ExternalAccess *ExternalAccessSpec `json:"externalAccess,omitempty"`
+
+	// Service defines additional metadata of the headless, client and external Services
+	// +optional
+	Service *ServiceMetadataSpec `json:"service,omitempty"`
+
+	// ServiceMesh enrolls the pods in an Istio or Linkerd service mesh
+	// +optional
+	ServiceMesh *ServiceMeshSpec `json:"serviceMesh,omitempty"`
+
+	// MultiCluster spreads the replica set members over several Kubernetes clusters, each running
+	// this resource with the same spec but its own clusterName
+	// +optional
+	MultiCluster *MultiClusterSpec `json:"multiCluster,omitempty"`
+
+	// ReplicaOf runs the members as read-only members of a remote replica set, a warm standby of
+	// another environment. ReplicaSetName must be the name of the remote replica set.
+	// +optional
+	ReplicaOf *ReplicaOfSpec `json:"replicaOf,omitempty"`
+
+	// UpdateStrategy controls how changes of the pod template are rolled out to the members
+	// +optional
+	UpdateStrategy *UpdateStrategySpec `json:"updateStrategy,omitempty"`
+
 	// ReplicaSetName is the name of the replica set
 	// +kubebuilder:default="rs0"
 	ReplicaSetName string `json:"replicaSetName,omitempty"`
 
-	// AdditionalConfig allows passing additional MongoDB configuration
+	// ClusterDomain is the DNS domain of the Kubernetes cluster used in member host names and
+	// connection strings. Defaults to the operator --cluster-domain flag (cluster.local).
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +optional
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+
+	// AdditionalConfig holds mongod.conf settings keyed by their dotted path, e.g.
+	// operationProfiling.slowOpThresholdMs. Values are parsed as YAML. Settings managed by
+	// the operator, such as net.port or replication.replSetName, can not be overridden.
 	// +optional
 	AdditionalConfig map[string]string `json:"additionalConfig,omitempty"`
+
+	// ResourceProfile sizes the members and exporter when their resources are not set: small, medium
+	// and large expand to requests, limits and a WiredTiger cache size that fit together, custom
+	// requires memory limits on the data-bearing members and derives the cache size from them
+	// +kubebuilder:validation:Enum=small;medium;large;custom
+	// +optional
+	ResourceProfile string `json:"resourceProfile,omitempty"`
+
+	// StorageEngine configures the compression, directory layout and journal of the members
+	// +optional
+	StorageEngine *StorageEngineSpec `json:"storageEngine,omitempty"`
+
+	// Profiling configures the database profiler of the members, applied without a restart
+	// +optional
+	Profiling *ProfilingSpec `json:"profiling,omitempty"`
+
+	// Logging configures the log verbosity of the members, applied without a restart
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+
+	// Telemetry turns off free monitoring and mongosh telemetry on the members, for
+	// airgapped and compliance-sensitive deployments
+	// +optional
+	Telemetry *TelemetrySpec `json:"telemetry,omitempty"`
+
+	// InitScripts are run once, in order, after the admin user is created, to load fixture data,
+	// application roles or collections before the cluster is reported Ready
+	// +optional
+	InitScripts []InitScript `json:"initScripts,omitempty"`
 }
 
 // ArbiterSpec defines arbiter configuration
@@ -85,6 +185,241 @@ type ArbiterSpec struct {
 	Resources ResourcesSpec `json:"resources,omitempty"`
 }
 
+// MemberOverride overrides the replica set configuration of one member
+type MemberOverride struct {
+	// Member is the ordinal of the pod the override applies to, <name>-<member>
+	// +kubebuilder:validation:Minimum=0
+	Member int32 `json:"member"`
+
+	// Priority is the election priority, 0 for a member that never becomes primary.
+	// Defaults to 0 for hidden and delayed members and to 1 otherwise.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+
+	// Votes is 1 for a voting member or 0 for a non-voting member, which requires priority 0
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	Votes *int32 `json:"votes,omitempty"`
+
+	// Hidden keeps the member out of the hosts advertised to clients, e.g. for reporting or backups
+	// +optional
+	Hidden bool `json:"hidden,omitempty"`
+
+	// SecondaryDelaySecs makes the member apply operations this many seconds behind the primary
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SecondaryDelaySecs int32 `json:"secondaryDelaySecs,omitempty"`
+
+	// Tags are the member tags used by read preferences and custom write concerns
+	// +optional
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// ReplicaSetSettings tunes elections and replication of the replica set
+type ReplicaSetSettings struct {
+	// ElectionTimeoutMillis is how long a secondary waits for the primary before calling an election
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ElectionTimeoutMillis *int32 `json:"electionTimeoutMillis,omitempty"`
+
+	// HeartbeatTimeoutSecs is how long members wait for a heartbeat before marking a member unreachable
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	HeartbeatTimeoutSecs *int32 `json:"heartbeatTimeoutSecs,omitempty"`
+
+	// CatchUpTimeoutMillis is how long a newly elected primary catches up with the other members,
+	// -1 to wait until it is caught up
+	// +kubebuilder:validation:Minimum=-1
+	// +optional
+	CatchUpTimeoutMillis *int32 `json:"catchUpTimeoutMillis,omitempty"`
+
+	// ChainingAllowed lets secondaries replicate from other secondaries
+	// +optional
+	ChainingAllowed *bool `json:"chainingAllowed,omitempty"`
+}
+
+// AutoResyncSpec configures the resync of stale members
+type AutoResyncSpec struct {
+	// Enabled deletes the data volume of a member stuck for StaleAfterSeconds. Stale members are
+	// reported in status whether or not this is enabled.
+	Enabled bool `json:"enabled"`
+
+	// StaleAfterSeconds is how long a member must be stuck before its data is wiped
+	// +kubebuilder:validation:Minimum=60
+	// +kubebuilder:default=900
+	// +optional
+	StaleAfterSeconds int32 `json:"staleAfterSeconds,omitempty"`
+}
+
+// StaleMemberStatus is a member stuck in a state it cannot leave by itself
+type StaleMemberStatus struct {
+	// Name is the pod name
+	Name string `json:"name"`
+
+	// Reason is the replica set state of the member, or CrashLoopBackOff
+	Reason string `json:"reason"`
+
+	// Since is when the member was first seen stuck
+	Since metav1.Time `json:"since"`
+
+	// ResyncStartedAt is when the operator wiped the data of the member to resync it
+	// +optional
+	ResyncStartedAt *metav1.Time `json:"resyncStartedAt,omitempty"`
+}
+
+// ForceReconfigStatus tracks a forced reconfiguration keeping only the surviving members
+type ForceReconfigStatus struct {
+	// Requested is the value of the force-reconfig annotation being handled
+	Requested string `json:"requested,omitempty"`
+
+	// Phase is the outcome of the request
+	// +kubebuilder:validation:Enum=Completed;Rejected
+	Phase string `json:"phase,omitempty"`
+
+	// Message explains a rejected request
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// RemovedMembers are the pods dropped from the configuration, added back once they are ready again
+	// +optional
+	RemovedMembers []string `json:"removedMembers,omitempty"`
+
+	// LastTransitionTime is when the request was handled
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ExternalAccessSpec defines per-member external access configuration
+type ExternalAccessSpec struct {
+	// Enabled creates one Service per replica set member
+	Enabled bool `json:"enabled"`
+
+	// Type is the type of the per-member Services
+	// +kubebuilder:validation:Enum=NodePort;LoadBalancer
+	// +kubebuilder:default=LoadBalancer
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Annotations are added to every per-member Service
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Hostnames are the external host names of the members, indexed by pod ordinal.
+	// Members without an entry use the address assigned to their Service.
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+
+	// HorizonName is the name of the replica set horizon advertising the external hosts.
+	// Clients connecting through an external host name over TLS receive the external member hosts.
+	// +kubebuilder:default=external
+	// +optional
+	HorizonName string `json:"horizonName,omitempty"`
+}
+
+// MultiClusterSpec defines a replica set spanning Kubernetes clusters. The members are numbered
+// across the clusters in their order: the pods of a cluster follow those of the clusters before it,
+// so every member has a unique pod name. Members reach each other at <pod>.<domain> of their cluster,
+// with the keyfile, admin credentials and TLS certificates shared by all clusters.
+type MultiClusterSpec struct {
+	// ClusterName is the name of the Kubernetes cluster this resource is deployed to, one of clusters
+	// +kubebuilder:validation:MinLength=1
+	ClusterName string `json:"clusterName"`
+
+	// Clusters are the Kubernetes clusters running members, in the same order in every cluster.
+	// The first one initiates the replica set, spec.members must be the sum of their members.
+	// +kubebuilder:validation:MinItems=2
+	Clusters []MemberClusterSpec `json:"clusters"`
+}
+
+// MemberClusterSpec defines the members of the replica set running in one Kubernetes cluster
+type MemberClusterSpec struct {
+	// Name identifies the Kubernetes cluster
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Members is the number of replica set members running in the cluster
+	// +kubebuilder:validation:Minimum=1
+	Members int32 `json:"members"`
+
+	// Domain is the externally resolvable DNS domain of the members of the cluster, which are
+	// reached at <pod>.<domain>:<port> from every cluster, e.g. through per-member LoadBalancer
+	// Services of externalAccess or a multi-cluster service discovery
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Domain string `json:"domain"`
+}
+
+// ReplicaOfSpec defines the remote replica set a disaster recovery cluster replicates. The members
+// join it with priority 0 and no vote, so they never become primary and do not count towards the
+// write majority. The remote replica set must share the keyfile and admin credentials.
+type ReplicaOfSpec struct {
+	// Hosts are host:port of members of the remote replica set reachable from this cluster, used
+	// to find its primary
+	// +kubebuilder:validation:MinItems=1
+	Hosts []string `json:"hosts"`
+
+	// Domain is the externally resolvable DNS domain of the members of this cluster, which the
+	// remote replica set reaches at <pod>.<domain>:<port>
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Domain string `json:"domain"`
+}
+
+// UpdateStrategySpec defines how changes of the pod template are rolled out to the members
+type UpdateStrategySpec struct {
+	// Type is RollingUpdate, replacing the members one after the other from the highest ordinal, or
+	// Canary, replacing the highest ordinal member first and the others once it soaked or the
+	// update was approved
+	// +kubebuilder:validation:Enum=RollingUpdate;Canary
+	// +kubebuilder:default=RollingUpdate
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// MaxUnavailable is the number of members replaced at the same time, it must leave a majority
+	// of the voting members up. Kubernetes replaces one member at a time without the
+	// MaxUnavailableStatefulSet feature gate.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+
+	// Canary configures the Canary update
+	// +optional
+	Canary *CanaryUpdateSpec `json:"canary,omitempty"`
+}
+
+// CanaryUpdateSpec defines when the members after the canary are updated
+type CanaryUpdateSpec struct {
+	// SoakSeconds is how long the canary has to stay ready on the new revision before the other
+	// members are updated, 600 by default
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SoakSeconds *int32 `json:"soakSeconds,omitempty"`
+
+	// ManualApproval holds the update after the canary, whatever the soak period, until the
+	// approve-update annotation is set to the revision in status.rollout
+	// +optional
+	ManualApproval bool `json:"manualApproval,omitempty"`
+}
+
+// RolloutStatus tracks a canary update of the members
+type RolloutStatus struct {
+	// Revision is the StatefulSet revision rolled out
+	Revision string `json:"revision,omitempty"`
+
+	// Phase is the rollout phase
+	// +kubebuilder:validation:Enum=Canary;Soaking;AwaitingApproval;Updating;Completed
+	Phase string `json:"phase,omitempty"`
+
+	// StartedAt is when the rollout started
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+
+	// CanaryReadyAt is when the canary member became ready on the new revision
+	// +optional
+	CanaryReadyAt *metav1.Time `json:"canaryReadyAt,omitempty"`
+}
+
 // MongoDBStatus defines the observed state of MongoDB
 type MongoDBStatus struct {
 	// Phase represents the current phase
@@ -101,6 +436,14 @@ type MongoDBStatus struct {
 	// +optional
 	Members []MemberStatus `json:"members,omitempty"`
 
+	// Storage contains the disk usage of each member
+	// +optional
+	Storage []MemberStorageStatus `json:"storage,omitempty"`
+
+	// StaleMembers are the members stuck in RECOVERING, ROLLBACK or a crash loop, and their resync
+	// +optional
+	StaleMembers []StaleMemberStatus `json:"staleMembers,omitempty"`
+
 	// Conditions represents the latest available observations
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
@@ -108,6 +451,10 @@ type MongoDBStatus struct {
 	// ConnectionString is the MongoDB connection URI
 	ConnectionString string `json:"connectionString,omitempty"`
 
+	// SRVConnectionString is the mongodb+srv:// URI looking up the members behind the headless Service in DNS
+	// +optional
+	SRVConnectionString string `json:"srvConnectionString,omitempty"`
+
 	// TLSSecretName is the name of the TLS secret
 	// +optional
 	TLSSecretName string `json:"tlsSecretName,omitempty"`
@@ -127,6 +474,66 @@ type MongoDBStatus struct {
 
 	// AdminUserCreated indicates if the admin user has been created
 	AdminUserCreated bool `json:"adminUserCreated,omitempty"`
+
+	// InitScripts are the names of the init scripts that ran, they are never run again
+	// +optional
+	InitScripts []string `json:"initScripts,omitempty"`
+
+	// AdminPasswordHash is a salted hash of the admin password applied to the database,
+	// used to detect rotations of the admin credentials secret
+	// +optional
+	AdminPasswordHash string `json:"adminPasswordHash,omitempty"`
+
+	// MonitoringPasswordHash is a salted hash of the exporter user password applied to the database
+	// +optional
+	MonitoringPasswordHash string `json:"monitoringPasswordHash,omitempty"`
+
+	// KeyfileRotation tracks the keyfile rotation requested through the rotate-keyfile annotation
+	// +optional
+	KeyfileRotation *KeyfileRotationStatus `json:"keyfileRotation,omitempty"`
+
+	// ExternalHosts are the externally reachable host:port addresses of the members, indexed by pod ordinal
+	// +optional
+	ExternalHosts []string `json:"externalHosts,omitempty"`
+
+	// HorizonHosts are the external hosts applied as replica set horizons, indexed by pod ordinal
+	// +optional
+	HorizonHosts []string `json:"horizonHosts,omitempty"`
+
+	// MemberSettingsHash is a hash of the member priorities, votes, visibility, delays and tags
+	// applied to the replica set configuration
+	// +optional
+	MemberSettingsHash string `json:"memberSettingsHash,omitempty"`
+
+	// ReplicaSetSettingsHash is a hash of the replica set settings applied to the configuration
+	// +optional
+	ReplicaSetSettingsHash string `json:"replicaSetSettingsHash,omitempty"`
+
+	// DiagnosticsHash is a hash of the profiler and log verbosity settings applied to the members
+	// +optional
+	DiagnosticsHash string `json:"diagnosticsHash,omitempty"`
+
+	// ForceReconfig tracks the forced reconfiguration requested through the force-reconfig annotation
+	// +optional
+	ForceReconfig *ForceReconfigStatus `json:"forceReconfig,omitempty"`
+
+	// AppliedSettings are the settings the cluster was last reconciled with that can not be
+	// changed safely in place
+	// +optional
+	AppliedSettings *AppliedSettings `json:"appliedSettings,omitempty"`
+
+	// Rollout tracks the canary update of the members
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// Versions are the image and MongoDB version of each member, which differ from the spec
+	// during a rollout
+	// +optional
+	Versions []MemberVersionStatus `json:"versions,omitempty"`
+
+	// Drift lists the out-of-band changes to owned resources reverted within the last hour
+	// +optional
+	Drift []DriftStatus `json:"drift,omitempty"`
 }
 
 // MemberStatus represents the status of a replica set member
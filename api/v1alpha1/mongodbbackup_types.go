@@ -28,7 +28,8 @@ type MongoDBBackupSpec struct {
 	// Storage defines backup storage location
 	Storage BackupStorageSpec `json:"storage"`
 
-	// Type is the backup type
+	// Type is the backup type. A full backup dumps the databases, an incremental backup dumps the
+	// oplog written since the previous backup of its replica set chain, see status.parentBackup.
 	// +kubebuilder:validation:Enum=full;incremental
 	// +kubebuilder:default="full"
 	Type string `json:"type,omitempty"`
@@ -41,12 +42,50 @@ type MongoDBBackupSpec struct {
 	// +kubebuilder:validation:Enum=gzip;zstd;snappy
 	// +kubebuilder:default="zstd"
 	CompressionType string `json:"compressionType,omitempty"`
+
+	// TTLSecondsAfterFinished is how long the finished backup Job and its pod are kept.
+	// The MongoDBBackup itself is kept.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=86400
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// BackoffLimit is how often a failing backup Job is retried before the backup fails
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// ActiveDeadlineSeconds bounds how long the backup Job may run, retries included, before
+	// the backup fails. The Job is not bounded when unset.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// ConcurrencyPolicy tells whether the backup may run alongside other backups of the same
+	// cluster. Forbid waits until no other backup Job of the cluster runs, Allow starts at once.
+	// +kubebuilder:validation:Enum=Allow;Forbid
+	// +kubebuilder:default="Forbid"
+	// +optional
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
 }
 
+// Types of backups
+const (
+	BackupTypeFull        = "full"
+	BackupTypeIncremental = "incremental"
+)
+
+// Concurrency policies of backups
+const (
+	BackupConcurrencyAllow  = "Allow"
+	BackupConcurrencyForbid = "Forbid"
+)
+
 // MongoDBBackupStatus defines the observed state of MongoDBBackup
 type MongoDBBackupStatus struct {
 	// Phase represents the current backup phase
-	// +kubebuilder:validation:Enum=Pending;Running;Completed;Failed
+	// +kubebuilder:validation:Enum=Pending;WaitingForCluster;Running;Completed;Failed
 	Phase string `json:"phase,omitempty"`
 
 	// StartTime is when the backup started
@@ -57,7 +96,8 @@ type MongoDBBackupStatus struct {
 	// +optional
 	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 
-	// Size is the backup size
+	// Size is the size of the archive as a quantity, e.g. 512Mi, read from the backup Job once it
+	// completes
 	// +optional
 	Size string `json:"size,omitempty"`
 
@@ -65,6 +105,29 @@ type MongoDBBackupStatus struct {
 	// +optional
 	Location string `json:"location,omitempty"`
 
+	// Archive is the object key below the S3 prefix, or the file name on the volume, of the
+	// archive the backup writes
+	// +optional
+	Archive string `json:"archive,omitempty"`
+
+	// ClusterGeneration is the generation of the cluster spec when the backup Job was created
+	// +optional
+	ClusterGeneration int64 `json:"clusterGeneration,omitempty"`
+
+	// ParentBackup is the backup an incremental backup follows, the full backup or the previous
+	// incremental backup of the chain. Restoring the backup replays the chain from its full backup.
+	// +optional
+	ParentBackup string `json:"parentBackup,omitempty"`
+
+	// OplogStart is the oplog timestamp an incremental backup starts after, the OplogEnd of its parent
+	// +optional
+	OplogStart *OplogTimestamp `json:"oplogStart,omitempty"`
+
+	// OplogEnd is the last oplog timestamp the backup covers. Backups of replica sets record it,
+	// they are the parents incremental backups chain to.
+	// +optional
+	OplogEnd *OplogTimestamp `json:"oplogEnd,omitempty"`
+
 	// Error contains error message if failed
 	// +optional
 	Error string `json:"error,omitempty"`
@@ -74,12 +137,22 @@ type MongoDBBackupStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// OplogTimestamp is the ts of an oplog entry
+type OplogTimestamp struct {
+	// T is the seconds since the epoch
+	T int64 `json:"t"`
+
+	// I is the ordinal of the operation within the second
+	I int64 `json:"i"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=mdbbackup
 // +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.name"
 // +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Parent",type="string",JSONPath=".status.parentBackup"
 // +kubebuilder:printcolumn:name="Size",type="string",JSONPath=".status.size"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
@@ -0,0 +1,330 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MongoDBCollectionSpec defines the desired state of MongoDBCollection
+type MongoDBCollectionSpec struct {
+	// ClusterRef references the MongoDB or MongoDBSharded cluster holding the collection
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Database is the database name
+	// +kubebuilder:validation:MinLength=1
+	Database string `json:"database"`
+
+	// Name is the collection name
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// TimeSeries creates the collection as a time-series collection. The options only apply when
+	// the operator creates the collection, they cannot be added to an existing one.
+	// +optional
+	TimeSeries *TimeSeriesSpec `json:"timeSeries,omitempty"`
+
+	// Capped creates the collection as a capped collection of a fixed size. The options only apply
+	// when the operator creates the collection. Capped collections cannot be sharded.
+	// +optional
+	Capped *CappedSpec `json:"capped,omitempty"`
+
+	// ShardKey defines the shard key fields in order. Only supported on MongoDBSharded clusters.
+	// The shard key cannot be changed once the collection is sharded.
+	// +optional
+	ShardKey []ShardKeyField `json:"shardKey,omitempty"`
+
+	// Unique enforces a uniqueness constraint on the shard key
+	// +optional
+	Unique bool `json:"unique,omitempty"`
+
+	// Presplit creates the initial chunks of the collection when it is sharded, so an initial load
+	// is spread over the shards instead of filling a single chunk
+	// +optional
+	Presplit *PresplitSpec `json:"presplit,omitempty"`
+
+	// Zones defines zone ranges of the collection for zone sharding
+	// +optional
+	Zones []ZoneRange `json:"zones,omitempty"`
+
+	// Indexes defines the indexes managed by the operator.
+	// Indexes removed from this list are dropped, other indexes are left untouched.
+	// +optional
+	Indexes []IndexSpec `json:"indexes,omitempty"`
+
+	// IndexBuildCommitQuorum is the number of data-bearing voting members (or "majority",
+	// "votingMembers") that must finish an index build before it is committed
+	// +kubebuilder:default="votingMembers"
+	// +optional
+	IndexBuildCommitQuorum string `json:"indexBuildCommitQuorum,omitempty"`
+
+	// IndexBuild selects how missing indexes are built
+	// +optional
+	IndexBuild *IndexBuildSpec `json:"indexBuild,omitempty"`
+}
+
+// IndexBuildSpec defines how missing indexes are built
+type IndexBuildSpec struct {
+	// Strategy is Simultaneous to build on every member at once, Rolling to build on one member
+	// at a time while it runs out of the replica set, secondaries first, or Auto to roll the
+	// builds of collections of at least RollingThreshold. Rolling builds are only supported on
+	// MongoDB replica sets of at least 3 members.
+	// +kubebuilder:validation:Enum=Simultaneous;Rolling;Auto
+	// +kubebuilder:default="Simultaneous"
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+
+	// RollingThreshold is the collection size from which the Auto strategy rolls index builds
+	// +optional
+	RollingThreshold *resource.Quantity `json:"rollingThreshold,omitempty"`
+}
+
+// TimeSeriesSpec defines the options of a time-series collection
+type TimeSeriesSpec struct {
+	// TimeField is the field holding the date of each measurement
+	// +kubebuilder:validation:MinLength=1
+	TimeField string `json:"timeField"`
+
+	// MetaField is the field holding the metadata identifying a series
+	// +optional
+	MetaField string `json:"metaField,omitempty"`
+
+	// Granularity is the expected interval between measurements of a series
+	// +kubebuilder:validation:Enum=seconds;minutes;hours
+	// +optional
+	Granularity string `json:"granularity,omitempty"`
+
+	// ExpireAfterSeconds deletes the measurements older than this age
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ExpireAfterSeconds *int64 `json:"expireAfterSeconds,omitempty"`
+}
+
+// CappedSpec defines the options of a capped collection
+type CappedSpec struct {
+	// Size is the maximum size of the collection in bytes, the oldest documents are removed beyond it
+	// +kubebuilder:validation:Minimum=1
+	Size int64 `json:"size"`
+
+	// Max is the maximum number of documents of the collection
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Max *int64 `json:"max,omitempty"`
+}
+
+// IndexSpec defines an index of a collection
+type IndexSpec struct {
+	// Name is the index name
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Keys defines the index key fields in order
+	// +kubebuilder:validation:MinItems=1
+	Keys []IndexKeyField `json:"keys"`
+
+	// Unique rejects documents with duplicate key values
+	// +optional
+	Unique bool `json:"unique,omitempty"`
+
+	// Sparse only indexes documents containing the indexed fields
+	// +optional
+	Sparse bool `json:"sparse,omitempty"`
+
+	// ExpireAfterSeconds turns the index into a TTL index
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ExpireAfterSeconds *int32 `json:"expireAfterSeconds,omitempty"`
+
+	// PartialFilterExpression only indexes documents matching the filter, as an Extended JSON document
+	// +optional
+	PartialFilterExpression string `json:"partialFilterExpression,omitempty"`
+}
+
+// IndexKeyField defines a single index key field
+type IndexKeyField struct {
+	// Field is the document field name
+	Field string `json:"field"`
+
+	// Type is the index type of the field
+	// +kubebuilder:validation:Enum=ascending;descending;hashed;text;"2dsphere"
+	// +kubebuilder:default="ascending"
+	Type string `json:"type,omitempty"`
+}
+
+// IndexStatus reports the state of a managed index
+type IndexStatus struct {
+	// Name is the index name
+	Name string `json:"name"`
+
+	// Phase is the index phase
+	// +kubebuilder:validation:Enum=Building;Ready
+	Phase string `json:"phase"`
+
+	// Progress reports the build progress, e.g. "1200/5000 (24%)"
+	// +optional
+	Progress string `json:"progress,omitempty"`
+}
+
+// RollingIndexBuildStatus reports a rolling index build. The members build the indexes one at a
+// time, each restarted as a standalone mongod for the duration of its build.
+type RollingIndexBuildStatus struct {
+	// Indexes are the indexes being built
+	Indexes []string `json:"indexes"`
+
+	// Member is the pod building the indexes
+	// +optional
+	Member string `json:"member,omitempty"`
+
+	// Phase is the step of the member: Restarting as a standalone, Building the indexes or
+	// Rejoining the replica set. A Failed build is not retried until the spec changes.
+	// +kubebuilder:validation:Enum=Restarting;Building;Rejoining;Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+
+	// CompletedMembers are the members that built the indexes
+	// +optional
+	CompletedMembers []string `json:"completedMembers,omitempty"`
+
+	// Message reports why the build failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ObservedGeneration is the generation of the MongoDBCollection the build started for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// ShardKeyField defines a single shard key field
+type ShardKeyField struct {
+	// Field is the document field name
+	Field string `json:"field"`
+
+	// Type is the index type of the field
+	// +kubebuilder:validation:Enum=ascending;hashed
+	// +kubebuilder:default="ascending"
+	Type string `json:"type,omitempty"`
+}
+
+// PresplitSpec defines the initial chunks of a collection
+type PresplitSpec struct {
+	// NumInitialChunks is the number of chunks created for a hashed shard key on an empty collection
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	NumInitialChunks *int32 `json:"numInitialChunks,omitempty"`
+
+	// HashedZones creates the chunks of a compound hashed shard key along the zone ranges and
+	// spreads them over the shards of each zone. The zones are configured before the collection
+	// is sharded.
+	// +optional
+	HashedZones bool `json:"hashedZones,omitempty"`
+
+	// SplitPoints are shard key values, as Extended JSON documents listing the shard key fields in
+	// order, the chunks are split at right after the collection is sharded,
+	// e.g. {"region": "EU", "userId": {"$minKey": 1}}
+	// +optional
+	SplitPoints []string `json:"splitPoints,omitempty"`
+}
+
+// ZoneRange assigns a shard key range to a zone
+type ZoneRange struct {
+	// Zone is the zone name
+	Zone string `json:"zone"`
+
+	// Shards lists the shards assigned to the zone (e.g. my-sharded-shard-0)
+	// +optional
+	Shards []string `json:"shards,omitempty"`
+
+	// Min is the inclusive lower bound of the range as an Extended JSON document,
+	// e.g. {"region": "EU", "userId": {"$minKey": 1}}
+	Min string `json:"min"`
+
+	// Max is the exclusive upper bound of the range as an Extended JSON document
+	Max string `json:"max"`
+}
+
+// ChunkDistribution reports the number of chunks held by a shard
+type ChunkDistribution struct {
+	// Shard is the shard name
+	Shard string `json:"shard"`
+
+	// Chunks is the number of chunks on the shard
+	Chunks int32 `json:"chunks"`
+}
+
+// MongoDBCollectionStatus defines the observed state of MongoDBCollection
+type MongoDBCollectionStatus struct {
+	// Phase represents the current phase
+	// +kubebuilder:validation:Enum=Pending;Building;Ready;Failed
+	Phase string `json:"phase,omitempty"`
+
+	// Sharded indicates if the collection has been sharded
+	Sharded bool `json:"sharded,omitempty"`
+
+	// ChunkDistribution lists the chunks held by each shard
+	// +optional
+	ChunkDistribution []ChunkDistribution `json:"chunkDistribution,omitempty"`
+
+	// AppliedZones lists the zone ranges configured on the cluster
+	// +optional
+	AppliedZones []ZoneRange `json:"appliedZones,omitempty"`
+
+	// Indexes reports the state of the managed indexes
+	// +optional
+	Indexes []IndexStatus `json:"indexes,omitempty"`
+
+	// RollingIndexBuild reports the rolling index build in progress
+	// +optional
+	RollingIndexBuild *RollingIndexBuildStatus `json:"rollingIndexBuild,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Conditions represents the latest available observations
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mdbcoll
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.name"
+// +kubebuilder:printcolumn:name="Database",type="string",JSONPath=".spec.database"
+// +kubebuilder:printcolumn:name="Collection",type="string",JSONPath=".spec.name"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// MongoDBCollection is the Schema for the mongodbcollections API
+type MongoDBCollection struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBCollectionSpec   `json:"spec,omitempty"`
+	Status MongoDBCollectionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBCollectionList contains a list of MongoDBCollection
+type MongoDBCollectionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBCollection `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBCollection{}, &MongoDBCollectionList{})
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Migration modes
+const (
+	// MigrationModeSnapshot copies the data of the source once
+	MigrationModeSnapshot = "Snapshot"
+
+	// MigrationModeContinuous copies the data of the source, then replays its oplog until the cutover
+	MigrationModeContinuous = "Continuous"
+)
+
+// MongoDBMigrationSpec defines a migration of the data of an external MongoDB deployment into a
+// cluster managed by the operator
+type MongoDBMigrationSpec struct {
+	// Source is the external MongoDB deployment the data is copied from
+	Source MigrationSourceSpec `json:"source"`
+
+	// ClusterRef references the MongoDB or MongoDBSharded cluster the data is copied to
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Mode is how the data is copied:
+	// Snapshot dumps the source and restores it into the cluster once,
+	// Continuous then replays the oplog of the source, a replica set, until the cutover
+	// +kubebuilder:validation:Enum=Snapshot;Continuous
+	// +kubebuilder:default=Continuous
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// CatchUpIntervalSeconds is the time between two oplog catch-up rounds
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=30
+	// +optional
+	CatchUpIntervalSeconds int32 `json:"catchUpIntervalSeconds,omitempty"`
+
+	// Cutover ends a Continuous migration. Set it once the applications stopped writing to the
+	// source: catch-up rounds run back to back until one has no operation left to apply, then the
+	// migration completes and the applications can switch to the cluster.
+	// +optional
+	Cutover bool `json:"cutover,omitempty"`
+}
+
+// MigrationSourceSpec defines the external MongoDB deployment of a migration
+type MigrationSourceSpec struct {
+	// URISecretRef references the key of a secret holding the connection string of the source,
+	// with the credentials of a user allowed to read every database and, for a Continuous
+	// migration, the oplog
+	URISecretRef corev1.SecretKeySelector `json:"uriSecretRef"`
+}
+
+// MongoDBMigrationStatus defines the observed state of MongoDBMigration
+type MongoDBMigrationStatus struct {
+	// Phase represents the current phase
+	// +kubebuilder:validation:Enum=Pending;InitialSync;CatchingUp;CuttingOver;Completed;Failed
+	Phase string `json:"phase,omitempty"`
+
+	// Message describes the current phase or why the migration failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// AppliedOpTime is the last operation of the source oplog applied to the cluster
+	// +optional
+	AppliedOpTime *OplogTimestamp `json:"appliedOpTime,omitempty"`
+
+	// LagSeconds is how far the cluster was behind the source at the end of the last catch-up round
+	// +optional
+	LagSeconds int64 `json:"lagSeconds,omitempty"`
+
+	// CatchUpRounds is the number of oplog catch-up rounds run
+	// +optional
+	CatchUpRounds int32 `json:"catchUpRounds,omitempty"`
+
+	// LastRoundOperations is the number of operations the last catch-up round applied
+	// +optional
+	LastRoundOperations int64 `json:"lastRoundOperations,omitempty"`
+
+	// LastRoundTime is when the last catch-up round completed
+	// +optional
+	LastRoundTime *metav1.Time `json:"lastRoundTime,omitempty"`
+
+	// StartTime is when the migration started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// InitialSyncTime is when the copy of the data of the source completed
+	// +optional
+	InitialSyncTime *metav1.Time `json:"initialSyncTime,omitempty"`
+
+	// CompletionTime is when the migration completed or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mdbmig
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.name"
+// +kubebuilder:printcolumn:name="Mode",type="string",JSONPath=".spec.mode"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Lag",type="integer",JSONPath=".status.lagSeconds"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// MongoDBMigration is the Schema for the mongodbmigrations API
+type MongoDBMigration struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBMigrationSpec   `json:"spec,omitempty"`
+	Status MongoDBMigrationStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBMigrationList contains a list of MongoDBMigration
+type MongoDBMigrationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBMigration `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBMigration{}, &MongoDBMigrationList{})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MongoDBOperatorConfigSpec defines the operator-wide defaults of the custom resources
+type MongoDBOperatorConfigSpec struct {
+	// Images defines the default images of the clusters and backups
+	// +optional
+	Images OperatorImagesSpec `json:"images,omitempty"`
+
+	// StorageClassName is the StorageClass of the data volumes of clusters without
+	// spec.storage.storageClassName. It takes precedence over the --default-storage-class flag.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Resources defines the resource requirements of the containers whose custom resource
+	// sets neither requests nor limits
+	// +optional
+	Resources OperatorResourcesSpec `json:"resources,omitempty"`
+
+	// RequeueIntervalSeconds is how often clusters and collections are checked again once they
+	// are up to date
+	// +kubebuilder:validation:Minimum=5
+	// +kubebuilder:default=30
+	// +optional
+	RequeueIntervalSeconds int32 `json:"requeueIntervalSeconds,omitempty"`
+
+	// RetryIntervalSeconds is how soon clusters, collections and backups waiting on pods or on
+	// another resource are checked again
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=10
+	// +optional
+	RetryIntervalSeconds int32 `json:"retryIntervalSeconds,omitempty"`
+
+	// FeatureGates turns operator features on or off by name, e.g. ShardHostRepair
+	// +optional
+	FeatureGates map[string]bool `json:"featureGates,omitempty"`
+}
+
+// OperatorImagesSpec defines the default images
+type OperatorImagesSpec struct {
+	// MongoDB is the image repository of mongod and mongos, tagged with spec.version.version.
+	// spec.version.image of a cluster takes precedence.
+	// +kubebuilder:default="mongo"
+	// +optional
+	MongoDB string `json:"mongodb,omitempty"`
+
+	// Exporter is the image of the mongodb_exporter sidecar.
+	// spec.monitoring.exporter.image of a cluster takes precedence.
+	// +optional
+	Exporter string `json:"exporter,omitempty"`
+
+	// Backup is the image of the backup Jobs, it needs mongodump and the AWS CLI for S3 storage
+	// +optional
+	Backup string `json:"backup,omitempty"`
+}
+
+// OperatorResourcesSpec defines the default resource requirements per container
+type OperatorResourcesSpec struct {
+	// Mongod applies to the members of replica sets, config servers and shards
+	// +optional
+	Mongod ResourcesSpec `json:"mongod,omitempty"`
+
+	// Mongos applies to the mongos routers
+	// +optional
+	Mongos ResourcesSpec `json:"mongos,omitempty"`
+
+	// Exporter applies to the mongodb_exporter sidecars
+	// +optional
+	Exporter ResourcesSpec `json:"exporter,omitempty"`
+
+	// Backup applies to the backup Jobs
+	// +optional
+	Backup ResourcesSpec `json:"backup,omitempty"`
+}
+
+// MongoDBOperatorConfigStatus defines the observed state of MongoDBOperatorConfig
+type MongoDBOperatorConfigStatus struct {
+	// Conditions represents the latest available observations
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=mdboc
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the operator only reads the MongoDBOperatorConfig named default"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type=='Ready')].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// MongoDBOperatorConfig is the Schema for the mongodboperatorconfigs API
+type MongoDBOperatorConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBOperatorConfigSpec   `json:"spec,omitempty"`
+	Status MongoDBOperatorConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBOperatorConfigList contains a list of MongoDBOperatorConfig
+type MongoDBOperatorConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBOperatorConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBOperatorConfig{}, &MongoDBOperatorConfigList{})
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MongoDBOpsRequestSpec defines a one-off maintenance operation on a cluster
+type MongoDBOpsRequestSpec struct {
+	// ClusterRef references the MongoDB or MongoDBSharded cluster to operate on
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Type is the operation to run:
+	// StepDown steps the primary of a replica set down,
+	// ResyncMember wipes the data of a member so it runs an initial sync,
+	// Compact compacts a collection on a member,
+	// RotateKeyfile rotates the internal authentication keyfile,
+	// FlushRouterConfig refreshes the routing table cache of every mongos (MongoDBSharded only),
+	// Restart rolls the pods of the cluster or of a component
+	// +kubebuilder:validation:Enum=StepDown;ResyncMember;Compact;RotateKeyfile;FlushRouterConfig;Restart
+	Type string `json:"type"`
+
+	// Member is the pod the operation targets. Required for ResyncMember and Compact; for StepDown
+	// it selects the replica set, by default the cluster replica set or the config server replica set.
+	// +optional
+	Member string `json:"member,omitempty"`
+
+	// Database is the database of the collection to compact
+	// +optional
+	Database string `json:"database,omitempty"`
+
+	// Collection is the collection to compact
+	// +optional
+	Collection string `json:"collection,omitempty"`
+
+	// Component is the component of a MongoDBSharded cluster to restart, all of them by default
+	// +kubebuilder:validation:Enum=ConfigServer;Shards;Mongos
+	// +optional
+	Component string `json:"component,omitempty"`
+}
+
+// OpsRequestStep reports the progress of one step of an operation
+type OpsRequestStep struct {
+	// Name is the step name
+	Name string `json:"name"`
+
+	// Phase is the step phase
+	// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed
+	Phase string `json:"phase"`
+
+	// Message describes what the step did or why it failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// StartTime is when the step started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the step succeeded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// MongoDBOpsRequestStatus defines the observed state of MongoDBOpsRequest
+type MongoDBOpsRequestStatus struct {
+	// Phase represents the current phase
+	// +kubebuilder:validation:Enum=Pending;Running;Succeeded;Failed
+	Phase string `json:"phase,omitempty"`
+
+	// Message describes why the operation failed
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Steps reports the progress of each step of the operation, run in order
+	// +optional
+	Steps []OpsRequestStep `json:"steps,omitempty"`
+
+	// StartTime is when the operation started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// CompletionTime is when the operation succeeded or failed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// ObservedGeneration is the most recent generation observed
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=mdbops
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.name"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// MongoDBOpsRequest is the Schema for the mongodbopsrequests API
+type MongoDBOpsRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MongoDBOpsRequestSpec   `json:"spec,omitempty"`
+	Status MongoDBOpsRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MongoDBOpsRequestList contains a list of MongoDBOpsRequest
+type MongoDBOpsRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MongoDBOpsRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MongoDBOpsRequest{}, &MongoDBOpsRequestList{})
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -25,6 +26,12 @@ type MongoDBShardedSpec struct {
 	// Version defines MongoDB version configuration
 	Version MongoDBVersion `json:"version"`
 
+	// Architecture restricts the pods to nodes of one CPU architecture. By default they run on
+	// amd64 and arm64 nodes, the architectures the official mongo images are built for.
+	// +kubebuilder:validation:Enum=amd64;arm64
+	// +optional
+	Architecture string `json:"architecture,omitempty"`
+
 	// ConfigServer defines config server configuration
 	ConfigServer ConfigServerSpec `json:"configServer"`
 
@@ -34,12 +41,21 @@ type MongoDBShardedSpec struct {
 	// Mongos defines mongos router configuration
 	Mongos MongosSpec `json:"mongos"`
 
+	// UpdateStrategy controls how template changes roll out to the config server and shard members
+	// +optional
+	UpdateStrategy *ShardedUpdateStrategySpec `json:"updateStrategy,omitempty"`
+
+	// Balancer configures the chunk balancer. The balancer is left untouched when unset.
+	// +optional
+	Balancer *BalancerSpec `json:"balancer,omitempty"`
+
 	// TLS defines TLS configuration
 	// +optional
 	TLS *TLSSpec `json:"tls,omitempty"`
 
 	// Auth defines authentication configuration
-	Auth AuthSpec `json:"auth"`
+	// +optional
+	Auth AuthSpec `json:"auth,omitempty"`
 
 	// Monitoring defines monitoring configuration
 	// +optional
@@ -49,9 +65,52 @@ type MongoDBShardedSpec struct {
 	// +optional
 	Backup *BackupSpec `json:"backup,omitempty"`
 
-	// AdditionalConfig allows passing additional MongoDB configuration
+	// ServiceMesh enrolls the pods in an Istio or Linkerd service mesh
+	// +optional
+	ServiceMesh *ServiceMeshSpec `json:"serviceMesh,omitempty"`
+
+	// ClusterDomain is the DNS domain of the Kubernetes cluster used in member host names and
+	// connection strings. Defaults to the operator --cluster-domain flag (cluster.local).
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +optional
+	ClusterDomain string `json:"clusterDomain,omitempty"`
+
+	// AdditionalConfig holds mongod.conf settings of the config servers and shards keyed by
+	// their dotted path, e.g. operationProfiling.slowOpThresholdMs. Values are parsed as YAML.
+	// Settings managed by the operator, such as net.port or sharding.clusterRole, can not be
+	// overridden. mongos does not use them.
 	// +optional
 	AdditionalConfig map[string]string `json:"additionalConfig,omitempty"`
+
+	// ResourceProfile sizes the config servers, shards, mongos and exporters when their resources
+	// are not set: small, medium and large expand to requests, limits and a WiredTiger cache size
+	// that fit together, custom requires memory limits on the config servers and shards and
+	// derives the cache size from them
+	// +kubebuilder:validation:Enum=small;medium;large;custom
+	// +optional
+	ResourceProfile string `json:"resourceProfile,omitempty"`
+
+	// StorageEngine configures the compression, directory layout and journal of the config servers and shards
+	// +optional
+	StorageEngine *StorageEngineSpec `json:"storageEngine,omitempty"`
+
+	// Profiling configures the database profiler of the config servers and shards, applied without a restart
+	// +optional
+	Profiling *ProfilingSpec `json:"profiling,omitempty"`
+
+	// Logging configures the log verbosity of the config servers and shards, applied without a restart
+	// +optional
+	Logging *LoggingSpec `json:"logging,omitempty"`
+
+	// Telemetry turns off free monitoring and mongosh telemetry on the config servers, shards and mongos, for
+	// airgapped and compliance-sensitive deployments
+	// +optional
+	Telemetry *TelemetrySpec `json:"telemetry,omitempty"`
+
+	// InitScripts are run once, in order, after the admin user is created, to load fixture data,
+	// application roles or collections before the cluster is reported Ready
+	// +optional
+	InitScripts []InitScript `json:"initScripts,omitempty"`
 }
 
 // ConfigServerSpec defines config server configuration
@@ -72,6 +131,10 @@ type ConfigServerSpec struct {
 	// Pod defines pod-level configuration
 	// +optional
 	Pod *PodSpec `json:"pod,omitempty"`
+
+	// Service defines additional metadata of the config server Service
+	// +optional
+	Service *ServiceMetadataSpec `json:"service,omitempty"`
 }
 
 // ShardSpec defines shard configuration
@@ -98,9 +161,103 @@ type ShardSpec struct {
 	// +optional
 	Pod *PodSpec `json:"pod,omitempty"`
 
+	// Service defines additional metadata of the shard Services
+	// +optional
+	Service *ServiceMetadataSpec `json:"service,omitempty"`
+
 	// AutoScaling defines shard auto-scaling configuration
 	// +optional
 	AutoScaling *ShardAutoScalingSpec `json:"autoScaling,omitempty"`
+
+	// PersistentVolumeClaimRetentionPolicy controls whether the volumes of a shard are deleted
+	// once it has been drained and removed after decreasing count
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +kubebuilder:default="Retain"
+	// +optional
+	PersistentVolumeClaimRetentionPolicy string `json:"persistentVolumeClaimRetentionPolicy,omitempty"`
+
+	// Zones assigns shards to zones. Shards are tagged with their zone in the cluster
+	// and their pods are placed on the zone's nodes.
+	// +optional
+	Zones []ShardZone `json:"zones,omitempty"`
+
+	// Placement overrides node placement of individual shards
+	// +optional
+	Placement []ShardPlacement `json:"placement,omitempty"`
+
+	// Isolation keeps the members of different shards off the same node, so losing a node
+	// degrades a single shard. The default anti-affinity only spreads the pods of the cluster.
+	// +optional
+	Isolation *ShardIsolationSpec `json:"isolation,omitempty"`
+}
+
+// ShardIsolationSpec defines the anti-affinity between the members of different shards
+type ShardIsolationSpec struct {
+	// Mode controls how strictly members of different shards are kept apart. Required refuses
+	// to schedule them on one node and needs a node per data-bearing member, Preferred only
+	// avoids it.
+	// +kubebuilder:validation:Enum=Preferred;Required
+	// +kubebuilder:default=Preferred
+	Mode string `json:"mode"`
+
+	// ConfigServers also keeps the config servers off the nodes of the shard members
+	// +optional
+	ConfigServers bool `json:"configServers,omitempty"`
+}
+
+// ShardZone maps a set of shards to a zone and a failure domain
+type ShardZone struct {
+	// Name is the zone name
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Shards lists the indexes of the shards in the zone (0 is <name>-shard-0)
+	// +kubebuilder:validation:MinItems=1
+	Shards []int32 `json:"shards"`
+
+	// NodeSelector pins the pods of the zone's shards to a failure domain,
+	// e.g. topology.kubernetes.io/zone: eu-west-1a
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are added to the pods of the zone's shards
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Ranges assigns shard key ranges of collections to the zone
+	// +optional
+	Ranges []ZoneKeyRange `json:"ranges,omitempty"`
+}
+
+// ZoneKeyRange assigns a shard key range of a collection to a zone
+type ZoneKeyRange struct {
+	// Namespace is the <database>.<collection> namespace
+	Namespace string `json:"namespace"`
+
+	// Min is the inclusive lower bound of the range as an Extended JSON document
+	Min string `json:"min"`
+
+	// Max is the exclusive upper bound of the range as an Extended JSON document
+	Max string `json:"max"`
+}
+
+// ShardPlacement overrides node placement of a single shard
+type ShardPlacement struct {
+	// Shard is the shard index
+	// +kubebuilder:validation:Minimum=0
+	Shard int32 `json:"shard"`
+
+	// NodeSelector is merged over the zone's node selector
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations are added to the shard's pods
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity replaces the node, pod and pod anti-affinity rules it sets
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
 }
 
 // ShardAutoScalingSpec defines shard auto-scaling
@@ -141,6 +298,101 @@ type MongosSpec struct {
 	// AutoScaling defines mongos auto-scaling configuration
 	// +optional
 	AutoScaling *AutoScalingSpec `json:"autoScaling,omitempty"`
+
+	// UpdateStrategy controls mongos rollouts. RollingUpdate replaces one instance at a time,
+	// Surge starts a new instance before stopping an old one so serving capacity never drops.
+	// +kubebuilder:validation:Enum=RollingUpdate;Surge
+	// +kubebuilder:default=RollingUpdate
+	// +optional
+	UpdateStrategy string `json:"updateStrategy,omitempty"`
+
+	// Mode controls how mongos instances are placed. Deployment runs Replicas instances anywhere,
+	// DaemonSet runs one instance on every node selected by the pod node selector, and PerZone runs
+	// Replicas instances in each of Zones. Applications reach a node-local mongos in DaemonSet mode
+	// and a zone-local one in PerZone mode.
+	// +kubebuilder:validation:Enum=Deployment;DaemonSet;PerZone
+	// +kubebuilder:default=Deployment
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// Zones are the topology.kubernetes.io/zone values mongos runs in, required in PerZone mode
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+
+	// Version overrides the MongoDB version of mongos, e.g. to upgrade mongos after the config
+	// servers and shards. It is the version of the data-bearing members or one release older.
+	// +kubebuilder:validation:Pattern=`^\d+\.\d+(\.\d+)?$`
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Image overrides the container image of mongos, such as a slim router image. Set Version as
+	// well when the image runs another version than the data-bearing members.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// BlueGreen runs a second "green" mongos Deployment with another release next to the current
+	// one, behind the same Service. Traffic is spread over the instances of both, so moving replicas
+	// from Replicas to the green Deployment shifts clients gradually. Removing BlueGreen rolls back
+	// instantly; setting Version and Image to those of the green release and then removing it
+	// promotes the green release. Only supported in Deployment mode without autoscaling.
+	// +optional
+	BlueGreen *MongosBlueGreenSpec `json:"blueGreen,omitempty"`
+}
+
+// MongosBlueGreenSpec defines the green mongos Deployment of a blue/green router upgrade
+type MongosBlueGreenSpec struct {
+	// Replicas is the number of green mongos instances
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	Replicas int32 `json:"replicas"`
+
+	// Version is the MongoDB version of the green instances, at most the version of the
+	// data-bearing members and one major version behind
+	// +kubebuilder:validation:Pattern=`^\d+\.\d+(\.\d+)?$`
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Image is the container image of the green instances, the official image of Version when
+	// unset. Version or Image is required.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// ShardedUpdateStrategySpec defines how the config server and shard StatefulSets roll out
+type ShardedUpdateStrategySpec struct {
+	// MemberHealthGate holds the rollout of a replica set until the member updated last reports
+	// PRIMARY or SECONDARY and caught up with the primary, instead of moving on as soon as its pod
+	// is ready
+	// +optional
+	MemberHealthGate bool `json:"memberHealthGate,omitempty"`
+
+	// MaxLagSeconds is the replication lag under which an updated secondary counts as caught up
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=10
+	// +optional
+	MaxLagSeconds *int32 `json:"maxLagSeconds,omitempty"`
+}
+
+// BalancerSpec defines the chunk balancer configuration
+type BalancerSpec struct {
+	// Enabled starts or stops the balancer
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+
+	// ActiveWindow restricts balancing to a daily time window; balancing runs at any time when unset
+	// +optional
+	ActiveWindow *BalancerWindow `json:"activeWindow,omitempty"`
+}
+
+// BalancerWindow is a daily time window in the config server's local time
+type BalancerWindow struct {
+	// Start is the start of the window (HH:MM)
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Start string `json:"start"`
+
+	// Stop is the end of the window (HH:MM)
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	Stop string `json:"stop"`
 }
 
 // MongosServiceSpec defines mongos service configuration
@@ -158,6 +410,10 @@ type MongosServiceSpec struct {
 	// +optional
 	Annotations map[string]string `json:"annotations,omitempty"`
 
+	// Labels are additional service labels. Labels set by the operator take precedence.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// LoadBalancerIP is the load balancer IP (for LoadBalancer type)
 	// +optional
 	LoadBalancerIP string `json:"loadBalancerIP,omitempty"`
@@ -179,6 +435,15 @@ type MongoDBShardedStatus struct {
 	// MongosStatus contains mongos status
 	Mongos ComponentStatus `json:"mongos,omitempty"`
 
+	// MongosGreen contains the status of the green mongos Deployment of a blue/green upgrade, also
+	// counted in Mongos
+	// +optional
+	MongosGreen *ComponentStatus `json:"mongosGreen,omitempty"`
+
+	// Storage contains the disk usage of each config server and shard member
+	// +optional
+	Storage []MemberStorageStatus `json:"storage,omitempty"`
+
 	// Conditions represents the latest available observations
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
@@ -186,6 +451,10 @@ type MongoDBShardedStatus struct {
 	// ConnectionString is the MongoDB connection URI (via mongos)
 	ConnectionString string `json:"connectionString,omitempty"`
 
+	// SRVConnectionString is the mongodb+srv:// URI looking up the mongos Service in DNS
+	// +optional
+	SRVConnectionString string `json:"srvConnectionString,omitempty"`
+
 	// LastBackup contains information about the last backup
 	// +optional
 	LastBackup *BackupStatus `json:"lastBackup,omitempty"`
@@ -200,16 +469,161 @@ type MongoDBShardedStatus struct {
 	// ConfigServerInitialized indicates if the config server replica set has been initialized
 	ConfigServerInitialized bool `json:"configServerInitialized,omitempty"`
 
-	// ShardsInitialized indicates which shards have been initialized (indexed by shard number)
-	// +optional
-	ShardsInitialized []bool `json:"shardsInitialized,omitempty"`
-
-	// ShardsAdded indicates which shards have been added to the cluster (indexed by shard number)
+	// ShardProvisioning tracks the initialization and registration of each shard, keyed by shard name
 	// +optional
-	ShardsAdded []bool `json:"shardsAdded,omitempty"`
+	ShardProvisioning map[string]ShardProvisioningStatus `json:"shardProvisioning,omitempty"`
 
 	// AdminUserCreated indicates if the admin user has been created
 	AdminUserCreated bool `json:"adminUserCreated,omitempty"`
+
+	// InitScripts are the names of the init scripts that ran, they are never run again
+	// +optional
+	InitScripts []string `json:"initScripts,omitempty"`
+
+	// AdminPasswordHash is a salted hash of the admin password applied to the database,
+	// used to detect rotations of the admin credentials secret
+	// +optional
+	AdminPasswordHash string `json:"adminPasswordHash,omitempty"`
+
+	// MonitoringPasswordHash is a salted hash of the exporter user password applied to the database
+	// +optional
+	MonitoringPasswordHash string `json:"monitoringPasswordHash,omitempty"`
+
+	// DiagnosticsHash is a hash of the profiler and log verbosity settings applied to the config servers and shards
+	// +optional
+	DiagnosticsHash string `json:"diagnosticsHash,omitempty"`
+
+	// KeyfileRotation tracks the keyfile rotation requested through the rotate-keyfile annotation
+	// +optional
+	KeyfileRotation *KeyfileRotationStatus `json:"keyfileRotation,omitempty"`
+
+	// RemovingShards tracks shards being drained after spec.shards.count was decreased
+	// +optional
+	RemovingShards []ShardRemovalStatus `json:"removingShards,omitempty"`
+
+	// Balancer reports the chunk balancer state
+	// +optional
+	Balancer *BalancerStatus `json:"balancer,omitempty"`
+
+	// Zones lists the zone assignments and ranges applied to the cluster
+	// +optional
+	Zones []AppliedShardZone `json:"zones,omitempty"`
+
+	// AppliedSettings are the settings the cluster was last reconciled with that can not be
+	// changed safely in place
+	// +optional
+	AppliedSettings *AppliedSettings `json:"appliedSettings,omitempty"`
+
+	// Versions are the image and MongoDB version of each config server and shard member, which
+	// differ from the spec during a rollout
+	// +optional
+	Versions []MemberVersionStatus `json:"versions,omitempty"`
+
+	// Drift lists the out-of-band changes to owned resources reverted within the last hour
+	// +optional
+	Drift []DriftStatus `json:"drift,omitempty"`
+}
+
+// AppliedShardZone is a zone as configured in the cluster
+type AppliedShardZone struct {
+	// Name is the zone name
+	Name string `json:"name"`
+
+	// Shards lists the shard names tagged with the zone
+	// +optional
+	Shards []string `json:"shards,omitempty"`
+
+	// Ranges lists the shard key ranges assigned to the zone
+	// +optional
+	Ranges []ZoneKeyRange `json:"ranges,omitempty"`
+}
+
+// BalancerStatus reports the chunk balancer state
+type BalancerStatus struct {
+	// Mode is the balancer mode reported by balancerStatus (full or off)
+	Mode string `json:"mode,omitempty"`
+
+	// InBalancerRound indicates a balancing round is in progress
+	InBalancerRound bool `json:"inBalancerRound,omitempty"`
+
+	// ActiveWindow is the configured balancing window
+	// +optional
+	ActiveWindow *BalancerWindow `json:"activeWindow,omitempty"`
+
+	// Migrations lists the chunk migrations in progress
+	// +optional
+	Migrations []ChunkMigration `json:"migrations,omitempty"`
+
+	// FailedMigrations is the number of failed chunk migrations recorded in config.changelog,
+	// which only keeps the recent history
+	// +optional
+	FailedMigrations int32 `json:"failedMigrations,omitempty"`
+
+	// JumboChunks is the number of chunks the balancer cannot move because they are too large
+	// +optional
+	JumboChunks int32 `json:"jumboChunks,omitempty"`
+}
+
+// ChunkMigration describes a chunk migration in progress
+type ChunkMigration struct {
+	// Namespace is the collection namespace
+	Namespace string `json:"namespace"`
+
+	// FromShard is the donor shard
+	FromShard string `json:"fromShard"`
+
+	// ToShard is the recipient shard
+	ToShard string `json:"toShard"`
+}
+
+// ShardRemovalStatus reports the draining progress of a shard being removed
+type ShardRemovalStatus struct {
+	// Name is the shard name
+	Name string `json:"name"`
+
+	// State is the removeShard state (started, ongoing or completed)
+	State string `json:"state,omitempty"`
+
+	// RemainingChunks is the number of chunks still to be migrated off the shard
+	RemainingChunks int64 `json:"remainingChunks"`
+
+	// JumboChunks is the number of remaining chunks too large to be migrated automatically
+	// +optional
+	JumboChunks int64 `json:"jumboChunks,omitempty"`
+
+	// DatabasesToMove lists the databases whose primary shard still is the shard being removed
+	// +optional
+	DatabasesToMove []string `json:"databasesToMove,omitempty"`
+
+	// PrimaryMoves tracks the movePrimary of every database whose primary shard was the shard
+	// being removed, moving its unsharded collections to a remaining shard
+	// +optional
+	PrimaryMoves []PrimaryMoveStatus `json:"primaryMoves,omitempty"`
+
+	// StartTime is when the removal started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+}
+
+// PrimaryMoveStatus reports the move of the primary shard of a database off a shard being removed
+type PrimaryMoveStatus struct {
+	// Database is the database name
+	Database string `json:"database"`
+
+	// ToShard is the shard the database moves to
+	ToShard string `json:"toShard"`
+
+	// Phase is the progress of the move
+	// +kubebuilder:validation:Enum=Pending;Moving;Moved;Failed
+	Phase string `json:"phase"`
+
+	// Message reports the error of the last attempt of a failed move, which is retried
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// CompletionTime is when the database was moved
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
 }
 
 // ComponentStatus represents the status of a cluster component
@@ -243,6 +657,19 @@ type ShardStatus struct {
 	Phase string `json:"phase,omitempty"`
 }
 
+// ShardProvisioningStatus is the last observed initialization and registration state of a shard.
+// The operator checks the replica set and listShards before acting, so the entry only saves
+// work and reports progress.
+type ShardProvisioningStatus struct {
+	// Initialized indicates the replica set of the shard has been initiated
+	// +optional
+	Initialized bool `json:"initialized,omitempty"`
+
+	// Added indicates the shard is registered in the cluster
+	// +optional
+	Added bool `json:"added,omitempty"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:shortName=mdbsh
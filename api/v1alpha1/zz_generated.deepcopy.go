@@ -22,10 +22,63 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedSettings) DeepCopyInto(out *AppliedSettings) {
+	*out = *in
+	if in.StorageSizes != nil {
+		in, out := &in.StorageSizes, &out.StorageSizes
+		*out = make(map[string]resource.Quantity, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.DirectoryPerDB != nil {
+		in, out := &in.DirectoryPerDB, &out.DirectoryPerDB
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedSettings.
+func (in *AppliedSettings) DeepCopy() *AppliedSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AppliedShardZone) DeepCopyInto(out *AppliedShardZone) {
+	*out = *in
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Ranges != nil {
+		in, out := &in.Ranges, &out.Ranges
+		*out = make([]ZoneKeyRange, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AppliedShardZone.
+func (in *AppliedShardZone) DeepCopy() *AppliedShardZone {
+	if in == nil {
+		return nil
+	}
+	out := new(AppliedShardZone)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ArbiterSpec) DeepCopyInto(out *ArbiterSpec) {
 	*out = *in
@@ -46,6 +99,11 @@ func (in *ArbiterSpec) DeepCopy() *ArbiterSpec {
 func (in *AuthSpec) DeepCopyInto(out *AuthSpec) {
 	*out = *in
 	out.AdminCredentialsSecretRef = in.AdminCredentialsSecretRef
+	if in.KeyfileSecretRef != nil {
+		in, out := &in.KeyfileSecretRef, &out.KeyfileSecretRef
+		*out = new(KeyfileSecretRef)
+		**out = **in
+	}
 	if in.Users != nil {
 		in, out := &in.Users, &out.Users
 		*out = make([]MongoDBUser, len(*in))
@@ -53,6 +111,11 @@ func (in *AuthSpec) DeepCopyInto(out *AuthSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = new(OIDCSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSpec.
@@ -65,6 +128,21 @@ func (in *AuthSpec) DeepCopy() *AuthSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoResyncSpec) DeepCopyInto(out *AutoResyncSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoResyncSpec.
+func (in *AutoResyncSpec) DeepCopy() *AutoResyncSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoResyncSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoScalingMetric) DeepCopyInto(out *AutoScalingMetric) {
 	*out = *in
@@ -169,6 +247,106 @@ func (in *BackupStorageSpec) DeepCopy() *BackupStorageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BalancerSpec) DeepCopyInto(out *BalancerSpec) {
+	*out = *in
+	if in.ActiveWindow != nil {
+		in, out := &in.ActiveWindow, &out.ActiveWindow
+		*out = new(BalancerWindow)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BalancerSpec.
+func (in *BalancerSpec) DeepCopy() *BalancerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BalancerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BalancerStatus) DeepCopyInto(out *BalancerStatus) {
+	*out = *in
+	if in.ActiveWindow != nil {
+		in, out := &in.ActiveWindow, &out.ActiveWindow
+		*out = new(BalancerWindow)
+		**out = **in
+	}
+	if in.Migrations != nil {
+		in, out := &in.Migrations, &out.Migrations
+		*out = make([]ChunkMigration, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BalancerStatus.
+func (in *BalancerStatus) DeepCopy() *BalancerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BalancerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BalancerWindow) DeepCopyInto(out *BalancerWindow) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BalancerWindow.
+func (in *BalancerWindow) DeepCopy() *BalancerWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(BalancerWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryUpdateSpec) DeepCopyInto(out *CanaryUpdateSpec) {
+	*out = *in
+	if in.SoakSeconds != nil {
+		in, out := &in.SoakSeconds, &out.SoakSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryUpdateSpec.
+func (in *CanaryUpdateSpec) DeepCopy() *CanaryUpdateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryUpdateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CappedSpec) DeepCopyInto(out *CappedSpec) {
+	*out = *in
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CappedSpec.
+func (in *CappedSpec) DeepCopy() *CappedSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CappedSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertIssuerRef) DeepCopyInto(out *CertIssuerRef) {
 	*out = *in
@@ -200,6 +378,36 @@ func (in *CertManagerSpec) DeepCopy() *CertManagerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChunkDistribution) DeepCopyInto(out *ChunkDistribution) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChunkDistribution.
+func (in *ChunkDistribution) DeepCopy() *ChunkDistribution {
+	if in == nil {
+		return nil
+	}
+	out := new(ChunkDistribution)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ChunkMigration) DeepCopyInto(out *ChunkMigration) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ChunkMigration.
+func (in *ChunkMigration) DeepCopy() *ChunkMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(ChunkMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterReference) DeepCopyInto(out *ClusterReference) {
 	*out = *in
@@ -240,6 +448,11 @@ func (in *ConfigServerSpec) DeepCopyInto(out *ConfigServerSpec) {
 		*out = new(PodSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceMetadataSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigServerSpec.
@@ -252,6 +465,21 @@ func (in *ConfigServerSpec) DeepCopy() *ConfigServerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsSecretRef) DeepCopyInto(out *CredentialsSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsSecretRef.
+func (in *CredentialsSecretRef) DeepCopy() *CredentialsSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CustomCertSpec) DeepCopyInto(out *CustomCertSpec) {
 	*out = *in
@@ -283,300 +511,315 @@ func (in *CustomMetricSpec) DeepCopy() *CustomMetricSpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExporterSpec) DeepCopyInto(out *ExporterSpec) {
+func (in *DriftStatus) DeepCopyInto(out *DriftStatus) {
 	*out = *in
-	in.Resources.DeepCopyInto(&out.Resources)
+	in.DetectedAt.DeepCopyInto(&out.DetectedAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExporterSpec.
-func (in *ExporterSpec) DeepCopy() *ExporterSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DriftStatus.
+func (in *DriftStatus) DeepCopy() *DriftStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ExporterSpec)
+	out := new(DriftStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MemberStatus) DeepCopyInto(out *MemberStatus) {
+func (in *ExporterSpec) DeepCopyInto(out *ExporterSpec) {
 	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CollStatsNamespaces != nil {
+		in, out := &in.CollStatsNamespaces, &out.CollStatsNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IndexStatsNamespaces != nil {
+		in, out := &in.IndexStatsNamespaces, &out.IndexStatsNamespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Collectors != nil {
+		in, out := &in.Collectors, &out.Collectors
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberStatus.
-func (in *MemberStatus) DeepCopy() *MemberStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExporterSpec.
+func (in *ExporterSpec) DeepCopy() *ExporterSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MemberStatus)
+	out := new(ExporterSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDB) DeepCopyInto(out *MongoDB) {
+func (in *ExternalAccessSpec) DeepCopyInto(out *ExternalAccessSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDB.
-func (in *MongoDB) DeepCopy() *MongoDB {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalAccessSpec.
+func (in *ExternalAccessSpec) DeepCopy() *ExternalAccessSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDB)
+	out := new(ExternalAccessSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDB) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBBackup) DeepCopyInto(out *MongoDBBackup) {
+func (in *ForceReconfigStatus) DeepCopyInto(out *ForceReconfigStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.RemovedMembers != nil {
+		in, out := &in.RemovedMembers, &out.RemovedMembers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackup.
-func (in *MongoDBBackup) DeepCopy() *MongoDBBackup {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForceReconfigStatus.
+func (in *ForceReconfigStatus) DeepCopy() *ForceReconfigStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBBackup)
+	out := new(ForceReconfigStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBBackup) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBBackupList) DeepCopyInto(out *MongoDBBackupList) {
+func (in *IndexKeyField) DeepCopyInto(out *IndexKeyField) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]MongoDBBackup, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackupList.
-func (in *MongoDBBackupList) DeepCopy() *MongoDBBackupList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexKeyField.
+func (in *IndexKeyField) DeepCopy() *IndexKeyField {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBBackupList)
+	out := new(IndexKeyField)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBBackupList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBBackupSpec) DeepCopyInto(out *MongoDBBackupSpec) {
+func (in *IndexBuildSpec) DeepCopyInto(out *IndexBuildSpec) {
 	*out = *in
-	out.ClusterRef = in.ClusterRef
-	in.Storage.DeepCopyInto(&out.Storage)
+	if in.RollingThreshold != nil {
+		in, out := &in.RollingThreshold, &out.RollingThreshold
+		x := (*in).DeepCopy()
+		*out = &x
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackupSpec.
-func (in *MongoDBBackupSpec) DeepCopy() *MongoDBBackupSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexBuildSpec.
+func (in *IndexBuildSpec) DeepCopy() *IndexBuildSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBBackupSpec)
+	out := new(IndexBuildSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBBackupStatus) DeepCopyInto(out *MongoDBBackupStatus) {
+func (in *IndexSpec) DeepCopyInto(out *IndexSpec) {
 	*out = *in
-	if in.StartTime != nil {
-		in, out := &in.StartTime, &out.StartTime
-		*out = (*in).DeepCopy()
-	}
-	if in.CompletionTime != nil {
-		in, out := &in.CompletionTime, &out.CompletionTime
-		*out = (*in).DeepCopy()
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]IndexKeyField, len(*in))
+		copy(*out, *in)
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.ExpireAfterSeconds != nil {
+		in, out := &in.ExpireAfterSeconds, &out.ExpireAfterSeconds
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackupStatus.
-func (in *MongoDBBackupStatus) DeepCopy() *MongoDBBackupStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexSpec.
+func (in *IndexSpec) DeepCopy() *IndexSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBBackupStatus)
+	out := new(IndexSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBList) DeepCopyInto(out *MongoDBList) {
+func (in *IndexStatus) DeepCopyInto(out *IndexStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]MongoDB, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBList.
-func (in *MongoDBList) DeepCopy() *MongoDBList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IndexStatus.
+func (in *IndexStatus) DeepCopy() *IndexStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBList)
+	out := new(IndexStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *InitScript) DeepCopyInto(out *InitScript) {
+	*out = *in
+	if in.ConfigMapRef != nil {
+		in, out := &in.ConfigMapRef, &out.ConfigMapRef
+		*out = new(v1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SecretRef != nil {
+		in, out := &in.SecretRef, &out.SecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
 	}
-	return nil
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new InitScript.
+func (in *InitScript) DeepCopy() *InitScript {
+	if in == nil {
+		return nil
+	}
+	out := new(InitScript)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBRole) DeepCopyInto(out *MongoDBRole) {
-	*out = *in
+func (in *JournalSpec) DeepCopyInto(out *JournalSpec) {
+	if in.CommitIntervalMs != nil {
+		in, out := &in.CommitIntervalMs, &out.CommitIntervalMs
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBRole.
-func (in *MongoDBRole) DeepCopy() *MongoDBRole {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JournalSpec.
+func (in *JournalSpec) DeepCopy() *JournalSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBRole)
+	out := new(JournalSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBSharded) DeepCopyInto(out *MongoDBSharded) {
+func (in *KeyfileRotationStatus) DeepCopyInto(out *KeyfileRotationStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBSharded.
-func (in *MongoDBSharded) DeepCopy() *MongoDBSharded {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyfileRotationStatus.
+func (in *KeyfileRotationStatus) DeepCopy() *KeyfileRotationStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBSharded)
+	out := new(KeyfileRotationStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBSharded) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyfileSecretRef) DeepCopyInto(out *KeyfileSecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyfileSecretRef.
+func (in *KeyfileSecretRef) DeepCopy() *KeyfileSecretRef {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(KeyfileSecretRef)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBShardedList) DeepCopyInto(out *MongoDBShardedList) {
+func (in *LoggingSpec) DeepCopyInto(out *LoggingSpec) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]MongoDBSharded, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
+	if in.Components != nil {
+		in, out := &in.Components, &out.Components
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBShardedList.
-func (in *MongoDBShardedList) DeepCopy() *MongoDBShardedList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoggingSpec.
+func (in *LoggingSpec) DeepCopy() *LoggingSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBShardedList)
+	out := new(LoggingSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MongoDBShardedList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemberClusterSpec) DeepCopyInto(out *MemberClusterSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberClusterSpec.
+func (in *MemberClusterSpec) DeepCopy() *MemberClusterSpec {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(MemberClusterSpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBShardedSpec) DeepCopyInto(out *MongoDBShardedSpec) {
+func (in *MemberOverride) DeepCopyInto(out *MemberOverride) {
 	*out = *in
-	out.Version = in.Version
-	in.ConfigServer.DeepCopyInto(&out.ConfigServer)
-	in.Shards.DeepCopyInto(&out.Shards)
-	in.Mongos.DeepCopyInto(&out.Mongos)
-	if in.TLS != nil {
-		in, out := &in.TLS, &out.TLS
-		*out = new(TLSSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	in.Auth.DeepCopyInto(&out.Auth)
-	if in.Monitoring != nil {
-		in, out := &in.Monitoring, &out.Monitoring
-		*out = new(MonitoringSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Priority != nil {
+		in, out := &in.Priority, &out.Priority
+		*out = new(int32)
+		**out = **in
 	}
-	if in.Backup != nil {
-		in, out := &in.Backup, &out.Backup
-		*out = new(BackupSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Votes != nil {
+		in, out := &in.Votes, &out.Votes
+		*out = new(int32)
+		**out = **in
 	}
-	if in.AdditionalConfig != nil {
-		in, out := &in.AdditionalConfig, &out.AdditionalConfig
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
 		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
@@ -584,214 +827,2205 @@ func (in *MongoDBShardedSpec) DeepCopyInto(out *MongoDBShardedSpec) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBShardedSpec.
-func (in *MongoDBShardedSpec) DeepCopy() *MongoDBShardedSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberOverride.
+func (in *MemberOverride) DeepCopy() *MemberOverride {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBShardedSpec)
+	out := new(MemberOverride)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBShardedStatus) DeepCopyInto(out *MongoDBShardedStatus) {
+func (in *MemberStatus) DeepCopyInto(out *MemberStatus) {
 	*out = *in
-	out.ConfigServer = in.ConfigServer
-	if in.Shards != nil {
-		in, out := &in.Shards, &out.Shards
-		*out = make([]ShardStatus, len(*in))
-		copy(*out, *in)
-	}
-	out.Mongos = in.Mongos
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.LastBackup != nil {
-		in, out := &in.LastBackup, &out.LastBackup
-		*out = new(BackupStatus)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ShardedCollections != nil {
-		in, out := &in.ShardedCollections, &out.ShardedCollections
-		*out = make([]string, len(*in))
-		copy(*out, *in)
-	}
-	if in.ShardsInitialized != nil {
-		in, out := &in.ShardsInitialized, &out.ShardsInitialized
-		*out = make([]bool, len(*in))
-		copy(*out, *in)
-	}
-	if in.ShardsAdded != nil {
-		in, out := &in.ShardsAdded, &out.ShardsAdded
-		*out = make([]bool, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBShardedStatus.
-func (in *MongoDBShardedStatus) DeepCopy() *MongoDBShardedStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberStatus.
+func (in *MemberStatus) DeepCopy() *MemberStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBShardedStatus)
+	out := new(MemberStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBSpec) DeepCopyInto(out *MongoDBSpec) {
+func (in *MemberStorageStatus) DeepCopyInto(out *MemberStorageStatus) {
 	*out = *in
-	out.Version = in.Version
-	in.Storage.DeepCopyInto(&out.Storage)
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.TLS != nil {
-		in, out := &in.TLS, &out.TLS
-		*out = new(TLSSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	in.Auth.DeepCopyInto(&out.Auth)
-	if in.Monitoring != nil {
-		in, out := &in.Monitoring, &out.Monitoring
-		*out = new(MonitoringSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Backup != nil {
-		in, out := &in.Backup, &out.Backup
-		*out = new(BackupSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.AutoScaling != nil {
-		in, out := &in.AutoScaling, &out.AutoScaling
-		*out = new(AutoScalingSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Pod != nil {
-		in, out := &in.Pod, &out.Pod
-		*out = new(PodSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Arbiter != nil {
-		in, out := &in.Arbiter, &out.Arbiter
-		*out = new(ArbiterSpec)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.AdditionalConfig != nil {
-		in, out := &in.AdditionalConfig, &out.AdditionalConfig
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBSpec.
-func (in *MongoDBSpec) DeepCopy() *MongoDBSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberStorageStatus.
+func (in *MemberStorageStatus) DeepCopy() *MemberStorageStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBSpec)
+	out := new(MemberStorageStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBStatus) DeepCopyInto(out *MongoDBStatus) {
+func (in *MemberVersionStatus) DeepCopyInto(out *MemberVersionStatus) {
 	*out = *in
-	if in.Members != nil {
-		in, out := &in.Members, &out.Members
-		*out = make([]MemberStatus, len(*in))
-		copy(*out, *in)
-	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
-	if in.LastBackup != nil {
-		in, out := &in.LastBackup, &out.LastBackup
-		*out = new(BackupStatus)
-		(*in).DeepCopyInto(*out)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemberVersionStatus.
+func (in *MemberVersionStatus) DeepCopy() *MemberVersionStatus {
+	if in == nil {
+		return nil
 	}
+	out := new(MemberVersionStatus)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBStatus.
-func (in *MongoDBStatus) DeepCopy() *MongoDBStatus {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MigrationSourceSpec) DeepCopyInto(out *MigrationSourceSpec) {
+	*out = *in
+	in.URISecretRef.DeepCopyInto(&out.URISecretRef)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MigrationSourceSpec.
+func (in *MigrationSourceSpec) DeepCopy() *MigrationSourceSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBStatus)
+	out := new(MigrationSourceSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
+func (in *MongoDB) DeepCopyInto(out *MongoDB) {
 	*out = *in
-	in.PasswordSecretRef.DeepCopyInto(&out.PasswordSecretRef)
-	if in.Roles != nil {
-		in, out := &in.Roles, &out.Roles
-		*out = make([]MongoDBRole, len(*in))
-		copy(*out, *in)
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDB.
+func (in *MongoDB) DeepCopy() *MongoDB {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDB)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDB) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBBackup) DeepCopyInto(out *MongoDBBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackup.
+func (in *MongoDBBackup) DeepCopy() *MongoDBBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBBackupList) DeepCopyInto(out *MongoDBBackupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBBackup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackupList.
+func (in *MongoDBBackupList) DeepCopy() *MongoDBBackupList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBBackupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBBackupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBBackupSpec) DeepCopyInto(out *MongoDBBackupSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	in.Storage.DeepCopyInto(&out.Storage)
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.BackoffLimit != nil {
+		in, out := &in.BackoffLimit, &out.BackoffLimit
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackupSpec.
+func (in *MongoDBBackupSpec) DeepCopy() *MongoDBBackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBBackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBBackupStatus) DeepCopyInto(out *MongoDBBackupStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.OplogStart != nil {
+		in, out := &in.OplogStart, &out.OplogStart
+		*out = new(OplogTimestamp)
+		**out = **in
+	}
+	if in.OplogEnd != nil {
+		in, out := &in.OplogEnd, &out.OplogEnd
+		*out = new(OplogTimestamp)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBBackupStatus.
+func (in *MongoDBBackupStatus) DeepCopy() *MongoDBBackupStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBBackupStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCollection) DeepCopyInto(out *MongoDBCollection) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCollection.
+func (in *MongoDBCollection) DeepCopy() *MongoDBCollection {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCollection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCollection) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCollectionList) DeepCopyInto(out *MongoDBCollectionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBCollection, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCollectionList.
+func (in *MongoDBCollectionList) DeepCopy() *MongoDBCollectionList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCollectionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBCollectionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCollectionSpec) DeepCopyInto(out *MongoDBCollectionSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.TimeSeries != nil {
+		in, out := &in.TimeSeries, &out.TimeSeries
+		*out = new(TimeSeriesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Capped != nil {
+		in, out := &in.Capped, &out.Capped
+		*out = new(CappedSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShardKey != nil {
+		in, out := &in.ShardKey, &out.ShardKey
+		*out = make([]ShardKeyField, len(*in))
+		copy(*out, *in)
+	}
+	if in.Presplit != nil {
+		in, out := &in.Presplit, &out.Presplit
+		*out = new(PresplitSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]ZoneRange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Indexes != nil {
+		in, out := &in.Indexes, &out.Indexes
+		*out = make([]IndexSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.IndexBuild != nil {
+		in, out := &in.IndexBuild, &out.IndexBuild
+		*out = new(IndexBuildSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCollectionSpec.
+func (in *MongoDBCollectionSpec) DeepCopy() *MongoDBCollectionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCollectionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBCollectionStatus) DeepCopyInto(out *MongoDBCollectionStatus) {
+	*out = *in
+	if in.ChunkDistribution != nil {
+		in, out := &in.ChunkDistribution, &out.ChunkDistribution
+		*out = make([]ChunkDistribution, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedZones != nil {
+		in, out := &in.AppliedZones, &out.AppliedZones
+		*out = make([]ZoneRange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Indexes != nil {
+		in, out := &in.Indexes, &out.Indexes
+		*out = make([]IndexStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.RollingIndexBuild != nil {
+		in, out := &in.RollingIndexBuild, &out.RollingIndexBuild
+		*out = new(RollingIndexBuildStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBCollectionStatus.
+func (in *MongoDBCollectionStatus) DeepCopy() *MongoDBCollectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBCollectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBList) DeepCopyInto(out *MongoDBList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDB, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBList.
+func (in *MongoDBList) DeepCopy() *MongoDBList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBMigration) DeepCopyInto(out *MongoDBMigration) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBMigration.
+func (in *MongoDBMigration) DeepCopy() *MongoDBMigration {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBMigration)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBMigration) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBMigrationList) DeepCopyInto(out *MongoDBMigrationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBMigration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBMigrationList.
+func (in *MongoDBMigrationList) DeepCopy() *MongoDBMigrationList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBMigrationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBMigrationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBMigrationSpec) DeepCopyInto(out *MongoDBMigrationSpec) {
+	*out = *in
+	in.Source.DeepCopyInto(&out.Source)
+	out.ClusterRef = in.ClusterRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBMigrationSpec.
+func (in *MongoDBMigrationSpec) DeepCopy() *MongoDBMigrationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBMigrationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBMigrationStatus) DeepCopyInto(out *MongoDBMigrationStatus) {
+	*out = *in
+	if in.AppliedOpTime != nil {
+		in, out := &in.AppliedOpTime, &out.AppliedOpTime
+		*out = new(OplogTimestamp)
+		**out = **in
+	}
+	if in.LastRoundTime != nil {
+		in, out := &in.LastRoundTime, &out.LastRoundTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.InitialSyncTime != nil {
+		in, out := &in.InitialSyncTime, &out.InitialSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBMigrationStatus.
+func (in *MongoDBMigrationStatus) DeepCopy() *MongoDBMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOperatorConfig) DeepCopyInto(out *MongoDBOperatorConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOperatorConfig.
+func (in *MongoDBOperatorConfig) DeepCopy() *MongoDBOperatorConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOperatorConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBOperatorConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOperatorConfigList) DeepCopyInto(out *MongoDBOperatorConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBOperatorConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOperatorConfigList.
+func (in *MongoDBOperatorConfigList) DeepCopy() *MongoDBOperatorConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOperatorConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBOperatorConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOperatorConfigSpec) DeepCopyInto(out *MongoDBOperatorConfigSpec) {
+	*out = *in
+	out.Images = in.Images
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.FeatureGates != nil {
+		in, out := &in.FeatureGates, &out.FeatureGates
+		*out = make(map[string]bool, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOperatorConfigSpec.
+func (in *MongoDBOperatorConfigSpec) DeepCopy() *MongoDBOperatorConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOperatorConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOperatorConfigStatus) DeepCopyInto(out *MongoDBOperatorConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOperatorConfigStatus.
+func (in *MongoDBOperatorConfigStatus) DeepCopy() *MongoDBOperatorConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOperatorConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOpsRequest) DeepCopyInto(out *MongoDBOpsRequest) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOpsRequest.
+func (in *MongoDBOpsRequest) DeepCopy() *MongoDBOpsRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOpsRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBOpsRequest) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOpsRequestList) DeepCopyInto(out *MongoDBOpsRequestList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBOpsRequest, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOpsRequestList.
+func (in *MongoDBOpsRequestList) DeepCopy() *MongoDBOpsRequestList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOpsRequestList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBOpsRequestList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOpsRequestSpec) DeepCopyInto(out *MongoDBOpsRequestSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOpsRequestSpec.
+func (in *MongoDBOpsRequestSpec) DeepCopy() *MongoDBOpsRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOpsRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBOpsRequestStatus) DeepCopyInto(out *MongoDBOpsRequestStatus) {
+	*out = *in
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]OpsRequestStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBOpsRequestStatus.
+func (in *MongoDBOpsRequestStatus) DeepCopy() *MongoDBOpsRequestStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBOpsRequestStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBRole) DeepCopyInto(out *MongoDBRole) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBRole.
+func (in *MongoDBRole) DeepCopy() *MongoDBRole {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBSharded) DeepCopyInto(out *MongoDBSharded) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBSharded.
+func (in *MongoDBSharded) DeepCopy() *MongoDBSharded {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBSharded)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBSharded) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBShardedList) DeepCopyInto(out *MongoDBShardedList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MongoDBSharded, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBShardedList.
+func (in *MongoDBShardedList) DeepCopy() *MongoDBShardedList {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBShardedList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MongoDBShardedList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBShardedSpec) DeepCopyInto(out *MongoDBShardedSpec) {
+	*out = *in
+	out.Version = in.Version
+	in.ConfigServer.DeepCopyInto(&out.ConfigServer)
+	in.Shards.DeepCopyInto(&out.Shards)
+	in.Mongos.DeepCopyInto(&out.Mongos)
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(ShardedUpdateStrategySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Balancer != nil {
+		in, out := &in.Balancer, &out.Balancer
+		*out = new(BalancerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(MonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(BackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceMesh != nil {
+		in, out := &in.ServiceMesh, &out.ServiceMesh
+		*out = new(ServiceMeshSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalConfig != nil {
+		in, out := &in.AdditionalConfig, &out.AdditionalConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StorageEngine != nil {
+		in, out := &in.StorageEngine, &out.StorageEngine
+		*out = new(StorageEngineSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Profiling != nil {
+		in, out := &in.Profiling, &out.Profiling
+		*out = new(ProfilingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(LoggingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Telemetry != nil {
+		in, out := &in.Telemetry, &out.Telemetry
+		*out = new(TelemetrySpec)
+		**out = **in
+	}
+	if in.InitScripts != nil {
+		in, out := &in.InitScripts, &out.InitScripts
+		*out = make([]InitScript, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBShardedSpec.
+func (in *MongoDBShardedSpec) DeepCopy() *MongoDBShardedSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBShardedSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBShardedStatus) DeepCopyInto(out *MongoDBShardedStatus) {
+	*out = *in
+	out.ConfigServer = in.ConfigServer
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]ShardStatus, len(*in))
+		copy(*out, *in)
+	}
+	out.Mongos = in.Mongos
+	if in.MongosGreen != nil {
+		in, out := &in.MongosGreen, &out.MongosGreen
+		*out = new(ComponentStatus)
+		**out = **in
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]MemberStorageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastBackup != nil {
+		in, out := &in.LastBackup, &out.LastBackup
+		*out = new(BackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShardedCollections != nil {
+		in, out := &in.ShardedCollections, &out.ShardedCollections
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ShardProvisioning != nil {
+		in, out := &in.ShardProvisioning, &out.ShardProvisioning
+		*out = make(map[string]ShardProvisioningStatus, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.InitScripts != nil {
+		in, out := &in.InitScripts, &out.InitScripts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeyfileRotation != nil {
+		in, out := &in.KeyfileRotation, &out.KeyfileRotation
+		*out = new(KeyfileRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RemovingShards != nil {
+		in, out := &in.RemovingShards, &out.RemovingShards
+		*out = make([]ShardRemovalStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Balancer != nil {
+		in, out := &in.Balancer, &out.Balancer
+		*out = new(BalancerStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]AppliedShardZone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AppliedSettings != nil {
+		in, out := &in.AppliedSettings, &out.AppliedSettings
+		*out = new(AppliedSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]MemberVersionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]DriftStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBShardedStatus.
+func (in *MongoDBShardedStatus) DeepCopy() *MongoDBShardedStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBShardedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBSpec) DeepCopyInto(out *MongoDBSpec) {
+	*out = *in
+	out.Version = in.Version
+	in.Storage.DeepCopyInto(&out.Storage)
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(MonitoringSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(BackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoScaling != nil {
+		in, out := &in.AutoScaling, &out.AutoScaling
+		*out = new(AutoScalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = new(PodSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Arbiter != nil {
+		in, out := &in.Arbiter, &out.Arbiter
+		*out = new(ArbiterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MemberOverrides != nil {
+		in, out := &in.MemberOverrides, &out.MemberOverrides
+		*out = make([]MemberOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicaSetSettings != nil {
+		in, out := &in.ReplicaSetSettings, &out.ReplicaSetSettings
+		*out = new(ReplicaSetSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoResync != nil {
+		in, out := &in.AutoResync, &out.AutoResync
+		*out = new(AutoResyncSpec)
+		**out = **in
+	}
+	if in.ExternalAccess != nil {
+		in, out := &in.ExternalAccess, &out.ExternalAccess
+		*out = new(ExternalAccessSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(ServiceMetadataSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceMesh != nil {
+		in, out := &in.ServiceMesh, &out.ServiceMesh
+		*out = new(ServiceMeshSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MultiCluster != nil {
+		in, out := &in.MultiCluster, &out.MultiCluster
+		*out = new(MultiClusterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaOf != nil {
+		in, out := &in.ReplicaOf, &out.ReplicaOf
+		*out = new(ReplicaOfSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(UpdateStrategySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalConfig != nil {
+		in, out := &in.AdditionalConfig, &out.AdditionalConfig
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.StorageEngine != nil {
+		in, out := &in.StorageEngine, &out.StorageEngine
+		*out = new(StorageEngineSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Profiling != nil {
+		in, out := &in.Profiling, &out.Profiling
+		*out = new(ProfilingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(LoggingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Telemetry != nil {
+		in, out := &in.Telemetry, &out.Telemetry
+		*out = new(TelemetrySpec)
+		**out = **in
+	}
+	if in.InitScripts != nil {
+		in, out := &in.InitScripts, &out.InitScripts
+		*out = make([]InitScript, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBSpec.
+func (in *MongoDBSpec) DeepCopy() *MongoDBSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBStatus) DeepCopyInto(out *MongoDBStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]MemberStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = make([]MemberStorageStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaleMembers != nil {
+		in, out := &in.StaleMembers, &out.StaleMembers
+		*out = make([]StaleMemberStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastBackup != nil {
+		in, out := &in.LastBackup, &out.LastBackup
+		*out = new(BackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.InitScripts != nil {
+		in, out := &in.InitScripts, &out.InitScripts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.KeyfileRotation != nil {
+		in, out := &in.KeyfileRotation, &out.KeyfileRotation
+		*out = new(KeyfileRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalHosts != nil {
+		in, out := &in.ExternalHosts, &out.ExternalHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HorizonHosts != nil {
+		in, out := &in.HorizonHosts, &out.HorizonHosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ForceReconfig != nil {
+		in, out := &in.ForceReconfig, &out.ForceReconfig
+		*out = new(ForceReconfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AppliedSettings != nil {
+		in, out := &in.AppliedSettings, &out.AppliedSettings
+		*out = new(AppliedSettings)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(RolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]MemberVersionStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]DriftStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBStatus.
+func (in *MongoDBStatus) DeepCopy() *MongoDBStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBUser) DeepCopyInto(out *MongoDBUser) {
+	*out = *in
+	in.PasswordSecretRef.DeepCopyInto(&out.PasswordSecretRef)
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]MongoDBRole, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUser.
+func (in *MongoDBUser) DeepCopy() *MongoDBUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongoDBVersion) DeepCopyInto(out *MongoDBVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBVersion.
+func (in *MongoDBVersion) DeepCopy() *MongoDBVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(MongoDBVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongosBlueGreenSpec) DeepCopyInto(out *MongosBlueGreenSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongosBlueGreenSpec.
+func (in *MongosBlueGreenSpec) DeepCopy() *MongosBlueGreenSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongosBlueGreenSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongosServiceSpec) DeepCopyInto(out *MongosServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongosServiceSpec.
+func (in *MongosServiceSpec) DeepCopy() *MongosServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongosServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MongosSpec) DeepCopyInto(out *MongosSpec) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.Pod != nil {
+		in, out := &in.Pod, &out.Pod
+		*out = new(PodSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(MongosServiceSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoScaling != nil {
+		in, out := &in.AutoScaling, &out.AutoScaling
+		*out = new(AutoScalingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.BlueGreen != nil {
+		in, out := &in.BlueGreen, &out.BlueGreen
+		*out = new(MongosBlueGreenSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongosSpec.
+func (in *MongosSpec) DeepCopy() *MongosSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MongosSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
+	*out = *in
+	if in.ServiceMonitor != nil {
+		in, out := &in.ServiceMonitor, &out.ServiceMonitor
+		*out = new(ServiceMonitorSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrometheusRules != nil {
+		in, out := &in.PrometheusRules, &out.PrometheusRules
+		*out = new(PrometheusRulesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exporter != nil {
+		in, out := &in.Exporter, &out.Exporter
+		*out = new(ExporterSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
+func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonitoringSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MultiClusterSpec) DeepCopyInto(out *MultiClusterSpec) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]MemberClusterSpec, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MultiClusterSpec.
+func (in *MultiClusterSpec) DeepCopy() *MultiClusterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MultiClusterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeTuningSpec) DeepCopyInto(out *NodeTuningSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeTuningSpec.
+func (in *NodeTuningSpec) DeepCopy() *NodeTuningSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeTuningSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCProviderSpec) DeepCopyInto(out *OIDCProviderSpec) {
+	*out = *in
+	if in.UseAuthorizationClaim != nil {
+		in, out := &in.UseAuthorizationClaim, &out.UseAuthorizationClaim
+		*out = new(bool)
+		**out = **in
+	}
+	if in.RequestScopes != nil {
+		in, out := &in.RequestScopes, &out.RequestScopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCProviderSpec.
+func (in *OIDCProviderSpec) DeepCopy() *OIDCProviderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCProviderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCSpec) DeepCopyInto(out *OIDCSpec) {
+	*out = *in
+	if in.Providers != nil {
+		in, out := &in.Providers, &out.Providers
+		*out = make([]OIDCProviderSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCSpec.
+func (in *OIDCSpec) DeepCopy() *OIDCSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorImagesSpec) DeepCopyInto(out *OperatorImagesSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorImagesSpec.
+func (in *OperatorImagesSpec) DeepCopy() *OperatorImagesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorImagesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorResourcesSpec) DeepCopyInto(out *OperatorResourcesSpec) {
+	*out = *in
+	in.Mongod.DeepCopyInto(&out.Mongod)
+	in.Mongos.DeepCopyInto(&out.Mongos)
+	in.Exporter.DeepCopyInto(&out.Exporter)
+	in.Backup.DeepCopyInto(&out.Backup)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorResourcesSpec.
+func (in *OperatorResourcesSpec) DeepCopy() *OperatorResourcesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorResourcesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OplogTimestamp) DeepCopyInto(out *OplogTimestamp) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OplogTimestamp.
+func (in *OplogTimestamp) DeepCopy() *OplogTimestamp {
+	if in == nil {
+		return nil
+	}
+	out := new(OplogTimestamp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpsRequestStep) DeepCopyInto(out *OpsRequestStep) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpsRequestStep.
+func (in *OpsRequestStep) DeepCopy() *OpsRequestStep {
+	if in == nil {
+		return nil
+	}
+	out := new(OpsRequestStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCStorageSpec) DeepCopyInto(out *PVCStorageSpec) {
+	*out = *in
+	out.Size = in.Size.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCStorageSpec.
+func (in *PVCStorageSpec) DeepCopy() *PVCStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodSpec) DeepCopyInto(out *PodSpec) {
+	*out = *in
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(v1.PodSecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerSecurityContext != nil {
+		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
+		*out = new(v1.SecurityContext)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadOnlyRootFilesystem != nil {
+		in, out := &in.ReadOnlyRootFilesystem, &out.ReadOnlyRootFilesystem
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TerminationGracePeriodSeconds != nil {
+		in, out := &in.TerminationGracePeriodSeconds, &out.TerminationGracePeriodSeconds
+		*out = new(int64)
+		**out = **in
+	}
+	if in.StartupProbe != nil {
+		in, out := &in.StartupProbe, &out.StartupProbe
+		*out = new(ProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LivenessProbe != nil {
+		in, out := &in.LivenessProbe, &out.LivenessProbe
+		*out = new(ProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadinessProbe != nil {
+		in, out := &in.ReadinessProbe, &out.ReadinessProbe
+		*out = new(ProbeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutomountServiceAccountToken != nil {
+		in, out := &in.AutomountServiceAccountToken, &out.AutomountServiceAccountToken
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]v1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ZoneSpread != nil {
+		in, out := &in.ZoneSpread, &out.ZoneSpread
+		*out = new(ZoneSpreadSpec)
+		**out = **in
+	}
+	if in.NodeTuning != nil {
+		in, out := &in.NodeTuning, &out.NodeTuning
+		*out = new(NodeTuningSpec)
+		**out = **in
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Sidecars != nil {
+		in, out := &in.Sidecars, &out.Sidecars
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]v1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]v1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]v1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSpec.
+func (in *PodSpec) DeepCopy() *PodSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PodSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PresplitSpec) DeepCopyInto(out *PresplitSpec) {
+	*out = *in
+	if in.NumInitialChunks != nil {
+		in, out := &in.NumInitialChunks, &out.NumInitialChunks
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SplitPoints != nil {
+		in, out := &in.SplitPoints, &out.SplitPoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PresplitSpec.
+func (in *PresplitSpec) DeepCopy() *PresplitSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PresplitSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrimaryMoveStatus) DeepCopyInto(out *PrimaryMoveStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrimaryMoveStatus.
+func (in *PrimaryMoveStatus) DeepCopy() *PrimaryMoveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PrimaryMoveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProbeSpec) DeepCopyInto(out *ProbeSpec) {
+	*out = *in
+	if in.InitialDelaySeconds != nil {
+		in, out := &in.InitialDelaySeconds, &out.InitialDelaySeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.PeriodSeconds != nil {
+		in, out := &in.PeriodSeconds, &out.PeriodSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.TimeoutSeconds != nil {
+		in, out := &in.TimeoutSeconds, &out.TimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProbeSpec.
+func (in *ProbeSpec) DeepCopy() *ProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfilingSpec) DeepCopyInto(out *ProfilingSpec) {
+	*out = *in
+	if in.SlowOpThresholdMs != nil {
+		in, out := &in.SlowOpThresholdMs, &out.SlowOpThresholdMs
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfilingSpec.
+func (in *ProfilingSpec) DeepCopy() *ProfilingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfilingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusRulesSpec) DeepCopyInto(out *PrometheusRulesSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusRulesSpec.
+func (in *PrometheusRulesSpec) DeepCopy() *PrometheusRulesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusRulesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaOfSpec) DeepCopyInto(out *ReplicaOfSpec) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaOfSpec.
+func (in *ReplicaOfSpec) DeepCopy() *ReplicaOfSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaOfSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaSetSettings) DeepCopyInto(out *ReplicaSetSettings) {
+	*out = *in
+	if in.ElectionTimeoutMillis != nil {
+		in, out := &in.ElectionTimeoutMillis, &out.ElectionTimeoutMillis
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HeartbeatTimeoutSecs != nil {
+		in, out := &in.HeartbeatTimeoutSecs, &out.HeartbeatTimeoutSecs
+		*out = new(int32)
+		**out = **in
+	}
+	if in.CatchUpTimeoutMillis != nil {
+		in, out := &in.CatchUpTimeoutMillis, &out.CatchUpTimeoutMillis
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ChainingAllowed != nil {
+		in, out := &in.ChainingAllowed, &out.ChainingAllowed
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaSetSettings.
+func (in *ReplicaSetSettings) DeepCopy() *ReplicaSetSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaSetSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourcesSpec) DeepCopyInto(out *ResourcesSpec) {
+	*out = *in
+	if in.Requests != nil {
+		in, out := &in.Requests, &out.Requests
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = make(v1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcesSpec.
+func (in *ResourcesSpec) DeepCopy() *ResourcesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourcesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetentionSpec) DeepCopyInto(out *RetentionSpec) {
+	*out = *in
+	if in.Count != nil {
+		in, out := &in.Count, &out.Count
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionSpec.
+func (in *RetentionSpec) DeepCopy() *RetentionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RetentionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingIndexBuildStatus) DeepCopyInto(out *RollingIndexBuildStatus) {
+	*out = *in
+	if in.Indexes != nil {
+		in, out := &in.Indexes, &out.Indexes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.CompletedMembers != nil {
+		in, out := &in.CompletedMembers, &out.CompletedMembers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingIndexBuildStatus.
+func (in *RollingIndexBuildStatus) DeepCopy() *RollingIndexBuildStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingIndexBuildStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutStatus) DeepCopyInto(out *RolloutStatus) {
+	*out = *in
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CanaryReadyAt != nil {
+		in, out := &in.CanaryReadyAt, &out.CanaryReadyAt
+		*out = new(metav1.Time)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutStatus.
+func (in *RolloutStatus) DeepCopy() *RolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3ServerSideEncryptionSpec) DeepCopyInto(out *S3ServerSideEncryptionSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3ServerSideEncryptionSpec.
+func (in *S3ServerSideEncryptionSpec) DeepCopy() *S3ServerSideEncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3ServerSideEncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3StorageSpec) DeepCopyInto(out *S3StorageSpec) {
+	*out = *in
+	out.CredentialsRef = in.CredentialsRef
+	if in.CASecretRef != nil {
+		in, out := &in.CASecretRef, &out.CASecretRef
+		*out = new(v1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServerSideEncryption != nil {
+		in, out := &in.ServerSideEncryption, &out.ServerSideEncryption
+		*out = new(S3ServerSideEncryptionSpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3StorageSpec.
+func (in *S3StorageSpec) DeepCopy() *S3StorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3StorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMeshSpec) DeepCopyInto(out *ServiceMeshSpec) {
+	*out = *in
+	if in.InjectSidecar != nil {
+		in, out := &in.InjectSidecar, &out.InjectSidecar
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMeshSpec.
+func (in *ServiceMeshSpec) DeepCopy() *ServiceMeshSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMeshSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMetadataSpec) DeepCopyInto(out *ServiceMetadataSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMetadataSpec.
+func (in *ServiceMetadataSpec) DeepCopy() *ServiceMetadataSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMetadataSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMonitorSpec) DeepCopyInto(out *ServiceMonitorSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMonitorSpec.
+func (in *ServiceMonitorSpec) DeepCopy() *ServiceMonitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMonitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardAutoScalingSpec) DeepCopyInto(out *ShardAutoScalingSpec) {
+	*out = *in
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]AutoScalingMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardAutoScalingSpec.
+func (in *ShardAutoScalingSpec) DeepCopy() *ShardAutoScalingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardAutoScalingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardIsolationSpec) DeepCopyInto(out *ShardIsolationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardIsolationSpec.
+func (in *ShardIsolationSpec) DeepCopy() *ShardIsolationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardIsolationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardKeyField) DeepCopyInto(out *ShardKeyField) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardKeyField.
+func (in *ShardKeyField) DeepCopy() *ShardKeyField {
+	if in == nil {
+		return nil
+	}
+	out := new(ShardKeyField)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShardPlacement) DeepCopyInto(out *ShardPlacement) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(v1.Affinity)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBUser.
-func (in *MongoDBUser) DeepCopy() *MongoDBUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardPlacement.
+func (in *ShardPlacement) DeepCopy() *ShardPlacement {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBUser)
+	out := new(ShardPlacement)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongoDBVersion) DeepCopyInto(out *MongoDBVersion) {
+func (in *ShardProvisioningStatus) DeepCopyInto(out *ShardProvisioningStatus) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongoDBVersion.
-func (in *MongoDBVersion) DeepCopy() *MongoDBVersion {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardProvisioningStatus.
+func (in *ShardProvisioningStatus) DeepCopy() *ShardProvisioningStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongoDBVersion)
+	out := new(ShardProvisioningStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongosServiceSpec) DeepCopyInto(out *MongosServiceSpec) {
+func (in *ShardRemovalStatus) DeepCopyInto(out *ShardRemovalStatus) {
 	*out = *in
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
+	if in.DatabasesToMove != nil {
+		in, out := &in.DatabasesToMove, &out.DatabasesToMove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PrimaryMoves != nil {
+		in, out := &in.PrimaryMoves, &out.PrimaryMoves
+		*out = make([]PrimaryMoveStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongosServiceSpec.
-func (in *MongosServiceSpec) DeepCopy() *MongosServiceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardRemovalStatus.
+func (in *ShardRemovalStatus) DeepCopy() *ShardRemovalStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MongosServiceSpec)
+	out := new(ShardRemovalStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MongosSpec) DeepCopyInto(out *MongosSpec) {
+func (in *ShardSpec) DeepCopyInto(out *ShardSpec) {
 	*out = *in
+	in.Storage.DeepCopyInto(&out.Storage)
 	in.Resources.DeepCopyInto(&out.Resources)
 	if in.Pod != nil {
 		in, out := &in.Pod, &out.Pod
@@ -800,96 +3034,67 @@ func (in *MongosSpec) DeepCopyInto(out *MongosSpec) {
 	}
 	if in.Service != nil {
 		in, out := &in.Service, &out.Service
-		*out = new(MongosServiceSpec)
+		*out = new(ServiceMetadataSpec)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.AutoScaling != nil {
 		in, out := &in.AutoScaling, &out.AutoScaling
-		*out = new(AutoScalingSpec)
+		*out = new(ShardAutoScalingSpec)
 		(*in).DeepCopyInto(*out)
 	}
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MongosSpec.
-func (in *MongosSpec) DeepCopy() *MongosSpec {
-	if in == nil {
-		return nil
-	}
-	out := new(MongosSpec)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
-	*out = *in
-	if in.ServiceMonitor != nil {
-		in, out := &in.ServiceMonitor, &out.ServiceMonitor
-		*out = new(ServiceMonitorSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]ShardZone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.PrometheusRules != nil {
-		in, out := &in.PrometheusRules, &out.PrometheusRules
-		*out = new(PrometheusRulesSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = make([]ShardPlacement, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	if in.Exporter != nil {
-		in, out := &in.Exporter, &out.Exporter
-		*out = new(ExporterSpec)
-		(*in).DeepCopyInto(*out)
+	if in.Isolation != nil {
+		in, out := &in.Isolation, &out.Isolation
+		*out = new(ShardIsolationSpec)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonitoringSpec.
-func (in *MonitoringSpec) DeepCopy() *MonitoringSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardSpec.
+func (in *ShardSpec) DeepCopy() *ShardSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(MonitoringSpec)
+	out := new(ShardSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PVCStorageSpec) DeepCopyInto(out *PVCStorageSpec) {
+func (in *ShardStatus) DeepCopyInto(out *ShardStatus) {
 	*out = *in
-	out.Size = in.Size.DeepCopy()
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCStorageSpec.
-func (in *PVCStorageSpec) DeepCopy() *PVCStorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardStatus.
+func (in *ShardStatus) DeepCopy() *ShardStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(PVCStorageSpec)
+	out := new(ShardStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodSpec) DeepCopyInto(out *PodSpec) {
+func (in *ShardZone) DeepCopyInto(out *ShardZone) {
 	*out = *in
-	if in.SecurityContext != nil {
-		in, out := &in.SecurityContext, &out.SecurityContext
-		*out = new(v1.PodSecurityContext)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.ContainerSecurityContext != nil {
-		in, out := &in.ContainerSecurityContext, &out.ContainerSecurityContext
-		*out = new(v1.SecurityContext)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Affinity != nil {
-		in, out := &in.Affinity, &out.Affinity
-		*out = new(v1.Affinity)
-		(*in).DeepCopyInto(*out)
-	}
-	if in.Tolerations != nil {
-		in, out := &in.Tolerations, &out.Tolerations
-		*out = make([]v1.Toleration, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
 	}
 	if in.NodeSelector != nil {
 		in, out := &in.NodeSelector, &out.NodeSelector
@@ -898,235 +3103,236 @@ func (in *PodSpec) DeepCopyInto(out *PodSpec) {
 			(*out)[key] = val
 		}
 	}
-	if in.TopologySpreadConstraints != nil {
-		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
-		*out = make([]v1.TopologySpreadConstraint, len(*in))
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]v1.Toleration, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Ranges != nil {
+		in, out := &in.Ranges, &out.Ranges
+		*out = make([]ZoneKeyRange, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodSpec.
-func (in *PodSpec) DeepCopy() *PodSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardZone.
+func (in *ShardZone) DeepCopy() *ShardZone {
 	if in == nil {
 		return nil
 	}
-	out := new(PodSpec)
+	out := new(ShardZone)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PrometheusRulesSpec) DeepCopyInto(out *PrometheusRulesSpec) {
+func (in *ShardedUpdateStrategySpec) DeepCopyInto(out *ShardedUpdateStrategySpec) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.MaxLagSeconds != nil {
+		in, out := &in.MaxLagSeconds, &out.MaxLagSeconds
+		*out = new(int32)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusRulesSpec.
-func (in *PrometheusRulesSpec) DeepCopy() *PrometheusRulesSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardedUpdateStrategySpec.
+func (in *ShardedUpdateStrategySpec) DeepCopy() *ShardedUpdateStrategySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PrometheusRulesSpec)
+	out := new(ShardedUpdateStrategySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ResourcesSpec) DeepCopyInto(out *ResourcesSpec) {
+func (in *StaleMemberStatus) DeepCopyInto(out *StaleMemberStatus) {
 	*out = *in
-	if in.Requests != nil {
-		in, out := &in.Requests, &out.Requests
-		*out = make(v1.ResourceList, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val.DeepCopy()
-		}
-	}
-	if in.Limits != nil {
-		in, out := &in.Limits, &out.Limits
-		*out = make(v1.ResourceList, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val.DeepCopy()
-		}
+	in.Since.DeepCopyInto(&out.Since)
+	if in.ResyncStartedAt != nil {
+		in, out := &in.ResyncStartedAt, &out.ResyncStartedAt
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourcesSpec.
-func (in *ResourcesSpec) DeepCopy() *ResourcesSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaleMemberStatus.
+func (in *StaleMemberStatus) DeepCopy() *StaleMemberStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ResourcesSpec)
+	out := new(StaleMemberStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RetentionSpec) DeepCopyInto(out *RetentionSpec) {
-	*out = *in
-	if in.Count != nil {
-		in, out := &in.Count, &out.Count
-		*out = new(int)
-		**out = **in
+func (in *StorageEngineSpec) DeepCopyInto(out *StorageEngineSpec) {
+	if in.Journal != nil {
+		in, out := &in.Journal, &out.Journal
+		*out = new(JournalSpec)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetentionSpec.
-func (in *RetentionSpec) DeepCopy() *RetentionSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageEngineSpec.
+func (in *StorageEngineSpec) DeepCopy() *StorageEngineSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(RetentionSpec)
+	out := new(StorageEngineSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *S3StorageSpec) DeepCopyInto(out *S3StorageSpec) {
+func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
 	*out = *in
-	out.CredentialsRef = in.CredentialsRef
+	out.Size = in.Size.DeepCopy()
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3StorageSpec.
-func (in *S3StorageSpec) DeepCopy() *S3StorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageSpec.
+func (in *StorageSpec) DeepCopy() *StorageSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(S3StorageSpec)
+	out := new(StorageSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServiceMonitorSpec) DeepCopyInto(out *ServiceMonitorSpec) {
+func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(CertManagerSpec)
+		**out = **in
+	}
+	if in.CustomCert != nil {
+		in, out := &in.CustomCert, &out.CustomCert
+		*out = new(CustomCertSpec)
+		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMonitorSpec.
-func (in *ServiceMonitorSpec) DeepCopy() *ServiceMonitorSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
+func (in *TLSSpec) DeepCopy() *TLSSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ServiceMonitorSpec)
+	out := new(TLSSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ShardAutoScalingSpec) DeepCopyInto(out *ShardAutoScalingSpec) {
+func (in *TelemetrySpec) DeepCopyInto(out *TelemetrySpec) {
 	*out = *in
-	if in.Metrics != nil {
-		in, out := &in.Metrics, &out.Metrics
-		*out = make([]AutoScalingMetric, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelemetrySpec.
+func (in *TelemetrySpec) DeepCopy() *TelemetrySpec {
+	if in == nil {
+		return nil
 	}
+	out := new(TelemetrySpec)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardAutoScalingSpec.
-func (in *ShardAutoScalingSpec) DeepCopy() *ShardAutoScalingSpec {
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeSeriesSpec) DeepCopyInto(out *TimeSeriesSpec) {
+	*out = *in
+	if in.ExpireAfterSeconds != nil {
+		in, out := &in.ExpireAfterSeconds, &out.ExpireAfterSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeSeriesSpec.
+func (in *TimeSeriesSpec) DeepCopy() *TimeSeriesSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ShardAutoScalingSpec)
+	out := new(TimeSeriesSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ShardSpec) DeepCopyInto(out *ShardSpec) {
+func (in *UpdateStrategySpec) DeepCopyInto(out *UpdateStrategySpec) {
 	*out = *in
-	in.Storage.DeepCopyInto(&out.Storage)
-	in.Resources.DeepCopyInto(&out.Resources)
-	if in.Pod != nil {
-		in, out := &in.Pod, &out.Pod
-		*out = new(PodSpec)
-		(*in).DeepCopyInto(*out)
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
 	}
-	if in.AutoScaling != nil {
-		in, out := &in.AutoScaling, &out.AutoScaling
-		*out = new(ShardAutoScalingSpec)
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryUpdateSpec)
 		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardSpec.
-func (in *ShardSpec) DeepCopy() *ShardSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategySpec.
+func (in *UpdateStrategySpec) DeepCopy() *UpdateStrategySpec {
 	if in == nil {
 		return nil
 	}
-	out := new(ShardSpec)
+	out := new(UpdateStrategySpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ShardStatus) DeepCopyInto(out *ShardStatus) {
+func (in *ZoneKeyRange) DeepCopyInto(out *ZoneKeyRange) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShardStatus.
-func (in *ShardStatus) DeepCopy() *ShardStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneKeyRange.
+func (in *ZoneKeyRange) DeepCopy() *ZoneKeyRange {
 	if in == nil {
 		return nil
 	}
-	out := new(ShardStatus)
+	out := new(ZoneKeyRange)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *StorageSpec) DeepCopyInto(out *StorageSpec) {
+func (in *ZoneRange) DeepCopyInto(out *ZoneRange) {
 	*out = *in
-	out.Size = in.Size.DeepCopy()
+	if in.Shards != nil {
+		in, out := &in.Shards, &out.Shards
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageSpec.
-func (in *StorageSpec) DeepCopy() *StorageSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneRange.
+func (in *ZoneRange) DeepCopy() *ZoneRange {
 	if in == nil {
 		return nil
 	}
-	out := new(StorageSpec)
+	out := new(ZoneRange)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
+func (in *ZoneSpreadSpec) DeepCopyInto(out *ZoneSpreadSpec) {
 	*out = *in
-	if in.CertManager != nil {
-		in, out := &in.CertManager, &out.CertManager
-		*out = new(CertManagerSpec)
-		**out = **in
-	}
-	if in.CustomCert != nil {
-		in, out := &in.CustomCert, &out.CustomCert
-		*out = new(CustomCertSpec)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
-func (in *TLSSpec) DeepCopy() *TLSSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZoneSpreadSpec.
+func (in *ZoneSpreadSpec) DeepCopy() *ZoneSpreadSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(TLSSpec)
+	out := new(ZoneSpreadSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,380 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// backupCompletedPrefix starts the line the backup Jobs log with the name of the archive
+const backupCompletedPrefix = "Backup completed: "
+
+func runBackup(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	name := fs.String("name", "", "Name of the MongoDBBackup, CLUSTER-<timestamp> when empty")
+	from := fs.String("from", "", "Existing MongoDBBackup whose storage and compression the backup reuses")
+	s3 := mongodbv1alpha1.S3StorageSpec{}
+	fs.StringVar(&s3.Bucket, "s3-bucket", "", "S3 bucket of the backup")
+	fs.StringVar(&s3.Endpoint, "s3-endpoint", "", "S3 endpoint URL")
+	fs.StringVar(&s3.Region, "s3-region", "", "S3 region")
+	fs.StringVar(&s3.Prefix, "s3-prefix", "", "Key prefix of the backup archive")
+	fs.StringVar(&s3.CredentialsRef.Name, "s3-credentials", "", "Secret with the access-key and secret-key of the bucket")
+	incremental := fs.Bool("incremental", false, "Back up the oplog written since the latest backup of the replica set in the same storage")
+	waitFor := fs.Bool("wait", false, "Wait until the backup completes or fails")
+	timeout := fs.Duration("timeout", time.Hour, "How long --wait waits")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb backup CLUSTER (--from BACKUP | --s3-bucket BUCKET --s3-credentials SECRET) [flags]")
+		fs.PrintDefaults()
+	}
+	positional, _, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	clusterName, err := singleArg(positional, "cluster name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	c, err := s.getCluster(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+
+	backup := &mongodbv1alpha1.MongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      *name,
+			Namespace: s.namespace,
+		},
+		Spec: mongodbv1alpha1.MongoDBBackupSpec{
+			ClusterRef: mongodbv1alpha1.ClusterReference{Name: c.name(), Kind: c.kind()},
+		},
+	}
+	if backup.Name == "" {
+		backup.Name = fmt.Sprintf("%s-%s", c.name(), time.Now().UTC().Format("20060102-150405"))
+	}
+
+	switch {
+	case *from != "" && s3.Bucket != "":
+		return fmt.Errorf("--from and --s3-bucket are mutually exclusive")
+	case *from != "":
+		source := &mongodbv1alpha1.MongoDBBackup{}
+		if err := s.client.Get(ctx, client.ObjectKey{Name: *from, Namespace: s.namespace}, source); err != nil {
+			return fmt.Errorf("failed to get MongoDBBackup %s: %w", *from, err)
+		}
+		backup.Spec.Storage = *source.Spec.Storage.DeepCopy()
+		backup.Spec.Type = source.Spec.Type
+		backup.Spec.Compression = source.Spec.Compression
+		backup.Spec.CompressionType = source.Spec.CompressionType
+	case s3.Bucket != "":
+		if s3.CredentialsRef.Name == "" {
+			return fmt.Errorf("--s3-credentials is required with --s3-bucket")
+		}
+		backup.Spec.Storage = mongodbv1alpha1.BackupStorageSpec{Type: "s3", S3: &s3}
+	default:
+		return fmt.Errorf("either --from or --s3-bucket is required")
+	}
+	if *incremental {
+		backup.Spec.Type = mongodbv1alpha1.BackupTypeIncremental
+	}
+
+	if err := s.client.Create(ctx, backup); err != nil {
+		return fmt.Errorf("failed to create MongoDBBackup %s: %w", backup.Name, err)
+	}
+	fmt.Printf("mongodbbackup/%s created\n", backup.Name)
+	if !*waitFor {
+		return nil
+	}
+	return s.waitForBackup(ctx, backup, *timeout)
+}
+
+func runBackups(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("backups", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	asJSON := fs.Bool("json", false, "Print the catalog as JSON, as the operator publishes it in the CLUSTER-backup-catalog ConfigMap")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb backups CLUSTER [flags]")
+		fmt.Fprintln(fs.Output(), "Lists the completed backups of a cluster, the targets of kubectl mongodb restore.")
+		fs.PrintDefaults()
+	}
+	positional, _, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	clusterName, err := singleArg(positional, "cluster name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	c, err := s.getCluster(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	backupList := &mongodbv1alpha1.MongoDBBackupList{}
+	if err := s.client.List(ctx, backupList, client.InNamespace(s.namespace)); err != nil {
+		return fmt.Errorf("failed to list MongoDBBackups: %w", err)
+	}
+	catalog := resources.BuildBackupCatalog(c.kind(), c.name(), backupList.Items)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(catalog)
+	}
+	if len(catalog.Backups) == 0 {
+		fmt.Printf("No completed backups of %s %s\n", c.kind(), c.name())
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tPARENT\tSIZE\tGENERATION\tCOMPLETED\tOPLOG END")
+	for _, entry := range catalog.Backups {
+		completed := "<none>"
+		if entry.CompletionTime != nil {
+			completed = entry.CompletionTime.UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", entry.Name, entry.Type, valueOrNone(entry.ParentBackup),
+			valueOrNone(entry.Size), entry.ClusterGeneration, completed, formatOplogTimestamp(entry.OplogEnd))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(catalog.Coverage) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println("Restore coverage, restores reach the end of a backup of the chain:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FULL BACKUP\tLAST BACKUP\tFROM\tTO")
+	for _, coverage := range catalog.Coverage {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", coverage.FullBackup, coverage.LastBackup,
+			formatOplogTimestamp(coverage.From), formatOplogTimestamp(coverage.To))
+	}
+	return w.Flush()
+}
+
+// formatOplogTimestamp prints an oplog timestamp as the time of its second
+func formatOplogTimestamp(ts *mongodbv1alpha1.OplogTimestamp) string {
+	if ts == nil {
+		return "<none>"
+	}
+	return time.Unix(ts.T, 0).UTC().Format(time.RFC3339)
+}
+
+// waitForBackup polls a MongoDBBackup until it completes or fails
+func (s *session) waitForBackup(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup, timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := s.client.Get(ctx, client.ObjectKeyFromObject(backup), backup); err != nil {
+			return false, err
+		}
+		return backup.Status.Phase == "Completed" || backup.Status.Phase == "Failed", nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for MongoDBBackup %s: %w", backup.Name, err)
+	}
+	if backup.Status.Phase == "Failed" {
+		return fmt.Errorf("backup %s failed: %s", backup.Name, backup.Status.Error)
+	}
+	fmt.Printf("mongodbbackup/%s completed\n", backup.Name)
+	return nil
+}
+
+func runRestore(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	archive := fs.String("archive", "", "Object key of the archive below the S3 prefix, read from the status or the logs of the backup Job when empty")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation")
+	waitFor := fs.Bool("wait", false, "Wait until the restore Job completes or fails")
+	timeout := fs.Duration("timeout", time.Hour, "How long --wait waits")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb restore BACKUP [flags]")
+		fmt.Fprintln(fs.Output(), "Restores an S3 backup into its cluster, dropping the collections of the archive first.")
+		fmt.Fprintln(fs.Output(), "Incremental backups restore the full backup of their chain, then replay the oplog of every incremental backup up to BACKUP.")
+		fs.PrintDefaults()
+	}
+	positional, _, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	backupName, err := singleArg(positional, "backup name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	backup := &mongodbv1alpha1.MongoDBBackup{}
+	if err := s.client.Get(ctx, client.ObjectKey{Name: backupName, Namespace: s.namespace}, backup); err != nil {
+		return fmt.Errorf("failed to get MongoDBBackup %s: %w", backupName, err)
+	}
+	if backup.Status.Phase != "Completed" {
+		return fmt.Errorf("backup %s is %s, only completed backups can be restored", backup.Name, valueOrNone(backup.Status.Phase))
+	}
+
+	// The Job reads the admin URI from the connection secret, make sure it is there
+	if _, err := s.getSecret(ctx, resources.ConnectionSecretName(backup.Spec.ClusterRef.Name)); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-restore-%s", backup.Name, time.Now().UTC().Format("20060102-150405"))
+	var job *batchv1.Job
+	var question string
+	if backup.Status.ParentBackup != "" {
+		if *archive != "" {
+			return fmt.Errorf("--archive cannot be used with incremental backups, their chain names the archives")
+		}
+		chain, err := s.backupChain(ctx, backup)
+		if err != nil {
+			return err
+		}
+		if job, err = resources.BuildRestoreChainJob(name, chain); err != nil {
+			return err
+		}
+		question = fmt.Sprintf("Restore full backup %s and replay %d incremental backups up to %s into %s %s, dropping the collections it contains?",
+			chain[0].Name, len(chain)-1, backup.Name, backup.Spec.ClusterRef.Kind, backup.Spec.ClusterRef.Name)
+	} else {
+		if *archive == "" {
+			*archive = backup.Status.Archive
+		}
+		if *archive == "" {
+			if *archive, err = s.backupArchive(ctx, backup); err != nil {
+				return err
+			}
+		}
+		if job, err = resources.BuildRestoreJob(name, backup, *archive); err != nil {
+			return err
+		}
+		question = fmt.Sprintf("Restore %s into %s %s, dropping the collections it contains?",
+			*archive, backup.Spec.ClusterRef.Kind, backup.Spec.ClusterRef.Name)
+	}
+
+	if !*yes && !confirm(question) {
+		return fmt.Errorf("restore cancelled")
+	}
+	if err := s.client.Create(ctx, job); err != nil {
+		return fmt.Errorf("failed to create restore Job %s: %w", job.Name, err)
+	}
+	fmt.Printf("job/%s created\n", job.Name)
+	if !*waitFor {
+		return nil
+	}
+	return s.waitForJob(ctx, job, *timeout)
+}
+
+// backupArchive returns the archive of a backup from the logs of its Job, which are gone once
+// the Job is deleted
+func (s *session) backupArchive(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (string, error) {
+	podList := &corev1.PodList{}
+	if err := s.client.List(ctx, podList, client.InNamespace(s.namespace), client.MatchingLabels{"job-name": backup.Name}); err != nil {
+		return "", fmt.Errorf("failed to list the pods of the backup Job: %w", err)
+	}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != corev1.PodSucceeded {
+			continue
+		}
+		logs, err := s.clientset.CoreV1().Pods(s.namespace).GetLogs(pod.Name, &corev1.PodLogOptions{}).Do(ctx).Raw()
+		if err != nil {
+			return "", fmt.Errorf("failed to get the logs of %s: %w", pod.Name, err)
+		}
+		for _, line := range strings.Split(string(logs), "\n") {
+			if name, ok := strings.CutPrefix(line, backupCompletedPrefix); ok {
+				return strings.TrimSpace(name) + resources.BackupArchiveExtension(backup), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("the logs of the backup Job of %s are gone, pass the object key of the archive with --archive", backup.Name)
+}
+
+// backupChain returns the backups an incremental backup restores, from the full backup it chains
+// to through its parents up to the backup itself
+func (s *session) backupChain(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) ([]*mongodbv1alpha1.MongoDBBackup, error) {
+	chain := []*mongodbv1alpha1.MongoDBBackup{backup}
+	seen := map[string]bool{backup.Name: true}
+	for parentName := backup.Status.ParentBackup; parentName != ""; {
+		if seen[parentName] {
+			return nil, fmt.Errorf("the backup chain of %s loops at %s", backup.Name, parentName)
+		}
+		seen[parentName] = true
+
+		parent := &mongodbv1alpha1.MongoDBBackup{}
+		if err := s.client.Get(ctx, client.ObjectKey{Name: parentName, Namespace: s.namespace}, parent); err != nil {
+			return nil, fmt.Errorf("failed to get MongoDBBackup %s of the chain of %s: %w", parentName, backup.Name, err)
+		}
+		if parent.Status.Phase != "Completed" {
+			return nil, fmt.Errorf("backup %s of the chain of %s is %s", parent.Name, backup.Name, valueOrNone(parent.Status.Phase))
+		}
+		chain = append([]*mongodbv1alpha1.MongoDBBackup{parent}, chain...)
+		parentName = parent.Status.ParentBackup
+	}
+	return chain, nil
+}
+
+// waitForJob polls a Job until it succeeds or fails
+func (s *session) waitForJob(ctx context.Context, job *batchv1.Job, timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(ctx, 5*time.Second, timeout, true, func(ctx context.Context) (bool, error) {
+		if err := s.client.Get(ctx, client.ObjectKeyFromObject(job), job); err != nil {
+			return false, err
+		}
+		return job.Status.Succeeded > 0 || job.Status.Failed > 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to wait for Job %s: %w", job.Name, err)
+	}
+	if job.Status.Failed > 0 {
+		return fmt.Errorf("job %s failed, see kubectl logs -n %s job/%s", job.Name, s.namespace, job.Name)
+	}
+	fmt.Printf("job/%s completed\n", job.Name)
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// diagnosticCommands are the mongosh commands whose output the bundle stores per member
+var diagnosticCommands = []struct {
+	file    string
+	command string
+	mongos  bool
+	mongod  bool
+}{
+	{file: "rs-status.json", command: "EJSON.stringify(rs.status(), null, 2, {relaxed: true})", mongod: true},
+	{file: "server-status.json", command: "EJSON.stringify(db.adminCommand({serverStatus: 1}), null, 2, {relaxed: true})", mongod: true, mongos: true},
+	{file: "sh-status.txt", command: "sh.status()", mongos: true},
+}
+
+func runBundle(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("bundle", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	output := fs.String("output", "", "Path of the tarball, CLUSTER-bundle-<timestamp>.tar.gz when empty")
+	fs.StringVar(output, "o", "", "Shorthand for --output")
+	operatorNamespace := fs.String("operator-namespace", "", "Namespace of the operator, every namespace when empty")
+	operatorSelector := fs.String("operator-selector", "app.kubernetes.io/name=mongodb-operator,app.kubernetes.io/component=controller-manager",
+		"Label selector of the operator pods")
+	since := fs.Duration("since", time.Hour, "How far back the operator logs are collected")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb bundle CLUSTER [flags]")
+		fmt.Fprintln(fs.Output(), "Collects the resources, events, replica set and server status and the operator logs of a")
+		fmt.Fprintln(fs.Output(), "cluster into a tarball to attach to issue reports. Secrets are never collected.")
+		fs.PrintDefaults()
+	}
+	positional, _, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleArg(positional, "cluster name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	c, err := s.getCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if *output == "" {
+		*output = fmt.Sprintf("%s-bundle-%s.tar.gz", name, now.Format("20060102-150405"))
+	}
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("failed to create the bundle: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+	b := &bundle{dir: fmt.Sprintf("%s-bundle-%s", name, now.Format("20060102-150405")), now: now}
+	if err := s.writeBundle(ctx, file, b, c, *operatorNamespace, *operatorSelector, *since); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+	fmt.Printf("Bundle written to %s\n", *output)
+	if len(b.errors) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d items could not be collected, see errors.txt in the bundle\n", len(b.errors))
+	}
+	return nil
+}
+
+// writeBundle collects the bundle of a cluster and writes it to w as a gzipped tarball
+func (s *session) writeBundle(ctx context.Context, w io.Writer, b *bundle, c *cluster, operatorNamespace, operatorSelector string, since time.Duration) error {
+	gz := gzip.NewWriter(w)
+	b.tw = tar.NewWriter(gz)
+
+	s.collectCluster(ctx, b, c)
+	s.collectWorkloads(ctx, b, c)
+	s.collectDiagnostics(ctx, b, c)
+	s.collectOperatorLogs(ctx, b, c, operatorNamespace, operatorSelector, since)
+	if len(b.errors) > 0 {
+		b.add("errors.txt", []byte(strings.Join(b.errors, "\n")+"\n"))
+	}
+
+	if err := b.tw.Close(); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write the bundle: %w", err)
+	}
+	return nil
+}
+
+// bundle writes the files of a support bundle below dir of a tarball. Items that cannot be
+// collected are recorded in errors.txt rather than failing the whole bundle.
+type bundle struct {
+	tw     *tar.Writer
+	dir    string
+	now    time.Time
+	errors []string
+}
+
+func (b *bundle) add(name string, data []byte) {
+	header := &tar.Header{
+		Name:    path.Join(b.dir, name),
+		Mode:    0o644,
+		Size:    int64(len(data)),
+		ModTime: b.now,
+	}
+	if err := b.tw.WriteHeader(header); err != nil {
+		b.fail(name, err)
+		return
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		b.fail(name, err)
+	}
+}
+
+// addObject adds an object as YAML, without its managed fields
+func (b *bundle) addObject(name string, obj client.Object) {
+	obj.SetManagedFields(nil)
+	if gvk, err := apiutil.GVKForObject(obj, scheme); err == nil {
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		b.fail(name, err)
+		return
+	}
+	b.add(name, data)
+}
+
+func (b *bundle) fail(item string, err error) {
+	b.errors = append(b.errors, fmt.Sprintf("%s: %v", item, err))
+}
+
+// collectCluster adds the custom resource, its conditions and the events of the cluster
+func (s *session) collectCluster(ctx context.Context, b *bundle, c *cluster) {
+	kind := strings.ToLower(c.kind())
+	b.addObject(kind+".yaml", c.object().DeepCopyObject().(client.Object))
+
+	var conditions bytes.Buffer
+	w := tabwriter.NewWriter(&conditions, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tSTATUS\tREASON\tLAST TRANSITION\tMESSAGE")
+	for _, condition := range c.conditions() {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", condition.Type, condition.Status, condition.Reason,
+			condition.LastTransitionTime.UTC().Format(time.RFC3339), condition.Message)
+	}
+	_ = w.Flush()
+	b.add("conditions.txt", conditions.Bytes())
+
+	eventList := &corev1.EventList{}
+	if err := s.client.List(ctx, eventList, client.InNamespace(s.namespace)); err != nil {
+		b.fail("events.txt", err)
+		return
+	}
+	// The events of the custom resource and of the objects named after it
+	var events []corev1.Event
+	for _, event := range eventList.Items {
+		if involved := event.InvolvedObject.Name; involved == c.name() || strings.HasPrefix(involved, c.name()+"-") {
+			events = append(events, event)
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+
+	var out bytes.Buffer
+	w = tabwriter.NewWriter(&out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LAST SEEN\tTYPE\tREASON\tOBJECT\tCOUNT\tMESSAGE")
+	for _, event := range events {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s/%s\t%d\t%s\n", eventTime(&event).UTC().Format(time.RFC3339), event.Type, event.Reason,
+			strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name, event.Count, event.Message)
+	}
+	_ = w.Flush()
+	b.add("events.txt", out.Bytes())
+}
+
+// eventTime returns when an event was last seen
+func eventTime(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+// collectWorkloads adds the StatefulSets, Deployments, Jobs, Pods and PersistentVolumeClaims
+// labelled with the cluster
+func (s *session) collectWorkloads(ctx context.Context, b *bundle, c *cluster) {
+	lists := map[string]client.ObjectList{
+		"statefulsets":           &appsv1.StatefulSetList{},
+		"deployments":            &appsv1.DeploymentList{},
+		"jobs":                   &batchv1.JobList{},
+		"pods":                   &corev1.PodList{},
+		"persistentvolumeclaims": &corev1.PersistentVolumeClaimList{},
+	}
+	for dir, list := range lists {
+		if err := s.client.List(ctx, list, client.InNamespace(s.namespace), client.MatchingLabels{"app.kubernetes.io/instance": c.name()}); err != nil {
+			b.fail(dir, err)
+			continue
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			b.fail(dir, err)
+			continue
+		}
+		for _, item := range items {
+			obj := item.(client.Object)
+			b.addObject(path.Join(dir, obj.GetName()+".yaml"), obj)
+		}
+	}
+}
+
+// collectDiagnostics adds the output of the diagnostic commands of every running mongod and
+// mongos, authenticated with the keyfile
+func (s *session) collectDiagnostics(ctx context.Context, b *bundle, c *cluster) {
+	keyfile, err := s.getKeyfile(ctx, c)
+	if err != nil {
+		b.fail("mongodb", err)
+		return
+	}
+	exec, err := s.mongoExecutor()
+	if err != nil {
+		b.fail("mongodb", err)
+		return
+	}
+	pods, err := s.listPods(ctx, c.name(), "")
+	if err != nil {
+		b.fail("mongodb", err)
+		return
+	}
+
+	for _, pod := range pods {
+		container := serverContainer(&pod)
+		if container == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		port := memberPort(c, pod.Labels["app.kubernetes.io/component"])
+		for _, diagnostic := range diagnosticCommands {
+			if (container == "mongos" && !diagnostic.mongos) || (container == "mongodb" && !diagnostic.mongod) {
+				continue
+			}
+			name := path.Join("mongodb", pod.Name, diagnostic.file)
+			result, err := exec.QueryMongoshWithAuthInContainer(ctx, pod.Name, s.namespace, container,
+				"__system", strings.TrimSpace(keyfile), "local", diagnostic.command, port)
+			if err != nil {
+				b.fail(name, err)
+				continue
+			}
+			if result.ExitCode != 0 {
+				b.fail(name, fmt.Errorf("exit code %d: %s", result.ExitCode, strings.TrimSpace(result.Stderr)))
+				continue
+			}
+			b.add(name, []byte(result.Stdout))
+		}
+	}
+}
+
+// memberPort returns the port of the members of a cluster component
+func memberPort(c *cluster, component string) int {
+	switch {
+	case c.replicaSet != nil:
+		return int(resources.ReplicaSetPort(c.replicaSet))
+	case component == "configsvr":
+		return 27019
+	case component == "mongos":
+		return 27017
+	default:
+		return 27018
+	}
+}
+
+// collectOperatorLogs adds the lines of the operator logs mentioning the cluster
+func (s *session) collectOperatorLogs(ctx context.Context, b *bundle, c *cluster, namespace, selector string, since time.Duration) {
+	podList := &corev1.PodList{}
+	opts := []client.ListOption{client.InNamespace(namespace)}
+	if selector != "" {
+		sel, err := labels.Parse(selector)
+		if err != nil {
+			b.fail("operator", fmt.Errorf("invalid --operator-selector: %w", err))
+			return
+		}
+		opts = append(opts, client.MatchingLabelsSelector{Selector: sel})
+	}
+	if err := s.client.List(ctx, podList, opts...); err != nil {
+		b.fail("operator", err)
+		return
+	}
+	if len(podList.Items) == 0 {
+		b.fail("operator", fmt.Errorf("no operator pod matches %q", selector))
+		return
+	}
+
+	seconds := int64(since.Seconds())
+	for _, pod := range podList.Items {
+		name := path.Join("operator", pod.Name+".log")
+		stream, err := s.clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container:    "manager",
+			SinceSeconds: &seconds,
+		}).Stream(ctx)
+		if err != nil {
+			b.fail(name, err)
+			continue
+		}
+
+		out, err := filterOperatorLogs(stream, c.name(), s.namespace)
+		if err != nil {
+			b.fail(name, err)
+		}
+		_ = stream.Close()
+		b.add(name, out)
+	}
+}
+
+// filterOperatorLogs returns the lines of the operator logs about a cluster. The reconcilers log
+// the name and namespace of the custom resource on every line.
+func filterOperatorLogs(stream io.Reader, clusterName, namespace string) ([]byte, error) {
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.Contains(line, `"`+clusterName+`"`) && strings.Contains(line, `"`+namespace+`"`) {
+			out.WriteString(line)
+			out.WriteByte('\n')
+		}
+	}
+	return out.Bytes(), scanner.Err()
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+	mongodbfake "github.com/keiailab/mongodb-operator/pkg/mongodb/fake"
+)
+
+// testMemberPod returns a running member pod of the cluster my-mongodb
+func testMemberPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{"app.kubernetes.io/instance": "my-mongodb", "app.kubernetes.io/component": "replicaset"},
+		},
+		Spec:   corev1.PodSpec{Containers: []corev1.Container{{Name: "mongodb"}, {Name: "exporter"}}},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+}
+
+// readBundle returns the files of a gzipped tarball by name
+func readBundle(t *testing.T, data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+}
+
+func TestWriteBundle(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 2,
+			Port:    27100,
+			Version: mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+		},
+		Status: mongodbv1alpha1.MongoDBStatus{Conditions: []metav1.Condition{{
+			Type: "Ready", Status: metav1.ConditionTrue, Reason: "ReplicaSetReady", LastTransitionTime: metav1.NewTime(now),
+		}}},
+	}
+	keyfile := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb-keyfile", Namespace: "default"},
+		Data:       map[string][]byte{"keyfile": []byte("secret-key")},
+	}
+	sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{
+		Name: "my-mongodb", Namespace: "default", Labels: map[string]string{"app.kubernetes.io/instance": "my-mongodb"},
+	}}
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "my-mongodb-0.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "my-mongodb-0"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "BackOff",
+		Message:        "Back-off restarting failed container",
+		Count:          3,
+		LastTimestamp:  metav1.NewTime(now),
+	}
+	otherEvent := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "other-0.1", Namespace: "default"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "other-0"},
+		Reason:         "Scheduled",
+	}
+	operator := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "mongodb-operator-7d9f", Namespace: "mongodb-operator-system", Labels: map[string]string{"app.kubernetes.io/name": "mongodb-operator"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(mdb, keyfile, sts, event, otherEvent, testMemberPod("my-mongodb-0"), testMemberPod("my-mongodb-1"), operator).
+		Build()
+
+	runner := mongodbfake.NewRunner()
+	runner.On("rs.status()", `{"set":"my-mongodb","ok":1}`)
+	runner.On("serverStatus", `{"ok":1}`)
+	runner.Add(mongodbfake.Response{Pod: "my-mongodb-1", Err: errors.New("pod my-mongodb-1 is not reachable")})
+	s := &session{
+		client:    c,
+		clientset: kubefake.NewClientset(operator),
+		namespace: "default",
+		executor:  mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions()),
+	}
+
+	var out bytes.Buffer
+	b := &bundle{dir: "my-mongodb-bundle-20240601-020000", now: now}
+	require.NoError(t, s.writeBundle(ctx, &out, b, &cluster{replicaSet: mdb}, "mongodb-operator-system", "app.kubernetes.io/name=mongodb-operator", time.Hour))
+
+	files := readBundle(t, out.Bytes())
+	var names []string
+	for name := range files {
+		require.True(t, strings.HasPrefix(name, "my-mongodb-bundle-20240601-020000/"), name)
+		names = append(names, strings.TrimPrefix(name, "my-mongodb-bundle-20240601-020000/"))
+	}
+	assert.ElementsMatch(t, []string{
+		"mongodb.yaml",
+		"conditions.txt",
+		"events.txt",
+		"statefulsets/my-mongodb.yaml",
+		"pods/my-mongodb-0.yaml",
+		"pods/my-mongodb-1.yaml",
+		"mongodb/my-mongodb-0/rs-status.json",
+		"mongodb/my-mongodb-0/server-status.json",
+		"operator/mongodb-operator-7d9f.log",
+		"errors.txt",
+	}, names)
+
+	file := func(name string) string { return files["my-mongodb-bundle-20240601-020000/"+name] }
+	assert.Contains(t, file("mongodb.yaml"), "kind: MongoDB\n")
+	assert.Contains(t, file("conditions.txt"), "Ready  True    ReplicaSetReady  2024-06-01T02:00:00Z")
+	assert.Contains(t, file("events.txt"), "pod/my-mongodb-0")
+	assert.NotContains(t, file("events.txt"), "other-0")
+	assert.Equal(t, `{"set":"my-mongodb","ok":1}`, file("mongodb/my-mongodb-0/rs-status.json"))
+	// The fake clientset logs "fake logs", which do not mention the cluster
+	assert.Empty(t, file("operator/mongodb-operator-7d9f.log"))
+	assert.Equal(t, "mongodb/my-mongodb-1/rs-status.json: pod my-mongodb-1 is not reachable\n"+
+		"mongodb/my-mongodb-1/server-status.json: pod my-mongodb-1 is not reachable\n", file("errors.txt"))
+	assert.Equal(t, b.errors, strings.Split(strings.TrimSuffix(file("errors.txt"), "\n"), "\n"))
+
+	// mongosh authenticates with the keyfile on the port of the cluster
+	calls := runner.Calls()
+	require.NotEmpty(t, calls)
+	for _, call := range calls {
+		assert.Equal(t, "mongodb", call.Container)
+		assert.Contains(t, strings.Join(call.Command, " "), "--port 27100 -u __system -p secret-key --authenticationDatabase local")
+	}
+}
+
+func TestMemberPort(t *testing.T) {
+	replicaSet := &cluster{replicaSet: &mongodbv1alpha1.MongoDB{}}
+	customPort := &cluster{replicaSet: &mongodbv1alpha1.MongoDB{Spec: mongodbv1alpha1.MongoDBSpec{Port: 27100}}}
+	sharded := &cluster{sharded: &mongodbv1alpha1.MongoDBSharded{}}
+	tests := []struct {
+		name      string
+		cluster   *cluster
+		component string
+		want      int
+	}{
+		{name: "replica set", cluster: replicaSet, component: "replicaset", want: 27017},
+		{name: "replica set on a custom port", cluster: customPort, component: "replicaset", want: 27100},
+		{name: "config server", cluster: sharded, component: "configsvr", want: 27019},
+		{name: "mongos", cluster: sharded, component: "mongos", want: 27017},
+		{name: "shard", cluster: sharded, component: "shard-1", want: 27018},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, memberPort(tt.cluster, tt.component))
+		})
+	}
+}
+
+func TestFilterOperatorLogs(t *testing.T) {
+	logs := `{"level":"info","msg":"Reconciling MongoDB","MongoDB":{"name":"my-mongodb","namespace":"default"}}
+{"level":"info","msg":"Reconciling MongoDB","MongoDB":{"name":"my-mongodb","namespace":"staging"}}
+{"level":"info","msg":"Reconciling MongoDB","MongoDB":{"name":"my-mongodb-2","namespace":"default"}}
+{"level":"error","msg":"Reconciler error","name":"my-mongodb","namespace":"default","error":"timeout"}
+{"level":"info","msg":"Starting workers"}
+`
+	tests := []struct {
+		name      string
+		cluster   string
+		namespace string
+		want      string
+	}{
+		{
+			name:      "cluster of a namespace",
+			cluster:   "my-mongodb",
+			namespace: "default",
+			want: `{"level":"info","msg":"Reconciling MongoDB","MongoDB":{"name":"my-mongodb","namespace":"default"}}
+{"level":"error","msg":"Reconciler error","name":"my-mongodb","namespace":"default","error":"timeout"}
+`,
+		},
+		{
+			name:      "same name in another namespace",
+			cluster:   "my-mongodb",
+			namespace: "staging",
+			want: `{"level":"info","msg":"Reconciling MongoDB","MongoDB":{"name":"my-mongodb","namespace":"staging"}}
+`,
+		},
+		{name: "unknown cluster", cluster: "other", namespace: "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := filterOperatorLogs(strings.NewReader(logs), tt.cluster, tt.namespace)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
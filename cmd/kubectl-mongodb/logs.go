@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// serverContainers are the containers whose logs hold the slow operations
+var serverContainers = []string{"mongodb", "mongos"}
+
+func runSlowQueries(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("slow-queries", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	member := fs.String("member", "", "Only read the logs of this pod")
+	follow := fs.Bool("follow", false, "Keep streaming new slow operations")
+	fs.BoolVar(follow, "f", false, "Shorthand for --follow")
+	since := fs.Duration("since", 0, "Only read the logs newer than this duration, e.g. 1h")
+	minMillis := fs.Int64("min-duration", 0, "Only show operations at least this many milliseconds long")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb slow-queries CLUSTER [flags]")
+		fmt.Fprintln(fs.Output(), "Shows the operations mongod and mongos logged as slower than slowms.")
+		fs.PrintDefaults()
+	}
+	positional, _, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleArg(positional, "cluster name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	c, err := s.getCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+	pods, err := s.listPods(ctx, c.name(), "")
+	if err != nil {
+		return err
+	}
+
+	logOptions := corev1.PodLogOptions{Follow: *follow}
+	if *since > 0 {
+		seconds := int64(since.Seconds())
+		logOptions.SinceSeconds = &seconds
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(pods))
+	streamed := 0
+	for _, pod := range pods {
+		if *member != "" && pod.Name != *member {
+			continue
+		}
+		container := serverContainer(&pod)
+		if container == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		streamed++
+
+		podLogOptions := logOptions
+		podLogOptions.Container = container
+		wg.Add(1)
+		go func(pod string) {
+			defer wg.Done()
+			stream, err := s.clientset.CoreV1().Pods(s.namespace).GetLogs(pod, &podLogOptions).Stream(ctx)
+			if err != nil {
+				errCh <- fmt.Errorf("failed to read the logs of %s: %w", pod, err)
+				return
+			}
+			defer func() { _ = stream.Close() }()
+			if err := printSlowQueries(os.Stdout, stream, pod, *minMillis, &mu); err != nil {
+				errCh <- fmt.Errorf("failed to read the logs of %s: %w", pod, err)
+			}
+		}(pod.Name)
+	}
+	if streamed == 0 {
+		if *member != "" {
+			return fmt.Errorf("no running member %s in %s", *member, c.name())
+		}
+		return fmt.Errorf("no running member in %s", c.name())
+	}
+
+	wg.Wait()
+	close(errCh)
+	// The first error, nil without any
+	return <-errCh
+}
+
+// serverContainer returns the mongod or mongos container of a pod, "" for the other pods of
+// the cluster such as backup Jobs
+func serverContainer(pod *corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if slices.Contains(serverContainers, container.Name) {
+			return container.Name
+		}
+	}
+	return ""
+}
+
+// printSlowQueries prints a line per slow operation of a log stream to w. mu serializes the
+// output of the pods streamed concurrently.
+func printSlowQueries(w io.Writer, stream io.Reader, pod string, minMillis int64, mu *sync.Mutex) error {
+	scanner := bufio.NewScanner(stream)
+	// Log entries embed the whole command
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		query, ok := mongodb.ParseSlowQuery(scanner.Text())
+		if !ok || query.DurationMillis < minMillis {
+			continue
+		}
+		mu.Lock()
+		fmt.Fprintf(w, "%s %s %dms %s %s plan=%s keysExamined=%d docsExamined=%d nreturned=%d %s\n",
+			query.Time.Format(time.RFC3339), pod, query.DurationMillis, query.Type, query.Namespace,
+			valueOrNone(query.PlanSummary), query.KeysExamined, query.DocsExamined, query.NReturned, query.Command)
+		mu.Unlock()
+	}
+	return scanner.Err()
+}
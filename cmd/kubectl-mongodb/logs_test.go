@@ -0,0 +1,94 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testServerLog = `{"t":{"$date":"2024-06-01T02:00:00.123+00:00"},"s":"I","c":"NETWORK","id":22943,"ctx":"listener","msg":"Connection accepted"}
+{"t":{"$date":"2024-06-01T02:00:01.000+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12","msg":"Slow query","attr":{"type":"command","ns":"app.orders","command":{"find":"orders"},"planSummary":"COLLSCAN","keysExamined":0,"docsExamined":10000,"nreturned":3,"durationMillis":152}}
+not a structured log line
+{"t":{"$date":"2024-06-01T02:00:02.000+00:00"},"s":"I","c":"WRITE","id":51803,"ctx":"conn13","msg":"Slow query","attr":{"type":"update","ns":"app.users","command":{"q":{"_id":1}},"keysExamined":1,"docsExamined":1,"durationMillis":40}}
+`
+
+func TestPrintSlowQueries(t *testing.T) {
+	tests := []struct {
+		name      string
+		minMillis int64
+		want      []string
+	}{
+		{
+			name: "every slow operation",
+			want: []string{
+				`2024-06-01T02:00:01Z my-mongodb-0 152ms command app.orders plan=COLLSCAN keysExamined=0 docsExamined=10000 nreturned=3 {"find":"orders"}`,
+				`2024-06-01T02:00:02Z my-mongodb-0 40ms update app.users plan=<none> keysExamined=1 docsExamined=1 nreturned=0 {"q":{"_id":1}}`,
+			},
+		},
+		{
+			name:      "minimum duration",
+			minMillis: 100,
+			want: []string{
+				`2024-06-01T02:00:01Z my-mongodb-0 152ms command app.orders plan=COLLSCAN keysExamined=0 docsExamined=10000 nreturned=3 {"find":"orders"}`,
+			},
+		},
+		{
+			name:      "none slow enough",
+			minMillis: 1000,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var mu sync.Mutex
+			require.NoError(t, printSlowQueries(&out, strings.NewReader(testServerLog), "my-mongodb-0", tt.minMillis, &mu))
+			var got []string
+			if out.Len() > 0 {
+				got = strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServerContainer(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []string
+		want       string
+	}{
+		{name: "mongod", containers: []string{"mongodb", "exporter"}, want: "mongodb"},
+		{name: "mongos", containers: []string{"mongos"}, want: "mongos"},
+		{name: "backup job", containers: []string{"backup"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{}
+			for _, name := range tt.containers {
+				pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: name})
+			}
+			assert.Equal(t, tt.want, serverContainer(pod))
+		})
+	}
+}
@@ -0,0 +1,289 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// kubectl-mongodb is a kubectl plugin for day-to-day operations on the clusters of the operator.
+// Installed on the PATH it runs as "kubectl mongodb".
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+
+	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(mongodbv1alpha1.AddToScheme(scheme))
+}
+
+const usage = `kubectl mongodb operates the MongoDB clusters of the mongodb-operator.
+
+Usage:
+  kubectl mongodb <command> [flags]
+
+Commands:
+  status CLUSTER                   Show the topology and the replica set members of a cluster
+  shell CLUSTER [-- MONGOSH-ARGS]  Open mongosh on the cluster through a port-forward
+  backup CLUSTER                   Create a MongoDBBackup of a cluster
+  backups CLUSTER                  List the completed backups of a cluster and their restore coverage
+  restore BACKUP                   Restore an S3 backup into its cluster
+  slow-queries CLUSTER             Show the slow operations logged by the members
+  bundle CLUSTER                   Collect a support bundle of a cluster for issue reports
+
+Every command accepts -n/--namespace, --kubeconfig and --context.
+Run "kubectl mongodb <command> -h" for the flags of a command.
+`
+
+// commands maps the command names to their implementation
+var commands = map[string]func(ctx context.Context, args []string) error{
+	"status":       runStatus,
+	"shell":        runShell,
+	"backup":       runBackup,
+	"backups":      runBackups,
+	"restore":      runRestore,
+	"slow-queries": runSlowQueries,
+	"bundle":       runBundle,
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		fmt.Print(usage)
+		return nil
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return command(ctx, args[1:])
+}
+
+// options are the flags shared by every command
+type options struct {
+	namespace  string
+	kubeconfig string
+	context    string
+}
+
+func (o *options) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.namespace, "namespace", "", "Namespace of the resource, the one of the kubeconfig context when empty")
+	fs.StringVar(&o.namespace, "n", "", "Shorthand for --namespace")
+	fs.StringVar(&o.kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
+	fs.StringVar(&o.context, "context", "", "Name of the kubeconfig context to use")
+}
+
+// parseArgs parses the flags of a command, which may be interspersed with its arguments.
+// The arguments after "--" are returned as is in passthrough.
+func parseArgs(fs *flag.FlagSet, args []string) (positional, passthrough []string, err error) {
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, passthrough = args[:i], args[i+1:]
+	}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, passthrough, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// singleArg returns the only argument of a command
+func singleArg(args []string, what string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected exactly one %s, got %d arguments", what, len(args))
+	}
+	return args[0], nil
+}
+
+// session holds the clients of the cluster and the namespace selected by the flags
+type session struct {
+	client    client.Client
+	clientset kubernetes.Interface
+	config    *rest.Config
+	namespace string
+	// executor runs mongosh in the pods, created from config on first use
+	executor mongodb.Executor
+}
+
+func (o *options) connect() (*session, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = o.kubeconfig
+	loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{CurrentContext: o.context})
+
+	config, err := loader.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the kubeconfig: %w", err)
+	}
+	namespace := o.namespace
+	if namespace == "" {
+		if namespace, _, err = loader.Namespace(); err != nil {
+			return nil, fmt.Errorf("failed to get the namespace of the kubeconfig context: %w", err)
+		}
+	}
+
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+	return &session{client: c, clientset: clientset, config: config, namespace: namespace}, nil
+}
+
+// mongoExecutor returns the executor running mongosh in the pods of the cluster
+func (s *session) mongoExecutor() (mongodb.Executor, error) {
+	if s.executor == nil {
+		exec, err := mongodb.NewExecutorForConfig(s.config, mongodb.DefaultExecutorOptions())
+		if err != nil {
+			return nil, err
+		}
+		s.executor = exec
+	}
+	return s.executor, nil
+}
+
+// cluster is a MongoDB or a MongoDBSharded, exactly one of them is set
+type cluster struct {
+	replicaSet *mongodbv1alpha1.MongoDB
+	sharded    *mongodbv1alpha1.MongoDBSharded
+}
+
+func (c *cluster) name() string {
+	if c.sharded != nil {
+		return c.sharded.Name
+	}
+	return c.replicaSet.Name
+}
+
+func (c *cluster) kind() string {
+	if c.sharded != nil {
+		return "MongoDBSharded"
+	}
+	return "MongoDB"
+}
+
+func (c *cluster) object() client.Object {
+	if c.sharded != nil {
+		return c.sharded
+	}
+	return c.replicaSet
+}
+
+func (c *cluster) conditions() []metav1.Condition {
+	if c.sharded != nil {
+		return c.sharded.Status.Conditions
+	}
+	return c.replicaSet.Status.Conditions
+}
+
+func (c *cluster) auth() mongodbv1alpha1.AuthSpec {
+	if c.sharded != nil {
+		return c.sharded.Spec.Auth
+	}
+	return c.replicaSet.Spec.Auth
+}
+
+// getCluster returns the MongoDB or, failing that, the MongoDBSharded of a name
+func (s *session) getCluster(ctx context.Context, name string) (*cluster, error) {
+	key := client.ObjectKey{Name: name, Namespace: s.namespace}
+
+	mdb := &mongodbv1alpha1.MongoDB{}
+	err := s.client.Get(ctx, key, mdb)
+	if err == nil {
+		return &cluster{replicaSet: mdb}, nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get MongoDB %s: %w", name, err)
+	}
+
+	mdbsh := &mongodbv1alpha1.MongoDBSharded{}
+	if err := s.client.Get(ctx, key, mdbsh); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("no MongoDB or MongoDBSharded %s in namespace %s", name, s.namespace)
+		}
+		return nil, fmt.Errorf("failed to get MongoDBSharded %s: %w", name, err)
+	}
+	return &cluster{sharded: mdbsh}, nil
+}
+
+// listPods returns the pods of a cluster component sorted by name, running pods first
+func (s *session) listPods(ctx context.Context, clusterName, component string) ([]corev1.Pod, error) {
+	labels := client.MatchingLabels{"app.kubernetes.io/instance": clusterName}
+	if component != "" {
+		labels["app.kubernetes.io/component"] = component
+	}
+	podList := &corev1.PodList{}
+	if err := s.client.List(ctx, podList, client.InNamespace(s.namespace), labels); err != nil {
+		return nil, fmt.Errorf("failed to list the pods of %s: %w", clusterName, err)
+	}
+
+	pods := podList.Items
+	sort.SliceStable(pods, func(i, j int) bool {
+		iRunning, jRunning := pods[i].Status.Phase == corev1.PodRunning, pods[j].Status.Phase == corev1.PodRunning
+		if iRunning != jRunning {
+			return iRunning
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// getSecret returns a secret of the namespace
+func (s *session) getSecret(ctx context.Context, name string) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := s.client.Get(ctx, client.ObjectKey{Name: name, Namespace: s.namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret %s: %w", name, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		wantPositional  []string
+		wantPassthrough []string
+		wantNamespace   string
+		wantErr         bool
+	}{
+		{
+			name:           "flags first",
+			args:           []string{"-n", "prod", "my-mongodb"},
+			wantPositional: []string{"my-mongodb"},
+			wantNamespace:  "prod",
+		},
+		{
+			name:           "flags after the arguments",
+			args:           []string{"my-mongodb", "--namespace=prod", "other"},
+			wantPositional: []string{"my-mongodb", "other"},
+			wantNamespace:  "prod",
+		},
+		{
+			name:            "passthrough arguments",
+			args:            []string{"my-mongodb", "-n", "prod", "--", "--eval", "db.stats()", "-n"},
+			wantPositional:  []string{"my-mongodb"},
+			wantPassthrough: []string{"--eval", "db.stats()", "-n"},
+			wantNamespace:   "prod",
+		},
+		{
+			name: "no arguments",
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"my-mongodb", "--unknown"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			var o options
+			o.addFlags(fs)
+
+			positional, passthrough, err := parseArgs(fs, tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPositional, positional)
+			assert.Equal(t, tt.wantPassthrough, passthrough)
+			assert.Equal(t, tt.wantNamespace, o.namespace)
+		})
+	}
+}
+
+func TestSingleArg(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{name: "one argument", args: []string{"my-mongodb"}, want: "my-mongodb"},
+		{name: "no argument", wantErr: "expected exactly one cluster name, got 0 arguments"},
+		{name: "two arguments", args: []string{"a", "b"}, wantErr: "expected exactly one cluster name, got 2 arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := singleArg(tt.args, "cluster name")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
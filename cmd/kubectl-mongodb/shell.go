@@ -0,0 +1,188 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+func runShell(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("shell", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	localPort := fs.Int("local-port", 0, "Local port of the port-forward, a free port when 0")
+	mongosh := fs.String("mongosh", "mongosh", "Path of the mongosh binary")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb shell CLUSTER [flags] [-- MONGOSH-ARGS]")
+		fmt.Fprintln(fs.Output(), "Connects to the primary of a replica set or to a mongos of a sharded cluster as the admin user.")
+		fs.PrintDefaults()
+	}
+	positional, mongoshArgs, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleArg(positional, "cluster name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	c, err := s.getCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+	pod, port, err := s.shellTarget(ctx, c)
+	if err != nil {
+		return err
+	}
+	secret, err := s.getSecret(ctx, resources.ConnectionSecretName(name))
+	if err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	readyCh := make(chan struct{})
+	forwarder, err := s.portForward(pod, *localPort, port, readyCh, stopCh)
+	if err != nil {
+		return err
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- forwarder.ForwardPorts() }()
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return fmt.Errorf("failed to port-forward to %s: %w", pod, err)
+	}
+	defer close(stopCh)
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		return err
+	}
+	shellArgs, cleanup, err := mongoshConnectArgs(secret, int(ports[0].Local))
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	// Ctrl-C interrupts the running operation of mongosh, it must not stop the port-forward
+	signal.Notify(make(chan os.Signal, 1), os.Interrupt)
+	defer signal.Reset(os.Interrupt)
+
+	fmt.Fprintf(os.Stderr, "Connecting to %s through localhost:%d\n", pod, ports[0].Local)
+	cmd := exec.CommandContext(ctx, *mongosh, append(shellArgs, mongoshArgs...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// shellTarget returns the pod and port the shell connects to: the primary of a replica set, or
+// a running mongos of a sharded cluster
+func (s *session) shellTarget(ctx context.Context, c *cluster) (string, int, error) {
+	component, port := "mongos", 27017
+	if mdb := c.replicaSet; mdb != nil {
+		port = int(resources.ReplicaSetPort(mdb))
+		if mdb.Status.CurrentPrimary != "" {
+			return mdb.Status.CurrentPrimary, port, nil
+		}
+		component = "replicaset"
+	}
+
+	pods, err := s.listPods(ctx, c.name(), component)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(pods) == 0 || pods[0].Status.Phase != corev1.PodRunning {
+		return "", 0, fmt.Errorf("no running %s pod found for %s", component, c.name())
+	}
+	return pods[0].Name, port, nil
+}
+
+// portForward forwards localPort to remotePort of a pod, like kubectl port-forward
+func (s *session) portForward(pod string, localPort, remotePort int, readyCh, stopCh chan struct{}) (*portforward.PortForwarder, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(s.config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create port-forward transport: %w", err)
+	}
+	req := s.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(s.namespace).
+		Name(pod).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	ports := []string{fmt.Sprintf("%d:%d", localPort, remotePort)}
+	return portforward.New(dialer, ports, stopCh, readyCh, io.Discard, os.Stderr)
+}
+
+// mongoshConnectArgs returns the mongosh arguments connecting to the forwarded port as the
+// admin user of the connection secret. cleanup removes the CA file written for TLS clusters.
+func mongoshConnectArgs(secret *corev1.Secret, localPort int) (args []string, cleanup func(), err error) {
+	query := url.Values{}
+	query.Set("directConnection", "true")
+	query.Set("authSource", "admin")
+	query.Set("appName", "kubectl-mongodb")
+	uri := url.URL{
+		Scheme:   "mongodb",
+		User:     url.UserPassword(string(secret.Data["username"]), string(secret.Data["password"])),
+		Host:     fmt.Sprintf("localhost:%d", localPort),
+		Path:     "/",
+		RawQuery: query.Encode(),
+	}
+	args = []string{uri.String()}
+	cleanup = func() {}
+
+	caCert, ok := secret.Data["ca.crt"]
+	if !ok {
+		return args, cleanup, nil
+	}
+	caFile, err := os.CreateTemp("", "kubectl-mongodb-ca-*.crt")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write the CA certificate: %w", err)
+	}
+	cleanup = func() { _ = os.Remove(caFile.Name()) }
+	if _, err := caFile.Write(caCert); err != nil {
+		_ = caFile.Close()
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write the CA certificate: %w", err)
+	}
+	if err := caFile.Close(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write the CA certificate: %w", err)
+	}
+	// The member certificates name the cluster DNS names, not localhost
+	args = append(args, "--tls", "--tlsCAFile", caFile.Name(), "--tlsAllowInvalidHostnames")
+	return args, cleanup, nil
+}
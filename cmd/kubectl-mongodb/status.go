@@ -0,0 +1,171 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// replicaSetTarget is a replica set of a cluster whose members report rs.status()
+type replicaSetTarget struct {
+	name      string
+	component string
+	port      int
+}
+
+func runStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	var o options
+	o.addFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: kubectl mongodb status CLUSTER [flags]")
+		fs.PrintDefaults()
+	}
+	positional, _, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	name, err := singleArg(positional, "cluster name")
+	if err != nil {
+		return err
+	}
+
+	s, err := o.connect()
+	if err != nil {
+		return err
+	}
+	c, err := s.getCluster(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	targets, err := printTopology(os.Stdout, c)
+	if err != nil {
+		return err
+	}
+
+	keyfile, err := s.getKeyfile(ctx, c)
+	if err != nil {
+		return err
+	}
+	exec, err := s.mongoExecutor()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPLICA SET\tMEMBER\tSTATE\tHEALTH\tUPTIME")
+	for _, target := range targets {
+		if err := s.printReplicaSetStatus(ctx, w, exec, keyfile, c.name(), target); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// printTopology prints the summary of the members of a cluster and returns its replica sets
+func printTopology(w io.Writer, c *cluster) ([]replicaSetTarget, error) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var targets []replicaSetTarget
+	if mdb := c.replicaSet; mdb != nil {
+		fmt.Fprintf(tw, "Cluster:\t%s (MongoDB)\n", mdb.Name)
+		fmt.Fprintf(tw, "Version:\t%s\n", mdb.Spec.Version.Version)
+		fmt.Fprintf(tw, "Phase:\t%s\n", mdb.Status.Phase)
+		fmt.Fprintf(tw, "Members:\t%d/%d ready\n", mdb.Status.ReadyMembers, mdb.Spec.Members)
+		fmt.Fprintf(tw, "Primary:\t%s\n", valueOrNone(mdb.Status.CurrentPrimary))
+		targets = append(targets, replicaSetTarget{name: mdb.Name, component: "replicaset", port: int(resources.ReplicaSetPort(mdb))})
+	} else {
+		mdbsh := c.sharded
+		fmt.Fprintf(tw, "Cluster:\t%s (MongoDBSharded)\n", mdbsh.Name)
+		fmt.Fprintf(tw, "Version:\t%s\n", mdbsh.Spec.Version.Version)
+		fmt.Fprintf(tw, "Phase:\t%s\n", mdbsh.Status.Phase)
+		fmt.Fprintf(tw, "Config servers:\t%d/%d ready\n", mdbsh.Status.ConfigServer.Ready, mdbsh.Status.ConfigServer.Total)
+		fmt.Fprintf(tw, "Mongos:\t%d/%d ready\n", mdbsh.Status.Mongos.Ready, mdbsh.Status.Mongos.Total)
+		for _, shard := range mdbsh.Status.Shards {
+			fmt.Fprintf(tw, "Shard %s:\t%d/%d ready, primary %s\n", shard.Name, shard.Ready, shard.Total, valueOrNone(shard.Primary))
+		}
+		// Config servers listen on 27019 and shards on 27018
+		targets = append(targets, replicaSetTarget{name: mdbsh.Name + "-cfg", component: "configsvr", port: 27019})
+		for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+			targets = append(targets, replicaSetTarget{name: resources.ShardName(mdbsh.Name, i), component: fmt.Sprintf("shard-%d", i), port: 27018})
+		}
+	}
+	return targets, tw.Flush()
+}
+
+// printReplicaSetStatus prints a row per member from the rs.status() of the first member
+// that answers
+func (s *session) printReplicaSetStatus(ctx context.Context, w io.Writer, exec mongodb.Executor, keyfile, clusterName string, target replicaSetTarget) error {
+	pods, err := s.listPods(ctx, clusterName, target.component)
+	if err != nil {
+		return err
+	}
+
+	rsManager := mongodb.NewReplicaSetManagerWithExecutorAndPort(exec, target.port)
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		status, err := rsManager.GetStatusWithKeyfile(ctx, pod.Name, s.namespace, keyfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to get the replica set status from %s: %v\n", pod.Name, err)
+			continue
+		}
+		for _, member := range status.Members {
+			uptime := time.Duration(member.Uptime) * time.Second
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\n", target.name, member.Name, member.StateStr, member.Health, uptime)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(w, "%s\t-\tUNREACHABLE\t-\t-\n", target.name)
+	return nil
+}
+
+// getKeyfile returns the first key of the keyfile secret, the one every member accepts
+func (s *session) getKeyfile(ctx context.Context, c *cluster) (string, error) {
+	auth := c.auth()
+	secret, err := s.getSecret(ctx, resources.KeyfileSecretName(c.name(), auth))
+	if err != nil {
+		return "", err
+	}
+	key := resources.KeyfileSecretKey(auth)
+	keys := resources.ParseKeyfile(string(secret.Data[key]))
+	if len(keys) == 0 {
+		return "", fmt.Errorf("%s key not found in secret %s", key, secret.Name)
+	}
+	return keys[0], nil
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestPrintTopology(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     *cluster
+		wantOutput  string
+		wantTargets []replicaSetTarget
+	}{
+		{
+			name: "replica set",
+			cluster: &cluster{replicaSet: &mongodbv1alpha1.MongoDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb"},
+				Spec: mongodbv1alpha1.MongoDBSpec{
+					Members: 3,
+					Version: mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+				},
+				Status: mongodbv1alpha1.MongoDBStatus{Phase: "Running", ReadyMembers: 2, CurrentPrimary: "my-mongodb-0"},
+			}},
+			wantOutput: "Cluster:  my-mongodb (MongoDB)\n" +
+				"Version:  8.0\n" +
+				"Phase:    Running\n" +
+				"Members:  2/3 ready\n" +
+				"Primary:  my-mongodb-0\n",
+			wantTargets: []replicaSetTarget{{name: "my-mongodb", component: "replicaset", port: 27017}},
+		},
+		{
+			name: "replica set without primary on a custom port",
+			cluster: &cluster{replicaSet: &mongodbv1alpha1.MongoDB{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb"},
+				Spec: mongodbv1alpha1.MongoDBSpec{
+					Members: 1,
+					Port:    27100,
+					Version: mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+				},
+				Status: mongodbv1alpha1.MongoDBStatus{Phase: "Pending"},
+			}},
+			wantOutput: "Cluster:  my-mongodb (MongoDB)\n" +
+				"Version:  7.0\n" +
+				"Phase:    Pending\n" +
+				"Members:  0/1 ready\n" +
+				"Primary:  <none>\n",
+			wantTargets: []replicaSetTarget{{name: "my-mongodb", component: "replicaset", port: 27100}},
+		},
+		{
+			name: "sharded cluster",
+			cluster: &cluster{sharded: &mongodbv1alpha1.MongoDBSharded{
+				ObjectMeta: metav1.ObjectMeta{Name: "my-sharded"},
+				Spec: mongodbv1alpha1.MongoDBShardedSpec{
+					Version: mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+					Shards:  mongodbv1alpha1.ShardSpec{Count: 2, MembersPerShard: 3},
+				},
+				Status: mongodbv1alpha1.MongoDBShardedStatus{
+					Phase:        "Running",
+					ConfigServer: mongodbv1alpha1.ComponentStatus{Ready: 3, Total: 3},
+					Mongos:       mongodbv1alpha1.ComponentStatus{Ready: 1, Total: 2},
+					Shards: []mongodbv1alpha1.ShardStatus{
+						{Name: "my-sharded-shard-0", Ready: 3, Total: 3, Primary: "my-sharded-shard-0-0"},
+						{Name: "my-sharded-shard-1", Ready: 2, Total: 3},
+					},
+				},
+			}},
+			wantOutput: "Cluster:                   my-sharded (MongoDBSharded)\n" +
+				"Version:                   8.0\n" +
+				"Phase:                     Running\n" +
+				"Config servers:            3/3 ready\n" +
+				"Mongos:                    1/2 ready\n" +
+				"Shard my-sharded-shard-0:  3/3 ready, primary my-sharded-shard-0-0\n" +
+				"Shard my-sharded-shard-1:  2/3 ready, primary <none>\n",
+			wantTargets: []replicaSetTarget{
+				{name: "my-sharded-cfg", component: "configsvr", port: 27019},
+				{name: "my-sharded-shard-0", component: "shard-0", port: 27018},
+				{name: "my-sharded-shard-1", component: "shard-1", port: 27018},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			targets, err := printTopology(&out, tt.cluster)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOutput, out.String())
+			assert.Equal(t, tt.wantTargets, targets)
+		})
+	}
+}
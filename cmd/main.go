@@ -19,16 +19,21 @@ package main
 import (
 	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/filters"
@@ -37,6 +42,9 @@ import (
 
 	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
 	"github.com/keiailab/mongodb-operator/internal/controller"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	webhookv1alpha1 "github.com/keiailab/mongodb-operator/internal/webhook/v1alpha1"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
 )
 
 var (
@@ -55,7 +63,16 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var clusterDomain string
+	var defaultStorageClass string
+	var openShift bool
+	var execQPS float64
+	var watchNamespaces string
+	var watchLabelSelector string
+	var enableWebhooks bool
+	var webhookPort int
 	var tlsOpts []func(*tls.Config)
+	execOptions := mongodb.DefaultExecutorOptions()
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -67,6 +84,37 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&clusterDomain, "cluster-domain", resources.DefaultClusterDomain,
+		"The DNS domain of the Kubernetes cluster, used in member host names of clusters without spec.clusterDomain.")
+	flag.StringVar(&defaultStorageClass, "default-storage-class", os.Getenv("DEFAULT_STORAGE_CLASS"),
+		"The StorageClass of clusters without spec.storage.storageClassName. "+
+			"Defaults to the DEFAULT_STORAGE_CLASS environment variable, leave empty to use the cluster default.")
+	flag.BoolVar(&openShift, "openshift", os.Getenv("OPENSHIFT") == "true",
+		"If set, database pods leave the UID, GID and fsGroup to the OpenShift SCC instead of using 999. "+
+			"Defaults to true when the OPENSHIFT environment variable is \"true\".")
+	flag.Float64Var(&execQPS, "exec-qps", float64(execOptions.QPS),
+		"The number of exec calls per second the operator makes against the pods of one cluster, 0 disables the limit.")
+	flag.IntVar(&execOptions.Burst, "exec-burst", execOptions.Burst,
+		"The number of exec calls the operator may make against the pods of one cluster in a burst.")
+	flag.DurationVar(&execOptions.QueryTimeout, "exec-query-timeout", execOptions.QueryTimeout,
+		"How long a read-only MongoDB query such as rs.status() may run in a pod, 0 disables the timeout.")
+	flag.DurationVar(&execOptions.CommandTimeout, "exec-command-timeout", execOptions.CommandTimeout,
+		"How long a MongoDB command that may change the deployment may run in a pod, 0 disables the timeout.")
+	flag.DurationVar(&execOptions.LongCommandTimeout, "exec-long-command-timeout", execOptions.LongCommandTimeout,
+		"How long a MongoDB command copying or rewriting data, such as compact or movePrimary, may run in a pod, "+
+			"0 disables the timeout.")
+	flag.StringVar(&watchNamespaces, "watch-namespaces", os.Getenv("WATCH_NAMESPACE"),
+		"Comma-separated namespaces the operator watches, leave empty to watch all namespaces. "+
+			"Defaults to the WATCH_NAMESPACE environment variable.")
+	flag.StringVar(&watchLabelSelector, "watch-label-selector", os.Getenv("WATCH_LABEL_SELECTOR"),
+		"Label selector the custom resources must match to be reconciled, e.g. team=payments. "+
+			"Defaults to the WATCH_LABEL_SELECTOR environment variable.")
+
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", os.Getenv("ENABLE_WEBHOOKS") == "true",
+		"If set, the validating webhooks rejecting risky updates of MongoDB and MongoDBSharded are served. "+
+			"They need a serving certificate and a ValidatingWebhookConfiguration. "+
+			"Defaults to true when the ENABLE_WEBHOOKS environment variable is \"true\".")
+	flag.IntVar(&webhookPort, "webhook-port", webhook.DefaultPort, "The port the webhook server listens on.")
 
 	opts := zap.Options{
 		Development: true,
@@ -76,6 +124,11 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	resources.DefaultClusterDomain = clusterDomain
+	resources.DefaultStorageClassName = defaultStorageClass
+	resources.OpenShiftCompatibility = openShift
+	execOptions.QPS = float32(execQPS)
+
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
 	// due to its vulnerabilities. More specifically, disabling http/2 will
 	// prevent from being affected by the HTTP/2 Stream Cancellation and
@@ -92,6 +145,7 @@ func main() {
 	}
 
 	webhookServer := webhook.NewServer(webhook.Options{
+		Port:    webhookPort,
 		TLSOpts: tlsOpts,
 	})
 
@@ -113,8 +167,18 @@ func main() {
 		metricsServerOptions.FilterProvider = filters.WithAuthenticationAndAuthorization
 	}
 
+	cacheOptions, err := watchCacheOptions(watchNamespaces, watchLabelSelector)
+	if err != nil {
+		setupLog.Error(err, "unable to configure the watched resources")
+		os.Exit(1)
+	}
+	if len(cacheOptions.DefaultNamespaces) > 0 || watchLabelSelector != "" {
+		setupLog.Info("restricting the watched resources", "namespaces", watchNamespaces, "labelSelector", watchLabelSelector)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
+		Cache:                  cacheOptions,
 		Metrics:                metricsServerOptions,
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -126,10 +190,20 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The reconcilers share one executor, running their MongoDB commands in the pods
+	executor, err := mongodb.NewExecutorForConfig(mgr.GetConfig(), execOptions)
+	if err != nil {
+		setupLog.Error(err, "unable to create MongoDB command executor")
+		os.Exit(1)
+	}
+	managers := controller.Managers{Executor: executor}
+
 	// Setup MongoDB controller
 	if err = (&controller.MongoDBReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("mongodb-controller"),
+		Managers: managers,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MongoDB")
 		os.Exit(1)
@@ -137,8 +211,9 @@ func main() {
 
 	// Setup MongoDBSharded controller
 	if err = (&controller.MongoDBShardedReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Managers: managers,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "MongoDBSharded")
 		os.Exit(1)
@@ -146,13 +221,63 @@ func main() {
 
 	// Setup MongoDBBackup controller
 	if err = (&controller.MongoDBBackupReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Managers: managers,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MongoDBBackup")
+		os.Exit(1)
+	}
+
+	// Setup MongoDBCollection controller
+	if err = (&controller.MongoDBCollectionReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Managers: managers,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MongoDBCollection")
+		os.Exit(1)
+	}
+
+	// Setup MongoDBOpsRequest controller
+	if err = (&controller.MongoDBOpsRequestReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Managers: managers,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MongoDBOpsRequest")
+		os.Exit(1)
+	}
+
+	// Setup MongoDBMigration controller
+	if err = (&controller.MongoDBMigrationReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "MongoDBBackup")
+		setupLog.Error(err, "unable to create controller", "controller", "MongoDBMigration")
+		os.Exit(1)
+	}
+
+	// Setup MongoDBOperatorConfig controller
+	if err = (&controller.MongoDBOperatorConfigReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MongoDBOperatorConfig")
 		os.Exit(1)
 	}
 
+	if enableWebhooks {
+		if err := webhookv1alpha1.SetupMongoDBWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MongoDB")
+			os.Exit(1)
+		}
+		if err := webhookv1alpha1.SetupMongoDBShardedWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MongoDBSharded")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -162,9 +287,52 @@ func main() {
 		os.Exit(1)
 	}
 
+	// The clusters render their resources with the operator config from the first reconcile on
+	ctx := ctrl.SetupSignalHandler()
+	if err := controller.LoadOperatorConfig(ctx, mgr.GetAPIReader()); err != nil {
+		setupLog.Error(err, "unable to load the operator config")
+		os.Exit(1)
+	}
+
 	setupLog.Info("starting manager")
-	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
+	if err := mgr.Start(ctx); err != nil {
 		setupLog.Error(err, "problem running manager")
 		os.Exit(1)
 	}
 }
+
+// watchCacheOptions restricts the manager cache to the given comma-separated namespaces, and the
+// custom resources to the ones matching labelSelector. Objects owned by the custom resources are
+// not filtered by label, so reconciles triggered by them for a filtered out resource find nothing.
+func watchCacheOptions(namespaces, labelSelector string) (cache.Options, error) {
+	var opts cache.Options
+	for _, namespace := range strings.Split(namespaces, ",") {
+		if namespace = strings.TrimSpace(namespace); namespace == "" {
+			continue
+		}
+		if opts.DefaultNamespaces == nil {
+			opts.DefaultNamespaces = map[string]cache.Config{}
+		}
+		opts.DefaultNamespaces[namespace] = cache.Config{}
+	}
+
+	if labelSelector == "" {
+		return opts, nil
+	}
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return opts, fmt.Errorf("invalid watch label selector %q: %w", labelSelector, err)
+	}
+	opts.ByObject = map[client.Object]cache.ByObject{}
+	for _, obj := range []client.Object{
+		&mongodbv1alpha1.MongoDB{},
+		&mongodbv1alpha1.MongoDBSharded{},
+		&mongodbv1alpha1.MongoDBBackup{},
+		&mongodbv1alpha1.MongoDBCollection{},
+		&mongodbv1alpha1.MongoDBOpsRequest{},
+		&mongodbv1alpha1.MongoDBMigration{},
+	} {
+		opts.ByObject[obj] = cache.ByObject{Label: selector}
+	}
+	return opts, nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// runAuthBootstrapJob creates the Job creating the admin user of a cluster unless it exists, and
+// reports whether it completed. The completion of the Job is the only evidence the operator has
+// that the user exists, it reads no pod. A failed Job is deleted with its error, so the next
+// reconcile runs it again.
+func runAuthBootstrapJob(ctx context.Context, c client.Client, scheme *runtime.Scheme, owner client.Object, job *batchv1.Job) (bool, error) {
+	existing := &batchv1.Job{}
+	err := c.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing)
+	if errors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(owner, job, scheme); err != nil {
+			return false, err
+		}
+		log.FromContext(ctx).Info("Creating admin bootstrap Job", "job", job.Name)
+		return false, c.Create(ctx, job)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, condition := range existing.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, nil
+		case batchv1.JobFailed:
+			if err := c.Delete(ctx, existing, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+				return false, err
+			}
+			return false, fmt.Errorf("admin bootstrap Job %s failed: %s", existing.Name, condition.Message)
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+	mongodbfake "github.com/keiailab/mongodb-operator/pkg/mongodb/fake"
+)
+
+// TestAuthBootstrapJob checks that the admin user is only marked as created once its Job completed,
+// without running anything in the pods
+func TestAuthBootstrapJob(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default", UID: "uid"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 3,
+			Auth:    mongodbv1alpha1.AuthSpec{Bootstrap: resources.AuthBootstrapJob},
+		},
+	}
+	admin := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb-admin", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(mdb, admin).WithStatusSubresource(mdb).Build()
+	runner := mongodbfake.NewRunner()
+	executor := mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions())
+	r := &MongoDBReconciler{Client: c, Scheme: scheme, Managers: Managers{Executor: executor}}
+
+	require.NoError(t, r.reconcileAdminUser(ctx, mdb))
+	assert.False(t, mdb.Status.AdminUserCreated, "the Job is running")
+	job := &batchv1.Job{}
+	key := types.NamespacedName{Name: "my-mongodb-bootstrap-admin", Namespace: "default"}
+	require.NoError(t, c.Get(ctx, key, job))
+	assert.Equal(t, "my-mongodb", job.OwnerReferences[0].Name)
+
+	// A failed Job is run again
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Message: "BackoffLimitExceeded"}}
+	require.NoError(t, c.Status().Update(ctx, job))
+	assert.EqualError(t, r.reconcileAdminUser(ctx, mdb), "admin bootstrap Job my-mongodb-bootstrap-admin failed: BackoffLimitExceeded")
+	assert.False(t, mdb.Status.AdminUserCreated)
+	assert.True(t, errors.IsNotFound(c.Get(ctx, key, &batchv1.Job{})))
+
+	require.NoError(t, r.reconcileAdminUser(ctx, mdb))
+	require.NoError(t, c.Get(ctx, key, job))
+	job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	require.NoError(t, c.Status().Update(ctx, job))
+	require.NoError(t, r.reconcileAdminUser(ctx, mdb))
+	assert.True(t, mdb.Status.AdminUserCreated)
+	assert.Equal(t, hashPassword(mdb.UID, "secret"), mdb.Status.AdminPasswordHash)
+	assert.Empty(t, runner.Calls(), "no pods/exec")
+}
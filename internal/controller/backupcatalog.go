@@ -0,0 +1,100 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// updateBackupCatalog writes the catalog of the completed backups of the cluster of a backup to
+// its ConfigMap. The ConfigMap is owned by the cluster, nothing is written once it is gone.
+func (r *MongoDBBackupReconciler) updateBackupCatalog(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) error {
+	key := types.NamespacedName{Name: backup.Spec.ClusterRef.Name, Namespace: backup.Namespace}
+	var cluster client.Object
+	switch backup.Spec.ClusterRef.Kind {
+	case "MongoDB":
+		cluster = &mongodbv1alpha1.MongoDB{}
+	case "MongoDBSharded":
+		cluster = &mongodbv1alpha1.MongoDBSharded{}
+	default:
+		return fmt.Errorf("unknown cluster kind: %s", backup.Spec.ClusterRef.Kind)
+	}
+	if err := r.Get(ctx, key, cluster); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	backupList := &mongodbv1alpha1.MongoDBBackupList{}
+	if err := r.List(ctx, backupList, client.InNamespace(backup.Namespace),
+		client.MatchingFields{backupClusterRefIndex: backup.Spec.ClusterRef.Name}); err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+	// Backups being deleted leave the catalog
+	backups := backupList.Items[:0]
+	for _, item := range backupList.Items {
+		if item.DeletionTimestamp.IsZero() {
+			backups = append(backups, item)
+		}
+	}
+
+	catalog := resources.BuildBackupCatalog(backup.Spec.ClusterRef.Kind, backup.Spec.ClusterRef.Name, backups)
+	cm, err := resources.BuildBackupCatalogConfigMap(catalog, backup.Namespace)
+	if err != nil {
+		return err
+	}
+	// Not the controller, the cluster reconciler does not need to run on catalog changes
+	if err := controllerutil.SetOwnerReference(cluster, cm, r.Scheme); err != nil {
+		return err
+	}
+
+	existing := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, existing); err != nil {
+		if errors.IsNotFound(err) {
+			return r.Create(ctx, cm)
+		}
+		return err
+	}
+	existing.Labels = cm.Labels
+	existing.OwnerReferences = cm.OwnerReferences
+	existing.Data = cm.Data
+	return r.Update(ctx, existing)
+}
+
+// archiveSize returns the size of the archive the succeeded pod of a backup Job reported in its
+// termination message, empty when no pod reported it
+func (r *MongoDBBackupReconciler) archiveSize(ctx context.Context, job *batchv1.Job) (string, error) {
+	message, err := jobTerminationMessage(ctx, r.Client, job, "backup")
+	if err != nil {
+		return "", err
+	}
+	size, err := strconv.ParseInt(message, 10, 64)
+	if err != nil {
+		return "", nil
+	}
+	return resources.ArchiveSize(size), nil
+}
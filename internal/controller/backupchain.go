@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// oplogWindow returns the oplog window of the primary of the replica set a backup targets, nil
+// for sharded clusters and standalone deployments, which are only backed up in full
+func (r *MongoDBBackupReconciler) oplogWindow(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (*mongodb.OplogWindow, error) {
+	if backup.Spec.ClusterRef.Kind != "MongoDB" {
+		return nil, nil
+	}
+	mdb := &mongodbv1alpha1.MongoDB{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.ClusterRef.Name, Namespace: backup.Namespace}, mdb); err != nil {
+		return nil, fmt.Errorf("failed to get MongoDB cluster: %w", err)
+	}
+	if resources.Standalone(mdb) {
+		return nil, nil
+	}
+	if mdb.Status.CurrentPrimary == "" {
+		return nil, fmt.Errorf("MongoDB %s has no primary", mdb.Name)
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return nil, err
+	}
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+	return rsManager.GetOplogWindowWithKeyfile(ctx, mdb.Status.CurrentPrimary, mdb.Namespace, keyfile)
+}
+
+// planBackup records the archive of a backup and, for replica sets, the oplog position it
+// covers. An incremental backup chains to the latest completed backup of the cluster in the same
+// storage and covers the oplog written since.
+func (r *MongoDBBackupReconciler) planBackup(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup, window *mongodb.OplogWindow) error {
+	incremental := backup.Spec.Type == mongodbv1alpha1.BackupTypeIncremental
+	if window == nil {
+		if incremental {
+			return fmt.Errorf("incremental backups need a replica set, %s %s is not one",
+				backup.Spec.ClusterRef.Kind, backup.Spec.ClusterRef.Name)
+		}
+		backup.Status.Archive = resources.BackupArchiveName(backup)
+		return nil
+	}
+
+	if incremental {
+		parent, err := r.latestChainBackup(ctx, backup)
+		if err != nil {
+			return err
+		}
+		if parent == nil {
+			return fmt.Errorf("no completed backup of %s in the same storage to chain to, take a full backup first", backup.Spec.ClusterRef.Name)
+		}
+		if !window.Covers(fromOplogTimestamp(parent.Status.OplogEnd)) {
+			return fmt.Errorf("the oplog no longer holds the operations after backup %s, take a full backup", parent.Name)
+		}
+		backup.Status.ParentBackup = parent.Name
+		backup.Status.OplogStart = parent.Status.OplogEnd.DeepCopy()
+	}
+	backup.Status.OplogEnd = toOplogTimestamp(window.Last)
+	backup.Status.Archive = resources.BackupArchiveName(backup)
+	return nil
+}
+
+// latestChainBackup returns the completed backup of the same cluster and storage with the latest
+// oplog position, nil when there is none
+func (r *MongoDBBackupReconciler) latestChainBackup(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (*mongodbv1alpha1.MongoDBBackup, error) {
+	backupList := &mongodbv1alpha1.MongoDBBackupList{}
+	if err := r.List(ctx, backupList, client.InNamespace(backup.Namespace),
+		client.MatchingFields{backupClusterRefIndex: backup.Spec.ClusterRef.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var latest *mongodbv1alpha1.MongoDBBackup
+	for i := range backupList.Items {
+		candidate := &backupList.Items[i]
+		if candidate.Name == backup.Name || candidate.Spec.ClusterRef.Kind != backup.Spec.ClusterRef.Kind ||
+			candidate.Status.Phase != "Completed" || candidate.Status.OplogEnd == nil ||
+			!reflect.DeepEqual(candidate.Spec.Storage, backup.Spec.Storage) {
+			continue
+		}
+		if latest == nil || fromOplogTimestamp(latest.Status.OplogEnd).Before(fromOplogTimestamp(candidate.Status.OplogEnd)) {
+			latest = candidate
+		}
+	}
+	return latest, nil
+}
+
+func toOplogTimestamp(ts mongodb.OplogTimestamp) *mongodbv1alpha1.OplogTimestamp {
+	return &mongodbv1alpha1.OplogTimestamp{T: int64(ts.T), I: int64(ts.I)}
+}
+
+func fromOplogTimestamp(ts *mongodbv1alpha1.OplogTimestamp) mongodb.OplogTimestamp {
+	return mongodb.OplogTimestamp{T: uint32(ts.T), I: uint32(ts.I)}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// getUserCredentials reads the passwords of additional users.
+// Users whose password secret is not available yet are left out of the connection secret.
+func getUserCredentials(ctx context.Context, c client.Client, namespace string, users []mongodbv1alpha1.MongoDBUser) []resources.UserCredentials {
+	logger := log.FromContext(ctx)
+
+	var creds []resources.UserCredentials
+	for _, user := range users {
+		secret := &corev1.Secret{}
+		ref := user.PasswordSecretRef
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+			logger.Info("Skipping user without password secret", "user", user.Name, "secret", ref.Name)
+			continue
+		}
+
+		password, ok := secret.Data[ref.Key]
+		if !ok || len(password) == 0 {
+			logger.Info("Skipping user without password", "user", user.Name, "secret", ref.Name, "key", ref.Key)
+			continue
+		}
+
+		creds = append(creds, resources.UserCredentials{
+			Username: user.Name,
+			Password: string(password),
+			Database: user.DB,
+		})
+	}
+	return creds
+}
+
+// getCACert returns the CA certificate of a custom TLS secret, if one is configured
+func getCACert(ctx context.Context, c client.Client, namespace string, tls *mongodbv1alpha1.TLSSpec) []byte {
+	if tls == nil || !tls.Enabled || tls.CustomCert == nil {
+		return nil
+	}
+
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: tls.CustomCert.SecretName, Namespace: namespace}, secret); err != nil {
+		return nil
+	}
+	return secret.Data["ca.crt"]
+}
+
+// isUserPasswordSecret reports whether a secret holds the password of an additional user
+func isUserPasswordSecret(secretName string, auth mongodbv1alpha1.AuthSpec) bool {
+	for _, user := range auth.Users {
+		if user.PasswordSecretRef.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteStaleUserConnectionSecrets removes the connection secrets of users removed from the spec
+// or given another connectionSecretName. Secrets of users whose password is not available are kept.
+func deleteStaleUserConnectionSecrets(ctx context.Context, c client.Client, clusterName, namespace string, users []mongodbv1alpha1.MongoDBUser) error {
+	wanted := map[string]bool{}
+	for _, user := range users {
+		wanted[resources.UserConnectionSecretName(clusterName, user)] = true
+	}
+
+	secrets := &corev1.SecretList{}
+	if err := c.List(ctx, secrets, client.InNamespace(namespace), client.MatchingLabels{
+		"app.kubernetes.io/instance":  clusterName,
+		"app.kubernetes.io/component": resources.UserConnectionComponent,
+	}); err != nil {
+		return fmt.Errorf("failed to list user connection secrets: %w", err)
+	}
+	for i := range secrets.Items {
+		if wanted[secrets.Items[i].Name] {
+			continue
+		}
+		if err := client.IgnoreNotFound(c.Delete(ctx, &secrets.Items[i])); err != nil {
+			return fmt.Errorf("failed to delete user connection secret %s: %w", secrets.Items[i].Name, err)
+		}
+	}
+	return nil
+}
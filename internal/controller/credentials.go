@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// adminCredentials holds the credentials of the operator-managed admin user
+type adminCredentials struct {
+	Username string
+	Password string
+}
+
+// memberCredentials are the credentials the operator authenticates to a database member with
+type memberCredentials struct {
+	Username string
+	Password string
+	Database string
+}
+
+// keyfileCredentials authenticates as the internal __system user, which works on every member
+// whatever its replica set state and without knowing the admin password
+func keyfileCredentials(keyfile string) memberCredentials {
+	return memberCredentials{Username: "__system", Password: strings.TrimSpace(keyfile), Database: "local"}
+}
+
+// getAdminCredentials reads the admin credentials secret of a cluster, honoring custom key names.
+// The username defaults to "admin" when the secret only carries a password.
+func getAdminCredentials(ctx context.Context, c client.Client, clusterName, namespace string, auth mongodbv1alpha1.AuthSpec) (*adminCredentials, error) {
+	secret := &corev1.Secret{}
+	secretName := resources.AdminSecretName(clusterName, auth)
+	if err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get admin credentials secret: %w", err)
+	}
+
+	usernameKey, passwordKey := resources.CredentialKeys(auth.AdminCredentialsSecretRef)
+
+	password, ok := secret.Data[passwordKey]
+	if !ok || len(password) == 0 {
+		return nil, fmt.Errorf("%s key not found in secret %s", passwordKey, secretName)
+	}
+
+	username := resources.AdminUsername
+	if value, ok := secret.Data[usernameKey]; ok && len(value) > 0 {
+		username = string(value)
+	}
+
+	return &adminCredentials{Username: username, Password: string(password)}, nil
+}
+
+// hashPassword returns the hash recorded in status for a password applied to the database.
+// The cluster UID is used as salt so the status never exposes a plain password digest.
+func hashPassword(salt types.UID, password string) string {
+	sum := sha256.Sum256([]byte(string(salt) + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// setPasswordRotatedCondition records a successful admin password rotation
+func setPasswordRotatedCondition(conditions *[]metav1.Condition, generation int64) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               "PasswordRotated",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Rotated",
+		Message:            "Admin password was updated from the credentials secret",
+	})
+}
+
+// usesSecret reports whether a secret holds the admin or exporter credentials, the keyfile or a user password of a cluster
+func usesSecret(secretName, clusterName string, auth mongodbv1alpha1.AuthSpec) bool {
+	return secretName == resources.AdminSecretName(clusterName, auth) ||
+		secretName == resources.MonitoringSecretName(clusterName) ||
+		secretName == resources.KeyfileSecretName(clusterName, auth) ||
+		isUserPasswordSecret(secretName, auth)
+}
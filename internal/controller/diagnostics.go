@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// applyDiagnostics sets the profiler and log verbosity on the members <baseName>-<first> to
+// <baseName>-<first+members-1>. The profiler and the server parameters are per process, so every
+// member is configured rather than only the primary.
+func (m Managers) applyDiagnostics(ctx context.Context, baseName, namespace string, creds memberCredentials, first, members int32, port int, profiling, verbosity map[string]any) error {
+	rsManager := m.replicaSetManager(port)
+
+	for i := first; i < first+members; i++ {
+		podName := fmt.Sprintf("%s-%d", baseName, i)
+		if err := rsManager.SetDiagnosticsWithAuth(ctx, podName, namespace, creds.Username, creds.Password, creds.Database, profiling, verbosity); err != nil {
+			return fmt.Errorf("member %s: %w", podName, err)
+		}
+	}
+	return nil
+}
+
+// reconcileDiagnostics applies spec.profiling and spec.logging to the running members when they change
+func (r *MongoDBReconciler) reconcileDiagnostics(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	// Leave the members alone until the settings are used, they may have been tuned by hand
+	if !resources.DiagnosticsConfigured(mdb.Spec.Profiling, mdb.Spec.Logging) && mdb.Status.DiagnosticsHash == "" {
+		return nil
+	}
+
+	profiling := resources.BuildProfilingSettings(mdb.Spec.Profiling)
+	verbosity := resources.BuildLogComponentVerbosity(mdb.Spec.Logging)
+	hash := settingsHash([]any{profiling, verbosity})
+	if mdb.Status.DiagnosticsHash == hash {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Profiler or log verbosity changed, updating members", "profiling", profiling)
+
+	creds, err := r.getMemberCredentials(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	first, count := resources.LocalMembers(mdb)
+	if err := r.applyDiagnostics(ctx, mdb.Name, mdb.Namespace, creds, first, count, int(resources.ReplicaSetPort(mdb)), profiling, verbosity); err != nil {
+		return err
+	}
+
+	mdb.Status.DiagnosticsHash = hash
+	return r.Status().Update(ctx, mdb)
+}
+
+// reconcileDiagnostics applies spec.profiling and spec.logging to the running config server and
+// shard members when they change. mongos has no profiler and keeps its default log verbosity.
+func (r *MongoDBShardedReconciler) reconcileDiagnostics(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !resources.DiagnosticsConfigured(mdbsh.Spec.Profiling, mdbsh.Spec.Logging) && mdbsh.Status.DiagnosticsHash == "" {
+		return nil
+	}
+
+	profiling := resources.BuildProfilingSettings(mdbsh.Spec.Profiling)
+	verbosity := resources.BuildLogComponentVerbosity(mdbsh.Spec.Logging)
+	hash := settingsHash([]any{profiling, verbosity})
+	if mdbsh.Status.DiagnosticsHash == hash {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Profiler or log verbosity changed, updating config server and shard members", "profiling", profiling)
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return err
+	}
+	creds := keyfileCredentials(keyfile)
+
+	if err := r.applyDiagnostics(ctx, mdbsh.Name+"-cfg", mdbsh.Namespace, creds, 0, mdbsh.Spec.ConfigServer.Members, 27019, profiling, verbosity); err != nil {
+		return err
+	}
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		shardName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, i)
+		if err := r.applyDiagnostics(ctx, shardName, mdbsh.Namespace, creds, 0, mdbsh.Spec.Shards.MembersPerShard, 27018, profiling, verbosity); err != nil {
+			return err
+		}
+	}
+
+	mdbsh.Status.DiagnosticsHash = hash
+	return r.Status().Update(ctx, mdbsh)
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// collectMemberVersions returns the image and the buildInfo version of the members <baseName>-<first>
+// to <baseName>-<first+members-1>. The image is the one the kubelet runs, which lags behind the pod
+// template during a rollout. Members that cannot be queried are reported without a version.
+func (m Managers) collectMemberVersions(ctx context.Context, c client.Client, baseName, namespace string, creds memberCredentials, first, members int32, port int) ([]mongodbv1alpha1.MemberVersionStatus, error) {
+	rsManager := m.replicaSetManager(port)
+
+	logger := log.FromContext(ctx)
+	versions := make([]mongodbv1alpha1.MemberVersionStatus, 0, members)
+	for i := first; i < first+members; i++ {
+		podName := fmt.Sprintf("%s-%d", baseName, i)
+		pod := &corev1.Pod{}
+		if err := c.Get(ctx, types.NamespacedName{Name: podName, Namespace: namespace}, pod); err != nil {
+			logger.Info("Failed to get member pod", "pod", podName, "error", err)
+			continue
+		}
+
+		member := mongodbv1alpha1.MemberVersionStatus{Name: podName, Image: containerImage(pod, "mongodb")}
+		info, err := rsManager.GetBuildInfoWithAuth(ctx, podName, namespace, creds.Username, creds.Password, creds.Database)
+		if err != nil {
+			logger.Info("Failed to collect the member version", "pod", podName, "error", err)
+		} else {
+			member.Version = info.Version
+		}
+		versions = append(versions, member)
+	}
+	return versions, nil
+}
+
+// containerImage returns the image a container of a pod runs, the one of its spec until it started
+func containerImage(pod *corev1.Pod, container string) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container && status.Image != "" {
+			return status.Image
+		}
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == container {
+			return c.Image
+		}
+	}
+	return ""
+}
+
+// checkDrift stamps the desired state on an owned object about to be updated and reports whether
+// its existing version was changed out-of-band, in which case the update reverts the change
+func checkDrift(ctx context.Context, obj, existing client.Object, drift []mongodbv1alpha1.DriftStatus) []mongodbv1alpha1.DriftStatus {
+	resources.SetDesiredState(obj)
+	if !resources.OutOfBandChange(obj, existing) {
+		return drift
+	}
+
+	// Typed objects leave their kind empty, it is the name of their Go type
+	resource := reflect.TypeOf(obj).Elem().Name() + "/" + obj.GetName()
+	log.FromContext(ctx).Info("Reverting out-of-band change", "resource", resource)
+	return resources.RecordDrift(drift, resource, time.Now())
+}
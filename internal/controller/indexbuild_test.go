@@ -0,0 +1,280 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+	mongodbfake "github.com/keiailab/mongodb-operator/pkg/mongodb/fake"
+)
+
+const testHealthyReplicaSetStatus = `{"set":"my-mongodb","ok":1,"members":[
+	{"_id":0,"name":"my-mongodb-0.my-mongodb-headless.default.svc.cluster.local:27017","stateStr":"PRIMARY","health":1},
+	{"_id":1,"name":"my-mongodb-1.my-mongodb-headless.default.svc.cluster.local:27017","stateStr":"SECONDARY","health":1},
+	{"_id":2,"name":"my-mongodb-2.my-mongodb-headless.default.svc.cluster.local:27017","stateStr":"SECONDARY","health":1}]}`
+
+// testIndexTarget returns a collection of the replica set my-mongodb declaring the index email,
+// and the reconciler and target running its commands through runner
+func testIndexTarget(t *testing.T, runner *mongodbfake.Runner, strategy string) (*MongoDBCollectionReconciler, *collectionTarget, *mongodbv1alpha1.MongoDBCollection) {
+	scheme := testScheme(t)
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 3,
+			Storage: mongodbv1alpha1.StorageSpec{DataDirPath: "/data/db"},
+		},
+	}
+	keyfile := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb-keyfile", Namespace: "default"},
+		Data:       map[string][]byte{"keyfile": []byte("secret-key")},
+	}
+	coll := &mongodbv1alpha1.MongoDBCollection{
+		ObjectMeta: metav1.ObjectMeta{Name: "users", Namespace: "default", Generation: 1},
+		Spec: mongodbv1alpha1.MongoDBCollectionSpec{
+			ClusterRef: mongodbv1alpha1.ClusterReference{Name: "my-mongodb", Kind: "MongoDB"},
+			Database:   "app",
+			Name:       "users",
+			Indexes: []mongodbv1alpha1.IndexSpec{
+				{Name: "email", Keys: []mongodbv1alpha1.IndexKeyField{{Field: "email"}}, Unique: true},
+			},
+			IndexBuild:             &mongodbv1alpha1.IndexBuildSpec{Strategy: strategy},
+			IndexBuildCommitQuorum: "votingMembers",
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(keyfile).Build()
+	executor := mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions())
+	r := &MongoDBCollectionReconciler{Client: c, Scheme: scheme, Managers: Managers{Executor: executor}}
+	target := &collectionTarget{
+		pod:       "my-mongodb-0",
+		container: "mongodb",
+		port:      27017,
+		creds:     &adminCredentials{Username: "admin", Password: "secret"},
+		indexes:   r.indexManager(),
+		mdb:       mdb,
+	}
+	return r, target, coll
+}
+
+// callsOn returns the commands run on a pod, mongosh scripts or command lines
+func callsOn(runner *mongodbfake.Runner, pod string) []string {
+	var calls []string
+	for _, call := range runner.Calls() {
+		if call.Pod != pod {
+			continue
+		}
+		if script := call.Eval(); script != "" {
+			calls = append(calls, script)
+		} else {
+			calls = append(calls, strings.Join(call.Command, " "))
+		}
+	}
+	return calls
+}
+
+func containsCall(calls []string, substr string) bool {
+	for _, call := range calls {
+		if strings.Contains(call, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestRollingIndexBuild drives a rolling index build through the fake runner: each secondary
+// builds the index out of the replica set, then the primary once stepped down
+func TestRollingIndexBuild(t *testing.T) {
+	ctx := context.Background()
+	runner := mongodbfake.NewRunner()
+	runner.On("getIndexes()", `[{"name":"_id_"}]`)
+	runner.On("$currentOp", `[]`)
+	runner.On("rs.status()", testHealthyReplicaSetStatus)
+	runner.On("touch", "")
+	runner.On("shutdown", "")
+	runner.On("nohup", "")
+	r, target, coll := testIndexTarget(t, runner, resources.IndexBuildRolling)
+
+	// The first secondary restarts out of the replica set
+	building, err := r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.True(t, building)
+	build := coll.Status.RollingIndexBuild
+	require.NotNil(t, build)
+	assert.Equal(t, []string{"email"}, build.Indexes)
+	assert.Equal(t, "my-mongodb-1", build.Member)
+	assert.Equal(t, resources.RollingIndexBuildRestarting, build.Phase)
+	assert.Equal(t, "0/3 members, my-mongodb-1", coll.Status.Indexes[0].Progress)
+	calls := callsOn(runner, "my-mongodb-1")
+	assert.True(t, containsCall(calls, "touch /data/db/.mongodb-operator-maintenance"))
+	assert.True(t, containsCall(calls, "shutdown"))
+	assert.False(t, containsCall(callsOn(runner, "my-mongodb-0"), "nohup"), "nothing is built on the primary")
+
+	// Once standalone it builds the index without a commit quorum
+	runner.Reset()
+	runner.Add(mongodbfake.Response{Pod: "my-mongodb-1", Contains: "db.hello()", Stdout: `{"setName":""}`})
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, resources.RollingIndexBuildBuilding, build.Phase)
+	calls = callsOn(runner, "my-mongodb-1")
+	require.True(t, containsCall(calls, "nohup"))
+	assert.False(t, containsCall(calls, "commitQuorum"))
+
+	runner.Add(mongodbfake.Response{Pod: "my-mongodb-1", Contains: "$currentOp", Stdout: `[{"name":"email","done":1200,"total":5000}]`})
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, resources.RollingIndexBuildBuilding, build.Phase)
+	assert.Equal(t, "0/3 members, my-mongodb-1: 1200/5000 (24%)", coll.Status.Indexes[0].Progress)
+
+	// The build is over, the member restarts into the replica set
+	runner.Reset()
+	runner.Add(mongodbfake.Response{Pod: "my-mongodb-1", Contains: "$currentOp", Stdout: `[]`})
+	runner.Add(mongodbfake.Response{Pod: "my-mongodb-1", Contains: "getIndexes()", Stdout: `[{"name":"_id_"},{"name":"email"}]`})
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, resources.RollingIndexBuildRejoining, build.Phase)
+	calls = callsOn(runner, "my-mongodb-1")
+	assert.True(t, containsCall(calls, "shutdown"))
+	assert.False(t, containsCall(calls, "touch"))
+
+	// Back as a secondary, the next member follows
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"my-mongodb-1"}, build.CompletedMembers)
+	assert.Empty(t, build.Phase)
+
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, "my-mongodb-2", build.Member)
+
+	// Only the primary left, it steps down first
+	build.CompletedMembers = []string{"my-mongodb-1", "my-mongodb-2"}
+	build.Member, build.Phase = "", ""
+	runner.Reset()
+	runner.On("rs.stepDown", "")
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Empty(t, build.Member)
+	assert.True(t, containsCall(callsOn(runner, "my-mongodb-0"), "rs.stepDown(60, 10)"))
+	assert.False(t, containsCall(callsOn(runner, "my-mongodb-0"), "shutdown"))
+
+	// Every member built the index
+	build.CompletedMembers = append(build.CompletedMembers, "my-mongodb-0")
+	runner.On("getIndexes()", `[{"name":"_id_"},{"name":"email"}]`)
+	building, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.False(t, building)
+	assert.Nil(t, coll.Status.RollingIndexBuild)
+	assert.Equal(t, []mongodbv1alpha1.IndexStatus{{Name: "email", Phase: "Ready"}}, coll.Status.Indexes)
+}
+
+func TestRollingIndexBuildFailure(t *testing.T) {
+	ctx := context.Background()
+	runner := mongodbfake.NewRunner()
+	runner.On("getIndexes()", `[{"name":"_id_"}]`)
+	runner.On("$currentOp", `[]`)
+	runner.On("shutdown", "")
+	r, target, coll := testIndexTarget(t, runner, resources.IndexBuildRolling)
+	coll.Status.RollingIndexBuild = &mongodbv1alpha1.RollingIndexBuildStatus{
+		Indexes:            []string{"email"},
+		Member:             "my-mongodb-1",
+		Phase:              resources.RollingIndexBuildBuilding,
+		ObservedGeneration: 1,
+	}
+
+	// The build ended without the index, the member rejoins and the build stops
+	_, err := r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, resources.RollingIndexBuildFailed, coll.Status.RollingIndexBuild.Phase)
+	assert.True(t, containsCall(callsOn(runner, "my-mongodb-1"), "shutdown"))
+
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	assert.EqualError(t, err, "rolling index build failed on my-mongodb-1: indexes email were not built, see the log of the mongodb container")
+
+	// A spec change starts over
+	coll.Generation = 2
+	runner.On("rs.status()", testHealthyReplicaSetStatus)
+	runner.On("touch", "")
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Equal(t, resources.RollingIndexBuildRestarting, coll.Status.RollingIndexBuild.Phase)
+	assert.Equal(t, int64(2), coll.Status.RollingIndexBuild.ObservedGeneration)
+}
+
+func TestIndexBuildStrategy(t *testing.T) {
+	ctx := context.Background()
+	runner := mongodbfake.NewRunner()
+	runner.On("getIndexes()", `[{"name":"_id_"}]`)
+	runner.On("$currentOp", `[]`)
+	runner.On("$collStats", `{"size":1024}`)
+	runner.On("nohup", "")
+	r, target, coll := testIndexTarget(t, runner, resources.IndexBuildAuto)
+
+	// A small collection builds on every member at once
+	building, err := r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.True(t, building)
+	assert.Nil(t, coll.Status.RollingIndexBuild)
+	assert.True(t, containsCall(callsOn(runner, "my-mongodb-0"), "commitQuorum"))
+
+	// Too few members to roll: Auto builds at once, Rolling fails
+	runner.On("$collStats", `{"size":20000000000}`)
+	target.mdb.Spec.Members = 2
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.Nil(t, coll.Status.RollingIndexBuild)
+
+	coll.Spec.IndexBuild.Strategy = resources.IndexBuildRolling
+	_, err = r.reconcileIndexes(ctx, coll, target)
+	assert.EqualError(t, err, "rolling index builds require at least 3 members, my-mongodb has 2")
+}
+
+func TestReconcileIndexOptions(t *testing.T) {
+	ctx := context.Background()
+	runner := mongodbfake.NewRunner()
+	runner.On("getIndexes()", `[{"name":"_id_"},{"name":"email","changed":true},{"name":"expires","ttlChanged":true}]`)
+	runner.On("$currentOp", `[]`)
+	runner.On("dropIndex", "")
+	runner.On("collMod", "")
+	runner.On("nohup", "")
+	r, target, coll := testIndexTarget(t, runner, resources.IndexBuildSimultaneous)
+	ttl := int32(3600)
+	coll.Spec.Indexes = append(coll.Spec.Indexes, mongodbv1alpha1.IndexSpec{
+		Name: "expires", Keys: []mongodbv1alpha1.IndexKeyField{{Field: "expiresAt"}}, ExpireAfterSeconds: &ttl,
+	})
+
+	building, err := r.reconcileIndexes(ctx, coll, target)
+	require.NoError(t, err)
+	assert.True(t, building)
+	assert.Equal(t, []mongodbv1alpha1.IndexStatus{{Name: "email", Phase: "Building"}, {Name: "expires", Phase: "Ready"}}, coll.Status.Indexes)
+
+	calls := callsOn(runner, "my-mongodb-0")
+	assert.True(t, containsCall(calls, `dropIndex("email")`), "a changed key or option rebuilds the index")
+	assert.True(t, containsCall(calls, `index: { name: "expires", expireAfterSeconds: 3600 }`), "a changed TTL is applied in place")
+	assert.False(t, containsCall(calls, `dropIndex("expires")`))
+	assert.True(t, containsCall(calls, `"name":"email"`))
+}
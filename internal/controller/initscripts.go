@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// initScriptTarget is the container the init scripts of a cluster run in: the primary of a
+// replica set or a mongos of a sharded cluster
+type initScriptTarget struct {
+	pod       string
+	container string
+	port      int
+}
+
+// applyInitScripts runs the init scripts of a cluster that did not run yet, in order, as the admin
+// user. The status records each script once it succeeded so it never runs again, a failed script
+// is retried on the next reconcile.
+func (m Managers) applyInitScripts(ctx context.Context, c client.Client, cluster client.Object, auth mongodbv1alpha1.AuthSpec,
+	scripts []mongodbv1alpha1.InitScript, applied *[]string, target initScriptTarget) error {
+	pending := resources.PendingInitScripts(scripts, *applied)
+	if len(pending) == 0 {
+		return nil
+	}
+
+	creds, err := getAdminCredentials(ctx, c, cluster.GetName(), cluster.GetNamespace(), auth)
+	if err != nil {
+		return err
+	}
+	for _, script := range pending {
+		content, err := loadInitScript(ctx, c, cluster.GetNamespace(), script)
+		if err != nil {
+			return err
+		}
+
+		database := resources.InitScriptDatabase(script)
+		if resources.IsJSONInitScript(script) {
+			err = m.Executor.InsertDocumentsInContainer(ctx, target.pod, cluster.GetNamespace(), target.container,
+				creds.Username, creds.Password, database, script.Collection, content, target.port)
+		} else {
+			err = m.Executor.RunScriptInContainer(ctx, target.pod, cluster.GetNamespace(), target.container,
+				creds.Username, creds.Password, database, content, target.port)
+		}
+		if err != nil {
+			return fmt.Errorf("init script %s: %w", script.Name, err)
+		}
+
+		log.FromContext(ctx).Info("Init script ran", "script", script.Name)
+		*applied = append(*applied, script.Name)
+		if err := c.Status().Update(ctx, cluster); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadInitScript reads an init script from its ConfigMap or Secret
+func loadInitScript(ctx context.Context, c client.Client, namespace string, script mongodbv1alpha1.InitScript) (string, error) {
+	if ref := script.SecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+			return "", fmt.Errorf("failed to get secret of init script %s: %w", script.Name, err)
+		}
+		content, ok := secret.Data[ref.Key]
+		if !ok {
+			return "", fmt.Errorf("key %s of init script %s not found in secret %s", ref.Key, script.Name, ref.Name)
+		}
+		return string(content), nil
+	}
+
+	ref := script.ConfigMapRef
+	configMap := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, configMap); err != nil {
+		return "", fmt.Errorf("failed to get ConfigMap of init script %s: %w", script.Name, err)
+	}
+	content, ok := configMap.Data[ref.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s of init script %s not found in ConfigMap %s", ref.Key, script.Name, ref.Name)
+	}
+	return content, nil
+}
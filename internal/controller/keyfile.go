@@ -0,0 +1,194 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// getKeyfileSecret fetches the internal authentication keyfile secret of a cluster
+func getKeyfileSecret(ctx context.Context, c client.Client, clusterName, namespace string, auth mongodbv1alpha1.AuthSpec) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	secretName := resources.KeyfileSecretName(clusterName, auth)
+	if err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get keyfile secret: %w", err)
+	}
+	return secret, nil
+}
+
+// getKeyfile returns a key accepted by every member of a cluster.
+// During a rotation the first key is the one all members still share.
+func getKeyfile(ctx context.Context, c client.Client, clusterName, namespace string, auth mongodbv1alpha1.AuthSpec) (string, error) {
+	secret, err := getKeyfileSecret(ctx, c, clusterName, namespace, auth)
+	if err != nil {
+		return "", err
+	}
+
+	keyfileKey := resources.KeyfileSecretKey(auth)
+	keys := resources.ParseKeyfile(string(secret.Data[keyfileKey]))
+	if len(keys) == 0 {
+		return "", fmt.Errorf("%s key not found in secret %s", keyfileKey, secret.Name)
+	}
+	return keys[0], nil
+}
+
+// keyfileTemplateHash returns the keyfile hash stamped on pod templates.
+// Generated keyfiles that were never rotated keep unannotated templates to avoid a needless restart,
+// user-provided keyfiles are always tracked so members restart when the referenced secret changes.
+func keyfileTemplateHash(ctx context.Context, c client.Client, clusterName, namespace string, auth mongodbv1alpha1.AuthSpec, rotation *mongodbv1alpha1.KeyfileRotationStatus) (string, error) {
+	if rotation == nil && resources.IsKeyfileSecretGenerated(auth) {
+		return "", nil
+	}
+
+	secret, err := getKeyfileSecret(ctx, c, clusterName, namespace, auth)
+	if err != nil {
+		return "", err
+	}
+	return resources.KeyfileHash(secret.Data[resources.KeyfileSecretKey(auth)]), nil
+}
+
+// statefulSetRolledOut reports whether every pod of a StatefulSet runs the template with the given keyfile hash
+func statefulSetRolledOut(sts *appsv1.StatefulSet, keyfileHash string) bool {
+	return sts.Spec.Template.Annotations[resources.KeyfileHashAnnotation] == keyfileHash && statefulSetUpdated(sts)
+}
+
+// statefulSetUpdated reports whether every pod of a StatefulSet runs its current template and is ready
+func statefulSetUpdated(sts *appsv1.StatefulSet) bool {
+	if sts.Spec.Replicas == nil {
+		return false
+	}
+	return sts.Status.ObservedGeneration == sts.Generation &&
+		sts.Status.UpdatedReplicas == *sts.Spec.Replicas &&
+		sts.Status.ReadyReplicas == *sts.Spec.Replicas &&
+		sts.Status.CurrentRevision == sts.Status.UpdateRevision
+}
+
+// deploymentUpdated reports whether every pod of a Deployment runs its current template and is available
+func deploymentUpdated(deploy *appsv1.Deployment) bool {
+	if deploy.Spec.Replicas == nil {
+		return false
+	}
+	return deploy.Status.ObservedGeneration == deploy.Generation &&
+		deploy.Status.UpdatedReplicas == *deploy.Spec.Replicas &&
+		deploy.Status.Replicas == *deploy.Spec.Replicas &&
+		deploy.Status.AvailableReplicas == *deploy.Spec.Replicas
+}
+
+// daemonSetUpdated reports whether every pod of a DaemonSet runs its current template and is available
+func daemonSetUpdated(ds *appsv1.DaemonSet) bool {
+	return ds.Status.ObservedGeneration == ds.Generation &&
+		ds.Status.UpdatedNumberScheduled == ds.Status.DesiredNumberScheduled &&
+		ds.Status.NumberAvailable == ds.Status.DesiredNumberScheduled
+}
+
+// advanceKeyfileRotation performs the next keyfile rotation step once all workloads have rolled out:
+//
+//	Distributing: members get a keyfile holding both the old and the new key
+//	Finalizing:   members get a keyfile holding only the new key
+//	Completed:    every member restarted with the new key
+//
+// It returns the rotation status to record, or nil when nothing changed.
+func advanceKeyfileRotation(ctx context.Context, c client.Client, obj client.Object, auth mongodbv1alpha1.AuthSpec, current *mongodbv1alpha1.KeyfileRotationStatus, rolledOut bool) (*mongodbv1alpha1.KeyfileRotationStatus, error) {
+	logger := log.FromContext(ctx)
+
+	requested := obj.GetAnnotations()[resources.RotateKeyfileAnnotation]
+	inProgress := current != nil && current.Phase != resources.KeyfileRotationCompleted
+
+	// A new request is only picked up once the previous rotation completed
+	if !inProgress && (requested == "" || (current != nil && current.Requested == requested)) {
+		return nil, nil
+	}
+
+	// User-provided keyfiles may be shared with other clusters, the operator must not rewrite them
+	if !resources.IsKeyfileSecretGenerated(auth) {
+		return nil, fmt.Errorf("keyfile rotation is not supported for the user-provided keyfile secret %s", auth.KeyfileSecretRef.Name)
+	}
+
+	// Never touch the keyfile while members are still restarting
+	if !rolledOut {
+		return nil, nil
+	}
+
+	secret, err := getKeyfileSecret(ctx, c, obj.GetName(), obj.GetNamespace(), auth)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := resources.ParseKeyfile(string(secret.Data["keyfile"]))
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("keyfile key not found in secret %s", secret.Name)
+	}
+
+	if !inProgress {
+		logger.Info("Starting keyfile rotation, distributing combined keyfile")
+		secret.Data["keyfile"] = []byte(resources.BuildKeyfile(keys[0], resources.NewKeyfileKey()))
+		if err := c.Update(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to update keyfile secret: %w", err)
+		}
+
+		next := &mongodbv1alpha1.KeyfileRotationStatus{
+			Requested: requested,
+			Phase:     resources.KeyfileRotationDistributing,
+		}
+		if current != nil {
+			next.LastRotationTime = current.LastRotationTime
+		}
+		return next, nil
+	}
+
+	next := current.DeepCopy()
+	switch current.Phase {
+	case resources.KeyfileRotationDistributing:
+		logger.Info("Combined keyfile rolled out, dropping the old key")
+		secret.Data["keyfile"] = []byte(resources.BuildKeyfile(keys[len(keys)-1]))
+		if err := c.Update(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to update keyfile secret: %w", err)
+		}
+		next.Phase = resources.KeyfileRotationFinalizing
+
+	case resources.KeyfileRotationFinalizing:
+		logger.Info("Keyfile rotation completed")
+		now := metav1.Now()
+		next.Phase = resources.KeyfileRotationCompleted
+		next.LastRotationTime = &now
+	}
+
+	return next, nil
+}
+
+// setKeyfileRotatedCondition records a completed keyfile rotation
+func setKeyfileRotatedCondition(conditions *[]metav1.Condition, generation int64) {
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               "KeyfileRotated",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "Rotated",
+		Message:            "Internal authentication keyfile was rotated",
+	})
+}
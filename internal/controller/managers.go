@@ -0,0 +1,44 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// Managers creates the MongoDB managers of a reconciler. They all run their commands with
+// Executor: the operator sets one exec'ing into the pods through the API server, tests one on
+// the runner of the fake package.
+type Managers struct {
+	Executor mongodb.Executor
+}
+
+func (m Managers) replicaSetManager(port int) mongodb.ReplicaSetManager {
+	return mongodb.NewReplicaSetManagerWithExecutorAndPort(m.Executor, port)
+}
+
+func (m Managers) shardManager() mongodb.ShardManager {
+	return mongodb.NewShardManagerWithExecutor(m.Executor)
+}
+
+func (m Managers) authManager() mongodb.AuthManager {
+	return mongodb.NewAuthManagerWithExecutor(m.Executor)
+}
+
+func (m Managers) indexManager() mongodb.IndexManager {
+	return mongodb.NewIndexManagerWithExecutor(m.Executor)
+}
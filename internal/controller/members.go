@@ -0,0 +1,59 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// buildMemberSettings maps the pod name of every member to its settings. Members without an
+// override get the replica set defaults, so removing an override restores them.
+func buildMemberSettings(mdb *mongodbv1alpha1.MongoDB) map[string]mongodb.MemberSettings {
+	settings := make(map[string]mongodb.MemberSettings, mdb.Spec.Members)
+	for i := int32(0); i < mdb.Spec.Members; i++ {
+		o := resources.MemberOverrideFor(mdb, i)
+		member := mongodb.MemberSettings{
+			Priority: float64(resources.MemberPriority(o)),
+			Votes:    int(resources.MemberVotes(o)),
+			Tags:     map[string]string{},
+		}
+		if o != nil {
+			member.Hidden = o.Hidden
+			member.SecondaryDelaySecs = int(o.SecondaryDelaySecs)
+			for k, v := range o.Tags {
+				member.Tags[k] = v
+			}
+		}
+		settings[fmt.Sprintf("%s-%d", mdb.Name, i)] = member
+	}
+	return settings
+}
+
+// settingsHash returns the hash recorded in status for member or replica set settings applied to
+// the replica set configuration. JSON encodes map keys in sorted order, so equal settings hash the same.
+func settingsHash(settings any) string {
+	data, _ := json.Marshal(settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
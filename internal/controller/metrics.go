@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// Balancer metrics of the sharded clusters, served on the operator metrics endpoint
+var (
+	balancerEnabled = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodb_operator_balancer_enabled",
+		Help: "Whether the chunk balancer of a sharded cluster is enabled",
+	}, []string{"namespace", "name"})
+
+	balancerInRound = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodb_operator_balancer_in_round",
+		Help: "Whether a balancing round of a sharded cluster is in progress",
+	}, []string{"namespace", "name"})
+
+	chunkMigrationsRunning = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodb_operator_chunk_migrations_running",
+		Help: "Chunk migrations in progress in a sharded cluster",
+	}, []string{"namespace", "name"})
+
+	chunkMigrationsFailed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodb_operator_chunk_migrations_failed",
+		Help: "Failed chunk migrations recorded in the config.changelog of a sharded cluster",
+	}, []string{"namespace", "name"})
+
+	jumboChunks = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "mongodb_operator_jumbo_chunks",
+		Help: "Chunks of a sharded cluster the balancer cannot move because they are too large",
+	}, []string{"namespace", "name"})
+
+	balancerMetrics = []*prometheus.GaugeVec{balancerEnabled, balancerInRound, chunkMigrationsRunning, chunkMigrationsFailed, jumboChunks}
+)
+
+func init() {
+	for _, m := range balancerMetrics {
+		metrics.Registry.MustRegister(m)
+	}
+}
+
+// recordBalancerMetrics publishes the balancer state of a sharded cluster
+func recordBalancerMetrics(mdbsh *mongodbv1alpha1.MongoDBSharded, status *mongodbv1alpha1.BalancerStatus) {
+	enabled, inRound := 0.0, 0.0
+	if status.Mode != "off" {
+		enabled = 1
+	}
+	if status.InBalancerRound {
+		inRound = 1
+	}
+
+	balancerEnabled.WithLabelValues(mdbsh.Namespace, mdbsh.Name).Set(enabled)
+	balancerInRound.WithLabelValues(mdbsh.Namespace, mdbsh.Name).Set(inRound)
+	chunkMigrationsRunning.WithLabelValues(mdbsh.Namespace, mdbsh.Name).Set(float64(len(status.Migrations)))
+	chunkMigrationsFailed.WithLabelValues(mdbsh.Namespace, mdbsh.Name).Set(float64(status.FailedMigrations))
+	jumboChunks.WithLabelValues(mdbsh.Namespace, mdbsh.Name).Set(float64(status.JumboChunks))
+}
+
+// forgetBalancerMetrics removes the balancer metrics of a deleted sharded cluster
+func forgetBalancerMetrics(mdbsh *mongodbv1alpha1.MongoDBSharded) {
+	for _, m := range balancerMetrics {
+		m.DeleteLabelValues(mdbsh.Namespace, mdbsh.Name)
+	}
+}
@@ -19,33 +19,42 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
-	"github.com/keiailab/mongodb-operator/internal/mongodb"
 	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
 )
 
 const (
 	mongodbFinalizer = "mongodb.keiailab.com/finalizer"
 	requeueAfter     = 30 * time.Second
+	retryAfter       = 10 * time.Second
 )
 
 // MongoDBReconciler reconciles a MongoDB object
 type MongoDBReconciler struct {
 	client.Client
-	Scheme *runtime.Scheme
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	Managers
 }
 
 // +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbs,verbs=get;list;watch;create;update;patch;delete
@@ -55,9 +64,13 @@ type MongoDBReconciler struct {
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;delete
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 
 func (r *MongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -74,6 +87,9 @@ func (r *MongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	// Exec calls of this reconcile share the rate limit and query results of the cluster
+	ctx = mongodb.WithClusterScope(ctx, mdb.Namespace, mdb.Name)
+
 	// Handle deletion
 	if !mdb.DeletionTimestamp.IsZero() {
 		return r.handleDeletion(ctx, mdb)
@@ -96,75 +112,245 @@ func (r *MongoDBReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		}
 	}
 
+	// Validate the spec before touching any workload
+	if err := resources.ValidateAuth(mdb.Spec.Auth, mdb.Spec.Version.Version); err != nil {
+		return r.updateStatusError(ctx, mdb, "Auth", err)
+	}
+	if err := resources.ValidateUserConnectionSecrets(mdb.Name, mdb.Spec.Auth.Users); err != nil {
+		return r.updateStatusError(ctx, mdb, "Users", err)
+	}
+	if err := resources.ValidateMonitoring(mdb.Spec.Monitoring); err != nil {
+		return r.updateStatusError(ctx, mdb, "Monitoring", err)
+	}
+	if err := resources.ValidateMode(mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "Mode", err)
+	}
+	if err := resources.ValidateMultiCluster(mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "MultiCluster", err)
+	}
+	if err := resources.ValidateReplicaOf(mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "ReplicaOf", err)
+	}
+	if err := resources.ValidateMemberOverrides(mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "MemberOverrides", err)
+	}
+	if err := resources.ValidateVotingMembers(mdb); err != nil {
+		meta.SetStatusCondition(&mdb.Status.Conditions, resources.BuildInvalidSpecCondition(err, mdb.Generation))
+		return r.updateStatusError(ctx, mdb, "Members", err)
+	}
+	if warning := resources.EvenVotingMembersWarning(resources.VotingMembers(mdb)); warning != "" && !resources.Standalone(mdb) &&
+		mdb.Status.ObservedGeneration != mdb.Generation {
+		r.event(mdb, corev1.EventTypeWarning, "EvenVotingMembers", warning)
+	}
+	if err := resources.ValidateAdditionalConfig(mdb.Spec.AdditionalConfig); err != nil {
+		return r.updateStatusError(ctx, mdb, "AdditionalConfig", err)
+	}
+	if err := resources.ValidateStorageEngine(mdb.Spec.StorageEngine, mdb.Spec.AdditionalConfig); err != nil {
+		return r.updateStatusError(ctx, mdb, "StorageEngine", err)
+	}
+	if err := resources.ValidateResourceProfile(mdb.Spec.ResourceProfile, map[string]mongodbv1alpha1.ResourcesSpec{
+		resources.ModeReplicaSet: mdb.Spec.Resources,
+	}); err != nil {
+		return r.updateStatusError(ctx, mdb, "ResourceProfile", err)
+	}
+	if err := resources.ValidateDiagnostics(mdb.Spec.Profiling, mdb.Spec.Logging); err != nil {
+		return r.updateStatusError(ctx, mdb, "Diagnostics", err)
+	}
+	if err := resources.ValidateInitScripts(mdb.Spec.InitScripts); err != nil {
+		return r.updateStatusError(ctx, mdb, "InitScripts", err)
+	}
+	if err := resources.ValidateUpdateStrategy(mdb); err != nil {
+		meta.SetStatusCondition(&mdb.Status.Conditions, resources.BuildInvalidSpecCondition(err, mdb.Generation))
+		return r.updateStatusError(ctx, mdb, "UpdateStrategy", err)
+	}
+
+	// Refuse changes that would wedge the members unless they are forced
+	settings := resources.ReplicaSetSettings(mdb)
+	if err := resources.ValidateSettingsChange(mdb.Status.AppliedSettings, settings); err != nil && !resources.ForceUpdate(mdb.Annotations) {
+		meta.SetStatusCondition(&mdb.Status.Conditions, resources.BuildInvalidUpdateCondition(err, mdb.Generation))
+		return r.updateStatusError(ctx, mdb, "Update", err)
+	}
+	mdb.Status.AppliedSettings = settings
+
 	// Reconcile resources in order
 
-	// 1. Keyfile Secret
+	// 1. Admin credentials Secret (generated when not referenced)
+	if err := r.reconcileAdminSecret(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "AdminSecret", err)
+	}
+
+	// 2. Exporter credentials Secret
+	if resources.MonitoringEnabled(mdb.Spec.Monitoring) {
+		if err := ensureMonitoringSecret(ctx, r.Client, mdb.Name, mdb.Namespace); err != nil {
+			return r.updateStatusError(ctx, mdb, "MonitoringSecret", err)
+		}
+	}
+
+	// 3. Keyfile Secret
 	if err := r.reconcileKeyfileSecret(ctx, mdb); err != nil {
 		return r.updateStatusError(ctx, mdb, "KeyfileSecret", err)
 	}
 
-	// 2. ConfigMap
+	// 4. ServiceAccount of the database pods
+	if err := r.reconcileServiceAccount(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "ServiceAccount", err)
+	}
+
+	// 5. ConfigMap
 	if err := r.reconcileConfigMap(ctx, mdb); err != nil {
 		return r.updateStatusError(ctx, mdb, "ConfigMap", err)
 	}
 
-	// 3. Headless Service
+	// 6. Headless Service
 	if err := r.reconcileHeadlessService(ctx, mdb); err != nil {
 		return r.updateStatusError(ctx, mdb, "HeadlessService", err)
 	}
 
-	// 4. Client Service
+	// 7. Client Service
 	if err := r.reconcileClientService(ctx, mdb); err != nil {
 		return r.updateStatusError(ctx, mdb, "ClientService", err)
 	}
 
-	// 5. StatefulSet
+	// 8. Per-member external Services
+	if err := r.reconcileExternalAccess(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "ExternalAccess", err)
+	}
+
+	// 9. StatefulSet
 	if err := r.reconcileStatefulSet(ctx, mdb); err != nil {
 		return r.updateStatusError(ctx, mdb, "StatefulSet", err)
 	}
 
-	// 6. Wait for all pods to be ready
+	// 10. Forced reconfiguration to the surviving members (requested through the force-reconfig annotation)
+	if err := r.reconcileForceReconfig(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "ForceReconfig", err)
+	}
+
+	// 11. Resync members stuck in recovery or a crash loop
+	if err := r.reconcileStaleMembers(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "StaleMembers", err)
+	}
+
+	// 12. Wait for all pods to be ready
 	allReady, err := r.areAllPodsReady(ctx, mdb)
 	if err != nil {
 		return r.updateStatusError(ctx, mdb, "PodReadiness", err)
 	}
 	if !allReady {
 		logger.Info("Waiting for all pods to be ready")
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
 	}
 
-	// 7. Initialize replica set if not initialized
-	if !mdb.Status.ReplicaSetInitialized {
+	// 13. Initialize replica set if not initialized, or join the members of a disaster recovery
+	// cluster to the remote replica set
+	if resources.ReplicaOfEnabled(mdb) {
+		if err := r.reconcileReplicaOf(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "ReplicaOf", err)
+		}
+	} else if !mdb.Status.ReplicaSetInitialized && !resources.Standalone(mdb) {
 		if err := r.reconcileReplicaSetInitialization(ctx, mdb); err != nil {
 			return r.updateStatusError(ctx, mdb, "ReplicaSetInit", err)
 		}
 	}
 
-	// 8. Wait for primary election
+	// 14. Wait for primary election
 	hasPrimary, err := r.hasPrimary(ctx, mdb)
 	if err != nil {
 		logger.Info("Waiting for primary election", "error", err)
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
 	}
 	if !hasPrimary {
 		logger.Info("Waiting for primary election")
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	// 15. The replica set configuration and users are changed on the primary, which may run in
+	// another Kubernetes cluster of a multi-cluster replica set or a disaster recovery cluster
+	managed, err := r.managesReplicaSet(ctx, mdb)
+	if err != nil {
+		return r.updateStatusError(ctx, mdb, "MultiCluster", err)
 	}
+	if managed {
+		// 16. Create admin user if not created
+		if !mdb.Status.AdminUserCreated {
+			if err := r.reconcileAdminUser(ctx, mdb); err != nil {
+				return r.updateStatusError(ctx, mdb, "AdminUser", err)
+			}
+			if !mdb.Status.AdminUserCreated {
+				logger.Info("Waiting for the admin bootstrap Job")
+				return ctrl.Result{RequeueAfter: retryInterval()}, nil
+			}
+		}
+
+		// 17. Members of the other Kubernetes clusters of a multi-cluster replica set, which join
+		// once the admin user exists
+		if err := r.reconcileMultiClusterMembers(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "MultiClusterMembers", err)
+		}
+
+		// 18. Rotate admin password when the credentials secret changed
+		if err := r.reconcileAdminPassword(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "PasswordRotation", err)
+		}
+
+		// 19. Exporter user
+		if err := r.reconcileMonitoringUser(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "MonitoringUser", err)
+		}
 
-	// 9. Create admin user if not created
-	if !mdb.Status.AdminUserCreated {
-		if err := r.reconcileAdminUser(ctx, mdb); err != nil {
-			return r.updateStatusError(ctx, mdb, "AdminUser", err)
+		// 20. Init scripts, run once after the admin user is created
+		if err := r.reconcileInitScripts(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "InitScripts", err)
 		}
+
+		// 21. Replica set horizons advertising the external hosts
+		if err := r.reconcileHorizons(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "Horizons", err)
+		}
+
+		// 22. Priorities, votes, hidden and delayed members from the member overrides
+		if err := r.reconcileMemberSettings(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "MemberSettings", err)
+		}
+
+		// 23. Election and replication settings of the replica set
+		if err := r.reconcileReplicaSetSettings(ctx, mdb); err != nil {
+			return r.updateStatusError(ctx, mdb, "ReplicaSetSettings", err)
+		}
+	}
+
+	// 24. Profiler and log verbosity of every member
+	if err := r.reconcileDiagnostics(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "Diagnostics", err)
+	}
+
+	// 25. Keyfile rotation (requested through the rotate-keyfile annotation)
+	if err := r.reconcileKeyfileRotation(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "KeyfileRotation", err)
+	}
+
+	// 26. Connection Secret for applications
+	if err := r.reconcileConnectionSecret(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "ConnectionSecret", err)
+	}
+
+	// 27. Disk usage of every member
+	if err := r.reconcileStorageUsage(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "StorageUsage", err)
+	}
+
+	// 28. Image and version every member runs
+	if err := r.reconcileMemberVersions(ctx, mdb); err != nil {
+		return r.updateStatusError(ctx, mdb, "MemberVersions", err)
 	}
 
-	// 10. Update status
+	// 29. Update status
 	if err := r.updateStatus(ctx, mdb); err != nil {
 		return ctrl.Result{}, err
 	}
 
 	logger.Info("Successfully reconciled MongoDB")
-	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	return ctrl.Result{RequeueAfter: requeueInterval()}, nil
 }
 
 func (r *MongoDBReconciler) handleDeletion(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (ctrl.Result, error) {
@@ -174,6 +360,8 @@ func (r *MongoDBReconciler) handleDeletion(ctx context.Context, mdb *mongodbv1al
 	if controllerutil.ContainsFinalizer(mdb, mongodbFinalizer) {
 		// Perform cleanup logic here if needed
 
+		r.Executor.ForgetCluster(mdb.Namespace, mdb.Name)
+
 		// Remove finalizer
 		controllerutil.RemoveFinalizer(mdb, mongodbFinalizer)
 		if err := r.Update(ctx, mdb); err != nil {
@@ -184,7 +372,41 @@ func (r *MongoDBReconciler) handleDeletion(ctx context.Context, mdb *mongodbv1al
 	return ctrl.Result{}, nil
 }
 
+func (r *MongoDBReconciler) reconcileAdminSecret(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	// Referenced secrets are owned by the user
+	if !resources.IsAdminSecretGenerated(mdb.Spec.Auth) {
+		return nil
+	}
+
+	// Never regenerate an existing secret - the admin user password lives in the database
+	existingSecret := &corev1.Secret{}
+	secretName := resources.AdminSecretName(mdb.Name, mdb.Spec.Auth)
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: mdb.Namespace}, existingSecret)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	// No owner reference: the secret must outlive the CR just like the data volumes,
+	// otherwise recreating the cluster would generate a password that no longer matches
+	secret := resources.BuildAdminSecret(mdb.Name, mdb.Namespace)
+	return r.Create(ctx, secret)
+}
+
 func (r *MongoDBReconciler) reconcileKeyfileSecret(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	// A standalone mongod has no other members to authenticate
+	if resources.Standalone(mdb) {
+		return nil
+	}
+
+	// Referenced keyfiles are owned by the user, only make sure they are usable
+	if !resources.IsKeyfileSecretGenerated(mdb.Spec.Auth) {
+		_, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+		return err
+	}
+
 	// Check if keyfile secret already exists - DO NOT regenerate if it exists
 	// Keyfile must remain constant across all pods for replica set authentication
 	existingSecret := &corev1.Secret{}
@@ -206,9 +428,21 @@ func (r *MongoDBReconciler) reconcileKeyfileSecret(ctx context.Context, mdb *mon
 	return r.Create(ctx, secret)
 }
 
+// reconcileServiceAccount creates the ServiceAccount of the pods unless every pod spec names its own
+func (r *MongoDBReconciler) reconcileServiceAccount(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if !resources.UsesClusterServiceAccount(mdb.Spec.Pod) {
+		return nil
+	}
+	return r.createOrUpdate(ctx, mdb, resources.BuildServiceAccount(mdb.Name, mdb.Namespace))
+}
+
 func (r *MongoDBReconciler) reconcileConfigMap(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
 	cm := resources.BuildMongoDBConfigMap(mdb)
-	return r.createOrUpdate(ctx, mdb, cm)
+	if err := r.createOrUpdate(ctx, mdb, cm); err != nil {
+		return err
+	}
+
+	return r.createOrUpdate(ctx, mdb, resources.BuildReplicaSetConfigMap(mdb))
 }
 
 func (r *MongoDBReconciler) reconcileHeadlessService(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
@@ -221,18 +455,116 @@ func (r *MongoDBReconciler) reconcileClientService(ctx context.Context, mdb *mon
 	return r.createOrUpdate(ctx, mdb, svc)
 }
 
+func (r *MongoDBReconciler) reconcileExternalAccess(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	var wanted map[string]bool
+	var hosts []string
+	if resources.ExternalAccessEnabled(mdb) {
+		first, count := resources.LocalMembers(mdb)
+		wanted = make(map[string]bool, count)
+		for i, svc := range resources.BuildExternalServices(mdb) {
+			ordinal := first + int32(i)
+			if err := r.createOrUpdate(ctx, mdb, svc); err != nil {
+				return err
+			}
+			wanted[svc.Name] = true
+
+			// Read back the Service for the addresses allocated by the cluster
+			current := &corev1.Service{}
+			if err := r.Get(ctx, types.NamespacedName{Name: svc.Name, Namespace: mdb.Namespace}, current); err != nil {
+				return err
+			}
+			pod := &corev1.Pod{}
+			if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%d", mdb.Name, ordinal), Namespace: mdb.Namespace}, pod); err != nil {
+				if !errors.IsNotFound(err) {
+					return err
+				}
+				pod = nil
+			}
+			hosts = append(hosts, resources.ExternalHost(mdb, ordinal, current, pod))
+		}
+	}
+	mdb.Status.ExternalHosts = hosts
+
+	// Remove Services of members scaled away, or all of them once external access is disabled
+	services := &corev1.ServiceList{}
+	if err := r.List(ctx, services, client.InNamespace(mdb.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/instance":  mdb.Name,
+		"app.kubernetes.io/component": resources.ExternalComponent,
+	}); err != nil {
+		return fmt.Errorf("failed to list external services: %w", err)
+	}
+	for i := range services.Items {
+		if wanted[services.Items[i].Name] {
+			continue
+		}
+		if err := client.IgnoreNotFound(r.Delete(ctx, &services.Items[i])); err != nil {
+			return fmt.Errorf("failed to delete external service %s: %w", services.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
 func (r *MongoDBReconciler) reconcileStatefulSet(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if err := r.advanceRollout(ctx, mdb); err != nil {
+		return err
+	}
+
 	sts := resources.BuildReplicaSetStatefulSet(mdb)
+	if resources.Standalone(mdb) {
+		return r.createOrUpdate(ctx, mdb, sts)
+	}
+
+	keyfileHash, err := keyfileTemplateHash(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth, mdb.Status.KeyfileRotation)
+	if err != nil {
+		return err
+	}
+	if keyfileHash != "" {
+		resources.SetKeyfileHashAnnotation(&sts.Spec.Template, keyfileHash)
+	}
+
 	return r.createOrUpdate(ctx, mdb, sts)
 }
 
+// advanceRollout moves a canary update on before the StatefulSet is written, so the partition is
+// lifted in the same reconcile once the rollout is released
+func (r *MongoDBReconciler) advanceRollout(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if !resources.CanaryUpdate(mdb) {
+		mdb.Status.Rollout = nil
+		return nil
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: mdb.Name, Namespace: mdb.Namespace}, sts); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	// The canary is the member with the highest ordinal, the first one the StatefulSet replaces
+	first, members := resources.LocalMembers(mdb)
+	canary := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: fmt.Sprintf("%s-%d", mdb.Name, first+members-1), Namespace: mdb.Namespace}, canary); err != nil {
+		if !errors.IsNotFound(err) {
+			return err
+		}
+		canary = nil
+	}
+
+	rollout := resources.AdvanceRollout(mdb.Status.Rollout, mdb.Spec.UpdateStrategy, sts, canary, mdb.Annotations, time.Now())
+	if rollout != nil && (mdb.Status.Rollout == nil || mdb.Status.Rollout.Revision != rollout.Revision || mdb.Status.Rollout.Phase != rollout.Phase) {
+		log.FromContext(ctx).Info("Canary update", "revision", rollout.Revision, "phase", rollout.Phase)
+		r.event(mdb, corev1.EventTypeNormal, "CanaryUpdate", fmt.Sprintf("Revision %s: %s", rollout.Revision, rollout.Phase))
+	}
+	mdb.Status.Rollout = rollout
+	return nil
+}
+
 func (r *MongoDBReconciler) areAllPodsReady(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (bool, error) {
 	sts := &appsv1.StatefulSet{}
 	if err := r.Get(ctx, types.NamespacedName{Name: mdb.Name, Namespace: mdb.Namespace}, sts); err != nil {
 		return false, err
 	}
 
-	return sts.Status.ReadyReplicas == mdb.Spec.Members, nil
+	_, members := resources.LocalMembers(mdb)
+	return sts.Status.ReadyReplicas == members, nil
 }
 
 func (r *MongoDBReconciler) reconcileReplicaSetInitialization(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
@@ -240,13 +572,10 @@ func (r *MongoDBReconciler) reconcileReplicaSetInitialization(ctx context.Contex
 	logger.Info("Initializing replica set")
 
 	// Create replica set manager
-	rsManager, err := mongodb.NewReplicaSetManager()
-	if err != nil {
-		return fmt.Errorf("failed to create replica set manager: %w", err)
-	}
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
 
 	// Check if already initialized by querying first pod
-	firstPod := fmt.Sprintf("%s-0", mdb.Name)
+	firstPod := resources.FirstMemberPod(mdb)
 	initialized, err := rsManager.IsInitialized(ctx, firstPod, mdb.Namespace)
 	if err != nil {
 		logger.Info("Failed to check initialization status, will retry", "error", err)
@@ -256,19 +585,32 @@ func (r *MongoDBReconciler) reconcileReplicaSetInitialization(ctx context.Contex
 	if initialized {
 		logger.Info("Replica set already initialized")
 		mdb.Status.ReplicaSetInitialized = true
+		// The first Kubernetes cluster of a multi-cluster replica set only adds the members of the
+		// others once it created the admin user
+		if !resources.MultiClusterCoordinator(mdb) {
+			mdb.Status.AdminUserCreated = true
+		}
 		return r.Status().Update(ctx, mdb)
 	}
 
-	// Build replica set configuration
-	serviceName := mdb.Name + "-headless"
-	config := mongodb.BuildReplicaSetConfig(
-		mdb.Spec.ReplicaSetName,
-		mdb.Name,
-		serviceName,
-		mdb.Namespace,
-		int(mdb.Spec.Members),
-		27017,
-	)
+	// The other Kubernetes clusters of a multi-cluster replica set wait for the first one to add their members
+	if !resources.MultiClusterCoordinator(mdb) {
+		logger.Info("Waiting for the first Kubernetes cluster to add the members to the replica set")
+		return nil
+	}
+
+	// Build replica set configuration. A multi-cluster replica set is initiated with the members of
+	// this Kubernetes cluster, the others are added once it has a primary.
+	first, count := resources.LocalMembers(mdb)
+	config := mongodb.ReplicaSetConfig{ID: mdb.Spec.ReplicaSetName}
+	for i := first; i < first+count; i++ {
+		config.Members = append(config.Members, mongodb.ReplicaSetMember{
+			ID:   int(i),
+			Host: resources.MemberHost(mdb, i),
+			// A replica set with more than seven members can only be initiated with the others non-voting
+			NonVoting: resources.MemberVotes(resources.MemberOverrideFor(mdb, i)) == 0,
+		})
+	}
 
 	// Initialize replica set
 	if err := rsManager.Initiate(ctx, firstPod, mdb.Namespace, config); err != nil {
@@ -281,13 +623,48 @@ func (r *MongoDBReconciler) reconcileReplicaSetInitialization(ctx context.Contex
 }
 
 func (r *MongoDBReconciler) hasPrimary(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (bool, error) {
-	rsManager, err := mongodb.NewReplicaSetManager()
+	// A standalone mongod accepts writes as soon as it is ready
+	if resources.Standalone(mdb) {
+		return true, nil
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	return rsManager.HasPrimary(ctx, resources.FirstMemberPod(mdb), mdb.Namespace)
+}
+
+// getPrimaryPod returns the pod accepting writes, the only pod of a standalone deployment
+func (r *MongoDBReconciler) getPrimaryPod(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (string, error) {
+	firstPod := resources.FirstMemberPod(mdb)
+	if resources.Standalone(mdb) {
+		return firstPod, nil
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	primaryPod, err := rsManager.GetPrimaryPod(ctx, firstPod, mdb.Namespace)
 	if err != nil {
-		return false, err
+		return "", fmt.Errorf("failed to get primary pod: %w", err)
 	}
+	return primaryPod, nil
+}
 
-	firstPod := fmt.Sprintf("%s-0", mdb.Name)
-	return rsManager.HasPrimary(ctx, firstPod, mdb.Namespace)
+// getMemberCredentials returns the credentials the operator authenticates to the members with: the
+// internal __system user for a replica set, the admin user for a standalone mongod, which has no keyfile
+func (r *MongoDBReconciler) getMemberCredentials(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (memberCredentials, error) {
+	if resources.Standalone(mdb) {
+		creds, err := getAdminCredentials(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+		if err != nil {
+			return memberCredentials{}, fmt.Errorf("failed to get admin credentials: %w", err)
+		}
+		return memberCredentials{Username: creds.Username, Password: creds.Password, Database: "admin"}, nil
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return memberCredentials{}, err
+	}
+	return keyfileCredentials(keyfile), nil
 }
 
 func (r *MongoDBReconciler) reconcileAdminUser(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
@@ -295,60 +672,382 @@ func (r *MongoDBReconciler) reconcileAdminUser(ctx context.Context, mdb *mongodb
 	logger.Info("Creating admin user")
 
 	// Get admin credentials from secret
-	adminPassword, err := r.getAdminPassword(ctx, mdb)
+	creds, err := getAdminCredentials(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
 	if err != nil {
-		return fmt.Errorf("failed to get admin password: %w", err)
+		return fmt.Errorf("failed to get admin credentials: %w", err)
 	}
 
-	// Find the primary pod
-	rsManager, err := mongodb.NewReplicaSetManager()
-	if err != nil {
-		return fmt.Errorf("failed to create replica set manager: %w", err)
+	// The bootstrap Job creates the user, which only exists once the Job completed
+	if resources.JobAuthBootstrap(mdb.Spec.Auth) {
+		done, err := runAuthBootstrapJob(ctx, r.Client, r.Scheme, mdb, resources.BuildAuthBootstrapJob(mdb))
+		if err != nil || !done {
+			return err
+		}
+		logger.Info("Admin user created by the bootstrap Job")
+		mdb.Status.AdminUserCreated = true
+		mdb.Status.AdminPasswordHash = hashPassword(mdb.UID, creds.Password)
+		return r.Status().Update(ctx, mdb)
 	}
 
-	firstPod := fmt.Sprintf("%s-0", mdb.Name)
-	primaryPod, err := rsManager.GetPrimaryPod(ctx, firstPod, mdb.Namespace)
+	// Find the primary pod
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
 	if err != nil {
-		return fmt.Errorf("failed to get primary pod: %w", err)
+		return err
 	}
 
 	// Create auth manager
-	authManager, err := mongodb.NewAuthManager()
-	if err != nil {
-		return fmt.Errorf("failed to create auth manager: %w", err)
-	}
+	authManager := r.authManager()
 
 	// Check if admin user already exists
-	exists, _ := authManager.UserExists(ctx, primaryPod, mdb.Namespace, "admin", "admin")
+	exists, _ := authManager.UserExistsInContainer(ctx, primaryPod, mdb.Namespace, "mongodb", creds.Username, "admin", int(resources.ReplicaSetPort(mdb)))
 	if exists {
 		logger.Info("Admin user already exists")
 		mdb.Status.AdminUserCreated = true
+		mdb.Status.AdminPasswordHash = hashPassword(mdb.UID, creds.Password)
 		return r.Status().Update(ctx, mdb)
 	}
 
 	// Create admin user using localhost exception
-	if err := authManager.CreateAdminUser(ctx, primaryPod, mdb.Namespace, "admin", adminPassword); err != nil {
+	if err := authManager.CreateAdminUserInContainer(ctx, primaryPod, mdb.Namespace, "mongodb", creds.Username, creds.Password, int(resources.ReplicaSetPort(mdb))); err != nil {
 		return fmt.Errorf("failed to create admin user: %w", err)
 	}
 
 	logger.Info("Admin user created successfully")
 	mdb.Status.AdminUserCreated = true
+	mdb.Status.AdminPasswordHash = hashPassword(mdb.UID, creds.Password)
+	return r.Status().Update(ctx, mdb)
+}
+
+func (r *MongoDBReconciler) reconcileAdminPassword(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	logger := log.FromContext(ctx)
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	hash := hashPassword(mdb.UID, creds.Password)
+	if mdb.Status.AdminPasswordHash == hash {
+		return nil
+	}
+
+	// Clusters created before rotation support have no recorded hash: adopt the current password
+	if mdb.Status.AdminPasswordHash == "" {
+		mdb.Status.AdminPasswordHash = hash
+		return r.Status().Update(ctx, mdb)
+	}
+
+	if resources.Standalone(mdb) {
+		return r.adoptStandaloneAdminPassword(ctx, mdb, creds, hash)
+	}
+
+	logger.Info("Admin credentials secret changed, rotating admin password")
+
+	// The previous password is gone, so authenticate with the keyfile as the internal user
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	authManager := r.authManager()
+
+	if err := authManager.UpdatePasswordWithKeyfile(ctx, primaryPod, mdb.Namespace, "mongodb", keyfile, creds.Username, "admin", creds.Password, int(resources.ReplicaSetPort(mdb))); err != nil {
+		return fmt.Errorf("failed to rotate admin password: %w", err)
+	}
+
+	logger.Info("Admin password rotated successfully")
+	mdb.Status.AdminPasswordHash = hash
+	setPasswordRotatedCondition(&mdb.Status.Conditions, mdb.Generation)
+	return r.Status().Update(ctx, mdb)
+}
+
+// adoptStandaloneAdminPassword records a new admin password of a standalone mongod. Without a keyfile
+// the operator cannot change a password it no longer knows, so the password must have been changed
+// in the database before the credentials secret.
+func (r *MongoDBReconciler) adoptStandaloneAdminPassword(ctx context.Context, mdb *mongodbv1alpha1.MongoDB, creds *adminCredentials, hash string) error {
+	authManager := r.authManager()
+
+	pod := fmt.Sprintf("%s-0", mdb.Name)
+	if err := authManager.AuthenticateInContainer(ctx, pod, mdb.Namespace, "mongodb", creds.Username, creds.Password, "admin", int(resources.ReplicaSetPort(mdb))); err != nil {
+		return fmt.Errorf("standalone mode cannot rotate the admin password, change it with db.changeUserPassword() before updating the credentials secret: %w", err)
+	}
+
+	log.FromContext(ctx).Info("Admin password was changed in the database, recording it")
+	mdb.Status.AdminPasswordHash = hash
+	setPasswordRotatedCondition(&mdb.Status.Conditions, mdb.Generation)
 	return r.Status().Update(ctx, mdb)
 }
 
-func (r *MongoDBReconciler) getAdminPassword(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (string, error) {
-	secret := &corev1.Secret{}
-	secretName := mdb.Spec.Auth.AdminCredentialsSecretRef.Name
-	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: mdb.Namespace}, secret); err != nil {
-		return "", fmt.Errorf("failed to get admin credentials secret: %w", err)
+// reconcileMonitoringUser creates the exporter user and keeps its password in sync with the credentials secret
+func (r *MongoDBReconciler) reconcileMonitoringUser(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if !resources.MonitoringEnabled(mdb.Spec.Monitoring) {
+		return nil
+	}
+
+	user, err := getMonitoringUser(ctx, r.Client, mdb.Name, mdb.Namespace)
+	if err != nil {
+		return err
+	}
+
+	hash := hashPassword(mdb.UID, user.Password)
+	if mdb.Status.MonitoringPasswordHash == hash {
+		return nil
+	}
+
+	creds, err := r.getMemberCredentials(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return err
 	}
 
-	password, ok := secret.Data["password"]
-	if !ok {
-		return "", fmt.Errorf("password key not found in secret %s", secretName)
+	authManager := r.authManager()
+
+	if err := authManager.UpsertUserWithAuth(ctx, primaryPod, mdb.Namespace, "mongodb", creds.Username, creds.Password, creds.Database, user, int(resources.ReplicaSetPort(mdb))); err != nil {
+		return fmt.Errorf("failed to apply monitoring user: %w", err)
 	}
 
-	return string(password), nil
+	log.FromContext(ctx).Info("Monitoring user applied", "user", user.Username)
+	mdb.Status.MonitoringPasswordHash = hash
+	return r.Status().Update(ctx, mdb)
+}
+
+// reconcileInitScripts runs the init scripts that did not run yet on the primary
+func (r *MongoDBReconciler) reconcileInitScripts(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if resources.InitScriptsDone(mdb.Spec.InitScripts, mdb.Status.InitScripts) {
+		return nil
+	}
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return err
+	}
+	return r.applyInitScripts(ctx, r.Client, mdb, mdb.Spec.Auth, mdb.Spec.InitScripts, &mdb.Status.InitScripts,
+		initScriptTarget{pod: primaryPod, container: "mongodb", port: int(resources.ReplicaSetPort(mdb))})
+}
+
+// reconcileHorizons keeps the replica set horizons in sync with the external hosts of the members
+func (r *MongoDBReconciler) reconcileHorizons(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	// Clients connect directly to a standalone mongod, there is no member list to advertise. The
+	// members of a multi-cluster replica set are configured with their external host names already.
+	if resources.Standalone(mdb) || resources.MultiClusterEnabled(mdb) {
+		return nil
+	}
+
+	var hosts []string
+	horizons := map[string]map[string]string{}
+	if resources.ExternalAccessEnabled(mdb) {
+		horizons = resources.BuildHorizons(mdb, mdb.Status.ExternalHosts)
+		if horizons == nil {
+			// Horizons are set on all members at once, wait until every external address is assigned
+			return nil
+		}
+		hosts = mdb.Status.ExternalHosts
+	}
+	if slices.Equal(hosts, mdb.Status.HorizonHosts) {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+	logger.Info("External hosts changed, updating replica set horizons", "hosts", hosts)
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	firstPod := fmt.Sprintf("%s-0", mdb.Name)
+	primaryPod, err := rsManager.GetPrimaryPod(ctx, firstPod, mdb.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get primary pod: %w", err)
+	}
+
+	if err := rsManager.SetHorizonsWithKeyfile(ctx, primaryPod, mdb.Namespace, keyfile, horizons); err != nil {
+		return err
+	}
+
+	mdb.Status.HorizonHosts = hosts
+	return r.Status().Update(ctx, mdb)
+}
+
+// reconcileMemberSettings applies the member overrides to the replica set configuration when they change
+func (r *MongoDBReconciler) reconcileMemberSettings(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if resources.Standalone(mdb) {
+		return nil
+	}
+
+	// Leave the configuration alone until overrides are used, it may have been tuned by hand
+	if len(mdb.Spec.MemberOverrides) == 0 && mdb.Status.MemberSettingsHash == "" {
+		return nil
+	}
+
+	settings := buildMemberSettings(mdb)
+	hash := settingsHash(settings)
+	if mdb.Status.MemberSettingsHash == hash {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Member overrides changed, reconfiguring replica set members")
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	if err := rsManager.SetMemberSettingsWithKeyfile(ctx, primaryPod, mdb.Namespace, keyfile, settings); err != nil {
+		return err
+	}
+
+	mdb.Status.MemberSettingsHash = hash
+	return r.Status().Update(ctx, mdb)
+}
+
+// reconcileReplicaSetSettings merges spec.replicaSetSettings into the replica set configuration when it changes
+func (r *MongoDBReconciler) reconcileReplicaSetSettings(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if resources.Standalone(mdb) {
+		return nil
+	}
+
+	settings := resources.BuildReplicaSetSettings(mdb.Spec.ReplicaSetSettings)
+	hash := settingsHash(settings)
+	if len(settings) == 0 || mdb.Status.ReplicaSetSettingsHash == hash {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("Replica set settings changed, reconfiguring replica set", "settings", settings)
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	if err := rsManager.SetSettingsWithKeyfile(ctx, primaryPod, mdb.Namespace, keyfile, settings); err != nil {
+		return err
+	}
+
+	mdb.Status.ReplicaSetSettingsHash = hash
+	return r.Status().Update(ctx, mdb)
+}
+
+func (r *MongoDBReconciler) reconcileKeyfileRotation(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if resources.Standalone(mdb) {
+		return nil
+	}
+
+	keyfileHash, err := keyfileTemplateHash(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth, mdb.Status.KeyfileRotation)
+	if err != nil {
+		return err
+	}
+
+	sts := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, types.NamespacedName{Name: mdb.Name, Namespace: mdb.Namespace}, sts); err != nil {
+		return err
+	}
+
+	rotation, err := advanceKeyfileRotation(ctx, r.Client, mdb, mdb.Spec.Auth, mdb.Status.KeyfileRotation, statefulSetRolledOut(sts, keyfileHash))
+	if err != nil || rotation == nil {
+		return err
+	}
+
+	mdb.Status.KeyfileRotation = rotation
+	if rotation.Phase == resources.KeyfileRotationCompleted {
+		setKeyfileRotatedCondition(&mdb.Status.Conditions, mdb.Generation)
+	}
+	return r.Status().Update(ctx, mdb)
+}
+
+func (r *MongoDBReconciler) reconcileConnectionSecret(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	creds, err := getAdminCredentials(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	replicaSet := mdb.Spec.ReplicaSetName
+	if resources.Standalone(mdb) {
+		replicaSet = ""
+	}
+
+	info := resources.ConnectionInfo{
+		Host:       resources.ServiceFQDN(mdb.Name, mdb.Namespace, mdb.Spec.ClusterDomain),
+		Hosts:      resources.MemberHosts(mdb),
+		SRVHost:    resources.ServiceFQDN(mdb.Name+"-headless", mdb.Namespace, mdb.Spec.ClusterDomain),
+		Port:       int(resources.ReplicaSetPort(mdb)),
+		ReplicaSet: replicaSet,
+		TLS:        resources.TLSEnabled(mdb.Spec.TLS),
+		CACert:     getCACert(ctx, r.Client, mdb.Namespace, mdb.Spec.TLS),
+		Admin:      resources.UserCredentials{Username: creds.Username, Password: creds.Password, Database: "admin"},
+		Users:      getUserCredentials(ctx, r.Client, mdb.Namespace, mdb.Spec.Auth.Users),
+	}
+
+	secret := resources.BuildConnectionSecret(mdb.Name, mdb.Namespace, info)
+	if err := r.createOrUpdate(ctx, mdb, secret); err != nil {
+		return err
+	}
+
+	for _, secret := range resources.BuildUserConnectionSecrets(mdb.Name, mdb.Namespace, info, mdb.Spec.Auth.Users) {
+		if err := r.createOrUpdate(ctx, mdb, secret); err != nil {
+			return err
+		}
+	}
+	return deleteStaleUserConnectionSecrets(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth.Users)
+}
+
+// reconcileStorageUsage collects the disk usage of the members for the status and the StorageHealthy condition
+func (r *MongoDBReconciler) reconcileStorageUsage(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	creds, err := r.getMemberCredentials(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	first, count := resources.LocalMembers(mdb)
+	usage, err := r.collectStorageUsage(ctx, mdb.Name, mdb.Namespace, creds, first, count, int(resources.ReplicaSetPort(mdb)))
+	if err != nil {
+		return err
+	}
+
+	mdb.Status.Storage = usage
+	logMembersLowOnStorage(ctx, resources.MembersLowOnStorage(usage, mdb.Spec.Storage))
+	return nil
+}
+
+// reconcileMemberVersions collects the image and version of the members for the status and the Drifted condition
+func (r *MongoDBReconciler) reconcileMemberVersions(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	creds, err := r.getMemberCredentials(ctx, mdb)
+	if err != nil {
+		return err
+	}
+
+	first, count := resources.LocalMembers(mdb)
+	versions, err := r.collectMemberVersions(ctx, r.Client, mdb.Name, mdb.Namespace, creds, first, count, int(resources.ReplicaSetPort(mdb)))
+	if err != nil {
+		return err
+	}
+
+	mdb.Status.Versions = versions
+	return nil
 }
 
 func (r *MongoDBReconciler) createOrUpdate(ctx context.Context, mdb *mongodbv1alpha1.MongoDB, obj client.Object) error {
@@ -364,11 +1063,27 @@ func (r *MongoDBReconciler) createOrUpdate(ctx context.Context, mdb *mongodbv1al
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Create the object
+			resources.SetDesiredState(obj)
 			return r.Create(ctx, obj)
 		}
 		return err
 	}
 
+	// Volume claim templates are immutable, keep the ones the StatefulSet was created with
+	// so a new default StorageClass only applies to new clusters
+	if sts, ok := obj.(*appsv1.StatefulSet); ok {
+		sts.Spec.VolumeClaimTemplates = existing.(*appsv1.StatefulSet).Spec.VolumeClaimTemplates
+	}
+
+	// Keep the allocated and externally managed Service fields, e.g. node ports and the
+	// annotations of cloud controllers
+	if svc, ok := obj.(*corev1.Service); ok {
+		resources.PreserveServiceFields(svc, existing.(*corev1.Service))
+	}
+
+	// Changes made by others since the last update are reverted by it, report them
+	mdb.Status.Drift = checkDrift(ctx, obj, existing, mdb.Status.Drift)
+
 	// Update the object
 	obj.SetResourceVersion(existing.GetResourceVersion())
 	return r.Update(ctx, obj)
@@ -387,29 +1102,28 @@ func (r *MongoDBReconciler) updateStatus(ctx context.Context, mdb *mongodbv1alph
 	}
 
 	// Update phase based on ready members and initialization status
-	if mdb.Status.ReadyMembers == mdb.Spec.Members && mdb.Status.ReplicaSetInitialized && mdb.Status.AdminUserCreated {
+	_, members := resources.LocalMembers(mdb)
+	if mdb.Status.ReadyMembers == members && replicaSetInitialized(mdb) && mdb.Status.AdminUserCreated &&
+		resources.InitScriptsDone(mdb.Spec.InitScripts, mdb.Status.InitScripts) {
 		mdb.Status.Phase = "Running"
+		// Only published once clients can connect and authenticate
+		mdb.Status.ConnectionString = resources.ReplicaSetConnectionString(mdb)
+		mdb.Status.SRVConnectionString = resources.ReplicaSetSRVConnectionString(mdb)
 	} else if mdb.Status.ReadyMembers > 0 {
 		mdb.Status.Phase = "Initializing"
 	}
 
 	// Get current primary if replica set is initialized
 	if mdb.Status.ReplicaSetInitialized {
-		rsManager, err := mongodb.NewReplicaSetManager()
-		if err == nil {
-			firstPod := fmt.Sprintf("%s-0", mdb.Name)
-			if primaryPod, err := rsManager.GetPrimaryPod(ctx, firstPod, mdb.Namespace); err == nil {
-				mdb.Status.CurrentPrimary = primaryPod
-			}
+		rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+		if primaryPod, err := rsManager.GetPrimaryPod(ctx, resources.FirstMemberPod(mdb), mdb.Namespace); err == nil {
+			mdb.Status.CurrentPrimary = primaryPod
 		}
 	}
 
-	// Set connection string
-	mdb.Status.ConnectionString = fmt.Sprintf("mongodb://%s-headless.%s.svc.cluster.local:27017/?replicaSet=%s",
-		mdb.Name, mdb.Namespace, mdb.Spec.ReplicaSetName)
-
 	mdb.Status.Version = mdb.Spec.Version.Version
 	mdb.Status.ObservedGeneration = mdb.Generation
+	mdb.Status.Drift = resources.PruneDrift(mdb.Status.Drift, time.Now())
 
 	// Update conditions
 	mdb.Status.Conditions = r.buildConditions(mdb)
@@ -423,9 +1137,11 @@ func (r *MongoDBReconciler) buildConditions(mdb *mongodbv1alpha1.MongoDB) []meta
 	// Ready condition
 	readyStatus := metav1.ConditionFalse
 	readyReason := "NotReady"
-	readyMessage := fmt.Sprintf("%d/%d members ready", mdb.Status.ReadyMembers, mdb.Spec.Members)
+	_, members := resources.LocalMembers(mdb)
+	readyMessage := fmt.Sprintf("%d/%d members ready", mdb.Status.ReadyMembers, members)
 
-	if mdb.Status.ReadyMembers == mdb.Spec.Members && mdb.Status.ReplicaSetInitialized && mdb.Status.AdminUserCreated {
+	if mdb.Status.ReadyMembers == members && replicaSetInitialized(mdb) && mdb.Status.AdminUserCreated &&
+		resources.InitScriptsDone(mdb.Spec.InitScripts, mdb.Status.InitScripts) {
 		readyStatus = metav1.ConditionTrue
 		readyReason = "Ready"
 		readyMessage = "All members are ready and cluster is fully initialized"
@@ -440,7 +1156,7 @@ func (r *MongoDBReconciler) buildConditions(mdb *mongodbv1alpha1.MongoDB) []meta
 		Message:            readyMessage,
 	})
 
-	// ReplicaSetInitialized condition
+	// ReplicaSetInitialized condition, a standalone mongod has no replica set
 	rsInitStatus := metav1.ConditionFalse
 	rsInitReason := "NotInitialized"
 	rsInitMessage := "Replica set has not been initialized"
@@ -450,14 +1166,16 @@ func (r *MongoDBReconciler) buildConditions(mdb *mongodbv1alpha1.MongoDB) []meta
 		rsInitMessage = "Replica set has been initialized"
 	}
 
-	conditions = append(conditions, metav1.Condition{
-		Type:               "ReplicaSetInitialized",
-		Status:             rsInitStatus,
-		ObservedGeneration: mdb.Generation,
-		LastTransitionTime: metav1.Now(),
-		Reason:             rsInitReason,
-		Message:            rsInitMessage,
-	})
+	if !resources.Standalone(mdb) {
+		conditions = append(conditions, metav1.Condition{
+			Type:               "ReplicaSetInitialized",
+			Status:             rsInitStatus,
+			ObservedGeneration: mdb.Generation,
+			LastTransitionTime: metav1.Now(),
+			Reason:             rsInitReason,
+			Message:            rsInitMessage,
+		})
+	}
 
 	// AuthenticationReady condition
 	authStatus := metav1.ConditionFalse
@@ -478,9 +1196,49 @@ func (r *MongoDBReconciler) buildConditions(mdb *mongodbv1alpha1.MongoDB) []meta
 		Message:            authMessage,
 	})
 
+	// InvalidSpec condition, a spec failing validation never gets here
+	invalidSpecCondition := resources.BuildInvalidSpecCondition(nil, mdb.Generation)
+	invalidSpecCondition.LastTransitionTime = metav1.Now()
+	conditions = append(conditions, invalidSpecCondition)
+
+	// InvalidUpdate condition, a refused change never gets here either
+	invalidUpdateCondition := resources.BuildInvalidUpdateCondition(nil, mdb.Generation)
+	invalidUpdateCondition.LastTransitionTime = metav1.Now()
+	conditions = append(conditions, invalidUpdateCondition)
+
+	// Drifted condition
+	driftedCondition := resources.BuildDriftedCondition(mdb.Status.Versions, mdb.Spec.Version.Version, mdb.Status.Drift, mdb.Generation)
+	driftedCondition.LastTransitionTime = metav1.Now()
+	conditions = append(conditions, driftedCondition)
+
+	// StorageHealthy condition
+	storageCondition := resources.BuildStorageHealthyCondition(mdb.Status.Storage,
+		resources.MembersLowOnStorage(mdb.Status.Storage, mdb.Spec.Storage), mdb.Generation)
+	storageCondition.LastTransitionTime = metav1.Now()
+	conditions = append(conditions, storageCondition)
+
+	// MemberResync condition
+	if !resources.Standalone(mdb) {
+		resyncCondition := resources.BuildResyncCondition(mdb.Status.StaleMembers, mdb.Generation)
+		resyncCondition.LastTransitionTime = metav1.Now()
+		conditions = append(conditions, resyncCondition)
+	}
+
+	// Rotation conditions are only set when a rotation completes, keep them across rebuilds
+	for _, conditionType := range []string{"PasswordRotated", "KeyfileRotated"} {
+		if rotated := meta.FindStatusCondition(mdb.Status.Conditions, conditionType); rotated != nil {
+			conditions = append(conditions, *rotated)
+		}
+	}
+
 	return conditions
 }
 
+// replicaSetInitialized reports whether the replica set was initialized, which a standalone mongod never needs
+func replicaSetInitialized(mdb *mongodbv1alpha1.MongoDB) bool {
+	return mdb.Status.ReplicaSetInitialized || resources.Standalone(mdb)
+}
+
 func (r *MongoDBReconciler) updateStatusError(ctx context.Context, mdb *mongodbv1alpha1.MongoDB, component string, err error) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 	logger.Error(err, "Failed to reconcile component", "component", component)
@@ -498,7 +1256,26 @@ func (r *MongoDBReconciler) updateStatusError(ctx context.Context, mdb *mongodbv
 		logger.Error(statusErr, "Failed to update status")
 	}
 
-	return ctrl.Result{RequeueAfter: requeueAfter}, err
+	return ctrl.Result{RequeueAfter: requeueInterval()}, err
+}
+
+// findMongoDBsForSecret maps admin credentials, keyfile and user password secrets to the clusters using them.
+// These secrets may not be owned by the cluster, so Owns() does not pick up their changes.
+func (r *MongoDBReconciler) findMongoDBsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	mdbList := &mongodbv1alpha1.MongoDBList{}
+	if err := r.List(ctx, mdbList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, mdb := range mdbList.Items {
+		if usesSecret(obj.GetName(), mdb.Name, mdb.Spec.Auth) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: mdb.Name, Namespace: mdb.Namespace},
+			})
+		}
+	}
+	return requests
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -506,8 +1283,11 @@ func (r *MongoDBReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mongodbv1alpha1.MongoDB{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&batchv1.Job{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.ServiceAccount{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findMongoDBsForSecret)).
 		Complete(r)
 }
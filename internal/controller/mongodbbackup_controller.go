@@ -19,31 +19,54 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
 	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/connstring"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
 )
 
 const (
 	mongodbBackupFinalizer = "mongodbbackup.keiailab.com/finalizer"
+
+	// backupClusterRefIndex indexes backups by the name of the cluster they reference
+	backupClusterRefIndex = "spec.clusterRef.name"
+
+	// backupClusterReadyCondition reports whether the referenced cluster can be backed up
+	backupClusterReadyCondition = "ClusterReady"
+
+	// backupPhaseWaitingForCluster is the phase of backups whose cluster is not ready yet
+	backupPhaseWaitingForCluster = "WaitingForCluster"
+
+	// backupScheduledCondition reports whether the backup Job was created, or which backup of
+	// the cluster it waits for under the Forbid concurrency policy
+	backupScheduledCondition = "Scheduled"
 )
 
 // MongoDBBackupReconciler reconciles a MongoDBBackup object
 type MongoDBBackupReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	Managers
 }
 
 // +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbbackups,verbs=get;list;watch;create;update;patch;delete
@@ -94,17 +117,92 @@ func (r *MongoDBBackupReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		}
 	}
 
+	// Wait for the cluster to be ready before launching the Job, it would only fail against an
+	// initializing or failed cluster. The Ready transition of the cluster enqueues the backup
+	// again, the requeue covers missed events. A running Job is left to finish.
+	if backup.Status.Phase != "Running" {
+		ready, err := r.isClusterReady(ctx, backup)
+		if err != nil {
+			return r.updateStatusError(ctx, backup, err)
+		}
+		if !ready {
+			logger.Info("Waiting for cluster to be ready", "cluster", backup.Spec.ClusterRef.Name)
+			if err := r.setClusterReady(ctx, backup, metav1.ConditionFalse, "ClusterNotReady",
+				fmt.Sprintf("Waiting for %s %s to be ready", backup.Spec.ClusterRef.Kind, backup.Spec.ClusterRef.Name)); err != nil {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: 30 * time.Second}, nil
+		}
+		if err := r.setClusterReady(ctx, backup, metav1.ConditionTrue, "ClusterReady",
+			fmt.Sprintf("%s %s is ready", backup.Spec.ClusterRef.Kind, backup.Spec.ClusterRef.Name)); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Under the Forbid policy the Job is only created once no other backup Job of the cluster runs
+	if backup.Spec.ConcurrencyPolicy != mongodbv1alpha1.BackupConcurrencyAllow {
+		running, err := r.runningBackupJob(ctx, backup)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if running != "" {
+			logger.Info("Waiting for the running backup of the cluster", "job", running)
+			if meta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+				Type:               backupScheduledCondition,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: backup.Generation,
+				Reason:             "ConcurrentBackup",
+				Message:            fmt.Sprintf("Waiting for backup Job %s of the cluster to finish", running),
+			}) {
+				if err := r.Status().Update(ctx, backup); err != nil {
+					return ctrl.Result{}, err
+				}
+			}
+			return ctrl.Result{RequeueAfter: retryInterval()}, nil
+		}
+	}
+
 	// Get cluster connection string
-	connectionString, err := r.getClusterConnectionString(ctx, backup)
+	target, err := r.getBackupTarget(ctx, backup)
 	if err != nil {
 		return r.updateStatusError(ctx, backup, err)
 	}
 
+	// Record the archive and the oplog position before the Job is built, the Job dumps up to it.
+	// Running Jobs of earlier operator versions name their archive themselves.
+	if backup.Status.Archive == "" && backup.Status.Phase != "Running" {
+		window, err := r.oplogWindow(ctx, backup)
+		if err != nil {
+			if mongodb.IsRetryable(err) {
+				logger.Info("Failed to read the oplog of the cluster, retrying", "error", err)
+				return ctrl.Result{RequeueAfter: retryInterval()}, nil
+			}
+			return r.updateStatusError(ctx, backup, err)
+		}
+		if err := r.planBackup(ctx, backup, window); err != nil {
+			return r.updateStatusError(ctx, backup, err)
+		}
+		backup.Status.ClusterGeneration = target.generation
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	// Create backup job
-	job := resources.BuildBackupJob(backup, connectionString)
+	job := resources.BuildBackupJob(backup, target.uri, target.credentials)
+	if target.tlsSecret != "" {
+		resources.AddBackupTLS(job, target.tlsSecret)
+	}
 	if err := r.createOrUpdate(ctx, backup, job); err != nil {
 		return r.updateStatusError(ctx, backup, err)
 	}
+	meta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+		Type:               backupScheduledCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: backup.Generation,
+		Reason:             "JobCreated",
+		Message:            fmt.Sprintf("Backup Job %s was created", job.Name),
+	})
 
 	// Update status based on job status
 	if err := r.updateBackupStatus(ctx, backup, job.Name); err != nil {
@@ -113,7 +211,13 @@ func (r *MongoDBBackupReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	// If still running, requeue
 	if backup.Status.Phase == "Running" || backup.Status.Phase == "Pending" {
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	if backup.Status.Phase == "Completed" {
+		if err := r.updateBackupCatalog(ctx, backup); err != nil {
+			logger.Error(err, "Failed to update the backup catalog")
+		}
 	}
 
 	logger.Info("Successfully reconciled MongoDBBackup")
@@ -139,57 +243,198 @@ func (r *MongoDBBackupReconciler) handleDeletion(ctx context.Context, backup *mo
 		if err := r.Update(ctx, backup); err != nil {
 			return ctrl.Result{}, err
 		}
+
+		if backup.Status.Phase == "Completed" {
+			if err := r.updateBackupCatalog(ctx, backup); err != nil {
+				logger.Error(err, "Failed to update the backup catalog")
+			}
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
-func (r *MongoDBBackupReconciler) getClusterConnectionString(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (string, error) {
-	var host string
-	var authSecretName string
+// isClusterReady reports whether the Ready condition of the referenced cluster is true. A missing
+// cluster is waited for, it may be created after the backup.
+func (r *MongoDBBackupReconciler) isClusterReady(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (bool, error) {
+	return clusterReady(ctx, r.Client, backup.Namespace, backup.Spec.ClusterRef)
+}
 
-	switch backup.Spec.ClusterRef.Kind {
+// clusterReady reports whether the Ready condition of a referenced cluster is true, false when
+// the cluster does not exist
+func clusterReady(ctx context.Context, c client.Client, namespace string, ref mongodbv1alpha1.ClusterReference) (bool, error) {
+	key := types.NamespacedName{Name: ref.Name, Namespace: namespace}
+
+	switch ref.Kind {
 	case "MongoDB":
 		mdb := &mongodbv1alpha1.MongoDB{}
-		if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.ClusterRef.Name, Namespace: backup.Namespace}, mdb); err != nil {
-			return "", fmt.Errorf("failed to get MongoDB cluster: %w", err)
+		if err := c.Get(ctx, key, mdb); err != nil {
+			return false, client.IgnoreNotFound(err)
 		}
-		// Extract host from connection string (remove mongodb:// prefix)
-		host = mdb.Name + "." + backup.Namespace + ".svc.cluster.local:27017"
-		authSecretName = mdb.Spec.Auth.AdminCredentialsSecretRef.Name
+		return meta.IsStatusConditionTrue(mdb.Status.Conditions, "Ready"), nil
 
 	case "MongoDBSharded":
 		mdbsh := &mongodbv1alpha1.MongoDBSharded{}
-		if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.ClusterRef.Name, Namespace: backup.Namespace}, mdbsh); err != nil {
-			return "", fmt.Errorf("failed to get MongoDBSharded cluster: %w", err)
+		if err := c.Get(ctx, key, mdbsh); err != nil {
+			return false, client.IgnoreNotFound(err)
 		}
-		host = mdbsh.Name + "-mongos." + backup.Namespace + ".svc.cluster.local:27017"
-		authSecretName = mdbsh.Spec.Auth.AdminCredentialsSecretRef.Name
+		return meta.IsStatusConditionTrue(mdbsh.Status.Conditions, "Ready"), nil
 
 	default:
-		return "", fmt.Errorf("unknown cluster kind: %s", backup.Spec.ClusterRef.Kind)
+		return false, fmt.Errorf("unknown cluster kind: %s", ref.Kind)
 	}
+}
 
-	// Get admin credentials from secret
-	secret := &corev1.Secret{}
-	if err := r.Get(ctx, types.NamespacedName{Name: authSecretName, Namespace: backup.Namespace}, secret); err != nil {
-		return "", fmt.Errorf("failed to get auth secret %s: %w", authSecretName, err)
+// setClusterReady sets the ClusterReady condition and moves the backup between the
+// WaitingForCluster and Pending phases. The status is only written when it changes.
+func (r *MongoDBBackupReconciler) setClusterReady(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup, status metav1.ConditionStatus, reason, message string) error {
+	phase := backup.Status.Phase
+	if status == metav1.ConditionFalse {
+		phase = backupPhaseWaitingForCluster
+	} else if phase == backupPhaseWaitingForCluster {
+		phase = "Pending"
 	}
 
-	username := string(secret.Data["username"])
-	password := string(secret.Data["password"])
+	changed := meta.SetStatusCondition(&backup.Status.Conditions, metav1.Condition{
+		Type:               backupClusterReadyCondition,
+		Status:             status,
+		ObservedGeneration: backup.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if !changed && phase == backup.Status.Phase {
+		return nil
+	}
+	backup.Status.Phase = phase
+	return r.Status().Update(ctx, backup)
+}
 
-	if username == "" || password == "" {
-		return "", fmt.Errorf("auth secret %s missing username or password", authSecretName)
+// runningBackupJob returns the name of an unfinished backup Job of another backup of the same
+// cluster, empty when there is none or when the Job of this backup already exists
+func (r *MongoDBBackupReconciler) runningBackupJob(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (string, error) {
+	own := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: backup.Name, Namespace: backup.Namespace}, own)
+	if err == nil {
+		return "", nil
+	}
+	if !errors.IsNotFound(err) {
+		return "", err
 	}
 
-	// Build connection string with authentication
-	// Note: Don't include database path (/admin) - only authSource parameter
-	// Otherwise mongodump will only backup the specified database
-	connectionString := fmt.Sprintf("mongodb://%s:%s@%s/?authSource=admin",
-		username, password, host)
+	jobs := &batchv1.JobList{}
+	if err := r.List(ctx, jobs, client.InNamespace(backup.Namespace), client.MatchingLabels{
+		resources.BackupClusterLabel:  backup.Spec.ClusterRef.Name,
+		"app.kubernetes.io/component": "backup",
+	}); err != nil {
+		return "", fmt.Errorf("failed to list backup Jobs: %w", err)
+	}
+	for i := range jobs.Items {
+		if !jobFinished(&jobs.Items[i]) {
+			return jobs.Items[i].Name, nil
+		}
+	}
+	return "", nil
+}
 
-	return connectionString, nil
+// jobTerminationMessage returns the termination message of the named container of the succeeded
+// pod of a Job, empty when no pod succeeded
+func jobTerminationMessage(ctx context.Context, c client.Client, job *batchv1.Job, container string) (string, error) {
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", fmt.Errorf("failed to list the pods of Job %s: %w", job.Name, err)
+	}
+	for _, pod := range podList.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.State.Terminated
+			if status.Name == container && terminated != nil && terminated.ExitCode == 0 {
+				return strings.TrimSpace(terminated.Message), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// jobFinished reports whether a Job completed or failed
+func jobFinished(job *batchv1.Job) bool {
+	for _, condition := range job.Status.Conditions {
+		if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// backupTarget is how a backup Job reaches the cluster it backs up
+type backupTarget struct {
+	// uri is the connection string, without credentials
+	uri         string
+	credentials resources.BackupCredentials
+	// tlsSecret is the TLS secret the Job mounts, empty without TLS
+	tlsSecret string
+	// generation is the generation of the cluster spec
+	generation int64
+}
+
+// getBackupTarget returns how the backup Job reaches the cluster of a backup, authenticating as
+// the admin user
+func (r *MongoDBBackupReconciler) getBackupTarget(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup) (*backupTarget, error) {
+	// No database path, mongodump would only back up that database
+	uri := connstring.ConnString{AuthSource: "admin"}
+	var tls *mongodbv1alpha1.TLSSpec
+	var auth mongodbv1alpha1.AuthSpec
+	var clusterName, clusterNamespace string
+	var generation int64
+
+	switch backup.Spec.ClusterRef.Kind {
+	case "MongoDB":
+		mdb := &mongodbv1alpha1.MongoDB{}
+		if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.ClusterRef.Name, Namespace: backup.Namespace}, mdb); err != nil {
+			return nil, fmt.Errorf("failed to get MongoDB cluster: %w", err)
+		}
+		uri.Hosts = []string{fmt.Sprintf("%s:%d", resources.ServiceFQDN(mdb.Name, backup.Namespace, mdb.Spec.ClusterDomain), resources.ReplicaSetPort(mdb))}
+		if !resources.Standalone(mdb) {
+			uri.ReplicaSet = mdb.Spec.ReplicaSetName
+		}
+		tls, auth = mdb.Spec.TLS, mdb.Spec.Auth
+		clusterName, clusterNamespace, generation = mdb.Name, mdb.Namespace, mdb.Generation
+
+	case "MongoDBSharded":
+		mdbsh := &mongodbv1alpha1.MongoDBSharded{}
+		if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.ClusterRef.Name, Namespace: backup.Namespace}, mdbsh); err != nil {
+			return nil, fmt.Errorf("failed to get MongoDBSharded cluster: %w", err)
+		}
+		uri.Hosts = []string{resources.MongosServiceHost(mdbsh)}
+		tls, auth = mdbsh.Spec.TLS, mdbsh.Spec.Auth
+		clusterName, clusterNamespace, generation = mdbsh.Name, mdbsh.Namespace, mdbsh.Generation
+
+	default:
+		return nil, fmt.Errorf("unknown cluster kind: %s", backup.Spec.ClusterRef.Kind)
+	}
+
+	// Reading the credentials checks the secret holds a password before the Job starts
+	creds, err := getAdminCredentials(ctx, r.Client, clusterName, clusterNamespace, auth)
+	if err != nil {
+		return nil, err
+	}
+	_, passwordKey := resources.CredentialKeys(auth.AdminCredentialsSecretRef)
+	target := &backupTarget{
+		generation: generation,
+		credentials: resources.BackupCredentials{
+			Username: creds.Username,
+			PasswordSecretRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: resources.AdminSecretName(clusterName, auth)},
+				Key:                  passwordKey,
+			},
+		},
+	}
+
+	if resources.TLSEnabled(tls) {
+		uri.TLS = true
+		uri.TLSCAFile = resources.BackupTLSMountPath + "/ca.crt"
+		target.tlsSecret = resources.TLSSecretName(clusterName, tls)
+	}
+	target.uri = uri.String()
+	return target, nil
 }
 
 func (r *MongoDBBackupReconciler) createOrUpdate(ctx context.Context, backup *mongodbv1alpha1.MongoDBBackup, obj client.Object) error {
@@ -225,6 +470,11 @@ func (r *MongoDBBackupReconciler) updateBackupStatus(ctx context.Context, backup
 		if condition.Type == batchv1.JobComplete && condition.Status == corev1.ConditionTrue {
 			backup.Status.Phase = "Completed"
 			backup.Status.CompletionTime = condition.LastTransitionTime.DeepCopy()
+			size, err := r.archiveSize(ctx, job)
+			if err != nil {
+				return err
+			}
+			backup.Status.Size = size
 			break
 		}
 		if condition.Type == batchv1.JobFailed && condition.Status == corev1.ConditionTrue {
@@ -241,11 +491,11 @@ func (r *MongoDBBackupReconciler) updateBackupStatus(ctx context.Context, backup
 	}
 
 	// Set location based on storage type
-	if backup.Spec.Storage.Type == "s3" && backup.Spec.Storage.S3 != nil {
+	if backup.Spec.Storage.Type == "s3" && backup.Spec.Storage.S3 != nil && backup.Status.Archive != "" {
 		backup.Status.Location = fmt.Sprintf("s3://%s/%s%s",
 			backup.Spec.Storage.S3.Bucket,
 			backup.Spec.Storage.S3.Prefix,
-			backup.Name)
+			backup.Status.Archive)
 	}
 
 	return r.Status().Update(ctx, backup)
@@ -266,10 +516,71 @@ func (r *MongoDBBackupReconciler) updateStatusError(ctx context.Context, backup
 	return ctrl.Result{}, err
 }
 
+// findBackupsForCluster maps a cluster to the unfinished backups referencing it
+func (r *MongoDBBackupReconciler) findBackupsForCluster(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		backupList := &mongodbv1alpha1.MongoDBBackupList{}
+		if err := r.List(ctx, backupList, client.InNamespace(obj.GetNamespace()),
+			client.MatchingFields{backupClusterRefIndex: obj.GetName()}); err != nil {
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, backup := range backupList.Items {
+			if backup.Spec.ClusterRef.Kind != kind || backup.Status.Phase == "Completed" || backup.Status.Phase == "Failed" {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: backup.Name, Namespace: backup.Namespace},
+			})
+		}
+		return requests
+	}
+}
+
+// clusterBecameReady passes cluster events that turn the Ready condition of the cluster true
+func clusterBecameReady() predicate.Predicate {
+	ready := func(obj client.Object) bool {
+		switch cluster := obj.(type) {
+		case *mongodbv1alpha1.MongoDB:
+			return meta.IsStatusConditionTrue(cluster.Status.Conditions, "Ready")
+		case *mongodbv1alpha1.MongoDBSharded:
+			return meta.IsStatusConditionTrue(cluster.Status.Conditions, "Ready")
+		}
+		return false
+	}
+
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return ready(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !ready(e.ObjectOld) && ready(e.ObjectNew)
+		},
+		DeleteFunc: func(event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *MongoDBBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &mongodbv1alpha1.MongoDBBackup{}, backupClusterRefIndex,
+		func(obj client.Object) []string {
+			return []string{obj.(*mongodbv1alpha1.MongoDBBackup).Spec.ClusterRef.Name}
+		}); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&mongodbv1alpha1.MongoDBBackup{}).
 		Owns(&batchv1.Job{}).
+		Watches(&mongodbv1alpha1.MongoDB{}, handler.EnqueueRequestsFromMapFunc(r.findBackupsForCluster("MongoDB")),
+			builder.WithPredicates(clusterBecameReady())).
+		Watches(&mongodbv1alpha1.MongoDBSharded{}, handler.EnqueueRequestsFromMapFunc(r.findBackupsForCluster("MongoDBSharded")),
+			builder.WithPredicates(clusterBecameReady())).
 		Complete(r)
 }
@@ -0,0 +1,792 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// MongoDBCollectionReconciler reconciles a MongoDBCollection object
+type MongoDBCollectionReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Managers
+}
+
+// collectionTarget holds what is needed to run commands against a collection's cluster.
+// Commands go to mongos for sharded clusters and to the primary for replica sets.
+type collectionTarget struct {
+	pod       string
+	container string
+	port      int
+	creds     *adminCredentials
+	shards    mongodb.ShardManager
+	indexes   mongodb.IndexManager
+	// mdb is the replica set of the collection, nil on a sharded cluster
+	mdb *mongodbv1alpha1.MongoDB
+}
+
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbcollections,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbcollections/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbcollections/finalizers,verbs=update
+
+func (r *MongoDBCollectionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciling MongoDBCollection", "namespace", req.Namespace, "name", req.Name)
+
+	// Fetch MongoDBCollection instance
+	coll := &mongodbv1alpha1.MongoDBCollection{}
+	if err := r.Get(ctx, req.NamespacedName, coll); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("MongoDBCollection resource not found, ignoring")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get MongoDBCollection")
+		return ctrl.Result{}, err
+	}
+
+	// Collection commands share the exec rate limit of the cluster
+	ctx = mongodb.WithClusterScope(ctx, coll.Namespace, coll.Spec.ClusterRef.Name)
+
+	// Validate the spec before touching the cluster
+	if err := resources.ValidateCollection(coll.Spec); err != nil {
+		return r.updateStatusError(ctx, coll, err)
+	}
+
+	// 1. Wait for the cluster to be running
+	target, err := r.getTarget(ctx, coll)
+	if err != nil {
+		return r.updateStatusError(ctx, coll, err)
+	}
+	if target == nil {
+		logger.Info("Waiting for cluster to be running", "cluster", coll.Spec.ClusterRef.Name)
+		if coll.Status.Phase == "" {
+			coll.Status.Phase = "Pending"
+			if err := r.Status().Update(ctx, coll); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	// 2. Time-series and capped collections are created with their options
+	if err := r.reconcileCollectionKind(ctx, coll, target); err != nil {
+		return r.updateStatusError(ctx, coll, err)
+	}
+
+	if len(coll.Spec.ShardKey) > 0 {
+		// 3. Zone ranges a hashed shard key is presplit along must exist before it is sharded
+		if resources.PresplitHashedZones(coll.Spec) {
+			if err := r.reconcileZones(ctx, coll, target); err != nil {
+				return r.updateStatusError(ctx, coll, err)
+			}
+		}
+
+		// 4. Shard the collection
+		if err := r.reconcileShardKey(ctx, coll, target); err != nil {
+			return r.updateStatusError(ctx, coll, err)
+		}
+
+		// 5. Zone ranges
+		if !resources.PresplitHashedZones(coll.Spec) {
+			if err := r.reconcileZones(ctx, coll, target); err != nil {
+				return r.updateStatusError(ctx, coll, err)
+			}
+		}
+	}
+
+	// 6. Indexes
+	building, err := r.reconcileIndexes(ctx, coll, target)
+	if err != nil {
+		return r.updateStatusError(ctx, coll, err)
+	}
+
+	// 7. Update status
+	if err := r.updateStatus(ctx, coll, target, building); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if building {
+		logger.Info("Waiting for index builds to complete")
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	logger.Info("Successfully reconciled MongoDBCollection")
+	return ctrl.Result{RequeueAfter: requeueInterval()}, nil
+}
+
+// getTarget returns the pod and credentials to run commands with, or nil while the cluster is not running
+func (r *MongoDBCollectionReconciler) getTarget(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection) (*collectionTarget, error) {
+	indexManager := r.indexManager()
+
+	if coll.Spec.ClusterRef.Kind != "MongoDBSharded" {
+		return r.getReplicaSetTarget(ctx, coll, indexManager)
+	}
+
+	mdbsh := &mongodbv1alpha1.MongoDBSharded{}
+	if err := r.Get(ctx, types.NamespacedName{Name: coll.Spec.ClusterRef.Name, Namespace: coll.Namespace}, mdbsh); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get MongoDBSharded cluster: %w", err)
+	}
+
+	if mdbsh.Status.Phase != "Running" || !mdbsh.Status.AdminUserCreated {
+		return nil, nil
+	}
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+
+	shardManager := r.shardManager()
+
+	return &collectionTarget{
+		pod:       mongosPod,
+		container: "mongos",
+		port:      27017,
+		creds:     creds,
+		shards:    shardManager,
+		indexes:   indexManager,
+	}, nil
+}
+
+// getReplicaSetTarget returns the primary pod and credentials of a MongoDB replica set
+func (r *MongoDBCollectionReconciler) getReplicaSetTarget(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, indexManager mongodb.IndexManager) (*collectionTarget, error) {
+	mdb := &mongodbv1alpha1.MongoDB{}
+	if err := r.Get(ctx, types.NamespacedName{Name: coll.Spec.ClusterRef.Name, Namespace: coll.Namespace}, mdb); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get MongoDB cluster: %w", err)
+	}
+
+	// A member building indexes out of the replica set is not ready, the build goes on regardless
+	if !mdb.Status.AdminUserCreated || (mdb.Status.Phase != "Running" && coll.Status.RollingIndexBuild == nil) {
+		return nil, nil
+	}
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	primaryPod, err := rsManager.GetPrimaryPod(ctx, resources.FirstMemberPod(mdb), mdb.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get primary pod: %w", err)
+	}
+
+	return &collectionTarget{
+		pod:       primaryPod,
+		container: "mongodb",
+		port:      int(resources.ReplicaSetPort(mdb)),
+		creds:     creds,
+		indexes:   indexManager,
+		mdb:       mdb,
+	}, nil
+}
+
+// reconcileCollectionKind creates a time-series or capped collection with its options, which
+// cannot be added later, and checks that an existing collection is of the declared kind
+func (r *MongoDBCollectionReconciler) reconcileCollectionKind(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget) error {
+	logger := log.FromContext(ctx)
+
+	options, err := resources.BuildCreateCollectionOptions(coll.Spec)
+	if err != nil || options == "" {
+		return err
+	}
+
+	info, err := target.indexes.GetCollectionInfoInContainer(ctx, target.pod, coll.Namespace, target.container,
+		target.creds.Username, target.creds.Password, coll.Spec.Database, coll.Spec.Name, target.port)
+	if err != nil {
+		return err
+	}
+	if info != nil {
+		return resources.ValidateCollectionKind(coll.Spec, info.Type, info.Capped)
+	}
+
+	logger.Info("Creating collection", "collection", resources.CollectionNamespace(coll.Spec), "kind", resources.CollectionKind(coll.Spec))
+	return target.indexes.CreateCollectionInContainer(ctx, target.pod, coll.Namespace, target.container,
+		target.creds.Username, target.creds.Password, coll.Spec.Database, coll.Spec.Name, options, target.port)
+}
+
+func (r *MongoDBCollectionReconciler) reconcileShardKey(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget) error {
+	logger := log.FromContext(ctx)
+	namespace := resources.CollectionNamespace(coll.Spec)
+
+	key, err := resources.BuildShardKey(coll.Spec.ShardKey)
+	if err != nil {
+		return err
+	}
+
+	current, err := target.shards.GetShardKeyInContainer(ctx, target.pod, coll.Namespace, target.container,
+		target.creds.Username, target.creds.Password, namespace, target.port)
+	if err != nil {
+		return err
+	}
+
+	if current != "" && current != key {
+		return fmt.Errorf("collection %s is already sharded with key %s, cannot change it to %s", namespace, current, key)
+	}
+
+	if current == "" {
+		logger.Info("Sharding collection", "collection", namespace, "key", key)
+		if err := target.shards.ShardCollectionInContainer(ctx, target.pod, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, namespace, key, coll.Spec.Unique,
+			resources.BuildShardCollectionOptions(coll.Spec), target.port); err != nil {
+			return err
+		}
+	}
+
+	// Split points only shape the initial chunks, the balancer owns them once the collection is
+	// reported sharded
+	if points := resources.SplitPoints(coll.Spec); len(points) > 0 && !coll.Status.Sharded {
+		logger.Info("Splitting initial chunks", "collection", namespace, "points", len(points))
+		return target.shards.SplitChunksInContainer(ctx, target.pod, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, namespace, points, target.port)
+	}
+	return nil
+}
+
+func (r *MongoDBCollectionReconciler) reconcileZones(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget) error {
+	logger := log.FromContext(ctx)
+	namespace := resources.CollectionNamespace(coll.Spec)
+
+	// Remove ranges that are no longer declared
+	for _, applied := range coll.Status.AppliedZones {
+		if zoneRangeDeclared(coll.Spec.Zones, applied) {
+			continue
+		}
+		logger.Info("Removing zone range", "zone", applied.Zone, "min", applied.Min, "max", applied.Max)
+		if err := target.shards.UpdateZoneKeyRangeInContainer(ctx, target.pod, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, namespace, applied.Min, applied.Max, "", target.port); err != nil {
+			return err
+		}
+	}
+
+	for _, zone := range coll.Spec.Zones {
+		for _, shard := range zone.Shards {
+			if err := target.shards.AddShardToZoneInContainer(ctx, target.pod, coll.Namespace, target.container,
+				target.creds.Username, target.creds.Password, shard, zone.Zone, target.port); err != nil {
+				return err
+			}
+		}
+
+		if err := target.shards.UpdateZoneKeyRangeInContainer(ctx, target.pod, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, namespace, zone.Min, zone.Max, zone.Zone, target.port); err != nil {
+			return err
+		}
+	}
+
+	coll.Status.AppliedZones = coll.Spec.Zones
+	return nil
+}
+
+// zoneRangeDeclared reports whether a range with the same bounds is still declared
+func zoneRangeDeclared(zones []mongodbv1alpha1.ZoneRange, zone mongodbv1alpha1.ZoneRange) bool {
+	for _, z := range zones {
+		if z.Min == zone.Min && z.Max == zone.Max {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileIndexes drops indexes removed from the spec, applies the changed options of existing
+// ones and starts builds of missing ones, on every member at once or one member at a time.
+// It reports whether any index is still building.
+func (r *MongoDBCollectionReconciler) reconcileIndexes(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget) (bool, error) {
+	logger := log.FromContext(ctx)
+	db, name := coll.Spec.Database, coll.Spec.Name
+
+	// Drop indexes the operator created that are no longer declared
+	for _, applied := range coll.Status.Indexes {
+		if indexDeclared(coll.Spec.Indexes, applied.Name) {
+			continue
+		}
+		logger.Info("Dropping index", "index", applied.Name)
+		if err := target.indexes.DropIndexInContainer(ctx, target.pod, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, db, name, applied.Name, target.port); err != nil {
+			return false, err
+		}
+	}
+
+	if len(coll.Spec.Indexes) == 0 && coll.Status.RollingIndexBuild == nil {
+		coll.Status.Indexes = nil
+		return false, nil
+	}
+
+	definitions, err := indexDefinitions(coll.Spec.Indexes)
+	if err != nil {
+		return false, err
+	}
+
+	present, err := r.reconcileIndexOptions(ctx, coll, target, definitions)
+	if err != nil {
+		return false, err
+	}
+
+	builds, err := target.indexes.GetIndexBuildsInContainer(ctx, target.pod, coll.Namespace, target.container,
+		target.creds.Username, target.creds.Password, db, name, target.port)
+	if err != nil {
+		return false, err
+	}
+
+	var missing []string
+	statuses := make([]mongodbv1alpha1.IndexStatus, 0, len(coll.Spec.Indexes))
+	for _, idx := range coll.Spec.Indexes {
+		status := mongodbv1alpha1.IndexStatus{Name: idx.Name, Phase: "Ready"}
+		if build := findIndexBuild(builds, idx.Name); build != nil {
+			status.Phase = "Building"
+			status.Progress = resources.FormatIndexProgress(build.Done, build.Total)
+		} else if !present[idx.Name] {
+			status.Phase = "Building"
+			missing = append(missing, idx.Name)
+		}
+		statuses = append(statuses, status)
+	}
+	coll.Status.Indexes = statuses
+
+	building := slices.ContainsFunc(statuses, func(s mongodbv1alpha1.IndexStatus) bool { return s.Phase == "Building" })
+
+	rolling, err := r.rollingIndexBuild(ctx, coll, target, missing)
+	if err != nil || rolling {
+		return building, err
+	}
+
+	for _, index := range missing {
+		logger.Info("Starting index build", "index", index)
+		if err := target.indexes.StartIndexBuildInContainer(ctx, target.pod, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, db, name, definitions[index], coll.Spec.IndexBuildCommitQuorum, target.port); err != nil {
+			return false, err
+		}
+	}
+
+	return building, nil
+}
+
+// indexDefinitions renders the declared indexes by name
+func indexDefinitions(indexes []mongodbv1alpha1.IndexSpec) (map[string]string, error) {
+	definitions := make(map[string]string, len(indexes))
+	for _, idx := range indexes {
+		definition, err := resources.BuildIndexDefinition(idx)
+		if err != nil {
+			return nil, err
+		}
+		definitions[idx.Name] = definition
+	}
+	return definitions, nil
+}
+
+// reconcileIndexOptions compares the existing indexes with their declaration. A changed
+// expireAfterSeconds is applied in place, any other change drops the index to build it again.
+// It returns the declared indexes that exist with their declared options.
+func (r *MongoDBCollectionReconciler) reconcileIndexOptions(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget, definitions map[string]string) (map[string]bool, error) {
+	logger := log.FromContext(ctx)
+	db, name := coll.Spec.Database, coll.Spec.Name
+
+	existing, err := target.indexes.ListIndexesInContainer(ctx, target.pod, coll.Namespace, target.container,
+		target.creds.Username, target.creds.Password, db, name, slices.Collect(maps.Values(definitions)), target.port)
+	if err != nil {
+		return nil, err
+	}
+
+	present := make(map[string]bool, len(existing))
+	for _, index := range existing {
+		switch {
+		case index.Changed:
+			logger.Info("Dropping index to build it with its changed options", "index", index.Name)
+			if err := target.indexes.DropIndexInContainer(ctx, target.pod, coll.Namespace, target.container,
+				target.creds.Username, target.creds.Password, db, name, index.Name, target.port); err != nil {
+				return nil, err
+			}
+		case index.TTLChanged:
+			ttl := *findIndexSpec(coll.Spec.Indexes, index.Name).ExpireAfterSeconds
+			logger.Info("Changing index TTL", "index", index.Name, "expireAfterSeconds", ttl)
+			if err := target.indexes.SetIndexTTLInContainer(ctx, target.pod, coll.Namespace, target.container,
+				target.creds.Username, target.creds.Password, db, name, index.Name, ttl, target.port); err != nil {
+				return nil, err
+			}
+			present[index.Name] = true
+		default:
+			present[index.Name] = true
+		}
+	}
+	return present, nil
+}
+
+// rollingIndexBuild advances the rolling build of the missing indexes, if they are built one
+// member at a time: a rolling build is in progress, or the strategy picks one for the
+// collection. A failed rolling build is reported until the spec changes.
+func (r *MongoDBCollectionReconciler) rollingIndexBuild(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget, missing []string) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	if build := coll.Status.RollingIndexBuild; build != nil && build.Phase == resources.RollingIndexBuildFailed {
+		if build.ObservedGeneration == coll.Generation {
+			return true, fmt.Errorf("rolling index build failed on %s: %s", build.Member, build.Message)
+		}
+		coll.Status.RollingIndexBuild = nil
+	}
+
+	if coll.Status.RollingIndexBuild == nil {
+		if len(missing) == 0 || target.mdb == nil || coll.Spec.IndexBuild == nil ||
+			coll.Spec.IndexBuild.Strategy == "" || coll.Spec.IndexBuild.Strategy == resources.IndexBuildSimultaneous {
+			return false, nil
+		}
+
+		if err := resources.RollingIndexBuildSupported(target.mdb); err != nil {
+			if coll.Spec.IndexBuild.Strategy == resources.IndexBuildRolling {
+				return false, err
+			}
+			logger.Info("Building indexes on every member at once", "reason", err.Error())
+			return false, nil
+		}
+
+		var size int64
+		if coll.Spec.IndexBuild.Strategy == resources.IndexBuildAuto {
+			var err error
+			size, err = target.indexes.GetCollectionSizeInContainer(ctx, target.pod, coll.Namespace, target.container,
+				target.creds.Username, target.creds.Password, coll.Spec.Database, coll.Spec.Name, target.port)
+			if err != nil {
+				return false, err
+			}
+		}
+		if !resources.RollingIndexBuild(coll.Spec.IndexBuild, size) {
+			return false, nil
+		}
+
+		logger.Info("Starting rolling index build", "indexes", missing, "size", size)
+		coll.Status.RollingIndexBuild = &mongodbv1alpha1.RollingIndexBuildStatus{
+			Indexes:            missing,
+			ObservedGeneration: coll.Generation,
+		}
+	}
+
+	return true, r.advanceRollingIndexBuild(ctx, coll, target)
+}
+
+// advanceRollingIndexBuild takes the rolling index build one step further. Each member in turn,
+// secondaries first, is restarted out of the replica set, builds the indexes as a standalone and
+// is restarted into the replica set, where it catches up before the next member leaves. The
+// primary steps down before its turn.
+func (r *MongoDBCollectionReconciler) advanceRollingIndexBuild(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget) error {
+	logger := log.FromContext(ctx)
+	build := coll.Status.RollingIndexBuild
+	mdb := target.mdb
+	members := resources.LocalMemberPods(mdb)
+	user, password := target.creds.Username, target.creds.Password
+
+	defer r.setRollingIndexProgress(ctx, coll, target, len(members))
+
+	switch build.Phase {
+	case "":
+		states, err := r.memberStates(ctx, target)
+		if err != nil {
+			return err
+		}
+		pending := slices.DeleteFunc(slices.Clone(members), func(pod string) bool {
+			return slices.Contains(build.CompletedMembers, pod)
+		})
+		if len(pending) == 0 {
+			logger.Info("Rolling index build completed", "indexes", build.Indexes)
+			coll.Status.RollingIndexBuild = nil
+			return nil
+		}
+
+		member, stepDown := resources.NextRollingIndexBuildMember(states, pending)
+		if member == "" {
+			logger.Info("Waiting for every member to be healthy before the next index build")
+			return nil
+		}
+		if stepDown {
+			logger.Info("Stepping down the primary to build the indexes on it", "pod", member)
+			keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+			if err != nil {
+				return err
+			}
+			return r.replicaSetManager(target.port).StepDownWithKeyfile(ctx, member, mdb.Namespace, keyfile, 60, 10)
+		}
+
+		logger.Info("Restarting member out of the replica set to build indexes", "pod", member)
+		if err := target.indexes.RestartInContainer(ctx, member, mdb.Namespace, target.container, user, password,
+			resources.MaintenanceMarkerPath(mdb), target.port); err != nil {
+			return err
+		}
+		build.Member = member
+		build.Phase = resources.RollingIndexBuildRestarting
+
+	case resources.RollingIndexBuildRestarting:
+		setName, err := target.indexes.GetSetNameInContainer(ctx, build.Member, mdb.Namespace, target.container, user, password, target.port)
+		if err != nil {
+			logger.Info("Waiting for member to restart", "pod", build.Member, "error", err)
+			return nil
+		}
+		if setName != "" {
+			// The shutdown did not happen, the marker is still there for the next start
+			logger.Info("Member still runs in the replica set, restarting it again", "pod", build.Member)
+			return target.indexes.RestartInContainer(ctx, build.Member, mdb.Namespace, target.container, user, password,
+				resources.MaintenanceMarkerPath(mdb), target.port)
+		}
+
+		definitions, err := indexDefinitions(coll.Spec.Indexes)
+		if err != nil {
+			return err
+		}
+		for _, index := range build.Indexes {
+			definition, ok := definitions[index]
+			if !ok {
+				continue
+			}
+			logger.Info("Starting index build on standalone member", "index", index, "pod", build.Member)
+			if err := target.indexes.StartIndexBuildInContainer(ctx, build.Member, mdb.Namespace, target.container, user, password,
+				coll.Spec.Database, coll.Spec.Name, definition, "", target.port); err != nil {
+				return err
+			}
+		}
+		build.Phase = resources.RollingIndexBuildBuilding
+
+	case resources.RollingIndexBuildBuilding:
+		builds, err := target.indexes.GetIndexBuildsInContainer(ctx, build.Member, mdb.Namespace, target.container, user, password,
+			coll.Spec.Database, coll.Spec.Name, target.port)
+		if err != nil || len(builds) > 0 {
+			return err
+		}
+
+		existing, err := target.indexes.ListIndexesInContainer(ctx, build.Member, mdb.Namespace, target.container, user, password,
+			coll.Spec.Database, coll.Spec.Name, nil, target.port)
+		if err != nil {
+			return err
+		}
+		var failed []string
+		for _, index := range build.Indexes {
+			if indexDeclared(coll.Spec.Indexes, index) && !slices.ContainsFunc(existing, func(i mongodb.Index) bool { return i.Name == index }) {
+				failed = append(failed, index)
+			}
+		}
+
+		logger.Info("Restarting member into the replica set", "pod", build.Member)
+		if err := target.indexes.RestartInContainer(ctx, build.Member, mdb.Namespace, target.container, user, password, "", target.port); err != nil {
+			return err
+		}
+		if len(failed) > 0 {
+			build.Phase = resources.RollingIndexBuildFailed
+			build.Message = fmt.Sprintf("indexes %s were not built, see the log of the %s container", strings.Join(failed, ", "), target.container)
+			logger.Info("Rolling index build failed", "pod", build.Member, "indexes", failed)
+			return nil
+		}
+		build.Phase = resources.RollingIndexBuildRejoining
+
+	case resources.RollingIndexBuildRejoining:
+		states, err := r.memberStates(ctx, target)
+		if err != nil {
+			return err
+		}
+		if states[build.Member] != "SECONDARY" {
+			logger.Info("Waiting for member to rejoin the replica set", "pod", build.Member, "state", states[build.Member])
+			return nil
+		}
+		logger.Info("Member built the indexes", "pod", build.Member)
+		build.CompletedMembers = append(build.CompletedMembers, build.Member)
+		build.Member = ""
+		build.Phase = ""
+	}
+	return nil
+}
+
+// memberStates returns the state of the data-bearing members of the replica set of a collection,
+// as reported by its primary
+func (r *MongoDBCollectionReconciler) memberStates(ctx context.Context, target *collectionTarget) (map[string]string, error) {
+	mdb := target.mdb
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := r.replicaSetManager(target.port).GetStatusWithKeyfile(ctx, target.pod, mdb.Namespace, keyfile)
+	if err != nil {
+		return nil, err
+	}
+
+	members := resources.LocalMemberPods(mdb)
+	states := make(map[string]string, len(members))
+	for _, member := range status.Members {
+		if pod := strings.Split(member.Name, ".")[0]; slices.Contains(members, pod) {
+			states[pod] = member.StateStr
+		}
+	}
+	return states, nil
+}
+
+// setRollingIndexProgress reports the progress of a rolling index build on the indexes it builds
+func (r *MongoDBCollectionReconciler) setRollingIndexProgress(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget, members int) {
+	build := coll.Status.RollingIndexBuild
+	if build == nil {
+		return
+	}
+
+	var builds []mongodb.IndexBuild
+	if build.Phase == resources.RollingIndexBuildBuilding {
+		// Progress is informational, a member that does not answer reports none
+		builds, _ = target.indexes.GetIndexBuildsInContainer(ctx, build.Member, coll.Namespace, target.container,
+			target.creds.Username, target.creds.Password, coll.Spec.Database, coll.Spec.Name, target.port)
+	}
+
+	for i := range coll.Status.Indexes {
+		status := &coll.Status.Indexes[i]
+		if !slices.Contains(build.Indexes, status.Name) {
+			continue
+		}
+		var done, total int64
+		if b := findIndexBuild(builds, status.Name); b != nil {
+			done, total = b.Done, b.Total
+		}
+		status.Phase = "Building"
+		status.Progress = resources.FormatRollingIndexProgress(len(build.CompletedMembers), members, build.Member, done, total)
+	}
+}
+
+// indexDeclared reports whether an index is still declared in the spec
+func indexDeclared(indexes []mongodbv1alpha1.IndexSpec, name string) bool {
+	for _, idx := range indexes {
+		if idx.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findIndexSpec returns the declared index of a name
+func findIndexSpec(indexes []mongodbv1alpha1.IndexSpec, name string) *mongodbv1alpha1.IndexSpec {
+	for i := range indexes {
+		if indexes[i].Name == name {
+			return &indexes[i]
+		}
+	}
+	return nil
+}
+
+func findIndexBuild(builds []mongodb.IndexBuild, name string) *mongodb.IndexBuild {
+	for i := range builds {
+		if builds[i].Name == name {
+			return &builds[i]
+		}
+	}
+	return nil
+}
+
+func (r *MongoDBCollectionReconciler) updateStatus(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget, building bool) error {
+	coll.Status.ObservedGeneration = coll.Generation
+
+	if building {
+		coll.Status.Phase = "Building"
+		meta.SetStatusCondition(&coll.Status.Conditions, metav1.Condition{
+			Type:               "Ready",
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: coll.Generation,
+			Reason:             "IndexBuildInProgress",
+			Message:            "Waiting for index builds to complete",
+		})
+		return r.Status().Update(ctx, coll)
+	}
+
+	if len(coll.Spec.ShardKey) > 0 {
+		r.updateChunkDistribution(ctx, coll, target)
+	}
+
+	coll.Status.Phase = "Ready"
+	coll.Status.Sharded = len(coll.Spec.ShardKey) > 0
+	meta.SetStatusCondition(&coll.Status.Conditions, metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: coll.Generation,
+		Reason:             "Reconciled",
+		Message:            "Collection shard key, zones and indexes are configured",
+	})
+
+	return r.Status().Update(ctx, coll)
+}
+
+func (r *MongoDBCollectionReconciler) updateChunkDistribution(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, target *collectionTarget) {
+	logger := log.FromContext(ctx)
+
+	counts, err := target.shards.GetChunkDistributionInContainer(ctx, target.pod, coll.Namespace, target.container,
+		target.creds.Username, target.creds.Password, resources.CollectionNamespace(coll.Spec), target.port)
+	if err != nil {
+		// Chunk distribution is informational, keep the last known value
+		logger.Info("Failed to get chunk distribution", "error", err)
+	} else {
+		coll.Status.ChunkDistribution = make([]mongodbv1alpha1.ChunkDistribution, 0, len(counts))
+		for _, c := range counts {
+			coll.Status.ChunkDistribution = append(coll.Status.ChunkDistribution, mongodbv1alpha1.ChunkDistribution{
+				Shard:  c.Shard,
+				Chunks: c.Chunks,
+			})
+		}
+	}
+}
+
+func (r *MongoDBCollectionReconciler) updateStatusError(ctx context.Context, coll *mongodbv1alpha1.MongoDBCollection, err error) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Error(err, "Failed to reconcile collection")
+
+	coll.Status.Phase = "Failed"
+	meta.SetStatusCondition(&coll.Status.Conditions, metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: coll.Generation,
+		Reason:             "ReconcileFailed",
+		Message:            err.Error(),
+	})
+
+	if statusErr := r.Status().Update(ctx, coll); statusErr != nil {
+		logger.Error(statusErr, "Failed to update status")
+	}
+
+	return ctrl.Result{RequeueAfter: requeueInterval()}, err
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MongoDBCollectionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mongodbv1alpha1.MongoDBCollection{}).
+		Complete(r)
+}
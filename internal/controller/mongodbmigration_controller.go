@@ -0,0 +1,324 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+const (
+	// migrationPollInterval is how often a running migration Job is checked
+	migrationPollInterval = 10 * time.Second
+
+	// migrationClusterWaitInterval is how often a migration checks its cluster became ready
+	migrationClusterWaitInterval = 30 * time.Second
+)
+
+// MongoDBMigrationReconciler reconciles a MongoDBMigration object
+type MongoDBMigrationReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbmigrations,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbmigrations/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbmigrations/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+func (r *MongoDBMigrationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciling MongoDBMigration", "namespace", req.Namespace, "name", req.Name)
+
+	// Fetch MongoDBMigration instance
+	migration := &mongodbv1alpha1.MongoDBMigration{}
+	if err := r.Get(ctx, req.NamespacedName, migration); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("MongoDBMigration resource not found, ignoring")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get MongoDBMigration")
+		return ctrl.Result{}, err
+	}
+
+	// A finished migration is only kept as a record
+	if migration.Status.Phase == resources.MigrationCompleted || migration.Status.Phase == resources.MigrationFailed {
+		return ctrl.Result{}, nil
+	}
+
+	// 1. Validate the spec
+	if migration.Status.Phase == "" {
+		if err := resources.ValidateMigration(migration.Spec); err != nil {
+			return ctrl.Result{}, r.fail(ctx, migration, err.Error())
+		}
+		now := metav1.Now()
+		migration.Status.Phase = resources.MigrationPending
+		migration.Status.StartTime = &now
+		migration.Status.ObservedGeneration = migration.Generation
+		if err := r.Status().Update(ctx, migration); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	switch migration.Status.Phase {
+	case resources.MigrationPending:
+		return r.startInitialSync(ctx, migration)
+	case resources.MigrationInitialSync:
+		return r.reconcileInitialSync(ctx, migration)
+	default:
+		return r.reconcileCatchUp(ctx, migration)
+	}
+}
+
+// startInitialSync starts the copy of the data of the source once the cluster is ready, restoring
+// into an initializing cluster would fail
+func (r *MongoDBMigrationReconciler) startInitialSync(ctx context.Context, migration *mongodbv1alpha1.MongoDBMigration) (ctrl.Result, error) {
+	ref := migration.Spec.ClusterRef
+	ready, err := clusterReady(ctx, r.Client, migration.Namespace, ref)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !ready {
+		log.FromContext(ctx).Info("Waiting for cluster to be ready", "cluster", ref.Name)
+		message := fmt.Sprintf("Waiting for %s %s to be ready", ref.Kind, ref.Name)
+		if migration.Status.Message != message {
+			migration.Status.Message = message
+			if err := r.Status().Update(ctx, migration); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		return ctrl.Result{RequeueAfter: migrationClusterWaitInterval}, nil
+	}
+
+	if _, _, err := r.runJob(ctx, migration, resources.BuildMigrationInitialSyncJob(migration)); err != nil {
+		return ctrl.Result{}, err
+	}
+	migration.Status.Phase = resources.MigrationInitialSync
+	migration.Status.Message = "Copying the data of the source"
+	if err := r.Status().Update(ctx, migration); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: migrationPollInterval}, nil
+}
+
+// reconcileInitialSync waits for the copy of the data of the source. A Snapshot migration
+// completes with it, a Continuous migration goes on with the catch-up rounds from the oplog
+// position recorded before the copy.
+func (r *MongoDBMigrationReconciler) reconcileInitialSync(ctx context.Context, migration *mongodbv1alpha1.MongoDBMigration) (ctrl.Result, error) {
+	job := resources.BuildMigrationInitialSyncJob(migration)
+	finished, failure, err := r.runJob(ctx, migration, job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if failure != "" {
+		return ctrl.Result{}, r.fail(ctx, migration, fmt.Sprintf("initial sync failed: %s", failure))
+	}
+	if !finished {
+		return ctrl.Result{RequeueAfter: migrationPollInterval}, nil
+	}
+
+	now := metav1.Now()
+	migration.Status.InitialSyncTime = &now
+	if resources.MigrationMode(migration.Spec) == mongodbv1alpha1.MigrationModeSnapshot {
+		return ctrl.Result{}, r.succeed(ctx, migration)
+	}
+
+	progress, err := r.jobProgress(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, r.fail(ctx, migration, fmt.Sprintf("initial sync: %v", err))
+	}
+	log.FromContext(ctx).Info("Initial sync completed, catching up with the oplog of the source", "appliedOpTime", progress.Applied)
+	migration.Status.Phase = resources.MigrationCatchingUp
+	migration.Status.Message = "Replaying the oplog of the source"
+	migration.Status.AppliedOpTime = &progress.Applied
+	migration.Status.LagSeconds = progress.LagSeconds()
+	if err := r.Status().Update(ctx, migration); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// reconcileCatchUp runs the oplog catch-up rounds, one Job each, every catchUpIntervalSeconds.
+// Once the cutover is requested the rounds run back to back and the migration completes after a
+// round without operations to apply.
+func (r *MongoDBMigrationReconciler) reconcileCatchUp(ctx context.Context, migration *mongodbv1alpha1.MongoDBMigration) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	if migration.Spec.Cutover && migration.Status.Phase == resources.MigrationCatchingUp {
+		logger.Info("Cutover requested, replaying the oplog of the source until it is drained")
+		migration.Status.Phase = resources.MigrationCuttingOver
+		migration.Status.Message = "Replaying the last operations of the source"
+		migration.Status.ObservedGeneration = migration.Generation
+		if err := r.Status().Update(ctx, migration); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	round := migration.Status.CatchUpRounds + 1
+	job, err := resources.BuildMigrationCatchUpJob(migration, round)
+	if err != nil {
+		return ctrl.Result{}, r.fail(ctx, migration, err.Error())
+	}
+
+	// Wait out the interval before starting the next round, unless cutting over
+	if migration.Status.Phase == resources.MigrationCatchingUp {
+		existing := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing)
+		if errors.IsNotFound(err) {
+			last := migration.Status.InitialSyncTime
+			if migration.Status.LastRoundTime != nil {
+				last = migration.Status.LastRoundTime
+			}
+			if last != nil {
+				if wait := time.Until(last.Add(resources.MigrationCatchUpInterval(migration.Spec))); wait > 0 {
+					return ctrl.Result{RequeueAfter: wait}, nil
+				}
+			}
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	finished, failure, err := r.runJob(ctx, migration, job)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if failure != "" {
+		return ctrl.Result{}, r.fail(ctx, migration, fmt.Sprintf("catch-up round %d failed: %s", round, failure))
+	}
+	if !finished {
+		return ctrl.Result{RequeueAfter: migrationPollInterval}, nil
+	}
+
+	progress, err := r.jobProgress(ctx, job)
+	if err != nil {
+		return ctrl.Result{}, r.fail(ctx, migration, fmt.Sprintf("catch-up round %d: %v", round, err))
+	}
+	logger.Info("Catch-up round completed", "round", round, "operations", progress.Operations, "lagSeconds", progress.LagSeconds())
+
+	now := metav1.Now()
+	migration.Status.AppliedOpTime = &progress.Applied
+	migration.Status.LagSeconds = progress.LagSeconds()
+	migration.Status.CatchUpRounds = round
+	migration.Status.LastRoundOperations = progress.Operations
+	migration.Status.LastRoundTime = &now
+	if migration.Status.Phase == resources.MigrationCuttingOver && progress.Operations == 0 {
+		if err := r.succeed(ctx, migration); err != nil {
+			return ctrl.Result{}, err
+		}
+	} else if err := r.Status().Update(ctx, migration); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// The status records the round, its Job is not needed anymore
+	propagationPolicy := metav1.DeletePropagationBackground
+	if err := r.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &propagationPolicy}); client.IgnoreNotFound(err) != nil {
+		logger.Error(err, "Failed to delete catch-up Job", "job", job.Name)
+	}
+
+	switch migration.Status.Phase {
+	case resources.MigrationCompleted:
+		return ctrl.Result{}, nil
+	case resources.MigrationCuttingOver:
+		return ctrl.Result{Requeue: true}, nil
+	default:
+		return ctrl.Result{RequeueAfter: resources.MigrationCatchUpInterval(migration.Spec)}, nil
+	}
+}
+
+// runJob creates the Job of a migration step unless it exists, and reports whether it completed
+// or why it failed
+func (r *MongoDBMigrationReconciler) runJob(ctx context.Context, migration *mongodbv1alpha1.MongoDBMigration, job *batchv1.Job) (bool, string, error) {
+	existing := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing)
+	if errors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(migration, job, r.Scheme); err != nil {
+			return false, "", err
+		}
+		log.FromContext(ctx).Info("Creating migration Job", "job", job.Name)
+		return false, "", r.Create(ctx, job)
+	}
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, condition := range existing.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			return true, "", nil
+		case batchv1.JobFailed:
+			return false, condition.Message, nil
+		}
+	}
+	return false, "", nil
+}
+
+// jobProgress returns the progress a completed migration Job reported in its termination message
+func (r *MongoDBMigrationReconciler) jobProgress(ctx context.Context, job *batchv1.Job) (*resources.MigrationProgress, error) {
+	message, err := jobTerminationMessage(ctx, r.Client, job, "migration")
+	if err != nil {
+		return nil, err
+	}
+	return resources.ParseMigrationProgress(message)
+}
+
+func (r *MongoDBMigrationReconciler) succeed(ctx context.Context, migration *mongodbv1alpha1.MongoDBMigration) error {
+	log.FromContext(ctx).Info("Migration completed", "cluster", migration.Spec.ClusterRef.Name)
+
+	now := metav1.Now()
+	migration.Status.Phase = resources.MigrationCompleted
+	migration.Status.Message = ""
+	migration.Status.CompletionTime = &now
+	return r.Status().Update(ctx, migration)
+}
+
+func (r *MongoDBMigrationReconciler) fail(ctx context.Context, migration *mongodbv1alpha1.MongoDBMigration, message string) error {
+	log.FromContext(ctx).Info("Migration failed", "cluster", migration.Spec.ClusterRef.Name, "reason", message)
+
+	now := metav1.Now()
+	migration.Status.Phase = resources.MigrationFailed
+	migration.Status.Message = message
+	migration.Status.CompletionTime = &now
+	migration.Status.ObservedGeneration = migration.Generation
+	return r.Status().Update(ctx, migration)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MongoDBMigrationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mongodbv1alpha1.MongoDBMigration{}).
+		Owns(&batchv1.Job{}).
+		Complete(r)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// MongoDBOperatorConfigReconciler installs the MongoDBOperatorConfig named default as the
+// defaults of the other reconcilers
+type MongoDBOperatorConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodboperatorconfigs,verbs=get;list;watch
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodboperatorconfigs/status,verbs=get;update;patch
+
+func (r *MongoDBOperatorConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	if req.Name != resources.OperatorConfigName {
+		return ctrl.Result{}, nil
+	}
+
+	config := &mongodbv1alpha1.MongoDBOperatorConfig{}
+	if err := r.Get(ctx, req.NamespacedName, config); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("MongoDBOperatorConfig not found, using the built-in defaults")
+			resources.SetOperatorConfig(nil)
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get MongoDBOperatorConfig")
+		return ctrl.Result{}, err
+	}
+
+	condition := metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: config.Generation,
+		Reason:             "Applied",
+		Message:            "The defaults apply to every cluster at its next reconcile",
+	}
+	if err := resources.ValidateOperatorConfig(&config.Spec); err != nil {
+		// The previous configuration stays in place
+		logger.Info("Ignoring invalid MongoDBOperatorConfig", "error", err)
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidSpec"
+		condition.Message = err.Error()
+	} else {
+		logger.Info("Applying MongoDBOperatorConfig", "generation", config.Generation)
+		resources.SetOperatorConfig(&config.Spec)
+	}
+
+	meta.SetStatusCondition(&config.Status.Conditions, condition)
+	config.Status.ObservedGeneration = config.Generation
+	return ctrl.Result{}, r.Status().Update(ctx, config)
+}
+
+// LoadOperatorConfig installs the MongoDBOperatorConfig before the reconcilers start, so the
+// first reconcile of a cluster already renders its resources with the configured defaults.
+// A missing MongoDBOperatorConfig or CRD leaves the built-in defaults.
+func LoadOperatorConfig(ctx context.Context, reader client.Reader) error {
+	config := &mongodbv1alpha1.MongoDBOperatorConfig{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: resources.OperatorConfigName}, config); err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get MongoDBOperatorConfig: %w", err)
+	}
+	if err := resources.ValidateOperatorConfig(&config.Spec); err != nil {
+		return fmt.Errorf("invalid MongoDBOperatorConfig: %w", err)
+	}
+	resources.SetOperatorConfig(&config.Spec)
+	return nil
+}
+
+// requeueInterval is how often up-to-date resources are checked again
+func requeueInterval() time.Duration {
+	return resources.RequeueInterval(requeueAfter)
+}
+
+// retryInterval is how soon resources waiting on pods or on another resource are checked again
+func retryInterval() time.Duration {
+	return resources.RetryInterval(retryAfter)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MongoDBOperatorConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mongodbv1alpha1.MongoDBOperatorConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
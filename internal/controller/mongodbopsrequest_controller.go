@@ -0,0 +1,523 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+const (
+	// opsRequestPollInterval is how often the progress of a waiting step is checked
+	opsRequestPollInterval = 10 * time.Second
+
+	// opsStepDownSeconds keeps the stepped down primary from being elected again right away
+	opsStepDownSeconds = 60
+
+	// opsStepDownCatchUpSeconds is how long the primary waits for an electable secondary to catch up
+	opsStepDownCatchUpSeconds = 15
+)
+
+// MongoDBOpsRequestReconciler reconciles a MongoDBOpsRequest object
+type MongoDBOpsRequestReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	Managers
+}
+
+// opsCluster is the cluster an operation runs against, exactly one of mdb and mdbsh is set
+type opsCluster struct {
+	mdb   *mongodbv1alpha1.MongoDB
+	mdbsh *mongodbv1alpha1.MongoDBSharded
+}
+
+func (c *opsCluster) object() client.Object {
+	if c.mdbsh != nil {
+		return c.mdbsh
+	}
+	return c.mdb
+}
+
+func (c *opsCluster) auth() mongodbv1alpha1.AuthSpec {
+	if c.mdbsh != nil {
+		return c.mdbsh.Spec.Auth
+	}
+	return c.mdb.Spec.Auth
+}
+
+// member returns the member running in pod, or the first member of the replica set, or of the
+// config server replica set, when pod is empty
+func (c *opsCluster) member(pod string) (*resources.OpsRequestMember, error) {
+	if c.mdbsh != nil {
+		if pod == "" {
+			pod = c.mdbsh.Name + "-cfg-0"
+		}
+		return resources.ShardedOpsMember(c.mdbsh, pod)
+	}
+
+	if resources.Standalone(c.mdb) {
+		return nil, fmt.Errorf("%s is a standalone mongod without replica set", c.mdb.Name)
+	}
+	if pod == "" {
+		pod = c.mdb.Name + "-0"
+	}
+	return resources.ReplicaSetOpsMember(c.mdb, pod)
+}
+
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbopsrequests,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbopsrequests/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbopsrequests/finalizers,verbs=update
+
+func (r *MongoDBOpsRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	logger.Info("Reconciling MongoDBOpsRequest", "namespace", req.Namespace, "name", req.Name)
+
+	// Fetch MongoDBOpsRequest instance
+	ops := &mongodbv1alpha1.MongoDBOpsRequest{}
+	if err := r.Get(ctx, req.NamespacedName, ops); err != nil {
+		if errors.IsNotFound(err) {
+			logger.Info("MongoDBOpsRequest resource not found, ignoring")
+			return ctrl.Result{}, nil
+		}
+		logger.Error(err, "Failed to get MongoDBOpsRequest")
+		return ctrl.Result{}, err
+	}
+
+	// Operations on the cluster share its exec rate limit
+	ctx = mongodb.WithClusterScope(ctx, ops.Namespace, ops.Spec.ClusterRef.Name)
+
+	// An operation runs once, a finished request is only kept as a record
+	if ops.Status.Phase == resources.OpsRequestSucceeded || ops.Status.Phase == resources.OpsRequestFailed {
+		return ctrl.Result{}, nil
+	}
+
+	// 1. Validate the spec and plan the steps
+	if ops.Status.Phase == "" {
+		if err := resources.ValidateOpsRequest(ops.Spec); err != nil {
+			return ctrl.Result{}, r.fail(ctx, ops, err.Error())
+		}
+		now := metav1.Now()
+		ops.Status.Phase = resources.OpsRequestRunning
+		ops.Status.StartTime = &now
+		ops.Status.ObservedGeneration = ops.Generation
+		ops.Status.Steps = nil
+		for _, name := range resources.OpsRequestSteps(ops.Spec) {
+			ops.Status.Steps = append(ops.Status.Steps, mongodbv1alpha1.OpsRequestStep{Name: name, Phase: resources.OpsRequestPending})
+		}
+		if err := r.Status().Update(ctx, ops); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// 2. Fetch the cluster
+	cluster, err := r.getCluster(ctx, ops)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if cluster == nil {
+		return ctrl.Result{}, r.fail(ctx, ops, fmt.Sprintf("%s %s not found", ops.Spec.ClusterRef.Kind, ops.Spec.ClusterRef.Name))
+	}
+
+	// 3. Run the current step
+	step := currentOpsStep(ops)
+	if step == nil {
+		return ctrl.Result{}, r.succeed(ctx, ops)
+	}
+	if step.Phase == resources.OpsRequestPending {
+		now := metav1.Now()
+		step.Phase = resources.OpsRequestRunning
+		step.StartTime = &now
+	}
+
+	done, message, err := r.runStep(ctx, ops, cluster, step.Name)
+	if err != nil {
+		logger.Error(err, "Operation step failed", "step", step.Name)
+		now := metav1.Now()
+		step.Phase = resources.OpsRequestFailed
+		step.Message = err.Error()
+		step.CompletionTime = &now
+		return ctrl.Result{}, r.fail(ctx, ops, fmt.Sprintf("step %s failed: %s", step.Name, err))
+	}
+	step.Message = message
+
+	if !done {
+		if err := r.Status().Update(ctx, ops); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: opsRequestPollInterval}, nil
+	}
+
+	logger.Info("Operation step succeeded", "step", step.Name)
+	now := metav1.Now()
+	step.Phase = resources.OpsRequestSucceeded
+	step.CompletionTime = &now
+	if currentOpsStep(ops) == nil {
+		return ctrl.Result{}, r.succeed(ctx, ops)
+	}
+	if err := r.Status().Update(ctx, ops); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{Requeue: true}, nil
+}
+
+// currentOpsStep returns the first step that did not succeed, or nil when all of them did
+func currentOpsStep(ops *mongodbv1alpha1.MongoDBOpsRequest) *mongodbv1alpha1.OpsRequestStep {
+	for i := range ops.Status.Steps {
+		if ops.Status.Steps[i].Phase != resources.OpsRequestSucceeded {
+			return &ops.Status.Steps[i]
+		}
+	}
+	return nil
+}
+
+// getCluster returns the cluster referenced by the request, or nil when it does not exist
+func (r *MongoDBOpsRequestReconciler) getCluster(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest) (*opsCluster, error) {
+	key := types.NamespacedName{Name: ops.Spec.ClusterRef.Name, Namespace: ops.Namespace}
+
+	if ops.Spec.ClusterRef.Kind == "MongoDBSharded" {
+		mdbsh := &mongodbv1alpha1.MongoDBSharded{}
+		if err := r.Get(ctx, key, mdbsh); err != nil {
+			if errors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to get MongoDBSharded cluster: %w", err)
+		}
+		return &opsCluster{mdbsh: mdbsh}, nil
+	}
+
+	mdb := &mongodbv1alpha1.MongoDB{}
+	if err := r.Get(ctx, key, mdb); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get MongoDB cluster: %w", err)
+	}
+	return &opsCluster{mdb: mdb}, nil
+}
+
+// runStep runs a step and reports whether it is done. Waiting steps return false until the cluster
+// reached the expected state, errors fail the operation.
+func (r *MongoDBOpsRequestReconciler) runStep(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster, step string) (bool, string, error) {
+	switch step {
+	case resources.OpsStepStepDown:
+		return r.stepDown(ctx, ops, cluster)
+	case resources.OpsStepWaitForPrimary:
+		return r.waitForPrimary(ctx, ops, cluster)
+	case resources.OpsStepDeleteData:
+		return r.deleteData(ctx, ops, cluster)
+	case resources.OpsStepWaitForMember:
+		return r.waitForMember(ctx, ops, cluster)
+	case resources.OpsStepCompact:
+		return r.compact(ctx, ops, cluster)
+	case resources.OpsStepRequestRotation:
+		return r.requestRotation(ctx, ops, cluster)
+	case resources.OpsStepWaitForRotation:
+		return waitForRotation(ops, cluster)
+	case resources.OpsStepFlushRouterConfig:
+		return r.flushRouterConfig(ctx, ops, cluster)
+	case resources.OpsStepRequestRestart:
+		return r.requestRestart(ctx, ops, cluster)
+	case resources.OpsStepWaitForRollout:
+		return r.waitForRollout(ctx, ops, cluster)
+	}
+	return false, "", fmt.Errorf("unknown step %s", step)
+}
+
+// memberManager returns the member an operation targets with a replica set manager for its port
+// and the keyfile to authenticate with
+func (r *MongoDBOpsRequestReconciler) memberManager(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (*resources.OpsRequestMember, mongodb.ReplicaSetManager, string, error) {
+	member, err := cluster.member(ops.Spec.Member)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, cluster.object().GetName(), ops.Namespace, cluster.auth())
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	rsManager := r.replicaSetManager(int(member.Port))
+	return member, rsManager, keyfile, nil
+}
+
+func (r *MongoDBOpsRequestReconciler) stepDown(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	member, rsManager, keyfile, err := r.memberManager(ctx, ops, cluster)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := rsManager.StepDownWithKeyfile(ctx, member.Pod, ops.Namespace, keyfile, opsStepDownSeconds, opsStepDownCatchUpSeconds); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("Primary of the %s replica set stepped down", member.StatefulSet), nil
+}
+
+func (r *MongoDBOpsRequestReconciler) waitForPrimary(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	member, rsManager, keyfile, err := r.memberManager(ctx, ops, cluster)
+	if err != nil {
+		return false, "", err
+	}
+
+	// The members may still be electing, retried on the next poll
+	rsStatus, err := rsManager.GetStatusWithKeyfile(ctx, member.Pod, ops.Namespace, keyfile)
+	if err != nil {
+		return false, "Waiting for the replica set status", nil
+	}
+	for _, m := range rsStatus.Members {
+		if m.StateStr == "PRIMARY" && m.Health == 1 {
+			return true, fmt.Sprintf("%s is the new primary", strings.Split(m.Name, ".")[0]), nil
+		}
+	}
+	return false, "Waiting for a primary to be elected", nil
+}
+
+func (r *MongoDBOpsRequestReconciler) deleteData(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	member, err := cluster.member(ops.Spec.Member)
+	if err != nil {
+		return false, "", err
+	}
+
+	log.FromContext(ctx).Info("Resyncing member", "pod", member.Pod)
+	if err := resyncMember(ctx, r.Client, ops.Namespace, member.Pod, member.Storage); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("Deleted the data of %s", member.Pod), nil
+}
+
+func (r *MongoDBOpsRequestReconciler) waitForMember(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	member, rsManager, keyfile, err := r.memberManager(ctx, ops, cluster)
+	if err != nil {
+		return false, "", err
+	}
+
+	if err := retryResync(ctx, r.Client, ops.Namespace, member.Pod, member.Storage); err != nil {
+		return false, "", err
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: member.Pod, Namespace: ops.Namespace}, pod); err != nil || !resources.PodReady(pod) {
+		return false, fmt.Sprintf("Waiting for %s to be ready", member.Pod), nil
+	}
+
+	// The initial sync may take a while, the member reports STARTUP2 until it is done
+	rsStatus, err := rsManager.GetStatusWithKeyfile(ctx, member.Pod, ops.Namespace, keyfile)
+	if err != nil {
+		return false, fmt.Sprintf("Waiting for the replica set status of %s", member.Pod), nil
+	}
+	for _, m := range rsStatus.Members {
+		if !m.Self {
+			continue
+		}
+		if m.StateStr == "PRIMARY" || m.StateStr == "SECONDARY" {
+			return true, fmt.Sprintf("%s completed its initial sync", member.Pod), nil
+		}
+		return false, fmt.Sprintf("%s is in state %s", member.Pod, m.StateStr), nil
+	}
+	return false, fmt.Sprintf("Waiting for %s to join the replica set", member.Pod), nil
+}
+
+func (r *MongoDBOpsRequestReconciler) compact(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	member, rsManager, keyfile, err := r.memberManager(ctx, ops, cluster)
+	if err != nil {
+		return false, "", err
+	}
+
+	log.FromContext(ctx).Info("Compacting collection", "pod", member.Pod, "database", ops.Spec.Database, "collection", ops.Spec.Collection)
+	if err := rsManager.CompactWithKeyfile(ctx, member.Pod, ops.Namespace, keyfile, ops.Spec.Database, ops.Spec.Collection); err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("Compacted %s.%s on %s", ops.Spec.Database, ops.Spec.Collection, member.Pod), nil
+}
+
+// requestRotation sets the rotate-keyfile annotation of the cluster to the request UID, the cluster
+// controller then runs the rotation
+func (r *MongoDBOpsRequestReconciler) requestRotation(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	auth := cluster.auth()
+	if !resources.IsKeyfileSecretGenerated(auth) {
+		return false, "", fmt.Errorf("keyfile rotation is not supported for the user-provided keyfile secret %s", auth.KeyfileSecretRef.Name)
+	}
+
+	if err := r.annotateCluster(ctx, cluster, []string{resources.RotateKeyfileAnnotation}, string(ops.UID)); err != nil {
+		return false, "", err
+	}
+	return true, "Requested the keyfile rotation", nil
+}
+
+func waitForRotation(ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	var rotation *mongodbv1alpha1.KeyfileRotationStatus
+	if cluster.mdbsh != nil {
+		rotation = cluster.mdbsh.Status.KeyfileRotation
+	} else {
+		rotation = cluster.mdb.Status.KeyfileRotation
+	}
+
+	if rotation == nil || rotation.Requested != string(ops.UID) {
+		return false, "Waiting for the keyfile rotation to start", nil
+	}
+	if rotation.Phase != resources.KeyfileRotationCompleted {
+		return false, fmt.Sprintf("Keyfile rotation is %s", rotation.Phase), nil
+	}
+	return true, "Keyfile rotated", nil
+}
+
+func (r *MongoDBOpsRequestReconciler) flushRouterConfig(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	mdbsh := cluster.mdbsh
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	shardManager := r.shardManager()
+
+	flushed, err := flushRouterConfigs(ctx, r.Client, mdbsh, shardManager, creds)
+	if err != nil {
+		return false, "", err
+	}
+	return true, fmt.Sprintf("Flushed the routing table cache of %d mongos", flushed), nil
+}
+
+// restartAnnotations returns the cluster annotations restarting the components of the request
+func restartAnnotations(ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) []string {
+	if cluster.mdbsh == nil {
+		return []string{resources.RestartAnnotation}
+	}
+	if ops.Spec.Component != "" {
+		return []string{resources.RestartAnnotationFor(ops.Spec.Component)}
+	}
+	return []string{
+		resources.RestartAnnotationFor(resources.ComponentConfigServer),
+		resources.RestartAnnotationFor(resources.ComponentShards),
+		resources.RestartAnnotationFor(resources.ComponentMongos),
+	}
+}
+
+// requestRestart sets the restart annotations of the cluster to the request UID, the cluster
+// controller rolls the pods when it updates the workloads
+func (r *MongoDBOpsRequestReconciler) requestRestart(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	if err := r.annotateCluster(ctx, cluster, restartAnnotations(ops, cluster), string(ops.UID)); err != nil {
+		return false, "", err
+	}
+	return true, "Requested the restart", nil
+}
+
+func (r *MongoDBOpsRequestReconciler) waitForRollout(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, cluster *opsCluster) (bool, string, error) {
+	var statefulSets []string
+	mongos := false
+	if cluster.mdbsh == nil {
+		statefulSets = append(statefulSets, cluster.mdb.Name)
+	} else {
+		mdbsh := cluster.mdbsh
+		if ops.Spec.Component == "" || ops.Spec.Component == resources.ComponentConfigServer {
+			statefulSets = append(statefulSets, mdbsh.Name+"-cfg")
+		}
+		if ops.Spec.Component == "" || ops.Spec.Component == resources.ComponentShards {
+			for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+				statefulSets = append(statefulSets, fmt.Sprintf("%s-shard-%d", mdbsh.Name, i))
+			}
+		}
+		mongos = ops.Spec.Component == "" || ops.Spec.Component == resources.ComponentMongos
+	}
+
+	for _, name := range statefulSets {
+		sts := &appsv1.StatefulSet{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: ops.Namespace}, sts); err != nil {
+			return false, "", err
+		}
+		if sts.Spec.Template.Annotations[resources.RestartedAnnotation] != string(ops.UID) || !statefulSetUpdated(sts) {
+			return false, fmt.Sprintf("Waiting for StatefulSet %s to roll out", name), nil
+		}
+	}
+
+	if mongos {
+		workloads, err := getMongosWorkloads(ctx, r.Client, cluster.mdbsh)
+		if err != nil {
+			return false, "", err
+		}
+		for _, w := range workloads {
+			if w.template.Annotations[resources.RestartedAnnotation] != string(ops.UID) || !w.updated {
+				return false, "Waiting for mongos to roll out", nil
+			}
+		}
+	}
+
+	return true, "All pods restarted", nil
+}
+
+// annotateCluster sets the annotations on the cluster to value
+func (r *MongoDBOpsRequestReconciler) annotateCluster(ctx context.Context, cluster *opsCluster, keys []string, value string) error {
+	obj := cluster.object()
+	patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for _, key := range keys {
+		annotations[key] = value
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := r.Patch(ctx, obj, patch); err != nil {
+		return fmt.Errorf("failed to annotate %s: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+func (r *MongoDBOpsRequestReconciler) succeed(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest) error {
+	log.FromContext(ctx).Info("Operation succeeded", "type", ops.Spec.Type)
+
+	now := metav1.Now()
+	ops.Status.Phase = resources.OpsRequestSucceeded
+	ops.Status.Message = ""
+	ops.Status.CompletionTime = &now
+	return r.Status().Update(ctx, ops)
+}
+
+func (r *MongoDBOpsRequestReconciler) fail(ctx context.Context, ops *mongodbv1alpha1.MongoDBOpsRequest, message string) error {
+	log.FromContext(ctx).Info("Operation failed", "type", ops.Spec.Type, "reason", message)
+
+	now := metav1.Now()
+	ops.Status.Phase = resources.OpsRequestFailed
+	ops.Status.Message = message
+	ops.Status.CompletionTime = &now
+	ops.Status.ObservedGeneration = ops.Generation
+	return r.Status().Update(ctx, ops)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MongoDBOpsRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&mongodbv1alpha1.MongoDBOpsRequest{}).
+		Complete(r)
+}
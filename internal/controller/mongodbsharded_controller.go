@@ -19,22 +19,29 @@ package controller
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
-	"github.com/keiailab/mongodb-operator/internal/mongodb"
 	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
 )
 
 const (
@@ -45,6 +52,7 @@ const (
 type MongoDBShardedReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	Managers
 }
 
 // +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbshardeds,verbs=get;list;watch;create;update;patch;delete
@@ -52,11 +60,17 @@ type MongoDBShardedReconciler struct {
 // +kubebuilder:rbac:groups=mongodb.keiailab.com,resources=mongodbshardeds/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups=core,resources=pods/exec,verbs=create
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 
 func (r *MongoDBShardedReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
@@ -73,6 +87,9 @@ func (r *MongoDBShardedReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
+	// Exec calls of this reconcile share the rate limit and query results of the cluster
+	ctx = mongodb.WithClusterScope(ctx, mdbsh.Namespace, mdbsh.Name)
+
 	// Handle deletion
 	if !mdbsh.DeletionTimestamp.IsZero() {
 		return r.handleDeletion(ctx, mdbsh)
@@ -95,83 +112,239 @@ func (r *MongoDBShardedReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		}
 	}
 
+	// Validate the spec before touching any workload
+	if err := resources.ValidateMongosVersion(mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "MongosVersion", err)
+	}
+	// mongos runs the oldest version of the cluster
+	if err := resources.ValidateAuth(mdbsh.Spec.Auth, resources.MongosVersion(mdbsh)); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Auth", err)
+	}
+	if err := resources.ValidateUserConnectionSecrets(mdbsh.Name, mdbsh.Spec.Auth.Users); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Users", err)
+	}
+	if err := resources.ValidateMonitoring(mdbsh.Spec.Monitoring); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Monitoring", err)
+	}
+	if err := resources.ValidateShardZones(mdbsh.Spec.Shards); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Zones", err)
+	}
+	if err := resources.ValidateMongosMode(mdbsh.Spec.Mongos); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "MongosMode", err)
+	}
+	if err := resources.ValidateMongosBlueGreen(mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "MongosBlueGreen", err)
+	}
+	if err := resources.ValidateAdditionalConfig(mdbsh.Spec.AdditionalConfig); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "AdditionalConfig", err)
+	}
+	if err := resources.ValidateStorageEngine(mdbsh.Spec.StorageEngine, mdbsh.Spec.AdditionalConfig); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "StorageEngine", err)
+	}
+	if err := resources.ValidateResourceProfile(mdbsh.Spec.ResourceProfile, map[string]mongodbv1alpha1.ResourcesSpec{
+		resources.ComponentConfigServer: mdbsh.Spec.ConfigServer.Resources,
+		resources.ComponentShards:       mdbsh.Spec.Shards.Resources,
+	}); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "ResourceProfile", err)
+	}
+	if err := resources.ValidateDiagnostics(mdbsh.Spec.Profiling, mdbsh.Spec.Logging); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Diagnostics", err)
+	}
+	if err := resources.ValidateInitScripts(mdbsh.Spec.InitScripts); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "InitScripts", err)
+	}
+	if err := resources.ValidateShardedVotingMembers(mdbsh); err != nil {
+		meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildInvalidSpecCondition(err, mdbsh.Generation))
+		return r.updateStatusError(ctx, mdbsh, "Members", err)
+	}
+
+	// Refuse changes that would wedge the members unless they are forced
+	settings := resources.ShardedSettings(mdbsh)
+	if err := resources.ValidateSettingsChange(mdbsh.Status.AppliedSettings, settings); err != nil && !resources.ForceUpdate(mdbsh.Annotations) {
+		meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildInvalidUpdateCondition(err, mdbsh.Generation))
+		return r.updateStatusError(ctx, mdbsh, "Update", err)
+	}
+	mdbsh.Status.AppliedSettings = settings
+	if mdbsh.Status.ObservedGeneration != mdbsh.Generation {
+		for _, votes := range []int32{mdbsh.Spec.ConfigServer.Members, mdbsh.Spec.Shards.MembersPerShard} {
+			if warning := resources.EvenVotingMembersWarning(votes); warning != "" {
+				logger.Info("Even number of voting members", "warning", warning)
+			}
+		}
+	}
+
 	// Reconcile resources in order
 
-	// 1. Keyfile Secret
+	// 1. Admin credentials Secret (generated when not referenced)
+	if err := r.reconcileAdminSecret(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "AdminSecret", err)
+	}
+
+	// 2. Exporter credentials Secret
+	if resources.MonitoringEnabled(mdbsh.Spec.Monitoring) {
+		if err := ensureMonitoringSecret(ctx, r.Client, mdbsh.Name, mdbsh.Namespace); err != nil {
+			return r.updateStatusError(ctx, mdbsh, "MonitoringSecret", err)
+		}
+	}
+
+	// 3. Keyfile Secret
 	if err := r.reconcileKeyfileSecret(ctx, mdbsh); err != nil {
 		return r.updateStatusError(ctx, mdbsh, "KeyfileSecret", err)
 	}
 
-	// 2. Config Server
+	// 4. ServiceAccount of the config server, shard and mongos pods
+	if err := r.reconcileServiceAccount(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "ServiceAccount", err)
+	}
+
+	// 5. Config Server
 	if err := r.reconcileConfigServer(ctx, mdbsh); err != nil {
 		return r.updateStatusError(ctx, mdbsh, "ConfigServer", err)
 	}
 
-	// 3. Wait for Config Server to be ready
+	// 6. Shards, provisioned alongside the config servers
+	if err := r.reconcileShards(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Shards", err)
+	}
+
+	// 7. Wait for Config Server to be ready
 	if !r.isConfigServerReady(ctx, mdbsh) {
 		logger.Info("Waiting for config server to be ready")
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
 	}
 
-	// 4. Shards
-	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
-		if err := r.reconcileShard(ctx, mdbsh, i); err != nil {
-			return r.updateStatusError(ctx, mdbsh, fmt.Sprintf("Shard-%d", i), err)
+	// 8. Initialize Config Server replica set
+	if !mdbsh.Status.ConfigServerInitialized {
+		if err := r.reconcileConfigServerInit(ctx, mdbsh); err != nil {
+			logger.Info("Failed to initialize config server, will retry", "error", err)
+			return ctrl.Result{RequeueAfter: retryInterval()}, nil
 		}
 	}
 
-	// 5. Wait for Shards to be ready
-	if !r.areShardsReady(ctx, mdbsh) {
-		logger.Info("Waiting for shards to be ready")
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
-	}
-
-	// 6. Mongos
+	// 9. Mongos, rolled out as soon as the config servers are up
 	if err := r.reconcileMongos(ctx, mdbsh); err != nil {
 		return r.updateStatusError(ctx, mdbsh, "Mongos", err)
 	}
 
-	// 7. Initialize Config Server replica set
-	if !mdbsh.Status.ConfigServerInitialized {
-		if err := r.reconcileConfigServerInit(ctx, mdbsh); err != nil {
-			logger.Info("Failed to initialize config server, will retry", "error", err)
-			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
-		}
-	}
-
-	// 8. Initialize Shard replica sets
+	// 10. Initialize the replica sets of the shards that are ready
 	if err := r.reconcileShardsInit(ctx, mdbsh); err != nil {
 		logger.Info("Failed to initialize shards, will retry", "error", err)
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
 	}
 
-	// 9. Wait for mongos to be ready
+	// 11. Wait for mongos to be ready
 	if !r.isMongosReady(ctx, mdbsh) {
 		logger.Info("Waiting for mongos to be ready")
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
 	}
 
-	// 10. Create admin user
+	// 12. Create admin user
 	if !mdbsh.Status.AdminUserCreated {
 		if err := r.reconcileShardedAdminUser(ctx, mdbsh); err != nil {
 			logger.Info("Failed to create admin user, will retry", "error", err)
-			return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+			return ctrl.Result{RequeueAfter: retryInterval()}, nil
+		}
+		if !mdbsh.Status.AdminUserCreated {
+			logger.Info("Waiting for the admin bootstrap Job")
+			return ctrl.Result{RequeueAfter: retryInterval()}, nil
 		}
 	}
 
-	// 11. Add shards to cluster
+	// 13. Add the initialized shards to the cluster
 	if err := r.reconcileAddShards(ctx, mdbsh); err != nil {
 		logger.Info("Failed to add shards, will retry", "error", err)
-		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	// 14. Wait for every shard to be ready and part of the cluster
+	if !r.areShardsReady(ctx, mdbsh) || !r.areShardsAdded(mdbsh) {
+		logger.Info("Waiting for shards to be ready")
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	// 15. Verify the shard hosts registered on the config servers
+	if err := r.reconcileShardHosts(ctx, mdbsh); err != nil {
+		logger.Info("Failed to verify shard hosts, will retry", "error", err)
+	}
+
+	// 16. Drain and remove shards above spec.shards.count
+	if err := r.reconcileShardRemoval(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "ShardRemoval", err)
+	}
+
+	// 17. Balancer state and window
+	if err := r.reconcileBalancer(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Balancer", err)
+	}
+
+	// 18. Shard zones and zone key ranges
+	if err := r.reconcileShardZones(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Zones", err)
+	}
+
+	// 19. Rotate admin password when the credentials secret changed
+	if err := r.reconcileAdminPassword(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "PasswordRotation", err)
+	}
+
+	// 20. Exporter user on the config servers and every shard
+	if err := r.reconcileMonitoringUser(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "MonitoringUser", err)
+	}
+
+	// 21. Init scripts through mongos, run once the shards are part of the cluster
+	if err := r.reconcileInitScripts(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "InitScripts", err)
+	}
+
+	// 22. Profiler and log verbosity of the config server and shard members
+	if err := r.reconcileDiagnostics(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "Diagnostics", err)
+	}
+
+	// 23. Keyfile rotation (requested through the rotate-keyfile annotation)
+	if err := r.reconcileKeyfileRotation(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "KeyfileRotation", err)
+	}
+
+	// 24. Connection Secret for applications
+	if err := r.reconcileConnectionSecret(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "ConnectionSecret", err)
+	}
+
+	// 25. ServiceMonitors for every component
+	if err := r.reconcileServiceMonitors(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "ServiceMonitor", err)
+	}
+
+	// 26. Disk usage of the config server and shard members
+	if err := r.reconcileStorageUsage(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "StorageUsage", err)
+	}
+
+	// 27. Image and version of the config server and shard members
+	if err := r.reconcileMemberVersions(ctx, mdbsh); err != nil {
+		return r.updateStatusError(ctx, mdbsh, "MemberVersions", err)
 	}
 
-	// 12. Update status
+	// 28. Update status
 	if err := r.updateStatus(ctx, mdbsh); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	if len(mdbsh.Status.RemovingShards) > 0 {
+		logger.Info("Waiting for shards to drain", "shards", len(mdbsh.Status.RemovingShards))
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
+	// Members catching up do not trigger a reconcile, check on them until the rollouts are done
+	if meta.IsStatusConditionTrue(mdbsh.Status.Conditions, resources.MembersUpdatingCondition) {
+		logger.Info("Waiting for updated members to catch up")
+		return ctrl.Result{RequeueAfter: retryInterval()}, nil
+	}
+
 	logger.Info("Successfully reconciled MongoDBSharded")
-	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+	return ctrl.Result{RequeueAfter: requeueInterval()}, nil
 }
 
 func (r *MongoDBShardedReconciler) handleDeletion(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) (ctrl.Result, error) {
@@ -181,6 +354,9 @@ func (r *MongoDBShardedReconciler) handleDeletion(ctx context.Context, mdbsh *mo
 	if controllerutil.ContainsFinalizer(mdbsh, mongodbShardedFinalizer) {
 		// Perform cleanup logic here if needed
 
+		r.Executor.ForgetCluster(mdbsh.Namespace, mdbsh.Name)
+		forgetBalancerMetrics(mdbsh)
+
 		// Remove finalizer
 		controllerutil.RemoveFinalizer(mdbsh, mongodbShardedFinalizer)
 		if err := r.Update(ctx, mdbsh); err != nil {
@@ -191,7 +367,36 @@ func (r *MongoDBShardedReconciler) handleDeletion(ctx context.Context, mdbsh *mo
 	return ctrl.Result{}, nil
 }
 
+func (r *MongoDBShardedReconciler) reconcileAdminSecret(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	// Referenced secrets are owned by the user
+	if !resources.IsAdminSecretGenerated(mdbsh.Spec.Auth) {
+		return nil
+	}
+
+	// Never regenerate an existing secret - the admin user password lives in the database
+	existingSecret := &corev1.Secret{}
+	secretName := resources.AdminSecretName(mdbsh.Name, mdbsh.Spec.Auth)
+	err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: mdbsh.Namespace}, existingSecret)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+
+	// No owner reference: the secret must outlive the CR just like the data volumes,
+	// otherwise recreating the cluster would generate a password that no longer matches
+	secret := resources.BuildAdminSecret(mdbsh.Name, mdbsh.Namespace)
+	return r.Create(ctx, secret)
+}
+
 func (r *MongoDBShardedReconciler) reconcileKeyfileSecret(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	// Referenced keyfiles are owned by the user, only make sure they are usable
+	if !resources.IsKeyfileSecretGenerated(mdbsh.Spec.Auth) {
+		_, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+		return err
+	}
+
 	// Check if keyfile secret already exists - DO NOT regenerate if it exists
 	// Keyfile must remain constant across all pods for replica set authentication
 	existingSecret := &corev1.Secret{}
@@ -213,6 +418,14 @@ func (r *MongoDBShardedReconciler) reconcileKeyfileSecret(ctx context.Context, m
 	return r.Create(ctx, secret)
 }
 
+// reconcileServiceAccount creates the ServiceAccount of the pods unless every pod spec names its own
+func (r *MongoDBShardedReconciler) reconcileServiceAccount(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !resources.UsesClusterServiceAccount(mdbsh.Spec.ConfigServer.Pod, mdbsh.Spec.Shards.Pod, mdbsh.Spec.Mongos.Pod) {
+		return nil
+	}
+	return r.createOrUpdate(ctx, mdbsh, resources.BuildServiceAccount(mdbsh.Name, mdbsh.Namespace))
+}
+
 func (r *MongoDBShardedReconciler) reconcileConfigServer(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
 	// Headless service
 	svc := resources.BuildConfigServerService(mdbsh)
@@ -220,8 +433,21 @@ func (r *MongoDBShardedReconciler) reconcileConfigServer(ctx context.Context, md
 		return err
 	}
 
+	// mongod.conf
+	cm := resources.BuildMongodConfigMap(mdbsh.Name, mdbsh.Name+"-cfg", mdbsh.Namespace, resources.BuildConfigServerConfig(mdbsh), mdbsh.Spec.Telemetry)
+	if err := r.createOrUpdate(ctx, mdbsh, cm); err != nil {
+		return err
+	}
+
 	// StatefulSet
 	sts := resources.BuildConfigServerStatefulSet(mdbsh)
+	if err := r.setKeyfileHashAnnotation(ctx, mdbsh, &sts.Spec.Template); err != nil {
+		return err
+	}
+	// Config servers listen on 27019
+	if err := r.gateRollout(ctx, mdbsh, sts, 27019); err != nil {
+		return err
+	}
 	return r.createOrUpdate(ctx, mdbsh, sts)
 }
 
@@ -233,26 +459,76 @@ func (r *MongoDBShardedReconciler) isConfigServerReady(ctx context.Context, mdbs
 	return sts.Status.ReadyReplicas == mdbsh.Spec.ConfigServer.Members
 }
 
-func (r *MongoDBShardedReconciler) reconcileShard(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32) error {
+// reconcileShards creates or updates the resources of every shard, several shards at a time.
+// The shards collect the out-of-band changes they revert on their own, they are recorded in the
+// status once all shards are done.
+func (r *MongoDBShardedReconciler) reconcileShards(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	drift := make([][]mongodbv1alpha1.DriftStatus, mdbsh.Spec.Shards.Count)
+	err := forEachShard(mdbsh.Spec.Shards.Count, func(i int32) error {
+		var err error
+		drift[i], err = r.reconcileShard(ctx, mdbsh, i)
+		return err
+	})
+
+	for _, shardDrift := range drift {
+		for _, d := range shardDrift {
+			mdbsh.Status.Drift = resources.RecordDrift(mdbsh.Status.Drift, d.Resource, d.DetectedAt.Time)
+		}
+	}
+	return err
+}
+
+// reconcileShard creates or updates the resources of a shard and returns the out-of-band changes
+// it reverted. It runs next to the other shards, so it does not write the status.
+func (r *MongoDBShardedReconciler) reconcileShard(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32) ([]mongodbv1alpha1.DriftStatus, error) {
 	// Headless service
 	svc := resources.BuildShardService(mdbsh, shardIndex)
-	if err := r.createOrUpdate(ctx, mdbsh, svc); err != nil {
-		return err
+	drift, err := r.applyObject(ctx, mdbsh, svc, nil)
+	if err != nil {
+		return drift, err
+	}
+
+	// mongod.conf
+	cm := resources.BuildMongodConfigMap(mdbsh.Name, fmt.Sprintf("%s-shard-%d", mdbsh.Name, shardIndex), mdbsh.Namespace, resources.BuildShardConfig(mdbsh, shardIndex), mdbsh.Spec.Telemetry)
+	if drift, err = r.applyObject(ctx, mdbsh, cm, drift); err != nil {
+		return drift, err
 	}
 
 	// StatefulSet
 	sts := resources.BuildShardStatefulSet(mdbsh, shardIndex)
-	return r.createOrUpdate(ctx, mdbsh, sts)
+	if err := r.setKeyfileHashAnnotation(ctx, mdbsh, &sts.Spec.Template); err != nil {
+		return drift, err
+	}
+	// Shards listen on 27018
+	if err := r.gateRollout(ctx, mdbsh, sts, 27018); err != nil {
+		return drift, err
+	}
+	return r.applyObject(ctx, mdbsh, sts, drift)
 }
 
 func (r *MongoDBShardedReconciler) areShardsReady(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) bool {
 	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
-		sts := &appsv1.StatefulSet{}
-		stsName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, i)
-		if err := r.Get(ctx, types.NamespacedName{Name: stsName, Namespace: mdbsh.Namespace}, sts); err != nil {
+		if !r.isShardReady(ctx, mdbsh, i) {
 			return false
 		}
-		if sts.Status.ReadyReplicas != mdbsh.Spec.Shards.MembersPerShard {
+	}
+	return true
+}
+
+// isShardReady reports whether every member of a shard is ready
+func (r *MongoDBShardedReconciler) isShardReady(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32) bool {
+	sts := &appsv1.StatefulSet{}
+	stsName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, shardIndex)
+	if err := r.Get(ctx, types.NamespacedName{Name: stsName, Namespace: mdbsh.Namespace}, sts); err != nil {
+		return false
+	}
+	return sts.Status.ReadyReplicas == mdbsh.Spec.Shards.MembersPerShard
+}
+
+// areShardsAdded reports whether every shard has been initialized and added to the cluster
+func (r *MongoDBShardedReconciler) areShardsAdded(mdbsh *mongodbv1alpha1.MongoDBSharded) bool {
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		if !resources.ShardAdded(&mdbsh.Status, resources.ShardName(mdbsh.Name, i)) {
 			return false
 		}
 	}
@@ -272,17 +548,171 @@ func (r *MongoDBShardedReconciler) reconcileMongos(ctx context.Context, mdbsh *m
 		return err
 	}
 
-	// Deployment
-	deploy := resources.BuildMongosDeployment(mdbsh)
-	return r.createOrUpdate(ctx, mdbsh, deploy)
+	// Deployments or DaemonSet
+	switch resources.MongosMode(mdbsh) {
+	case resources.MongosModeDaemonSet:
+		ds := resources.BuildMongosDaemonSet(mdbsh)
+		if err := r.setKeyfileHashAnnotation(ctx, mdbsh, &ds.Spec.Template); err != nil {
+			return err
+		}
+		if err := r.createOrUpdate(ctx, mdbsh, ds); err != nil {
+			return err
+		}
+	case resources.MongosModePerZone:
+		for _, deploy := range resources.BuildMongosZoneDeployments(mdbsh) {
+			if err := r.setKeyfileHashAnnotation(ctx, mdbsh, &deploy.Spec.Template); err != nil {
+				return err
+			}
+			if err := r.createOrUpdate(ctx, mdbsh, deploy); err != nil {
+				return err
+			}
+		}
+	default:
+		deploy := resources.BuildMongosDeployment(mdbsh)
+		if err := r.setKeyfileHashAnnotation(ctx, mdbsh, &deploy.Spec.Template); err != nil {
+			return err
+		}
+		if resources.MongosAutoScalingEnabled(mdbsh) {
+			// The HPA owns the replica count, keep whatever it last scaled to
+			existing := &appsv1.Deployment{}
+			if err := r.Get(ctx, client.ObjectKeyFromObject(deploy), existing); err == nil {
+				deploy.Spec.Replicas = existing.Spec.Replicas
+			} else if !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		if err := r.createOrUpdate(ctx, mdbsh, deploy); err != nil {
+			return err
+		}
+
+		// The green release serves next to the current one behind the same Service
+		if resources.MongosBlueGreenEnabled(mdbsh) {
+			green := resources.BuildMongosGreenDeployment(mdbsh)
+			if err := r.setKeyfileHashAnnotation(ctx, mdbsh, &green.Spec.Template); err != nil {
+				return err
+			}
+			if err := r.createOrUpdate(ctx, mdbsh, green); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Workloads of a previous mode or a finished blue/green upgrade, once the current ones serve
+	if err := r.pruneMongosWorkloads(ctx, mdbsh); err != nil {
+		return err
+	}
+
+	// HorizontalPodAutoscaler
+	return r.reconcileMongosHPA(ctx, mdbsh)
+}
+
+// pruneMongosWorkloads removes the mongos Deployments and DaemonSet the current mode does not use,
+// left over from a previous mode, removed zones or a removed green release. Nothing is removed before every current workload
+// rolled out, so clients always find a mongos behind the Service.
+func (r *MongoDBShardedReconciler) pruneMongosWorkloads(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	workloads, err := getMongosWorkloads(ctx, r.Client, mdbsh)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, w := range workloads {
+		if !w.updated {
+			return nil
+		}
+	}
+
+	labels := client.MatchingLabels{
+		"app.kubernetes.io/instance":  mdbsh.Name,
+		"app.kubernetes.io/component": "mongos",
+	}
+	var stale []client.Object
+
+	deployments := &appsv1.DeploymentList{}
+	if err := r.List(ctx, deployments, client.InNamespace(mdbsh.Namespace), labels); err != nil {
+		return err
+	}
+	desired := resources.MongosDeploymentNames(mdbsh)
+	for i := range deployments.Items {
+		if !slices.Contains(desired, deployments.Items[i].Name) {
+			stale = append(stale, &deployments.Items[i])
+		}
+	}
+
+	if resources.MongosMode(mdbsh) != resources.MongosModeDaemonSet {
+		daemonSets := &appsv1.DaemonSetList{}
+		if err := r.List(ctx, daemonSets, client.InNamespace(mdbsh.Namespace), labels); err != nil {
+			return err
+		}
+		for i := range daemonSets.Items {
+			stale = append(stale, &daemonSets.Items[i])
+		}
+	}
+
+	for _, obj := range stale {
+		if !metav1.IsControlledBy(obj, mdbsh) {
+			continue
+		}
+		log.FromContext(ctx).Info("Removing unused mongos workload", "name", obj.GetName())
+		if err := r.Delete(ctx, obj); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileMongosHPA creates the mongos HPA when autoscaling is enabled and removes it otherwise
+func (r *MongoDBShardedReconciler) reconcileMongosHPA(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !resources.MongosAutoScalingEnabled(mdbsh) {
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		err := r.Get(ctx, types.NamespacedName{Name: mdbsh.Name + "-mongos", Namespace: mdbsh.Namespace}, hpa)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(hpa, mdbsh) {
+			return nil
+		}
+		return client.IgnoreNotFound(r.Delete(ctx, hpa))
+	}
+
+	hpa, err := resources.BuildMongosHPA(mdbsh)
+	if err != nil {
+		return err
+	}
+	return r.createOrUpdate(ctx, mdbsh, hpa)
+}
+
+// reconcileServiceMonitors creates the ServiceMonitors of the config servers, shards and mongos.
+// Clusters without the Prometheus Operator CRDs are skipped.
+func (r *MongoDBShardedReconciler) reconcileServiceMonitors(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !resources.ServiceMonitorEnabled(mdbsh.Spec.Monitoring) {
+		return nil
+	}
+
+	for _, sm := range resources.BuildShardedServiceMonitors(mdbsh) {
+		err := r.createOrUpdate(ctx, mdbsh, sm)
+		if meta.IsNoMatchError(err) {
+			log.FromContext(ctx).Info("ServiceMonitor CRD not installed, skipping ServiceMonitors")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *MongoDBShardedReconciler) isMongosReady(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) bool {
-	deploy := &appsv1.Deployment{}
-	if err := r.Get(ctx, types.NamespacedName{Name: mdbsh.Name + "-mongos", Namespace: mdbsh.Namespace}, deploy); err != nil {
+	workloads, err := getMongosWorkloads(ctx, r.Client, mdbsh)
+	if err != nil {
 		return false
 	}
-	return deploy.Status.ReadyReplicas >= 1
+	ready, _ := mongosInstances(workloads)
+	return ready >= 1
 }
 
 func (r *MongoDBShardedReconciler) reconcileConfigServerInit(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
@@ -290,10 +720,7 @@ func (r *MongoDBShardedReconciler) reconcileConfigServerInit(ctx context.Context
 	logger.Info("Initializing config server replica set")
 
 	// Config servers use port 27019
-	rsManager, err := mongodb.NewReplicaSetManagerWithPort(27019)
-	if err != nil {
-		return fmt.Errorf("failed to create replica set manager: %w", err)
-	}
+	rsManager := r.replicaSetManager(27019)
 
 	// Check if already initialized
 	firstPod := fmt.Sprintf("%s-cfg-0", mdbsh.Name)
@@ -316,6 +743,7 @@ func (r *MongoDBShardedReconciler) reconcileConfigServerInit(ctx context.Context
 		mdbsh.Name+"-cfg",
 		serviceName,
 		mdbsh.Namespace,
+		resources.ClusterDomain(mdbsh.Spec.ClusterDomain),
 		int(mdbsh.Spec.ConfigServer.Members),
 		27019, // Config servers use port 27019
 	)
@@ -333,40 +761,31 @@ func (r *MongoDBShardedReconciler) reconcileConfigServerInit(ctx context.Context
 func (r *MongoDBShardedReconciler) reconcileShardsInit(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
 	logger := log.FromContext(ctx)
 
-	// Initialize or expand the ShardsInitialized slice if needed (preserve existing values on scale-up)
-	if len(mdbsh.Status.ShardsInitialized) < int(mdbsh.Spec.Shards.Count) {
-		newSlice := make([]bool, mdbsh.Spec.Shards.Count)
-		copy(newSlice, mdbsh.Status.ShardsInitialized)
-		mdbsh.Status.ShardsInitialized = newSlice
-	}
-
 	// Shards use port 27018
-	rsManager, err := mongodb.NewReplicaSetManagerWithPort(27018)
-	if err != nil {
-		return fmt.Errorf("failed to create replica set manager: %w", err)
-	}
-
-	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
-		if mdbsh.Status.ShardsInitialized[i] {
-			continue
+	rsManager := r.replicaSetManager(27018)
+
+	// Shards are initialized as soon as their members are ready, several at a time. Whether a
+	// shard needs rs.initiate() is read from its replica set, only shards registered in the
+	// cluster are known to be initialized.
+	initialized := make([]bool, mdbsh.Spec.Shards.Count)
+	_ = forEachShard(mdbsh.Spec.Shards.Count, func(i int32) error {
+		shardName := resources.ShardName(mdbsh.Name, i)
+		if resources.ShardAdded(&mdbsh.Status, shardName) || !r.isShardReady(ctx, mdbsh, i) {
+			return nil
 		}
 
-		shardName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, i)
 		firstPod := fmt.Sprintf("%s-0", shardName)
 		serviceName := shardName + "-headless"
 
-		logger.Info("Initializing shard replica set", "shard", shardName)
-
 		// Check if already initialized
-		initialized, err := rsManager.IsInitialized(ctx, firstPod, mdbsh.Namespace)
+		isInitialized, err := rsManager.IsInitialized(ctx, firstPod, mdbsh.Namespace)
 		if err != nil {
-			continue // Will retry
+			return nil // Will retry
 		}
 
-		if initialized {
-			logger.Info("Shard replica set already initialized", "shard", shardName)
-			mdbsh.Status.ShardsInitialized[i] = true
-			continue
+		if isInitialized {
+			initialized[i] = true
+			return nil
 		}
 
 		// Build shard replica set configuration
@@ -375,20 +794,28 @@ func (r *MongoDBShardedReconciler) reconcileShardsInit(ctx context.Context, mdbs
 			shardName,
 			serviceName,
 			mdbsh.Namespace,
+			resources.ClusterDomain(mdbsh.Spec.ClusterDomain),
 			int(mdbsh.Spec.Shards.MembersPerShard),
 			27018, // Shards use port 27018
 		)
 
 		// Initialize
+		logger.Info("Initializing shard replica set", "shard", shardName)
 		if err := rsManager.Initiate(ctx, firstPod, mdbsh.Namespace, config); err != nil {
 			logger.Error(err, "Failed to initiate shard replica set", "shard", shardName)
-			continue // Will retry
+			return nil // Will retry
 		}
 
 		logger.Info("Shard replica set initialized successfully", "shard", shardName)
-		mdbsh.Status.ShardsInitialized[i] = true
-	}
+		initialized[i] = true
+		return nil
+	})
 
+	for i, ok := range initialized {
+		if ok {
+			resources.SetShardInitialized(&mdbsh.Status, resources.ShardName(mdbsh.Name, int32(i)))
+		}
+	}
 	return r.Status().Update(ctx, mdbsh)
 }
 
@@ -396,119 +823,757 @@ func (r *MongoDBShardedReconciler) reconcileShardedAdminUser(ctx context.Context
 	logger := log.FromContext(ctx)
 	logger.Info("Creating admin user via mongos")
 
-	// Get admin password from secret
-	adminPassword, err := r.getAdminPassword(ctx, mdbsh)
+	// Get admin credentials from secret
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
 	if err != nil {
-		return fmt.Errorf("failed to get admin password: %w", err)
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	// The bootstrap Job creates the user on the config servers, it only exists once the Job completed
+	if resources.JobAuthBootstrap(mdbsh.Spec.Auth) {
+		done, err := runAuthBootstrapJob(ctx, r.Client, r.Scheme, mdbsh, resources.BuildShardedAuthBootstrapJob(mdbsh))
+		if err != nil || !done {
+			return err
+		}
+		logger.Info("Admin user created by the bootstrap Job")
+		mdbsh.Status.AdminUserCreated = true
+		mdbsh.Status.AdminPasswordHash = hashPassword(mdbsh.UID, creds.Password)
+		return r.Status().Update(ctx, mdbsh)
 	}
 
 	// Get mongos pod name
-	mongosPod, err := r.getMongosPodName(ctx, mdbsh)
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get mongos pod: %w", err)
 	}
 
 	// Create auth manager
-	authManager, err := mongodb.NewAuthManager()
-	if err != nil {
-		return fmt.Errorf("failed to create auth manager: %w", err)
-	}
+	authManager := r.authManager()
 
 	// Check if admin user already exists
 	// Mongos container name is "mongos", port is 27017
-	exists, _ := authManager.UserExistsInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", "admin", "admin", 27017)
+	exists, _ := authManager.UserExistsInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, "admin", 27017)
 	if exists {
 		logger.Info("Admin user already exists")
 		mdbsh.Status.AdminUserCreated = true
+		mdbsh.Status.AdminPasswordHash = hashPassword(mdbsh.UID, creds.Password)
 		return r.Status().Update(ctx, mdbsh)
 	}
 
 	// Create admin user via mongos (container "mongos", port 27017)
-	if err := authManager.CreateAdminUserInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", "admin", adminPassword, 27017); err != nil {
+	if err := authManager.CreateAdminUserInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017); err != nil {
 		return fmt.Errorf("failed to create admin user: %w", err)
 	}
 
 	logger.Info("Admin user created successfully")
 	mdbsh.Status.AdminUserCreated = true
+	mdbsh.Status.AdminPasswordHash = hashPassword(mdbsh.UID, creds.Password)
 	return r.Status().Update(ctx, mdbsh)
 }
 
-func (r *MongoDBShardedReconciler) reconcileAddShards(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+func (r *MongoDBShardedReconciler) reconcileAdminPassword(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
 	logger := log.FromContext(ctx)
 
-	// Initialize or expand the ShardsAdded slice if needed (preserve existing values on scale-up)
-	if len(mdbsh.Status.ShardsAdded) < int(mdbsh.Spec.Shards.Count) {
-		newSlice := make([]bool, mdbsh.Spec.Shards.Count)
-		copy(newSlice, mdbsh.Status.ShardsAdded)
-		mdbsh.Status.ShardsAdded = newSlice
+	if !mdbsh.Status.AdminUserCreated {
+		return nil
+	}
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
 	}
 
-	// All shards must be initialized first
-	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
-		if !mdbsh.Status.ShardsInitialized[i] {
-			return nil // Wait for all shards to be initialized
-		}
+	hash := hashPassword(mdbsh.UID, creds.Password)
+	if mdbsh.Status.AdminPasswordHash == hash {
+		return nil
 	}
 
-	shardManager, err := mongodb.NewShardManager()
-	if err != nil {
-		return fmt.Errorf("failed to create shard manager: %w", err)
+	// Clusters created before rotation support have no recorded hash: adopt the current password
+	if mdbsh.Status.AdminPasswordHash == "" {
+		mdbsh.Status.AdminPasswordHash = hash
+		return r.Status().Update(ctx, mdbsh)
 	}
 
-	// Get admin password for authentication
-	adminPassword, err := r.getAdminPassword(ctx, mdbsh)
+	logger.Info("Admin credentials secret changed, rotating admin password")
+
+	// The previous password is gone, so authenticate with the keyfile as the internal user
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
 	if err != nil {
-		return fmt.Errorf("failed to get admin password: %w", err)
+		return err
 	}
 
-	// Get mongos pod name
-	mongosPod, err := r.getMongosPodName(ctx, mdbsh)
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
 	if err != nil {
 		return fmt.Errorf("failed to get mongos pod: %w", err)
 	}
 
-	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
-		if mdbsh.Status.ShardsAdded[i] {
-			continue
-		}
-
-		shardName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, i)
-		serviceName := shardName + "-headless"
+	authManager := r.authManager()
 
-		logger.Info("Adding shard to cluster", "shard", shardName)
+	// Cluster users live on the config servers, mongos forwards the change there
+	if err := authManager.UpdatePasswordWithKeyfile(ctx, mongosPod, mdbsh.Namespace, "mongos", keyfile, creds.Username, "admin", creds.Password, 27017); err != nil {
+		return fmt.Errorf("failed to rotate admin password: %w", err)
+	}
 
-		// Build shard connection string (shards run on port 27018)
-		shardConnString := mongodb.BuildShardConnectionString(
-			shardName,
-			shardName,
-			serviceName,
-			mdbsh.Namespace,
-			int(mdbsh.Spec.Shards.MembersPerShard),
-			27018, // Shards use port 27018
-		)
+	logger.Info("Admin password rotated successfully")
+	mdbsh.Status.AdminPasswordHash = hash
+	setPasswordRotatedCondition(&mdbsh.Status.Conditions, mdbsh.Generation)
+	return r.Status().Update(ctx, mdbsh)
+}
 
-		// Add shard via mongos with authentication (container "mongos", port 27017)
-		if err := shardManager.AddShardWithAuthInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", "admin", adminPassword, shardConnString, 27017); err != nil {
-			logger.Error(err, "Failed to add shard", "shard", shardName)
-			continue // Will retry
-		}
+// reconcileInitScripts runs the init scripts that did not run yet through a mongos
+func (r *MongoDBShardedReconciler) reconcileInitScripts(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if resources.InitScriptsDone(mdbsh.Spec.InitScripts, mdbsh.Status.InitScripts) {
+		return nil
+	}
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return err
+	}
+	return r.applyInitScripts(ctx, r.Client, mdbsh, mdbsh.Spec.Auth, mdbsh.Spec.InitScripts, &mdbsh.Status.InitScripts,
+		initScriptTarget{pod: mongosPod, container: "mongos", port: 27017})
+}
+
+// reconcileMonitoringUser creates the exporter user and keeps its password in sync with the credentials secret.
+// Users created through mongos live on the config servers, so shards need their own shard-local user
+// for the exporters that connect to them directly.
+func (r *MongoDBShardedReconciler) reconcileMonitoringUser(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !resources.MonitoringEnabled(mdbsh.Spec.Monitoring) || !mdbsh.Status.AdminUserCreated {
+		return nil
+	}
+
+	user, err := getMonitoringUser(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return err
+	}
+
+	// The shard count is part of the hash so shards added later get their shard-local user too
+	hash := hashPassword(mdbsh.UID, fmt.Sprintf("%s:%d", user.Password, mdbsh.Spec.Shards.Count))
+	if mdbsh.Status.MonitoringPasswordHash == hash {
+		return nil
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	authManager := r.authManager()
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+	if err := authManager.UpsertUserWithKeyfile(ctx, mongosPod, mdbsh.Namespace, "mongos", keyfile, user, 27017); err != nil {
+		return fmt.Errorf("failed to apply monitoring user: %w", err)
+	}
+
+	// Shards use port 27018
+	rsManager := r.replicaSetManager(27018)
+
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		shardName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, i)
+		primaryPod, err := rsManager.GetPrimaryPod(ctx, shardName+"-0", mdbsh.Namespace)
+		if err != nil {
+			return fmt.Errorf("failed to get primary of shard %s: %w", shardName, err)
+		}
+		if err := authManager.UpsertUserWithKeyfile(ctx, primaryPod, mdbsh.Namespace, "mongodb", keyfile, user, 27018); err != nil {
+			return fmt.Errorf("failed to apply monitoring user on shard %s: %w", shardName, err)
+		}
+	}
+
+	log.FromContext(ctx).Info("Monitoring user applied", "user", user.Username)
+	mdbsh.Status.MonitoringPasswordHash = hash
+	return r.Status().Update(ctx, mdbsh)
+}
+
+func (r *MongoDBShardedReconciler) reconcileAddShards(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	logger := log.FromContext(ctx)
+
+	shardManager := r.shardManager()
+
+	// Get admin credentials for authentication
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	// Get mongos pod name
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+
+	// The shards registered in the cluster are read from listShards, including the shards above
+	// shards.count that are still being removed
+	shards, err := shardManager.ListShardsInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017)
+	if err != nil {
+		return err
+	}
+	registered := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		if _, ok := resources.ShardIndex(mdbsh.Name, shard.ID); ok {
+			registered[shard.ID] = true
+			resources.SetShardAdded(&mdbsh.Status, shard.ID, true)
+		}
+	}
+	for shardName := range mdbsh.Status.ShardProvisioning {
+		if !registered[shardName] && resources.ShardAdded(&mdbsh.Status, shardName) {
+			logger.Info("Shard is no longer registered in the cluster", "shard", shardName)
+			resources.SetShardAdded(&mdbsh.Status, shardName, false)
+		}
+	}
+
+	// Shards are added as soon as they are initialized, several at a time
+	added := make([]bool, mdbsh.Spec.Shards.Count)
+	_ = forEachShard(mdbsh.Spec.Shards.Count, func(i int32) error {
+		shardName := resources.ShardName(mdbsh.Name, i)
+		if registered[shardName] || !resources.ShardInitialized(&mdbsh.Status, shardName) {
+			return nil
+		}
+
+		logger.Info("Adding shard to cluster", "shard", shardName)
+
+		shardConnString := shardConnectionString(mdbsh, i)
+
+		// Add shard via mongos with authentication (container "mongos", port 27017)
+		if err := shardManager.AddShardWithAuthInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, shardConnString, 27017); err != nil {
+			logger.Error(err, "Failed to add shard", "shard", shardName)
+			return nil // Will retry
+		}
 
 		logger.Info("Shard added successfully", "shard", shardName)
-		mdbsh.Status.ShardsAdded[i] = true
+		added[i] = true
+		return nil
+	})
+
+	for i, ok := range added {
+		if ok {
+			resources.SetShardAdded(&mdbsh.Status, resources.ShardName(mdbsh.Name, int32(i)), true)
+		}
+	}
+	if slices.Contains(added, true) {
+		r.refreshRouters(ctx, mdbsh, shardManager, creds, "shards added")
 	}
+	return r.Status().Update(ctx, mdbsh)
+}
+
+// shardConnectionString returns the connection string a shard is registered with,
+// shards run on port 27018
+func shardConnectionString(mdbsh *mongodbv1alpha1.MongoDBSharded, index int32) string {
+	shardName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, index)
+	return mongodb.BuildShardConnectionString(
+		shardName,
+		shardName,
+		shardName+"-headless",
+		mdbsh.Namespace,
+		resources.ClusterDomain(mdbsh.Spec.ClusterDomain),
+		int(mdbsh.Spec.Shards.MembersPerShard),
+		27018,
+	)
+}
+
+// reconcileShardRemoval drains the shards above spec.shards.count one at a time, highest index first.
+// A shard is removed from the cluster with removeShard, its databases are moved to the first shard,
+// and only then are its StatefulSet, Service and (per the retention policy) volumes deleted.
+func (r *MongoDBShardedReconciler) reconcileShardRemoval(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	logger := log.FromContext(ctx)
 
+	// Draining cannot be cancelled, the shard must be gone before it can be added again
+	for _, removal := range mdbsh.Status.RemovingShards {
+		for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+			if removal.Name == fmt.Sprintf("%s-shard-%d", mdbsh.Name, i) {
+				return fmt.Errorf("shard %s is being removed, wait for the removal to complete before increasing shards.count", removal.Name)
+			}
+		}
+	}
+
+	removed := resources.RemovedShards(mdbsh.Name, &mdbsh.Status, mdbsh.Spec.Shards.Count)
+	if len(removed) == 0 {
+		return nil
+	}
+
+	i := removed[0]
+	shardName := resources.ShardName(mdbsh.Name, i)
+
+	if resources.ShardAdded(&mdbsh.Status, shardName) {
+		completed, err := r.drainShard(ctx, mdbsh, shardName)
+		if err != nil {
+			return err
+		}
+		if !completed {
+			return r.Status().Update(ctx, mdbsh)
+		}
+	}
+
+	logger.Info("Deleting removed shard", "shard", shardName)
+	if err := r.deleteShardResources(ctx, mdbsh, i); err != nil {
+		return err
+	}
+
+	mdbsh.Status.RemovingShards = slices.DeleteFunc(mdbsh.Status.RemovingShards, func(s mongodbv1alpha1.ShardRemovalStatus) bool {
+		return s.Name == shardName
+	})
+	delete(mdbsh.Status.ShardProvisioning, shardName)
 	return r.Status().Update(ctx, mdbsh)
 }
 
-func (r *MongoDBShardedReconciler) getMongosPodName(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) (string, error) {
+// drainShard runs removeShard and moves the databases whose primary is the shard.
+// It reports whether MongoDB finished removing the shard.
+func (r *MongoDBShardedReconciler) drainShard(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardName string) (bool, error) {
+	logger := log.FromContext(ctx)
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return false, fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return false, fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+
+	shardManager := r.shardManager()
+
+	res, err := shardManager.RemoveShardInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, shardName, 27017)
+	if err != nil {
+		return false, err
+	}
+
+	logger.Info("Removing shard", "shard", shardName, "state", res.State, "remainingChunks", res.RemainingChunks)
+	if res.State == "completed" {
+		r.refreshRouters(ctx, mdbsh, shardManager, creds, "shard removed")
+		return true, nil
+	}
+
+	removal := findShardRemoval(mdbsh, shardName)
+	removal.State = res.State
+	removal.RemainingChunks = res.RemainingChunks
+	removal.JumboChunks = res.JumboChunks
+	removal.DatabasesToMove = res.DBsToMove
+
+	// Databases can only move once their sharded chunks are gone, removeShard alone leaves their
+	// unsharded collections on the shard
+	if res.RemainingChunks == 0 {
+		resources.PlanPrimaryMoves(removal, res.DBsToMove, mdbsh.Name, mdbsh.Spec.Shards.Count)
+		if err := r.movePrimaries(ctx, mdbsh, removal, shardManager, mongosPod, creds); err != nil {
+			return false, err
+		}
+	}
+
+	return false, nil
+}
+
+// movePrimaries runs the pending movePrimary of the databases on a shard being removed, one
+// database at a time. A failed move is recorded and retried on the next reconcile, the others
+// still run.
+func (r *MongoDBShardedReconciler) movePrimaries(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, removal *mongodbv1alpha1.ShardRemovalStatus, shardManager mongodb.ShardManager, mongosPod string, creds *adminCredentials) error {
+	logger := log.FromContext(ctx)
+
+	var databases []string
+	for _, move := range resources.PendingPrimaryMoves(removal) {
+		databases = append(databases, move.Database)
+	}
+
+	shardName := removal.Name
+	moved := false
+	for _, db := range databases {
+		// movePrimary copies the unsharded collections and can run for a while, report it first
+		move := findPrimaryMove(findShardRemoval(mdbsh, shardName), db)
+		move.Phase = resources.PrimaryMoving
+		move.Message = ""
+		if err := r.Status().Update(ctx, mdbsh); err != nil {
+			return err
+		}
+		// The update replaced the status, find the move again
+		move = findPrimaryMove(findShardRemoval(mdbsh, shardName), db)
+
+		logger.Info("Moving primary shard of database", "database", db, "from", shardName, "to", move.ToShard)
+		err := shardManager.MovePrimaryInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, db, move.ToShard, 27017)
+		if err != nil {
+			logger.Error(err, "Failed to move primary shard of database", "database", db)
+		}
+		resources.SetPrimaryMoveResult(move, err)
+		moved = moved || err == nil
+	}
+
+	if moved {
+		r.refreshRouters(ctx, mdbsh, shardManager, creds, "primary shards moved")
+	}
+	return nil
+}
+
+// findPrimaryMove returns the move of a database off a shard being removed
+func findPrimaryMove(removal *mongodbv1alpha1.ShardRemovalStatus, database string) *mongodbv1alpha1.PrimaryMoveStatus {
+	for i := range removal.PrimaryMoves {
+		if removal.PrimaryMoves[i].Database == database {
+			return &removal.PrimaryMoves[i]
+		}
+	}
+	return nil
+}
+
+// findShardRemoval returns the removal status of a shard, adding it when the removal just started
+func findShardRemoval(mdbsh *mongodbv1alpha1.MongoDBSharded, shardName string) *mongodbv1alpha1.ShardRemovalStatus {
+	for i := range mdbsh.Status.RemovingShards {
+		if mdbsh.Status.RemovingShards[i].Name == shardName {
+			return &mdbsh.Status.RemovingShards[i]
+		}
+	}
+
+	now := metav1.Now()
+	mdbsh.Status.RemovingShards = append(mdbsh.Status.RemovingShards, mongodbv1alpha1.ShardRemovalStatus{
+		Name:      shardName,
+		StartTime: &now,
+	})
+	return &mdbsh.Status.RemovingShards[len(mdbsh.Status.RemovingShards)-1]
+}
+
+// deleteShardResources deletes the StatefulSet, ConfigMap, Service and ServiceMonitor of a removed shard,
+// and its volumes when the retention policy is Delete
+func (r *MongoDBShardedReconciler) deleteShardResources(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32) error {
+	shardName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, shardIndex)
+
+	sts := &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: shardName, Namespace: mdbsh.Namespace}}
+	if err := client.IgnoreNotFound(r.Delete(ctx, sts)); err != nil {
+		return fmt.Errorf("failed to delete shard StatefulSet: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: resources.ConfigMapName(shardName), Namespace: mdbsh.Namespace}}
+	if err := client.IgnoreNotFound(r.Delete(ctx, cm)); err != nil {
+		return fmt.Errorf("failed to delete shard ConfigMap: %w", err)
+	}
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: shardName + "-headless", Namespace: mdbsh.Namespace}}
+	if err := client.IgnoreNotFound(r.Delete(ctx, svc)); err != nil {
+		return fmt.Errorf("failed to delete shard Service: %w", err)
+	}
+
+	if resources.ServiceMonitorEnabled(mdbsh.Spec.Monitoring) {
+		sm := resources.BuildShardServiceMonitor(mdbsh, shardIndex)
+		if err := r.Delete(ctx, sm); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return fmt.Errorf("failed to delete shard ServiceMonitor: %w", err)
+		}
+	}
+
+	if mdbsh.Spec.Shards.PersistentVolumeClaimRetentionPolicy != "Delete" {
+		return nil
+	}
+
+	// StatefulSet volume claims carry the selector labels of their StatefulSet
+	pvcs := &corev1.PersistentVolumeClaimList{}
+	if err := r.List(ctx, pvcs, client.InNamespace(mdbsh.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/instance":  mdbsh.Name,
+		"app.kubernetes.io/component": fmt.Sprintf("shard-%d", shardIndex),
+	}); err != nil {
+		return fmt.Errorf("failed to list shard volumes: %w", err)
+	}
+	for i := range pvcs.Items {
+		if err := client.IgnoreNotFound(r.Delete(ctx, &pvcs.Items[i])); err != nil {
+			return fmt.Errorf("failed to delete shard volume %s: %w", pvcs.Items[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// reconcileBalancer applies spec.balancer and records the balancer state in status.
+// The state is persisted by updateStatus at the end of the reconcile.
+func (r *MongoDBShardedReconciler) reconcileBalancer(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	logger := log.FromContext(ctx)
+
+	if !mdbsh.Status.AdminUserCreated {
+		return nil
+	}
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+
+	shardManager := r.shardManager()
+
+	state, err := shardManager.GetBalancerStateInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017)
+	if err != nil {
+		return err
+	}
+
+	if spec := mdbsh.Spec.Balancer; spec != nil {
+		if state.Enabled() != spec.Enabled {
+			logger.Info("Changing balancer state", "enabled", spec.Enabled)
+			if err := shardManager.SetBalancerEnabledInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, spec.Enabled, 27017); err != nil {
+				return err
+			}
+		}
+
+		var window *mongodb.BalancerWindow
+		if spec.ActiveWindow != nil {
+			window = &mongodb.BalancerWindow{Start: spec.ActiveWindow.Start, Stop: spec.ActiveWindow.Stop}
+		}
+		if !equalBalancerWindow(state.ActiveWindow, window) {
+			logger.Info("Changing balancer window", "window", spec.ActiveWindow)
+			if err := shardManager.SetBalancerWindowInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, window, 27017); err != nil {
+				return err
+			}
+		}
+
+		if state, err = shardManager.GetBalancerStateInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017); err != nil {
+			return err
+		}
+	}
+
+	status := &mongodbv1alpha1.BalancerStatus{
+		Mode:             state.Mode,
+		InBalancerRound:  state.InBalancerRound,
+		FailedMigrations: state.FailedMigrations,
+		JumboChunks:      state.JumboChunks,
+	}
+	if state.ActiveWindow != nil {
+		status.ActiveWindow = &mongodbv1alpha1.BalancerWindow{Start: state.ActiveWindow.Start, Stop: state.ActiveWindow.Stop}
+	}
+	for _, m := range state.Migrations {
+		status.Migrations = append(status.Migrations, mongodbv1alpha1.ChunkMigration{
+			Namespace: m.Namespace,
+			FromShard: m.FromShard,
+			ToShard:   m.ToShard,
+		})
+	}
+	mdbsh.Status.Balancer = status
+	recordBalancerMetrics(mdbsh, status)
+	return nil
+}
+
+// reconcileShardZones tags shards with their zone and assigns the zone key ranges.
+// Assignments and ranges removed from the spec are removed from the cluster.
+// The applied state is persisted by updateStatus at the end of the reconcile.
+func (r *MongoDBShardedReconciler) reconcileShardZones(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	logger := log.FromContext(ctx)
+
+	if !mdbsh.Status.AdminUserCreated || (len(mdbsh.Spec.Shards.Zones) == 0 && len(mdbsh.Status.Zones) == 0) {
+		return nil
+	}
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+
+	shardManager := r.shardManager()
+
+	desired := make([]mongodbv1alpha1.AppliedShardZone, 0, len(mdbsh.Spec.Shards.Zones))
+	for _, zone := range mdbsh.Spec.Shards.Zones {
+		applied := mongodbv1alpha1.AppliedShardZone{Name: zone.Name, Ranges: zone.Ranges}
+		for _, i := range zone.Shards {
+			applied.Shards = append(applied.Shards, fmt.Sprintf("%s-shard-%d", mdbsh.Name, i))
+		}
+		desired = append(desired, applied)
+	}
+
+	// Ranges go first: a shard cannot leave a zone that still has ranges and no other shard
+	for _, old := range mdbsh.Status.Zones {
+		for _, rng := range old.Ranges {
+			if zoneHasRange(desired, old.Name, rng) {
+				continue
+			}
+			logger.Info("Removing zone key range", "zone", old.Name, "namespace", rng.Namespace)
+			if err := shardManager.UpdateZoneKeyRangeInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos",
+				creds.Username, creds.Password, rng.Namespace, rng.Min, rng.Max, "", 27017); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, zone := range desired {
+		for _, shard := range zone.Shards {
+			if err := shardManager.AddShardToZoneInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos",
+				creds.Username, creds.Password, shard, zone.Name, 27017); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, old := range mdbsh.Status.Zones {
+		for _, shard := range old.Shards {
+			if zoneHasShard(desired, old.Name, shard) {
+				continue
+			}
+			logger.Info("Removing shard from zone", "zone", old.Name, "shard", shard)
+			if err := shardManager.RemoveShardFromZoneInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos",
+				creds.Username, creds.Password, shard, old.Name, 27017); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, zone := range desired {
+		for _, rng := range zone.Ranges {
+			if err := shardManager.UpdateZoneKeyRangeInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos",
+				creds.Username, creds.Password, rng.Namespace, rng.Min, rng.Max, zone.Name, 27017); err != nil {
+				return err
+			}
+		}
+	}
+
+	mdbsh.Status.Zones = desired
+	if len(desired) == 0 {
+		mdbsh.Status.Zones = nil
+	}
+	return nil
+}
+
+func zoneHasShard(zones []mongodbv1alpha1.AppliedShardZone, zone, shard string) bool {
+	for _, z := range zones {
+		if z.Name == zone && slices.Contains(z.Shards, shard) {
+			return true
+		}
+	}
+	return false
+}
+
+func zoneHasRange(zones []mongodbv1alpha1.AppliedShardZone, zone string, rng mongodbv1alpha1.ZoneKeyRange) bool {
+	for _, z := range zones {
+		if z.Name == zone && slices.Contains(z.Ranges, rng) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalBalancerWindow(a, b *mongodb.BalancerWindow) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func (r *MongoDBShardedReconciler) reconcileConnectionSecret(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !mdbsh.Status.AdminUserCreated {
+		return nil
+	}
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	// Mongos pods come and go with the Deployment or DaemonSet status, which triggers a reconcile
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(mdbsh.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/instance":  mdbsh.Name,
+		"app.kubernetes.io/component": "mongos",
+	}); err != nil {
+		return fmt.Errorf("failed to list mongos pods: %w", err)
+	}
+
+	svc := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: mdbsh.Name + "-mongos", Namespace: mdbsh.Namespace}, svc); err != nil {
+		return fmt.Errorf("failed to get mongos service: %w", err)
+	}
+
+	// Applications always go through the mongos Service
+	info := resources.ConnectionInfo{
+		Host:          resources.ServiceFQDN(svc.Name, mdbsh.Namespace, mdbsh.Spec.ClusterDomain),
+		Hosts:         []string{resources.MongosServiceHost(mdbsh)},
+		SRVHost:       resources.ServiceFQDN(svc.Name, mdbsh.Namespace, mdbsh.Spec.ClusterDomain),
+		Endpoints:     resources.MongosEndpoints(pods.Items, 27017),
+		ExternalHosts: resources.MongosExternalHosts(svc, pods.Items),
+		Port:          int(resources.MongosServicePort(mdbsh)),
+		TLS:           resources.TLSEnabled(mdbsh.Spec.TLS),
+		CACert:        getCACert(ctx, r.Client, mdbsh.Namespace, mdbsh.Spec.TLS),
+		Admin:         resources.UserCredentials{Username: creds.Username, Password: creds.Password, Database: "admin"},
+		Users:         getUserCredentials(ctx, r.Client, mdbsh.Namespace, mdbsh.Spec.Auth.Users),
+	}
+
+	secret := resources.BuildConnectionSecret(mdbsh.Name, mdbsh.Namespace, info)
+	if err := r.createOrUpdate(ctx, mdbsh, secret); err != nil {
+		return err
+	}
+
+	for _, secret := range resources.BuildUserConnectionSecrets(mdbsh.Name, mdbsh.Namespace, info, mdbsh.Spec.Auth.Users) {
+		if err := r.createOrUpdate(ctx, mdbsh, secret); err != nil {
+			return err
+		}
+	}
+	return deleteStaleUserConnectionSecrets(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth.Users)
+}
+
+// setKeyfileHashAnnotation stamps the keyfile hash on a pod template so keyfile rotations roll every component
+func (r *MongoDBShardedReconciler) setKeyfileHashAnnotation(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, template *corev1.PodTemplateSpec) error {
+	keyfileHash, err := keyfileTemplateHash(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth, mdbsh.Status.KeyfileRotation)
+	if err != nil {
+		return err
+	}
+	if keyfileHash != "" {
+		resources.SetKeyfileHashAnnotation(template, keyfileHash)
+	}
+	return nil
+}
+
+func (r *MongoDBShardedReconciler) reconcileKeyfileRotation(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	keyfileHash, err := keyfileTemplateHash(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth, mdbsh.Status.KeyfileRotation)
+	if err != nil {
+		return err
+	}
+
+	rotation, err := advanceKeyfileRotation(ctx, r.Client, mdbsh, mdbsh.Spec.Auth, mdbsh.Status.KeyfileRotation, r.isRolledOut(ctx, mdbsh, keyfileHash))
+	if err != nil || rotation == nil {
+		return err
+	}
+
+	mdbsh.Status.KeyfileRotation = rotation
+	if rotation.Phase == resources.KeyfileRotationCompleted {
+		setKeyfileRotatedCondition(&mdbsh.Status.Conditions, mdbsh.Generation)
+	}
+	return r.Status().Update(ctx, mdbsh)
+}
+
+// isRolledOut reports whether config servers, shards and mongos all run pods with the given keyfile hash
+func (r *MongoDBShardedReconciler) isRolledOut(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, keyfileHash string) bool {
+	stsNames := []string{mdbsh.Name + "-cfg"}
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		stsNames = append(stsNames, fmt.Sprintf("%s-shard-%d", mdbsh.Name, i))
+	}
+
+	for _, name := range stsNames {
+		sts := &appsv1.StatefulSet{}
+		if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: mdbsh.Namespace}, sts); err != nil {
+			return false
+		}
+		if !statefulSetRolledOut(sts, keyfileHash) {
+			return false
+		}
+	}
+
+	workloads, err := getMongosWorkloads(ctx, r.Client, mdbsh)
+	if err != nil {
+		return false
+	}
+	for _, w := range workloads {
+		if w.template.Annotations[resources.KeyfileHashAnnotation] != keyfileHash || !w.updated {
+			return false
+		}
+	}
+	return true
+}
+
+// getMongosPodName returns a running mongos pod of a sharded cluster
+func getMongosPodName(ctx context.Context, c client.Client, clusterName, namespace string) (string, error) {
 	// List mongos pods
 	podList := &corev1.PodList{}
 	labels := map[string]string{
-		"app.kubernetes.io/instance":  mdbsh.Name,
+		"app.kubernetes.io/instance":  clusterName,
 		"app.kubernetes.io/component": "mongos",
 	}
 
-	if err := r.List(ctx, podList, client.InNamespace(mdbsh.Namespace), client.MatchingLabels(labels)); err != nil {
+	if err := c.List(ctx, podList, client.InNamespace(namespace), client.MatchingLabels(labels)); err != nil {
 		return "", err
 	}
 
@@ -521,25 +1586,97 @@ func (r *MongoDBShardedReconciler) getMongosPodName(ctx context.Context, mdbsh *
 	return "", fmt.Errorf("no running mongos pod found")
 }
 
-func (r *MongoDBShardedReconciler) getAdminPassword(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) (string, error) {
-	secret := &corev1.Secret{}
-	secretName := mdbsh.Spec.Auth.AdminCredentialsSecretRef.Name
-	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: mdbsh.Namespace}, secret); err != nil {
-		return "", fmt.Errorf("failed to get admin credentials secret: %w", err)
+// mongosWorkload is a Deployment or DaemonSet running mongos instances
+type mongosWorkload struct {
+	template *corev1.PodTemplateSpec
+
+	// updated reports whether every instance runs the current template and is available
+	updated bool
+
+	ready   int32
+	desired int32
+}
+
+// getMongosWorkloads returns the mongos workloads of the current mode of a sharded cluster,
+// failing with a NotFound error when one does not exist yet
+func getMongosWorkloads(ctx context.Context, c client.Client, mdbsh *mongodbv1alpha1.MongoDBSharded) ([]mongosWorkload, error) {
+	if resources.MongosMode(mdbsh) == resources.MongosModeDaemonSet {
+		ds := &appsv1.DaemonSet{}
+		if err := c.Get(ctx, types.NamespacedName{Name: mdbsh.Name + "-mongos", Namespace: mdbsh.Namespace}, ds); err != nil {
+			return nil, err
+		}
+		return []mongosWorkload{{
+			template: &ds.Spec.Template,
+			updated:  daemonSetUpdated(ds),
+			ready:    ds.Status.NumberReady,
+			desired:  ds.Status.DesiredNumberScheduled,
+		}}, nil
 	}
 
-	password, ok := secret.Data["password"]
-	if !ok {
-		return "", fmt.Errorf("password key not found in secret %s", secretName)
+	var workloads []mongosWorkload
+	for _, name := range resources.MongosDeploymentNames(mdbsh) {
+		deploy := &appsv1.Deployment{}
+		if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: mdbsh.Namespace}, deploy); err != nil {
+			return nil, err
+		}
+		var desired int32
+		if deploy.Spec.Replicas != nil {
+			desired = *deploy.Spec.Replicas
+		}
+		workloads = append(workloads, mongosWorkload{
+			template: &deploy.Spec.Template,
+			updated:  deploymentUpdated(deploy),
+			ready:    deploy.Status.ReadyReplicas,
+			desired:  desired,
+		})
+	}
+	return workloads, nil
+}
+
+// mongosGreenStatus returns the instances of the green mongos Deployment, nil without a blue/green
+// upgrade
+func (r *MongoDBShardedReconciler) mongosGreenStatus(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) *mongodbv1alpha1.ComponentStatus {
+	if !resources.MongosBlueGreenEnabled(mdbsh) {
+		return nil
+	}
+	deploy := &appsv1.Deployment{}
+	key := types.NamespacedName{Name: resources.MongosGreenDeploymentName(mdbsh.Name), Namespace: mdbsh.Namespace}
+	if err := r.Get(ctx, key, deploy); err != nil {
+		return nil
+	}
+	desired := mdbsh.Spec.Mongos.BlueGreen.Replicas
+	return &mongodbv1alpha1.ComponentStatus{
+		Ready: deploy.Status.ReadyReplicas,
+		Total: desired,
+		Phase: r.getComponentPhase(deploy.Status.ReadyReplicas, desired),
 	}
+}
 
-	return string(password), nil
+// mongosInstances returns the ready and desired mongos instances of all workloads
+func mongosInstances(workloads []mongosWorkload) (ready, desired int32) {
+	for _, w := range workloads {
+		ready += w.ready
+		desired += w.desired
+	}
+	return ready, desired
 }
 
+// createOrUpdate writes an owned object and records the out-of-band change it reverted in the
+// status
 func (r *MongoDBShardedReconciler) createOrUpdate(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, obj client.Object) error {
-	// Set owner reference
-	if err := controllerutil.SetControllerReference(mdbsh, obj, r.Scheme); err != nil {
-		return err
+	drift, err := r.applyObject(ctx, mdbsh, obj, mdbsh.Status.Drift)
+	mdbsh.Status.Drift = drift
+	return err
+}
+
+// applyObject creates or updates an owned object. An out-of-band change the update reverts is
+// added to drift, which is returned; mdbsh is only read, so shards can be applied concurrently.
+func (r *MongoDBShardedReconciler) applyObject(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, obj client.Object, drift []mongodbv1alpha1.DriftStatus) ([]mongodbv1alpha1.DriftStatus, error) {
+	// Set owner reference, owner references cannot cross namespaces
+	if obj.GetNamespace() == mdbsh.Namespace {
+		if err := controllerutil.SetControllerReference(mdbsh, obj, r.Scheme); err != nil {
+			return drift, err
+		}
 	}
 
 	// Check if object exists
@@ -549,14 +1686,95 @@ func (r *MongoDBShardedReconciler) createOrUpdate(ctx context.Context, mdbsh *mo
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Create the object
-			return r.Create(ctx, obj)
+			resources.SetDesiredState(obj)
+			return drift, r.Create(ctx, obj)
 		}
-		return err
+		return drift, err
 	}
 
+	// Volume claim templates are immutable, keep the ones the StatefulSet was created with
+	// so a new default StorageClass only applies to new clusters
+	if sts, ok := obj.(*appsv1.StatefulSet); ok {
+		sts.Spec.VolumeClaimTemplates = existing.(*appsv1.StatefulSet).Spec.VolumeClaimTemplates
+	}
+
+	// Keep the allocated and externally managed Service fields, e.g. node ports and the
+	// annotations of cloud controllers
+	if svc, ok := obj.(*corev1.Service); ok {
+		resources.PreserveServiceFields(svc, existing.(*corev1.Service))
+	}
+
+	// Changes made by others since the last update are reverted by it, report them
+	drift = checkDrift(ctx, obj, existing, drift)
+
 	// Update the object
 	obj.SetResourceVersion(existing.GetResourceVersion())
-	return r.Update(ctx, obj)
+	return drift, r.Update(ctx, obj)
+}
+
+// reconcileStorageUsage collects the disk usage of the config server and shard members.
+// mongod listens on 27019 on config servers and 27018 on shards.
+func (r *MongoDBShardedReconciler) reconcileStorageUsage(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	usage, err := r.collectStorageUsage(ctx, mdbsh.Name+"-cfg", mdbsh.Namespace, keyfileCredentials(keyfile), 0, mdbsh.Spec.ConfigServer.Members, 27019)
+	if err != nil {
+		return err
+	}
+
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		shardUsage, err := r.collectStorageUsage(ctx, fmt.Sprintf("%s-shard-%d", mdbsh.Name, i), mdbsh.Namespace, keyfileCredentials(keyfile), 0, mdbsh.Spec.Shards.MembersPerShard, 27018)
+		if err != nil {
+			return err
+		}
+		usage = append(usage, shardUsage...)
+	}
+
+	mdbsh.Status.Storage = usage
+	logMembersLowOnStorage(ctx, r.membersLowOnStorage(mdbsh))
+	return nil
+}
+
+// reconcileMemberVersions collects the image and version of the config server and shard members.
+// mongos is left out, it may run another version than the members.
+func (r *MongoDBShardedReconciler) reconcileMemberVersions(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	versions, err := r.collectMemberVersions(ctx, r.Client, mdbsh.Name+"-cfg", mdbsh.Namespace, keyfileCredentials(keyfile), 0, mdbsh.Spec.ConfigServer.Members, 27019)
+	if err != nil {
+		return err
+	}
+
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		shardVersions, err := r.collectMemberVersions(ctx, r.Client, fmt.Sprintf("%s-shard-%d", mdbsh.Name, i), mdbsh.Namespace, keyfileCredentials(keyfile), 0, mdbsh.Spec.Shards.MembersPerShard, 27018)
+		if err != nil {
+			return err
+		}
+		versions = append(versions, shardVersions...)
+	}
+
+	mdbsh.Status.Versions = versions
+	return nil
+}
+
+// membersLowOnStorage returns the members over the warning threshold of their component's storage
+func (r *MongoDBShardedReconciler) membersLowOnStorage(mdbsh *mongodbv1alpha1.MongoDBSharded) []mongodbv1alpha1.MemberStorageStatus {
+	var cfg, shards []mongodbv1alpha1.MemberStorageStatus
+	for _, m := range mdbsh.Status.Storage {
+		if strings.HasPrefix(m.Name, mdbsh.Name+"-cfg-") {
+			cfg = append(cfg, m)
+		} else {
+			shards = append(shards, m)
+		}
+	}
+	return append(resources.MembersLowOnStorage(cfg, mdbsh.Spec.ConfigServer.Storage),
+		resources.MembersLowOnStorage(shards, mdbsh.Spec.Shards.Storage)...)
 }
 
 func (r *MongoDBShardedReconciler) updateStatus(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
@@ -571,29 +1789,37 @@ func (r *MongoDBShardedReconciler) updateStatus(ctx context.Context, mdbsh *mong
 	}
 
 	// Update Shards status
+	primaries := r.shardPrimaries(ctx, mdbsh)
 	mdbsh.Status.Shards = []mongodbv1alpha1.ShardStatus{}
 	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
 		shardSts := &appsv1.StatefulSet{}
 		stsName := fmt.Sprintf("%s-shard-%d", mdbsh.Name, i)
 		if err := r.Get(ctx, types.NamespacedName{Name: stsName, Namespace: mdbsh.Namespace}, shardSts); err == nil {
 			mdbsh.Status.Shards = append(mdbsh.Status.Shards, mongodbv1alpha1.ShardStatus{
-				Name:  stsName,
-				Ready: shardSts.Status.ReadyReplicas,
-				Total: mdbsh.Spec.Shards.MembersPerShard,
-				Phase: r.getComponentPhase(shardSts.Status.ReadyReplicas, mdbsh.Spec.Shards.MembersPerShard),
+				Name:    stsName,
+				Ready:   shardSts.Status.ReadyReplicas,
+				Total:   mdbsh.Spec.Shards.MembersPerShard,
+				Primary: primaries[stsName],
+				Phase:   r.getComponentPhase(shardSts.Status.ReadyReplicas, mdbsh.Spec.Shards.MembersPerShard),
 			})
 		}
 	}
 
+	// Update sharded collections
+	r.updateShardedCollections(ctx, mdbsh)
+
 	// Update Mongos status
-	mongosDeploy := &appsv1.Deployment{}
-	if err := r.Get(ctx, types.NamespacedName{Name: mdbsh.Name + "-mongos", Namespace: mdbsh.Namespace}, mongosDeploy); err == nil {
+	if workloads, err := getMongosWorkloads(ctx, r.Client, mdbsh); err == nil {
+		// The desired count follows the Deployments, scaled by the HPA with autoscaling,
+		// and the nodes selected in DaemonSet mode
+		ready, desired := mongosInstances(workloads)
 		mdbsh.Status.Mongos = mongodbv1alpha1.ComponentStatus{
-			Ready: mongosDeploy.Status.ReadyReplicas,
-			Total: mdbsh.Spec.Mongos.Replicas,
-			Phase: r.getComponentPhase(mongosDeploy.Status.ReadyReplicas, mdbsh.Spec.Mongos.Replicas),
+			Ready: ready,
+			Total: desired,
+			Phase: r.getComponentPhase(ready, desired),
 		}
 	}
+	mdbsh.Status.MongosGreen = r.mongosGreenStatus(ctx, mdbsh)
 
 	// Update overall phase
 	if r.isClusterReady(mdbsh) {
@@ -602,12 +1828,44 @@ func (r *MongoDBShardedReconciler) updateStatus(ctx context.Context, mdbsh *mong
 		mdbsh.Status.Phase = "Initializing"
 	}
 
-	// Set connection string
-	mdbsh.Status.ConnectionString = fmt.Sprintf("mongodb://%s-mongos.%s.svc.cluster.local:27017",
-		mdbsh.Name, mdbsh.Namespace)
-
 	mdbsh.Status.ObservedGeneration = mdbsh.Generation
 
+	// Ready condition, clients such as backups need the admin user as well
+	ready := metav1.Condition{
+		Type:               "Ready",
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: mdbsh.Generation,
+		Reason:             "NotReady",
+		Message:            "Config servers, shards or mongos are not ready yet",
+	}
+	if mdbsh.Status.Phase == "Running" && mdbsh.Status.AdminUserCreated &&
+		resources.InitScriptsDone(mdbsh.Spec.InitScripts, mdbsh.Status.InitScripts) {
+		ready.Status = metav1.ConditionTrue
+		ready.Reason = "Ready"
+		ready.Message = "All components are ready and cluster is fully initialized"
+
+		// Only published once clients can connect and authenticate
+		mdbsh.Status.ConnectionString = resources.ShardedConnectionString(mdbsh)
+		mdbsh.Status.SRVConnectionString = resources.ShardedSRVConnectionString(mdbsh)
+	}
+	meta.SetStatusCondition(&mdbsh.Status.Conditions, ready)
+	meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildInvalidSpecCondition(nil, mdbsh.Generation))
+	meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildInvalidUpdateCondition(nil, mdbsh.Generation))
+
+	meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildStorageHealthyCondition(
+		mdbsh.Status.Storage, r.membersLowOnStorage(mdbsh), mdbsh.Generation))
+
+	mdbsh.Status.Drift = resources.PruneDrift(mdbsh.Status.Drift, time.Now())
+	meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildDriftedCondition(
+		mdbsh.Status.Versions, mdbsh.Spec.Version.Version, mdbsh.Status.Drift, mdbsh.Generation))
+
+	if resources.MemberHealthGate(mdbsh) {
+		meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildMembersUpdatingCondition(
+			r.membersUpdating(ctx, mdbsh), mdbsh.Generation))
+	} else {
+		meta.RemoveStatusCondition(&mdbsh.Status.Conditions, resources.MembersUpdatingCondition)
+	}
+
 	return r.Status().Update(ctx, mdbsh)
 }
 
@@ -625,7 +1883,7 @@ func (r *MongoDBShardedReconciler) isClusterReady(mdbsh *mongodbv1alpha1.MongoDB
 	if mdbsh.Status.ConfigServer.Ready != mdbsh.Spec.ConfigServer.Members {
 		return false
 	}
-	if mdbsh.Status.Mongos.Ready != mdbsh.Spec.Mongos.Replicas {
+	if mdbsh.Status.Mongos.Total == 0 || mdbsh.Status.Mongos.Ready != mdbsh.Status.Mongos.Total {
 		return false
 	}
 	for _, shard := range mdbsh.Status.Shards {
@@ -653,7 +1911,26 @@ func (r *MongoDBShardedReconciler) updateStatusError(ctx context.Context, mdbsh
 		logger.Error(statusErr, "Failed to update status")
 	}
 
-	return ctrl.Result{RequeueAfter: requeueAfter}, err
+	return ctrl.Result{RequeueAfter: requeueInterval()}, err
+}
+
+// findMongoDBShardedsForSecret maps admin credentials, keyfile and user password secrets to the clusters using them.
+// These secrets may not be owned by the cluster, so Owns() does not pick up their changes.
+func (r *MongoDBShardedReconciler) findMongoDBShardedsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
+	mdbshList := &mongodbv1alpha1.MongoDBShardedList{}
+	if err := r.List(ctx, mdbshList, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, mdbsh := range mdbshList.Items {
+		if usesSecret(obj.GetName(), mdbsh.Name, mdbsh.Spec.Auth) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: mdbsh.Name, Namespace: mdbsh.Namespace},
+			})
+		}
+	}
+	return requests
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -662,8 +1939,13 @@ func (r *MongoDBShardedReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&mongodbv1alpha1.MongoDBSharded{}).
 		Owns(&appsv1.StatefulSet{}).
 		Owns(&appsv1.Deployment{}).
+		Owns(&appsv1.DaemonSet{}).
+		Owns(&batchv1.Job{}).
+		Owns(&autoscalingv2.HorizontalPodAutoscaler{}).
 		Owns(&corev1.Service{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.ServiceAccount{}).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.findMongoDBShardedsForSecret)).
 		Complete(r)
 }
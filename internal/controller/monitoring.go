@@ -0,0 +1,64 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// ensureMonitoringSecret creates the exporter credentials secret when it does not exist.
+// Like the generated admin secret it has no owner reference, the user outlives the CR in the data volumes.
+func ensureMonitoringSecret(ctx context.Context, c client.Client, clusterName, namespace string) error {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: resources.MonitoringSecretName(clusterName), Namespace: namespace}, secret)
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return err
+	}
+	return c.Create(ctx, resources.BuildMonitoringSecret(clusterName, namespace))
+}
+
+// getMonitoringUser reads the exporter credentials secret of a cluster
+func getMonitoringUser(ctx context.Context, c client.Client, clusterName, namespace string) (mongodb.MongoUser, error) {
+	secret := &corev1.Secret{}
+	secretName := resources.MonitoringSecretName(clusterName)
+	if err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: namespace}, secret); err != nil {
+		return mongodb.MongoUser{}, fmt.Errorf("failed to get monitoring credentials secret: %w", err)
+	}
+
+	password := secret.Data["password"]
+	if len(password) == 0 {
+		return mongodb.MongoUser{}, fmt.Errorf("password key not found in secret %s", secretName)
+	}
+
+	username := resources.MonitoringUsername
+	if value := secret.Data["username"]; len(value) > 0 {
+		username = string(value)
+	}
+	return mongodb.ClusterMonitorUser(username, string(password)), nil
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// managesReplicaSet reports whether this operator changes the replica set configuration and users:
+// always for a replica set in a single Kubernetes cluster, only while the primary runs in this
+// cluster for a multi-cluster replica set and never for a disaster recovery cluster, whose remote
+// replica set is managed where it runs
+func (r *MongoDBReconciler) managesReplicaSet(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) (bool, error) {
+	if resources.ReplicaOfEnabled(mdb) {
+		return false, nil
+	}
+	if !resources.MultiClusterEnabled(mdb) {
+		return true, nil
+	}
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return false, err
+	}
+	if !resources.IsLocalMember(mdb, primaryPod) {
+		log.FromContext(ctx).V(1).Info("Primary runs in another Kubernetes cluster, leaving the replica set to its operator", "primary", primaryPod)
+		return false, nil
+	}
+	return true, nil
+}
+
+// reconcileMultiClusterMembers adds the members of every Kubernetes cluster of a multi-cluster
+// replica set missing from its configuration. Members are never removed, and the members a forced
+// reconfiguration removed are added back by restoreRemovedMembers.
+func (r *MongoDBReconciler) reconcileMultiClusterMembers(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if !resources.MultiClusterEnabled(mdb) {
+		return nil
+	}
+
+	var removed []string
+	if mdb.Status.ForceReconfig != nil {
+		removed = mdb.Status.ForceReconfig.RemovedMembers
+	}
+	var hosts []string
+	for i := int32(0); i < mdb.Spec.Members; i++ {
+		if !slices.Contains(removed, fmt.Sprintf("%s-%d", mdb.Name, i)) {
+			hosts = append(hosts, resources.MemberHost(mdb, i))
+		}
+	}
+
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		return err
+	}
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+	return rsManager.AddMembersWithKeyfile(ctx, primaryPod, mdb.Namespace, keyfile, hosts)
+}
+
+// reconcileReplicaOf adds the members of a disaster recovery cluster missing from the remote
+// replica set as read-only members. The users come with the data of the remote replica set, so
+// the admin user is reported created once the members joined.
+func (r *MongoDBReconciler) reconcileReplicaOf(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+	if err := rsManager.JoinReplicaSetWithKeyfile(ctx, resources.FirstMemberPod(mdb), mdb.Namespace, keyfile,
+		mdb.Spec.ReplicaSetName, mdb.Spec.ReplicaOf.Hosts, resources.MemberHosts(mdb)); err != nil {
+		return err
+	}
+
+	if mdb.Status.ReplicaSetInitialized && mdb.Status.AdminUserCreated {
+		return nil
+	}
+	log.FromContext(ctx).Info("Joined the remote replica set", "hosts", mdb.Spec.ReplicaOf.Hosts)
+	mdb.Status.ReplicaSetInitialized = true
+	mdb.Status.AdminUserCreated = true
+	return r.Status().Update(ctx, mdb)
+}
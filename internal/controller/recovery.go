@@ -0,0 +1,187 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// reconcileForceReconfig handles the force-reconfig annotation: when the replica set has no primary,
+// the configuration is forced down to the listed surviving members so they can elect one. The
+// removed members are added back once their pods are ready again. It runs before the pods readiness
+// wait, the lost members may never come back.
+func (r *MongoDBReconciler) reconcileForceReconfig(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if resources.Standalone(mdb) || !mdb.Status.ReplicaSetInitialized {
+		return nil
+	}
+
+	requested := mdb.Annotations[resources.ForceReconfigAnnotation]
+	current := mdb.Status.ForceReconfig
+	if requested != "" && (current == nil || current.Requested != requested) {
+		return r.forceReconfig(ctx, mdb, requested)
+	}
+
+	if current != nil && len(current.RemovedMembers) > 0 {
+		return r.restoreRemovedMembers(ctx, mdb)
+	}
+	return nil
+}
+
+// forceReconfig forces the configuration to the surviving members, or rejects the request when the
+// replica set still has a primary or a survivor is unreachable
+func (r *MongoDBReconciler) forceReconfig(ctx context.Context, mdb *mongodbv1alpha1.MongoDB, requested string) error {
+	logger := log.FromContext(ctx)
+
+	reject := func(message string) error {
+		logger.Info("Rejecting forced reconfiguration", "reason", message)
+		r.event(mdb, corev1.EventTypeWarning, "ForceReconfigRejected", message)
+		now := metav1.Now()
+		mdb.Status.ForceReconfig = &mongodbv1alpha1.ForceReconfigStatus{
+			Requested:          requested,
+			Phase:              resources.ForceReconfigRejected,
+			Message:            message,
+			LastTransitionTime: &now,
+		}
+		return r.Status().Update(ctx, mdb)
+	}
+
+	if resources.ReplicaOfEnabled(mdb) {
+		return reject("the members of a disaster recovery cluster do not vote, force the reconfiguration where the remote replica set runs")
+	}
+	survivors, err := resources.ParseSurvivingMembers(mdb, requested)
+	if err != nil {
+		return reject(err.Error())
+	}
+	if !resources.IsLocalMember(mdb, survivors[0]) {
+		return reject(fmt.Sprintf("surviving member %s runs in another Kubernetes cluster, list a member of this cluster first", survivors[0]))
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	// Retried on the next reconcile, a survivor may still be starting
+	rsStatus, err := rsManager.GetStatusWithKeyfile(ctx, survivors[0], mdb.Namespace, keyfile)
+	if err != nil {
+		return err
+	}
+
+	reachable := map[string]bool{}
+	for _, member := range rsStatus.Members {
+		podName := strings.Split(member.Name, ".")[0]
+		if member.StateStr == "PRIMARY" {
+			return reject(fmt.Sprintf("replica set has a primary (%s), a forced reconfiguration is only needed when the majority is lost", podName))
+		}
+		if member.Health == 1 {
+			reachable[podName] = true
+		}
+	}
+	for _, name := range survivors {
+		if !reachable[name] {
+			return reject(fmt.Sprintf("surviving member %s is not reachable from %s", name, survivors[0]))
+		}
+	}
+
+	lost := resources.LostMembers(mdb, survivors)
+	logger.Info("Forcing replica set reconfiguration", "survivors", survivors, "removed", lost)
+	if err := rsManager.ForceReconfigWithKeyfile(ctx, survivors[0], mdb.Namespace, keyfile, survivors); err != nil {
+		return err
+	}
+	r.event(mdb, corev1.EventTypeWarning, "ForceReconfig",
+		fmt.Sprintf("Forced the replica set configuration to %s, removed %s", strings.Join(survivors, ", "), strings.Join(lost, ", ")))
+
+	now := metav1.Now()
+	mdb.Status.ForceReconfig = &mongodbv1alpha1.ForceReconfigStatus{
+		Requested:          requested,
+		Phase:              resources.ForceReconfigCompleted,
+		RemovedMembers:     lost,
+		LastTransitionTime: &now,
+	}
+	// The member overrides are applied again to the new configuration
+	mdb.Status.MemberSettingsHash = ""
+	return r.Status().Update(ctx, mdb)
+}
+
+// restoreRemovedMembers adds the members removed by a forced reconfiguration back to the replica
+// set once their pods are ready. A member that diverged from the survivors rolls back or, with
+// spec.autoResync, is resynced. In a multi-cluster replica set the operator of the Kubernetes
+// cluster running the primary adds them, the members of other clusters without checking their pods.
+func (r *MongoDBReconciler) restoreRemovedMembers(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		log.FromContext(ctx).Info("Waiting for a primary to add the removed members back", "error", err)
+		return nil
+	}
+	if !resources.IsLocalMember(mdb, primaryPod) {
+		return nil
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	horizons := resources.BuildHorizons(mdb, mdb.Status.HorizonHosts)
+	status := mdb.Status.ForceReconfig
+	removed := slices.Clone(status.RemovedMembers)
+	for _, name := range removed {
+		if resources.IsLocalMember(mdb, name) {
+			pod := &corev1.Pod{}
+			if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: mdb.Namespace}, pod); err != nil {
+				if errors.IsNotFound(err) {
+					continue
+				}
+				return err
+			}
+			if !resources.PodReady(pod) {
+				continue
+			}
+		}
+
+		host := resources.PodMemberHost(mdb, name)
+		if err := rsManager.AddMemberWithKeyfile(ctx, primaryPod, mdb.Namespace, keyfile, host, horizons[name]); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Added removed member back to the replica set", "pod", name)
+		r.event(mdb, corev1.EventTypeNormal, "MemberRestored", fmt.Sprintf("Added member %s back to the replica set", name))
+
+		status.RemovedMembers = slices.DeleteFunc(status.RemovedMembers, func(m string) bool { return m == name })
+		mdb.Status.MemberSettingsHash = ""
+	}
+
+	if len(status.RemovedMembers) == len(removed) {
+		return nil
+	}
+	return r.Status().Update(ctx, mdb)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// reconcileStaleMembers records the members stuck in RECOVERING, ROLLBACK or a crash loop and, with
+// spec.autoResync enabled, wipes the data of one of them once it was stuck for staleAfterSeconds so
+// it runs an initial sync. It runs before the pods readiness wait, a crash looping member never
+// becomes ready. Members are only judged against a primary, without one there is no member to
+// sync from.
+func (r *MongoDBReconciler) reconcileStaleMembers(ctx context.Context, mdb *mongodbv1alpha1.MongoDB) error {
+	if resources.Standalone(mdb) || !mdb.Status.ReplicaSetInitialized {
+		return nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	primaryPod, err := r.getPrimaryPod(ctx, mdb)
+	if err != nil {
+		logger.Info("Skipping stale member detection without a primary", "error", err)
+		return nil
+	}
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdb.Name, mdb.Namespace, mdb.Spec.Auth)
+	if err != nil {
+		return err
+	}
+
+	rsManager := r.replicaSetManager(int(resources.ReplicaSetPort(mdb)))
+
+	rsStatus, err := rsManager.GetStatusWithKeyfile(ctx, primaryPod, mdb.Namespace, keyfile)
+	if err != nil {
+		return err
+	}
+
+	stuck := map[string]string{}
+	healthy := map[string]bool{}
+	for _, member := range rsStatus.Members {
+		podName := strings.Split(member.Name, ".")[0]
+		switch {
+		case member.StateStr == "PRIMARY" || member.StateStr == "SECONDARY":
+			healthy[podName] = true
+		case resources.StaleMemberState(member.StateStr):
+			stuck[podName] = member.StateStr
+		}
+	}
+
+	// A member crash looping on a broken dbPath is only DOWN to the others. Only crashes that
+	// point at the data files count, and only on the current revision: a member crashing on a
+	// bad configuration or on a template being rolled out keeps its data.
+	for _, podName := range resources.LocalMemberPods(mdb) {
+		pod := &corev1.Pod{}
+		if err := r.Get(ctx, types.NamespacedName{Name: podName, Namespace: mdb.Namespace}, pod); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		if !resources.CrashLooping(pod, "mongodb") || !resources.DataFilesUnusable(pod, "mongodb") {
+			continue
+		}
+		current, err := onUpdateRevision(ctx, r.Client, pod)
+		if err != nil {
+			return err
+		}
+		if current {
+			stuck[podName] = resources.CrashLoopBackOff
+		}
+	}
+
+	now := metav1.Now()
+	stale, resynced := resources.UpdateStaleMembers(mdb.Status.StaleMembers, stuck, healthy, now)
+	for _, name := range resynced {
+		logger.Info("Member resync completed", "pod", name)
+		r.event(mdb, corev1.EventTypeNormal, "ResyncCompleted", fmt.Sprintf("Member %s completed its initial sync", name))
+	}
+
+	if resources.AutoResyncEnabled(mdb.Spec.AutoResync) {
+		for i := range stale {
+			if stale[i].ResyncStartedAt != nil {
+				if err := retryResync(ctx, r.Client, mdb.Namespace, stale[i].Name, mdb.Spec.Storage); err != nil {
+					return err
+				}
+			}
+		}
+
+		if name := resources.NextResync(stale, resources.StaleAfter(mdb.Spec.AutoResync), now.Time); name != "" {
+			if err := resyncMember(ctx, r.Client, mdb.Namespace, name, mdb.Spec.Storage); err != nil {
+				return err
+			}
+			for i := range stale {
+				if stale[i].Name == name {
+					stale[i].ResyncStartedAt = &now
+					logger.Info("Resyncing stale member", "pod", name, "reason", stale[i].Reason)
+					r.event(mdb, corev1.EventTypeWarning, "ResyncStarted",
+						fmt.Sprintf("Wiping the data of member %s, stuck in %s since %s, to run an initial sync",
+							name, stale[i].Reason, stale[i].Since.UTC().Format(time.RFC3339)))
+				}
+			}
+		}
+	}
+
+	if reflect.DeepEqual(stale, mdb.Status.StaleMembers) {
+		return nil
+	}
+	mdb.Status.StaleMembers = stale
+	return r.Status().Update(ctx, mdb)
+}
+
+// onUpdateRevision reports whether a pod runs the update revision of its StatefulSet
+func onUpdateRevision(ctx context.Context, c client.Client, pod *corev1.Pod) (bool, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "StatefulSet" {
+		return false, nil
+	}
+	sts := &appsv1.StatefulSet{}
+	if err := c.Get(ctx, types.NamespacedName{Name: owner.Name, Namespace: pod.Namespace}, sts); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return sts.Status.UpdateRevision != "" && pod.Labels[appsv1.ControllerRevisionHashLabelKey] == sts.Status.UpdateRevision, nil
+}
+
+// resyncMember deletes the data volume claim and the pod of a member. The StatefulSet recreates the
+// pod with an empty volume, or an empty emptyDir for ephemeral storage, and mongod runs an initial
+// sync from the other members.
+func resyncMember(ctx context.Context, c client.Client, namespace, podName string, storage mongodbv1alpha1.StorageSpec) error {
+	if !resources.EphemeralStorage(storage) {
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "data-" + podName, Namespace: namespace}}
+		if err := c.Delete(ctx, pvc); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete data volume of %s: %w", podName, err)
+		}
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: namespace}}
+	if err := c.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod %s: %w", podName, err)
+	}
+	return nil
+}
+
+// retryResync deletes the pod of a resyncing member again while its old volume claim is still
+// terminating. The StatefulSet may recreate the pod before the claim is gone, the pod then stays
+// pending on the claim being deleted and the protection finalizer keeps the claim.
+func retryResync(ctx context.Context, c client.Client, namespace, podName string, storage mongodbv1alpha1.StorageSpec) error {
+	if resources.EphemeralStorage(storage) {
+		return nil
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "data-" + podName, Namespace: namespace}, pvc); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if pvc.DeletionTimestamp.IsZero() {
+		return nil
+	}
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: podName, Namespace: namespace}}
+	if err := c.Delete(ctx, pod); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pod %s: %w", podName, err)
+	}
+	return nil
+}
+
+// event records an event on the cluster when the reconciler has a recorder
+func (r *MongoDBReconciler) event(mdb *mongodbv1alpha1.MongoDB, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(mdb, eventType, reason, message)
+	}
+}
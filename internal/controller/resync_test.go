@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+	mongodbfake "github.com/keiailab/mongodb-operator/pkg/mongodb/fake"
+)
+
+const testReplicaSetStatus = `{"set":"my-mongodb","ok":1,"members":[
+	{"_id":0,"name":"my-mongodb-0.my-mongodb-headless.default.svc.cluster.local:27017","stateStr":"PRIMARY","health":1},
+	{"_id":1,"name":"my-mongodb-1.my-mongodb-headless.default.svc.cluster.local:27017","stateStr":"RECOVERING","health":1},
+	{"_id":2,"name":"my-mongodb-2.my-mongodb-headless.default.svc.cluster.local:27017","stateStr":"(not reachable/healthy)","health":0}]}`
+
+// crashLoopingPod returns a member pod of the StatefulSet my-mongodb crash looping on corrupt
+// data files, created from revision
+func crashLoopingPod(name, revision string) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{appsv1.ControllerRevisionHashLabelKey: revision},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "apps/v1", Kind: "StatefulSet", Name: "my-mongodb", UID: "sts-uid", Controller: &controller,
+			}},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "mongodb",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: resources.CrashLoopBackOff}},
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{ExitCode: 62},
+			},
+		}}},
+	}
+}
+
+// TestReconcileStaleMembers drives the reconciler through the fake runner: members are judged
+// from the rs.status() of the primary and from their pods
+func TestReconcileStaleMembers(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default", UID: "uid"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 3,
+			Version: mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+		},
+		Status: mongodbv1alpha1.MongoDBStatus{ReplicaSetInitialized: true},
+	}
+	keyfile := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb-keyfile", Namespace: "default"},
+		Data:       map[string][]byte{"keyfile": []byte("secret-key")},
+	}
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Status:     appsv1.StatefulSetStatus{UpdateRevision: "rev-2"},
+	}
+	// The crash loop of a pod still on the previous revision is left to the rollout
+	pod := crashLoopingPod("my-mongodb-2", "rev-1")
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(mdb, keyfile, sts, pod).
+		WithStatusSubresource(mdb).
+		Build()
+
+	runner := mongodbfake.NewRunner()
+	runner.On("rs.status()", testReplicaSetStatus)
+	executor := mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions())
+	r := &MongoDBReconciler{Client: c, Scheme: scheme, Managers: Managers{Executor: executor}}
+
+	require.NoError(t, r.reconcileStaleMembers(ctx, mdb))
+	require.Len(t, mdb.Status.StaleMembers, 1)
+	assert.Equal(t, "my-mongodb-1", mdb.Status.StaleMembers[0].Name)
+	assert.Equal(t, "RECOVERING", mdb.Status.StaleMembers[0].Reason)
+	for _, call := range runner.Calls() {
+		assert.Equal(t, "my-mongodb-0", call.Pod)
+	}
+	assert.Contains(t, runner.Scripts()[len(runner.Scripts())-1], "rs.status()")
+
+	// Once on the update revision, the crash loop counts
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(pod), pod))
+	pod.Labels[appsv1.ControllerRevisionHashLabelKey] = "rev-2"
+	require.NoError(t, c.Update(ctx, pod))
+
+	require.NoError(t, r.reconcileStaleMembers(ctx, mdb))
+	require.Len(t, mdb.Status.StaleMembers, 2)
+	assert.Equal(t, "my-mongodb-2", mdb.Status.StaleMembers[1].Name)
+	assert.Equal(t, resources.CrashLoopBackOff, mdb.Status.StaleMembers[1].Reason)
+
+	stored := &mongodbv1alpha1.MongoDB{}
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(mdb), stored))
+	assert.Equal(t, mdb.Status.StaleMembers, stored.Status.StaleMembers)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// gateRollout sets the partition of a config server or shard StatefulSet about to be written, so
+// its rollout only moves on to the next member once the member updated last is caught up
+func (r *MongoDBShardedReconciler) gateRollout(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, sts *appsv1.StatefulSet, port int) error {
+	if !resources.MemberHealthGate(mdbsh) {
+		return nil
+	}
+
+	existing := &appsv1.StatefulSet{}
+	if err := r.Get(ctx, client.ObjectKeyFromObject(sts), existing); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(sts.Namespace), client.MatchingLabels(existing.Spec.Selector.MatchLabels)); err != nil {
+		return err
+	}
+	revisions := make(map[string]string, len(pods.Items))
+	for _, pod := range pods.Items {
+		revisions[pod.Name] = pod.Labels[appsv1.ControllerRevisionHashLabelKey]
+	}
+
+	var caughtUp map[string]bool
+	if resources.RolloutInProgress(existing) {
+		caughtUp = r.caughtUpMembers(ctx, mdbsh, existing, port)
+	}
+	resources.ApplyGatedPartition(sts, existing, revisions, caughtUp)
+	return nil
+}
+
+// caughtUpMembers returns the members of the replica set of a StatefulSet that are caught up with
+// the primary, nil when no member reports the replica set status
+func (r *MongoDBShardedReconciler) caughtUpMembers(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, sts *appsv1.StatefulSet, port int) map[string]bool {
+	logger := log.FromContext(ctx)
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		logger.Info("Failed to read the keyfile for the rollout", "error", err)
+		return nil
+	}
+	rsManager := r.replicaSetManager(port)
+
+	// Any member reports the state of the others, ask the next one when a member is down
+	for i := int32(0); i < sts.Status.Replicas; i++ {
+		status, err := rsManager.GetStatusWithKeyfile(ctx, fmt.Sprintf("%s-%d", sts.Name, i), mdbsh.Namespace, keyfile)
+		if err != nil {
+			logger.Info("Failed to get replica set status for the rollout", "statefulset", sts.Name, "member", i, "error", err)
+			continue
+		}
+		return status.CaughtUpPods(resources.MaxRolloutLag(mdbsh.Spec.UpdateStrategy))
+	}
+	return nil
+}
+
+// membersUpdating returns the members the health-gated rollouts of the config servers and shards
+// wait for
+func (r *MongoDBShardedReconciler) membersUpdating(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) []string {
+	names := []string{mdbsh.Name + "-cfg"}
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		names = append(names, resources.ShardName(mdbsh.Name, i))
+	}
+
+	var waiting []string
+	for _, name := range names {
+		sts := &appsv1.StatefulSet{}
+		if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: mdbsh.Namespace}, sts); err != nil {
+			continue
+		}
+		if member := resources.WaitingMember(sts); member != "" {
+			waiting = append(waiting, member)
+		}
+	}
+	return waiting
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// flushRouterConfigs marks the routing table cache of every running mongos of a cluster as stale
+// and returns how many were flushed. Every router keeps its own cache, a mongos that is not
+// running reloads it on start.
+func flushRouterConfigs(ctx context.Context, c client.Client, mdbsh *mongodbv1alpha1.MongoDBSharded, shardManager mongodb.ShardManager, creds *adminCredentials) (int, error) {
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList, client.InNamespace(mdbsh.Namespace), client.MatchingLabels{
+		"app.kubernetes.io/instance":  mdbsh.Name,
+		"app.kubernetes.io/component": "mongos",
+	}); err != nil {
+		return 0, err
+	}
+
+	flushed := 0
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		if err := shardManager.FlushRouterConfigInContainer(ctx, pod.Name, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017); err != nil {
+			return flushed, err
+		}
+		flushed++
+	}
+	return flushed, nil
+}
+
+// refreshRouters flushes the routing table cache of every mongos after the shards or the primary
+// shard of a database changed, so no router keeps sending requests to the old topology. Failures
+// are only logged, the FlushRouterConfig ops request runs the flush again on demand.
+func (r *MongoDBShardedReconciler) refreshRouters(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardManager mongodb.ShardManager, creds *adminCredentials, reason string) {
+	logger := log.FromContext(ctx)
+
+	flushed, err := flushRouterConfigs(ctx, r.Client, mdbsh, shardManager, creds)
+	if err != nil {
+		logger.Info("Failed to flush the router config", "reason", reason, "flushed", flushed, "error", err)
+		return
+	}
+	logger.Info("Flushed the router config", "reason", reason, "flushed", flushed)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// reconcileShardHosts compares the shards registered in config.shards with the shard members.
+// A shard missing from config.shards is marked as not added so it is added again, a stale host
+// is rewritten on the config server primary and the routers are told to reload it. The
+// ShardHostRepair feature gate turns the check off.
+func (r *MongoDBShardedReconciler) reconcileShardHosts(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if !resources.FeatureEnabled(resources.FeatureShardHostRepair) {
+		meta.RemoveStatusCondition(&mdbsh.Status.Conditions, resources.ShardHostsCondition)
+		return nil
+	}
+	if !mdbsh.Status.AdminUserCreated {
+		return nil
+	}
+	logger := log.FromContext(ctx)
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get admin credentials: %w", err)
+	}
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get mongos pod: %w", err)
+	}
+
+	shardManager := r.shardManager()
+
+	shards, err := shardManager.ListShardsInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017)
+	if err != nil {
+		return err
+	}
+	registered := make(map[string]string, len(shards))
+	for _, shard := range shards {
+		registered[shard.ID] = shard.Host
+	}
+
+	var repaired, missing []string
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		shardName := resources.ShardName(mdbsh.Name, i)
+		if !resources.ShardAdded(&mdbsh.Status, shardName) {
+			continue
+		}
+
+		host, ok := registered[shardName]
+		if !ok {
+			logger.Info("Shard is missing from config.shards, adding it again", "shard", shardName)
+			resources.SetShardAdded(&mdbsh.Status, shardName, false)
+			missing = append(missing, shardName)
+			continue
+		}
+
+		desired := shardConnectionString(mdbsh, i)
+		if !resources.ShardHostDrifted(host, desired) {
+			continue
+		}
+
+		logger.Info("Repairing stale shard host", "shard", shardName, "registered", host, "desired", desired)
+		if err := r.setShardHost(ctx, mdbsh, shardManager, shardName, desired); err != nil {
+			return err
+		}
+		repaired = append(repaired, shardName)
+	}
+
+	if len(repaired) > 0 {
+		r.refreshRouters(ctx, mdbsh, shardManager, creds, "shard hosts repaired")
+	}
+
+	meta.SetStatusCondition(&mdbsh.Status.Conditions, resources.BuildShardHostsCondition(repaired, missing, mdbsh.Generation))
+	return nil
+}
+
+// setShardHost rewrites the host of a shard on the config server primary, config servers
+// listen on 27019
+func (r *MongoDBShardedReconciler) setShardHost(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded, shardManager mongodb.ShardManager, shardName, host string) error {
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		return fmt.Errorf("failed to get keyfile: %w", err)
+	}
+
+	rsManager := r.replicaSetManager(27019)
+
+	// Any member reports the primary, ask the next one when a member is down
+	primary := ""
+	for m := int32(0); m < mdbsh.Spec.ConfigServer.Members && primary == ""; m++ {
+		status, err := rsManager.GetStatusWithKeyfile(ctx, fmt.Sprintf("%s-cfg-%d", mdbsh.Name, m), mdbsh.Namespace, keyfile)
+		if err != nil {
+			continue
+		}
+		primary = status.PrimaryPod()
+	}
+	if primary == "" {
+		return fmt.Errorf("no config server primary to repair shard %s", shardName)
+	}
+
+	return shardManager.SetShardHostWithKeyfile(ctx, primary, mdbsh.Namespace, keyfile, shardName, host, 27019)
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// maxParallelShards bounds the shards provisioned, initialized or added to the cluster at the
+// same time, so bootstrapping many shards neither takes one reconcile per shard nor floods the
+// API server with exec sessions
+const maxParallelShards = 4
+
+// forEachShard runs fn for the shards 0 to count-1 on at most maxParallelShards goroutines. A
+// failing shard does not stop the others, the errors of all shards are returned joined. fn may
+// only write to the status entries of its own shard.
+func forEachShard(count int32, fn func(index int32) error) error {
+	errs := make([]error, count)
+
+	var g errgroup.Group
+	g.SetLimit(maxParallelShards)
+	for i := int32(0); i < count; i++ {
+		g.Go(func() error {
+			if err := fn(i); err != nil {
+				errs[i] = fmt.Errorf("shard %d: %w", i, err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	return errors.Join(errs...)
+}
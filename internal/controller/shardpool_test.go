@@ -0,0 +1,101 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testScheme(t *testing.T) *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, mongodbv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func testSharded(shards int32) *mongodbv1alpha1.MongoDBSharded {
+	return &mongodbv1alpha1.MongoDBSharded{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sharded", Namespace: "default", UID: "uid"},
+		Spec: mongodbv1alpha1.MongoDBShardedSpec{
+			Version:      mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+			ConfigServer: mongodbv1alpha1.ConfigServerSpec{Members: 3},
+			Shards:       mongodbv1alpha1.ShardSpec{Count: shards, MembersPerShard: 3},
+			Mongos:       mongodbv1alpha1.MongosSpec{Replicas: 2},
+		},
+	}
+}
+
+func TestForEachShard(t *testing.T) {
+	visited := make([]bool, 10)
+	err := forEachShard(10, func(i int32) error {
+		visited[i] = true
+		if i%4 == 1 {
+			return fmt.Errorf("failed")
+		}
+		return nil
+	})
+	assert.NotContains(t, visited, false)
+	assert.EqualError(t, err, "shard 1: failed\nshard 5: failed\nshard 9: failed")
+}
+
+// TestReconcileShardsDrift reconciles more shards than run at the same time, each reverting an
+// out-of-band change. Run with -race, the shards must not write the status concurrently.
+func TestReconcileShardsDrift(t *testing.T) {
+	ctx := context.Background()
+	scheme := testScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).Build()
+	r := &MongoDBShardedReconciler{Client: c, Scheme: scheme}
+
+	const shards = 2*maxParallelShards + 1
+	mdbsh := testSharded(shards)
+	require.NoError(t, r.reconcileShards(ctx, mdbsh))
+	assert.Empty(t, mdbsh.Status.Drift)
+
+	for i := 0; i < shards; i++ {
+		cm := &corev1.ConfigMap{}
+		require.NoError(t, c.Get(ctx, client.ObjectKey{Name: fmt.Sprintf("my-sharded-shard-%d-config", i), Namespace: "default"}, cm))
+		cm.Data["mongod.conf"] = "changed"
+		require.NoError(t, c.Update(ctx, cm))
+	}
+
+	require.NoError(t, r.reconcileShards(ctx, mdbsh))
+	var want, got []string
+	for i := 0; i < shards; i++ {
+		want = append(want, fmt.Sprintf("ConfigMap/my-sharded-shard-%d-config", i))
+	}
+	for _, d := range mdbsh.Status.Drift {
+		got = append(got, d.Resource)
+	}
+	assert.ElementsMatch(t, want, got)
+
+	// The changes are reverted, nothing more is recorded
+	require.NoError(t, r.reconcileShards(ctx, mdbsh))
+	assert.Len(t, mdbsh.Status.Drift, shards)
+}
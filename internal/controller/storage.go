@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// collectStorageUsage returns the disk usage of the members <baseName>-<first> to <baseName>-<first+members-1>.
+// Members that cannot be queried are logged and left out rather than failing the reconcile,
+// a member whose disk is full may no longer answer.
+func (m Managers) collectStorageUsage(ctx context.Context, baseName, namespace string, creds memberCredentials, first, members int32, port int) ([]mongodbv1alpha1.MemberStorageStatus, error) {
+	rsManager := m.replicaSetManager(port)
+
+	logger := log.FromContext(ctx)
+	usage := make([]mongodbv1alpha1.MemberStorageStatus, 0, members)
+	for i := first; i < first+members; i++ {
+		podName := fmt.Sprintf("%s-%d", baseName, i)
+		stats, err := rsManager.GetStorageStatsWithAuth(ctx, podName, namespace, creds.Username, creds.Password, creds.Database)
+		if err != nil {
+			logger.Info("Failed to collect disk usage", "pod", podName, "error", err)
+			continue
+		}
+		usage = append(usage, mongodbv1alpha1.MemberStorageStatus{
+			Name:        podName,
+			DataSize:    stats.DataSize,
+			StorageSize: stats.StorageSize,
+			IndexSize:   stats.IndexSize,
+			FsUsedSize:  stats.FsUsedSize,
+			FsTotalSize: stats.FsTotalSize,
+			UsedPercent: stats.UsedPercent(),
+		})
+	}
+	return usage, nil
+}
+
+// logMembersLowOnStorage warns about the members that reached their disk usage warning threshold
+func logMembersLowOnStorage(ctx context.Context, low []mongodbv1alpha1.MemberStorageStatus) {
+	for _, m := range low {
+		log.FromContext(ctx).Info("Member is running out of disk space", "pod", m.Name, "usedPercent", m.UsedPercent)
+	}
+}
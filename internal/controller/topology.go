@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// shardPrimaries returns the primary pod of every initialized shard, keyed by shard name.
+// Shards without a reachable primary are left out, the status then shows no primary.
+func (r *MongoDBShardedReconciler) shardPrimaries(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) map[string]string {
+	logger := log.FromContext(ctx)
+
+	keyfile, err := getKeyfile(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		logger.Info("Failed to read the keyfile for the shard primaries", "error", err)
+		return nil
+	}
+
+	// Shards listen on 27018
+	rsManager := r.replicaSetManager(27018)
+
+	primaries := make(map[string]string)
+	for i := int32(0); i < mdbsh.Spec.Shards.Count; i++ {
+		// Any member reports the primary, ask the next one when a member is down
+		shardName := resources.ShardName(mdbsh.Name, i)
+		if !resources.ShardInitialized(&mdbsh.Status, shardName) {
+			continue
+		}
+		for m := int32(0); m < mdbsh.Spec.Shards.MembersPerShard; m++ {
+			status, err := rsManager.GetStatusWithKeyfile(ctx, fmt.Sprintf("%s-%d", shardName, m), mdbsh.Namespace, keyfile)
+			if err != nil {
+				logger.Info("Failed to get shard replica set status", "shard", shardName, "member", m, "error", err)
+				continue
+			}
+			if primary := status.PrimaryPod(); primary != "" {
+				primaries[shardName] = primary
+			}
+			break
+		}
+	}
+	return primaries
+}
+
+// updateShardedCollections lists the sharded collections through mongos. The previous list is
+// kept when mongos cannot be reached.
+func (r *MongoDBShardedReconciler) updateShardedCollections(ctx context.Context, mdbsh *mongodbv1alpha1.MongoDBSharded) {
+	if !mdbsh.Status.AdminUserCreated {
+		return
+	}
+	logger := log.FromContext(ctx)
+
+	creds, err := getAdminCredentials(ctx, r.Client, mdbsh.Name, mdbsh.Namespace, mdbsh.Spec.Auth)
+	if err != nil {
+		logger.Info("Failed to get admin credentials for the sharded collections", "error", err)
+		return
+	}
+
+	mongosPod, err := getMongosPodName(ctx, r.Client, mdbsh.Name, mdbsh.Namespace)
+	if err != nil {
+		logger.Info("Failed to get mongos pod for the sharded collections", "error", err)
+		return
+	}
+
+	shardManager := r.shardManager()
+
+	collections, err := shardManager.ListShardedCollectionsInContainer(ctx, mongosPod, mdbsh.Namespace, "mongos", creds.Username, creds.Password, 27017)
+	if err != nil {
+		logger.Info("Failed to list sharded collections", "error", err)
+		return
+	}
+	mdbsh.Status.ShardedCollections = collections
+}
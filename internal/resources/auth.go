@@ -0,0 +1,224 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// AdminUsername is the name of the operator-managed root user
+	AdminUsername = "admin"
+
+	adminPasswordLength = 32
+	passwordAlphabet    = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+)
+
+// AdminSecretName returns the name of the admin credentials secret for a cluster.
+// When no secret is referenced, the operator-generated <name>-admin secret is used.
+func AdminSecretName(clusterName string, auth mongodbv1alpha1.AuthSpec) string {
+	if auth.AdminCredentialsSecretRef.Name != "" {
+		return auth.AdminCredentialsSecretRef.Name
+	}
+	return clusterName + "-admin"
+}
+
+// CredentialKeys returns the username and password keys of a credentials secret,
+// falling back to the conventional "username" and "password" keys
+func CredentialKeys(ref mongodbv1alpha1.CredentialsSecretRef) (string, string) {
+	usernameKey := ref.UsernameKey
+	if usernameKey == "" {
+		usernameKey = "username"
+	}
+	passwordKey := ref.PasswordKey
+	if passwordKey == "" {
+		passwordKey = "password"
+	}
+	return usernameKey, passwordKey
+}
+
+// IsAdminSecretGenerated reports whether the admin credentials secret is managed by the operator
+func IsAdminSecretGenerated(auth mongodbv1alpha1.AuthSpec) bool {
+	return auth.AdminCredentialsSecretRef.Name == ""
+}
+
+// BuildAdminSecret creates an admin credentials secret with a generated password
+func BuildAdminSecret(clusterName, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName + "-admin",
+			Namespace: namespace,
+			Labels:    buildLabels(clusterName, "admin-credentials"),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			"username": []byte(AdminUsername),
+			"password": []byte(generateRandomPassword(adminPasswordLength)),
+		},
+	}
+}
+
+// generateRandomPassword returns a URI-safe alphanumeric password
+func generateRandomPassword(length int) string {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	out := make([]byte, length)
+	for i := range out {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(fmt.Sprintf("failed to read random bytes: %v", err))
+		}
+		out[i] = passwordAlphabet[n.Int64()]
+	}
+	return string(out)
+}
+
+// oidcIdentityProvider mirrors a single entry of the oidcIdentityProviders server parameter
+type oidcIdentityProvider struct {
+	Issuer                string   `json:"issuer"`
+	Audience              string   `json:"audience"`
+	AuthNamePrefix        string   `json:"authNamePrefix"`
+	ClientID              string   `json:"clientId,omitempty"`
+	MatchPattern          string   `json:"matchPattern,omitempty"`
+	PrincipalName         string   `json:"principalName,omitempty"`
+	AuthorizationClaim    string   `json:"authorizationClaim,omitempty"`
+	UseAuthorizationClaim bool     `json:"useAuthorizationClaim"`
+	SupportsHumanFlows    bool     `json:"supportsHumanFlows"`
+	RequestScopes         []string `json:"requestScopes,omitempty"`
+}
+
+// applyAuthParameters adds the mongod/mongos server parameters derived from the auth spec
+func applyAuthParameters(config map[string]interface{}, auth mongodbv1alpha1.AuthSpec) {
+	if auth.OIDC == nil || !auth.OIDC.Enabled {
+		return
+	}
+
+	providers, err := BuildOIDCIdentityProviders(auth.OIDC)
+	if err != nil {
+		return
+	}
+
+	setConfigValue(config, "setParameter.authenticationMechanisms", "SCRAM-SHA-1,SCRAM-SHA-256,MONGODB-OIDC")
+	setConfigValue(config, "setParameter.oidcIdentityProviders", providers)
+}
+
+// BuildOIDCIdentityProviders renders the oidcIdentityProviders server parameter value
+func BuildOIDCIdentityProviders(oidc *mongodbv1alpha1.OIDCSpec) (string, error) {
+	providers := make([]oidcIdentityProvider, 0, len(oidc.Providers))
+	for _, p := range oidc.Providers {
+		useAuthorizationClaim := true
+		if p.UseAuthorizationClaim != nil {
+			useAuthorizationClaim = *p.UseAuthorizationClaim
+		}
+
+		authorizationClaim := p.AuthorizationClaim
+		if useAuthorizationClaim && authorizationClaim == "" {
+			authorizationClaim = "groups"
+		}
+
+		providers = append(providers, oidcIdentityProvider{
+			Issuer:                p.Issuer,
+			Audience:              p.Audience,
+			AuthNamePrefix:        p.AuthNamePrefix,
+			ClientID:              p.ClientID,
+			MatchPattern:          p.MatchPattern,
+			PrincipalName:         p.PrincipalNameClaim,
+			AuthorizationClaim:    authorizationClaim,
+			UseAuthorizationClaim: useAuthorizationClaim,
+			SupportsHumanFlows:    p.SupportsHumanFlows,
+			RequestScopes:         p.RequestScopes,
+		})
+	}
+
+	out, err := json.Marshal(providers)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OIDC identity providers: %w", err)
+	}
+	return string(out), nil
+}
+
+// ValidateAuth checks the auth spec against the requested MongoDB version
+func ValidateAuth(auth mongodbv1alpha1.AuthSpec, version string) error {
+	if auth.OIDC == nil || !auth.OIDC.Enabled {
+		return nil
+	}
+
+	if !versionAtLeast(version, 7, 0) {
+		return fmt.Errorf("OIDC authentication requires MongoDB 7.0 or later, got %s", version)
+	}
+
+	if len(auth.OIDC.Providers) == 0 {
+		return fmt.Errorf("OIDC authentication requires at least one provider")
+	}
+
+	prefixes := make(map[string]bool, len(auth.OIDC.Providers))
+	for _, p := range auth.OIDC.Providers {
+		if prefixes[p.AuthNamePrefix] {
+			return fmt.Errorf("duplicate OIDC authNamePrefix %q", p.AuthNamePrefix)
+		}
+		prefixes[p.AuthNamePrefix] = true
+
+		if len(auth.OIDC.Providers) > 1 && p.MatchPattern == "" {
+			return fmt.Errorf("OIDC provider %q requires matchPattern when multiple providers are configured", p.Issuer)
+		}
+		if p.SupportsHumanFlows && p.ClientID == "" {
+			return fmt.Errorf("OIDC provider %q requires clientId when supportsHumanFlows is enabled", p.Issuer)
+		}
+	}
+
+	return nil
+}
+
+// versionAtLeast reports whether a "major.minor[.patch]" version is >= major.minor
+func versionAtLeast(version string, major, minor int) bool {
+	vMajor, vMinor, ok := parseReleaseSeries(version)
+	if !ok {
+		return false
+	}
+
+	if vMajor != major {
+		return vMajor > major
+	}
+	return vMinor >= minor
+}
+
+// parseReleaseSeries returns the major and minor version of a "major.minor[.patch]" version
+func parseReleaseSeries(version string) (major, minor int, ok bool) {
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
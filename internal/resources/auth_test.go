@@ -0,0 +1,166 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testOIDCSpec() *mongodbv1alpha1.OIDCSpec {
+	return &mongodbv1alpha1.OIDCSpec{
+		Enabled: true,
+		Providers: []mongodbv1alpha1.OIDCProviderSpec{
+			{
+				Issuer:             "https://login.example.com",
+				Audience:           "mongodb",
+				AuthNamePrefix:     "corp",
+				PrincipalNameClaim: "sub",
+			},
+		},
+	}
+}
+
+func TestBuildOIDCIdentityProviders(t *testing.T) {
+	out, err := BuildOIDCIdentityProviders(testOIDCSpec())
+	require.NoError(t, err)
+
+	var providers []map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(out), &providers))
+	require.Len(t, providers, 1)
+
+	assert.Equal(t, "https://login.example.com", providers[0]["issuer"])
+	assert.Equal(t, "mongodb", providers[0]["audience"])
+	assert.Equal(t, "corp", providers[0]["authNamePrefix"])
+	assert.Equal(t, "sub", providers[0]["principalName"])
+	assert.Equal(t, "groups", providers[0]["authorizationClaim"])
+	assert.Equal(t, true, providers[0]["useAuthorizationClaim"])
+}
+
+func TestBuildReplicaSetStatefulSetWithOIDC(t *testing.T) {
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-mongodb",
+			Namespace: "default",
+		},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members:        3,
+			ReplicaSetName: "rs0",
+			Version:        mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+			Auth:           mongodbv1alpha1.AuthSpec{OIDC: testOIDCSpec()},
+		},
+	}
+
+	config := BuildReplicaSetConfig(mdb)
+
+	assert.Contains(t, config, "authenticationMechanisms: SCRAM-SHA-1,SCRAM-SHA-256,MONGODB-OIDC")
+	assert.Contains(t, config, "oidcIdentityProviders: '[")
+}
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name    string
+		auth    mongodbv1alpha1.AuthSpec
+		version string
+		wantErr bool
+	}{
+		{
+			name:    "oidc disabled",
+			auth:    mongodbv1alpha1.AuthSpec{},
+			version: "6.0",
+			wantErr: false,
+		},
+		{
+			name:    "oidc on supported version",
+			auth:    mongodbv1alpha1.AuthSpec{OIDC: testOIDCSpec()},
+			version: "7.0.5",
+			wantErr: false,
+		},
+		{
+			name:    "oidc on unsupported version",
+			auth:    mongodbv1alpha1.AuthSpec{OIDC: testOIDCSpec()},
+			version: "6.0",
+			wantErr: true,
+		},
+		{
+			name: "multiple providers without matchPattern",
+			auth: mongodbv1alpha1.AuthSpec{OIDC: &mongodbv1alpha1.OIDCSpec{
+				Enabled: true,
+				Providers: []mongodbv1alpha1.OIDCProviderSpec{
+					{Issuer: "https://a.example.com", Audience: "a", AuthNamePrefix: "a"},
+					{Issuer: "https://b.example.com", Audience: "b", AuthNamePrefix: "b"},
+				},
+			}},
+			version: "8.0",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAuth(tt.auth, tt.version)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAdminSecretName(t *testing.T) {
+	assert.Equal(t, "my-mongodb-admin", AdminSecretName("my-mongodb", mongodbv1alpha1.AuthSpec{}))
+
+	auth := mongodbv1alpha1.AuthSpec{
+		AdminCredentialsSecretRef: mongodbv1alpha1.CredentialsSecretRef{Name: "custom-admin"},
+	}
+	assert.Equal(t, "custom-admin", AdminSecretName("my-mongodb", auth))
+	assert.False(t, IsAdminSecretGenerated(auth))
+}
+
+func TestBuildAdminSecret(t *testing.T) {
+	secret := BuildAdminSecret("my-mongodb", "default")
+
+	assert.Equal(t, "my-mongodb-admin", secret.Name)
+	assert.Equal(t, "default", secret.Namespace)
+	assert.Equal(t, AdminUsername, string(secret.Data["username"]))
+	assert.Len(t, secret.Data["password"], adminPasswordLength)
+
+	// Passwords must be unique per secret
+	other := BuildAdminSecret("my-mongodb", "default")
+	assert.NotEqual(t, secret.Data["password"], other.Data["password"])
+}
+
+func TestCredentialKeys(t *testing.T) {
+	usernameKey, passwordKey := CredentialKeys(mongodbv1alpha1.CredentialsSecretRef{Name: "creds"})
+	assert.Equal(t, "username", usernameKey)
+	assert.Equal(t, "password", passwordKey)
+
+	usernameKey, passwordKey = CredentialKeys(mongodbv1alpha1.CredentialsSecretRef{
+		Name:        "creds",
+		UsernameKey: "MONGO_USER",
+		PasswordKey: "MONGO_PASSWORD",
+	})
+	assert.Equal(t, "MONGO_USER", usernameKey)
+	assert.Equal(t, "MONGO_PASSWORD", passwordKey)
+}
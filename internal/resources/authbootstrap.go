@@ -0,0 +1,163 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/pkg/connstring"
+)
+
+const (
+	// AuthBootstrapExec creates the admin user by running mongosh in the primary through pods/exec
+	AuthBootstrapExec = "Exec"
+	// AuthBootstrapJob creates the admin user from a one-shot Job, which authenticates to the
+	// replica set storing the users with the keyfile, without pods/exec
+	AuthBootstrapJob = "Job"
+
+	// authBootstrapContainerName is the name of the container of the admin bootstrap Job
+	authBootstrapContainerName = "bootstrap-admin"
+)
+
+// JobAuthBootstrap reports whether the admin user is created by the bootstrap Job
+func JobAuthBootstrap(auth mongodbv1alpha1.AuthSpec) bool {
+	return auth.Bootstrap == AuthBootstrapJob
+}
+
+// AuthBootstrapJobName returns the name of the Job creating the admin user of a cluster
+func AuthBootstrapJobName(clusterName string) string {
+	return clusterName + "-bootstrap-admin"
+}
+
+// authBootstrapScript creates the admin user unless it exists. mongosh reads the keyfile and the
+// admin credentials from their files, out of its arguments and environment, and authenticates as
+// the internal __system user. A keyfile listing several keys during a rotation authenticates with
+// the first one.
+func authBootstrapScript(uri string) string {
+	return fmt.Sprintf(`set -e
+mongosh --nodb --quiet --eval '
+    const fs = require("fs");
+    const read = (file) => fs.readFileSync("%[1]s/" + file, "utf8");
+    const key = read("%[2]s").trim().split("\n")[0].replace(/^-\s*/, "").trim();
+    const username = fs.existsSync("%[1]s/%[3]s") ? read("%[3]s") : "%[4]s";
+
+    const conn = new Mongo("%[5]s");
+    conn.getDB("local").auth("__system", key);
+    const admin = conn.getDB("admin");
+    if (admin.getUser(username) !== null) {
+        print("Admin user " + username + " already exists");
+    } else {
+        admin.createUser({user: username, pwd: read("%[6]s"), roles: [{role: "root", db: "admin"}]});
+        print("Admin user " + username + " created");
+    }
+'
+`, JobCredentialsMountPath, credentialFileKeyfile, credentialFileAdminUsername, AdminUsername, uri, credentialFileAdminPassword)
+}
+
+// BuildAuthBootstrapJob creates the Job creating the admin user of a replica set through its members
+func BuildAuthBootstrapJob(mdb *mongodbv1alpha1.MongoDB) *batchv1.Job {
+	uri := connstring.ConnString{Hosts: MemberHosts(mdb), ReplicaSet: mdb.Spec.ReplicaSetName}
+	return buildAuthBootstrapJob(mdb.Name, mdb.Namespace, getMongoDBImage(mdb.Spec.Version), mdb.Spec.Auth, mdb.Spec.TLS, uri)
+}
+
+// BuildShardedAuthBootstrapJob creates the Job creating the admin user of a sharded cluster on
+// its config servers, which store the users mongos authenticates
+func BuildShardedAuthBootstrapJob(mdbsh *mongodbv1alpha1.MongoDBSharded) *batchv1.Job {
+	uri := connstring.ConnString{Hosts: configServerHosts(mdbsh), ReplicaSet: mdbsh.Name + "-cfg"}
+	return buildAuthBootstrapJob(mdbsh.Name, mdbsh.Namespace, getMongoDBImage(mdbsh.Spec.Version), mdbsh.Spec.Auth, mdbsh.Spec.TLS, uri)
+}
+
+// buildAuthBootstrapJob creates the admin bootstrap Job of a cluster reached through uri, which
+// holds no credentials. The keyfile and the admin credentials are mounted below
+// JobCredentialsMountPath.
+func buildAuthBootstrapJob(clusterName, namespace, image string, auth mongodbv1alpha1.AuthSpec, tls *mongodbv1alpha1.TLSSpec, uri connstring.ConnString) *batchv1.Job {
+	labels := buildLabels(clusterName, "bootstrap-admin")
+	backoff := int32(6)
+	ttl := int32(86400)
+
+	if TLSEnabled(tls) {
+		uri.TLS = true
+		uri.TLSCAFile = BackupTLSMountPath + "/ca.crt"
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AuthBootstrapJobName(clusterName),
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoff,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyOnFailure,
+					Containers: []corev1.Container{
+						{
+							Name:    authBootstrapContainerName,
+							Image:   image,
+							Command: []string{"/bin/sh", "-c"},
+							Args:    []string{authBootstrapScript(uri.String())},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("10m"),
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	usernameKey, passwordKey := CredentialKeys(auth.AdminCredentialsSecretRef)
+	adminSecret := AdminSecretName(clusterName, auth)
+	addJobCredentials(&job.Spec.Template.Spec, []credentialFile{
+		{secret: KeyfileSecretName(clusterName, auth), key: KeyfileSecretKey(auth), path: credentialFileKeyfile},
+		{secret: adminSecret, key: usernameKey, path: credentialFileAdminUsername, optional: boolPtr(true)},
+		{secret: adminSecret, key: passwordKey, path: credentialFileAdminPassword},
+	})
+	if TLSEnabled(tls) {
+		AddBackupTLS(job, TLSSecretName(clusterName, tls))
+	}
+	return job
+}
+
+// configServerHosts returns the host:port of the config server members
+func configServerHosts(mdbsh *mongodbv1alpha1.MongoDBSharded) []string {
+	hosts := make([]string, 0, mdbsh.Spec.ConfigServer.Members)
+	for i := int32(0); i < mdbsh.Spec.ConfigServer.Members; i++ {
+		host := fmt.Sprintf("%s-cfg-%d.%s", mdbsh.Name, i, ServiceFQDN(mdbsh.Name+"-cfg-headless", mdbsh.Namespace, mdbsh.Spec.ClusterDomain))
+		hosts = append(hosts, net.JoinHostPort(host, strconv.Itoa(configServerPort)))
+	}
+	return hosts
+}
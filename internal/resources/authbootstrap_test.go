@@ -0,0 +1,86 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestAuthBootstrapJob(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ReplicaSetName = "rs0"
+	mdb.Spec.Auth.Bootstrap = AuthBootstrapJob
+	mdb.Spec.Auth.AdminCredentialsSecretRef.Name = "my-admin"
+	mdb.Spec.Auth.AdminCredentialsSecretRef.PasswordKey = "pass"
+	assert.False(t, JobAuthBootstrap(mongodbv1alpha1.AuthSpec{}), "pods/exec by default")
+	assert.True(t, JobAuthBootstrap(mdb.Spec.Auth))
+
+	job := BuildAuthBootstrapJob(mdb)
+	assert.Equal(t, "my-mongodb-bootstrap-admin", job.Name)
+	pod := job.Spec.Template.Spec
+	require.Len(t, pod.Containers, 1)
+	bootstrap := pod.Containers[0]
+	assert.Equal(t, getMongoDBImage(mdb.Spec.Version), bootstrap.Image)
+	assert.Equal(t, corev1.RestartPolicyOnFailure, pod.RestartPolicy)
+
+	script := bootstrap.Args[0]
+	assert.Contains(t, script, `new Mongo("mongodb://my-mongodb-0.my-mongodb-headless.default.svc.cluster.local:27017,`)
+	assert.Contains(t, script, "replicaSet=rs0")
+	assert.Contains(t, script, `auth("__system", key)`)
+	assert.Contains(t, script, "createUser")
+
+	// The credentials are files, out of the environment of the container
+	assert.Empty(t, bootstrap.Env)
+	assert.Contains(t, bootstrap.VolumeMounts, corev1.VolumeMount{Name: "credentials", MountPath: JobCredentialsMountPath, ReadOnly: true})
+	sources := pod.Volumes[0].Projected.Sources
+	require.Len(t, sources, 3)
+	assert.Equal(t, "my-mongodb-keyfile", sources[0].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "keyfile", Path: credentialFileKeyfile}}, sources[0].Secret.Items)
+	assert.Equal(t, "my-admin", sources[1].Secret.Name)
+	assert.True(t, *sources[1].Secret.Optional, "the username defaults to admin")
+	assert.Equal(t, []corev1.KeyToPath{{Key: "pass", Path: credentialFileAdminPassword}}, sources[2].Secret.Items)
+
+	// The members only run mongod, the bootstrap runs apart from them
+	assert.Len(t, BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers, 1)
+}
+
+func TestAuthBootstrapJobTLS(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Auth.Bootstrap = AuthBootstrapJob
+	mdb.Spec.TLS = &mongodbv1alpha1.TLSSpec{Enabled: true}
+
+	job := BuildAuthBootstrapJob(mdb)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args[0], "tls=true&tlsCAFile=%2Fetc%2Fmongodb-backup%2Ftls%2Fca.crt")
+	assert.Contains(t, volumeNames(job.Spec.Template.Spec.Volumes), "cluster-tls")
+}
+
+func TestShardedAuthBootstrapJob(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Auth.Bootstrap = AuthBootstrapJob
+
+	// The users of a sharded cluster are stored on the config servers
+	script := BuildShardedAuthBootstrapJob(mdbsh).Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, "my-sharded-cfg-0.my-sharded-cfg-headless.default.svc.cluster.local:27019")
+	assert.Contains(t, script, "replicaSet=my-sharded-cfg")
+	assert.Contains(t, BuildMongosConfigMap(mdbsh).Data[MongosConfigKey], "my-sharded-cfg/my-sharded-cfg-0.my-sharded-cfg-headless")
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// defaultCPUUtilization is the CPU target used when no metric is configured
+const defaultCPUUtilization = 80
+
+// MongosAutoScalingEnabled reports whether the mongos Deployment is scaled by an HPA
+func MongosAutoScalingEnabled(mdbsh *mongodbv1alpha1.MongoDBSharded) bool {
+	return mdbsh.Spec.Mongos.AutoScaling != nil && mdbsh.Spec.Mongos.AutoScaling.Enabled
+}
+
+// BuildMongosHPA creates the HorizontalPodAutoscaler of the mongos Deployment.
+// MinReplicas falls back to spec.mongos.replicas and CPU utilization of 80% is used without metrics.
+func BuildMongosHPA(mdbsh *mongodbv1alpha1.MongoDBSharded) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	spec := mdbsh.Spec.Mongos.AutoScaling
+
+	minReplicas := spec.MinReplicas
+	if minReplicas == 0 {
+		minReplicas = mdbsh.Spec.Mongos.Replicas
+	}
+	if spec.MaxReplicas < minReplicas {
+		return nil, fmt.Errorf("mongos autoScaling maxReplicas (%d) must not be lower than minReplicas (%d)", spec.MaxReplicas, minReplicas)
+	}
+
+	metrics, err := buildHPAMetrics(spec.Metrics)
+	if err != nil {
+		return nil, err
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      mdbsh.Name + "-mongos",
+			Namespace: mdbsh.Namespace,
+			Labels:    buildLabels(mdbsh.Name, "mongos"),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       mdbsh.Name + "-mongos",
+			},
+			MinReplicas: int32Ptr(minReplicas),
+			MaxReplicas: spec.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}, nil
+}
+
+// buildHPAMetrics converts the scaling metrics of the spec.
+// Custom metrics are per-pod metrics served by a metrics adapter such as prometheus-adapter.
+func buildHPAMetrics(metrics []mongodbv1alpha1.AutoScalingMetric) ([]autoscalingv2.MetricSpec, error) {
+	if len(metrics) == 0 {
+		return []autoscalingv2.MetricSpec{buildResourceMetric(corev1.ResourceCPU, defaultCPUUtilization)}, nil
+	}
+
+	out := make([]autoscalingv2.MetricSpec, 0, len(metrics))
+	for _, m := range metrics {
+		switch m.Type {
+		case "cpu":
+			out = append(out, buildResourceMetric(corev1.ResourceCPU, m.Target))
+		case "memory":
+			out = append(out, buildResourceMetric(corev1.ResourceMemory, m.Target))
+		case "custom":
+			if m.CustomMetric == nil || m.CustomMetric.Name == "" {
+				return nil, fmt.Errorf("custom autoScaling metric requires customMetric.name")
+			}
+			out = append(out, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.PodsMetricSourceType,
+				Pods: &autoscalingv2.PodsMetricSource{
+					Metric: autoscalingv2.MetricIdentifier{Name: m.CustomMetric.Name},
+					Target: autoscalingv2.MetricTarget{
+						Type:         autoscalingv2.AverageValueMetricType,
+						AverageValue: resource.NewQuantity(int64(m.Target), resource.DecimalSI),
+					},
+				},
+			})
+		default:
+			return nil, fmt.Errorf("unsupported autoScaling metric type %q", m.Type)
+		}
+	}
+	return out, nil
+}
+
+func buildResourceMetric(name corev1.ResourceName, utilization int32) autoscalingv2.MetricSpec {
+	return autoscalingv2.MetricSpec{
+		Type: autoscalingv2.ResourceMetricSourceType,
+		Resource: &autoscalingv2.ResourceMetricSource{
+			Name: name,
+			Target: autoscalingv2.MetricTarget{
+				Type:               autoscalingv2.UtilizationMetricType,
+				AverageUtilization: int32Ptr(utilization),
+			},
+		},
+	}
+}
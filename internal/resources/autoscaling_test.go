@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestMongosAutoScalingEnabled(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	assert.False(t, MongosAutoScalingEnabled(mdbsh))
+
+	mdbsh.Spec.Mongos.AutoScaling = &mongodbv1alpha1.AutoScalingSpec{MaxReplicas: 5}
+	assert.False(t, MongosAutoScalingEnabled(mdbsh))
+
+	mdbsh.Spec.Mongos.AutoScaling.Enabled = true
+	assert.True(t, MongosAutoScalingEnabled(mdbsh))
+}
+
+func TestBuildMongosHPADefaults(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.AutoScaling = &mongodbv1alpha1.AutoScalingSpec{Enabled: true, MaxReplicas: 5}
+	hpa, err := BuildMongosHPA(mdbsh)
+	require.NoError(t, err)
+
+	assert.Equal(t, "my-sharded-mongos", hpa.Name)
+	assert.Equal(t, "Deployment", hpa.Spec.ScaleTargetRef.Kind)
+	assert.Equal(t, "my-sharded-mongos", hpa.Spec.ScaleTargetRef.Name)
+	assert.Equal(t, int32(2), *hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(5), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 1)
+	assert.Equal(t, corev1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, int32(80), *hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+}
+
+func TestBuildMongosHPAMetrics(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.AutoScaling = &mongodbv1alpha1.AutoScalingSpec{
+		Enabled:     true,
+		MinReplicas: 3,
+		MaxReplicas: 10,
+		Metrics: []mongodbv1alpha1.AutoScalingMetric{
+			{Type: "memory", Target: 70},
+			{Type: "custom", Target: 500, CustomMetric: &mongodbv1alpha1.CustomMetricSpec{Name: "mongodb_connections_current"}},
+		},
+	}
+	hpa, err := BuildMongosHPA(mdbsh)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(3), *hpa.Spec.MinReplicas)
+	require.Len(t, hpa.Spec.Metrics, 2)
+	assert.Equal(t, corev1.ResourceMemory, hpa.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, autoscalingv2.PodsMetricSourceType, hpa.Spec.Metrics[1].Type)
+	assert.Equal(t, "mongodb_connections_current", hpa.Spec.Metrics[1].Pods.Metric.Name)
+	assert.Equal(t, int64(500), hpa.Spec.Metrics[1].Pods.Target.AverageValue.Value())
+}
+
+func TestBuildMongosHPAInvalid(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.AutoScaling = &mongodbv1alpha1.AutoScalingSpec{Enabled: true, MaxReplicas: 1}
+	_, err := BuildMongosHPA(mdbsh)
+	assert.Error(t, err)
+
+	mdbsh.Spec.Mongos.AutoScaling = &mongodbv1alpha1.AutoScalingSpec{
+		Enabled:     true,
+		MaxReplicas: 5,
+		Metrics:     []mongodbv1alpha1.AutoScalingMetric{{Type: "custom", Target: 10}},
+	}
+	_, err = BuildMongosHPA(mdbsh)
+	assert.Error(t, err)
+}
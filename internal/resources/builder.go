@@ -20,6 +20,8 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"strconv"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -32,10 +34,12 @@ import (
 )
 
 const (
-	mongoDBPort   = 27017
-	metricsPort   = 9216
-	defaultImage  = "mongo:8.2"
-	exporterImage = "percona/mongodb_exporter:0.40"
+	mongoDBPort      = 27017
+	shardPort        = 27018
+	configServerPort = 27019
+	metricsPort      = 9216
+	defaultImage     = "mongo:8.2"
+	exporterImage    = "percona/mongodb_exporter:0.40"
 )
 
 // Helper functions
@@ -49,11 +53,50 @@ func generateRandomKey(length int) string {
 	return base64.StdEncoding.EncodeToString(bytes)
 }
 
+// DefaultStorageClassName is the StorageClass of the data volumes of clusters without
+// spec.storage.storageClassName, set from the operator --default-storage-class flag.
+// Empty means the default StorageClass of the Kubernetes cluster.
+var DefaultStorageClassName string
+
+// buildStorageClassName returns the StorageClass of the data volumes, nil for the cluster default
+func buildStorageClassName(spec mongodbv1alpha1.StorageSpec) *string {
+	name := spec.StorageClassName
+	if name == "" {
+		name = OperatorConfig().StorageClassName
+	}
+	if name == "" {
+		name = DefaultStorageClassName
+	}
+	if name == "" {
+		return nil
+	}
+	return &name
+}
+
+// ReplicaSetPort returns the port the members of a replica set listen on
+func ReplicaSetPort(mdb *mongodbv1alpha1.MongoDB) int32 {
+	if mdb.Spec.Port != 0 {
+		return mdb.Spec.Port
+	}
+	return mongoDBPort
+}
+
 func getMongoDBImage(version mongodbv1alpha1.MongoDBVersion) string {
+	image := mongoDBImage(version.Version)
 	if version.Image != "" {
-		return version.Image
+		image = version.Image
 	}
-	return fmt.Sprintf("mongo:%s", version.Version)
+	return pinImageDigest(image, version.ImageDigest)
+}
+
+// pinImageDigest references an image by digest. The tag is kept for readability, the container
+// runtime pulls by the digest. A digest already in the image is replaced.
+func pinImageDigest(image, digest string) string {
+	if digest == "" {
+		return image
+	}
+	name, _, _ := strings.Cut(image, "@")
+	return name + "@" + digest
 }
 
 func buildLabels(name, component string) map[string]string {
@@ -73,24 +116,47 @@ func buildResourceRequirements(spec mongodbv1alpha1.ResourcesSpec) corev1.Resour
 }
 
 func buildDefaultSecurityContext() *corev1.PodSecurityContext {
-	return &corev1.PodSecurityContext{
-		FSGroup:      int64Ptr(999),
-		RunAsUser:    int64Ptr(999),
-		RunAsGroup:   int64Ptr(999),
-		RunAsNonRoot: boolPtr(true),
+	fsGroupChangePolicy := corev1.FSGroupChangeOnRootMismatch
+	sc := &corev1.PodSecurityContext{
+		RunAsNonRoot:        boolPtr(true),
+		FSGroupChangePolicy: &fsGroupChangePolicy,
+		SeccompProfile:      &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	// On OpenShift the SCC assigns the UID and the fsGroup from the namespace range
+	if !OpenShiftCompatibility {
+		sc.FSGroup = int64Ptr(mongoDBUID)
+		sc.RunAsUser = int64Ptr(mongoDBUID)
+		sc.RunAsGroup = int64Ptr(mongoDBUID)
 	}
+	return sc
 }
 
 func buildDefaultContainerSecurityContext() *corev1.SecurityContext {
-	return &corev1.SecurityContext{
+	sc := &corev1.SecurityContext{
 		RunAsNonRoot:             boolPtr(true),
-		RunAsUser:                int64Ptr(999),
 		AllowPrivilegeEscalation: boolPtr(false),
-		ReadOnlyRootFilesystem:   boolPtr(false),
+		ReadOnlyRootFilesystem:   boolPtr(true),
 		Capabilities: &corev1.Capabilities{
 			Drop: []corev1.Capability{"ALL"},
 		},
 	}
+	if !OpenShiftCompatibility {
+		sc.RunAsUser = int64Ptr(mongoDBUID)
+	}
+	return sc
+}
+
+// buildKeyfileInitSecurityContext returns the security context of the init container copying the keyfile
+func buildKeyfileInitSecurityContext() *corev1.SecurityContext {
+	sc := &corev1.SecurityContext{
+		RunAsNonRoot:             boolPtr(true),
+		AllowPrivilegeEscalation: boolPtr(false),
+	}
+	if !OpenShiftCompatibility {
+		sc.RunAsUser = int64Ptr(mongoDBUID)
+		sc.RunAsGroup = int64Ptr(mongoDBUID)
+	}
+	return sc
 }
 
 // BuildKeyfileSecret creates a keyfile secret for MongoDB internal auth
@@ -125,10 +191,7 @@ func BuildShardedKeyfileSecret(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.Se
 
 // BuildMongoDBConfigMap creates a ConfigMap for MongoDB configuration
 func BuildMongoDBConfigMap(mdb *mongodbv1alpha1.MongoDB) *corev1.ConfigMap {
-	readinessScript := `#!/bin/bash
-set -e
-mongosh --quiet --eval "db.adminCommand('ping')" > /dev/null 2>&1
-`
+	readinessScript := "#!/bin/bash\n" + memberReadinessScript(ReplicaSetPort(mdb))
 
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -144,7 +207,7 @@ mongosh --quiet --eval "db.adminCommand('ping')" > /dev/null 2>&1
 
 // BuildHeadlessService creates a headless service for StatefulSet
 func BuildHeadlessService(mdb *mongodbv1alpha1.MongoDB) *corev1.Service {
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mdb.Name + "-headless",
 			Namespace: mdb.Namespace,
@@ -154,16 +217,19 @@ func BuildHeadlessService(mdb *mongodbv1alpha1.MongoDB) *corev1.Service {
 			ClusterIP: "None",
 			Selector:  buildLabels(mdb.Name, "replicaset"),
 			Ports: []corev1.ServicePort{
-				{Name: "mongodb", Port: mongoDBPort, TargetPort: intstr.FromInt(mongoDBPort)},
+				{Name: "mongodb", Port: ReplicaSetPort(mdb), TargetPort: intstr.FromInt(int(ReplicaSetPort(mdb)))},
 			},
 			PublishNotReadyAddresses: true,
 		},
 	}
+	applyServiceMetadata(svc, mdb.Spec.Service)
+	applyServiceMeshPorts(svc, mdb.Spec.ServiceMesh)
+	return svc
 }
 
 // BuildClientService creates a client service for MongoDB access
 func BuildClientService(mdb *mongodbv1alpha1.MongoDB) *corev1.Service {
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mdb.Name,
 			Namespace: mdb.Namespace,
@@ -173,34 +239,29 @@ func BuildClientService(mdb *mongodbv1alpha1.MongoDB) *corev1.Service {
 			Type:     corev1.ServiceTypeClusterIP,
 			Selector: buildLabels(mdb.Name, "replicaset"),
 			Ports: []corev1.ServicePort{
-				{Name: "mongodb", Port: mongoDBPort, TargetPort: intstr.FromInt(mongoDBPort)},
-				{Name: "metrics", Port: metricsPort, TargetPort: intstr.FromInt(metricsPort)},
+				{Name: "mongodb", Port: ReplicaSetPort(mdb), TargetPort: intstr.FromInt(int(ReplicaSetPort(mdb)))},
+				{Name: "metrics", Port: exporterPort(mdb.Spec.Monitoring), TargetPort: intstr.FromString("metrics")},
 			},
 		},
 	}
+	applyServiceMetadata(svc, mdb.Spec.Service)
+	applyServiceMeshPorts(svc, mdb.Spec.ServiceMesh)
+	return svc
 }
 
 // BuildReplicaSetStatefulSet creates a StatefulSet for MongoDB ReplicaSet
 func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSet {
 	labels := buildLabels(mdb.Name, "replicaset")
+	port := ReplicaSetPort(mdb)
 
-	// Build mongod args
-	args := []string{
-		"--replSet", mdb.Spec.ReplicaSetName,
-		"--bind_ip_all",
-		"--auth",
-		"--keyFile", "/etc/mongodb-keyfile/keyfile",
-	}
+	livenessCommand := pingCommand(port)
 
 	// Volumes
 	volumes := []corev1.Volume{
 		{
 			Name: "keyfile-secret",
 			VolumeSource: corev1.VolumeSource{
-				Secret: &corev1.SecretVolumeSource{
-					SecretName:  mdb.Name + "-keyfile",
-					DefaultMode: int32Ptr(0400),
-				},
+				Secret: buildKeyfileVolumeSource(mdb.Name, mdb.Spec.Auth),
 			},
 		},
 		{
@@ -229,7 +290,7 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 	}
 
 	// Init container to copy keyfile with correct permissions
-	// Runs as mongodb user (999) and uses FSGroup for proper file ownership
+	// Runs as the mongodb user and uses FSGroup for proper file ownership
 	initContainers := []corev1.Container{
 		{
 			Name:  "copy-keyfile",
@@ -242,12 +303,7 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 				{Name: "keyfile-secret", MountPath: "/keyfile-secret", ReadOnly: true},
 				{Name: "keyfile", MountPath: "/keyfile"},
 			},
-			SecurityContext: &corev1.SecurityContext{
-				RunAsUser:                int64Ptr(999),
-				RunAsGroup:               int64Ptr(999),
-				RunAsNonRoot:             boolPtr(true),
-				AllowPrivilegeEscalation: boolPtr(false),
-			},
+			SecurityContext: buildKeyfileInitSecurityContext(),
 		},
 	}
 
@@ -257,16 +313,18 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 			Name:  "mongodb",
 			Image: getMongoDBImage(mdb.Spec.Version),
 			Ports: []corev1.ContainerPort{
-				{Name: "mongodb", ContainerPort: mongoDBPort, Protocol: corev1.ProtocolTCP},
+				{Name: "mongodb", ContainerPort: port, Protocol: corev1.ProtocolTCP},
 			},
-			Args:            args,
 			VolumeMounts:    volumeMounts,
-			Resources:       buildResourceRequirements(mdb.Spec.Resources),
+			Resources:       buildResourceRequirements(mongodResources(mdb.Spec.Resources, mdb.Spec.ResourceProfile)),
 			SecurityContext: buildDefaultContainerSecurityContext(),
+			// The end of the log of a crashed mongod tells a corrupt dbPath from other failures
+			TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+			StartupProbe:             buildStartupProbe(port, memberStartupFailureThreshold),
 			LivenessProbe: &corev1.Probe{
 				ProbeHandler: corev1.ProbeHandler{
 					Exec: &corev1.ExecAction{
-						Command: []string{"mongosh", "--quiet", "--eval", "db.adminCommand('ping')"},
+						Command: livenessCommand,
 					},
 				},
 				InitialDelaySeconds: 30,
@@ -295,53 +353,8 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 		},
 	}
 
-	// Add exporter sidecar if monitoring enabled
-	if mdb.Spec.Monitoring != nil && mdb.Spec.Monitoring.Enabled {
-		exporterImg := exporterImage
-		if mdb.Spec.Monitoring.Exporter != nil && mdb.Spec.Monitoring.Exporter.Image != "" {
-			exporterImg = mdb.Spec.Monitoring.Exporter.Image
-		}
-
-		containers = append(containers, corev1.Container{
-			Name:  "exporter",
-			Image: exporterImg,
-			Ports: []corev1.ContainerPort{
-				{Name: "metrics", ContainerPort: metricsPort, Protocol: corev1.ProtocolTCP},
-			},
-			Args: []string{
-				"--collect-all",
-				"--compatible-mode",
-			},
-			Env: []corev1.EnvVar{
-				{
-					Name:  "MONGODB_URI",
-					Value: "mongodb://localhost:27017",
-				},
-			},
-			Resources: corev1.ResourceRequirements{
-				Requests: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("50m"),
-					corev1.ResourceMemory: resource.MustParse("64Mi"),
-				},
-				Limits: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("200m"),
-					corev1.ResourceMemory: resource.MustParse("256Mi"),
-				},
-			},
-		})
-	}
-
-	// Security context
-	securityContext := buildDefaultSecurityContext()
-	if mdb.Spec.Pod != nil && mdb.Spec.Pod.SecurityContext != nil {
-		securityContext = mdb.Spec.Pod.SecurityContext
-	}
-
 	// Storage class - use nil for cluster default if not specified
-	var storageClassName *string
-	if mdb.Spec.Storage.StorageClassName != "" {
-		storageClassName = &mdb.Spec.Storage.StorageClassName
-	}
+	storageClassName := buildStorageClassName(mdb.Spec.Storage)
 
 	// Storage size
 	storageSize := mdb.Spec.Storage.Size
@@ -349,7 +362,14 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 		storageSize = resource.MustParse("10Gi")
 	}
 
-	return &appsv1.StatefulSet{
+	// The pods of a multi-cluster replica set are numbered after those of the clusters before this one
+	firstMember, members := LocalMembers(mdb)
+	var ordinals *appsv1.StatefulSetOrdinals
+	if firstMember > 0 {
+		ordinals = &appsv1.StatefulSetOrdinals{Start: firstMember}
+	}
+
+	sts := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mdb.Name,
 			Namespace: mdb.Namespace,
@@ -357,7 +377,8 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 		},
 		Spec: appsv1.StatefulSetSpec{
 			ServiceName: mdb.Name + "-headless",
-			Replicas:    &mdb.Spec.Members,
+			Replicas:    &members,
+			Ordinals:    ordinals,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
@@ -370,11 +391,11 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 					Labels: labels,
 					Annotations: map[string]string{
 						"prometheus.io/scrape": "true",
-						"prometheus.io/port":   fmt.Sprintf("%d", metricsPort),
+						"prometheus.io/port":   fmt.Sprintf("%d", exporterPort(mdb.Spec.Monitoring)),
 					},
 				},
 				Spec: corev1.PodSpec{
-					SecurityContext: securityContext,
+					SecurityContext: buildDefaultSecurityContext(),
 					InitContainers:  initContainers,
 					Containers:      containers,
 					Volumes:         volumes,
@@ -399,6 +420,37 @@ func BuildReplicaSetStatefulSet(mdb *mongodbv1alpha1.MongoDB) *appsv1.StatefulSe
 			},
 		},
 	}
+
+	applyConfigFile(&sts.Spec.Template, "mongodb", ConfigMapName(mdb.Name), MongodConfigKey, BuildReplicaSetConfig(mdb))
+	applyMongoshConfig(&sts.Spec.Template, "mongodb", mdb.Spec.Telemetry)
+	if Standalone(mdb) {
+		applyStandalone(&sts.Spec.Template.Spec, "mongodb", livenessCommand)
+	} else {
+		applyPrimaryStepDown(&sts.Spec.Template.Spec, "mongodb", port)
+		applyMaintenanceMode(&sts.Spec.Template, "mongodb", MaintenanceMarkerPath(mdb))
+	}
+	applyEphemeralStorage(sts, mdb.Spec.Storage, "mongodb")
+	applyProbeSettings(&sts.Spec.Template.Spec, mdb.Spec.Pod, "mongodb")
+	applyUpdateStrategy(sts, mdb)
+
+	// Add exporter sidecar if monitoring enabled
+	if MonitoringEnabled(mdb.Spec.Monitoring) {
+		addExporterSidecar(&sts.Spec.Template.Spec, mdb.Name, mdb.Spec.Monitoring, mdb.Spec.TLS, port, mdb.Spec.ResourceProfile)
+	}
+
+	applyServiceMesh(&sts.Spec.Template, mdb.Spec.ServiceMesh, "mongodb",
+		meshPorts{listen: port, inbound: []int32{port}, outbound: []int32{port}})
+	applyRootFilesystem(&sts.Spec.Template.Spec, mdb.Spec.Pod, "mongodb")
+	applyNodeTuning(&sts.Spec.Template.Spec, mdb.Spec.Pod)
+	applyMemberSpreading(&sts.Spec.Template.Spec, mdb.Spec.Pod, labels)
+	applyServiceAccount(&sts.Spec.Template.Spec, mdb.Name, mdb.Spec.Pod)
+	applyPodScheduling(&sts.Spec.Template.Spec, mdb.Spec.Pod, "mongodb")
+	applyArchitecture(&sts.Spec.Template.Spec, mdb.Spec.Architecture)
+	applyTerminationGracePeriod(&sts.Spec.Template.Spec, mdb.Spec.Pod)
+	applyPodMetadata(&sts.Spec.Template, mdb.Spec.Pod)
+	applyRestart(&sts.Spec.Template, mdb.Annotations, RestartAnnotation)
+	applyPodExtensions(&sts.Spec.Template.Spec, mdb.Spec.Pod, "mongodb")
+	return sts
 }
 
 func buildDefaultAffinity(instanceName string) *corev1.Affinity {
@@ -424,7 +476,7 @@ func buildDefaultAffinity(instanceName string) *corev1.Affinity {
 // BuildConfigServerService creates a headless service for Config Server
 func BuildConfigServerService(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.Service {
 	labels := buildLabels(mdbsh.Name, "configsvr")
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mdbsh.Name + "-cfg-headless",
 			Namespace: mdbsh.Namespace,
@@ -439,32 +491,28 @@ func BuildConfigServerService(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.Ser
 			PublishNotReadyAddresses: true,
 		},
 	}
+	if MonitoringEnabled(mdbsh.Spec.Monitoring) {
+		svc.Spec.Ports = append(svc.Spec.Ports,
+			corev1.ServicePort{Name: "metrics", Port: exporterPort(mdbsh.Spec.Monitoring), TargetPort: intstr.FromString("metrics")})
+	}
+	applyServiceMetadata(svc, mdbsh.Spec.ConfigServer.Service)
+	applyServiceMeshPorts(svc, mdbsh.Spec.ServiceMesh)
+	return svc
 }
 
 // BuildConfigServerStatefulSet creates a StatefulSet for Config Server
 func BuildConfigServerStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.StatefulSet {
 	labels := buildLabels(mdbsh.Name, "configsvr")
 
-	args := []string{
-		"--configsvr",
-		"--replSet", mdbsh.Name + "-cfg",
-		"--bind_ip_all",
-		"--auth",
-		"--keyFile", "/etc/mongodb-keyfile/keyfile",
-	}
-
 	// Storage class - use nil for cluster default if not specified
-	var storageClassName *string
-	if mdbsh.Spec.ConfigServer.Storage.StorageClassName != "" {
-		storageClassName = &mdbsh.Spec.ConfigServer.Storage.StorageClassName
-	}
+	storageClassName := buildStorageClassName(mdbsh.Spec.ConfigServer.Storage)
 
 	storageSize := mdbsh.Spec.ConfigServer.Storage.Size
 	if storageSize.IsZero() {
 		storageSize = resource.MustParse("10Gi")
 	}
 
-	return &appsv1.StatefulSet{
+	sts := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mdbsh.Name + "-cfg",
 			Namespace: mdbsh.Namespace,
@@ -495,12 +543,7 @@ func BuildConfigServerStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1
 								{Name: "keyfile-secret", MountPath: "/keyfile-secret", ReadOnly: true},
 								{Name: "keyfile", MountPath: "/keyfile"},
 							},
-							SecurityContext: &corev1.SecurityContext{
-								RunAsUser:                int64Ptr(999),
-								RunAsGroup:               int64Ptr(999),
-								RunAsNonRoot:             boolPtr(true),
-								AllowPrivilegeEscalation: boolPtr(false),
-							},
+							SecurityContext: buildKeyfileInitSecurityContext(),
 						},
 					},
 					Containers: []corev1.Container{
@@ -510,8 +553,7 @@ func BuildConfigServerStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1
 							Ports: []corev1.ContainerPort{
 								{Name: "mongodb", ContainerPort: mongoDBPort},
 							},
-							Args:            args,
-							Resources:       buildResourceRequirements(mdbsh.Spec.ConfigServer.Resources),
+							Resources:       buildResourceRequirements(mongodResources(mdbsh.Spec.ConfigServer.Resources, mdbsh.Spec.ResourceProfile)),
 							SecurityContext: buildDefaultContainerSecurityContext(),
 							VolumeMounts: []corev1.VolumeMount{
 								{Name: "data", MountPath: "/data/configdb"},
@@ -523,10 +565,7 @@ func BuildConfigServerStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1
 						{
 							Name: "keyfile-secret",
 							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName:  mdbsh.Name + "-keyfile",
-									DefaultMode: int32Ptr(0400),
-								},
+								Secret: buildKeyfileVolumeSource(mdbsh.Name, mdbsh.Spec.Auth),
 							},
 						},
 						{
@@ -553,6 +592,33 @@ func BuildConfigServerStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1
 			},
 		},
 	}
+
+	applyConfigFile(&sts.Spec.Template, "mongodb", ConfigMapName(mdbsh.Name+"-cfg"), MongodConfigKey, BuildConfigServerConfig(mdbsh))
+	applyMongoshConfig(&sts.Spec.Template, "mongodb", mdbsh.Spec.Telemetry)
+	applyPrimaryStepDown(&sts.Spec.Template.Spec, "mongodb", configServerPort)
+	applyMemberProbes(&sts.Spec.Template.Spec, "mongodb", configServerPort)
+	applyEphemeralStorage(sts, mdbsh.Spec.ConfigServer.Storage, "mongodb")
+	applyProbeSettings(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod, "mongodb")
+
+	// Add exporter sidecar if monitoring enabled, config servers listen on 27019
+	if MonitoringEnabled(mdbsh.Spec.Monitoring) {
+		addExporterSidecar(&sts.Spec.Template.Spec, mdbsh.Name, mdbsh.Spec.Monitoring, mdbsh.Spec.TLS, configServerPort, mdbsh.Spec.ResourceProfile)
+	}
+
+	applyServiceMesh(&sts.Spec.Template, mdbsh.Spec.ServiceMesh, "mongodb",
+		meshPorts{listen: configServerPort, inbound: []int32{configServerPort}, outbound: shardedMemberPorts})
+	applyRootFilesystem(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod, "mongodb")
+	applyNodeTuning(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod)
+	applyMemberSpreading(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod, labels)
+	applyShardIsolation(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Isolation, mdbsh.Name, "configsvr")
+	applyServiceAccount(&sts.Spec.Template.Spec, mdbsh.Name, mdbsh.Spec.ConfigServer.Pod)
+	applyPodScheduling(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod, "mongodb")
+	applyArchitecture(&sts.Spec.Template.Spec, mdbsh.Spec.Architecture)
+	applyTerminationGracePeriod(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod)
+	applyPodMetadata(&sts.Spec.Template, mdbsh.Spec.ConfigServer.Pod)
+	applyRestart(&sts.Spec.Template, mdbsh.Annotations, RestartAnnotationFor(ComponentConfigServer))
+	applyPodExtensions(&sts.Spec.Template.Spec, mdbsh.Spec.ConfigServer.Pod, "mongodb")
+	return sts
 }
 
 // BuildShardService creates a headless service for a Shard
@@ -560,7 +626,7 @@ func BuildShardService(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32)
 	name := fmt.Sprintf("%s-shard-%d", mdbsh.Name, shardIndex)
 	labels := buildLabels(mdbsh.Name, fmt.Sprintf("shard-%d", shardIndex))
 
-	return &corev1.Service{
+	svc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name + "-headless",
 			Namespace: mdbsh.Namespace,
@@ -575,6 +641,13 @@ func BuildShardService(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32)
 			PublishNotReadyAddresses: true,
 		},
 	}
+	if MonitoringEnabled(mdbsh.Spec.Monitoring) {
+		svc.Spec.Ports = append(svc.Spec.Ports,
+			corev1.ServicePort{Name: "metrics", Port: exporterPort(mdbsh.Spec.Monitoring), TargetPort: intstr.FromString("metrics")})
+	}
+	applyServiceMetadata(svc, mdbsh.Spec.Shards.Service)
+	applyServiceMeshPorts(svc, mdbsh.Spec.ServiceMesh)
+	return svc
 }
 
 // BuildShardStatefulSet creates a StatefulSet for a Shard
@@ -582,26 +655,15 @@ func BuildShardStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int
 	name := fmt.Sprintf("%s-shard-%d", mdbsh.Name, shardIndex)
 	labels := buildLabels(mdbsh.Name, fmt.Sprintf("shard-%d", shardIndex))
 
-	args := []string{
-		"--shardsvr",
-		"--replSet", name,
-		"--bind_ip_all",
-		"--auth",
-		"--keyFile", "/etc/mongodb-keyfile/keyfile",
-	}
-
 	// Storage class - use nil for cluster default if not specified
-	var storageClassName *string
-	if mdbsh.Spec.Shards.Storage.StorageClassName != "" {
-		storageClassName = &mdbsh.Spec.Shards.Storage.StorageClassName
-	}
+	storageClassName := buildStorageClassName(mdbsh.Spec.Shards.Storage)
 
 	storageSize := mdbsh.Spec.Shards.Storage.Size
 	if storageSize.IsZero() {
 		storageSize = resource.MustParse("50Gi")
 	}
 
-	return &appsv1.StatefulSet{
+	sts := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
 			Namespace: mdbsh.Namespace,
@@ -632,12 +694,7 @@ func BuildShardStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int
 								{Name: "keyfile-secret", MountPath: "/keyfile-secret", ReadOnly: true},
 								{Name: "keyfile", MountPath: "/keyfile"},
 							},
-							SecurityContext: &corev1.SecurityContext{
-								RunAsUser:                int64Ptr(999),
-								RunAsGroup:               int64Ptr(999),
-								RunAsNonRoot:             boolPtr(true),
-								AllowPrivilegeEscalation: boolPtr(false),
-							},
+							SecurityContext: buildKeyfileInitSecurityContext(),
 						},
 					},
 					Containers: []corev1.Container{
@@ -647,8 +704,7 @@ func BuildShardStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int
 							Ports: []corev1.ContainerPort{
 								{Name: "mongodb", ContainerPort: mongoDBPort},
 							},
-							Args:            args,
-							Resources:       buildResourceRequirements(mdbsh.Spec.Shards.Resources),
+							Resources:       buildResourceRequirements(mongodResources(mdbsh.Spec.Shards.Resources, mdbsh.Spec.ResourceProfile)),
 							SecurityContext: buildDefaultContainerSecurityContext(),
 							VolumeMounts: []corev1.VolumeMount{
 								{Name: "data", MountPath: "/data/db"},
@@ -660,10 +716,7 @@ func BuildShardStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int
 						{
 							Name: "keyfile-secret",
 							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName:  mdbsh.Name + "-keyfile",
-									DefaultMode: int32Ptr(0400),
-								},
+								Secret: buildKeyfileVolumeSource(mdbsh.Name, mdbsh.Spec.Auth),
 							},
 						},
 						{
@@ -690,31 +743,59 @@ func BuildShardStatefulSet(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int
 			},
 		},
 	}
+
+	applyConfigFile(&sts.Spec.Template, "mongodb", ConfigMapName(name), MongodConfigKey, BuildShardConfig(mdbsh, shardIndex))
+	applyMongoshConfig(&sts.Spec.Template, "mongodb", mdbsh.Spec.Telemetry)
+	applyPrimaryStepDown(&sts.Spec.Template.Spec, "mongodb", shardPort)
+	applyMemberProbes(&sts.Spec.Template.Spec, "mongodb", shardPort)
+	applyEphemeralStorage(sts, mdbsh.Spec.Shards.Storage, "mongodb")
+	applyProbeSettings(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod, "mongodb")
+
+	// Add exporter sidecar if monitoring enabled, shards listen on 27018
+	if MonitoringEnabled(mdbsh.Spec.Monitoring) {
+		addExporterSidecar(&sts.Spec.Template.Spec, mdbsh.Name, mdbsh.Spec.Monitoring, mdbsh.Spec.TLS, shardPort, mdbsh.Spec.ResourceProfile)
+	}
+
+	applyServiceMesh(&sts.Spec.Template, mdbsh.Spec.ServiceMesh, "mongodb",
+		meshPorts{listen: shardPort, inbound: []int32{shardPort}, outbound: shardedMemberPorts})
+
+	// Per-shard placement refines the scheduling shared by all shards
+	applyRootFilesystem(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod, "mongodb")
+	applyNodeTuning(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod)
+	applyMemberSpreading(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod, labels)
+	applyShardIsolation(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Isolation, mdbsh.Name, fmt.Sprintf("shard-%d", shardIndex))
+	applyServiceAccount(&sts.Spec.Template.Spec, mdbsh.Name, mdbsh.Spec.Shards.Pod)
+	applyPodScheduling(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod, "mongodb")
+	applyTerminationGracePeriod(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod)
+	applyShardPlacement(&sts.Spec.Template, mdbsh.Spec.Shards, shardIndex)
+	applyArchitecture(&sts.Spec.Template.Spec, mdbsh.Spec.Architecture)
+	applyPodMetadata(&sts.Spec.Template, mdbsh.Spec.Shards.Pod)
+	applyRestart(&sts.Spec.Template, mdbsh.Annotations, RestartAnnotationFor(ComponentShards))
+	applyPodExtensions(&sts.Spec.Template.Spec, mdbsh.Spec.Shards.Pod, "mongodb")
+	return sts
 }
 
 // BuildMongosConfigMap creates a ConfigMap for Mongos configuration
 func BuildMongosConfigMap(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.ConfigMap {
-	// Build config server connection string
-	// Config servers use port 27019
-	var configHosts string
-	for i := int32(0); i < mdbsh.Spec.ConfigServer.Members; i++ {
-		if i > 0 {
-			configHosts += ","
-		}
-		configHosts += fmt.Sprintf("%s-cfg-%d.%s-cfg-headless.%s.svc.cluster.local:27019",
-			mdbsh.Name, i, mdbsh.Name, mdbsh.Namespace)
-	}
-
-	return &corev1.ConfigMap{
+	cm := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      mdbsh.Name + "-mongos-config",
+			Name:      ConfigMapName(mdbsh.Name + "-mongos"),
 			Namespace: mdbsh.Namespace,
 			Labels:    buildLabels(mdbsh.Name, "mongos"),
 		},
 		Data: map[string]string{
-			"configdb": fmt.Sprintf("%s-cfg/%s", mdbsh.Name, configHosts),
+			"configdb":      mongosConfigDB(mdbsh),
+			MongosConfigKey: BuildMongosConfig(mdbsh),
 		},
 	}
+	addMongoshConfig(cm.Data, mdbsh.Spec.Telemetry)
+	return cm
+}
+
+// mongosConfigDB returns the config server replica set connection string of the routers,
+// config servers use port 27019
+func mongosConfigDB(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	return fmt.Sprintf("%s-cfg/%s", mdbsh.Name, strings.Join(configServerHosts(mdbsh), ","))
 }
 
 // BuildMongosService creates a service for Mongos
@@ -736,8 +817,8 @@ func BuildMongosService(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.Service {
 			Type:     svcType,
 			Selector: labels,
 			Ports: []corev1.ServicePort{
-				{Name: "mongodb", Port: mongoDBPort, TargetPort: intstr.FromInt(mongoDBPort)},
-				{Name: "metrics", Port: metricsPort, TargetPort: intstr.FromInt(metricsPort)},
+				{Name: "mongodb", Port: MongosServicePort(mdbsh), TargetPort: intstr.FromInt(mongoDBPort)},
+				{Name: "metrics", Port: exporterPort(mdbsh.Spec.Monitoring), TargetPort: intstr.FromString("metrics")},
 			},
 		},
 	}
@@ -746,11 +827,23 @@ func BuildMongosService(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.Service {
 		if mdbsh.Spec.Mongos.Service.Annotations != nil {
 			svc.Annotations = mdbsh.Spec.Mongos.Service.Annotations
 		}
+		svc.Labels = mergeStringMaps(mdbsh.Spec.Mongos.Service.Labels, svc.Labels)
 		if mdbsh.Spec.Mongos.Service.LoadBalancerIP != "" {
 			svc.Spec.LoadBalancerIP = mdbsh.Spec.Mongos.Service.LoadBalancerIP
 		}
 	}
 
+	// Keep client connections on the node or in the zone of the client
+	switch MongosMode(mdbsh) {
+	case MongosModeDaemonSet:
+		policy := corev1.ServiceInternalTrafficPolicyLocal
+		svc.Spec.InternalTrafficPolicy = &policy
+	case MongosModePerZone:
+		distribution := corev1.ServiceTrafficDistributionPreferClose
+		svc.Spec.TrafficDistribution = &distribution
+	}
+
+	applyServiceMeshPorts(svc, mdbsh.Spec.ServiceMesh)
 	return svc
 }
 
@@ -758,81 +851,46 @@ func BuildMongosService(mdbsh *mongodbv1alpha1.MongoDBSharded) *corev1.Service {
 func BuildMongosDeployment(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.Deployment {
 	labels := buildLabels(mdbsh.Name, "mongos")
 
-	// Build config server connection string
-	// Config servers use port 27019
-	var configHosts string
-	for i := int32(0); i < mdbsh.Spec.ConfigServer.Members; i++ {
-		if i > 0 {
-			configHosts += ","
-		}
-		configHosts += fmt.Sprintf("%s-cfg-%d.%s-cfg-headless.%s.svc.cluster.local:27019",
-			mdbsh.Name, i, mdbsh.Name, mdbsh.Namespace)
-	}
-
-	args := []string{
-		"--configdb", fmt.Sprintf("%s-cfg/%s", mdbsh.Name, configHosts),
-		"--bind_ip_all",
-		"--keyFile", "/etc/mongodb-keyfile/keyfile",
-	}
-
 	containers := []corev1.Container{
 		{
 			Name:    "mongos",
-			Image:   getMongoDBImage(mdbsh.Spec.Version),
+			Image:   getMongosImage(mdbsh),
 			Command: []string{"mongos"},
-			Args:    args,
 			Ports: []corev1.ContainerPort{
 				{Name: "mongodb", ContainerPort: mongoDBPort},
 			},
-			Resources:       buildResourceRequirements(mdbsh.Spec.Mongos.Resources),
+			Resources:       buildResourceRequirements(mongosResources(mdbsh.Spec.Mongos.Resources, mdbsh.Spec.ResourceProfile)),
 			SecurityContext: buildDefaultContainerSecurityContext(),
 			VolumeMounts: []corev1.VolumeMount{
 				{Name: "keyfile", MountPath: "/etc/mongodb-keyfile", ReadOnly: true},
 			},
+			// Liveness only checks mongos itself, losing the config servers must not restart it
+			StartupProbe: buildStartupProbe(mongoDBPort, mongosStartupFailureThreshold),
 			LivenessProbe: &corev1.Probe{
 				ProbeHandler: corev1.ProbeHandler{
-					TCPSocket: &corev1.TCPSocketAction{
-						Port: intstr.FromInt(mongoDBPort),
+					Exec: &corev1.ExecAction{
+						Command: pingCommand(mongoDBPort),
 					},
 				},
 				InitialDelaySeconds: 30,
 				PeriodSeconds:       10,
+				TimeoutSeconds:      5,
+				FailureThreshold:    6,
 			},
 			ReadinessProbe: &corev1.Probe{
 				ProbeHandler: corev1.ProbeHandler{
 					Exec: &corev1.ExecAction{
-						Command: []string{"mongosh", "--quiet", "--eval", "db.adminCommand('ping')"},
+						Command: []string{"bash", "-c", mongosReadinessScript(mongoDBPort)},
 					},
 				},
 				InitialDelaySeconds: 10,
 				PeriodSeconds:       10,
-				TimeoutSeconds:      5,
+				TimeoutSeconds:      mongosReadinessTimeoutSeconds,
 			},
 		},
 	}
 
-	// Add exporter sidecar if monitoring enabled
-	if mdbsh.Spec.Monitoring != nil && mdbsh.Spec.Monitoring.Enabled {
-		containers = append(containers, corev1.Container{
-			Name:  "exporter",
-			Image: exporterImage,
-			Ports: []corev1.ContainerPort{
-				{Name: "metrics", ContainerPort: metricsPort},
-			},
-			Args: []string{"--collect-all", "--compatible-mode"},
-			Env: []corev1.EnvVar{
-				{Name: "MONGODB_URI", Value: "mongodb://localhost:27017"},
-			},
-			Resources: corev1.ResourceRequirements{
-				Requests: corev1.ResourceList{
-					corev1.ResourceCPU:    resource.MustParse("50m"),
-					corev1.ResourceMemory: resource.MustParse("64Mi"),
-				},
-			},
-		})
-	}
-
-	return &appsv1.Deployment{
+	deploy := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      mdbsh.Name + "-mongos",
 			Namespace: mdbsh.Namespace,
@@ -843,13 +901,7 @@ func BuildMongosDeployment(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.Deploy
 			Selector: &metav1.LabelSelector{
 				MatchLabels: labels,
 			},
-			Strategy: appsv1.DeploymentStrategy{
-				Type: appsv1.RollingUpdateDeploymentStrategyType,
-				RollingUpdate: &appsv1.RollingUpdateDeployment{
-					MaxUnavailable: &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
-					MaxSurge:       &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
-				},
-			},
+			Strategy: buildMongosStrategy(mdbsh.Spec.Mongos),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
@@ -868,12 +920,7 @@ func BuildMongosDeployment(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.Deploy
 								{Name: "keyfile-secret", MountPath: "/keyfile-secret", ReadOnly: true},
 								{Name: "keyfile", MountPath: "/keyfile"},
 							},
-							SecurityContext: &corev1.SecurityContext{
-								RunAsUser:                int64Ptr(999),
-								RunAsGroup:               int64Ptr(999),
-								RunAsNonRoot:             boolPtr(true),
-								AllowPrivilegeEscalation: boolPtr(false),
-							},
+							SecurityContext: buildKeyfileInitSecurityContext(),
 						},
 					},
 					Containers: containers,
@@ -881,10 +928,7 @@ func BuildMongosDeployment(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.Deploy
 						{
 							Name: "keyfile-secret",
 							VolumeSource: corev1.VolumeSource{
-								Secret: &corev1.SecretVolumeSource{
-									SecretName:  mdbsh.Name + "-keyfile",
-									DefaultMode: int32Ptr(0400),
-								},
+								Secret: buildKeyfileVolumeSource(mdbsh.Name, mdbsh.Spec.Auth),
 							},
 						},
 						{
@@ -899,54 +943,77 @@ func BuildMongosDeployment(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.Deploy
 			},
 		},
 	}
+
+	applyConfigFile(&deploy.Spec.Template, "mongos", ConfigMapName(mdbsh.Name+"-mongos"), MongosConfigKey, BuildMongosConfig(mdbsh))
+	applyMongoshConfig(&deploy.Spec.Template, "mongos", mdbsh.Spec.Telemetry)
+
+	// Add exporter sidecar if monitoring enabled
+	if MonitoringEnabled(mdbsh.Spec.Monitoring) {
+		addExporterSidecar(&deploy.Spec.Template.Spec, mdbsh.Name, mdbsh.Spec.Monitoring, mdbsh.Spec.TLS, mongoDBPort, mdbsh.Spec.ResourceProfile)
+	}
+
+	// Client traffic to mongos stays in the mesh, only its connections to the members bypass the proxy
+	applyServiceMesh(&deploy.Spec.Template, mdbsh.Spec.ServiceMesh, "mongos",
+		meshPorts{listen: mongoDBPort, outbound: shardedMemberPorts})
+	applyRootFilesystem(&deploy.Spec.Template.Spec, mdbsh.Spec.Mongos.Pod, "mongos")
+	applyMemberSpreading(&deploy.Spec.Template.Spec, mdbsh.Spec.Mongos.Pod, labels)
+	applyServiceAccount(&deploy.Spec.Template.Spec, mdbsh.Name, mdbsh.Spec.Mongos.Pod)
+	applyPodScheduling(&deploy.Spec.Template.Spec, mdbsh.Spec.Mongos.Pod, "mongos")
+	applyArchitecture(&deploy.Spec.Template.Spec, mdbsh.Spec.Architecture)
+	applyProbeSettings(&deploy.Spec.Template.Spec, mdbsh.Spec.Mongos.Pod, "mongos")
+	applyMongosDrain(&deploy.Spec.Template.Spec, mdbsh.Spec.Mongos.Pod)
+	applyPodMetadata(&deploy.Spec.Template, mdbsh.Spec.Mongos.Pod)
+	applyRestart(&deploy.Spec.Template, mdbsh.Annotations, RestartAnnotationFor(ComponentMongos))
+	applyPodExtensions(&deploy.Spec.Template.Spec, mdbsh.Spec.Mongos.Pod, "mongos")
+	return deploy
 }
 
+// BackupCredentials are the credentials a backup Job authenticates with. They are passed to
+// mongodump apart from the connection string, which holds no credentials, and the password is
+// mounted from its secret below JobCredentialsMountPath.
+type BackupCredentials struct {
+	Username          string
+	PasswordSecretRef corev1.SecretKeySelector
+}
+
+// BackupClusterLabel labels backup Jobs with the name of the cluster they back up
+const BackupClusterLabel = "mongodb.keiailab.com/backup-cluster"
+
 // BuildBackupJob creates a Job for MongoDB backup
-func BuildBackupJob(backup *mongodbv1alpha1.MongoDBBackup, connectionString string) *batchv1.Job {
+func BuildBackupJob(backup *mongodbv1alpha1.MongoDBBackup, connectionString string, credentials BackupCredentials) *batchv1.Job {
 	labels := buildLabels(backup.Name, "backup")
+	labels[BackupClusterLabel] = backup.Spec.ClusterRef.Name
 
 	backoff := int32(3)
+	if backup.Spec.BackoffLimit != nil {
+		backoff = *backup.Spec.BackoffLimit
+	}
 	ttl := int32(86400) // 24 hours
+	if backup.Spec.TTLSecondsAfterFinished != nil {
+		ttl = *backup.Spec.TTLSecondsAfterFinished
+	}
 
 	var envVars []corev1.EnvVar
-	envVars = append(envVars, corev1.EnvVar{
-		Name:  "MONGODB_URI",
-		Value: connectionString,
-	})
+	envVars = append(envVars,
+		corev1.EnvVar{Name: "MONGODB_URI", Value: connectionString},
+		corev1.EnvVar{Name: "MONGODB_USERNAME", Value: credentials.Username},
+	)
+	files := []credentialFile{{
+		secret: credentials.PasswordSecretRef.Name,
+		key:    credentials.PasswordSecretRef.Key,
+		path:   credentialFileMongoDBPassword,
+	}}
 
 	// S3 storage configuration
 	if backup.Spec.Storage.Type == "s3" && backup.Spec.Storage.S3 != nil {
-		s3 := backup.Spec.Storage.S3
-		envVars = append(envVars,
-			corev1.EnvVar{Name: "S3_BUCKET", Value: s3.Bucket},
-			corev1.EnvVar{Name: "S3_ENDPOINT", Value: s3.Endpoint},
-			corev1.EnvVar{Name: "S3_REGION", Value: s3.Region},
-			corev1.EnvVar{Name: "S3_PREFIX", Value: s3.Prefix},
-			corev1.EnvVar{
-				Name: "AWS_ACCESS_KEY_ID",
-				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: &corev1.SecretKeySelector{
-						LocalObjectReference: s3.CredentialsRef,
-						Key:                  "access-key",
-					},
-				},
-			},
-			corev1.EnvVar{
-				Name: "AWS_SECRET_ACCESS_KEY",
-				ValueFrom: &corev1.EnvVarSource{
-					SecretKeyRef: &corev1.SecretKeySelector{
-						LocalObjectReference: s3.CredentialsRef,
-						Key:                  "secret-key",
-					},
-				},
-			},
-		)
+		envVars = append(envVars, buildS3EnvVars(backup.Spec.Storage.S3)...)
+		files = append(files, s3CredentialFiles(backup.Spec.Storage.S3)...)
 	}
 
 	// Build backup script
 	script := buildBackupScript(backup)
 
-	return &batchv1.Job{
+	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      backup.Name,
 			Namespace: backup.Namespace,
@@ -955,6 +1022,7 @@ func BuildBackupJob(backup *mongodbv1alpha1.MongoDBBackup, connectionString stri
 		Spec: batchv1.JobSpec{
 			BackoffLimit:            &backoff,
 			TTLSecondsAfterFinished: &ttl,
+			ActiveDeadlineSeconds:   backup.Spec.ActiveDeadlineSeconds,
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
@@ -963,58 +1031,157 @@ func BuildBackupJob(backup *mongodbv1alpha1.MongoDBBackup, connectionString stri
 					RestartPolicy: corev1.RestartPolicyOnFailure,
 					Containers: []corev1.Container{
 						{
-							Name:    "backup",
-							Image:   defaultImage,
-							Command: []string{"/bin/bash", "-c"},
-							Args:    []string{script},
-							Env:     envVars,
-							Resources: corev1.ResourceRequirements{
-								Requests: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("100m"),
-									corev1.ResourceMemory: resource.MustParse("256Mi"),
-								},
-								Limits: corev1.ResourceList{
-									corev1.ResourceCPU:    resource.MustParse("500m"),
-									corev1.ResourceMemory: resource.MustParse("1Gi"),
-								},
-							},
+							Name:      "backup",
+							Image:     defaultBackupImage(),
+							Command:   []string{"/bin/bash", "-c"},
+							Args:      []string{script},
+							Env:       envVars,
+							Resources: buildBackupResources(),
 						},
 					},
 				},
 			},
 		},
 	}
+	addJobCredentials(&job.Spec.Template.Spec, files)
+	return job
 }
 
-func buildBackupScript(backup *mongodbv1alpha1.MongoDBBackup) string {
-	compressionFlag := "--gzip"
-	if backup.Spec.CompressionType == "zstd" {
-		compressionFlag = "--archive"
+// BackupTLSMountPath is where backup Jobs of TLS clusters mount the certificates of the cluster
+const BackupTLSMountPath = "/etc/mongodb-backup/tls"
+
+// AddBackupTLS mounts the TLS secret of the cluster into the containers of a backup Job, their
+// connection string verifies the servers with the ca.crt under BackupTLSMountPath
+func AddBackupTLS(job *batchv1.Job, secretName string) {
+	podSpec := &job.Spec.Template.Spec
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name: "cluster-tls", MountPath: BackupTLSMountPath, ReadOnly: true,
+		})
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "cluster-tls",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+		},
+	})
+}
+
+// buildS3EnvVars returns the environment of the S3 bucket of backup and restore Jobs. The
+// credentials of the bucket are mounted with s3CredentialFiles.
+func buildS3EnvVars(s3 *mongodbv1alpha1.S3StorageSpec) []corev1.EnvVar {
+	envVars := []corev1.EnvVar{
+		{Name: "S3_BUCKET", Value: s3.Bucket},
+		{Name: "S3_ENDPOINT", Value: s3.Endpoint},
+		{Name: "S3_REGION", Value: s3.Region},
+		{Name: "S3_PREFIX", Value: s3.Prefix},
+		{Name: "S3_FORCE_PATH_STYLE", Value: strconv.FormatBool(s3.ForcePathStyle)},
+		{Name: "S3_INSECURE_SKIP_TLS", Value: strconv.FormatBool(s3.InsecureSkipTLS)},
+	}
+	if sse := s3.ServerSideEncryption; sse != nil {
+		envVars = append(envVars,
+			corev1.EnvVar{Name: "S3_SSE", Value: sse.Algorithm},
+			corev1.EnvVar{Name: "S3_SSE_KMS_KEY_ID", Value: sse.KMSKeyID},
+		)
+	}
+	return envVars
+}
+
+// s3UploadArgs returns the arguments of aws s3 cp requesting the server-side encryption of an
+// upload, read from the S3_SSE variables of buildS3EnvVars
+func s3UploadArgs(s3 *mongodbv1alpha1.S3StorageSpec) string {
+	sse := s3.ServerSideEncryption
+	if sse == nil {
+		return ""
+	}
+	args := ` --sse="${S3_SSE}"`
+	if sse.Algorithm == "aws:kms" && sse.KMSKeyID != "" {
+		args += ` --sse-kms-key-id="${S3_SSE_KMS_KEY_ID}"`
+	}
+	return args
+}
+
+// buildBackupResources returns the backup Job resource requirements: the ones of the operator
+// config, or defaults sized for mongodump
+func buildBackupResources() corev1.ResourceRequirements {
+	if profile := OperatorConfig().Resources.Backup; len(profile.Requests) > 0 || len(profile.Limits) > 0 {
+		return buildResourceRequirements(*profile.DeepCopy())
 	}
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("100m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+}
+
+// buildBackupScript dumps the cluster as one archive piped through the compressor of the backup,
+// named by BackupArchiveName
+func buildBackupScript(backup *mongodbv1alpha1.MongoDBBackup) string {
+	compressor := compressorFor(backup.Spec)
+	dump := fmt.Sprintf(`mongodump --uri="${MONGODB_URI}" --username="${MONGODB_USERNAME}" --config="$(mongo_config password %s)" %s | %s`,
+		credentialFileMongoDBPassword, backupDumpArgs(backup), compressor.compress)
 
 	if backup.Spec.Storage.Type == "s3" {
 		return fmt.Sprintf(`
-set -e
-BACKUP_NAME="%s-$(date +%%Y%%m%%d-%%H%%M%%S)"
+set -eo pipefail
+%[1]s
+BACKUP_NAME="%[2]s"
 echo "Starting backup: ${BACKUP_NAME}"
 
-# Install aws-cli
-apt-get update && apt-get install -y awscli
+# Install the AWS CLI and the compressor
+apt-get update && apt-get install -y %[3]s
 
 # Create backup and upload to S3
-mongodump --uri="${MONGODB_URI}" %s --archive | \
-    aws s3 cp - "s3://${S3_BUCKET}/${S3_PREFIX}${BACKUP_NAME}.archive.gz" \
-    --endpoint-url="${S3_ENDPOINT}"
+s3_credentials
+%[4]s | \
+    aws s3 cp - "s3://${S3_BUCKET}/${S3_PREFIX}${BACKUP_NAME}%[5]s" \
+    --endpoint-url="${S3_ENDPOINT}"%[7]s
+
+# Report the size of the archive to the operator
+aws s3api head-object --bucket "${S3_BUCKET}" --key "${S3_PREFIX}${BACKUP_NAME}%[5]s" \
+    --endpoint-url="${S3_ENDPOINT}" --query ContentLength --output text > %[6]s
 
 echo "Backup completed: ${BACKUP_NAME}"
-`, backup.Spec.ClusterRef.Name, compressionFlag)
+`, jobCredentialsScript, backupName(backup), strings.Join(append([]string{"awscli"}, compressor.packages...), " "),
+			dump, BackupArchiveExtension(backup), corev1.TerminationMessagePathDefault, s3UploadArgs(backup.Spec.Storage.S3))
 	}
 
+	install := ""
+	if len(compressor.packages) > 0 {
+		install = fmt.Sprintf("apt-get update && apt-get install -y %s\n", strings.Join(compressor.packages, " "))
+	}
 	return fmt.Sprintf(`
-set -e
-BACKUP_NAME="%s-$(date +%%Y%%m%%d-%%H%%M%%S)"
+set -eo pipefail
+%[1]s
+BACKUP_NAME="%[2]s"
 echo "Starting backup: ${BACKUP_NAME}"
-mongodump --uri="${MONGODB_URI}" --out="/backup/${BACKUP_NAME}" %s
+%[3]s%[4]s > "/backup/${BACKUP_NAME}%[5]s"
+stat -c %%s "/backup/${BACKUP_NAME}%[5]s" > %[6]s
 echo "Backup completed: ${BACKUP_NAME}"
-`, backup.Spec.ClusterRef.Name, compressionFlag)
+`, jobCredentialsScript, backupName(backup), install, dump, BackupArchiveExtension(backup), corev1.TerminationMessagePathDefault)
+}
+
+// backupDumpArgs returns what mongodump dumps. A full backup dumps every database as an archive,
+// with the oplog written during the dump when the backup records its oplog position. An
+// incremental backup dumps the oplog entries after its parent up to its own position as BSON.
+func backupDumpArgs(backup *mongodbv1alpha1.MongoDBBackup) string {
+	start, end := backup.Status.OplogStart, backup.Status.OplogEnd
+	if backup.Spec.Type == mongodbv1alpha1.BackupTypeIncremental && start != nil && end != nil {
+		return fmt.Sprintf(`--db=local --collection=oplog.rs --query='{"ts": {"$gt": %s, "$lte": %s}}' --out=-`,
+			oplogTimestampJSON(start), oplogTimestampJSON(end))
+	}
+	if end != nil {
+		return "--archive --oplog"
+	}
+	return "--archive"
+}
+
+// oplogTimestampJSON returns an oplog timestamp as extended JSON
+func oplogTimestampJSON(ts *mongodbv1alpha1.OplogTimestamp) string {
+	return fmt.Sprintf(`{"$timestamp": {"t": %d, "i": %d}}`, ts.T, ts.I)
 }
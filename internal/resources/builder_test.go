@@ -17,6 +17,7 @@ limitations under the License.
 package resources
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -161,6 +162,29 @@ func TestBuildReplicaSetStatefulSetWithoutStorageClass(t *testing.T) {
 	assert.Nil(t, sts.Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
 }
 
+func TestBuildStorageClassNameDefault(t *testing.T) {
+	defer func(name string) { DefaultStorageClassName = name }(DefaultStorageClassName)
+
+	DefaultStorageClassName = ""
+	assert.Nil(t, buildStorageClassName(mongodbv1alpha1.StorageSpec{}))
+
+	DefaultStorageClassName = "standard-rwo"
+	require.NotNil(t, buildStorageClassName(mongodbv1alpha1.StorageSpec{}))
+	assert.Equal(t, "standard-rwo", *buildStorageClassName(mongodbv1alpha1.StorageSpec{}))
+	assert.Equal(t, "fast-storage", *buildStorageClassName(mongodbv1alpha1.StorageSpec{StorageClassName: "fast-storage"}))
+
+	mdbsh := &mongodbv1alpha1.MongoDBSharded{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-sharded", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBShardedSpec{
+			Version:      mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+			ConfigServer: mongodbv1alpha1.ConfigServerSpec{Members: 3},
+			Shards:       mongodbv1alpha1.ShardSpec{Count: 1, MembersPerShard: 3},
+		},
+	}
+	assert.Equal(t, "standard-rwo", *BuildConfigServerStatefulSet(mdbsh).Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+	assert.Equal(t, "standard-rwo", *BuildShardStatefulSet(mdbsh, 0).Spec.VolumeClaimTemplates[0].Spec.StorageClassName)
+}
+
 func TestBuildReplicaSetStatefulSetWithMonitoring(t *testing.T) {
 	mdb := &mongodbv1alpha1.MongoDB{
 		ObjectMeta: metav1.ObjectMeta{
@@ -191,6 +215,52 @@ func TestBuildReplicaSetStatefulSetWithMonitoring(t *testing.T) {
 	assert.Equal(t, "exporter", sts.Spec.Template.Spec.Containers[1].Name)
 }
 
+func TestBuildReplicaSetCustomPorts(t *testing.T) {
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-mongodb",
+			Namespace: "default",
+		},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members:        3,
+			ReplicaSetName: "rs0",
+			Port:           27100,
+			Version: mongodbv1alpha1.MongoDBVersion{
+				Version: "7.0",
+			},
+			Monitoring: &mongodbv1alpha1.MonitoringSpec{
+				Enabled:  true,
+				Exporter: &mongodbv1alpha1.ExporterSpec{Port: 9300},
+			},
+		},
+	}
+
+	assert.Equal(t, int32(27100), ReplicaSetPort(mdb))
+	assert.Equal(t, int32(27100), BuildHeadlessService(mdb).Spec.Ports[0].Port)
+
+	client := BuildClientService(mdb)
+	assert.Equal(t, int32(27100), client.Spec.Ports[0].Port)
+	assert.Equal(t, 27100, client.Spec.Ports[0].TargetPort.IntValue())
+	assert.Equal(t, int32(9300), client.Spec.Ports[1].Port)
+
+	assert.Contains(t, BuildMongoDBConfigMap(mdb).Data["readiness-probe.sh"], "mongodb://127.0.0.1:27100/")
+
+	sts := BuildReplicaSetStatefulSet(mdb)
+	mongod := sts.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, int32(27100), mongod.Ports[0].ContainerPort)
+	assert.Contains(t, BuildReplicaSetConfig(mdb), "port: 27100")
+	assert.Contains(t, strings.Join(mongod.LivenessProbe.Exec.Command, " "), "--port 27100")
+	assert.Equal(t, "9300", sts.Spec.Template.Annotations["prometheus.io/port"])
+
+	assert.Contains(t, exporterURI(t, sts.Spec.Template.Spec.Containers), "@localhost:27100/")
+
+	mdb.Spec.Port = 0
+	mongod = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers[0]
+	assert.Contains(t, BuildReplicaSetConfig(mdb), "port: 27017")
+	assert.NotContains(t, strings.Join(mongod.LivenessProbe.Exec.Command, " "), "--port")
+	assert.Equal(t, int32(27017), mongod.Ports[0].ContainerPort)
+}
+
 func TestBuildLabels(t *testing.T) {
 	labels := buildLabels("my-instance", "replicaset")
 
@@ -221,6 +291,23 @@ func TestGetMongoDBImage(t *testing.T) {
 			},
 			expected: "myregistry/mongo:7.0-custom",
 		},
+		{
+			name: "pinned digest",
+			version: mongodbv1alpha1.MongoDBVersion{
+				Version:     "7.0",
+				ImageDigest: "sha256:" + strings.Repeat("a", 64),
+			},
+			expected: "mongo:7.0@sha256:" + strings.Repeat("a", 64),
+		},
+		{
+			name: "pinned digest replaces the digest of the image",
+			version: mongodbv1alpha1.MongoDBVersion{
+				Version:     "7.0",
+				Image:       "myregistry/mongo:7.0-custom@sha256:" + strings.Repeat("b", 64),
+				ImageDigest: "sha256:" + strings.Repeat("a", 64),
+			},
+			expected: "myregistry/mongo:7.0-custom@sha256:" + strings.Repeat("a", 64),
+		},
 	}
 
 	for _, tt := range tests {
@@ -269,7 +356,7 @@ func TestBuildConfigServerStatefulSet(t *testing.T) {
 
 	assert.Equal(t, "test-sharded-cfg", sts.Name)
 	assert.Equal(t, int32(3), *sts.Spec.Replicas)
-	assert.Contains(t, sts.Spec.Template.Spec.Containers[0].Args, "--configsvr")
+	assert.Contains(t, BuildConfigServerConfig(mdbsh), "clusterRole: configsvr")
 }
 
 func TestBuildShardStatefulSet(t *testing.T) {
@@ -296,7 +383,7 @@ func TestBuildShardStatefulSet(t *testing.T) {
 
 	assert.Equal(t, "test-sharded-shard-0", sts.Name)
 	assert.Equal(t, int32(3), *sts.Spec.Replicas)
-	assert.Contains(t, sts.Spec.Template.Spec.Containers[0].Args, "--shardsvr")
+	assert.Contains(t, BuildShardConfig(mdbsh, 0), "clusterRole: shardsvr")
 }
 
 func TestBuildMongosDeployment(t *testing.T) {
@@ -324,3 +411,154 @@ func TestBuildMongosDeployment(t *testing.T) {
 	assert.Equal(t, int32(2), *deploy.Spec.Replicas)
 	assert.Equal(t, "mongos", deploy.Spec.Template.Spec.Containers[0].Command[0])
 }
+
+func TestBuildMongosConfigDBClusterDomain(t *testing.T) {
+	mdbsh := &mongodbv1alpha1.MongoDBSharded{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-sharded",
+			Namespace: "default",
+		},
+		Spec: mongodbv1alpha1.MongoDBShardedSpec{
+			Version: mongodbv1alpha1.MongoDBVersion{
+				Version: "7.0",
+			},
+			ConfigServer: mongodbv1alpha1.ConfigServerSpec{
+				Members: 2,
+			},
+			Mongos: mongodbv1alpha1.MongosSpec{
+				Replicas: 1,
+			},
+		},
+	}
+
+	cm := BuildMongosConfigMap(mdbsh)
+	assert.Equal(t, "test-sharded-cfg/"+
+		"test-sharded-cfg-0.test-sharded-cfg-headless.default.svc.cluster.local:27019,"+
+		"test-sharded-cfg-1.test-sharded-cfg-headless.default.svc.cluster.local:27019", cm.Data["configdb"])
+
+	mdbsh.Spec.ClusterDomain = "corp.example"
+	assert.Contains(t, BuildMongosConfig(mdbsh), "configDB: test-sharded-cfg/"+
+		"test-sharded-cfg-0.test-sharded-cfg-headless.default.svc.corp.example:27019,"+
+		"test-sharded-cfg-1.test-sharded-cfg-headless.default.svc.corp.example:27019")
+}
+
+func TestAddBackupTLS(t *testing.T) {
+	backup := &mongodbv1alpha1.MongoDBBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"}}
+	job := BuildBackupJob(backup, "mongodb://host/?tls=true&tlsCAFile=%2Fetc%2Fmongodb-backup%2Ftls%2Fca.crt", BackupCredentials{})
+
+	AddBackupTLS(job, "my-mongodb-tls")
+
+	podSpec := job.Spec.Template.Spec
+	require.Len(t, podSpec.Volumes, 2)
+	assert.Equal(t, "my-mongodb-tls", podSpec.Volumes[1].Secret.SecretName)
+	assert.Contains(t, podSpec.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "cluster-tls", MountPath: BackupTLSMountPath, ReadOnly: true})
+}
+
+func TestBuildBackupJobCredentials(t *testing.T) {
+	backup := testS3Backup()
+	passwordRef := corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "my-mongodb-admin"}, Key: "password"}
+
+	job := BuildBackupJob(backup, "mongodb://my-mongodb.default.svc.cluster.local:27017/?authSource=admin",
+		BackupCredentials{Username: "admin", PasswordSecretRef: passwordRef})
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "MONGODB_USERNAME", Value: "admin"})
+	assert.Contains(t, container.Args[0], `--username="${MONGODB_USERNAME}" --config="$(mongo_config password mongodb-password)"`)
+	assert.Contains(t, container.Args[0], "s3_credentials")
+	for _, env := range container.Env {
+		assert.Nil(t, env.ValueFrom, "no secret in the environment: %s", env.Name)
+		assert.NotContains(t, env.Value, "@", "no credentials in the connection string")
+	}
+
+	// The password and the S3 keys are mounted from their secrets
+	volumes := job.Spec.Template.Spec.Volumes
+	require.Len(t, volumes, 1)
+	require.NotNil(t, volumes[0].Projected)
+	assert.Equal(t, int32(0400), *volumes[0].Projected.DefaultMode)
+	sources := volumes[0].Projected.Sources
+	require.Len(t, sources, 3)
+	assert.Equal(t, "my-mongodb-admin", sources[0].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "password", Path: "mongodb-password"}}, sources[0].Secret.Items)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "secret-key", Path: "s3-secret-key"}}, sources[2].Secret.Items)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "credentials", MountPath: JobCredentialsMountPath, ReadOnly: true}}, container.VolumeMounts)
+}
+
+func TestBuildBackupJobLimits(t *testing.T) {
+	backup := testS3Backup()
+
+	job := BuildBackupJob(backup, "mongodb://host", BackupCredentials{})
+	assert.Equal(t, int32(3), *job.Spec.BackoffLimit)
+	assert.Equal(t, int32(86400), *job.Spec.TTLSecondsAfterFinished)
+	assert.Nil(t, job.Spec.ActiveDeadlineSeconds)
+	assert.Equal(t, "my-mongodb", job.Labels[BackupClusterLabel])
+	assert.Equal(t, "my-mongodb", job.Spec.Template.Labels[BackupClusterLabel])
+
+	backoff, ttl, deadline := int32(0), int32(600), int64(3600)
+	backup.Spec.BackoffLimit = &backoff
+	backup.Spec.TTLSecondsAfterFinished = &ttl
+	backup.Spec.ActiveDeadlineSeconds = &deadline
+
+	job = BuildBackupJob(backup, "mongodb://host", BackupCredentials{})
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, int32(600), *job.Spec.TTLSecondsAfterFinished)
+	assert.Equal(t, int64(3600), *job.Spec.ActiveDeadlineSeconds)
+}
+
+func TestBuildBackupJobOplog(t *testing.T) {
+	backup := testS3Backup()
+	script := BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, "--archive | cat")
+	assert.NotContains(t, script, "--oplog")
+
+	// Backups recording their oplog position dump the oplog written during the dump
+	backup.Status.OplogEnd = &mongodbv1alpha1.OplogTimestamp{T: 1704110400, I: 3}
+	script = BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, "--archive --oplog | cat")
+
+	// Incremental backups dump the oplog range after their parent
+	backup.Spec.Type = mongodbv1alpha1.BackupTypeIncremental
+	backup.Status.OplogStart = &mongodbv1alpha1.OplogTimestamp{T: 1704106800, I: 1}
+	script = BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, `--db=local --collection=oplog.rs --query='{"ts": {"$gt": {"$timestamp": {"t": 1704106800, "i": 1}}, "$lte": {"$timestamp": {"t": 1704110400, "i": 3}}}}' --out=- | cat`)
+	assert.Contains(t, script, `${BACKUP_NAME}.oplog.bson"`)
+	assert.NotContains(t, script, "--archive")
+}
+
+func TestBuildBackupJobS3Client(t *testing.T) {
+	backup := testS3Backup()
+	job := BuildBackupJob(backup, "mongodb://host", BackupCredentials{})
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "S3_FORCE_PATH_STYLE", Value: "false"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "S3_INSECURE_SKIP_TLS", Value: "false"})
+	assert.NotContains(t, container.Args[0], "--sse")
+	require.Len(t, job.Spec.Template.Spec.Volumes[0].Projected.Sources, 3)
+
+	optional := true
+	backup.Spec.Storage.S3.ForcePathStyle = true
+	backup.Spec.Storage.S3.InsecureSkipTLS = true
+	backup.Spec.Storage.S3.CASecretRef = &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "minio-ca"}, Key: "ca.crt", Optional: &optional,
+	}
+	backup.Spec.Storage.S3.ServerSideEncryption = &mongodbv1alpha1.S3ServerSideEncryptionSpec{Algorithm: "aws:kms", KMSKeyID: "alias/backups"}
+
+	job = BuildBackupJob(backup, "mongodb://host", BackupCredentials{})
+	container = job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "S3_FORCE_PATH_STYLE", Value: "true"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "S3_INSECURE_SKIP_TLS", Value: "true"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "S3_SSE", Value: "aws:kms"})
+	assert.Contains(t, container.Env, corev1.EnvVar{Name: "S3_SSE_KMS_KEY_ID", Value: "alias/backups"})
+	assert.Contains(t, container.Args[0], `--endpoint-url="${S3_ENDPOINT}" --sse="${S3_SSE}" --sse-kms-key-id="${S3_SSE_KMS_KEY_ID}"`)
+	assert.Contains(t, container.Args[0], `export AWS_CA_BUNDLE="${CREDENTIALS}/s3-ca.crt"`)
+
+	// The CA bundle is mounted with the credentials
+	sources := job.Spec.Template.Spec.Volumes[0].Projected.Sources
+	require.Len(t, sources, 4)
+	assert.Equal(t, "minio-ca", sources[3].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "ca.crt", Path: "s3-ca.crt"}}, sources[3].Secret.Items)
+	assert.Equal(t, &optional, sources[3].Secret.Optional)
+
+	// AES256 has no key
+	backup.Spec.Storage.S3.ServerSideEncryption = &mongodbv1alpha1.S3ServerSideEncryptionSpec{Algorithm: "AES256"}
+	script := BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, `--endpoint-url="${S3_ENDPOINT}" --sse="${S3_SSE}"`+"\n")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// BackupCatalogKey is the key of the catalog in the backup catalog ConfigMap
+const BackupCatalogKey = "catalog.json"
+
+// BackupCatalog lists the completed backups of a cluster, the restore targets of the cluster
+type BackupCatalog struct {
+	Cluster string `json:"cluster"`
+	Kind    string `json:"kind"`
+	// Backups are ordered by completion time
+	Backups []BackupCatalogEntry `json:"backups"`
+	// Coverage holds one oplog window per full backup recording its oplog position
+	Coverage []BackupCoverage `json:"coverage,omitempty"`
+}
+
+// BackupCatalogEntry is a completed backup and the artifact it wrote
+type BackupCatalogEntry struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Archive  string `json:"archive,omitempty"`
+	Location string `json:"location,omitempty"`
+	Size     string `json:"size,omitempty"`
+	// ClusterGeneration is the generation of the cluster spec when the backup started
+	ClusterGeneration int64                           `json:"clusterGeneration,omitempty"`
+	ParentBackup      string                          `json:"parentBackup,omitempty"`
+	CompletionTime    *metav1.Time                    `json:"completionTime,omitempty"`
+	OplogStart        *mongodbv1alpha1.OplogTimestamp `json:"oplogStart,omitempty"`
+	OplogEnd          *mongodbv1alpha1.OplogTimestamp `json:"oplogEnd,omitempty"`
+}
+
+// BackupCoverage is the oplog window a full backup and the incremental backups chained to it can
+// be restored to, from the end of the full backup to the end of its latest incremental backup.
+// Restores reach the end of one of the backups of the chain.
+type BackupCoverage struct {
+	FullBackup string                          `json:"fullBackup"`
+	LastBackup string                          `json:"lastBackup"`
+	From       *mongodbv1alpha1.OplogTimestamp `json:"from"`
+	To         *mongodbv1alpha1.OplogTimestamp `json:"to"`
+}
+
+// BackupCatalogName returns the name of the ConfigMap holding the backup catalog of a cluster
+func BackupCatalogName(clusterName string) string {
+	return clusterName + "-backup-catalog"
+}
+
+// BuildBackupCatalog builds the catalog of the completed backups of a cluster out of backups,
+// which may hold the backups of other clusters
+func BuildBackupCatalog(kind, clusterName string, backups []mongodbv1alpha1.MongoDBBackup) *BackupCatalog {
+	catalog := &BackupCatalog{Cluster: clusterName, Kind: kind, Backups: []BackupCatalogEntry{}}
+	for i := range backups {
+		backup := &backups[i]
+		if backup.Spec.ClusterRef.Kind != kind || backup.Spec.ClusterRef.Name != clusterName || backup.Status.Phase != "Completed" {
+			continue
+		}
+		backupType := backup.Spec.Type
+		if backupType == "" {
+			backupType = mongodbv1alpha1.BackupTypeFull
+		}
+		catalog.Backups = append(catalog.Backups, BackupCatalogEntry{
+			Name:              backup.Name,
+			Type:              backupType,
+			Archive:           backup.Status.Archive,
+			Location:          backup.Status.Location,
+			Size:              backup.Status.Size,
+			ClusterGeneration: backup.Status.ClusterGeneration,
+			ParentBackup:      backup.Status.ParentBackup,
+			CompletionTime:    backup.Status.CompletionTime,
+			OplogStart:        backup.Status.OplogStart,
+			OplogEnd:          backup.Status.OplogEnd,
+		})
+	}
+	slices.SortFunc(catalog.Backups, func(a, b BackupCatalogEntry) int {
+		return cmp.Or(cmp.Compare(completionUnix(a), completionUnix(b)), cmp.Compare(a.Name, b.Name))
+	})
+
+	// The chain of a full backup may branch when incremental backups run concurrently, its
+	// coverage ends with the latest backup reachable from it
+	children := make(map[string][]*BackupCatalogEntry)
+	for i := range catalog.Backups {
+		entry := &catalog.Backups[i]
+		if entry.ParentBackup != "" {
+			children[entry.ParentBackup] = append(children[entry.ParentBackup], entry)
+		}
+	}
+	for i := range catalog.Backups {
+		full := &catalog.Backups[i]
+		if full.Type == mongodbv1alpha1.BackupTypeIncremental || full.OplogEnd == nil {
+			continue
+		}
+		last := full
+		queue := []*BackupCatalogEntry{full}
+		for len(queue) > 0 {
+			entry := queue[0]
+			queue = append(queue[1:], children[entry.Name]...)
+			if entry.OplogEnd != nil && oplogAfter(entry.OplogEnd, last.OplogEnd) {
+				last = entry
+			}
+		}
+		catalog.Coverage = append(catalog.Coverage, BackupCoverage{
+			FullBackup: full.Name,
+			LastBackup: last.Name,
+			From:       full.OplogEnd,
+			To:         last.OplogEnd,
+		})
+	}
+	return catalog
+}
+
+// BuildBackupCatalogConfigMap creates the ConfigMap publishing the backup catalog of a cluster
+func BuildBackupCatalogConfigMap(catalog *BackupCatalog, namespace string) (*corev1.ConfigMap, error) {
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the backup catalog: %w", err)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackupCatalogName(catalog.Cluster),
+			Namespace: namespace,
+			Labels:    buildLabels(catalog.Cluster, "backup-catalog"),
+		},
+		Data: map[string]string{
+			BackupCatalogKey: string(data),
+		},
+	}, nil
+}
+
+// ArchiveSize formats the size of an archive in bytes as a quantity, rounded up to a whole number of
+// the largest binary unit that keeps at least two digits, e.g. 1206Ki or 118Mi
+func ArchiveSize(bytes int64) string {
+	units := []string{"Ti", "Gi", "Mi", "Ki"}
+	for i, unit := range units {
+		scale := int64(1) << (10 * (len(units) - i))
+		if bytes >= 10*scale {
+			return fmt.Sprintf("%d%s", (bytes+scale-1)/scale, unit)
+		}
+	}
+	return fmt.Sprintf("%d", bytes)
+}
+
+func completionUnix(entry BackupCatalogEntry) int64 {
+	if entry.CompletionTime == nil {
+		return 0
+	}
+	return entry.CompletionTime.Unix()
+}
+
+// oplogAfter reports whether the oplog timestamp a is after b
+func oplogAfter(a, b *mongodbv1alpha1.OplogTimestamp) bool {
+	return a.T > b.T || (a.T == b.T && a.I > b.I)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testCatalogBackup(name, backupType, parent string, completed time.Time, oplogEnd int64) mongodbv1alpha1.MongoDBBackup {
+	backup := mongodbv1alpha1.MongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBBackupSpec{
+			ClusterRef: mongodbv1alpha1.ClusterReference{Name: "my-mongodb", Kind: "MongoDB"},
+			Type:       backupType,
+		},
+		Status: mongodbv1alpha1.MongoDBBackupStatus{
+			Phase:          "Completed",
+			CompletionTime: &metav1.Time{Time: completed},
+			ParentBackup:   parent,
+			Archive:        name + ".archive.zst",
+		},
+	}
+	if oplogEnd > 0 {
+		backup.Status.OplogEnd = &mongodbv1alpha1.OplogTimestamp{T: oplogEnd}
+	}
+	return backup
+}
+
+func TestBuildBackupCatalog(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	failed := testCatalogBackup("failed", "full", "", day, 0)
+	failed.Status.Phase = "Failed"
+	other := testCatalogBackup("other-cluster", "full", "", day, 100)
+	other.Spec.ClusterRef.Name = "other"
+
+	backups := []mongodbv1alpha1.MongoDBBackup{
+		testCatalogBackup("hourly-2", "incremental", "hourly-1", day.Add(2*time.Hour), 300),
+		testCatalogBackup("nightly", "", "", day, 100),
+		testCatalogBackup("hourly-1", "incremental", "nightly", day.Add(time.Hour), 200),
+		// Ran alongside hourly-2 under the Allow policy
+		testCatalogBackup("hourly-2b", "incremental", "hourly-1", day.Add(2*time.Hour+time.Minute), 250),
+		testCatalogBackup("legacy", "full", "", day.Add(-time.Hour), 0),
+		failed,
+		other,
+	}
+	catalog := BuildBackupCatalog("MongoDB", "my-mongodb", backups)
+
+	var names []string
+	for _, entry := range catalog.Backups {
+		names = append(names, entry.Name)
+	}
+	assert.Equal(t, []string{"legacy", "nightly", "hourly-1", "hourly-2", "hourly-2b"}, names, "completed backups of the cluster by completion time")
+	assert.Equal(t, "full", catalog.Backups[1].Type, "the default type")
+	assert.Equal(t, "nightly", catalog.Backups[2].ParentBackup)
+
+	// Backups without an oplog position have no coverage
+	require.Len(t, catalog.Coverage, 1)
+	assert.Equal(t, BackupCoverage{
+		FullBackup: "nightly",
+		LastBackup: "hourly-2",
+		From:       &mongodbv1alpha1.OplogTimestamp{T: 100},
+		To:         &mongodbv1alpha1.OplogTimestamp{T: 300},
+	}, catalog.Coverage[0])
+}
+
+func TestBuildBackupCatalogConfigMap(t *testing.T) {
+	catalog := BuildBackupCatalog("MongoDB", "my-mongodb", nil)
+	cm, err := BuildBackupCatalogConfigMap(catalog, "default")
+	require.NoError(t, err)
+	assert.Equal(t, "my-mongodb-backup-catalog", cm.Name)
+	assert.Equal(t, "backup-catalog", cm.Labels["app.kubernetes.io/component"])
+
+	var decoded BackupCatalog
+	require.NoError(t, json.Unmarshal([]byte(cm.Data[BackupCatalogKey]), &decoded))
+	assert.Equal(t, "my-mongodb", decoded.Cluster)
+	assert.Empty(t, decoded.Backups)
+	assert.Contains(t, cm.Data[BackupCatalogKey], `"backups": []`, "an empty list rather than null")
+}
+
+func TestArchiveSize(t *testing.T) {
+	assert.Equal(t, "0", ArchiveSize(0))
+	assert.Equal(t, "10239", ArchiveSize(10239))
+	assert.Equal(t, "10Ki", ArchiveSize(10240))
+	assert.Equal(t, "1206Ki", ArchiveSize(1234567))
+	assert.Equal(t, "118Mi", ArchiveSize(123000000))
+	assert.Equal(t, "12Gi", ArchiveSize(12<<30))
+	assert.Equal(t, "20Ti", ArchiveSize(20<<40))
+}
+
+func TestBackupScriptReportsSize(t *testing.T) {
+	backup := testS3Backup()
+	assert.Contains(t, buildBackupScript(backup),
+		`--endpoint-url="${S3_ENDPOINT}" --query ContentLength --output text > /dev/termination-log`)
+
+	backup.Spec.Storage.Type = "pvc"
+	assert.Contains(t, buildBackupScript(backup), `stat -c %s "/backup/${BACKUP_NAME}.archive" > /dev/termination-log`)
+}
@@ -0,0 +1,372 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// CollectionNamespace returns the <database>.<collection> namespace of a collection
+func CollectionNamespace(spec mongodbv1alpha1.MongoDBCollectionSpec) string {
+	return spec.Database + "." + spec.Name
+}
+
+// BuildShardKey renders the shard key document, keeping the field order of the spec.
+// The output matches JSON.stringify of the key stored in config.collections.
+func BuildShardKey(fields []mongodbv1alpha1.ShardKeyField) (string, error) {
+	if len(fields) == 0 {
+		return "", fmt.Errorf("shard key requires at least one field")
+	}
+
+	parts := make([]string, 0, len(fields))
+	for _, f := range fields {
+		name, err := json.Marshal(f.Field)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal shard key field: %w", err)
+		}
+
+		value := "1"
+		if f.Type == "hashed" {
+			value = `"hashed"`
+		}
+		parts = append(parts, string(name)+":"+value)
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+// Kinds of collection created with options
+const (
+	CollectionKindRegular    = "collection"
+	CollectionKindTimeSeries = "timeseries"
+	CollectionKindCapped     = "capped"
+)
+
+// createCollectionOptions are the options of createCollection
+type createCollectionOptions struct {
+	Capped             bool               `json:"capped,omitempty"`
+	Size               int64              `json:"size,omitempty"`
+	Max                *int64             `json:"max,omitempty"`
+	TimeSeries         *timeSeriesOptions `json:"timeseries,omitempty"`
+	ExpireAfterSeconds *int64             `json:"expireAfterSeconds,omitempty"`
+}
+
+type timeSeriesOptions struct {
+	TimeField   string `json:"timeField"`
+	MetaField   string `json:"metaField,omitempty"`
+	Granularity string `json:"granularity,omitempty"`
+}
+
+// CollectionKind returns the kind of collection a spec declares
+func CollectionKind(spec mongodbv1alpha1.MongoDBCollectionSpec) string {
+	switch {
+	case spec.TimeSeries != nil:
+		return CollectionKindTimeSeries
+	case spec.Capped != nil:
+		return CollectionKindCapped
+	default:
+		return CollectionKindRegular
+	}
+}
+
+// BuildCreateCollectionOptions renders the createCollection options of a time-series or capped
+// collection. A regular collection is created by its first write and has no options.
+func BuildCreateCollectionOptions(spec mongodbv1alpha1.MongoDBCollectionSpec) (string, error) {
+	var options createCollectionOptions
+	switch CollectionKind(spec) {
+	case CollectionKindTimeSeries:
+		ts := spec.TimeSeries
+		options.TimeSeries = &timeSeriesOptions{TimeField: ts.TimeField, MetaField: ts.MetaField, Granularity: ts.Granularity}
+		options.ExpireAfterSeconds = ts.ExpireAfterSeconds
+	case CollectionKindCapped:
+		options.Capped = true
+		options.Size = spec.Capped.Size
+		options.Max = spec.Capped.Max
+	default:
+		return "", nil
+	}
+
+	out, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal collection options: %w", err)
+	}
+	return string(out), nil
+}
+
+// ValidateCollectionKind checks that an existing collection, of the given type and capped flag
+// as reported by listCollections, is of the kind the spec declares. Options cannot be added to
+// an existing collection, it would have to be recreated.
+func ValidateCollectionKind(spec mongodbv1alpha1.MongoDBCollectionSpec, collectionType string, capped bool) error {
+	kind := CollectionKind(spec)
+	if kind == CollectionKindRegular {
+		return nil
+	}
+
+	existing := collectionType
+	if collectionType == CollectionKindRegular && capped {
+		existing = CollectionKindCapped
+	}
+	if existing != kind {
+		return fmt.Errorf("collection %s already exists as a %s collection and cannot be turned into a %s collection",
+			CollectionNamespace(spec), existing, kind)
+	}
+	return nil
+}
+
+// BuildShardCollectionOptions renders the options document of sh.shardCollection creating the
+// initial chunks of spec.presplit
+func BuildShardCollectionOptions(spec mongodbv1alpha1.MongoDBCollectionSpec) string {
+	var parts []string
+	if p := spec.Presplit; p != nil {
+		if p.NumInitialChunks != nil {
+			parts = append(parts, fmt.Sprintf(`"numInitialChunks":%d`, *p.NumInitialChunks))
+		}
+		if p.HashedZones {
+			parts = append(parts, `"presplitHashedZones":true`)
+		}
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// PresplitHashedZones reports whether the zones of a collection are configured before it is
+// sharded, so its chunks are created along the zone ranges
+func PresplitHashedZones(spec mongodbv1alpha1.MongoDBCollectionSpec) bool {
+	return spec.Presplit != nil && spec.Presplit.HashedZones
+}
+
+// SplitPoints returns the shard key values the chunks of a collection are split at once it is sharded
+func SplitPoints(spec mongodbv1alpha1.MongoDBCollectionSpec) []string {
+	if spec.Presplit == nil {
+		return nil
+	}
+	return spec.Presplit.SplitPoints
+}
+
+// BuildIndexDefinition renders an index as a mongosh document for createIndexes,
+// keeping the key order of the spec
+func BuildIndexDefinition(index mongodbv1alpha1.IndexSpec) (string, error) {
+	keys := make([]string, 0, len(index.Keys))
+	for _, k := range index.Keys {
+		name, err := json.Marshal(k.Field)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal index key field: %w", err)
+		}
+
+		var value string
+		switch k.Type {
+		case "", "ascending":
+			value = "1"
+		case "descending":
+			value = "-1"
+		default:
+			value = `"` + k.Type + `"`
+		}
+		keys = append(keys, string(name)+":"+value)
+	}
+
+	name, err := json.Marshal(index.Name)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal index name: %w", err)
+	}
+
+	parts := []string{
+		`"key":{` + strings.Join(keys, ",") + "}",
+		`"name":` + string(name),
+	}
+	if index.Unique {
+		parts = append(parts, `"unique":true`)
+	}
+	if index.Sparse {
+		parts = append(parts, `"sparse":true`)
+	}
+	if index.ExpireAfterSeconds != nil {
+		parts = append(parts, fmt.Sprintf(`"expireAfterSeconds":%d`, *index.ExpireAfterSeconds))
+	}
+	if index.PartialFilterExpression != "" {
+		filter, err := json.Marshal(index.PartialFilterExpression)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal partial filter expression: %w", err)
+		}
+		parts = append(parts, `"partialFilterExpression":EJSON.parse(`+string(filter)+")")
+	}
+	return "{" + strings.Join(parts, ",") + "}", nil
+}
+
+// FormatIndexProgress renders index build progress for status, e.g. "1200/5000 (24%)"
+func FormatIndexProgress(done, total int64) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d/%d (%d%%)", done, total, done*100/total)
+}
+
+// ValidateCollection checks a collection spec before anything is applied to the cluster
+func ValidateCollection(spec mongodbv1alpha1.MongoDBCollectionSpec) error {
+	if spec.ClusterRef.Kind != "MongoDBSharded" {
+		if len(spec.ShardKey) > 0 || len(spec.Zones) > 0 {
+			return fmt.Errorf("collection sharding requires a MongoDBSharded cluster, got %s", spec.ClusterRef.Kind)
+		}
+	}
+	if len(spec.Zones) > 0 && len(spec.ShardKey) == 0 {
+		return fmt.Errorf("zones require a shard key")
+	}
+
+	hashed := 0
+	seen := make(map[string]bool, len(spec.ShardKey))
+	for _, f := range spec.ShardKey {
+		if f.Field == "" {
+			return fmt.Errorf("shard key field name must not be empty")
+		}
+		if seen[f.Field] {
+			return fmt.Errorf("duplicate shard key field %q", f.Field)
+		}
+		seen[f.Field] = true
+		if f.Type == "hashed" {
+			hashed++
+		}
+	}
+	if hashed > 1 {
+		return fmt.Errorf("shard key can contain at most one hashed field")
+	}
+	if hashed > 0 && spec.Unique {
+		return fmt.Errorf("unique is not supported with a hashed shard key")
+	}
+
+	if err := validatePresplit(spec, hashed > 0); err != nil {
+		return err
+	}
+
+	if err := validateCollectionKind(spec); err != nil {
+		return err
+	}
+
+	if err := validateIndexBuild(spec); err != nil {
+		return err
+	}
+
+	for _, z := range spec.Zones {
+		if z.Zone == "" {
+			return fmt.Errorf("zone name must not be empty")
+		}
+		if !json.Valid([]byte(z.Min)) || !json.Valid([]byte(z.Max)) {
+			return fmt.Errorf("zone %q range bounds must be valid Extended JSON documents", z.Zone)
+		}
+	}
+
+	names := make(map[string]bool, len(spec.Indexes))
+	for _, idx := range spec.Indexes {
+		if idx.Name == "" || idx.Name == "_id_" {
+			return fmt.Errorf("index name %q is not allowed", idx.Name)
+		}
+		if names[idx.Name] {
+			return fmt.Errorf("duplicate index %q", idx.Name)
+		}
+		names[idx.Name] = true
+		if len(idx.Keys) == 0 {
+			return fmt.Errorf("index %q requires at least one key", idx.Name)
+		}
+		if idx.ExpireAfterSeconds != nil && len(idx.Keys) > 1 {
+			return fmt.Errorf("index %q: TTL indexes must have a single key", idx.Name)
+		}
+		if idx.PartialFilterExpression != "" && !json.Valid([]byte(idx.PartialFilterExpression)) {
+			return fmt.Errorf("index %q partial filter expression must be a valid Extended JSON document", idx.Name)
+		}
+		if idx.PartialFilterExpression != "" && idx.Sparse {
+			return fmt.Errorf("index %q cannot be both sparse and partial", idx.Name)
+		}
+	}
+
+	return nil
+}
+
+// validatePresplit checks the initial chunks of a collection against its shard key and zones
+func validatePresplit(spec mongodbv1alpha1.MongoDBCollectionSpec, hashed bool) error {
+	p := spec.Presplit
+	if p == nil {
+		return nil
+	}
+	if len(spec.ShardKey) == 0 {
+		return fmt.Errorf("presplit requires a shard key")
+	}
+	if p.NumInitialChunks != nil && !hashed {
+		return fmt.Errorf("presplit numInitialChunks requires a hashed shard key")
+	}
+	if p.HashedZones && (!hashed || len(spec.Zones) == 0) {
+		return fmt.Errorf("presplit hashedZones requires a hashed shard key and zones")
+	}
+	if p.NumInitialChunks != nil && p.HashedZones {
+		return fmt.Errorf("presplit numInitialChunks cannot be combined with hashedZones")
+	}
+	if len(p.SplitPoints) > 0 && (p.NumInitialChunks != nil || p.HashedZones) {
+		return fmt.Errorf("presplit splitPoints cannot be combined with numInitialChunks or hashedZones")
+	}
+
+	for _, point := range p.SplitPoints {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(point), &fields); err != nil {
+			return fmt.Errorf("split point %s must be a valid Extended JSON document", point)
+		}
+		if len(fields) != len(spec.ShardKey) {
+			return fmt.Errorf("split point %s must list every shard key field", point)
+		}
+		for _, f := range spec.ShardKey {
+			if _, ok := fields[f.Field]; !ok {
+				return fmt.Errorf("split point %s must list every shard key field", point)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCollectionKind checks the options of a time-series or capped collection against its
+// shard key and indexes
+func validateCollectionKind(spec mongodbv1alpha1.MongoDBCollectionSpec) error {
+	if spec.TimeSeries != nil && spec.Capped != nil {
+		return fmt.Errorf("a collection cannot be both time-series and capped")
+	}
+
+	if spec.Capped != nil && len(spec.ShardKey) > 0 {
+		return fmt.Errorf("capped collections cannot be sharded")
+	}
+
+	ts := spec.TimeSeries
+	if ts == nil {
+		return nil
+	}
+	if ts.MetaField != "" && ts.MetaField == ts.TimeField {
+		return fmt.Errorf("time-series metaField and timeField must differ")
+	}
+	if spec.Unique {
+		return fmt.Errorf("unique is not supported on time-series collections")
+	}
+	// Time-series collections are sharded on their metadata and time
+	for _, f := range spec.ShardKey {
+		meta := ts.MetaField != "" && (f.Field == ts.MetaField || strings.HasPrefix(f.Field, ts.MetaField+"."))
+		if !meta && f.Field != ts.TimeField {
+			return fmt.Errorf("shard key field %q of a time-series collection must be the metaField, one of its subfields or the timeField", f.Field)
+		}
+	}
+	for _, idx := range spec.Indexes {
+		if idx.Unique {
+			return fmt.Errorf("index %q: unique indexes are not supported on time-series collections", idx.Name)
+		}
+	}
+	return nil
+}
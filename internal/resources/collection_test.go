@@ -0,0 +1,274 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testCollectionSpec() mongodbv1alpha1.MongoDBCollectionSpec {
+	return mongodbv1alpha1.MongoDBCollectionSpec{
+		ClusterRef: mongodbv1alpha1.ClusterReference{Name: "my-sharded", Kind: "MongoDBSharded"},
+		Database:   "app",
+		Name:       "users",
+		ShardKey: []mongodbv1alpha1.ShardKeyField{
+			{Field: "region", Type: "ascending"},
+			{Field: "userId", Type: "ascending"},
+		},
+		Zones: []mongodbv1alpha1.ZoneRange{
+			{
+				Zone:   "EU",
+				Shards: []string{"my-sharded-shard-0"},
+				Min:    `{"region": "EU", "userId": {"$minKey": 1}}`,
+				Max:    `{"region": "EU", "userId": {"$maxKey": 1}}`,
+			},
+		},
+	}
+}
+
+func TestBuildShardKey(t *testing.T) {
+	key, err := BuildShardKey(testCollectionSpec().ShardKey)
+	require.NoError(t, err)
+	assert.Equal(t, `{"region":1,"userId":1}`, key)
+
+	key, err = BuildShardKey([]mongodbv1alpha1.ShardKeyField{{Field: "_id", Type: "hashed"}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"_id":"hashed"}`, key)
+
+	_, err = BuildShardKey(nil)
+	assert.Error(t, err)
+}
+
+func TestValidateCollection(t *testing.T) {
+	assert.NoError(t, ValidateCollection(testCollectionSpec()))
+	assert.Equal(t, "app.users", CollectionNamespace(testCollectionSpec()))
+
+	replicaSet := testCollectionSpec()
+	replicaSet.ClusterRef.Kind = "MongoDB"
+	assert.Error(t, ValidateCollection(replicaSet))
+
+	hashedUnique := testCollectionSpec()
+	hashedUnique.ShardKey = []mongodbv1alpha1.ShardKeyField{{Field: "_id", Type: "hashed"}}
+	hashedUnique.Unique = true
+	assert.Error(t, ValidateCollection(hashedUnique))
+
+	duplicate := testCollectionSpec()
+	duplicate.ShardKey = append(duplicate.ShardKey, mongodbv1alpha1.ShardKeyField{Field: "region"})
+	assert.Error(t, ValidateCollection(duplicate))
+
+	badZone := testCollectionSpec()
+	badZone.Zones[0].Min = "region: EU"
+	assert.Error(t, ValidateCollection(badZone))
+}
+
+func TestValidateCollectionIndexes(t *testing.T) {
+	ttl := int32(3600)
+
+	replicaSet := testCollectionSpec()
+	replicaSet.ClusterRef.Kind = "MongoDB"
+	replicaSet.ShardKey = nil
+	replicaSet.Zones = nil
+	replicaSet.Indexes = []mongodbv1alpha1.IndexSpec{
+		{Name: "email_1", Keys: []mongodbv1alpha1.IndexKeyField{{Field: "email"}}, Unique: true},
+		{Name: "createdAt_ttl", Keys: []mongodbv1alpha1.IndexKeyField{{Field: "createdAt"}}, ExpireAfterSeconds: &ttl},
+	}
+	assert.NoError(t, ValidateCollection(replicaSet))
+
+	duplicate := replicaSet
+	duplicate.Indexes = append(duplicate.Indexes, replicaSet.Indexes[0])
+	assert.Error(t, ValidateCollection(duplicate))
+
+	compoundTTL := replicaSet
+	compoundTTL.Indexes = []mongodbv1alpha1.IndexSpec{{
+		Name:               "ttl",
+		Keys:               []mongodbv1alpha1.IndexKeyField{{Field: "a"}, {Field: "b"}},
+		ExpireAfterSeconds: &ttl,
+	}}
+	assert.Error(t, ValidateCollection(compoundTTL))
+
+	badFilter := replicaSet
+	badFilter.Indexes = []mongodbv1alpha1.IndexSpec{{
+		Name:                    "partial",
+		Keys:                    []mongodbv1alpha1.IndexKeyField{{Field: "a"}},
+		PartialFilterExpression: "a > 1",
+	}}
+	assert.Error(t, ValidateCollection(badFilter))
+
+	idIndex := replicaSet
+	idIndex.Indexes = []mongodbv1alpha1.IndexSpec{{Name: "_id_", Keys: []mongodbv1alpha1.IndexKeyField{{Field: "_id"}}}}
+	assert.Error(t, ValidateCollection(idIndex))
+}
+
+func TestBuildIndexDefinition(t *testing.T) {
+	ttl := int32(86400)
+
+	def, err := BuildIndexDefinition(mongodbv1alpha1.IndexSpec{
+		Name: "status_createdAt",
+		Keys: []mongodbv1alpha1.IndexKeyField{
+			{Field: "status", Type: "ascending"},
+			{Field: "createdAt", Type: "descending"},
+		},
+		Unique:                  true,
+		PartialFilterExpression: `{"status": "active"}`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":{"status":1,"createdAt":-1},"name":"status_createdAt","unique":true,`+
+		`"partialFilterExpression":EJSON.parse("{\"status\": \"active\"}")}`, def)
+
+	def, err = BuildIndexDefinition(mongodbv1alpha1.IndexSpec{
+		Name:               "expires",
+		Keys:               []mongodbv1alpha1.IndexKeyField{{Field: "createdAt"}},
+		ExpireAfterSeconds: &ttl,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":{"createdAt":1},"name":"expires","expireAfterSeconds":86400}`, def)
+
+	def, err = BuildIndexDefinition(mongodbv1alpha1.IndexSpec{
+		Name: "location",
+		Keys: []mongodbv1alpha1.IndexKeyField{{Field: "location", Type: "2dsphere"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":{"location":"2dsphere"},"name":"location"}`, def)
+}
+
+func TestFormatIndexProgress(t *testing.T) {
+	assert.Equal(t, "1200/5000 (24%)", FormatIndexProgress(1200, 5000))
+	assert.Equal(t, "", FormatIndexProgress(0, 0))
+}
+
+func TestValidateCollectionPresplit(t *testing.T) {
+	chunks := int32(8)
+	hashed := func(presplit *mongodbv1alpha1.PresplitSpec) mongodbv1alpha1.MongoDBCollectionSpec {
+		spec := testCollectionSpec()
+		spec.ShardKey = []mongodbv1alpha1.ShardKeyField{{Field: "region"}, {Field: "userId", Type: "hashed"}}
+		spec.Presplit = presplit
+		return spec
+	}
+	ranged := func(points ...string) mongodbv1alpha1.MongoDBCollectionSpec {
+		spec := testCollectionSpec()
+		spec.Presplit = &mongodbv1alpha1.PresplitSpec{SplitPoints: points}
+		return spec
+	}
+
+	assert.NoError(t, ValidateCollection(hashed(&mongodbv1alpha1.PresplitSpec{NumInitialChunks: &chunks})))
+	assert.NoError(t, ValidateCollection(hashed(&mongodbv1alpha1.PresplitSpec{HashedZones: true})))
+	assert.NoError(t, ValidateCollection(ranged(`{"region": "EU", "userId": {"$minKey": 1}}`)))
+
+	assert.Error(t, ValidateCollection(hashed(&mongodbv1alpha1.PresplitSpec{NumInitialChunks: &chunks, HashedZones: true})))
+	assert.Error(t, ValidateCollection(hashed(&mongodbv1alpha1.PresplitSpec{NumInitialChunks: &chunks, SplitPoints: []string{`{}`}})))
+	assert.Error(t, ValidateCollection(ranged(`{"region": "EU"}`)), "split points list every shard key field")
+	assert.Error(t, ValidateCollection(ranged(`{"region": "EU", "user": 1}`)))
+	assert.Error(t, ValidateCollection(ranged("region: EU")))
+
+	numInitialChunks := testCollectionSpec()
+	numInitialChunks.Presplit = &mongodbv1alpha1.PresplitSpec{NumInitialChunks: &chunks}
+	assert.Error(t, ValidateCollection(numInitialChunks), "numInitialChunks requires a hashed shard key")
+
+	noZones := hashed(&mongodbv1alpha1.PresplitSpec{HashedZones: true})
+	noZones.Zones = nil
+	assert.Error(t, ValidateCollection(noZones))
+}
+
+func TestBuildShardCollectionOptions(t *testing.T) {
+	spec := testCollectionSpec()
+	assert.Equal(t, "{}", BuildShardCollectionOptions(spec))
+	assert.False(t, PresplitHashedZones(spec))
+	assert.Empty(t, SplitPoints(spec))
+
+	chunks := int32(16)
+	spec.Presplit = &mongodbv1alpha1.PresplitSpec{NumInitialChunks: &chunks}
+	assert.Equal(t, `{"numInitialChunks":16}`, BuildShardCollectionOptions(spec))
+
+	spec.Presplit = &mongodbv1alpha1.PresplitSpec{HashedZones: true}
+	assert.Equal(t, `{"presplitHashedZones":true}`, BuildShardCollectionOptions(spec))
+	assert.True(t, PresplitHashedZones(spec))
+}
+
+func TestBuildCreateCollectionOptions(t *testing.T) {
+	spec := testCollectionSpec()
+	options, err := BuildCreateCollectionOptions(spec)
+	require.NoError(t, err)
+	assert.Empty(t, options, "regular collections are created by their first write")
+	assert.Equal(t, CollectionKindRegular, CollectionKind(spec))
+
+	expire := int64(86400)
+	spec.TimeSeries = &mongodbv1alpha1.TimeSeriesSpec{TimeField: "ts", MetaField: "sensor", Granularity: "minutes", ExpireAfterSeconds: &expire}
+	options, err = BuildCreateCollectionOptions(spec)
+	require.NoError(t, err)
+	assert.Equal(t, `{"timeseries":{"timeField":"ts","metaField":"sensor","granularity":"minutes"},"expireAfterSeconds":86400}`, options)
+
+	maxDocs := int64(1000)
+	spec.TimeSeries = nil
+	spec.Capped = &mongodbv1alpha1.CappedSpec{Size: 1048576, Max: &maxDocs}
+	options, err = BuildCreateCollectionOptions(spec)
+	require.NoError(t, err)
+	assert.Equal(t, `{"capped":true,"size":1048576,"max":1000}`, options)
+}
+
+func TestValidateCollectionKind(t *testing.T) {
+	spec := testCollectionSpec()
+	assert.NoError(t, ValidateCollectionKind(spec, "timeseries", false), "regular collections are not checked")
+
+	spec.TimeSeries = &mongodbv1alpha1.TimeSeriesSpec{TimeField: "ts"}
+	assert.NoError(t, ValidateCollectionKind(spec, "timeseries", false))
+	assert.ErrorContains(t, ValidateCollectionKind(spec, "collection", false), "already exists as a collection")
+
+	spec.TimeSeries = nil
+	spec.Capped = &mongodbv1alpha1.CappedSpec{Size: 4096}
+	assert.NoError(t, ValidateCollectionKind(spec, "collection", true))
+	assert.ErrorContains(t, ValidateCollectionKind(spec, "collection", false), "cannot be turned into a capped collection")
+}
+
+func TestValidateCollectionTimeSeriesAndCapped(t *testing.T) {
+	timeSeries := func() mongodbv1alpha1.MongoDBCollectionSpec {
+		spec := testCollectionSpec()
+		spec.Zones = nil
+		spec.TimeSeries = &mongodbv1alpha1.TimeSeriesSpec{TimeField: "ts", MetaField: "sensor"}
+		spec.ShardKey = []mongodbv1alpha1.ShardKeyField{{Field: "sensor.region"}, {Field: "ts"}}
+		return spec
+	}
+	assert.NoError(t, ValidateCollection(timeSeries()))
+
+	badKey := timeSeries()
+	badKey.ShardKey = []mongodbv1alpha1.ShardKeyField{{Field: "userId"}}
+	assert.Error(t, ValidateCollection(badKey), "time-series are sharded on metadata and time")
+
+	uniqueIndex := timeSeries()
+	uniqueIndex.Indexes = []mongodbv1alpha1.IndexSpec{{Name: "by_ts", Keys: []mongodbv1alpha1.IndexKeyField{{Field: "ts"}}, Unique: true}}
+	assert.Error(t, ValidateCollection(uniqueIndex))
+
+	sameFields := timeSeries()
+	sameFields.TimeSeries.MetaField = "ts"
+	assert.Error(t, ValidateCollection(sameFields))
+
+	both := timeSeries()
+	both.Capped = &mongodbv1alpha1.CappedSpec{Size: 4096}
+	assert.Error(t, ValidateCollection(both))
+
+	cappedSharded := testCollectionSpec()
+	cappedSharded.Capped = &mongodbv1alpha1.CappedSpec{Size: 4096}
+	assert.Error(t, ValidateCollection(cappedSharded), "capped collections cannot be sharded")
+
+	capped := cappedSharded
+	capped.ShardKey, capped.Zones = nil, nil
+	assert.NoError(t, ValidateCollection(capped))
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"time"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// backupCompressor is the program a mongodump archive is piped through
+type backupCompressor struct {
+	// extension follows .archive in the name of the archive
+	extension string
+	// compress and decompress filter stdin to stdout
+	compress   string
+	decompress string
+	// packages are the apt packages providing the program
+	packages []string
+}
+
+// backupCompressors are the compressors by compression type
+var backupCompressors = map[string]backupCompressor{
+	"gzip":   {extension: ".gz", compress: "gzip -c", decompress: "gzip -dc"},
+	"zstd":   {extension: ".zst", compress: "zstd -c -T0", decompress: "zstd -dc", packages: []string{"zstd"}},
+	"snappy": {extension: ".sz", compress: "snzip -c", decompress: "snzip -dc", packages: []string{"snzip"}},
+}
+
+// uncompressed leaves the archive as mongodump writes it
+var uncompressed = backupCompressor{compress: "cat", decompress: "cat"}
+
+// compressorFor returns the compressor of a backup, zstd unless the backup sets another type
+func compressorFor(spec mongodbv1alpha1.MongoDBBackupSpec) backupCompressor {
+	if !spec.Compression {
+		return uncompressed
+	}
+	if compressor, ok := backupCompressors[spec.CompressionType]; ok {
+		return compressor
+	}
+	return backupCompressors["zstd"]
+}
+
+// BackupArchiveExtension returns the extension of the archives a backup writes, e.g.
+// .archive.zst, or .oplog.bson.zst for the oplog slices of incremental backups. The backup Job
+// logs the name of the archive without it.
+func BackupArchiveExtension(backup *mongodbv1alpha1.MongoDBBackup) string {
+	if backup.Spec.Type == mongodbv1alpha1.BackupTypeIncremental {
+		return ".oplog.bson" + compressorFor(backup.Spec).extension
+	}
+	return ".archive" + compressorFor(backup.Spec).extension
+}
+
+// BackupArchiveName returns the name of the archive of a backup, the cluster name and the start
+// time of the backup followed by the extension, e.g. my-mongodb-20240101-120000.archive.zst.
+// Retries of the backup Job overwrite the same archive.
+func BackupArchiveName(backup *mongodbv1alpha1.MongoDBBackup) string {
+	return backupName(backup) + BackupArchiveExtension(backup)
+}
+
+// backupName is the name of the archive of a backup without the extension
+func backupName(backup *mongodbv1alpha1.MongoDBBackup) string {
+	started := time.Now()
+	if backup.Status.StartTime != nil {
+		started = backup.Status.StartTime.Time
+	}
+	return fmt.Sprintf("%s-%s", backup.Spec.ClusterRef.Name, started.UTC().Format("20060102-150405"))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestBackupArchiveExtension(t *testing.T) {
+	backup := testS3Backup()
+	assert.Equal(t, ".archive", BackupArchiveExtension(backup), "compression disabled")
+
+	backup.Spec.Compression = true
+	for compressionType, extension := range map[string]string{
+		"":       ".archive.zst",
+		"gzip":   ".archive.gz",
+		"zstd":   ".archive.zst",
+		"snappy": ".archive.sz",
+	} {
+		backup.Spec.CompressionType = compressionType
+		assert.Equal(t, extension, BackupArchiveExtension(backup), compressionType)
+	}
+}
+
+func TestBackupArchiveName(t *testing.T) {
+	backup := testS3Backup()
+	backup.Spec.Compression = true
+	backup.Status.StartTime = &metav1.Time{Time: time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))}
+	assert.Equal(t, "my-mongodb-20240101-110000.archive.zst", BackupArchiveName(backup), "the start time in UTC")
+
+	backup.Spec.Type = mongodbv1alpha1.BackupTypeIncremental
+	assert.Equal(t, ".oplog.bson.zst", BackupArchiveExtension(backup))
+	assert.Equal(t, "my-mongodb-20240101-110000.oplog.bson.zst", BackupArchiveName(backup))
+	assert.Contains(t, buildBackupScript(backup), `BACKUP_NAME="my-mongodb-20240101-110000"`)
+}
+
+func TestBackupScriptCompression(t *testing.T) {
+	backup := testS3Backup()
+	backup.Spec.Compression = true
+
+	tests := []struct {
+		compressionType string
+		pipeline        string
+		install         string
+	}{
+		{"gzip", "--archive | gzip -c | \\\n", "apt-get install -y awscli\n"},
+		{"zstd", "--archive | zstd -c -T0 | \\\n", "apt-get install -y awscli zstd\n"},
+		{"snappy", "--archive | snzip -c | \\\n", "apt-get install -y awscli snzip\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.compressionType, func(t *testing.T) {
+			backup.Spec.CompressionType = tt.compressionType
+			script := buildBackupScript(backup)
+			assert.Contains(t, script, tt.pipeline)
+			assert.Contains(t, script, tt.install)
+			assert.Contains(t, script, `${BACKUP_NAME}`+BackupArchiveExtension(backup)+`"`)
+			assert.NotContains(t, script, "--gzip", "mongodump does not compress itself")
+		})
+	}
+
+	backup.Spec.Storage.Type = "pvc"
+	backup.Spec.CompressionType = "zstd"
+	script := buildBackupScript(backup)
+	assert.Contains(t, script, "apt-get install -y zstd\n")
+	assert.Contains(t, script, `--archive | zstd -c -T0 > "/backup/${BACKUP_NAME}.archive.zst"`)
+
+	backup.Spec.Compression = false
+	script = buildBackupScript(backup)
+	assert.NotContains(t, script, "apt-get")
+	assert.Contains(t, script, `--archive | cat > "/backup/${BACKUP_NAME}.archive"`)
+}
@@ -0,0 +1,409 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/pkg/connstring"
+)
+
+// UserCredentials holds the credentials of a database user
+type UserCredentials struct {
+	Username string
+	Password string
+	Database string
+}
+
+// ConnectionInfo describes how applications reach a cluster
+type ConnectionInfo struct {
+	// Host is the client Service DNS name
+	Host string
+	// Hosts lists every host:port to seed drivers with
+	Hosts []string
+	// Endpoints lists the host:port of the ready mongos pods behind Host, sharded clusters only
+	Endpoints []string
+	// ExternalHosts lists the host:port reachable from outside the cluster, if exposed
+	ExternalHosts []string
+	Port          int
+	// SRVHost is the name of the _mongodb._tcp SRV record of the cluster
+	SRVHost string
+	// ReplicaSet is empty for sharded clusters, which are reached through mongos
+	ReplicaSet string
+	TLS        bool
+	CACert     []byte
+	Admin      UserCredentials
+	Users      []UserCredentials
+}
+
+// DefaultClusterDomain is the DNS domain of clusters that do not set spec.clusterDomain.
+// It is set from the operator --cluster-domain flag.
+var DefaultClusterDomain = "cluster.local"
+
+// ClusterDomain returns the DNS domain of a cluster
+func ClusterDomain(specDomain string) string {
+	if specDomain != "" {
+		return specDomain
+	}
+	return DefaultClusterDomain
+}
+
+// ServiceFQDN returns the fully qualified domain name of a Service
+func ServiceFQDN(serviceName, namespace, specDomain string) string {
+	return fmt.Sprintf("%s.%s.svc.%s", serviceName, namespace, ClusterDomain(specDomain))
+}
+
+// ConnectionSecretName returns the name of the application connection secret for a cluster
+func ConnectionSecretName(clusterName string) string {
+	return clusterName + "-connection"
+}
+
+// BuildConnectionURI builds a mongodb:// URI authenticating the given user
+func BuildConnectionURI(info ConnectionInfo, user UserCredentials) string {
+	uri := connstring.ConnString{
+		Hosts:      info.Hosts,
+		Username:   user.Username,
+		Password:   user.Password,
+		AuthSource: user.Database,
+		ReplicaSet: info.ReplicaSet,
+		TLS:        info.TLS,
+	}
+	if user.Database != "admin" {
+		uri.Database = user.Database
+	}
+	return uri.String()
+}
+
+// BuildSRVConnectionURI builds a mongodb+srv:// URI authenticating the given user, empty when the
+// cluster has no SRV record
+func BuildSRVConnectionURI(info ConnectionInfo, user UserCredentials) string {
+	if info.SRVHost == "" {
+		return ""
+	}
+	uri := connstring.ConnString{
+		SRV:         true,
+		Hosts:       []string{info.SRVHost},
+		Username:    user.Username,
+		Password:    user.Password,
+		AuthSource:  user.Database,
+		ReplicaSet:  info.ReplicaSet,
+		TLS:         info.TLS,
+		ExplicitTLS: true,
+	}
+	if user.Database != "admin" {
+		uri.Database = user.Database
+	}
+	return uri.String()
+}
+
+// ReplicaSetConnectionString returns the connection string without credentials shown in the
+// status of a replica set: its members with the admin authentication database, or the Service of
+// a standalone server
+func ReplicaSetConnectionString(mdb *mongodbv1alpha1.MongoDB) string {
+	uri := connstring.ConnString{
+		Hosts:      MemberHosts(mdb),
+		AuthSource: "admin",
+		ReplicaSet: mdb.Spec.ReplicaSetName,
+		TLS:        TLSEnabled(mdb.Spec.TLS),
+	}
+	if Standalone(mdb) {
+		port := strconv.Itoa(int(ReplicaSetPort(mdb)))
+		uri.Hosts = []string{net.JoinHostPort(ServiceFQDN(mdb.Name, mdb.Namespace, mdb.Spec.ClusterDomain), port)}
+		uri.ReplicaSet = ""
+	}
+	return uri.String()
+}
+
+// ShardedConnectionString returns the connection string without credentials shown in the
+// status of a sharded cluster, through the mongos Service
+func ShardedConnectionString(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	return connstring.ConnString{
+		Hosts:      []string{MongosServiceHost(mdbsh)},
+		AuthSource: "admin",
+		TLS:        TLSEnabled(mdbsh.Spec.TLS),
+	}.String()
+}
+
+// ReplicaSetSRVConnectionString returns the mongodb+srv:// URI without credentials shown in the
+// status of a replica set. The named mongodb port of the headless Service gives it the
+// _mongodb._tcp SRV record of the members. Kubernetes DNS serves no TXT record, so the URI carries
+// the options drivers would read from it.
+func ReplicaSetSRVConnectionString(mdb *mongodbv1alpha1.MongoDB) string {
+	uri := connstring.ConnString{
+		SRV:         true,
+		Hosts:       []string{ServiceFQDN(mdb.Name+"-headless", mdb.Namespace, mdb.Spec.ClusterDomain)},
+		AuthSource:  "admin",
+		ReplicaSet:  mdb.Spec.ReplicaSetName,
+		TLS:         TLSEnabled(mdb.Spec.TLS),
+		ExplicitTLS: true,
+	}
+	if Standalone(mdb) {
+		uri.ReplicaSet = ""
+	}
+	return uri.String()
+}
+
+// ShardedSRVConnectionString returns the mongodb+srv:// URI without credentials shown in the
+// status of a sharded cluster, through the _mongodb._tcp SRV record of the mongos Service
+func ShardedSRVConnectionString(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	return connstring.ConnString{
+		SRV:         true,
+		Hosts:       []string{ServiceFQDN(mdbsh.Name+"-mongos", mdbsh.Namespace, mdbsh.Spec.ClusterDomain)},
+		AuthSource:  "admin",
+		TLS:         TLSEnabled(mdbsh.Spec.TLS),
+		ExplicitTLS: true,
+	}.String()
+}
+
+// MongosServicePort returns the port of the mongos Service, spec.mongos.service.port or 27017.
+// The mongos pods always listen on 27017.
+func MongosServicePort(mdbsh *mongodbv1alpha1.MongoDBSharded) int32 {
+	if mdbsh.Spec.Mongos.Service != nil && mdbsh.Spec.Mongos.Service.Port != 0 {
+		return mdbsh.Spec.Mongos.Service.Port
+	}
+	return mongoDBPort
+}
+
+// MongosServiceHost returns the host:port of the mongos Service applications connect to
+func MongosServiceHost(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	host := ServiceFQDN(mdbsh.Name+"-mongos", mdbsh.Namespace, mdbsh.Spec.ClusterDomain)
+	return net.JoinHostPort(host, strconv.Itoa(int(MongosServicePort(mdbsh))))
+}
+
+// TLSEnabled reports whether a cluster requires TLS connections
+func TLSEnabled(tls *mongodbv1alpha1.TLSSpec) bool {
+	return tls != nil && tls.Enabled
+}
+
+// BuildConnectionSecret creates the secret applications mount to connect to a cluster.
+// Per-user URIs are stored under uri-<username>, and under externalUri-<username> for the
+// external hosts, see UserURIKey.
+func BuildConnectionSecret(clusterName, namespace string, info ConnectionInfo) *corev1.Secret {
+	data := connectionSecretData(info, info.Admin)
+	for _, user := range info.Users {
+		data[UserURIKey("uri", user.Username)] = []byte(BuildConnectionURI(info, user))
+	}
+	if len(info.ExternalHosts) > 0 {
+		external := info
+		external.Hosts = info.ExternalHosts
+		for _, user := range info.Users {
+			data[UserURIKey("externalUri", user.Username)] = []byte(BuildConnectionURI(external, user))
+		}
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConnectionSecretName(clusterName),
+			Namespace: namespace,
+			Labels:    buildLabels(clusterName, "connection"),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+}
+
+// UserURIKey returns the connection Secret key of the URI of a user, <prefix>-<username>. Secret
+// keys only hold alphanumerics, '-', '_' and '.', so in usernames holding other characters, which
+// MongoDB allows, each byte of these characters is written as _ and its two hex digits, e.g.
+// uri-app_40corp for app@corp.
+func UserURIKey(prefix, username string) string {
+	key := prefix + "-" + username
+	if len(validation.IsConfigMapKey(key)) == 0 {
+		return key
+	}
+
+	var b strings.Builder
+	b.WriteString(prefix + "-")
+	for i := 0; i < len(username); i++ {
+		c := username[i]
+		if c == '-' || c == '_' || c == '.' || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "_%02x", c)
+	}
+	return b.String()
+}
+
+// connectionSecretData returns the connection details of a cluster authenticating the given user
+func connectionSecretData(info ConnectionInfo, user UserCredentials) map[string][]byte {
+	data := map[string][]byte{
+		"host":     []byte(info.Host),
+		"hosts":    []byte(strings.Join(info.Hosts, ",")),
+		"port":     []byte(strconv.Itoa(info.Port)),
+		"username": []byte(user.Username),
+		"password": []byte(user.Password),
+		"uri":      []byte(BuildConnectionURI(info, user)),
+	}
+	if info.ReplicaSet != "" {
+		data["replicaSet"] = []byte(info.ReplicaSet)
+	}
+	if info.SRVHost != "" {
+		data["srvUri"] = []byte(BuildSRVConnectionURI(info, user))
+	}
+	if len(info.CACert) > 0 {
+		data["ca.crt"] = info.CACert
+	}
+	if len(info.Endpoints) > 0 {
+		data["endpoints"] = []byte(strings.Join(info.Endpoints, ","))
+	}
+	if len(info.ExternalHosts) > 0 {
+		external := info
+		external.Hosts = info.ExternalHosts
+		data["externalHosts"] = []byte(strings.Join(info.ExternalHosts, ","))
+		data["externalUri"] = []byte(BuildConnectionURI(external, user))
+	}
+	return data
+}
+
+// UserConnectionComponent is the component label of the connection secrets of additional users
+const UserConnectionComponent = "user-connection"
+
+// UserConnectionSecretName returns the name of the connection secret of an additional user:
+// its connectionSecretName, or <cluster>-<db>-<name> lowercased with the characters not
+// allowed in secret names replaced by dashes
+func UserConnectionSecretName(clusterName string, user mongodbv1alpha1.MongoDBUser) string {
+	if user.ConnectionSecretName != "" {
+		return user.ConnectionSecretName
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '.' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(fmt.Sprintf("%s-%s-%s", clusterName, user.DB, user.Name)))
+	if len(name) > validation.DNS1123SubdomainMaxLength {
+		name = name[:validation.DNS1123SubdomainMaxLength]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// ValidateUserConnectionSecrets rejects additional users whose connection secrets would share a
+// name, or overwrite the connection secret of the cluster or a password secret
+func ValidateUserConnectionSecrets(clusterName string, users []mongodbv1alpha1.MongoDBUser) error {
+	taken := map[string]string{ConnectionSecretName(clusterName): "the connection secret of the cluster"}
+	for _, user := range users {
+		taken[user.PasswordSecretRef.Name] = fmt.Sprintf("the password secret of user %s", user.Name)
+	}
+
+	for _, user := range users {
+		name := UserConnectionSecretName(clusterName, user)
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("connection secret name %q of user %s is invalid: %s", name, user.Name, strings.Join(errs, ", "))
+		}
+		if owner, ok := taken[name]; ok {
+			return fmt.Errorf("connection secret %s of user %s would overwrite %s, set connectionSecretName", name, user.Name, owner)
+		}
+		taken[name] = fmt.Sprintf("the connection secret of user %s", user.Name)
+	}
+	return nil
+}
+
+// BuildUserConnectionSecrets creates a connection secret for every additional user with
+// credentials, holding the same keys as the cluster connection secret for that user
+func BuildUserConnectionSecrets(clusterName, namespace string, info ConnectionInfo, users []mongodbv1alpha1.MongoDBUser) []*corev1.Secret {
+	var secrets []*corev1.Secret
+	for _, user := range users {
+		i := slices.IndexFunc(info.Users, func(c UserCredentials) bool {
+			return c.Username == user.Name && c.Database == user.DB
+		})
+		if i < 0 {
+			continue
+		}
+
+		data := connectionSecretData(info, info.Users[i])
+		data["database"] = []byte(user.DB)
+		secrets = append(secrets, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      UserConnectionSecretName(clusterName, user),
+				Namespace: namespace,
+				Labels:    buildLabels(clusterName, UserConnectionComponent),
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: data,
+		})
+	}
+	return secrets
+}
+
+// MongosEndpoints returns the sorted host:port of the ready mongos pods
+func MongosEndpoints(pods []corev1.Pod, port int) []string {
+	var endpoints []string
+	for i := range pods {
+		if pods[i].Status.PodIP == "" || !PodReady(&pods[i]) {
+			continue
+		}
+		endpoints = append(endpoints, net.JoinHostPort(pods[i].Status.PodIP, strconv.Itoa(port)))
+	}
+	slices.Sort(endpoints)
+	return endpoints
+}
+
+// MongosExternalHosts returns the host:port of the mongos Service reachable from outside the
+// cluster: the load balancer ingresses, or the node port on the nodes running a ready mongos.
+// ClusterIP Services have none.
+func MongosExternalHosts(svc *corev1.Service, pods []corev1.Pod) []string {
+	if svc == nil || len(svc.Spec.Ports) == 0 {
+		return nil
+	}
+
+	var hosts []string
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		port := strconv.Itoa(int(svc.Spec.Ports[0].Port))
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" {
+				hosts = append(hosts, net.JoinHostPort(ingress.Hostname, port))
+			} else if ingress.IP != "" {
+				hosts = append(hosts, net.JoinHostPort(ingress.IP, port))
+			}
+		}
+	case corev1.ServiceTypeNodePort:
+		if svc.Spec.Ports[0].NodePort == 0 {
+			return nil
+		}
+		port := strconv.Itoa(int(svc.Spec.Ports[0].NodePort))
+		for i := range pods {
+			if pods[i].Status.HostIP == "" || !PodReady(&pods[i]) {
+				continue
+			}
+			hosts = append(hosts, net.JoinHostPort(pods[i].Status.HostIP, port))
+		}
+	}
+	slices.Sort(hosts)
+	return slices.Compact(hosts)
+}
+
+// PodReady reports whether the Ready condition of the pod is true
+func PodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
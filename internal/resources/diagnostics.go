@@ -0,0 +1,134 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// Profiler modes
+const (
+	ProfilingModeOff    = "off"
+	ProfilingModeSlowOp = "slowOp"
+	ProfilingModeAll    = "all"
+
+	defaultSlowOpThresholdMs = 100
+	maxLogVerbosity          = 5
+)
+
+// runtimeConfigKeys are the mongod.conf settings that are also applied to running members, so
+// changing them does not roll the pods
+var runtimeConfigKeys = []string{
+	"operationProfiling.mode",
+	"operationProfiling.slowOpThresholdMs",
+	"operationProfiling.slowOpSampleRate",
+	"systemLog.verbosity",
+	"systemLog.component",
+}
+
+var logComponentPattern = regexp.MustCompile(`^[a-zA-Z]+(\.[a-zA-Z]+)*$`)
+
+// ValidateDiagnostics checks the profiler and log verbosity settings
+func ValidateDiagnostics(profiling *mongodbv1alpha1.ProfilingSpec, logging *mongodbv1alpha1.LoggingSpec) error {
+	if profiling != nil && profiling.SampleRate != "" {
+		if rate, err := strconv.ParseFloat(profiling.SampleRate, 64); err != nil || rate < 0 || rate > 1 {
+			return fmt.Errorf("profiling sampleRate %q must be between 0 and 1", profiling.SampleRate)
+		}
+	}
+	if logging == nil {
+		return nil
+	}
+	for component, verbosity := range logging.Components {
+		if !logComponentPattern.MatchString(component) || slices.Contains(strings.Split(component, "."), "verbosity") {
+			return fmt.Errorf("invalid log component %q", component)
+		}
+		if verbosity < 0 || verbosity > maxLogVerbosity {
+			return fmt.Errorf("log component %q verbosity %d must be between 0 and %d", component, verbosity, maxLogVerbosity)
+		}
+	}
+	return nil
+}
+
+// DiagnosticsConfigured reports whether the profiler or log verbosity is configured
+func DiagnosticsConfigured(profiling *mongodbv1alpha1.ProfilingSpec, logging *mongodbv1alpha1.LoggingSpec) bool {
+	return profiling != nil || logging != nil
+}
+
+// BuildProfilingSettings returns the profiler level, slowms and sampleRate of the members.
+// Without a profiling spec the MongoDB defaults are returned.
+func BuildProfilingSettings(spec *mongodbv1alpha1.ProfilingSpec) map[string]any {
+	settings := map[string]any{"level": 0, "slowms": int32(defaultSlowOpThresholdMs), "sampleRate": 1.0}
+	if spec == nil {
+		return settings
+	}
+
+	switch spec.Mode {
+	case ProfilingModeOff:
+	case ProfilingModeAll:
+		settings["level"] = 2
+	default:
+		settings["level"] = 1
+	}
+	if spec.SlowOpThresholdMs != nil {
+		settings["slowms"] = *spec.SlowOpThresholdMs
+	}
+	if rate, err := strconv.ParseFloat(spec.SampleRate, 64); err == nil {
+		settings["sampleRate"] = rate
+	}
+	return settings
+}
+
+// BuildLogComponentVerbosity returns the logComponentVerbosity server parameter of the members
+func BuildLogComponentVerbosity(spec *mongodbv1alpha1.LoggingSpec) map[string]any {
+	verbosity := map[string]any{"verbosity": int32(0)}
+	if spec == nil {
+		return verbosity
+	}
+
+	verbosity["verbosity"] = spec.Verbosity
+	for component, level := range spec.Components {
+		setConfigValue(verbosity, component+".verbosity", level)
+	}
+	return verbosity
+}
+
+// applyDiagnosticsConfig writes the profiler and log verbosity settings into mongod.conf so
+// restarted members keep them
+func applyDiagnosticsConfig(config map[string]interface{}, profiling *mongodbv1alpha1.ProfilingSpec, logging *mongodbv1alpha1.LoggingSpec) {
+	if profiling != nil {
+		settings := BuildProfilingSettings(profiling)
+		mode := ProfilingModeSlowOp
+		if profiling.Mode != "" {
+			mode = profiling.Mode
+		}
+		setConfigValue(config, "operationProfiling.mode", mode)
+		setConfigValue(config, "operationProfiling.slowOpThresholdMs", settings["slowms"])
+		setConfigValue(config, "operationProfiling.slowOpSampleRate", settings["sampleRate"])
+	}
+	if logging != nil {
+		setConfigValue(config, "systemLog.verbosity", logging.Verbosity)
+		for component, level := range logging.Components {
+			setConfigValue(config, "systemLog.component."+component+".verbosity", level)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateDiagnostics(t *testing.T) {
+	assert.NoError(t, ValidateDiagnostics(nil, nil))
+	assert.NoError(t, ValidateDiagnostics(
+		&mongodbv1alpha1.ProfilingSpec{SampleRate: "0.5"},
+		&mongodbv1alpha1.LoggingSpec{Components: map[string]int32{"query": 2, "replication.election": 1}},
+	))
+
+	assert.Error(t, ValidateDiagnostics(&mongodbv1alpha1.ProfilingSpec{SampleRate: "1.5"}, nil))
+	assert.Error(t, ValidateDiagnostics(nil, &mongodbv1alpha1.LoggingSpec{Components: map[string]int32{"query": 6}}))
+	assert.Error(t, ValidateDiagnostics(nil, &mongodbv1alpha1.LoggingSpec{Components: map[string]int32{"query..plan": 1}}))
+	assert.Error(t, ValidateDiagnostics(nil, &mongodbv1alpha1.LoggingSpec{Components: map[string]int32{"query.verbosity": 1}}))
+}
+
+func TestBuildProfilingSettings(t *testing.T) {
+	assert.Equal(t, map[string]any{"level": 0, "slowms": int32(100), "sampleRate": 1.0}, BuildProfilingSettings(nil))
+	assert.Equal(t, 1, BuildProfilingSettings(&mongodbv1alpha1.ProfilingSpec{})["level"])
+	assert.Equal(t, map[string]any{"level": 2, "slowms": int32(50), "sampleRate": 0.25}, BuildProfilingSettings(&mongodbv1alpha1.ProfilingSpec{
+		Mode:              ProfilingModeAll,
+		SlowOpThresholdMs: int32Ptr(50),
+		SampleRate:        "0.25",
+	}))
+	assert.Equal(t, 0, BuildProfilingSettings(&mongodbv1alpha1.ProfilingSpec{Mode: ProfilingModeOff})["level"])
+}
+
+func TestBuildLogComponentVerbosity(t *testing.T) {
+	assert.Equal(t, map[string]any{"verbosity": int32(0)}, BuildLogComponentVerbosity(nil))
+	assert.Equal(t, map[string]any{
+		"verbosity": int32(1),
+		"query":     map[string]any{"verbosity": int32(2)},
+		"replication": map[string]any{
+			"election": map[string]any{"verbosity": int32(3)},
+		},
+	}, BuildLogComponentVerbosity(&mongodbv1alpha1.LoggingSpec{
+		Verbosity:  1,
+		Components: map[string]int32{"query": 2, "replication.election": 3},
+	}))
+}
+
+func TestDiagnosticsConfigDoesNotRollPods(t *testing.T) {
+	mdb := testMongoDB()
+	hash := BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations[ConfigHashAnnotation]
+
+	mdb.Spec.Profiling = &mongodbv1alpha1.ProfilingSpec{Mode: ProfilingModeAll, SlowOpThresholdMs: int32Ptr(20)}
+	mdb.Spec.Logging = &mongodbv1alpha1.LoggingSpec{Verbosity: 1, Components: map[string]int32{"query": 2}}
+
+	config := BuildReplicaSetConfig(mdb)
+	assert.Contains(t, config, `operationProfiling:
+  mode: all
+  slowOpSampleRate: 1
+  slowOpThresholdMs: 20
+`)
+	assert.Contains(t, config, `systemLog:
+  component:
+    query:
+      verbosity: 2
+  verbosity: 1
+`)
+	assert.Equal(t, hash, BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations[ConfigHashAnnotation])
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Profiling = mdb.Spec.Profiling
+	assert.Contains(t, BuildShardConfig(mdbsh, 0), "mode: all")
+	assert.Contains(t, BuildConfigServerConfig(mdbsh), "mode: all")
+	assert.NotContains(t, BuildMongosConfig(mdbsh), "operationProfiling")
+}
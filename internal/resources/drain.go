@@ -0,0 +1,118 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// Mongos update strategies
+	MongosUpdateRollingUpdate = "RollingUpdate"
+	MongosUpdateSurge         = "Surge"
+
+	// mongosTerminationGracePeriodSeconds is the default time a mongos has to drain and stop
+	mongosTerminationGracePeriodSeconds = 60
+
+	// drainEndpointDelaySeconds leaves time for the Service endpoints to drop the stopping mongos
+	// before it waits for its operations, clients keep sending requests until then
+	drainEndpointDelaySeconds = 5
+
+	// drainShutdownReserveSeconds is the part of the grace period kept for mongos to shut down
+	drainShutdownReserveSeconds = 10
+)
+
+// mongosDrainScript waits for the operations of client connections to finish, for at most
+// waitSeconds, so in-flight requests are answered before mongos receives SIGTERM. The operations
+// of the script and of the readiness probe are told apart by their appName. Failures end the
+// wait, they never block the shutdown.
+func mongosDrainScript(port int32, waitSeconds int64) string {
+	return keyfileKeyScript + fmt.Sprintf(`sleep %d
+DEADLINE=$(( $(date +%%s) + %d ))
+while [ "$(date +%%s)" -lt "$DEADLINE" ]; do
+  ACTIVE="$(mongosh --quiet "mongodb://127.0.0.1:%d/?appName=mongos-drain&authSource=local" -u __system -p "$KEY" --eval '
+db.getSiblingDB("admin").aggregate([
+  { $currentOp: { localOps: true } },
+  { $match: { active: true, client: { $exists: true }, appName: { $nin: ["mongos-drain", "mongos-readiness"] } } }
+]).itcount()' 2>/dev/null)" || break
+  [ "$ACTIVE" = "0" ] && break
+  sleep 1
+done
+`, drainEndpointDelaySeconds, waitSeconds, port)
+}
+
+// terminationGracePeriod returns the grace period set in the pod spec, or def
+func terminationGracePeriod(pod *mongodbv1alpha1.PodSpec, def int64) int64 {
+	if pod != nil && pod.TerminationGracePeriodSeconds != nil {
+		return *pod.TerminationGracePeriodSeconds
+	}
+	return def
+}
+
+// applyTerminationGracePeriod sets the grace period of the pod spec, overriding the default of the
+// component
+func applyTerminationGracePeriod(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec) {
+	if pod != nil && pod.TerminationGracePeriodSeconds != nil {
+		podSpec.TerminationGracePeriodSeconds = int64Ptr(*pod.TerminationGracePeriodSeconds)
+	}
+}
+
+// applyMongosDrain adds a preStop hook to the mongos container draining its client operations.
+// A terminating pod is removed from the Service endpoints, so once the endpoint delay passed no new
+// requests arrive and the hook only waits for the in-flight ones. The wait uses the grace period
+// minus the endpoint delay and the time kept for the shutdown.
+func applyMongosDrain(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec) {
+	grace := terminationGracePeriod(pod, mongosTerminationGracePeriodSeconds)
+	wait := max(grace-drainEndpointDelaySeconds-drainShutdownReserveSeconds, 0)
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != "mongos" {
+			continue
+		}
+		c.Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"bash", "-c", mongosDrainScript(mongoDBPort, wait)}},
+			},
+		}
+	}
+	podSpec.TerminationGracePeriodSeconds = int64Ptr(grace)
+}
+
+// buildMongosStrategy returns the Deployment strategy of mongos. Surge never stops an instance
+// before its replacement is available.
+func buildMongosStrategy(spec mongodbv1alpha1.MongosSpec) appsv1.DeploymentStrategy {
+	maxUnavailable := intstr.FromInt(1)
+	if spec.UpdateStrategy == MongosUpdateSurge {
+		maxUnavailable = intstr.FromInt(0)
+	}
+	maxSurge := intstr.FromInt(1)
+
+	return appsv1.DeploymentStrategy{
+		Type: appsv1.RollingUpdateDeploymentStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDeployment{
+			MaxUnavailable: &maxUnavailable,
+			MaxSurge:       &maxSurge,
+		},
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestApplyMongosDrain(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+
+	pod := BuildMongosDeployment(mdbsh).Spec.Template.Spec
+	mongos := findContainer(pod.Containers, "mongos")
+	require.NotNil(t, mongos.Lifecycle)
+	require.NotNil(t, mongos.Lifecycle.PreStop)
+	script := mongos.Lifecycle.PreStop.Exec.Command[2]
+	assert.Contains(t, script, "mongodb://127.0.0.1:27017/?appName=mongos-drain")
+	assert.Contains(t, script, "DEADLINE=$(( $(date +%s) + 45 ))")
+	assert.Equal(t, int64(60), *pod.TerminationGracePeriodSeconds)
+
+	mdbsh.Spec.Mongos.Pod = &mongodbv1alpha1.PodSpec{TerminationGracePeriodSeconds: int64Ptr(10)}
+	pod = BuildMongosDeployment(mdbsh).Spec.Template.Spec
+	assert.Contains(t, findContainer(pod.Containers, "mongos").Lifecycle.PreStop.Exec.Command[2], "DEADLINE=$(( $(date +%s) + 0 ))")
+	assert.Equal(t, int64(10), *pod.TerminationGracePeriodSeconds)
+}
+
+func TestApplyTerminationGracePeriod(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{TerminationGracePeriodSeconds: int64Ptr(120)}
+	assert.Equal(t, int64(120), *BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.TerminationGracePeriodSeconds)
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards.Pod = &mongodbv1alpha1.PodSpec{TerminationGracePeriodSeconds: int64Ptr(90)}
+	assert.Equal(t, int64(90), *BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.TerminationGracePeriodSeconds)
+	assert.Equal(t, int64(60), *BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.TerminationGracePeriodSeconds)
+}
+
+func TestBuildMongosStrategy(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+
+	rolling := BuildMongosDeployment(mdbsh).Spec.Strategy.RollingUpdate
+	assert.Equal(t, intstr.FromInt(1), *rolling.MaxUnavailable)
+	assert.Equal(t, intstr.FromInt(1), *rolling.MaxSurge)
+
+	mdbsh.Spec.Mongos.UpdateStrategy = MongosUpdateSurge
+	surge := BuildMongosDeployment(mdbsh).Spec.Strategy.RollingUpdate
+	assert.Equal(t, intstr.FromInt(0), *surge.MaxUnavailable)
+	assert.Equal(t, intstr.FromInt(1), *surge.MaxSurge)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/conversion"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// DriftedCondition reports members running another version than the spec and out-of-band
+	// changes to the resources owned by the operator
+	DriftedCondition = "Drifted"
+
+	// DesiredStateAnnotation is the hash of the content the operator last wrote to a resource it
+	// owns, telling its own changes from those made by others
+	DesiredStateAnnotation = "mongodb.keiailab.com/desired-state"
+
+	// DriftRetention is how long a reverted out-of-band change keeps the Drifted condition True
+	DriftRetention = time.Hour
+)
+
+// driftEqualities ignore the fields the operator leaves unset, numbers included, so the defaults
+// filled in by the API server are not taken for changes
+var driftEqualities = func() conversion.Equalities {
+	e := equality.Semantic.Copy()
+	if err := e.AddFuncs(
+		func(a, b int32) bool { return a == 0 || a == b },
+		func(a, b int64) bool { return a == 0 || a == b },
+		func(a, b int) bool { return a == 0 || a == b },
+	); err != nil {
+		panic(err)
+	}
+	return e
+}()
+
+// managedContent returns the part of a resource the operator writes, nil for the kinds it does
+// not check for drift
+func managedContent(obj metav1.Object) any {
+	switch o := obj.(type) {
+	case *appsv1.StatefulSet:
+		return &o.Spec
+	case *appsv1.Deployment:
+		return &o.Spec
+	case *corev1.Service:
+		return &o.Spec
+	case *corev1.ConfigMap:
+		return &struct {
+			Data       map[string]string `json:"data,omitempty"`
+			BinaryData map[string][]byte `json:"binaryData,omitempty"`
+		}{o.Data, o.BinaryData}
+	case *corev1.Secret:
+		return &o.Data
+	}
+	return nil
+}
+
+// SetDesiredState stamps the hash of the managed content on a resource about to be written. It
+// must be called once the fields kept from the existing resource were carried over.
+func SetDesiredState(obj metav1.Object) {
+	content := managedContent(obj)
+	if content == nil {
+		return
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return
+	}
+	hash := sha256.Sum256(data)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[DesiredStateAnnotation] = hex.EncodeToString(hash[:])
+	obj.SetAnnotations(annotations)
+}
+
+// OutOfBandChange reports whether someone else changed a resource since the operator wrote it:
+// the operator wants the same content as last time, which the resource no longer holds. Fields
+// the operator leaves unset and entries it does not write are not compared.
+func OutOfBandChange(desired, existing metav1.Object) bool {
+	hash := desired.GetAnnotations()[DesiredStateAnnotation]
+	if hash == "" || existing.GetAnnotations()[DesiredStateAnnotation] != hash {
+		return false
+	}
+	return !driftEqualities.DeepDerivative(managedContent(desired), managedContent(existing))
+}
+
+// RecordDrift records an out-of-band change to a resource, given as Kind/name, replacing an
+// earlier one of the same resource
+func RecordDrift(drift []mongodbv1alpha1.DriftStatus, resource string, now time.Time) []mongodbv1alpha1.DriftStatus {
+	recorded := mongodbv1alpha1.DriftStatus{Resource: resource, DetectedAt: metav1.NewTime(now)}
+	for i := range drift {
+		if drift[i].Resource == resource {
+			drift[i] = recorded
+			return drift
+		}
+	}
+	return append(drift, recorded)
+}
+
+// PruneDrift drops the changes reverted more than DriftRetention ago
+func PruneDrift(drift []mongodbv1alpha1.DriftStatus, now time.Time) []mongodbv1alpha1.DriftStatus {
+	var recent []mongodbv1alpha1.DriftStatus
+	for _, d := range drift {
+		if now.Sub(d.DetectedAt.Time) < DriftRetention {
+			recent = append(recent, d)
+		}
+	}
+	return recent
+}
+
+// VersionMatches reports whether a version reported by a member is the one of the spec, which may
+// leave out the patch release
+func VersionMatches(reported, spec string) bool {
+	return reported == spec || strings.HasPrefix(reported, spec+".")
+}
+
+// MembersOffVersion returns the members reporting another version than the spec. Members whose
+// version was not collected are left out.
+func MembersOffVersion(versions []mongodbv1alpha1.MemberVersionStatus, spec string) []mongodbv1alpha1.MemberVersionStatus {
+	var off []mongodbv1alpha1.MemberVersionStatus
+	for _, v := range versions {
+		if v.Version != "" && !VersionMatches(v.Version, spec) {
+			off = append(off, v)
+		}
+	}
+	return off
+}
+
+// BuildDriftedCondition builds the Drifted condition from the versions reported by the members and
+// the out-of-band changes reverted within DriftRetention
+func BuildDriftedCondition(versions []mongodbv1alpha1.MemberVersionStatus, spec string, drift []mongodbv1alpha1.DriftStatus, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               DriftedCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "InSync",
+		Message:            "The members run the version of the spec and the owned resources were not changed",
+	}
+
+	var messages []string
+	if off := MembersOffVersion(versions, spec); len(off) > 0 {
+		members := make([]string, 0, len(off))
+		for _, v := range off {
+			members = append(members, fmt.Sprintf("%s (%s)", v.Name, v.Version))
+		}
+		condition.Reason = "MixedVersions"
+		messages = append(messages, fmt.Sprintf("Members do not run version %s: %s", spec, strings.Join(members, ", ")))
+	}
+	if len(drift) > 0 {
+		changed := make([]string, 0, len(drift))
+		for _, d := range drift {
+			changed = append(changed, d.Resource)
+		}
+		if condition.Reason == "InSync" {
+			condition.Reason = "ManualChange"
+		}
+		messages = append(messages, fmt.Sprintf("Reverted out-of-band changes to %s", strings.Join(changed, ", ")))
+	}
+
+	if len(messages) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Message = strings.Join(messages, "; ")
+	}
+	return condition
+}
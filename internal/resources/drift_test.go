@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestOutOfBandChange(t *testing.T) {
+	mdb := testMongoDB()
+	desired := BuildReplicaSetStatefulSet(mdb)
+	SetDesiredState(desired)
+	assert.NotEmpty(t, desired.Annotations[DesiredStateAnnotation])
+
+	// The API server fills in defaults, which are no change
+	existing := desired.DeepCopy()
+	container := &existing.Spec.Template.Spec.Containers[0]
+	container.TerminationMessagePath = corev1.TerminationMessagePathDefault
+	container.ReadinessProbe.SuccessThreshold = 1
+	existing.Spec.RevisionHistoryLimit = int32Ptr(10)
+	next := BuildReplicaSetStatefulSet(mdb)
+	SetDesiredState(next)
+	assert.False(t, OutOfBandChange(next, existing))
+
+	// Someone scaled the StatefulSet
+	existing.Spec.Replicas = int32Ptr(5)
+	assert.True(t, OutOfBandChange(next, existing))
+
+	// The operator rolls out a new spec, which is not drift
+	mdb.Spec.Version.Version = "8.2.1"
+	upgraded := BuildReplicaSetStatefulSet(mdb)
+	SetDesiredState(upgraded)
+	assert.False(t, OutOfBandChange(upgraded, existing))
+
+	// Resources written before the annotation existed are not reported either
+	delete(existing.Annotations, DesiredStateAnnotation)
+	assert.False(t, OutOfBandChange(next, existing))
+}
+
+func TestOutOfBandChangeService(t *testing.T) {
+	desired := &corev1.Service{Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Name: "mongodb", Port: 27017}}}}
+	SetDesiredState(desired)
+
+	existing := desired.DeepCopy()
+	existing.Spec.Ports[0].TargetPort = intstr.FromInt32(27017)
+	existing.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	existing.Spec.SessionAffinity = corev1.ServiceAffinityNone
+	assert.False(t, OutOfBandChange(desired, existing))
+
+	existing.Spec.Ports[0].Port = 27018
+	assert.True(t, OutOfBandChange(desired, existing))
+}
+
+func TestRecordDrift(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	drift := RecordDrift(nil, "StatefulSet/my-mongodb", now.Add(-2*time.Hour))
+	drift = RecordDrift(drift, "Service/my-mongodb", now.Add(-time.Minute))
+	assert.Len(t, drift, 2)
+
+	assert.Equal(t, []mongodbv1alpha1.DriftStatus{
+		{Resource: "Service/my-mongodb", DetectedAt: metav1.NewTime(now.Add(-time.Minute))},
+	}, PruneDrift(drift, now))
+
+	drift = RecordDrift(drift, "StatefulSet/my-mongodb", now)
+	assert.Len(t, drift, 2)
+	assert.Len(t, PruneDrift(drift, now), 2)
+}
+
+func TestBuildDriftedCondition(t *testing.T) {
+	versions := []mongodbv1alpha1.MemberVersionStatus{
+		{Name: "my-mongodb-0", Version: "8.0.4"},
+		{Name: "my-mongodb-1", Version: "8.0.4"},
+		{Name: "my-mongodb-2"},
+	}
+	condition := BuildDriftedCondition(versions, "8.0", nil, 2)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "InSync", condition.Reason)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+
+	// A rollout half way through
+	versions[1].Version = "7.0.14"
+	condition = BuildDriftedCondition(versions, "8.0", nil, 2)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "MixedVersions", condition.Reason)
+	assert.Equal(t, "Members do not run version 8.0: my-mongodb-1 (7.0.14)", condition.Message)
+
+	drift := []mongodbv1alpha1.DriftStatus{{Resource: "StatefulSet/my-mongodb"}}
+	condition = BuildDriftedCondition(nil, "8.0", drift, 2)
+	assert.Equal(t, "ManualChange", condition.Reason)
+	assert.Equal(t, "Reverted out-of-band changes to StatefulSet/my-mongodb", condition.Message)
+}
+
+func TestVersionMatches(t *testing.T) {
+	assert.True(t, VersionMatches("8.0.4", "8.0"))
+	assert.True(t, VersionMatches("8.0.4", "8.0.4"))
+	assert.False(t, VersionMatches("8.0.14", "8.0.1"))
+	assert.False(t, VersionMatches("8.2.0", "8.0"))
+}
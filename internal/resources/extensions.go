@@ -0,0 +1,72 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// applyPodExtensions adds the user-defined sidecars, init containers and volumes to a pod and
+// mounts the extra volume mounts into the database container. Like a strategic merge patch,
+// entries are keyed by name (mount path for volume mounts) and replace the operator entries
+// with the same key.
+func applyPodExtensions(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec, container string) {
+	if pod == nil {
+		return
+	}
+
+	containerName := func(c corev1.Container) string { return c.Name }
+	podSpec.InitContainers = mergeByKey(podSpec.InitContainers, pod.InitContainers, containerName)
+	podSpec.Containers = mergeByKey(podSpec.Containers, pod.Sidecars, containerName)
+	podSpec.Volumes = mergeByKey(podSpec.Volumes, pod.Volumes, func(v corev1.Volume) string { return v.Name })
+
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == container {
+			podSpec.Containers[i].VolumeMounts = mergeByKey(podSpec.Containers[i].VolumeMounts, pod.VolumeMounts,
+				func(m corev1.VolumeMount) string { return m.MountPath })
+		}
+	}
+}
+
+// mergeByKey replaces the items of base with the overrides sharing their key and appends the others
+func mergeByKey[T any](base, overrides []T, key func(T) string) []T {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make([]T, 0, len(base)+len(overrides))
+	replaced := make(map[string]bool, len(overrides))
+	index := make(map[string]int, len(overrides))
+	for i, item := range overrides {
+		index[key(item)] = i
+	}
+	for _, item := range base {
+		if i, ok := index[key(item)]; ok {
+			merged = append(merged, overrides[i])
+			replaced[key(item)] = true
+			continue
+		}
+		merged = append(merged, item)
+	}
+	for _, item := range overrides {
+		if !replaced[key(item)] {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
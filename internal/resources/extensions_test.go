@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func containerNames(containers []corev1.Container) []string {
+	names := make([]string, 0, len(containers))
+	for _, c := range containers {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func TestApplyPodExtensions(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{
+		Sidecars: []corev1.Container{{Name: "log-shipper", Image: "fluent/fluent-bit:3.0"}},
+		InitContainers: []corev1.Container{
+			{Name: "seed", Image: "busybox:1.36"},
+			{Name: "copy-keyfile", Image: "registry.example.com/busybox:1.36"},
+		},
+		Volumes: []corev1.Volume{
+			{Name: "seed-data", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		},
+		VolumeMounts: []corev1.VolumeMount{{Name: "seed-data", MountPath: "/seed"}},
+	}
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+
+	assert.Equal(t, []string{"mongodb", "log-shipper"}, containerNames(pod.Containers))
+	assert.Equal(t, []string{"copy-keyfile", "seed"}, containerNames(pod.InitContainers))
+	assert.Equal(t, "registry.example.com/busybox:1.36", pod.InitContainers[0].Image, "same name replaces")
+
+	var volumes []string
+	for _, v := range pod.Volumes {
+		volumes = append(volumes, v.Name)
+	}
+	assert.Contains(t, volumes, "seed-data")
+	assert.Contains(t, volumes, "keyfile")
+
+	assert.Contains(t, pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "seed-data", MountPath: "/seed"})
+	assert.Empty(t, pod.Containers[1].VolumeMounts, "extra mounts only go to the database container")
+}
+
+func TestApplyPodExtensionsMongos(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.Pod = &mongodbv1alpha1.PodSpec{
+		VolumeMounts: []corev1.VolumeMount{{Name: "agent", MountPath: "/agent"}},
+	}
+
+	pod := BuildMongosDeployment(mdbsh).Spec.Template.Spec
+	require.Equal(t, "mongos", pod.Containers[0].Name)
+	assert.Contains(t, pod.Containers[0].VolumeMounts, corev1.VolumeMount{Name: "agent", MountPath: "/agent"})
+}
+
+func TestMergeByKey(t *testing.T) {
+	key := func(s string) string { return s[:1] }
+
+	assert.Equal(t, []string{"a1", "b1"}, mergeByKey([]string{"a1", "b1"}, nil, key))
+	assert.Equal(t, []string{"a2", "b1", "c2"}, mergeByKey([]string{"a1", "b1"}, []string{"c2", "a2"}, key))
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// ExternalComponent is the component label of the per-member external Services
+const ExternalComponent = "external"
+
+// ExternalAccessEnabled reports whether per-member external Services are requested
+func ExternalAccessEnabled(mdb *mongodbv1alpha1.MongoDB) bool {
+	return mdb.Spec.ExternalAccess != nil && mdb.Spec.ExternalAccess.Enabled
+}
+
+// ExternalServiceName returns the name of the external Service of a replica set member
+func ExternalServiceName(clusterName string, ordinal int32) string {
+	return fmt.Sprintf("%s-%d-external", clusterName, ordinal)
+}
+
+func externalServiceType(spec *mongodbv1alpha1.ExternalAccessSpec) corev1.ServiceType {
+	if spec.Type == string(corev1.ServiceTypeNodePort) {
+		return corev1.ServiceTypeNodePort
+	}
+	return corev1.ServiceTypeLoadBalancer
+}
+
+// BuildExternalService creates the Service exposing a single replica set member
+func BuildExternalService(mdb *mongodbv1alpha1.MongoDB, ordinal int32) *corev1.Service {
+	spec := mdb.Spec.ExternalAccess
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ExternalServiceName(mdb.Name, ordinal),
+			Namespace: mdb.Namespace,
+			Labels:    buildLabels(mdb.Name, ExternalComponent),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: externalServiceType(spec),
+			// Target exactly one pod so drivers can address each member individually
+			Selector: map[string]string{
+				"statefulset.kubernetes.io/pod-name": fmt.Sprintf("%s-%d", mdb.Name, ordinal),
+			},
+			Ports: []corev1.ServicePort{
+				{Name: "mongodb", Port: ReplicaSetPort(mdb), TargetPort: intstr.FromInt(int(ReplicaSetPort(mdb)))},
+			},
+			PublishNotReadyAddresses: true,
+		},
+	}
+	applyServiceMetadata(svc, mdb.Spec.Service)
+	// The annotations of the external access win over the common Service annotations
+	svc.Annotations = mergeStringMaps(svc.Annotations, spec.Annotations)
+	applyServiceMeshPorts(svc, mdb.Spec.ServiceMesh)
+	return svc
+}
+
+// BuildExternalServices creates one external Service per replica set member running in this
+// Kubernetes cluster
+func BuildExternalServices(mdb *mongodbv1alpha1.MongoDB) []*corev1.Service {
+	first, count := LocalMembers(mdb)
+	services := make([]*corev1.Service, 0, count)
+	for i := first; i < first+count; i++ {
+		services = append(services, BuildExternalService(mdb, i))
+	}
+	return services
+}
+
+// ExternalHost returns the externally reachable host:port of a replica set member,
+// or an empty string while its Service has no address assigned yet. pod may be nil.
+func ExternalHost(mdb *mongodbv1alpha1.MongoDB, ordinal int32, svc *corev1.Service, pod *corev1.Pod) string {
+	spec := mdb.Spec.ExternalAccess
+
+	var hostname string
+	if int(ordinal) < len(spec.Hostnames) {
+		hostname = spec.Hostnames[ordinal]
+	}
+	// An explicit host:port is used verbatim
+	if strings.Contains(hostname, ":") {
+		return hostname
+	}
+
+	port := ReplicaSetPort(mdb)
+	if externalServiceType(spec) == corev1.ServiceTypeNodePort {
+		if svc == nil || len(svc.Spec.Ports) == 0 || svc.Spec.Ports[0].NodePort == 0 {
+			return ""
+		}
+		port = svc.Spec.Ports[0].NodePort
+	}
+
+	if hostname != "" {
+		return net.JoinHostPort(hostname, strconv.Itoa(int(port)))
+	}
+	if svc == nil {
+		return ""
+	}
+	if externalServiceType(spec) == corev1.ServiceTypeNodePort {
+		if pod == nil || pod.Status.HostIP == "" {
+			return ""
+		}
+		return net.JoinHostPort(pod.Status.HostIP, strconv.Itoa(int(port)))
+	}
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		if ingress.Hostname != "" {
+			return net.JoinHostPort(ingress.Hostname, strconv.Itoa(int(port)))
+		}
+		if ingress.IP != "" {
+			return net.JoinHostPort(ingress.IP, strconv.Itoa(int(port)))
+		}
+	}
+	return ""
+}
+
+// HorizonName returns the name of the replica set horizon advertising the external hosts
+func HorizonName(spec *mongodbv1alpha1.ExternalAccessSpec) string {
+	if spec == nil || spec.HorizonName == "" {
+		return "external"
+	}
+	return spec.HorizonName
+}
+
+// BuildHorizons maps the pod name of every member to its replica set horizons. It returns nil
+// until every member has an external host, as horizons must be defined on all members at once.
+func BuildHorizons(mdb *mongodbv1alpha1.MongoDB, hosts []string) map[string]map[string]string {
+	if len(hosts) == 0 {
+		return nil
+	}
+	horizons := make(map[string]map[string]string, len(hosts))
+	for i, host := range hosts {
+		if host == "" {
+			return nil
+		}
+		horizons[fmt.Sprintf("%s-%d", mdb.Name, i)] = map[string]string{HorizonName(mdb.Spec.ExternalAccess): host}
+	}
+	return horizons
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestExternalAccessEnabled(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{Enabled: true, Type: "LoadBalancer"}
+	assert.True(t, ExternalAccessEnabled(mdb))
+
+	mdb.Spec.ExternalAccess.Enabled = false
+	assert.False(t, ExternalAccessEnabled(mdb))
+
+	mdb.Spec.ExternalAccess = nil
+	assert.False(t, ExternalAccessEnabled(mdb))
+}
+
+func TestBuildExternalServices(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{
+		Enabled:     true,
+		Type:        "",
+		Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+	}
+
+	services := BuildExternalServices(mdb)
+	require.Len(t, services, 3)
+
+	for i, svc := range services {
+		assert.Equal(t, ExternalServiceName("my-mongodb", int32(i)), svc.Name)
+		assert.Equal(t, "default", svc.Namespace)
+		assert.Equal(t, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+		assert.Equal(t, "external", svc.Labels["app.kubernetes.io/component"])
+		assert.Equal(t, "nlb", svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"])
+		assert.True(t, svc.Spec.PublishNotReadyAddresses)
+		require.Len(t, svc.Spec.Ports, 1)
+		assert.Equal(t, int32(27017), svc.Spec.Ports[0].Port)
+	}
+	assert.Equal(t, "my-mongodb-1-external", services[1].Name)
+	assert.Equal(t, map[string]string{"statefulset.kubernetes.io/pod-name": "my-mongodb-1"}, services[1].Spec.Selector)
+
+	mdb.Spec.ExternalAccess.Type = "NodePort"
+	assert.Equal(t, corev1.ServiceTypeNodePort, BuildExternalService(mdb, 0).Spec.Type)
+}
+
+func TestExternalHostLoadBalancer(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{Enabled: true, Type: "LoadBalancer"}
+	svc := BuildExternalService(mdb, 0)
+
+	assert.Empty(t, ExternalHost(mdb, 0, nil, nil))
+	assert.Empty(t, ExternalHost(mdb, 0, svc, nil))
+
+	svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}}
+	assert.Equal(t, "203.0.113.10:27017", ExternalHost(mdb, 0, svc, nil))
+
+	svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "abc.elb.amazonaws.com", IP: "203.0.113.10"}}
+	assert.Equal(t, "abc.elb.amazonaws.com:27017", ExternalHost(mdb, 0, svc, nil))
+
+	mdb.Spec.Port = 27100
+	assert.Equal(t, "abc.elb.amazonaws.com:27100", ExternalHost(mdb, 0, svc, nil))
+	assert.Equal(t, int32(27100), BuildExternalService(mdb, 0).Spec.Ports[0].Port)
+}
+
+func TestExternalHostNodePort(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{Enabled: true, Type: "NodePort"}
+	svc := BuildExternalService(mdb, 1)
+	pod := &corev1.Pod{Status: corev1.PodStatus{HostIP: "10.0.0.5"}}
+
+	assert.Empty(t, ExternalHost(mdb, 1, svc, pod), "node port not allocated yet")
+
+	svc.Spec.Ports[0].NodePort = 30017
+	assert.Empty(t, ExternalHost(mdb, 1, svc, nil), "pod not scheduled yet")
+	assert.Equal(t, "10.0.0.5:30017", ExternalHost(mdb, 1, svc, pod))
+}
+
+func TestExternalHostHostnames(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{Enabled: true, Type: "NodePort"}
+	mdb.Spec.ExternalAccess.Hostnames = []string{"mongo-0.example.com", "mongo-1.example.com:443"}
+	svc := BuildExternalService(mdb, 0)
+	svc.Spec.Ports[0].NodePort = 30017
+
+	assert.Equal(t, "mongo-0.example.com:30017", ExternalHost(mdb, 0, svc, nil))
+	assert.Equal(t, "mongo-1.example.com:443", ExternalHost(mdb, 1, nil, nil))
+	assert.Empty(t, ExternalHost(mdb, 2, BuildExternalService(mdb, 2), nil))
+
+	mdb.Spec.ExternalAccess.Type = "LoadBalancer"
+	assert.Equal(t, "mongo-0.example.com:27017", ExternalHost(mdb, 0, nil, nil))
+}
+
+func TestHorizonName(t *testing.T) {
+	assert.Equal(t, "external", HorizonName(nil))
+	assert.Equal(t, "external", HorizonName(&mongodbv1alpha1.ExternalAccessSpec{}))
+	assert.Equal(t, "public", HorizonName(&mongodbv1alpha1.ExternalAccessSpec{HorizonName: "public"}))
+}
+
+func TestBuildHorizons(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{Enabled: true, Type: "LoadBalancer"}
+
+	assert.Nil(t, BuildHorizons(mdb, nil))
+	assert.Nil(t, BuildHorizons(mdb, []string{"mongo-0.example.com:27017", "", "mongo-2.example.com:27017"}),
+		"horizons wait for every member")
+
+	horizons := BuildHorizons(mdb, []string{"mongo-0.example.com:27017", "mongo-1.example.com:27017"})
+	assert.Equal(t, map[string]map[string]string{
+		"my-mongodb-0": {"external": "mongo-0.example.com:27017"},
+		"my-mongodb-1": {"external": "mongo-1.example.com:27017"},
+	}, horizons)
+
+	mdb.Spec.ExternalAccess.HorizonName = "public"
+	horizons = BuildHorizons(mdb, []string{"mongo-0.example.com:27017"})
+	assert.Equal(t, "mongo-0.example.com:27017", horizons["my-mongodb-0"]["public"])
+}
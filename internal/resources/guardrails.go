@@ -0,0 +1,162 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// ForceUpdateAnnotation set to "true" lets the operator roll out changes it refuses otherwise,
+	// such as a release series downgrade or a new replica set name. Remove it once the change is
+	// applied.
+	ForceUpdateAnnotation = "mongodb.keiailab.com/force-update"
+
+	// InvalidUpdateCondition is the condition reporting a change the operator refuses to roll out
+	InvalidUpdateCondition = "InvalidUpdate"
+)
+
+// ForceUpdate reports whether the force-update annotation allows risky changes
+func ForceUpdate(annotations map[string]string) bool {
+	return annotations[ForceUpdateAnnotation] == "true"
+}
+
+// ReplicaSetSettings returns the settings of a replica set that can not be changed safely in place
+func ReplicaSetSettings(mdb *mongodbv1alpha1.MongoDB) *mongodbv1alpha1.AppliedSettings {
+	settings := &mongodbv1alpha1.AppliedSettings{
+		Version:        mdb.Spec.Version.Version,
+		ReplicaSetName: mdb.Spec.ReplicaSetName,
+		AuthMechanism:  mdb.Spec.Auth.Mechanism,
+	}
+	addStorageSize(settings, ModeReplicaSet, mdb.Spec.Storage)
+	addStorageLayout(settings, mdb.Spec.StorageEngine, mdb.Spec.AdditionalConfig)
+	return settings
+}
+
+// ShardedSettings returns the settings of a sharded cluster that can not be changed safely in place
+func ShardedSettings(mdbsh *mongodbv1alpha1.MongoDBSharded) *mongodbv1alpha1.AppliedSettings {
+	settings := &mongodbv1alpha1.AppliedSettings{
+		Version:       mdbsh.Spec.Version.Version,
+		AuthMechanism: mdbsh.Spec.Auth.Mechanism,
+	}
+	addStorageSize(settings, ComponentConfigServer, mdbsh.Spec.ConfigServer.Storage)
+	addStorageSize(settings, ComponentShards, mdbsh.Spec.Shards.Storage)
+	addStorageLayout(settings, mdbsh.Spec.StorageEngine, mdbsh.Spec.AdditionalConfig)
+	return settings
+}
+
+// addStorageSize records the size of the volumes of a component. The volume claim templates of the
+// StatefulSets keep their size, components on ephemeral storage or without a size have none.
+func addStorageSize(settings *mongodbv1alpha1.AppliedSettings, component string, spec mongodbv1alpha1.StorageSpec) {
+	if EphemeralStorage(spec) || spec.Size.IsZero() {
+		return
+	}
+	if settings.StorageSizes == nil {
+		settings.StorageSizes = map[string]resource.Quantity{}
+	}
+	settings.StorageSizes[component] = spec.Size
+}
+
+// ValidateSettingsChange refuses the changes from the applied settings that can not be rolled
+// out in place: mongod refuses to start on data of a newer release series until the
+// featureCompatibilityVersion is lowered, volumes can not shrink, members keep the replica set
+// name in their local configuration, users only hold credentials of the mechanisms they were
+// created with, and existing volumes keep the compressor and directory layout of their data
+// files. Nothing is refused before settings were applied.
+func ValidateSettingsChange(applied, desired *mongodbv1alpha1.AppliedSettings) error {
+	if applied == nil || desired == nil {
+		return nil
+	}
+
+	var changes []string
+	if applied.Version != "" && releaseSeriesDowngrade(applied.Version, desired.Version) {
+		changes = append(changes, fmt.Sprintf("version %s downgrades %s, lower the featureCompatibilityVersion first",
+			desired.Version, applied.Version))
+	}
+	if applied.ReplicaSetName != "" && desired.ReplicaSetName != applied.ReplicaSetName {
+		changes = append(changes, fmt.Sprintf("replicaSetName %s replaces %s, the members keep the old name in their data",
+			desired.ReplicaSetName, applied.ReplicaSetName))
+	}
+	if applied.AuthMechanism != "" && desired.AuthMechanism != applied.AuthMechanism {
+		changes = append(changes, fmt.Sprintf("auth mechanism %s replaces %s, existing users have no credentials for it",
+			desired.AuthMechanism, applied.AuthMechanism))
+	}
+	changes = append(changes, storageLayoutChanges(applied, desired)...)
+
+	components := make([]string, 0, len(applied.StorageSizes))
+	for component := range applied.StorageSizes {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		size, ok := desired.StorageSizes[component]
+		if !ok {
+			continue
+		}
+		if appliedSize := applied.StorageSizes[component]; size.Cmp(appliedSize) < 0 {
+			changes = append(changes, fmt.Sprintf("%s storage size %s is smaller than %s, volumes can not shrink",
+				component, size.String(), appliedSize.String()))
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s; set the %s annotation to \"true\" to apply it anyway",
+		strings.Join(changes, "; "), ForceUpdateAnnotation)
+}
+
+// BuildInvalidUpdateCondition builds the InvalidUpdate condition from the error of the settings
+// change validation, nil when the spec can be rolled out
+func BuildInvalidUpdateCondition(err error, generation int64) metav1.Condition {
+	if err == nil {
+		return metav1.Condition{
+			Type:               InvalidUpdateCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Applied",
+			Message:            "The spec can be rolled out in place",
+		}
+	}
+	return metav1.Condition{
+		Type:               InvalidUpdateCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "RiskyChange",
+		Message:            err.Error(),
+	}
+}
+
+// releaseSeriesDowngrade reports whether to runs an older major.minor release series than from.
+// Versions that do not parse are left to the other validations.
+func releaseSeriesDowngrade(from, to string) bool {
+	fromMajor, fromMinor, ok := parseReleaseSeries(from)
+	if !ok {
+		return false
+	}
+	if _, _, ok := parseReleaseSeries(to); !ok {
+		return false
+	}
+	return !versionAtLeast(to, fromMajor, fromMinor)
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateSettingsChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(mdb *mongodbv1alpha1.MongoDB)
+		err    string
+	}{
+		{"unchanged", func(mdb *mongodbv1alpha1.MongoDB) {}, ""},
+		{"upgrade", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Version.Version = "8.2" }, ""},
+		{"patch downgrade", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Version.Version = "8.0.1" }, ""},
+		{"release series downgrade", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Version.Version = "7.0" },
+			"version 7.0 downgrades 8.0.4, lower the featureCompatibilityVersion first"},
+		{"larger volumes", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Storage.Size = resource.MustParse("20Gi") }, ""},
+		{"smaller volumes", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Storage.Size = resource.MustParse("5Gi") },
+			"ReplicaSet storage size 5Gi is smaller than 10Gi"},
+		{"ephemeral storage", func(mdb *mongodbv1alpha1.MongoDB) {
+			mdb.Spec.Storage.Type = StorageTypeEphemeral
+			mdb.Spec.Storage.Size = resource.MustParse("1Gi")
+		}, ""},
+		{"replica set name", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.ReplicaSetName = "rs1" },
+			"replicaSetName rs1 replaces rs0"},
+		{"auth mechanism", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Auth.Mechanism = "SCRAM-SHA-1" },
+			"auth mechanism SCRAM-SHA-1 replaces SCRAM-SHA-256"},
+		{"block compressor", func(mdb *mongodbv1alpha1.MongoDB) {
+			mdb.Spec.StorageEngine = &mongodbv1alpha1.StorageEngineSpec{BlockCompressor: "zstd"}
+		}, "block compressor zstd replaces snappy"},
+		{"directoryPerDB", func(mdb *mongodbv1alpha1.MongoDB) {
+			mdb.Spec.StorageEngine = &mongodbv1alpha1.StorageEngineSpec{DirectoryPerDB: true}
+		}, "directoryPerDB true replaces false"},
+		{"directoryPerDB in additionalConfig", func(mdb *mongodbv1alpha1.MongoDB) {
+			mdb.Spec.AdditionalConfig = map[string]string{"storage.directoryPerDB": "true"}
+		}, "directoryPerDB true replaces false"},
+		{"journal", func(mdb *mongodbv1alpha1.MongoDB) {
+			mdb.Spec.StorageEngine = &mongodbv1alpha1.StorageEngineSpec{
+				Journal: &mongodbv1alpha1.JournalSpec{CommitIntervalMs: int32Ptr(50), Compressor: "zstd"},
+			}
+		}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := testMongoDB()
+			mdb.Spec.Version.Version = "8.0.4"
+			mdb.Spec.ReplicaSetName = "rs0"
+			mdb.Spec.Auth.Mechanism = "SCRAM-SHA-256"
+			mdb.Spec.Storage.Size = resource.MustParse("10Gi")
+			applied := ReplicaSetSettings(mdb)
+
+			tt.mutate(mdb)
+			err := ValidateSettingsChange(applied, ReplicaSetSettings(mdb))
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+			assert.ErrorContains(t, err, ForceUpdateAnnotation)
+		})
+	}
+}
+
+func TestValidateSettingsChangeSharded(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards.Storage.Size = resource.MustParse("100Gi")
+	applied := ShardedSettings(mdbsh)
+	assert.Empty(t, applied.ReplicaSetName)
+
+	mdbsh.Spec.Shards.Storage.Size = resource.MustParse("50Gi")
+	assert.ErrorContains(t, ValidateSettingsChange(applied, ShardedSettings(mdbsh)), "Shards storage size 50Gi is smaller than 100Gi")
+
+	// Nothing is refused before the cluster was reconciled once
+	assert.NoError(t, ValidateSettingsChange(nil, ShardedSettings(mdbsh)))
+}
+
+func TestForceUpdate(t *testing.T) {
+	assert.False(t, ForceUpdate(nil))
+	assert.False(t, ForceUpdate(map[string]string{ForceUpdateAnnotation: "yes"}))
+	assert.True(t, ForceUpdate(map[string]string{ForceUpdateAnnotation: "true"}))
+}
+
+func TestBuildInvalidUpdateCondition(t *testing.T) {
+	condition := BuildInvalidUpdateCondition(nil, 3)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, int64(3), condition.ObservedGeneration)
+
+	condition = BuildInvalidUpdateCondition(assert.AnError, 4)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "RiskyChange", condition.Reason)
+	assert.Equal(t, assert.AnError.Error(), condition.Message)
+}
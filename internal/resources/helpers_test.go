@@ -0,0 +1,57 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// testMongoDB returns a 3 member replica set, tests set the spec fields they cover
+func testMongoDB() *mongodbv1alpha1.MongoDB {
+	return &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 3,
+			Version: mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+		},
+	}
+}
+
+// testMongoDBSharded returns a sharded cluster of 2 shards of 3 members, tests set the spec fields
+// they cover
+func testMongoDBSharded() *mongodbv1alpha1.MongoDBSharded {
+	return &mongodbv1alpha1.MongoDBSharded{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sharded", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBShardedSpec{
+			Version:      mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+			ConfigServer: mongodbv1alpha1.ConfigServerSpec{Members: 3},
+			Shards:       mongodbv1alpha1.ShardSpec{Count: 2, MembersPerShard: 3},
+			Mongos:       mongodbv1alpha1.MongosSpec{Replicas: 2},
+		},
+	}
+}
+
+// testProvidedAuth references admin credentials and a keyfile from user Secrets, as the members of
+// a replica set spread over Kubernetes clusters require
+func testProvidedAuth() mongodbv1alpha1.AuthSpec {
+	return mongodbv1alpha1.AuthSpec{
+		AdminCredentialsSecretRef: mongodbv1alpha1.CredentialsSecretRef{Name: "admin"},
+		KeyfileSecretRef:          &mongodbv1alpha1.KeyfileSecretRef{Name: "keyfile"},
+	}
+}
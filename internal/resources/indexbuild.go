@@ -0,0 +1,192 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"path"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// Index build strategies of spec.indexBuild.strategy
+const (
+	IndexBuildSimultaneous = "Simultaneous"
+	IndexBuildRolling      = "Rolling"
+	IndexBuildAuto         = "Auto"
+)
+
+// Phases of a member in a rolling index build
+const (
+	RollingIndexBuildRestarting = "Restarting"
+	RollingIndexBuildBuilding   = "Building"
+	RollingIndexBuildRejoining  = "Rejoining"
+	RollingIndexBuildFailed     = "Failed"
+)
+
+const (
+	// MaintenanceConfigKey is the ConfigMap key of the configuration a replica set member runs with
+	// out of the replica set, to build indexes
+	MaintenanceConfigKey = "mongod-maintenance.conf"
+
+	// maintenanceMarker is the file of the dbPath that starts mongod with the maintenance
+	// configuration. It is removed as mongod starts, the next restart rejoins the replica set.
+	maintenanceMarker = ".mongodb-operator-maintenance"
+
+	// minRollingIndexBuildMembers keeps a majority up while one member builds out of the replica set
+	minRollingIndexBuildMembers = 3
+)
+
+// defaultRollingThreshold is the collection size from which the Auto strategy rolls index builds
+var defaultRollingThreshold = resource.MustParse("10Gi")
+
+// validateIndexBuild checks that a rolling index build targets a replica set, the shards of a
+// sharded cluster build their indexes through mongos
+func validateIndexBuild(spec mongodbv1alpha1.MongoDBCollectionSpec) error {
+	if spec.IndexBuild != nil && spec.IndexBuild.Strategy == IndexBuildRolling && spec.ClusterRef.Kind == "MongoDBSharded" {
+		return fmt.Errorf("rolling index builds require a MongoDB replica set, got %s", spec.ClusterRef.Kind)
+	}
+	return nil
+}
+
+// RollingIndexBuildSupported checks that a replica set can build indexes one member at a time:
+// with at least 3 members, all of them in this Kubernetes cluster where the operator restarts them
+func RollingIndexBuildSupported(mdb *mongodbv1alpha1.MongoDB) error {
+	if Standalone(mdb) {
+		return fmt.Errorf("rolling index builds require a replica set, %s runs a standalone mongod", mdb.Name)
+	}
+	if mdb.Spec.Members < minRollingIndexBuildMembers {
+		return fmt.Errorf("rolling index builds require at least %d members, %s has %d",
+			minRollingIndexBuildMembers, mdb.Name, mdb.Spec.Members)
+	}
+	if MultiClusterEnabled(mdb) {
+		return fmt.Errorf("rolling index builds are not supported on the multi-cluster replica set %s", mdb.Name)
+	}
+	return nil
+}
+
+// RollingIndexBuild reports whether the missing indexes of a collection of size bytes are built one
+// member at a time
+func RollingIndexBuild(spec *mongodbv1alpha1.IndexBuildSpec, size int64) bool {
+	if spec == nil {
+		return false
+	}
+	switch spec.Strategy {
+	case IndexBuildRolling:
+		return true
+	case IndexBuildAuto:
+		threshold := defaultRollingThreshold
+		if spec.RollingThreshold != nil {
+			threshold = *spec.RollingThreshold
+		}
+		return size >= threshold.Value()
+	}
+	return false
+}
+
+// NextRollingIndexBuildMember picks the member to build the indexes on next among pending: a
+// secondary, in pod order, then the primary once it is the only one left, which steps down first.
+// Nothing is picked while a member is not PRIMARY or SECONDARY, taking one more out of the
+// replica set could cost the majority.
+func NextRollingIndexBuildMember(states map[string]string, pending []string) (member string, stepDown bool) {
+	for _, state := range states {
+		if state != "PRIMARY" && state != "SECONDARY" {
+			return "", false
+		}
+	}
+	for _, pod := range slices.Sorted(slices.Values(pending)) {
+		if states[pod] == "SECONDARY" {
+			return pod, false
+		}
+	}
+	for _, pod := range pending {
+		if states[pod] == "PRIMARY" {
+			return pod, true
+		}
+	}
+	return "", false
+}
+
+// FormatRollingIndexProgress renders the progress of a rolling index build for status, e.g.
+// "1/3 members, my-mongodb-2: 1200/5000 (24%)"
+func FormatRollingIndexProgress(completed, members int, member string, done, total int64) string {
+	progress := fmt.Sprintf("%d/%d members", completed, members)
+	if member == "" {
+		return progress
+	}
+	progress += ", " + member
+	if p := FormatIndexProgress(done, total); p != "" {
+		progress += ": " + p
+	}
+	return progress
+}
+
+// MaintenanceMarkerPath returns the path of the file that starts a member out of the replica set
+func MaintenanceMarkerPath(mdb *mongodbv1alpha1.MongoDB) string {
+	dbPath := mdb.Spec.Storage.DataDirPath
+	if dbPath == "" {
+		dbPath = "/data/db"
+	}
+	return path.Join(dbPath, maintenanceMarker)
+}
+
+// BuildMaintenanceConfig renders the mongod.conf of a replica set member building indexes out of
+// the replica set: the one of the member without the replication settings and without the
+// logical session cache refresh, which needs a replica set. It keeps the port and the
+// authorization so the operator still reaches the member.
+func BuildMaintenanceConfig(mdb *mongodbv1alpha1.MongoDB) string {
+	var config map[string]interface{}
+	if err := yaml.Unmarshal([]byte(BuildReplicaSetConfig(mdb)), &config); err != nil {
+		return ""
+	}
+	deleteConfigValue(config, "replication")
+	setConfigValue(config, "setParameter.disableLogicalSessionCacheRefresh", true)
+	return renderConfig(config, nil)
+}
+
+// applyMaintenanceMode mounts the maintenance configuration into the mongod container and starts
+// mongod through a shell that picks it once when the marker exists in the dbPath. The marker is
+// removed before mongod starts, so a member left out of the replica set rejoins on its next
+// restart.
+func applyMaintenanceMode(template *corev1.PodTemplateSpec, container, marker string) {
+	spec := &template.Spec
+	for i := range spec.Volumes {
+		if v := &spec.Volumes[i]; v.Name == configVolumeName && v.ConfigMap != nil {
+			v.ConfigMap.Items = append(v.ConfigMap.Items, corev1.KeyToPath{Key: MaintenanceConfigKey, Path: MaintenanceConfigKey})
+		}
+	}
+
+	script := fmt.Sprintf(`if [ -f %[1]s ]; then
+  rm -f %[1]s
+  echo "Starting mongod out of the replica set for maintenance"
+  exec mongod --config %[2]s
+fi
+exec mongod "$@"
+`, marker, configMountPath+"/"+MaintenanceConfigKey)
+
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if c.Name == container {
+			c.Command = []string{"/bin/bash", "-c", script, "mongod"}
+		}
+	}
+}
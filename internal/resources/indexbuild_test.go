@@ -0,0 +1,124 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateIndexBuild(t *testing.T) {
+	spec := testCollectionSpec()
+	spec.IndexBuild = &mongodbv1alpha1.IndexBuildSpec{Strategy: IndexBuildAuto}
+	assert.NoError(t, validateIndexBuild(spec), "large collections of sharded clusters are built simultaneously")
+
+	spec.IndexBuild.Strategy = IndexBuildRolling
+	assert.EqualError(t, validateIndexBuild(spec), "rolling index builds require a MongoDB replica set, got MongoDBSharded")
+
+	spec.ClusterRef.Kind = "MongoDB"
+	assert.NoError(t, validateIndexBuild(spec))
+}
+
+func TestRollingIndexBuildSupported(t *testing.T) {
+	mdb := testMongoDB()
+	assert.NoError(t, RollingIndexBuildSupported(mdb))
+
+	mdb.Spec.Members = 2
+	assert.EqualError(t, RollingIndexBuildSupported(mdb), "rolling index builds require at least 3 members, my-mongodb has 2")
+
+	mdb.Spec.Members = 1
+	mdb.Spec.Mode = ModeStandalone
+	assert.Error(t, RollingIndexBuildSupported(mdb))
+}
+
+func TestRollingIndexBuild(t *testing.T) {
+	const gi = int64(1 << 30)
+	assert.False(t, RollingIndexBuild(nil, 100*gi))
+	assert.False(t, RollingIndexBuild(&mongodbv1alpha1.IndexBuildSpec{Strategy: IndexBuildSimultaneous}, 100*gi))
+	assert.True(t, RollingIndexBuild(&mongodbv1alpha1.IndexBuildSpec{Strategy: IndexBuildRolling}, 0))
+
+	auto := &mongodbv1alpha1.IndexBuildSpec{Strategy: IndexBuildAuto}
+	assert.False(t, RollingIndexBuild(auto, 10*gi-1))
+	assert.True(t, RollingIndexBuild(auto, 10*gi))
+
+	threshold := resource.MustParse("500Mi")
+	auto.RollingThreshold = &threshold
+	assert.True(t, RollingIndexBuild(auto, gi))
+}
+
+func TestNextRollingIndexBuildMember(t *testing.T) {
+	states := map[string]string{"db-0": "PRIMARY", "db-1": "SECONDARY", "db-2": "SECONDARY"}
+
+	member, stepDown := NextRollingIndexBuildMember(states, []string{"db-2", "db-0", "db-1"})
+	assert.Equal(t, "db-1", member)
+	assert.False(t, stepDown)
+
+	member, stepDown = NextRollingIndexBuildMember(states, []string{"db-0"})
+	assert.Equal(t, "db-0", member)
+	assert.True(t, stepDown, "the primary builds last, once stepped down")
+
+	states["db-2"] = "RECOVERING"
+	member, _ = NextRollingIndexBuildMember(states, []string{"db-1"})
+	assert.Empty(t, member, "one member is already out of the replica set")
+}
+
+func TestFormatRollingIndexProgress(t *testing.T) {
+	assert.Equal(t, "0/3 members", FormatRollingIndexProgress(0, 3, "", 0, 0))
+	assert.Equal(t, "1/3 members, db-2", FormatRollingIndexProgress(1, 3, "db-2", 0, 0))
+	assert.Equal(t, "1/3 members, db-2: 1200/5000 (24%)", FormatRollingIndexProgress(1, 3, "db-2", 1200, 5000))
+}
+
+func TestMaintenanceMode(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ReplicaSetName = "rs0"
+	mdb.Spec.Storage.DataDirPath = "/data/db"
+	mdb.Spec.AdditionalConfig = map[string]string{"replication.oplogSizeMB": "2048"}
+
+	config := BuildMaintenanceConfig(mdb)
+	assert.NotContains(t, config, "replication")
+	assert.Contains(t, config, "disableLogicalSessionCacheRefresh: true")
+	assert.Contains(t, config, "port: 27017")
+	assert.Contains(t, config, "authorization: enabled")
+
+	cm := BuildReplicaSetConfigMap(mdb)
+	assert.Equal(t, config, cm.Data[MaintenanceConfigKey])
+
+	sts := BuildReplicaSetStatefulSet(mdb)
+	mongod := findContainer(sts.Spec.Template.Spec.Containers, "mongodb")
+	require.Len(t, mongod.Command, 4)
+	assert.Contains(t, mongod.Command[2], "rm -f /data/db/.mongodb-operator-maintenance")
+	assert.Contains(t, mongod.Command[2], "exec mongod --config /etc/mongodb-config/mongod-maintenance.conf")
+	assert.Equal(t, []string{"--config", "/etc/mongodb-config/mongod.conf"}, mongod.Args, "the replica set configuration is the default")
+	for _, v := range sts.Spec.Template.Spec.Volumes {
+		if v.Name == configVolumeName {
+			assert.Contains(t, v.ConfigMap.Items, corev1.KeyToPath{Key: MaintenanceConfigKey, Path: MaintenanceConfigKey})
+		}
+	}
+
+	// A standalone mongod has no replica set to leave
+	mdb.Spec.Members = 1
+	mdb.Spec.Mode = ModeStandalone
+	assert.NotContains(t, BuildReplicaSetConfigMap(mdb).Data, MaintenanceConfigKey)
+	sts = BuildReplicaSetStatefulSet(mdb)
+	assert.Empty(t, findContainer(sts.Spec.Template.Spec.Containers, "mongodb").Command)
+}
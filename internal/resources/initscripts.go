@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// ValidateInitScripts checks every init script names a single source and that JSON files name
+// the collection their documents are inserted into
+func ValidateInitScripts(scripts []mongodbv1alpha1.InitScript) error {
+	seen := make(map[string]bool, len(scripts))
+	for _, script := range scripts {
+		if seen[script.Name] {
+			return fmt.Errorf("init script %s is listed more than once", script.Name)
+		}
+		seen[script.Name] = true
+
+		if (script.ConfigMapRef == nil) == (script.SecretRef == nil) {
+			return fmt.Errorf("init script %s requires exactly one of configMapRef and secretRef", script.Name)
+		}
+		if InitScriptKey(script) == "" {
+			return fmt.Errorf("init script %s requires the key holding the script", script.Name)
+		}
+		if IsJSONInitScript(script) && (script.Database == "" || script.Collection == "") {
+			return fmt.Errorf("init script %s is a JSON file and requires the database and collection its documents are inserted into", script.Name)
+		}
+	}
+	return nil
+}
+
+// InitScriptKey returns the key of the ConfigMap or Secret holding an init script
+func InitScriptKey(script mongodbv1alpha1.InitScript) string {
+	if script.ConfigMapRef != nil {
+		return script.ConfigMapRef.Key
+	}
+	if script.SecretRef != nil {
+		return script.SecretRef.Key
+	}
+	return ""
+}
+
+// IsJSONInitScript reports whether an init script holds documents to insert rather than JavaScript
+func IsJSONInitScript(script mongodbv1alpha1.InitScript) bool {
+	return strings.HasSuffix(InitScriptKey(script), ".json")
+}
+
+// InitScriptDatabase returns the database an init script runs against, admin by default
+func InitScriptDatabase(script mongodbv1alpha1.InitScript) string {
+	if script.Database == "" {
+		return "admin"
+	}
+	return script.Database
+}
+
+// PendingInitScripts returns the init scripts that did not run yet, in order
+func PendingInitScripts(scripts []mongodbv1alpha1.InitScript, applied []string) []mongodbv1alpha1.InitScript {
+	var pending []mongodbv1alpha1.InitScript
+	for _, script := range scripts {
+		if !slices.Contains(applied, script.Name) {
+			pending = append(pending, script)
+		}
+	}
+	return pending
+}
+
+// InitScriptsDone reports whether every init script ran, the cluster is only Ready afterwards
+func InitScriptsDone(scripts []mongodbv1alpha1.InitScript, applied []string) bool {
+	return len(PendingInitScripts(scripts, applied)) == 0
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testInitScripts() []mongodbv1alpha1.InitScript {
+	return []mongodbv1alpha1.InitScript{
+		{
+			Name: "roles",
+			ConfigMapRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "app-init"},
+				Key:                  "roles.js",
+			},
+		},
+		{
+			Name: "products",
+			ConfigMapRef: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "app-init"},
+				Key:                  "products.json",
+			},
+			Database:   "shop",
+			Collection: "products",
+		},
+		{
+			Name: "users",
+			SecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "app-users"},
+				Key:                  "users.js",
+			},
+			Database: "shop",
+		},
+	}
+}
+
+func TestValidateInitScripts(t *testing.T) {
+	require.NoError(t, ValidateInitScripts(testInitScripts()))
+	require.NoError(t, ValidateInitScripts(nil))
+
+	tests := []struct {
+		name   string
+		mutate func([]mongodbv1alpha1.InitScript)
+		err    string
+	}{
+		{"duplicate name", func(s []mongodbv1alpha1.InitScript) { s[2].Name = "roles" }, "more than once"},
+		{"no source", func(s []mongodbv1alpha1.InitScript) { s[0].ConfigMapRef = nil }, "exactly one"},
+		{"two sources", func(s []mongodbv1alpha1.InitScript) { s[2].ConfigMapRef = s[0].ConfigMapRef }, "exactly one"},
+		{"no key", func(s []mongodbv1alpha1.InitScript) { s[2].SecretRef.Key = "" }, "key"},
+		{"JSON without collection", func(s []mongodbv1alpha1.InitScript) { s[1].Collection = "" }, "collection"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scripts := testInitScripts()
+			tt.mutate(scripts)
+			assert.ErrorContains(t, ValidateInitScripts(scripts), tt.err)
+		})
+	}
+}
+
+func TestInitScriptKinds(t *testing.T) {
+	scripts := testInitScripts()
+	assert.False(t, IsJSONInitScript(scripts[0]))
+	assert.True(t, IsJSONInitScript(scripts[1]))
+	assert.Equal(t, "users.js", InitScriptKey(scripts[2]))
+	assert.Equal(t, "admin", InitScriptDatabase(scripts[0]))
+	assert.Equal(t, "shop", InitScriptDatabase(scripts[2]))
+}
+
+func TestPendingInitScripts(t *testing.T) {
+	scripts := testInitScripts()
+	assert.Len(t, PendingInitScripts(scripts, nil), 3)
+	assert.False(t, InitScriptsDone(scripts, nil))
+
+	pending := PendingInitScripts(scripts, []string{"products"})
+	require.Len(t, pending, 2)
+	assert.Equal(t, "roles", pending[0].Name, "scripts run in the order listed")
+	assert.Equal(t, "users", pending[1].Name)
+
+	assert.True(t, InitScriptsDone(scripts, []string{"users", "roles", "products"}))
+	assert.True(t, InitScriptsDone(nil, nil))
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// JobCredentialsMountPath is where backup, restore, migration and admin bootstrap Jobs mount their
+// credentials. They are projected from their secrets as files, so neither the pod spec nor the
+// environment of the container holds them.
+const JobCredentialsMountPath = "/etc/mongodb-backup/credentials"
+
+// Files below JobCredentialsMountPath
+const (
+	credentialFileMongoDBPassword = "mongodb-password"
+	credentialFileMongoDBURI      = "mongodb-uri"
+	credentialFileSourceURI       = "source-uri"
+	credentialFileS3AccessKey     = "s3-access-key"
+	credentialFileS3SecretKey     = "s3-secret-key"
+	credentialFileS3CA            = "s3-ca.crt"
+	credentialFileKeyfile         = "keyfile"
+	credentialFileAdminUsername   = "admin-username"
+	credentialFileAdminPassword   = "admin-password"
+)
+
+// credentialFile projects one key of a secret to a file below JobCredentialsMountPath
+type credentialFile struct {
+	secret   string
+	key      string
+	path     string
+	optional *bool
+}
+
+// s3CredentialFiles returns the files of the access and secret keys of an S3 bucket, and of the
+// CA bundle its endpoint is verified with when it has one
+func s3CredentialFiles(s3 *mongodbv1alpha1.S3StorageSpec) []credentialFile {
+	files := []credentialFile{
+		{secret: s3.CredentialsRef.Name, key: "access-key", path: credentialFileS3AccessKey},
+		{secret: s3.CredentialsRef.Name, key: "secret-key", path: credentialFileS3SecretKey},
+	}
+	if ca := s3.CASecretRef; ca != nil {
+		files = append(files, credentialFile{secret: ca.Name, key: ca.Key, path: credentialFileS3CA, optional: ca.Optional})
+	}
+	return files
+}
+
+// addJobCredentials mounts the credential files into the containers of a Job as one projected
+// volume, readable by the owner only
+func addJobCredentials(podSpec *corev1.PodSpec, files []credentialFile) {
+	mode := int32(0400)
+	var sources []corev1.VolumeProjection
+	for _, file := range files {
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: file.secret},
+				Items:                []corev1.KeyToPath{{Key: file.key, Path: file.path}},
+				Optional:             file.optional,
+			},
+		})
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "credentials",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{Sources: sources, DefaultMode: &mode},
+		},
+	})
+	for i := range podSpec.Containers {
+		podSpec.Containers[i].VolumeMounts = append(podSpec.Containers[i].VolumeMounts, corev1.VolumeMount{
+			Name: "credentials", MountPath: JobCredentialsMountPath, ReadOnly: true,
+		})
+	}
+}
+
+// jobCredentialsScript defines the shell helpers the scripts of backup and restore Jobs read
+// their credentials with. mongo_config writes a YAML file for the --config flag of the database
+// tools from key/file pairs, so the credentials are neither in the arguments nor in the
+// environment of the tools. s3_credentials exports the keys of the S3 bucket to the AWS CLI of
+// the script only, and configures the CLI for the endpoint: its CA bundle, path-style addressing
+// and, with S3_INSECURE_SKIP_TLS, an aws function skipping the verification of its certificate.
+const jobCredentialsScript = `
+CREDENTIALS="` + JobCredentialsMountPath + `"
+umask 077
+
+mongo_config() {
+    local config
+    config="$(mktemp)"
+    while [ $# -gt 0 ]; do
+        printf "%s: '%s'\n" "$1" "$(sed "s/'/''/g" "${CREDENTIALS}/$2")" >> "${config}"
+        shift 2
+    done
+    echo "${config}"
+}
+
+s3_credentials() {
+    export AWS_ACCESS_KEY_ID="$(cat "${CREDENTIALS}/` + credentialFileS3AccessKey + `")"
+    export AWS_SECRET_ACCESS_KEY="$(cat "${CREDENTIALS}/` + credentialFileS3SecretKey + `")"
+    if [ -f "${CREDENTIALS}/` + credentialFileS3CA + `" ]; then
+        export AWS_CA_BUNDLE="${CREDENTIALS}/` + credentialFileS3CA + `"
+    fi
+    if [ "${S3_FORCE_PATH_STYLE}" = "true" ]; then
+        export AWS_CONFIG_FILE="$(mktemp)"
+        printf '[default]\ns3 =\n    addressing_style = path\n' > "${AWS_CONFIG_FILE}"
+    fi
+    if [ "${S3_INSECURE_SKIP_TLS}" = "true" ]; then
+        aws() { command aws --no-verify-ssl "$@"; }
+    fi
+}
+`
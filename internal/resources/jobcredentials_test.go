@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestAddJobCredentials(t *testing.T) {
+	podSpec := &corev1.PodSpec{Containers: []corev1.Container{{Name: "backup"}, {Name: "upload"}}}
+
+	addJobCredentials(podSpec, []credentialFile{
+		{secret: "my-admin", key: "db-password", path: credentialFileMongoDBPassword},
+		{secret: "s3-ca", key: "bundle.pem", path: credentialFileS3CA, optional: boolPtr(true)},
+	})
+
+	require.Len(t, podSpec.Volumes, 1)
+	volume := podSpec.Volumes[0]
+	assert.Equal(t, "credentials", volume.Name)
+	require.NotNil(t, volume.Projected)
+	assert.Equal(t, int32(0400), *volume.Projected.DefaultMode, "readable by the owner only")
+	require.Len(t, volume.Projected.Sources, 2)
+	assert.Equal(t, "my-admin", volume.Projected.Sources[0].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "db-password", Path: "mongodb-password"}}, volume.Projected.Sources[0].Secret.Items)
+	assert.Nil(t, volume.Projected.Sources[0].Secret.Optional)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "bundle.pem", Path: "s3-ca.crt"}}, volume.Projected.Sources[1].Secret.Items)
+	assert.True(t, *volume.Projected.Sources[1].Secret.Optional)
+
+	for _, container := range podSpec.Containers {
+		assert.Equal(t, []corev1.VolumeMount{{Name: "credentials", MountPath: JobCredentialsMountPath, ReadOnly: true}},
+			container.VolumeMounts, container.Name)
+	}
+}
+
+func TestBuildBackupJobCustomPasswordKey(t *testing.T) {
+	auth := mongodbv1alpha1.AuthSpec{
+		AdminCredentialsSecretRef: mongodbv1alpha1.CredentialsSecretRef{Name: "my-admin", UsernameKey: "user", PasswordKey: "db-password"},
+	}
+	_, passwordKey := CredentialKeys(auth.AdminCredentialsSecretRef)
+	job := BuildBackupJob(testS3Backup(), "mongodb://my-mongodb.default.svc.cluster.local:27017/?authSource=admin",
+		BackupCredentials{
+			Username: "admin",
+			PasswordSecretRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: AdminSecretName("my-mongodb", auth)},
+				Key:                  passwordKey,
+			},
+		})
+
+	// The password is projected from the key of the spec, not the default "password" key
+	sources := job.Spec.Template.Spec.Volumes[0].Projected.Sources
+	assert.Equal(t, "my-admin", sources[0].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "db-password", Path: credentialFileMongoDBPassword}}, sources[0].Secret.Items)
+
+	container := job.Spec.Template.Spec.Containers[0]
+	for _, env := range container.Env {
+		assert.NotContains(t, env.Name, "PASSWORD")
+		assert.NotContains(t, env.Name, "SECRET")
+		assert.Nil(t, env.ValueFrom, "no secret in the environment: %s", env.Name)
+	}
+	assert.Empty(t, container.EnvFrom)
+}
+
+func TestJobCredentialsScript(t *testing.T) {
+	assert.Contains(t, jobCredentialsScript, `CREDENTIALS="/etc/mongodb-backup/credentials"`)
+	assert.Contains(t, jobCredentialsScript, "umask 077", "the config files of the tools are private")
+
+	// mongo_config reads each value from its file and doubles the single quotes of the YAML scalar
+	assert.Contains(t, jobCredentialsScript, `printf "%s: '%s'\n" "$1" "$(sed "s/'/''/g" "${CREDENTIALS}/$2")" >> "${config}"`)
+
+	// s3_credentials reads the keys from their files, the CA bundle only when it is mounted
+	assert.Contains(t, jobCredentialsScript, `export AWS_ACCESS_KEY_ID="$(cat "${CREDENTIALS}/s3-access-key")"`)
+	assert.Contains(t, jobCredentialsScript, `export AWS_SECRET_ACCESS_KEY="$(cat "${CREDENTIALS}/s3-secret-key")"`)
+	assert.Contains(t, jobCredentialsScript, `if [ -f "${CREDENTIALS}/s3-ca.crt" ]; then`)
+
+	// The backup script defines the helpers before it uses them
+	script := BuildBackupJob(testS3Backup(), "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Args[0]
+	defined := strings.Index(script, "mongo_config() {")
+	require.NotEqual(t, -1, defined)
+	assert.Less(t, defined, strings.Index(script, "$(mongo_config password mongodb-password)"))
+	assert.Less(t, strings.Index(script, "s3_credentials() {"), strings.LastIndex(script, "\ns3_credentials\n"))
+}
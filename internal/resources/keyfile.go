@@ -0,0 +1,131 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// RotateKeyfileAnnotation requests a keyfile rotation when set on a cluster.
+	// Changing its value after a completed rotation starts another one.
+	RotateKeyfileAnnotation = "mongodb.keiailab.com/rotate-keyfile"
+
+	// KeyfileHashAnnotation is set on pod templates so keyfile changes roll the pods
+	KeyfileHashAnnotation = "mongodb.keiailab.com/keyfile-hash"
+
+	// Keyfile rotation phases
+	KeyfileRotationDistributing = "Distributing"
+	KeyfileRotationFinalizing   = "Finalizing"
+	KeyfileRotationCompleted    = "Completed"
+
+	keyfileKeyLength = 756
+)
+
+// KeyfileSecretName returns the name of the keyfile secret for a cluster.
+// When no secret is referenced, the operator-generated <name>-keyfile secret is used.
+func KeyfileSecretName(clusterName string, auth mongodbv1alpha1.AuthSpec) string {
+	if auth.KeyfileSecretRef != nil && auth.KeyfileSecretRef.Name != "" {
+		return auth.KeyfileSecretRef.Name
+	}
+	return clusterName + "-keyfile"
+}
+
+// KeyfileSecretKey returns the secret key holding the keyfile content
+func KeyfileSecretKey(auth mongodbv1alpha1.AuthSpec) string {
+	if auth.KeyfileSecretRef != nil && auth.KeyfileSecretRef.Key != "" {
+		return auth.KeyfileSecretRef.Key
+	}
+	return "keyfile"
+}
+
+// IsKeyfileSecretGenerated reports whether the keyfile secret is managed by the operator
+func IsKeyfileSecretGenerated(auth mongodbv1alpha1.AuthSpec) bool {
+	return auth.KeyfileSecretRef == nil || auth.KeyfileSecretRef.Name == ""
+}
+
+// buildKeyfileVolumeSource mounts the keyfile secret where the copy-keyfile init container expects it.
+// Custom keys are projected to the "keyfile" path.
+func buildKeyfileVolumeSource(clusterName string, auth mongodbv1alpha1.AuthSpec) *corev1.SecretVolumeSource {
+	source := &corev1.SecretVolumeSource{
+		SecretName:  KeyfileSecretName(clusterName, auth),
+		DefaultMode: int32Ptr(0400),
+	}
+	if key := KeyfileSecretKey(auth); key != "keyfile" {
+		source.Items = []corev1.KeyToPath{{Key: key, Path: "keyfile"}}
+	}
+	return source
+}
+
+// NewKeyfileKey generates a new random internal authentication key
+func NewKeyfileKey() string {
+	return generateRandomKey(keyfileKeyLength)
+}
+
+// BuildKeyfile renders keyfile content. A single key is written as-is, multiple keys
+// use the YAML list format so members accept any of them during a rotation.
+func BuildKeyfile(keys ...string) string {
+	if len(keys) == 1 {
+		return keys[0]
+	}
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString("- " + key + "\n")
+	}
+	return b.String()
+}
+
+// ParseKeyfile returns the keys contained in keyfile content
+func ParseKeyfile(content string) []string {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "- ") {
+		if content == "" {
+			return nil
+		}
+		return []string{content}
+	}
+
+	var keys []string
+	for _, line := range strings.Split(content, "\n") {
+		key := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// KeyfileHash returns a short hash of keyfile content for pod template annotations
+func KeyfileHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// SetKeyfileHashAnnotation records the keyfile hash on a pod template
+func SetKeyfileHashAnnotation(template *corev1.PodTemplateSpec, hash string) {
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[KeyfileHashAnnotation] = hash
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestBuildKeyfile(t *testing.T) {
+	assert.Equal(t, "old-key", BuildKeyfile("old-key"))
+	assert.Equal(t, "- old-key\n- new-key\n", BuildKeyfile("old-key", "new-key"))
+}
+
+func TestParseKeyfile(t *testing.T) {
+	assert.Equal(t, []string{"old-key"}, ParseKeyfile("old-key\n"))
+	assert.Equal(t, []string{"old-key", "new-key"}, ParseKeyfile(BuildKeyfile("old-key", "new-key")))
+	assert.Empty(t, ParseKeyfile(""))
+
+	// Generated keys are base64 and must survive a round trip
+	key := NewKeyfileKey()
+	assert.Equal(t, []string{key}, ParseKeyfile(BuildKeyfile(key)))
+}
+
+func TestSetKeyfileHashAnnotation(t *testing.T) {
+	template := &corev1.PodTemplateSpec{}
+	hash := KeyfileHash([]byte("old-key"))
+
+	SetKeyfileHashAnnotation(template, hash)
+
+	assert.Equal(t, hash, template.Annotations[KeyfileHashAnnotation])
+	assert.NotEqual(t, hash, KeyfileHash([]byte("new-key")))
+}
+
+func TestKeyfileSecretRef(t *testing.T) {
+	auth := mongodbv1alpha1.AuthSpec{}
+	assert.Equal(t, "my-mongodb-keyfile", KeyfileSecretName("my-mongodb", auth))
+	assert.Equal(t, "keyfile", KeyfileSecretKey(auth))
+	assert.True(t, IsKeyfileSecretGenerated(auth))
+	assert.Empty(t, buildKeyfileVolumeSource("my-mongodb", auth).Items)
+
+	auth.KeyfileSecretRef = &mongodbv1alpha1.KeyfileSecretRef{Name: "shared-keyfile", Key: "mongodb.key"}
+	assert.Equal(t, "shared-keyfile", KeyfileSecretName("my-mongodb", auth))
+	assert.False(t, IsKeyfileSecretGenerated(auth))
+
+	source := buildKeyfileVolumeSource("my-mongodb", auth)
+	assert.Equal(t, "shared-keyfile", source.SecretName)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "mongodb.key", Path: "keyfile"}}, source.Items)
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// MemberOverrideFor returns the override of the member with the given ordinal, nil when it has none
+func MemberOverrideFor(mdb *mongodbv1alpha1.MongoDB, ordinal int32) *mongodbv1alpha1.MemberOverride {
+	for i := range mdb.Spec.MemberOverrides {
+		if mdb.Spec.MemberOverrides[i].Member == ordinal {
+			return &mdb.Spec.MemberOverrides[i]
+		}
+	}
+	return nil
+}
+
+// MemberPriority returns the election priority of a member: the override, else 0 for hidden and
+// delayed members, which MongoDB never lets become primary, else the default of 1
+func MemberPriority(o *mongodbv1alpha1.MemberOverride) int32 {
+	switch {
+	case o == nil:
+		return 1
+	case o.Priority != nil:
+		return *o.Priority
+	case o.Hidden || o.SecondaryDelaySecs > 0 || (o.Votes != nil && *o.Votes == 0):
+		return 0
+	default:
+		return 1
+	}
+}
+
+// MemberVotes returns the votes of a member, 1 unless the override makes it non-voting
+func MemberVotes(o *mongodbv1alpha1.MemberOverride) int32 {
+	if o == nil || o.Votes == nil {
+		return 1
+	}
+	return *o.Votes
+}
+
+// ValidateMemberOverrides checks the member overrides against the replica set configuration rules
+// of MongoDB, so an invalid override is reported before rs.reconfig rejects it
+func ValidateMemberOverrides(mdb *mongodbv1alpha1.MongoDB) error {
+	seen := make(map[int32]bool, len(mdb.Spec.MemberOverrides))
+	for i := range mdb.Spec.MemberOverrides {
+		o := &mdb.Spec.MemberOverrides[i]
+		if o.Member >= mdb.Spec.Members {
+			return fmt.Errorf("member override %d does not match a member, the replica set has %d members", o.Member, mdb.Spec.Members)
+		}
+		if seen[o.Member] {
+			return fmt.Errorf("member %d has more than one override", o.Member)
+		}
+		seen[o.Member] = true
+
+		if MemberPriority(o) == 0 {
+			continue
+		}
+		if o.Hidden || o.SecondaryDelaySecs > 0 {
+			return fmt.Errorf("member %d is hidden or delayed and must have priority 0", o.Member)
+		}
+		if MemberVotes(o) == 0 {
+			return fmt.Errorf("member %d does not vote and must have priority 0", o.Member)
+		}
+	}
+
+	for i := int32(0); i < mdb.Spec.Members; i++ {
+		if MemberPriority(MemberOverrideFor(mdb, i)) > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("member overrides leave no member that can become primary")
+}
+
+// InvalidSpecCondition reports member counts MongoDB can not form a replica set from
+const InvalidSpecCondition = "InvalidSpec"
+
+// Replica set limits of MongoDB
+const (
+	maxReplicaSetMembers = 50
+	maxVotingMembers     = 7
+)
+
+// ArbiterEnabled reports whether the replica set has an arbiter
+func ArbiterEnabled(mdb *mongodbv1alpha1.MongoDB) bool {
+	return mdb.Spec.Arbiter != nil && mdb.Spec.Arbiter.Enabled
+}
+
+// VotingMembers returns the number of voting members of a replica set, the arbiter included
+func VotingMembers(mdb *mongodbv1alpha1.MongoDB) int32 {
+	var votes int32
+	for i := int32(0); i < mdb.Spec.Members; i++ {
+		votes += MemberVotes(MemberOverrideFor(mdb, i))
+	}
+	if ArbiterEnabled(mdb) {
+		votes++
+	}
+	return votes
+}
+
+// ValidateVotingMembers checks the member and voting member counts against the limits of
+// MongoDB: at most 50 members, at most 7 of them voting, and an arbiter only to make an even
+// number of voting members odd. A standalone mongod has no replica set to check.
+func ValidateVotingMembers(mdb *mongodbv1alpha1.MongoDB) error {
+	if Standalone(mdb) {
+		return nil
+	}
+
+	members := mdb.Spec.Members
+	if ArbiterEnabled(mdb) {
+		members++
+	}
+	if members > maxReplicaSetMembers {
+		return fmt.Errorf("the replica set has %d members, MongoDB allows at most %d", members, maxReplicaSetMembers)
+	}
+
+	votes := VotingMembers(mdb)
+	if votes > maxVotingMembers {
+		return fmt.Errorf("the replica set has %d voting members, MongoDB allows at most %d, set votes 0 on the others in memberOverrides", votes, maxVotingMembers)
+	}
+	if ArbiterEnabled(mdb) && votes%2 == 0 {
+		return fmt.Errorf("the arbiter makes the number of voting members even (%d), only add an arbiter to an even number of voting members", votes)
+	}
+	return nil
+}
+
+// ValidateShardedVotingMembers checks the config server and shard member counts. Every member of
+// them votes, so MongoDB allows at most 7.
+func ValidateShardedVotingMembers(mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if mdbsh.Spec.ConfigServer.Members > maxVotingMembers {
+		return fmt.Errorf("the config server replica set has %d voting members, MongoDB allows at most %d", mdbsh.Spec.ConfigServer.Members, maxVotingMembers)
+	}
+	if mdbsh.Spec.Shards.MembersPerShard > maxVotingMembers {
+		return fmt.Errorf("every shard has %d voting members, MongoDB allows at most %d", mdbsh.Spec.Shards.MembersPerShard, maxVotingMembers)
+	}
+	return nil
+}
+
+// EvenVotingMembersWarning returns a warning for an even number of voting members, which survives
+// no more failures than one voting member less, or an empty string
+func EvenVotingMembersWarning(votes int32) string {
+	if votes < 2 || votes%2 != 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d voting members tolerate as many failures as %d, an odd number of voting members is recommended", votes, votes-1)
+}
+
+// BuildInvalidSpecCondition builds the InvalidSpec condition from the error of the member count
+// validation, nil for a valid spec
+func BuildInvalidSpecCondition(err error, generation int64) metav1.Condition {
+	if err == nil {
+		return metav1.Condition{
+			Type:               InvalidSpecCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Valid",
+			Message:            "The member counts are within the limits of MongoDB",
+		}
+	}
+	return metav1.Condition{
+		Type:               InvalidSpecCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "InvalidMembers",
+		Message:            err.Error(),
+	}
+}
+
+// BuildReplicaSetSettings returns the fields of the replica set settings document set in the spec,
+// keyed by their name in the replica set configuration
+func BuildReplicaSetSettings(spec *mongodbv1alpha1.ReplicaSetSettings) map[string]any {
+	settings := map[string]any{}
+	if spec == nil {
+		return settings
+	}
+	if spec.ElectionTimeoutMillis != nil {
+		settings["electionTimeoutMillis"] = *spec.ElectionTimeoutMillis
+	}
+	if spec.HeartbeatTimeoutSecs != nil {
+		settings["heartbeatTimeoutSecs"] = *spec.HeartbeatTimeoutSecs
+	}
+	if spec.CatchUpTimeoutMillis != nil {
+		settings["catchUpTimeoutMillis"] = *spec.CatchUpTimeoutMillis
+	}
+	if spec.ChainingAllowed != nil {
+		settings["chainingAllowed"] = *spec.ChainingAllowed
+	}
+	return settings
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestMemberPriority(t *testing.T) {
+	zero := int32(0)
+	five := int32(5)
+
+	assert.Equal(t, int32(1), MemberPriority(nil))
+	assert.Equal(t, int32(5), MemberPriority(&mongodbv1alpha1.MemberOverride{Priority: &five}))
+	assert.Equal(t, int32(0), MemberPriority(&mongodbv1alpha1.MemberOverride{Hidden: true}))
+	assert.Equal(t, int32(0), MemberPriority(&mongodbv1alpha1.MemberOverride{SecondaryDelaySecs: 3600}))
+	assert.Equal(t, int32(0), MemberPriority(&mongodbv1alpha1.MemberOverride{Votes: &zero}))
+	assert.Equal(t, int32(1), MemberPriority(&mongodbv1alpha1.MemberOverride{Tags: map[string]string{"dc": "east"}}))
+
+	assert.Equal(t, int32(1), MemberVotes(nil))
+	assert.Equal(t, int32(0), MemberVotes(&mongodbv1alpha1.MemberOverride{Votes: &zero}))
+}
+
+func TestValidateMemberOverrides(t *testing.T) {
+	zero := int32(0)
+	two := int32(2)
+
+	mdb := testMongoDB()
+	mdb.Spec.MemberOverrides = []mongodbv1alpha1.MemberOverride{
+		{Member: 0, Priority: &two},
+		{Member: 2, Hidden: true, SecondaryDelaySecs: 3600},
+	}
+	require.NoError(t, ValidateMemberOverrides(mdb))
+	assert.Equal(t, int32(0), MemberPriority(MemberOverrideFor(mdb, 2)))
+	assert.Nil(t, MemberOverrideFor(mdb, 1))
+
+	for name, overrides := range map[string][]mongodbv1alpha1.MemberOverride{
+		"unknown member":      {{Member: 3}},
+		"duplicate":           {{Member: 1}, {Member: 1}},
+		"electable hidden":    {{Member: 1, Hidden: true, Priority: &two}},
+		"electable non-voter": {{Member: 1, Votes: &zero, Priority: &two}},
+		"no primary":          {{Member: 0, Priority: &zero}, {Member: 1, Priority: &zero}, {Member: 2, Priority: &zero}},
+	} {
+		mdb.Spec.MemberOverrides = overrides
+		assert.Error(t, ValidateMemberOverrides(mdb), name)
+	}
+}
+
+func TestValidateVotingMembers(t *testing.T) {
+	zero := int32(0)
+
+	mdb := testMongoDB()
+	require.NoError(t, ValidateVotingMembers(mdb))
+	assert.Equal(t, int32(3), VotingMembers(mdb))
+
+	// An arbiter must make an even number of voting members odd
+	mdb.Spec.Arbiter = &mongodbv1alpha1.ArbiterSpec{Enabled: true}
+	assert.Error(t, ValidateVotingMembers(mdb))
+	mdb.Spec.Members = 2
+	require.NoError(t, ValidateVotingMembers(mdb))
+	assert.Equal(t, int32(3), VotingMembers(mdb))
+	mdb.Spec.Arbiter = nil
+
+	// Members beyond the seventh voter must not vote
+	mdb.Spec.Members = 9
+	assert.Error(t, ValidateVotingMembers(mdb))
+	mdb.Spec.MemberOverrides = []mongodbv1alpha1.MemberOverride{
+		{Member: 7, Votes: &zero},
+		{Member: 8, Votes: &zero},
+	}
+	require.NoError(t, ValidateVotingMembers(mdb))
+	assert.Equal(t, int32(7), VotingMembers(mdb))
+
+	mdb.Spec.Members = 50
+	mdb.Spec.Arbiter = &mongodbv1alpha1.ArbiterSpec{Enabled: true}
+	assert.ErrorContains(t, ValidateVotingMembers(mdb), "at most 50")
+}
+
+func TestValidateShardedVotingMembers(t *testing.T) {
+	mdbsh := &mongodbv1alpha1.MongoDBSharded{}
+	mdbsh.Spec.ConfigServer.Members = 3
+	mdbsh.Spec.Shards.MembersPerShard = 7
+	require.NoError(t, ValidateShardedVotingMembers(mdbsh))
+
+	mdbsh.Spec.Shards.MembersPerShard = 8
+	assert.Error(t, ValidateShardedVotingMembers(mdbsh))
+	mdbsh.Spec.Shards.MembersPerShard = 3
+	mdbsh.Spec.ConfigServer.Members = 9
+	assert.Error(t, ValidateShardedVotingMembers(mdbsh))
+}
+
+func TestEvenVotingMembersWarning(t *testing.T) {
+	assert.Empty(t, EvenVotingMembersWarning(1))
+	assert.Empty(t, EvenVotingMembersWarning(3))
+	assert.Contains(t, EvenVotingMembersWarning(4), "as many failures as 3")
+}
+
+func TestBuildInvalidSpecCondition(t *testing.T) {
+	valid := BuildInvalidSpecCondition(nil, 2)
+	assert.Equal(t, InvalidSpecCondition, valid.Type)
+	assert.Equal(t, metav1.ConditionFalse, valid.Status)
+	assert.Equal(t, int64(2), valid.ObservedGeneration)
+
+	invalid := BuildInvalidSpecCondition(fmt.Errorf("too many voters"), 3)
+	assert.Equal(t, metav1.ConditionTrue, invalid.Status)
+	assert.Equal(t, "InvalidMembers", invalid.Reason)
+	assert.Equal(t, "too many voters", invalid.Message)
+}
+
+func TestBuildReplicaSetSettings(t *testing.T) {
+	assert.Empty(t, BuildReplicaSetSettings(nil))
+
+	timeout := int32(5000)
+	chaining := false
+	settings := BuildReplicaSetSettings(&mongodbv1alpha1.ReplicaSetSettings{
+		ElectionTimeoutMillis: &timeout,
+		ChainingAllowed:       &chaining,
+	})
+	assert.Equal(t, map[string]any{"electionTimeoutMillis": int32(5000), "chainingAllowed": false}, settings)
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// MeshIstio enrolls the pods in an Istio mesh
+	MeshIstio = "Istio"
+	// MeshLinkerd enrolls the pods in a Linkerd mesh
+	MeshLinkerd = "Linkerd"
+)
+
+// shardedMemberPorts are the ports sharded cluster components connect to each other on
+var shardedMemberPorts = []int32{shardPort, configServerPort}
+
+// meshPorts describes the database ports of a pod for the service mesh
+type meshPorts struct {
+	// listen is the port the database process of the pod listens on
+	listen int32
+	// inbound and outbound are the member ports taken out of the mesh by ExcludeMemberPorts
+	inbound  []int32
+	outbound []int32
+}
+
+// appProtocols maps service port names to the protocol the mesh proxies should expect
+var appProtocols = map[string]string{
+	"mongodb": "mongo",
+	"metrics": "http",
+}
+
+// applyServiceMesh annotates a pod template for the service mesh. The proxy is started before
+// the application so members can reach each other as soon as mongod runs, and kubectl exec
+// (used by the operator to configure the replica set) defaults to the database container.
+func applyServiceMesh(template *corev1.PodTemplateSpec, mesh *mongodbv1alpha1.ServiceMeshSpec, container string, ports meshPorts) {
+	if mesh == nil {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	annotations := template.Annotations
+	annotations["kubectl.kubernetes.io/default-container"] = container
+
+	inject := mesh.InjectSidecar == nil || *mesh.InjectSidecar
+	exclude := mesh.ExcludeMemberPorts
+
+	switch mesh.Type {
+	case MeshIstio:
+		if inject {
+			annotations["sidecar.istio.io/inject"] = "true"
+		}
+		annotations["proxy.istio.io/config"] = `{"holdApplicationUntilProxyStarts": true}`
+		if exclude && len(ports.inbound) > 0 {
+			annotations["traffic.sidecar.istio.io/excludeInboundPorts"] = joinPorts(ports.inbound)
+		}
+		if exclude && len(ports.outbound) > 0 {
+			annotations["traffic.sidecar.istio.io/excludeOutboundPorts"] = joinPorts(ports.outbound)
+		}
+	case MeshLinkerd:
+		if inject {
+			annotations["linkerd.io/inject"] = "enabled"
+		}
+		annotations["config.linkerd.io/proxy-await"] = "enabled"
+		if exclude && len(ports.inbound) > 0 {
+			annotations["config.linkerd.io/skip-inbound-ports"] = joinPorts(ports.inbound)
+		}
+		if exclude && len(ports.outbound) > 0 {
+			annotations["config.linkerd.io/skip-outbound-ports"] = joinPorts(ports.outbound)
+		}
+		if !exclude || len(ports.inbound) == 0 {
+			// The MongoDB wire protocol is not HTTP, skip protocol detection
+			annotations["config.linkerd.io/opaque-ports"] = joinPorts([]int32{ports.listen})
+		}
+	}
+}
+
+// applyServiceMeshPorts sets the application protocol of the service ports
+func applyServiceMeshPorts(svc *corev1.Service, mesh *mongodbv1alpha1.ServiceMeshSpec) {
+	if mesh == nil {
+		return
+	}
+	for i := range svc.Spec.Ports {
+		if protocol, ok := appProtocols[svc.Spec.Ports[i].Name]; ok {
+			svc.Spec.Ports[i].AppProtocol = &protocol
+		}
+	}
+}
+
+func joinPorts(ports []int32) string {
+	values := make([]string, len(ports))
+	for i, port := range ports {
+		values[i] = fmt.Sprintf("%d", port)
+	}
+	return strings.Join(values, ",")
+}
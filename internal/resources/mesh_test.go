@@ -0,0 +1,126 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestServiceMeshDisabled(t *testing.T) {
+	mdb := testMongoDB()
+
+	sts := BuildReplicaSetStatefulSet(mdb)
+	assert.NotContains(t, sts.Spec.Template.Annotations, "kubectl.kubernetes.io/default-container")
+	assert.NotContains(t, sts.Spec.Template.Annotations, "sidecar.istio.io/inject")
+
+	for _, port := range BuildHeadlessService(mdb).Spec.Ports {
+		assert.Nil(t, port.AppProtocol)
+	}
+}
+
+func TestServiceMeshIstio(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ServiceMesh = &mongodbv1alpha1.ServiceMeshSpec{Type: MeshIstio}
+
+	annotations := BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations
+	assert.Equal(t, "mongodb", annotations["kubectl.kubernetes.io/default-container"])
+	assert.Equal(t, "true", annotations["sidecar.istio.io/inject"])
+	assert.Contains(t, annotations["proxy.istio.io/config"], `"holdApplicationUntilProxyStarts": true`)
+	assert.NotContains(t, annotations, "traffic.sidecar.istio.io/excludeInboundPorts")
+
+	mdb.Spec.ServiceMesh.ExcludeMemberPorts = true
+	mdb.Spec.Port = 27100
+	annotations = BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations
+	assert.Equal(t, "27100", annotations["traffic.sidecar.istio.io/excludeInboundPorts"])
+	assert.Equal(t, "27100", annotations["traffic.sidecar.istio.io/excludeOutboundPorts"])
+
+	inject := false
+	mdb.Spec.ServiceMesh.InjectSidecar = &inject
+	annotations = BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations
+	assert.NotContains(t, annotations, "sidecar.istio.io/inject")
+	assert.Contains(t, annotations, "proxy.istio.io/config")
+}
+
+func TestServiceMeshLinkerd(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ServiceMesh = &mongodbv1alpha1.ServiceMeshSpec{Type: MeshLinkerd}
+
+	annotations := BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations
+	assert.Equal(t, "enabled", annotations["linkerd.io/inject"])
+	assert.Equal(t, "enabled", annotations["config.linkerd.io/proxy-await"])
+	assert.Equal(t, "27017", annotations["config.linkerd.io/opaque-ports"])
+
+	mdb.Spec.ServiceMesh.ExcludeMemberPorts = true
+	annotations = BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations
+	assert.Equal(t, "27017", annotations["config.linkerd.io/skip-inbound-ports"])
+	assert.Equal(t, "27017", annotations["config.linkerd.io/skip-outbound-ports"])
+	assert.NotContains(t, annotations, "config.linkerd.io/opaque-ports")
+}
+
+func TestServiceMeshSharded(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.ServiceMesh = &mongodbv1alpha1.ServiceMeshSpec{
+		Type:               MeshIstio,
+		ExcludeMemberPorts: true,
+	}
+
+	cfg := BuildConfigServerStatefulSet(mdbsh).Spec.Template.Annotations
+	assert.Equal(t, "27019", cfg["traffic.sidecar.istio.io/excludeInboundPorts"])
+	assert.Equal(t, "27018,27019", cfg["traffic.sidecar.istio.io/excludeOutboundPorts"])
+
+	shard := BuildShardStatefulSet(mdbsh, 1).Spec.Template.Annotations
+	assert.Equal(t, "27018", shard["traffic.sidecar.istio.io/excludeInboundPorts"])
+
+	// Client traffic to mongos stays in the mesh
+	mongos := BuildMongosDeployment(mdbsh).Spec.Template.Annotations
+	assert.Equal(t, "mongos", mongos["kubectl.kubernetes.io/default-container"])
+	assert.NotContains(t, mongos, "traffic.sidecar.istio.io/excludeInboundPorts")
+	assert.Equal(t, "27018,27019", mongos["traffic.sidecar.istio.io/excludeOutboundPorts"])
+
+	mdbsh.Spec.ServiceMesh = &mongodbv1alpha1.ServiceMeshSpec{Type: MeshLinkerd, ExcludeMemberPorts: true}
+	mongos = BuildMongosDeployment(mdbsh).Spec.Template.Annotations
+	assert.Equal(t, "27017", mongos["config.linkerd.io/opaque-ports"])
+}
+
+func TestServiceMeshAppProtocol(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ServiceMesh = &mongodbv1alpha1.ServiceMeshSpec{Type: MeshIstio}
+
+	svc := BuildClientService(mdb)
+	require.NotEmpty(t, svc.Spec.Ports)
+	for _, port := range svc.Spec.Ports {
+		require.NotNil(t, port.AppProtocol, port.Name)
+		assert.Equal(t, appProtocols[port.Name], *port.AppProtocol)
+	}
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.ServiceMesh = &mongodbv1alpha1.ServiceMeshSpec{Type: MeshLinkerd}
+	for _, svc := range []*corev1.Service{
+		BuildConfigServerService(mdbsh),
+		BuildShardService(mdbsh, 0),
+		BuildMongosService(mdbsh),
+	} {
+		require.NotNil(t, svc.Spec.Ports[0].AppProtocol, svc.Name)
+		assert.Equal(t, "mongo", *svc.Spec.Ports[0].AppProtocol)
+	}
+}
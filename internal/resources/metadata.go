@@ -0,0 +1,43 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// applyPodMetadata adds the user-defined labels and annotations to a pod template. The operator
+// labels win over user labels as they back the selectors, user annotations win over the
+// operator annotations so defaults like the prometheus scrape settings can be overridden.
+func applyPodMetadata(template *corev1.PodTemplateSpec, pod *mongodbv1alpha1.PodSpec) {
+	if pod == nil {
+		return
+	}
+	template.Labels = mergeStringMaps(pod.Labels, template.Labels)
+	template.Annotations = mergeStringMaps(template.Annotations, pod.Annotations)
+}
+
+// applyServiceMetadata adds the user-defined labels and annotations to a Service
+func applyServiceMetadata(svc *corev1.Service, meta *mongodbv1alpha1.ServiceMetadataSpec) {
+	if meta == nil {
+		return
+	}
+	svc.Labels = mergeStringMaps(meta.Labels, svc.Labels)
+	svc.Annotations = mergeStringMaps(svc.Annotations, meta.Annotations)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestApplyPodMetadata(t *testing.T) {
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 3,
+			Version: mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+			Pod: &mongodbv1alpha1.PodSpec{
+				Labels: map[string]string{
+					"cost-center":                 "db",
+					"app.kubernetes.io/component": "overridden",
+				},
+				Annotations: map[string]string{
+					"prometheus.io/scrape":  "false",
+					"backup.velero.io/skip": "true",
+				},
+			},
+		},
+	}
+
+	sts := BuildReplicaSetStatefulSet(mdb)
+	template := sts.Spec.Template
+
+	assert.Equal(t, "db", template.Labels["cost-center"])
+	assert.Equal(t, "replicaset", template.Labels["app.kubernetes.io/component"], "operator labels take precedence")
+	assert.Equal(t, "false", template.Annotations["prometheus.io/scrape"], "user annotations take precedence")
+	assert.Equal(t, "true", template.Annotations["backup.velero.io/skip"])
+	assert.NotEmpty(t, template.Annotations["prometheus.io/port"])
+
+	// The selector and the StatefulSet labels are left untouched
+	assert.NotContains(t, sts.Spec.Selector.MatchLabels, "cost-center")
+	assert.NotContains(t, sts.Labels, "cost-center")
+}
+
+func TestApplyServiceMetadata(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{
+		Enabled:     true,
+		Type:        "LoadBalancer",
+		Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+	}
+	mdb.Spec.Service = &mongodbv1alpha1.ServiceMetadataSpec{
+		Labels: map[string]string{"team": "data"},
+		Annotations: map[string]string{
+			"example.com/owner": "data",
+			"service.beta.kubernetes.io/aws-load-balancer-type": "external",
+		},
+	}
+
+	for _, svc := range BuildExternalServices(mdb) {
+		assert.Equal(t, "data", svc.Labels["team"])
+		assert.Equal(t, "data", svc.Annotations["example.com/owner"])
+		assert.Equal(t, "nlb", svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"],
+			"external access annotations take precedence")
+	}
+
+	headless := BuildHeadlessService(mdb)
+	assert.Equal(t, "data", headless.Labels["team"])
+	assert.NotContains(t, headless.Spec.Selector, "team")
+
+	client := BuildClientService(mdb)
+	assert.Equal(t, "data", client.Annotations["example.com/owner"])
+}
+
+func TestApplyShardedMetadata(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.ConfigServer.Pod = &mongodbv1alpha1.PodSpec{Labels: map[string]string{"tier": "config"}}
+	mdbsh.Spec.ConfigServer.Service = &mongodbv1alpha1.ServiceMetadataSpec{Labels: map[string]string{"tier": "config"}}
+	mdbsh.Spec.Shards.Pod = &mongodbv1alpha1.PodSpec{Annotations: map[string]string{"example.com/shard": "true"}}
+	mdbsh.Spec.Shards.Service = &mongodbv1alpha1.ServiceMetadataSpec{Annotations: map[string]string{"example.com/shard": "true"}}
+	mdbsh.Spec.Mongos.Pod = &mongodbv1alpha1.PodSpec{Labels: map[string]string{"tier": "router"}}
+	mdbsh.Spec.Mongos.Service = &mongodbv1alpha1.MongosServiceSpec{Labels: map[string]string{"tier": "router"}}
+
+	assert.Equal(t, "config", BuildConfigServerStatefulSet(mdbsh).Spec.Template.Labels["tier"])
+	assert.Equal(t, "config", BuildConfigServerService(mdbsh).Labels["tier"])
+	assert.Equal(t, "true", BuildShardStatefulSet(mdbsh, 1).Spec.Template.Annotations["example.com/shard"])
+	assert.Equal(t, "true", BuildShardService(mdbsh, 1).Annotations["example.com/shard"])
+	assert.Equal(t, "router", BuildMongosDeployment(mdbsh).Spec.Template.Labels["tier"])
+
+	svc := BuildMongosService(mdbsh)
+	assert.Equal(t, "router", svc.Labels["tier"])
+	assert.NotContains(t, svc.Spec.Selector, "tier")
+}
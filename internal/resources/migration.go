@@ -0,0 +1,247 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// Migration phases
+	MigrationPending     = "Pending"
+	MigrationInitialSync = "InitialSync"
+	MigrationCatchingUp  = "CatchingUp"
+	MigrationCuttingOver = "CuttingOver"
+	MigrationCompleted   = "Completed"
+	MigrationFailed      = "Failed"
+
+	// defaultCatchUpInterval is the time between two oplog catch-up rounds
+	defaultCatchUpInterval = 30 * time.Second
+)
+
+// MigrationMode returns the mode of a migration, Continuous by default
+func MigrationMode(spec mongodbv1alpha1.MongoDBMigrationSpec) string {
+	if spec.Mode == "" {
+		return mongodbv1alpha1.MigrationModeContinuous
+	}
+	return spec.Mode
+}
+
+// MigrationCatchUpInterval returns the time between two oplog catch-up rounds
+func MigrationCatchUpInterval(spec mongodbv1alpha1.MongoDBMigrationSpec) time.Duration {
+	if spec.CatchUpIntervalSeconds > 0 {
+		return time.Duration(spec.CatchUpIntervalSeconds) * time.Second
+	}
+	return defaultCatchUpInterval
+}
+
+// ValidateMigration checks the spec of a migration before its first Job runs
+func ValidateMigration(spec mongodbv1alpha1.MongoDBMigrationSpec) error {
+	if spec.Source.URISecretRef.Name == "" || spec.Source.URISecretRef.Key == "" {
+		return fmt.Errorf("source.uriSecretRef requires the name and key of the secret holding the source connection string")
+	}
+	if spec.Cutover && MigrationMode(spec) == mongodbv1alpha1.MigrationModeSnapshot {
+		return fmt.Errorf("cutover only applies to Continuous migrations, a Snapshot migration completes with its copy")
+	}
+	return nil
+}
+
+// MigrationInitialSyncJobName returns the name of the Job copying the data of the source
+func MigrationInitialSyncJobName(migration *mongodbv1alpha1.MongoDBMigration) string {
+	return migration.Name + "-initial-sync"
+}
+
+// MigrationCatchUpJobName returns the name of the Job of an oplog catch-up round
+func MigrationCatchUpJobName(migration *mongodbv1alpha1.MongoDBMigration, round int32) string {
+	return fmt.Sprintf("%s-catchup-%d", migration.Name, round)
+}
+
+// MigrationProgress is what a migration Job reports in its termination message:
+// "<applied t> <applied i> <source t> <operations>"
+type MigrationProgress struct {
+	// Applied is the last operation of the source oplog applied to the cluster
+	Applied mongodbv1alpha1.OplogTimestamp
+	// SourceLatest is the seconds of the last operation of the source oplog when the Job ended
+	SourceLatest int64
+	// Operations is the number of oplog operations the Job replayed
+	Operations int64
+}
+
+// LagSeconds returns how far the cluster was behind the source when the Job ended
+func (p *MigrationProgress) LagSeconds() int64 {
+	return max(p.SourceLatest-p.Applied.T, 0)
+}
+
+// ParseMigrationProgress parses the termination message of a migration Job
+func ParseMigrationProgress(message string) (*MigrationProgress, error) {
+	fields := strings.Fields(message)
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("unexpected migration progress %q", message)
+	}
+	var values [4]int64
+	for i, field := range fields {
+		value, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected migration progress %q: %w", message, err)
+		}
+		values[i] = value
+	}
+	return &MigrationProgress{
+		Applied:      mongodbv1alpha1.OplogTimestamp{T: values[0], I: values[1]},
+		SourceLatest: values[2],
+		Operations:   values[3],
+	}, nil
+}
+
+// BuildMigrationInitialSyncJob creates the Job copying every database of the source into the
+// cluster, dropping the collections it restores. The users and the admin, config and local
+// databases of the source are not copied. A Continuous migration records the position of the
+// source oplog before the copy, the catch-up rounds replay the oplog from there.
+func BuildMigrationInitialSyncJob(migration *mongodbv1alpha1.MongoDBMigration) *batchv1.Job {
+	continuous := MigrationMode(migration.Spec) == mongodbv1alpha1.MigrationModeContinuous
+	dumpArgs, restoreArgs := "--archive", "--archive"
+	if continuous {
+		dumpArgs, restoreArgs = "--archive --oplog", "--archive --oplogReplay"
+	}
+	copyData := fmt.Sprintf(`mongodump --config="$(mongo_config uri %s)" %s | \
+    mongorestore --config="$(mongo_config uri %s)" %s --drop \
+    --nsExclude="admin.*" --nsExclude="config.*" --nsExclude="local.*"`,
+		credentialFileSourceURI, dumpArgs, credentialFileMongoDBURI, restoreArgs)
+
+	script := fmt.Sprintf(`
+set -eo pipefail
+%s
+echo "Starting the initial sync of migration %s"
+%s
+echo "Initial sync completed"
+`, jobCredentialsScript, migration.Name, copyData)
+	if continuous {
+		script = fmt.Sprintf(`
+set -eo pipefail
+%[1]s%[2]s
+echo "Starting the initial sync of migration %[3]s"
+# Oplog operations of the copy are replayed again by the first catch-up round, which is harmless
+APPLIED="$(source_oplog_latest)"
+%[4]s
+SOURCE="$(source_oplog_latest)"
+read -r SOURCE_T SOURCE_I <<< "${SOURCE}"
+echo "${APPLIED} ${SOURCE_T} 0" > %[5]s
+echo "Initial sync completed"
+`, jobCredentialsScript, sourceOplogScript, migration.Name, copyData, corev1.TerminationMessagePathDefault)
+	}
+	return buildMigrationJob(migration, MigrationInitialSyncJobName(migration), script)
+}
+
+// BuildMigrationCatchUpJob creates the Job of an oplog catch-up round. It replays the operations
+// of the source oplog after the applied position of the migration into the cluster, leaving out
+// the config and local databases and the users and roles.
+func BuildMigrationCatchUpJob(migration *mongodbv1alpha1.MongoDBMigration, round int32) (*batchv1.Job, error) {
+	applied := migration.Status.AppliedOpTime
+	if applied == nil {
+		return nil, fmt.Errorf("migration %s has no applied oplog position to catch up from", migration.Name)
+	}
+
+	script := fmt.Sprintf(`
+set -eo pipefail
+%[1]s%[2]s
+echo "Starting catch-up round %[3]d of migration %[4]s"
+LATEST="$(source_oplog_latest)"
+read -r LATEST_T LATEST_I <<< "${LATEST}"
+# Leaves out the no-op entries a replica set writes while idle, a round without writes applies nothing
+printf '{"ts": {"$gt": %[5]s, "$lte": {"$timestamp": {"t": %%s, "i": %%s}}}, "op": {"$ne": "n"}, "ns": {"$not": {"$regularExpression": {"pattern": "^(config[.]|local[.]|admin[.]system[.])", "options": ""}}}}' \
+    "${LATEST_T}" "${LATEST_I}" > /tmp/query.json
+
+# mongorestore replays the oplog.bson at the top of a dump directory
+rm -rf /tmp/replay && mkdir -p /tmp/replay
+mongodump --config="$(mongo_config uri %[6]s)" --db=local --collection=oplog.rs \
+    --queryFile=/tmp/query.json --out=- > /tmp/replay/oplog.bson
+OPERATIONS="$(bsondump --quiet /tmp/replay/oplog.bson | wc -l)"
+mongorestore --config="$(mongo_config uri %[7]s)" --oplogReplay --dir=/tmp/replay
+
+SOURCE="$(source_oplog_latest)"
+read -r SOURCE_T SOURCE_I <<< "${SOURCE}"
+echo "${LATEST} ${SOURCE_T} ${OPERATIONS}" > %[8]s
+echo "Catch-up round completed: ${OPERATIONS} operations"
+`, jobCredentialsScript, sourceOplogScript, round, migration.Name, oplogTimestampJSON(applied),
+		credentialFileSourceURI, credentialFileMongoDBURI, corev1.TerminationMessagePathDefault)
+	return buildMigrationJob(migration, MigrationCatchUpJobName(migration, round), script), nil
+}
+
+// sourceOplogScript defines source_oplog_latest, printing the ts of the last entry of the source
+// oplog as "<t> <i>". mongosh reads the connection string from its file, out of the arguments.
+const sourceOplogScript = `
+source_oplog_latest() {
+    mongosh --nodb --quiet --eval '
+        const uri = require("fs").readFileSync("` + JobCredentialsMountPath + `/` + credentialFileSourceURI + `", "utf8").trim();
+        const oplog = new Mongo(uri).getDB("local").getCollection("oplog.rs");
+        const ts = oplog.find().sort({$natural: -1}).limit(1).next().ts;
+        print(ts.getHighBits() + " " + ts.getLowBits());
+    '
+}
+`
+
+// buildMigrationJob creates a Job running a migration script with the connection strings of the
+// source and of the cluster, the admin URI of its connection secret
+func buildMigrationJob(migration *mongodbv1alpha1.MongoDBMigration, name, script string) *batchv1.Job {
+	labels := buildLabels(migration.Name, "migration")
+	backoff := int32(2)
+	ttl := int32(86400)
+	source := migration.Spec.Source.URISecretRef
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: migration.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoff,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:      "migration",
+							Image:     defaultBackupImage(),
+							Command:   []string{"/bin/bash", "-c"},
+							Args:      []string{script},
+							Resources: buildBackupResources(),
+						},
+					},
+				},
+			},
+		},
+	}
+	addJobCredentials(&job.Spec.Template.Spec, []credentialFile{
+		{secret: source.Name, key: source.Key, path: credentialFileSourceURI},
+		{secret: ConnectionSecretName(migration.Spec.ClusterRef.Name), key: "uri", path: credentialFileMongoDBURI},
+	})
+	return job
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testMigration(mode string) *mongodbv1alpha1.MongoDBMigration {
+	return &mongodbv1alpha1.MongoDBMigration{
+		ObjectMeta: metav1.ObjectMeta{Name: "import", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBMigrationSpec{
+			Source: mongodbv1alpha1.MigrationSourceSpec{
+				URISecretRef: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "legacy-mongodb"},
+					Key:                  "uri",
+				},
+			},
+			ClusterRef: mongodbv1alpha1.ClusterReference{Kind: "MongoDB", Name: "my-mongodb"},
+			Mode:       mode,
+		},
+	}
+}
+
+func TestValidateMigration(t *testing.T) {
+	require.NoError(t, ValidateMigration(testMigration("").Spec))
+
+	migration := testMigration("")
+	migration.Spec.Source.URISecretRef.Key = ""
+	assert.ErrorContains(t, ValidateMigration(migration.Spec), "uriSecretRef")
+
+	migration = testMigration(mongodbv1alpha1.MigrationModeSnapshot)
+	migration.Spec.Cutover = true
+	assert.ErrorContains(t, ValidateMigration(migration.Spec), "cutover")
+}
+
+func TestMigrationDefaults(t *testing.T) {
+	spec := testMigration("").Spec
+	assert.Equal(t, mongodbv1alpha1.MigrationModeContinuous, MigrationMode(spec))
+	assert.Equal(t, 30*time.Second, MigrationCatchUpInterval(spec))
+
+	spec.CatchUpIntervalSeconds = 5
+	assert.Equal(t, 5*time.Second, MigrationCatchUpInterval(spec))
+}
+
+func TestParseMigrationProgress(t *testing.T) {
+	progress, err := ParseMigrationProgress("1700000000 3 1700000012 42\n")
+	require.NoError(t, err)
+	assert.Equal(t, mongodbv1alpha1.OplogTimestamp{T: 1700000000, I: 3}, progress.Applied)
+	assert.Equal(t, int64(42), progress.Operations)
+	assert.Equal(t, int64(12), progress.LagSeconds())
+
+	progress.SourceLatest = 1
+	assert.Equal(t, int64(0), progress.LagSeconds(), "the lag is never negative")
+
+	for _, message := range []string{"", "1700000000 3 1700000012", "1700000000 x 1700000012 0"} {
+		_, err := ParseMigrationProgress(message)
+		assert.Error(t, err, message)
+	}
+}
+
+func TestBuildMigrationInitialSyncJob(t *testing.T) {
+	job := BuildMigrationInitialSyncJob(testMigration(""))
+	assert.Equal(t, "import-initial-sync", job.Name)
+	assert.Equal(t, "migration", job.Labels["app.kubernetes.io/component"])
+
+	podSpec := job.Spec.Template.Spec
+	require.Len(t, podSpec.Containers, 1)
+	script := podSpec.Containers[0].Args[0]
+	assert.Contains(t, script, "--archive --oplog |")
+	assert.Contains(t, script, "--archive --oplogReplay --drop")
+	assert.Contains(t, script, `--nsExclude="admin.*"`)
+	assert.Contains(t, script, `APPLIED="$(source_oplog_latest)"`)
+	assert.Contains(t, script, corev1.TerminationMessagePathDefault)
+
+	require.Len(t, podSpec.Volumes, 1)
+	sources := podSpec.Volumes[0].Projected.Sources
+	require.Len(t, sources, 2)
+	assert.Equal(t, "legacy-mongodb", sources[0].Secret.Name)
+	assert.Equal(t, credentialFileSourceURI, sources[0].Secret.Items[0].Path)
+	assert.Equal(t, "my-mongodb-connection", sources[1].Secret.Name)
+
+	script = BuildMigrationInitialSyncJob(testMigration(mongodbv1alpha1.MigrationModeSnapshot)).Spec.Template.Spec.Containers[0].Args[0]
+	assert.NotContains(t, script, "--oplog")
+	assert.NotContains(t, script, "source_oplog_latest")
+}
+
+func TestBuildMigrationCatchUpJob(t *testing.T) {
+	migration := testMigration("")
+	_, err := BuildMigrationCatchUpJob(migration, 1)
+	require.Error(t, err, "a catch-up round needs the applied position")
+
+	migration.Status.AppliedOpTime = &mongodbv1alpha1.OplogTimestamp{T: 1700000000, I: 3}
+	job, err := BuildMigrationCatchUpJob(migration, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "import-catchup-4", job.Name)
+
+	script := job.Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, `"$gt": {"$timestamp": {"t": 1700000000, "i": 3}}`)
+	assert.Contains(t, script, `"t": %s, "i": %s`)
+	assert.Contains(t, script, `"op": {"$ne": "n"}`)
+	assert.Contains(t, script, "--db=local --collection=oplog.rs")
+	assert.Contains(t, script, "--oplogReplay --dir=/tmp/replay")
+}
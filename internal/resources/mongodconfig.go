@@ -0,0 +1,272 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// ConfigHashAnnotation is set on pod templates so configuration changes roll the pods
+	ConfigHashAnnotation = "mongodb.keiailab.com/config-hash"
+
+	// MongodConfigKey and MongosConfigKey are the ConfigMap keys of the rendered configuration
+	MongodConfigKey = "mongod.conf"
+	MongosConfigKey = "mongos.conf"
+
+	configVolumeName = "config"
+	configMountPath  = "/etc/mongodb-config"
+	keyfilePath      = "/etc/mongodb-keyfile/keyfile"
+)
+
+// managedConfigKeys are the settings the operator owns, additionalConfig can not override them
+var managedConfigKeys = []string{
+	"net.port",
+	"net.bindIpAll",
+	"storage.dbPath",
+	"security.authorization",
+	"security.keyFile",
+	"replication.replSetName",
+	"sharding.clusterRole",
+	"sharding.configDB",
+	"cloud.monitoring.free.state",
+}
+
+// ConfigMapName returns the name of the ConfigMap holding the configuration of a StatefulSet
+func ConfigMapName(workloadName string) string {
+	return workloadName + "-config"
+}
+
+// ValidateAdditionalConfig checks that additionalConfig only holds dotted setting paths that
+// do not touch the settings managed by the operator, spec.profiling and spec.logging included
+func ValidateAdditionalConfig(config map[string]string) error {
+	for key := range config {
+		if slices.Contains(strings.Split(key, "."), "") {
+			return fmt.Errorf("additionalConfig key %q is not a dotted setting path", key)
+		}
+		for _, managed := range slices.Concat(managedConfigKeys, runtimeConfigKeys) {
+			if configKeysOverlap(key, managed) {
+				return fmt.Errorf("additionalConfig key %q overrides %s, which is managed by the operator", key, managed)
+			}
+		}
+	}
+	return nil
+}
+
+// configKeysOverlap reports whether two dotted setting paths are the same setting or one holds
+// the other
+func configKeysOverlap(key, other string) bool {
+	return key == other || strings.HasPrefix(other, key+".") || strings.HasPrefix(key, other+".")
+}
+
+// BuildReplicaSetConfig renders the mongod.conf of a replica set or standalone member
+func BuildReplicaSetConfig(mdb *mongodbv1alpha1.MongoDB) string {
+	config := mongodConfig(ReplicaSetPort(mdb), mdb.Spec.Storage.DataDirPath, mdb.Spec.Auth)
+	if !Standalone(mdb) {
+		setConfigValue(config, "security.keyFile", keyfilePath)
+		setConfigValue(config, "replication.replSetName", mdb.Spec.ReplicaSetName)
+	}
+	applyStorageEngineConfig(config, mdb.Spec.StorageEngine)
+	applyCacheSizeConfig(config, mdb.Spec.ResourceProfile, mongodResources(mdb.Spec.Resources, mdb.Spec.ResourceProfile))
+	applyDiagnosticsConfig(config, mdb.Spec.Profiling, mdb.Spec.Logging)
+	applyFreeMonitoringConfig(config, mdb.Spec.Version.Version, mdb.Spec.Telemetry)
+	return renderConfig(config, mdb.Spec.AdditionalConfig)
+}
+
+// BuildConfigServerConfig renders the mongod.conf of the config server members
+func BuildConfigServerConfig(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	config := mongodConfig(configServerPort, "/data/configdb", mdbsh.Spec.Auth)
+	setConfigValue(config, "security.keyFile", keyfilePath)
+	setConfigValue(config, "replication.replSetName", mdbsh.Name+"-cfg")
+	setConfigValue(config, "sharding.clusterRole", "configsvr")
+	applyStorageEngineConfig(config, mdbsh.Spec.StorageEngine)
+	applyCacheSizeConfig(config, mdbsh.Spec.ResourceProfile, mongodResources(mdbsh.Spec.ConfigServer.Resources, mdbsh.Spec.ResourceProfile))
+	applyDiagnosticsConfig(config, mdbsh.Spec.Profiling, mdbsh.Spec.Logging)
+	applyFreeMonitoringConfig(config, mdbsh.Spec.Version.Version, mdbsh.Spec.Telemetry)
+	return renderConfig(config, mdbsh.Spec.AdditionalConfig)
+}
+
+// BuildShardConfig renders the mongod.conf of the members of a shard
+func BuildShardConfig(mdbsh *mongodbv1alpha1.MongoDBSharded, shardIndex int32) string {
+	config := mongodConfig(shardPort, "/data/db", mdbsh.Spec.Auth)
+	setConfigValue(config, "security.keyFile", keyfilePath)
+	setConfigValue(config, "replication.replSetName", fmt.Sprintf("%s-shard-%d", mdbsh.Name, shardIndex))
+	setConfigValue(config, "sharding.clusterRole", "shardsvr")
+	applyStorageEngineConfig(config, mdbsh.Spec.StorageEngine)
+	applyCacheSizeConfig(config, mdbsh.Spec.ResourceProfile, mongodResources(mdbsh.Spec.Shards.Resources, mdbsh.Spec.ResourceProfile))
+	applyDiagnosticsConfig(config, mdbsh.Spec.Profiling, mdbsh.Spec.Logging)
+	applyFreeMonitoringConfig(config, mdbsh.Spec.Version.Version, mdbsh.Spec.Telemetry)
+	return renderConfig(config, mdbsh.Spec.AdditionalConfig)
+}
+
+// BuildMongosConfig renders the mongos.conf of the routers. additionalConfig holds mongod
+// settings and is not applied to mongos.
+func BuildMongosConfig(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	config := map[string]interface{}{}
+	setConfigValue(config, "net.port", mongoDBPort)
+	setConfigValue(config, "net.bindIpAll", true)
+	setConfigValue(config, "security.keyFile", keyfilePath)
+	setConfigValue(config, "sharding.configDB", mongosConfigDB(mdbsh))
+	applyAuthParameters(config, mdbsh.Spec.Auth)
+	return renderConfig(config, nil)
+}
+
+// BuildMongodConfigMap creates the ConfigMap holding the mongod.conf of a StatefulSet, and the
+// mongosh configuration when telemetry is turned off
+func BuildMongodConfigMap(clusterName, workloadName, namespace, config string, telemetry *mongodbv1alpha1.TelemetrySpec) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ConfigMapName(workloadName),
+			Namespace: namespace,
+			Labels:    buildLabels(clusterName, "config"),
+		},
+		Data: map[string]string{
+			MongodConfigKey: config,
+		},
+	}
+	addMongoshConfig(cm.Data, telemetry)
+	return cm
+}
+
+// BuildReplicaSetConfigMap creates the ConfigMap holding the configuration of the members of a
+// MongoDB, and the maintenance configuration the members of a replica set build indexes with
+func BuildReplicaSetConfigMap(mdb *mongodbv1alpha1.MongoDB) *corev1.ConfigMap {
+	cm := BuildMongodConfigMap(mdb.Name, mdb.Name, mdb.Namespace, BuildReplicaSetConfig(mdb), mdb.Spec.Telemetry)
+	if !Standalone(mdb) {
+		cm.Data[MaintenanceConfigKey] = BuildMaintenanceConfig(mdb)
+	}
+	return cm
+}
+
+func mongodConfig(port int32, dbPath string, auth mongodbv1alpha1.AuthSpec) map[string]interface{} {
+	config := map[string]interface{}{}
+	setConfigValue(config, "net.port", port)
+	setConfigValue(config, "net.bindIpAll", true)
+	if dbPath != "" {
+		setConfigValue(config, "storage.dbPath", dbPath)
+	}
+	setConfigValue(config, "security.authorization", "enabled")
+	applyAuthParameters(config, auth)
+	return config
+}
+
+// renderConfig applies the additionalConfig settings and renders the configuration file.
+// Values are parsed as YAML so numbers and booleans keep their type. Keys rejected by
+// ValidateAdditionalConfig are skipped.
+func renderConfig(config map[string]interface{}, additional map[string]string) string {
+	if ValidateAdditionalConfig(additional) == nil {
+		for key, raw := range additional {
+			var value interface{}
+			if err := yaml.Unmarshal([]byte(raw), &value); err != nil || value == nil {
+				value = raw
+			}
+			setConfigValue(config, key, value)
+		}
+	}
+
+	out, err := yaml.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// setConfigValue sets a setting by its dotted path, creating the enclosing sections
+func setConfigValue(config map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	section := config
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := section[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			section[part] = next
+		}
+		section = next
+	}
+	section[parts[len(parts)-1]] = value
+}
+
+// deleteConfigValue removes a setting by its dotted path, dropping the sections it leaves empty
+func deleteConfigValue(config map[string]interface{}, path string) {
+	key, rest, nested := strings.Cut(path, ".")
+	if !nested {
+		delete(config, key)
+		return
+	}
+	section, ok := config[key].(map[string]interface{})
+	if !ok {
+		return
+	}
+	deleteConfigValue(section, rest)
+	if len(section) == 0 {
+		delete(config, key)
+	}
+}
+
+// configHash hashes the settings that need a restart to take effect, the runtime settings are
+// applied to the running members instead
+func configHash(config string) string {
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal([]byte(config), &settings); err == nil {
+		for _, key := range runtimeConfigKeys {
+			deleteConfigValue(settings, key)
+		}
+		if out, err := yaml.Marshal(settings); err == nil {
+			config = string(out)
+		}
+	}
+	hash := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(hash[:])
+}
+
+// applyConfigFile mounts the rendered configuration into a container, points the process at it
+// and stamps its hash on the pod template so configuration changes needing a restart roll the pods
+func applyConfigFile(template *corev1.PodTemplateSpec, container, configMapName, key, config string) {
+	spec := &template.Spec
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: configVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+				Items:                []corev1.KeyToPath{{Key: key, Path: key}},
+			},
+		},
+	})
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		c.Args = []string{"--config", configMountPath + "/" + key}
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{Name: configVolumeName, MountPath: configMountPath, ReadOnly: true})
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[ConfigHashAnnotation] = configHash(config)
+}
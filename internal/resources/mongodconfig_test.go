@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAdditionalConfig(t *testing.T) {
+	assert.NoError(t, ValidateAdditionalConfig(nil))
+	assert.NoError(t, ValidateAdditionalConfig(map[string]string{
+		"net.maxIncomingConnections":       "2000",
+		"setParameter.ttlMonitorSleepSecs": "120",
+	}))
+
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"net.port": "27000"}))
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"replication": "{}"}))
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"security.keyFile.path": "/tmp"}))
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"storage..engine": "wiredTiger"}))
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"operationProfiling.mode": "all"}))
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"systemLog": "{}"}))
+}
+
+func TestBuildReplicaSetConfig(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.ReplicaSetName = "rs0"
+	mdb.Spec.Storage.DataDirPath = "/data/db"
+	mdb.Spec.AdditionalConfig = map[string]string{
+		"setParameter.ttlMonitorSleepSecs": "120",
+		"net.compression.compressors":      "zstd,snappy",
+		"storage.directoryPerDB":           "true",
+	}
+
+	assert.Equal(t, `net:
+  bindIpAll: true
+  compression:
+    compressors: zstd,snappy
+  port: 27017
+replication:
+  replSetName: rs0
+security:
+  authorization: enabled
+  keyFile: /etc/mongodb-keyfile/keyfile
+setParameter:
+  ttlMonitorSleepSecs: 120
+storage:
+  dbPath: /data/db
+  directoryPerDB: true
+`, BuildReplicaSetConfig(mdb))
+}
+
+func TestConfigFileRollsPods(t *testing.T) {
+	mdb := testMongoDB()
+	template := BuildReplicaSetStatefulSet(mdb).Spec.Template
+	mongod := findContainer(template.Spec.Containers, "mongodb")
+
+	assert.Equal(t, []string{"--config", "/etc/mongodb-config/mongod.conf"}, mongod.Args)
+	assert.Contains(t, volumeNames(template.Spec.Volumes), "config")
+	hash := template.Annotations[ConfigHashAnnotation]
+	require.NotEmpty(t, hash)
+
+	mdb.Spec.AdditionalConfig = map[string]string{"storage.directoryPerDB": "true"}
+	assert.NotEqual(t, hash, BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations[ConfigHashAnnotation])
+
+	cm := BuildMongodConfigMap(mdb.Name, mdb.Name, mdb.Namespace, BuildReplicaSetConfig(mdb), nil)
+	assert.Equal(t, mdb.Name+"-config", cm.Name)
+	assert.Contains(t, cm.Data[MongodConfigKey], "directoryPerDB: true")
+}
+
+func TestBuildShardedConfig(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.AdditionalConfig = map[string]string{"storage.directoryPerDB": "true"}
+
+	cfg := BuildConfigServerConfig(mdbsh)
+	assert.Contains(t, cfg, "port: 27019")
+	assert.Contains(t, cfg, "dbPath: /data/configdb")
+	assert.Contains(t, cfg, "directoryPerDB: true")
+
+	shard := BuildShardConfig(mdbsh, 1)
+	assert.Contains(t, shard, "port: 27018")
+	assert.Contains(t, shard, "replSetName: "+mdbsh.Name+"-shard-1")
+
+	mongos := BuildMongosConfig(mdbsh)
+	assert.Contains(t, mongos, "configDB: "+mdbsh.Name+"-cfg/")
+	assert.NotContains(t, mongos, "directoryPerDB")
+	assert.NotContains(t, mongos, "authorization")
+
+	deploy := BuildMongosDeployment(mdbsh)
+	assert.Equal(t, []string{"--config", "/etc/mongodb-config/mongos.conf"}, findContainer(deploy.Spec.Template.Spec.Containers, "mongos").Args)
+	assert.Equal(t, mongos, BuildMongosConfigMap(mdbsh).Data[MongosConfigKey])
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"maps"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// MongosTrackLabel tells the pods of the green mongos Deployment apart from the current ones
+	MongosTrackLabel = "mongodb.keiailab.com/mongos-track"
+
+	// MongosTrackGreen is the track of the green mongos Deployment
+	MongosTrackGreen = "green"
+)
+
+// MongosBlueGreenEnabled reports whether a green mongos Deployment runs next to the current one
+func MongosBlueGreenEnabled(mdbsh *mongodbv1alpha1.MongoDBSharded) bool {
+	return mdbsh.Spec.Mongos.BlueGreen != nil && MongosMode(mdbsh) == MongosModeDeployment
+}
+
+// MongosGreenDeploymentName returns the name of the green mongos Deployment
+func MongosGreenDeploymentName(clusterName string) string {
+	return clusterName + "-mongos-green"
+}
+
+// greenMongos returns a copy of the cluster running the green release of mongos
+func greenMongos(mdbsh *mongodbv1alpha1.MongoDBSharded) *mongodbv1alpha1.MongoDBSharded {
+	green := mdbsh.DeepCopy()
+	bg := mdbsh.Spec.Mongos.BlueGreen
+	green.Spec.Mongos.Replicas = bg.Replicas
+	green.Spec.Mongos.Version = bg.Version
+	green.Spec.Mongos.Image = bg.Image
+	return green
+}
+
+// MongosGreenVersion returns the MongoDB version the green mongos instances run
+func MongosGreenVersion(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	return MongosVersion(greenMongos(mdbsh))
+}
+
+// ValidateMongosBlueGreen checks the green release of mongos like the current one. The replica
+// counts of both Deployments weight the traffic, so the HPA must not scale one of them.
+func ValidateMongosBlueGreen(mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	bg := mdbsh.Spec.Mongos.BlueGreen
+	if bg == nil {
+		return nil
+	}
+	if MongosMode(mdbsh) != MongosModeDeployment {
+		return fmt.Errorf("mongos blue/green is only supported in %s mode", MongosModeDeployment)
+	}
+	if MongosAutoScalingEnabled(mdbsh) {
+		return fmt.Errorf("mongos blue/green is not supported with autoscaling")
+	}
+	if bg.Version == "" && bg.Image == "" {
+		return fmt.Errorf("mongos blue/green requires the version or image of the green release")
+	}
+
+	green := greenMongos(mdbsh)
+	if err := ValidateMongosVersion(green); err != nil {
+		return fmt.Errorf("green mongos: %w", err)
+	}
+	if err := ValidateAuth(green.Spec.Auth, MongosVersion(green)); err != nil {
+		return fmt.Errorf("green mongos: %w", err)
+	}
+	return nil
+}
+
+// BuildMongosGreenDeployment creates the green mongos Deployment, running the release of
+// spec.mongos.blueGreen. Its pods carry the labels the mongos Service selects, the track label
+// keeps the selectors of both Deployments apart.
+func BuildMongosGreenDeployment(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.Deployment {
+	deploy := BuildMongosDeployment(greenMongos(mdbsh))
+	deploy.Name = MongosGreenDeploymentName(mdbsh.Name)
+
+	selector := maps.Clone(deploy.Spec.Selector.MatchLabels)
+	selector[MongosTrackLabel] = MongosTrackGreen
+	deploy.Spec.Selector.MatchLabels = selector
+
+	deploy.Labels = maps.Clone(deploy.Labels)
+	deploy.Labels[MongosTrackLabel] = MongosTrackGreen
+
+	template := &deploy.Spec.Template
+	template.Labels = maps.Clone(template.Labels)
+	template.Labels[MongosTrackLabel] = MongosTrackGreen
+	return deploy
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateMongosBlueGreen(t *testing.T) {
+	autoscaling := &mongodbv1alpha1.AutoScalingSpec{Enabled: true, MaxReplicas: 5}
+
+	tests := []struct {
+		name    string
+		mutate  func(mdbsh *mongodbv1alpha1.MongoDBSharded)
+		wantErr bool
+	}{
+		{"disabled", func(mdbsh *mongodbv1alpha1.MongoDBSharded) { mdbsh.Spec.Mongos.BlueGreen = nil }, false},
+		{"green release of the members", func(mdbsh *mongodbv1alpha1.MongoDBSharded) {}, false},
+		{"green image", func(mdbsh *mongodbv1alpha1.MongoDBSharded) {
+			mdbsh.Spec.Mongos.BlueGreen = &mongodbv1alpha1.MongosBlueGreenSpec{Replicas: 1, Image: "registry.example.com/mongos:8.0-slim"}
+		}, false},
+		{"no release", func(mdbsh *mongodbv1alpha1.MongoDBSharded) {
+			mdbsh.Spec.Mongos.BlueGreen = &mongodbv1alpha1.MongosBlueGreenSpec{Replicas: 1}
+		}, true},
+		{"green newer than members", func(mdbsh *mongodbv1alpha1.MongoDBSharded) { mdbsh.Spec.Mongos.BlueGreen.Version = "8.2" }, true},
+		{"daemonset", func(mdbsh *mongodbv1alpha1.MongoDBSharded) { mdbsh.Spec.Mongos.Mode = MongosModeDaemonSet }, true},
+		{"autoscaling", func(mdbsh *mongodbv1alpha1.MongoDBSharded) { mdbsh.Spec.Mongos.AutoScaling = autoscaling }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdbsh := testMongoDBSharded()
+			mdbsh.Spec.Version.Version = "8.0"
+			mdbsh.Spec.Mongos.Version = "7.0"
+			mdbsh.Spec.Mongos.BlueGreen = &mongodbv1alpha1.MongosBlueGreenSpec{Replicas: 1, Version: "8.0"}
+			tt.mutate(mdbsh)
+
+			err := ValidateMongosBlueGreen(mdbsh)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildMongosGreenDeployment(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Version.Version = "8.0"
+	mdbsh.Spec.Mongos.Version = "7.0"
+	assert.False(t, MongosBlueGreenEnabled(mdbsh))
+	assert.Equal(t, []string{"my-sharded-mongos"}, MongosDeploymentNames(mdbsh))
+
+	mdbsh.Spec.Mongos.BlueGreen = &mongodbv1alpha1.MongosBlueGreenSpec{Replicas: 1, Version: "8.0"}
+	assert.True(t, MongosBlueGreenEnabled(mdbsh))
+	assert.Equal(t, []string{"my-sharded-mongos", "my-sharded-mongos-green"}, MongosDeploymentNames(mdbsh))
+	assert.Equal(t, "8.0", MongosGreenVersion(mdbsh))
+
+	blue := BuildMongosDeployment(mdbsh)
+	green := BuildMongosGreenDeployment(mdbsh)
+	assert.Equal(t, "my-sharded-mongos-green", green.Name)
+	assert.Equal(t, int32(1), *green.Spec.Replicas)
+	assert.Equal(t, int32(2), *blue.Spec.Replicas)
+	assert.Equal(t, "mongo:8.0", findContainer(green.Spec.Template.Spec.Containers, "mongos").Image)
+	assert.Equal(t, "mongo:7.0", findContainer(blue.Spec.Template.Spec.Containers, "mongos").Image)
+
+	// Both Deployments serve behind the mongos Service, their selectors stay apart
+	svc := BuildMongosService(mdbsh)
+	for key, value := range svc.Spec.Selector {
+		assert.Equal(t, value, green.Spec.Template.Labels[key])
+	}
+	assert.Equal(t, MongosTrackGreen, green.Spec.Selector.MatchLabels[MongosTrackLabel])
+	assert.Equal(t, MongosTrackGreen, green.Labels[MongosTrackLabel])
+	assert.NotContains(t, blue.Spec.Template.Labels, MongosTrackLabel)
+	assert.NotContains(t, blue.Spec.Selector.MatchLabels, MongosTrackLabel)
+
+	// The green image overrides the one of the current release
+	mdbsh.Spec.Mongos.Image = "registry.example.com/mongos:7.0-slim"
+	mdbsh.Spec.Mongos.BlueGreen.Image = "registry.example.com/mongos:8.0-slim"
+	green = BuildMongosGreenDeployment(mdbsh)
+	assert.Equal(t, "registry.example.com/mongos:8.0-slim", findContainer(green.Spec.Template.Spec.Containers, "mongos").Image)
+
+	// The green release is only run by a single Deployment
+	mdbsh.Spec.Mongos.Mode = MongosModePerZone
+	mdbsh.Spec.Mongos.Zones = []string{"zone-a"}
+	assert.False(t, MongosBlueGreenEnabled(mdbsh))
+}
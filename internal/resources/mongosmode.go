@@ -0,0 +1,163 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"maps"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// Mongos modes
+	MongosModeDeployment = "Deployment"
+	MongosModeDaemonSet  = "DaemonSet"
+	MongosModePerZone    = "PerZone"
+
+	// MongosZoneLabel is the pod label carrying the zone of a mongos in PerZone mode
+	MongosZoneLabel = "mongodb.keiailab.com/mongos-zone"
+)
+
+// MongosMode returns the mode of the mongos instances, Deployment when unset
+func MongosMode(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	if mdbsh.Spec.Mongos.Mode == "" {
+		return MongosModeDeployment
+	}
+	return mdbsh.Spec.Mongos.Mode
+}
+
+// ValidateMongosMode checks the zones of PerZone mode and that autoscaling is only used with a
+// single Deployment
+func ValidateMongosMode(spec mongodbv1alpha1.MongosSpec) error {
+	mode := spec.Mode
+	if mode == "" {
+		mode = MongosModeDeployment
+	}
+
+	if mode == MongosModePerZone {
+		if len(spec.Zones) == 0 {
+			return fmt.Errorf("mongos mode %s requires at least one zone", mode)
+		}
+		seen := make(map[string]bool, len(spec.Zones))
+		for _, zone := range spec.Zones {
+			if seen[zone] {
+				return fmt.Errorf("duplicate mongos zone %q", zone)
+			}
+			seen[zone] = true
+
+			// The zone is part of the Deployment name
+			if errs := validation.IsDNS1123Label(zone); len(errs) > 0 {
+				return fmt.Errorf("mongos zone %q is not a valid name: %s", zone, errs[0])
+			}
+		}
+	} else if len(spec.Zones) > 0 {
+		return fmt.Errorf("mongos zones are only supported in %s mode", MongosModePerZone)
+	}
+
+	if mode != MongosModeDeployment && spec.AutoScaling != nil && spec.AutoScaling.Enabled {
+		return fmt.Errorf("mongos autoscaling is only supported in %s mode", MongosModeDeployment)
+	}
+	return nil
+}
+
+// MongosZoneDeploymentName returns the name of the mongos Deployment of a zone in PerZone mode
+func MongosZoneDeploymentName(clusterName, zone string) string {
+	return fmt.Sprintf("%s-mongos-%s", clusterName, zone)
+}
+
+// MongosDeploymentNames returns the names of the mongos Deployments of the current mode, none in
+// DaemonSet mode, the green one included during a blue/green upgrade
+func MongosDeploymentNames(mdbsh *mongodbv1alpha1.MongoDBSharded) []string {
+	switch MongosMode(mdbsh) {
+	case MongosModeDaemonSet:
+		return nil
+	case MongosModePerZone:
+		names := make([]string, 0, len(mdbsh.Spec.Mongos.Zones))
+		for _, zone := range mdbsh.Spec.Mongos.Zones {
+			names = append(names, MongosZoneDeploymentName(mdbsh.Name, zone))
+		}
+		return names
+	}
+	if MongosBlueGreenEnabled(mdbsh) {
+		return []string{mdbsh.Name + "-mongos", MongosGreenDeploymentName(mdbsh.Name)}
+	}
+	return []string{mdbsh.Name + "-mongos"}
+}
+
+// BuildMongosDaemonSet creates a DaemonSet running one mongos on every node selected by the
+// mongos pod node selector
+func BuildMongosDaemonSet(mdbsh *mongodbv1alpha1.MongoDBSharded) *appsv1.DaemonSet {
+	deploy := BuildMongosDeployment(mdbsh)
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: deploy.ObjectMeta,
+		Spec: appsv1.DaemonSetSpec{
+			Selector:       deploy.Spec.Selector,
+			Template:       deploy.Spec.Template,
+			UpdateStrategy: buildMongosDaemonSetStrategy(mdbsh.Spec.Mongos),
+		},
+	}
+}
+
+// BuildMongosZoneDeployments creates one mongos Deployment per zone, each running spec.mongos.replicas
+// instances pinned to its zone. The zone label keeps the selectors of the Deployments apart.
+func BuildMongosZoneDeployments(mdbsh *mongodbv1alpha1.MongoDBSharded) []*appsv1.Deployment {
+	deployments := make([]*appsv1.Deployment, 0, len(mdbsh.Spec.Mongos.Zones))
+	for _, zone := range mdbsh.Spec.Mongos.Zones {
+		deploy := BuildMongosDeployment(mdbsh)
+		deploy.Name = MongosZoneDeploymentName(mdbsh.Name, zone)
+
+		selector := maps.Clone(deploy.Spec.Selector.MatchLabels)
+		selector[MongosZoneLabel] = zone
+		deploy.Spec.Selector.MatchLabels = selector
+
+		deploy.Labels = maps.Clone(deploy.Labels)
+		deploy.Labels[MongosZoneLabel] = zone
+
+		template := &deploy.Spec.Template
+		template.Labels = maps.Clone(template.Labels)
+		template.Labels[MongosZoneLabel] = zone
+		template.Spec.NodeSelector = mergeStringMaps(template.Spec.NodeSelector, map[string]string{zoneTopologyKey: zone})
+
+		deployments = append(deployments, deploy)
+	}
+	return deployments
+}
+
+// buildMongosDaemonSetStrategy returns the DaemonSet strategy of mongos. Surge starts the new
+// instance on a node before stopping the old one.
+func buildMongosDaemonSetStrategy(spec mongodbv1alpha1.MongosSpec) appsv1.DaemonSetUpdateStrategy {
+	maxUnavailable := intstr.FromInt(1)
+	maxSurge := intstr.FromInt(0)
+	if spec.UpdateStrategy == MongosUpdateSurge {
+		maxUnavailable = intstr.FromInt(0)
+		maxSurge = intstr.FromInt(1)
+	}
+
+	return appsv1.DaemonSetUpdateStrategy{
+		Type: appsv1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+			MaxUnavailable: &maxUnavailable,
+			MaxSurge:       &maxSurge,
+		},
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateMongosMode(t *testing.T) {
+	autoscaling := &mongodbv1alpha1.AutoScalingSpec{Enabled: true, MaxReplicas: 5}
+
+	tests := []struct {
+		name    string
+		spec    mongodbv1alpha1.MongosSpec
+		wantErr bool
+	}{
+		{"default", mongodbv1alpha1.MongosSpec{Replicas: 2}, false},
+		{"daemonset", mongodbv1alpha1.MongosSpec{Mode: MongosModeDaemonSet}, false},
+		{"per zone", mongodbv1alpha1.MongosSpec{Replicas: 1, Mode: MongosModePerZone, Zones: []string{"eu-west-1a", "eu-west-1b"}}, false},
+		{"per zone without zones", mongodbv1alpha1.MongosSpec{Replicas: 1, Mode: MongosModePerZone}, true},
+		{"duplicate zone", mongodbv1alpha1.MongosSpec{Replicas: 1, Mode: MongosModePerZone, Zones: []string{"a", "a"}}, true},
+		{"invalid zone name", mongodbv1alpha1.MongosSpec{Replicas: 1, Mode: MongosModePerZone, Zones: []string{"Zone_A"}}, true},
+		{"zones without per zone", mongodbv1alpha1.MongosSpec{Replicas: 1, Zones: []string{"a"}}, true},
+		{"autoscaling", mongodbv1alpha1.MongosSpec{Replicas: 2, AutoScaling: autoscaling}, false},
+		{"autoscaling with daemonset", mongodbv1alpha1.MongosSpec{Mode: MongosModeDaemonSet, AutoScaling: autoscaling}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMongosMode(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBuildMongosDaemonSet(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.Mode = MongosModeDaemonSet
+	mdbsh.Spec.Mongos.Pod = &mongodbv1alpha1.PodSpec{NodeSelector: map[string]string{"role": "app"}}
+
+	ds := BuildMongosDaemonSet(mdbsh)
+	assert.Equal(t, "my-sharded-mongos", ds.Name)
+	assert.Equal(t, "app", ds.Spec.Template.Spec.NodeSelector["role"])
+	assert.NotNil(t, findContainer(ds.Spec.Template.Spec.Containers, "mongos"))
+	assert.Equal(t, intstr.FromInt(1), *ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable)
+	assert.Empty(t, MongosDeploymentNames(mdbsh))
+
+	mdbsh.Spec.Mongos.UpdateStrategy = MongosUpdateSurge
+	rolling := BuildMongosDaemonSet(mdbsh).Spec.UpdateStrategy.RollingUpdate
+	assert.Equal(t, intstr.FromInt(0), *rolling.MaxUnavailable)
+	assert.Equal(t, intstr.FromInt(1), *rolling.MaxSurge)
+
+	svc := BuildMongosService(mdbsh)
+	require.NotNil(t, svc.Spec.InternalTrafficPolicy)
+	assert.Equal(t, corev1.ServiceInternalTrafficPolicyLocal, *svc.Spec.InternalTrafficPolicy)
+}
+
+func TestBuildMongosZoneDeployments(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.Mode = MongosModePerZone
+	mdbsh.Spec.Mongos.Zones = []string{"zone-a", "zone-b"}
+
+	deployments := BuildMongosZoneDeployments(mdbsh)
+	require.Len(t, deployments, 2)
+	assert.Equal(t, []string{"my-sharded-mongos-zone-a", "my-sharded-mongos-zone-b"}, MongosDeploymentNames(mdbsh))
+
+	for i, zone := range mdbsh.Spec.Mongos.Zones {
+		deploy := deployments[i]
+		assert.Equal(t, MongosZoneDeploymentName("my-sharded", zone), deploy.Name)
+		assert.Equal(t, int32(2), *deploy.Spec.Replicas)
+		assert.Equal(t, zone, deploy.Spec.Selector.MatchLabels[MongosZoneLabel])
+		assert.Equal(t, zone, deploy.Spec.Template.Labels[MongosZoneLabel])
+		assert.Equal(t, zone, deploy.Spec.Template.Spec.NodeSelector["topology.kubernetes.io/zone"])
+		assert.Equal(t, "mongos", deploy.Spec.Template.Labels["app.kubernetes.io/component"])
+	}
+
+	svc := BuildMongosService(mdbsh)
+	assert.NotContains(t, svc.Spec.Selector, MongosZoneLabel)
+	require.NotNil(t, svc.Spec.TrafficDistribution)
+	assert.Equal(t, corev1.ServiceTrafficDistributionPreferClose, *svc.Spec.TrafficDistribution)
+}
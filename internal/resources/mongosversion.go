@@ -0,0 +1,73 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// MongosVersion returns the MongoDB version mongos runs, spec.version unless overridden
+func MongosVersion(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	if mdbsh.Spec.Mongos.Version != "" {
+		return mdbsh.Spec.Mongos.Version
+	}
+	return mdbsh.Spec.Version.Version
+}
+
+// getMongosImage returns the mongos image: the image override, the official image of the version
+// override, or the image of the data-bearing members. spec.version.imageDigest is the digest of
+// the image of the members, so it only pins mongos when mongos runs that image.
+func getMongosImage(mdbsh *mongodbv1alpha1.MongoDBSharded) string {
+	if mdbsh.Spec.Mongos.Image != "" {
+		return mdbsh.Spec.Mongos.Image
+	}
+	if mdbsh.Spec.Mongos.Version != "" {
+		return mongoDBImage(mdbsh.Spec.Mongos.Version)
+	}
+	return getMongoDBImage(mdbsh.Spec.Version)
+}
+
+// ValidateMongosVersion checks that mongos can run against the config servers and shards. Sharded
+// clusters upgrade mongos last and downgrade it first, so mongos runs the release of the members
+// or an older one, at most one major version behind.
+func ValidateMongosVersion(mdbsh *mongodbv1alpha1.MongoDBSharded) error {
+	if mdbsh.Spec.Mongos.Version == "" {
+		return nil
+	}
+
+	version := mdbsh.Spec.Version.Version
+	mongosMajor, mongosMinor, ok := parseReleaseSeries(mdbsh.Spec.Mongos.Version)
+	if !ok {
+		return fmt.Errorf("invalid mongos version %q", mdbsh.Spec.Mongos.Version)
+	}
+	major, _, ok := parseReleaseSeries(version)
+	if !ok {
+		return fmt.Errorf("invalid version %q", version)
+	}
+
+	if !versionAtLeast(version, mongosMajor, mongosMinor) {
+		return fmt.Errorf("mongos version %s is newer than the config servers and shards (%s), upgrade them first",
+			mdbsh.Spec.Mongos.Version, version)
+	}
+	if mongosMajor < major-1 {
+		return fmt.Errorf("mongos version %s is more than one major version behind the config servers and shards (%s)",
+			mdbsh.Spec.Mongos.Version, version)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateMongosVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		mongos  string
+		wantErr bool
+	}{
+		{"no override", "8.0", "", false},
+		{"same version", "8.0", "8.0.4", false},
+		{"older minor", "8.2", "8.0", false},
+		{"previous major", "8.0", "7.0", false},
+		{"newer than members", "7.0", "8.0", true},
+		{"newer minor", "8.0", "8.2", true},
+		{"two majors behind", "8.0", "6.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdbsh := testMongoDBSharded()
+			mdbsh.Spec.Version.Version = tt.version
+			mdbsh.Spec.Mongos.Version = tt.mongos
+
+			err := ValidateMongosVersion(mdbsh)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMongosImage(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Version.Version = "8.0"
+
+	image := func() string {
+		return findContainer(BuildMongosDeployment(mdbsh).Spec.Template.Spec.Containers, "mongos").Image
+	}
+	assert.Equal(t, "mongo:8.0", image())
+	assert.Equal(t, "8.0", MongosVersion(mdbsh))
+
+	mdbsh.Spec.Mongos.Version = "7.0"
+	assert.Equal(t, "mongo:7.0", image())
+	assert.Equal(t, "7.0", MongosVersion(mdbsh))
+	assert.Equal(t, "mongo:8.0", findContainer(BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Containers, "mongodb").Image)
+
+	mdbsh.Spec.Mongos.Image = "registry.example.com/mongos:7.0-slim"
+	assert.Equal(t, "registry.example.com/mongos:7.0-slim", image())
+
+	// The digest of the member image only pins mongos when mongos runs that image
+	digest := "sha256:" + strings.Repeat("c", 64)
+	mdbsh.Spec.Version.ImageDigest = digest
+	assert.Equal(t, "registry.example.com/mongos:7.0-slim", image())
+	assert.Equal(t, "mongo:8.0@"+digest, findContainer(BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.Containers, "mongodb").Image)
+	mdbsh.Spec.Mongos.Image = ""
+	mdbsh.Spec.Mongos.Version = ""
+	assert.Equal(t, "mongo:8.0@"+digest, image())
+}
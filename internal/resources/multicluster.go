@@ -0,0 +1,166 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// MultiClusterEnabled reports whether the replica set spans Kubernetes clusters
+func MultiClusterEnabled(mdb *mongodbv1alpha1.MongoDB) bool {
+	return mdb.Spec.MultiCluster != nil
+}
+
+// ValidateMultiCluster checks the Kubernetes clusters of a multi-cluster replica set. Every cluster
+// generates its own secrets, so the keyfile and admin credentials must be referenced secrets holding
+// the same values everywhere, and the members of other clusters are only verified with a shared CA.
+func ValidateMultiCluster(mdb *mongodbv1alpha1.MongoDB) error {
+	spec := mdb.Spec.MultiCluster
+	if spec == nil {
+		return nil
+	}
+	if Standalone(mdb) {
+		return fmt.Errorf("standalone mode has no replica set to spread over Kubernetes clusters")
+	}
+	if ArbiterEnabled(mdb) {
+		return fmt.Errorf("arbiters are not supported with multiCluster, run a member in a third Kubernetes cluster instead")
+	}
+
+	var members int32
+	local := false
+	seen := make(map[string]bool, len(spec.Clusters))
+	for _, cluster := range spec.Clusters {
+		if seen[cluster.Name] {
+			return fmt.Errorf("Kubernetes cluster %s is listed more than once", cluster.Name)
+		}
+		seen[cluster.Name] = true
+		local = local || cluster.Name == spec.ClusterName
+		members += cluster.Members
+	}
+	if !local {
+		return fmt.Errorf("clusterName %s is not one of the clusters", spec.ClusterName)
+	}
+	if members != mdb.Spec.Members {
+		return fmt.Errorf("the clusters run %d members, members must be their sum (got %d)", members, mdb.Spec.Members)
+	}
+	if len(mdb.Spec.InitScripts) > 0 && !MultiClusterCoordinator(mdb) {
+		return fmt.Errorf("initScripts run once for the replica set, declare them in the resource of the first cluster only")
+	}
+
+	if IsKeyfileSecretGenerated(mdb.Spec.Auth) {
+		return fmt.Errorf("multiCluster requires auth.keyfileSecretRef, the keyfile must be the same in every Kubernetes cluster")
+	}
+	if IsAdminSecretGenerated(mdb.Spec.Auth) {
+		return fmt.Errorf("multiCluster requires auth.adminCredentialsSecretRef, the admin credentials must be the same in every Kubernetes cluster")
+	}
+	if TLSEnabled(mdb.Spec.TLS) && (mdb.Spec.TLS.CustomCert == nil || mdb.Spec.TLS.CustomCert.SecretName == "") {
+		return fmt.Errorf("multiCluster requires tls.customCert, a certificate for the member host names signed by a CA shared by every Kubernetes cluster")
+	}
+	return nil
+}
+
+// LocalMembers returns the ordinal of the first member running in this Kubernetes cluster and the
+// number of them: all the members of a replica set in a single cluster
+func LocalMembers(mdb *mongodbv1alpha1.MongoDB) (first, count int32) {
+	if !MultiClusterEnabled(mdb) {
+		return 0, mdb.Spec.Members
+	}
+	for _, cluster := range mdb.Spec.MultiCluster.Clusters {
+		if cluster.Name == mdb.Spec.MultiCluster.ClusterName {
+			return first, cluster.Members
+		}
+		first += cluster.Members
+	}
+	return 0, 0
+}
+
+// LocalMemberPods returns the pod names of the members running in this Kubernetes cluster
+func LocalMemberPods(mdb *mongodbv1alpha1.MongoDB) []string {
+	first, count := LocalMembers(mdb)
+	pods := make([]string, 0, count)
+	for i := first; i < first+count; i++ {
+		pods = append(pods, fmt.Sprintf("%s-%d", mdb.Name, i))
+	}
+	return pods
+}
+
+// FirstMemberPod returns the first member running in this Kubernetes cluster, the pod the operator
+// queries the replica set through
+func FirstMemberPod(mdb *mongodbv1alpha1.MongoDB) string {
+	first, _ := LocalMembers(mdb)
+	return fmt.Sprintf("%s-%d", mdb.Name, first)
+}
+
+// IsLocalMember reports whether the member running in pod name runs in this Kubernetes cluster, where
+// the operator can reach it
+func IsLocalMember(mdb *mongodbv1alpha1.MongoDB, name string) bool {
+	return slices.Contains(LocalMemberPods(mdb), name)
+}
+
+// MultiClusterCoordinator reports whether this Kubernetes cluster initiates the replica set: the first
+// one of a multi-cluster replica set
+func MultiClusterCoordinator(mdb *mongodbv1alpha1.MongoDB) bool {
+	spec := mdb.Spec.MultiCluster
+	return spec == nil || (len(spec.Clusters) > 0 && spec.Clusters[0].Name == spec.ClusterName)
+}
+
+// MemberHost returns the host:port of a member in the replica set configuration: its address in
+// the headless Service, or <pod>.<domain> of its Kubernetes cluster for a multi-cluster replica set
+// or a disaster recovery cluster
+func MemberHost(mdb *mongodbv1alpha1.MongoDB, ordinal int32) string {
+	pod := fmt.Sprintf("%s-%d", mdb.Name, ordinal)
+	port := strconv.Itoa(int(ReplicaSetPort(mdb)))
+	if ReplicaOfEnabled(mdb) {
+		return net.JoinHostPort(pod+"."+mdb.Spec.ReplicaOf.Domain, port)
+	}
+	if !MultiClusterEnabled(mdb) {
+		return net.JoinHostPort(pod+"."+ServiceFQDN(mdb.Name+"-headless", mdb.Namespace, mdb.Spec.ClusterDomain), port)
+	}
+
+	var first int32
+	for _, cluster := range mdb.Spec.MultiCluster.Clusters {
+		if ordinal < first+cluster.Members {
+			return net.JoinHostPort(pod+"."+cluster.Domain, port)
+		}
+		first += cluster.Members
+	}
+	return ""
+}
+
+// MemberHosts returns the hosts of all the members of the replica set, indexed by ordinal
+func MemberHosts(mdb *mongodbv1alpha1.MongoDB) []string {
+	hosts := make([]string, 0, mdb.Spec.Members)
+	for i := int32(0); i < mdb.Spec.Members; i++ {
+		hosts = append(hosts, MemberHost(mdb, i))
+	}
+	return hosts
+}
+
+// PodMemberHost returns the host of the member running in pod name, empty when it is not a member
+func PodMemberHost(mdb *mongodbv1alpha1.MongoDB, name string) string {
+	for i, pod := range memberPodNames(mdb) {
+		if pod == name {
+			return MemberHost(mdb, int32(i))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// testMultiClusterSpec spreads 5 members over 3 Kubernetes clusters, as seen from clusterName
+func testMultiClusterSpec(clusterName string) *mongodbv1alpha1.MultiClusterSpec {
+	return &mongodbv1alpha1.MultiClusterSpec{
+		ClusterName: clusterName,
+		Clusters: []mongodbv1alpha1.MemberClusterSpec{
+			{Name: "west", Members: 2, Domain: "west.example.com"},
+			{Name: "east", Members: 2, Domain: "east.example.com"},
+			{Name: "north", Members: 1, Domain: "north.example.com"},
+		},
+	}
+}
+
+func TestValidateMultiCluster(t *testing.T) {
+	valid := testMongoDB()
+	valid.Spec.Members = 5
+	valid.Spec.Auth = testProvidedAuth()
+	valid.Spec.MultiCluster = testMultiClusterSpec("east")
+	require.NoError(t, ValidateMultiCluster(valid))
+	require.NoError(t, ValidateMultiCluster(&mongodbv1alpha1.MongoDB{}), "a replica set in a single Kubernetes cluster")
+
+	first := valid.DeepCopy()
+	first.Spec.MultiCluster.ClusterName = "west"
+	first.Spec.InitScripts = testInitScripts()
+	require.NoError(t, ValidateMultiCluster(first), "init scripts run in the first cluster")
+
+	tests := []struct {
+		name   string
+		mutate func(*mongodbv1alpha1.MongoDB)
+		err    string
+	}{
+		{"unknown cluster", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.MultiCluster.ClusterName = "south" }, "clusterName south"},
+		{"duplicate cluster", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.MultiCluster.Clusters[2].Name = "west" }, "more than once"},
+		{"member sum", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Members = 3 }, "sum"},
+		{"standalone", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Mode = ModeStandalone }, "standalone"},
+		{"arbiter", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Arbiter = &mongodbv1alpha1.ArbiterSpec{Enabled: true} }, "arbiters"},
+		{"generated keyfile", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Auth.KeyfileSecretRef = nil }, "keyfileSecretRef"},
+		{"generated admin", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Auth.AdminCredentialsSecretRef.Name = "" }, "adminCredentialsSecretRef"},
+		{"cert-manager TLS", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.TLS = &mongodbv1alpha1.TLSSpec{Enabled: true} }, "customCert"},
+		{"init scripts outside the first cluster", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.InitScripts = testInitScripts() }, "first cluster"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := valid.DeepCopy()
+			tt.mutate(mdb)
+			assert.ErrorContains(t, ValidateMultiCluster(mdb), tt.err)
+		})
+	}
+}
+
+func TestLocalMembers(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Members = 5
+	mdb.Spec.MultiCluster = testMultiClusterSpec("east")
+	first, count := LocalMembers(mdb)
+	assert.Equal(t, int32(2), first)
+	assert.Equal(t, int32(2), count)
+	assert.Equal(t, []string{"my-mongodb-2", "my-mongodb-3"}, LocalMemberPods(mdb))
+	assert.Equal(t, "my-mongodb-2", FirstMemberPod(mdb))
+	assert.True(t, IsLocalMember(mdb, "my-mongodb-3"))
+	assert.False(t, IsLocalMember(mdb, "my-mongodb-0"))
+	assert.False(t, MultiClusterCoordinator(mdb))
+
+	mdb.Spec.MultiCluster.ClusterName = "west"
+	assert.True(t, MultiClusterCoordinator(mdb))
+
+	mdb.Spec.MultiCluster = nil
+	first, count = LocalMembers(mdb)
+	assert.Equal(t, int32(0), first)
+	assert.Equal(t, int32(5), count)
+	assert.Equal(t, "my-mongodb-0", FirstMemberPod(mdb))
+	assert.True(t, MultiClusterCoordinator(mdb))
+}
+
+func TestMemberHosts(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Members = 5
+	mdb.Spec.MultiCluster = testMultiClusterSpec("east")
+	assert.Equal(t, []string{
+		"my-mongodb-0.west.example.com:27017",
+		"my-mongodb-1.west.example.com:27017",
+		"my-mongodb-2.east.example.com:27017",
+		"my-mongodb-3.east.example.com:27017",
+		"my-mongodb-4.north.example.com:27017",
+	}, MemberHosts(mdb))
+	assert.Equal(t, "my-mongodb-4.north.example.com:27017", PodMemberHost(mdb, "my-mongodb-4"))
+	assert.Empty(t, PodMemberHost(mdb, "my-mongodb-5"))
+
+	mdb.Spec.MultiCluster = nil
+	assert.Equal(t, "my-mongodb-1.my-mongodb-headless.default.svc.cluster.local:27017", MemberHost(mdb, 1))
+}
+
+func TestMultiClusterStatefulSet(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Members = 5
+	mdb.Spec.Auth = testProvidedAuth()
+	mdb.Spec.MultiCluster = testMultiClusterSpec("east")
+	sts := BuildReplicaSetStatefulSet(mdb)
+	assert.Equal(t, int32(2), *sts.Spec.Replicas)
+	require.NotNil(t, sts.Spec.Ordinals)
+	assert.Equal(t, int32(2), sts.Spec.Ordinals.Start)
+
+	mdb.Spec.MultiCluster.ClusterName = "west"
+	sts = BuildReplicaSetStatefulSet(mdb)
+	assert.Equal(t, int32(2), *sts.Spec.Replicas)
+	assert.Nil(t, sts.Spec.Ordinals, "the first cluster numbers its members from 0")
+}
+
+func TestMultiClusterExternalServices(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Members = 5
+	mdb.Spec.MultiCluster = testMultiClusterSpec("east")
+	mdb.Spec.ExternalAccess = &mongodbv1alpha1.ExternalAccessSpec{Enabled: true, Type: "LoadBalancer"}
+	var names []string
+	for _, svc := range BuildExternalServices(mdb) {
+		names = append(names, svc.Name)
+	}
+	assert.Equal(t, []string{"my-mongodb-2-external", "my-mongodb-3-external"}, names)
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// OperatorConfigName is the name of the MongoDBOperatorConfig the operator reads
+	OperatorConfigName = "default"
+
+	// FeatureShardHostRepair rewrites stale config.shards hosts of sharded clusters
+	FeatureShardHostRepair = "ShardHostRepair"
+
+	defaultImageRepository = "mongo"
+)
+
+// featureGates are the known feature gates and whether they are on by default
+var featureGates = map[string]bool{
+	FeatureShardHostRepair: true,
+}
+
+// operatorConfig holds the spec of the MongoDBOperatorConfig, nil when there is none
+var operatorConfig atomic.Pointer[mongodbv1alpha1.MongoDBOperatorConfigSpec]
+
+// SetOperatorConfig installs the spec of the MongoDBOperatorConfig, nil restores the built-in
+// defaults. The builders read it on every reconcile, so changes apply to a cluster at its next
+// reconcile.
+func SetOperatorConfig(spec *mongodbv1alpha1.MongoDBOperatorConfigSpec) {
+	operatorConfig.Store(spec.DeepCopy())
+}
+
+// OperatorConfig returns the spec of the MongoDBOperatorConfig, empty when there is none.
+// The result is shared and must not be modified.
+func OperatorConfig() mongodbv1alpha1.MongoDBOperatorConfigSpec {
+	if spec := operatorConfig.Load(); spec != nil {
+		return *spec
+	}
+	return mongodbv1alpha1.MongoDBOperatorConfigSpec{}
+}
+
+// ValidateOperatorConfig checks that the feature gates of the MongoDBOperatorConfig are known
+func ValidateOperatorConfig(spec *mongodbv1alpha1.MongoDBOperatorConfigSpec) error {
+	var unknown []string
+	for name := range spec.FeatureGates {
+		if _, ok := featureGates[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("unknown feature gates: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// FeatureEnabled reports whether a feature gate is on, by the MongoDBOperatorConfig or by default
+func FeatureEnabled(name string) bool {
+	if enabled, ok := OperatorConfig().FeatureGates[name]; ok {
+		return enabled
+	}
+	return featureGates[name]
+}
+
+// RequeueInterval returns how often up-to-date resources are checked again, fallback when the
+// MongoDBOperatorConfig does not set it
+func RequeueInterval(fallback time.Duration) time.Duration {
+	if seconds := OperatorConfig().RequeueIntervalSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
+
+// RetryInterval returns how soon resources waiting on pods or on another resource are checked
+// again, fallback when the MongoDBOperatorConfig does not set it
+func RetryInterval(fallback time.Duration) time.Duration {
+	if seconds := OperatorConfig().RetryIntervalSeconds; seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return fallback
+}
+
+// mongoDBImage returns the image of a MongoDB version in the default image repository
+func mongoDBImage(version string) string {
+	repository := OperatorConfig().Images.MongoDB
+	if repository == "" {
+		repository = defaultImageRepository
+	}
+	return fmt.Sprintf("%s:%s", repository, version)
+}
+
+func defaultExporterImage() string {
+	if image := OperatorConfig().Images.Exporter; image != "" {
+		return image
+	}
+	return exporterImage
+}
+
+func defaultBackupImage() string {
+	if image := OperatorConfig().Images.Backup; image != "" {
+		return image
+	}
+	return defaultImage
+}
+
+// resourcesOrDefault returns spec when it sets requests or limits, else a copy of the default
+// resource profile of the container
+func resourcesOrDefault(spec, profile mongodbv1alpha1.ResourcesSpec) mongodbv1alpha1.ResourcesSpec {
+	if len(spec.Requests) > 0 || len(spec.Limits) > 0 {
+		return spec
+	}
+	return *profile.DeepCopy()
+}
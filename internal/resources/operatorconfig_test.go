@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func setTestOperatorConfig(t *testing.T, spec *mongodbv1alpha1.MongoDBOperatorConfigSpec) {
+	t.Helper()
+	SetOperatorConfig(spec)
+	t.Cleanup(func() { SetOperatorConfig(nil) })
+}
+
+func TestOperatorConfigImages(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Version.Version = "7.0"
+	backup := &mongodbv1alpha1.MongoDBBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"}}
+
+	assert.Equal(t, "mongo:7.0", BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "mongo:8.2", BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Image)
+
+	setTestOperatorConfig(t, &mongodbv1alpha1.MongoDBOperatorConfigSpec{
+		Images: mongodbv1alpha1.OperatorImagesSpec{
+			MongoDB:  "registry.example.com/mongo",
+			Exporter: "registry.example.com/mongodb_exporter:0.40",
+			Backup:   "registry.example.com/mongo-backup:1.0",
+		},
+	})
+	assert.Equal(t, "registry.example.com/mongo:7.0", BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers[0].Image)
+	assert.Equal(t, "registry.example.com/mongo-backup:1.0", BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Image)
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Monitoring = &mongodbv1alpha1.MonitoringSpec{Enabled: true}
+	mdbsh.Spec.Mongos.Version = "7.0"
+	assert.Equal(t, "registry.example.com/mongo:7.0", getMongosImage(mdbsh))
+	exporter := findContainer(BuildMongosDeployment(mdbsh).Spec.Template.Spec.Containers, "exporter")
+	require.NotNil(t, exporter)
+	assert.Equal(t, "registry.example.com/mongodb_exporter:0.40", exporter.Image)
+
+	// The image of the spec takes precedence
+	mdb.Spec.Version.Image = "percona/percona-server-mongodb:7.0"
+	assert.Equal(t, "percona/percona-server-mongodb:7.0", BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestOperatorConfigStorageClass(t *testing.T) {
+	defer func(name string) { DefaultStorageClassName = name }(DefaultStorageClassName)
+	DefaultStorageClassName = "standard-rwo"
+
+	setTestOperatorConfig(t, &mongodbv1alpha1.MongoDBOperatorConfigSpec{StorageClassName: "premium-rwo"})
+	assert.Equal(t, "premium-rwo", *buildStorageClassName(mongodbv1alpha1.StorageSpec{}))
+	assert.Equal(t, "fast-storage", *buildStorageClassName(mongodbv1alpha1.StorageSpec{StorageClassName: "fast-storage"}))
+}
+
+func TestOperatorConfigResources(t *testing.T) {
+	profile := mongodbv1alpha1.ResourcesSpec{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+	}
+	setTestOperatorConfig(t, &mongodbv1alpha1.MongoDBOperatorConfigSpec{
+		Resources: mongodbv1alpha1.OperatorResourcesSpec{Mongod: profile, Mongos: profile, Exporter: profile, Backup: profile},
+	})
+
+	mdb := testMongoDB()
+	container := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "1", container.Resources.Requests.Cpu().String())
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Monitoring = &mongodbv1alpha1.MonitoringSpec{Enabled: true}
+	assert.Equal(t, "1", BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().String())
+	exporter := findContainer(BuildMongosDeployment(mdbsh).Spec.Template.Spec.Containers, "exporter")
+	require.NotNil(t, exporter)
+	assert.Equal(t, "1", exporter.Resources.Requests.Cpu().String())
+	assert.Empty(t, exporter.Resources.Limits)
+
+	backup := &mongodbv1alpha1.MongoDBBackup{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"}}
+	assert.Equal(t, "1", BuildBackupJob(backup, "mongodb://host", BackupCredentials{}).Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().String())
+
+	// Requests or limits of the spec replace the profile as a whole
+	mdb.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}
+	container = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers[0]
+	assert.Empty(t, container.Resources.Requests)
+	assert.Equal(t, "2Gi", container.Resources.Limits.Memory().String())
+}
+
+func TestOperatorConfigIntervals(t *testing.T) {
+	assert.Equal(t, 30*time.Second, RequeueInterval(30*time.Second))
+	assert.Equal(t, 10*time.Second, RetryInterval(10*time.Second))
+
+	setTestOperatorConfig(t, &mongodbv1alpha1.MongoDBOperatorConfigSpec{RequeueIntervalSeconds: 120, RetryIntervalSeconds: 5})
+	assert.Equal(t, 2*time.Minute, RequeueInterval(30*time.Second))
+	assert.Equal(t, 5*time.Second, RetryInterval(10*time.Second))
+}
+
+func TestFeatureGates(t *testing.T) {
+	assert.True(t, FeatureEnabled(FeatureShardHostRepair))
+	assert.False(t, FeatureEnabled("Unknown"))
+
+	spec := &mongodbv1alpha1.MongoDBOperatorConfigSpec{FeatureGates: map[string]bool{FeatureShardHostRepair: false}}
+	require.NoError(t, ValidateOperatorConfig(spec))
+	setTestOperatorConfig(t, spec)
+	assert.False(t, FeatureEnabled(FeatureShardHostRepair))
+
+	spec.FeatureGates["Unknown"] = true
+	assert.EqualError(t, ValidateOperatorConfig(spec), "unknown feature gates: Unknown")
+	assert.False(t, FeatureEnabled("Unknown"), "the installed config is a copy")
+}
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// Operation types
+	OpsRequestStepDown          = "StepDown"
+	OpsRequestResyncMember      = "ResyncMember"
+	OpsRequestCompact           = "Compact"
+	OpsRequestRotateKeyfile     = "RotateKeyfile"
+	OpsRequestFlushRouterConfig = "FlushRouterConfig"
+	OpsRequestRestart           = "Restart"
+
+	// Operation and step phases
+	OpsRequestPending   = "Pending"
+	OpsRequestRunning   = "Running"
+	OpsRequestSucceeded = "Succeeded"
+	OpsRequestFailed    = "Failed"
+
+	// Operation steps
+	OpsStepStepDown          = "StepDown"
+	OpsStepWaitForPrimary    = "WaitForPrimary"
+	OpsStepDeleteData        = "DeleteData"
+	OpsStepWaitForMember     = "WaitForMember"
+	OpsStepCompact           = "Compact"
+	OpsStepRequestRotation   = "RequestRotation"
+	OpsStepWaitForRotation   = "WaitForRotation"
+	OpsStepFlushRouterConfig = "FlushRouterConfig"
+	OpsStepRequestRestart    = "RequestRestart"
+	OpsStepWaitForRollout    = "WaitForRollout"
+
+	// MongoDBSharded components
+	ComponentConfigServer = "ConfigServer"
+	ComponentShards       = "Shards"
+	ComponentMongos       = "Mongos"
+
+	// RestartAnnotation requests a rolling restart of the pods of a MongoDB cluster when set on it.
+	// MongoDBSharded clusters take one annotation per component, see RestartAnnotationFor.
+	// Changing its value restarts the pods again.
+	RestartAnnotation = "mongodb.keiailab.com/restart"
+
+	// RestartedAnnotation is set on pod templates to the restart request the pods run with
+	RestartedAnnotation = "mongodb.keiailab.com/restarted"
+)
+
+// RestartAnnotationFor returns the cluster annotation restarting a MongoDBSharded component, or
+// RestartAnnotation for an empty component
+func RestartAnnotationFor(component string) string {
+	if component == "" {
+		return RestartAnnotation
+	}
+	return RestartAnnotation + "-" + strings.ToLower(component)
+}
+
+// applyRestart copies the restart request of the cluster to the pod template, changing it rolls the pods
+func applyRestart(template *corev1.PodTemplateSpec, annotations map[string]string, key string) {
+	value := annotations[key]
+	if value == "" {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[RestartedAnnotation] = value
+}
+
+// OpsRequestSteps returns the steps of an operation, run in order
+func OpsRequestSteps(spec mongodbv1alpha1.MongoDBOpsRequestSpec) []string {
+	switch spec.Type {
+	case OpsRequestStepDown:
+		return []string{OpsStepStepDown, OpsStepWaitForPrimary}
+	case OpsRequestResyncMember:
+		return []string{OpsStepDeleteData, OpsStepWaitForMember}
+	case OpsRequestCompact:
+		return []string{OpsStepCompact}
+	case OpsRequestRotateKeyfile:
+		return []string{OpsStepRequestRotation, OpsStepWaitForRotation}
+	case OpsRequestFlushRouterConfig:
+		return []string{OpsStepFlushRouterConfig}
+	case OpsRequestRestart:
+		return []string{OpsStepRequestRestart, OpsStepWaitForRollout}
+	}
+	return nil
+}
+
+// ValidateOpsRequest checks that the fields an operation needs are set
+func ValidateOpsRequest(spec mongodbv1alpha1.MongoDBOpsRequestSpec) error {
+	sharded := spec.ClusterRef.Kind == "MongoDBSharded"
+
+	switch spec.Type {
+	case OpsRequestResyncMember:
+		if spec.Member == "" {
+			return fmt.Errorf("%s requires a member", spec.Type)
+		}
+	case OpsRequestCompact:
+		if spec.Member == "" || spec.Database == "" || spec.Collection == "" {
+			return fmt.Errorf("%s requires a member, a database and a collection", spec.Type)
+		}
+	case OpsRequestFlushRouterConfig:
+		if !sharded {
+			return fmt.Errorf("%s requires a MongoDBSharded cluster, got %s", spec.Type, spec.ClusterRef.Kind)
+		}
+	case "":
+		return fmt.Errorf("operation type must be set")
+	}
+
+	if spec.Component != "" && (!sharded || spec.Type != OpsRequestRestart) {
+		return fmt.Errorf("component is only supported by %s on a MongoDBSharded cluster", OpsRequestRestart)
+	}
+	return nil
+}
+
+// OpsRequestMember is the replica set member an operation targets
+type OpsRequestMember struct {
+	// Pod is the pod of the member
+	Pod string
+
+	// StatefulSet is the StatefulSet running the member
+	StatefulSet string
+
+	// Port is the port mongod listens on
+	Port int32
+
+	// Storage is the storage of the member data
+	Storage mongodbv1alpha1.StorageSpec
+}
+
+// ReplicaSetOpsMember returns the member of a MongoDB cluster running in pod
+func ReplicaSetOpsMember(mdb *mongodbv1alpha1.MongoDB, pod string) (*OpsRequestMember, error) {
+	if !slices.Contains(memberPodNames(mdb), pod) {
+		return nil, fmt.Errorf("%s is not a member of %s", pod, mdb.Name)
+	}
+	return &OpsRequestMember{Pod: pod, StatefulSet: mdb.Name, Port: ReplicaSetPort(mdb), Storage: mdb.Spec.Storage}, nil
+}
+
+// ShardedOpsMember returns the config server or shard member of a MongoDBSharded cluster running in pod
+func ShardedOpsMember(mdbsh *mongodbv1alpha1.MongoDBSharded, pod string) (*OpsRequestMember, error) {
+	cfg := mdbsh.Name + "-cfg"
+	for i := int32(0); i < mdbsh.Spec.ConfigServer.Members; i++ {
+		if pod == fmt.Sprintf("%s-%d", cfg, i) {
+			return &OpsRequestMember{Pod: pod, StatefulSet: cfg, Port: configServerPort, Storage: mdbsh.Spec.ConfigServer.Storage}, nil
+		}
+	}
+
+	for s := int32(0); s < mdbsh.Spec.Shards.Count; s++ {
+		shard := fmt.Sprintf("%s-shard-%d", mdbsh.Name, s)
+		for i := int32(0); i < mdbsh.Spec.Shards.MembersPerShard; i++ {
+			if pod == fmt.Sprintf("%s-%d", shard, i) {
+				return &OpsRequestMember{Pod: pod, StatefulSet: shard, Port: shardPort, Storage: mdbsh.Spec.Shards.Storage}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s is not a config server or shard member of %s", pod, mdbsh.Name)
+}
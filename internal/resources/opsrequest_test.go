@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateOpsRequest(t *testing.T) {
+	rs := mongodbv1alpha1.ClusterReference{Name: "my-mongodb", Kind: "MongoDB"}
+	sharded := mongodbv1alpha1.ClusterReference{Name: "my-sharded", Kind: "MongoDBSharded"}
+
+	tests := []struct {
+		name    string
+		spec    mongodbv1alpha1.MongoDBOpsRequestSpec
+		wantErr bool
+	}{
+		{"step down", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: rs, Type: OpsRequestStepDown}, false},
+		{"resync without member", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: rs, Type: OpsRequestResyncMember}, true},
+		{"compact without collection", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: rs, Type: OpsRequestCompact, Member: "my-mongodb-1", Database: "app"}, true},
+		{"compact", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: rs, Type: OpsRequestCompact, Member: "my-mongodb-1", Database: "app", Collection: "orders"}, false},
+		{"flush router config on a replica set", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: rs, Type: OpsRequestFlushRouterConfig}, true},
+		{"flush router config", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: sharded, Type: OpsRequestFlushRouterConfig}, false},
+		{"restart component", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: sharded, Type: OpsRequestRestart, Component: ComponentMongos}, false},
+		{"restart component of a replica set", mongodbv1alpha1.MongoDBOpsRequestSpec{ClusterRef: rs, Type: OpsRequestRestart, Component: ComponentMongos}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateOpsRequest(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.NotEmpty(t, OpsRequestSteps(tt.spec))
+			}
+		})
+	}
+}
+
+func TestOpsRequestMember(t *testing.T) {
+	member, err := ReplicaSetOpsMember(testMongoDB(), "my-mongodb-2")
+	require.NoError(t, err)
+	assert.Equal(t, "my-mongodb", member.StatefulSet)
+	assert.Equal(t, int32(27017), member.Port)
+
+	_, err = ReplicaSetOpsMember(testMongoDB(), "my-mongodb-3")
+	assert.Error(t, err)
+
+	mdbsh := testMongoDBSharded()
+	member, err = ShardedOpsMember(mdbsh, "my-sharded-cfg-0")
+	require.NoError(t, err)
+	assert.Equal(t, "my-sharded-cfg", member.StatefulSet)
+	assert.Equal(t, int32(27019), member.Port)
+
+	member, err = ShardedOpsMember(mdbsh, "my-sharded-shard-1-2")
+	require.NoError(t, err)
+	assert.Equal(t, "my-sharded-shard-1", member.StatefulSet)
+	assert.Equal(t, int32(27018), member.Port)
+
+	_, err = ShardedOpsMember(mdbsh, "my-sharded-shard-2-0")
+	assert.Error(t, err)
+}
+
+func TestApplyRestart(t *testing.T) {
+	mdb := testMongoDB()
+	assert.NotContains(t, BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations, RestartedAnnotation)
+
+	mdb.Annotations = map[string]string{RestartAnnotation: "req-1"}
+	assert.Equal(t, "req-1", BuildReplicaSetStatefulSet(mdb).Spec.Template.Annotations[RestartedAnnotation])
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Annotations = map[string]string{RestartAnnotationFor(ComponentMongos): "req-2"}
+	assert.Equal(t, "mongodb.keiailab.com/restart-mongos", RestartAnnotationFor(ComponentMongos))
+	assert.Equal(t, "req-2", BuildMongosDeployment(mdbsh).Spec.Template.Annotations[RestartedAnnotation])
+	assert.NotContains(t, BuildShardStatefulSet(mdbsh, 0).Spec.Template.Annotations, RestartedAnnotation)
+	assert.NotContains(t, BuildConfigServerStatefulSet(mdbsh).Spec.Template.Annotations, RestartedAnnotation)
+}
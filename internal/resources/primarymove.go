@@ -0,0 +1,88 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"slices"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// Phases of the move of the primary shard of a database
+const (
+	PrimaryMovePending = "Pending"
+	PrimaryMoving      = "Moving"
+	PrimaryMoved       = "Moved"
+	PrimaryMoveFailed  = "Failed"
+)
+
+// PlanPrimaryMoves records the databases removeShard reports on the shard being removed. A new
+// database is assigned to a remaining shard in turn, so the moved databases are spread over the
+// shards instead of all landing on the first one. A database no longer reported has moved.
+func PlanPrimaryMoves(removal *mongodbv1alpha1.ShardRemovalStatus, dbsToMove []string, clusterName string, count int32) {
+	now := metav1.Now()
+	for i := range removal.PrimaryMoves {
+		move := &removal.PrimaryMoves[i]
+		switch {
+		case slices.Contains(dbsToMove, move.Database) && move.Phase == PrimaryMoved:
+			// The database was created again on the shard
+			move.Phase = PrimaryMovePending
+			move.CompletionTime = nil
+		case !slices.Contains(dbsToMove, move.Database) && move.Phase != PrimaryMoved:
+			move.Phase = PrimaryMoved
+			move.Message = ""
+			move.CompletionTime = &now
+		}
+	}
+
+	for _, db := range dbsToMove {
+		if slices.ContainsFunc(removal.PrimaryMoves, func(m mongodbv1alpha1.PrimaryMoveStatus) bool { return m.Database == db }) {
+			continue
+		}
+		removal.PrimaryMoves = append(removal.PrimaryMoves, mongodbv1alpha1.PrimaryMoveStatus{
+			Database: db,
+			ToShard:  ShardName(clusterName, int32(len(removal.PrimaryMoves))%max(count, 1)),
+			Phase:    PrimaryMovePending,
+		})
+	}
+}
+
+// PendingPrimaryMoves returns the moves still to run, failed ones included
+func PendingPrimaryMoves(removal *mongodbv1alpha1.ShardRemovalStatus) []*mongodbv1alpha1.PrimaryMoveStatus {
+	var pending []*mongodbv1alpha1.PrimaryMoveStatus
+	for i := range removal.PrimaryMoves {
+		if removal.PrimaryMoves[i].Phase != PrimaryMoved {
+			pending = append(pending, &removal.PrimaryMoves[i])
+		}
+	}
+	return pending
+}
+
+// SetPrimaryMoveResult records the outcome of a movePrimary
+func SetPrimaryMoveResult(move *mongodbv1alpha1.PrimaryMoveStatus, err error) {
+	if err != nil {
+		move.Phase = PrimaryMoveFailed
+		move.Message = err.Error()
+		return
+	}
+	now := metav1.Now()
+	move.Phase = PrimaryMoved
+	move.Message = ""
+	move.CompletionTime = &now
+}
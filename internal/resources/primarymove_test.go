@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestPlanPrimaryMoves(t *testing.T) {
+	removal := &mongodbv1alpha1.ShardRemovalStatus{Name: "my-sharded-shard-3"}
+
+	// New databases are spread over the remaining shards
+	PlanPrimaryMoves(removal, []string{"app", "audit", "reports"}, "my-sharded", 2)
+	require.Len(t, removal.PrimaryMoves, 3)
+	assert.Equal(t, "my-sharded-shard-0", removal.PrimaryMoves[0].ToShard)
+	assert.Equal(t, "my-sharded-shard-1", removal.PrimaryMoves[1].ToShard)
+	assert.Equal(t, "my-sharded-shard-0", removal.PrimaryMoves[2].ToShard)
+	assert.Len(t, PendingPrimaryMoves(removal), 3)
+
+	SetPrimaryMoveResult(&removal.PrimaryMoves[0], nil)
+	SetPrimaryMoveResult(&removal.PrimaryMoves[1], errors.New("movePrimary failed: timed out"))
+	assert.Equal(t, PrimaryMoved, removal.PrimaryMoves[0].Phase)
+	assert.NotNil(t, removal.PrimaryMoves[0].CompletionTime)
+	assert.Equal(t, PrimaryMoveFailed, removal.PrimaryMoves[1].Phase)
+	assert.Equal(t, "movePrimary failed: timed out", removal.PrimaryMoves[1].Message)
+
+	// Known databases keep their target, databases no longer reported have moved
+	PlanPrimaryMoves(removal, []string{"audit"}, "my-sharded", 2)
+	require.Len(t, removal.PrimaryMoves, 3)
+	assert.Equal(t, "my-sharded-shard-1", removal.PrimaryMoves[1].ToShard)
+	assert.Equal(t, PrimaryMoveFailed, removal.PrimaryMoves[1].Phase)
+	assert.Equal(t, PrimaryMoved, removal.PrimaryMoves[2].Phase)
+	pending := PendingPrimaryMoves(removal)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "audit", pending[0].Database)
+
+	// A database created again on the shard moves again
+	PlanPrimaryMoves(removal, []string{"app", "audit"}, "my-sharded", 2)
+	assert.Equal(t, PrimaryMovePending, removal.PrimaryMoves[0].Phase)
+	assert.Nil(t, removal.PrimaryMoves[0].CompletionTime)
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// mongosReadinessTimeoutSeconds is the timeout of the mongos readiness probe
+	mongosReadinessTimeoutSeconds = 5
+
+	// mongosReadinessMaxTimeMS bounds the config server read of the readiness probe, leaving time
+	// for mongosh to start and exit within the probe timeout
+	mongosReadinessMaxTimeMS = 3000
+
+	// memberStartup2State is the replica set state of a member running its initial sync
+	memberStartup2State = 5
+
+	// notYetInitializedCode is the error code of replSetGetStatus before rs.initiate
+	notYetInitializedCode = 94
+
+	// startupProbePeriodSeconds is the interval of the startup probes
+	startupProbePeriodSeconds = 10
+
+	// memberStartupFailureThreshold gives mongod an hour to answer before the liveness probe
+	// takes over, WiredTiger recovery of a large dataset after an unclean shutdown keeps it from
+	// listening for that long
+	memberStartupFailureThreshold = 360
+
+	// mongosStartupFailureThreshold gives mongos five minutes to answer, it has no data to recover
+	mongosStartupFailureThreshold = 30
+)
+
+// pingCommand pings the mongod or mongos listening on port. mongosh defaults to 27017, the port
+// is only passed when it differs.
+func pingCommand(port int32) []string {
+	if port == mongoDBPort {
+		return []string{"mongosh", "--quiet", "--eval", "db.adminCommand('ping')"}
+	}
+	return []string{"mongosh", "--quiet", "--port", fmt.Sprintf("%d", port), "--eval", "db.adminCommand('ping')"}
+}
+
+// buildStartupProbe holds back the liveness and readiness probes until the container answers a
+// ping, so a slow start is not mistaken for a hung process. A member running its initial sync
+// answers, the liveness probe only restarts it when mongod stops responding.
+func buildStartupProbe(port, failureThreshold int32) *corev1.Probe {
+	return &corev1.Probe{
+		ProbeHandler: corev1.ProbeHandler{
+			Exec: &corev1.ExecAction{Command: pingCommand(port)},
+		},
+		PeriodSeconds:    startupProbePeriodSeconds,
+		TimeoutSeconds:   5,
+		FailureThreshold: failureThreshold,
+	}
+}
+
+// applyMemberProbes adds the probes of a config server or shard member, which only differ from
+// those of a replica set member by running the readiness script inline
+func applyMemberProbes(podSpec *corev1.PodSpec, container string, port int32) {
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		c.StartupProbe = buildStartupProbe(port, memberStartupFailureThreshold)
+		c.LivenessProbe = &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: pingCommand(port)},
+			},
+			InitialDelaySeconds: 30,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      5,
+			FailureThreshold:    6,
+		}
+		c.ReadinessProbe = &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				Exec: &corev1.ExecAction{Command: []string{"bash", "-c", memberReadinessScript(port)}},
+			},
+			InitialDelaySeconds: 5,
+			PeriodSeconds:       10,
+			TimeoutSeconds:      5,
+		}
+	}
+}
+
+// mongosReadinessScript checks that mongos answers and reaches the config servers. listShards
+// reads config.shards from the config servers, so a mongos that lost them is taken out of the
+// Service endpoints instead of failing client requests. The probe authenticates as __system with
+// the keyfile, its operations are told apart from client ones by their appName.
+func mongosReadinessScript(port int32) string {
+	return keyfileKeyScript + fmt.Sprintf(`mongosh --quiet "mongodb://127.0.0.1:%d/?appName=mongos-readiness&authSource=local" -u __system -p "$KEY" --eval '
+if (!db.hello().msg) {
+  quit(1);
+}
+const res = db.adminCommand({ listShards: 1, maxTimeMS: %d });
+if (!res.ok) {
+  quit(1);
+}' > /dev/null 2>&1
+`, port, mongosReadinessMaxTimeMS)
+}
+
+// memberReadinessScript checks the replica set state of the member, a ping answers even when it is
+// in ROLLBACK, RECOVERING or removed from the replica set. The primary, secondaries and arbiters
+// are ready. A member without a replica set configuration is ready too, so the pods can be
+// initiated and added, and so is a member running its initial sync, which would otherwise hold
+// back rolling updates for the length of the sync. The probe authenticates as __system with the
+// keyfile, replSetGetStatus requires authentication.
+func memberReadinessScript(port int32) string {
+	return keyfileKeyScript + fmt.Sprintf(`mongosh --quiet "mongodb://127.0.0.1:%d/?appName=member-readiness&authSource=local" -u __system -p "$KEY" --eval '
+const hello = db.hello();
+if (hello.isWritablePrimary || hello.secondary || hello.arbiterOnly) {
+  quit(0);
+}
+let status;
+try {
+  status = db.adminCommand({ replSetGetStatus: 1 });
+} catch (e) {
+  status = e;
+}
+if (status.code === %d || status.myState === %d) {
+  quit(0);
+}
+quit(1);' > /dev/null 2>&1
+`, port, notYetInitializedCode, memberStartup2State)
+}
+
+// applyProbeSettings overrides the timing of the probes of the container with the fields set in
+// spec.pod. It must be applied after the settings of ephemeral storage.
+func applyProbeSettings(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec, container string) {
+	if pod == nil {
+		return
+	}
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		overrideProbe(c.StartupProbe, pod.StartupProbe)
+		overrideProbe(c.LivenessProbe, pod.LivenessProbe)
+		overrideProbe(c.ReadinessProbe, pod.ReadinessProbe)
+	}
+}
+
+func overrideProbe(probe *corev1.Probe, spec *mongodbv1alpha1.ProbeSpec) {
+	if probe == nil || spec == nil {
+		return
+	}
+	if spec.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *spec.InitialDelaySeconds
+	}
+	if spec.PeriodSeconds != nil {
+		probe.PeriodSeconds = *spec.PeriodSeconds
+	}
+	if spec.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *spec.TimeoutSeconds
+	}
+	if spec.FailureThreshold != nil {
+		probe.FailureThreshold = *spec.FailureThreshold
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestMongosProbes(t *testing.T) {
+	mongos := findContainer(BuildMongosDeployment(testMongoDBSharded()).Spec.Template.Spec.Containers, "mongos")
+
+	require.NotNil(t, mongos.LivenessProbe.Exec)
+	assert.Nil(t, mongos.LivenessProbe.TCPSocket)
+	assert.Equal(t, []string{"mongosh", "--quiet", "--eval", "db.adminCommand('ping')"}, mongos.LivenessProbe.Exec.Command)
+
+	require.NotNil(t, mongos.ReadinessProbe.Exec)
+	script := mongos.ReadinessProbe.Exec.Command[2]
+	assert.Contains(t, script, "mongodb://127.0.0.1:27017/?appName=mongos-readiness&authSource=local")
+	assert.Contains(t, script, "listShards: 1, maxTimeMS: 3000")
+	assert.Equal(t, int32(5), mongos.ReadinessProbe.TimeoutSeconds)
+
+	drain := mongos.Lifecycle.PreStop.Exec.Command[2]
+	assert.Contains(t, drain, `"mongos-readiness"`)
+}
+
+func TestMemberReadinessProbe(t *testing.T) {
+	mdb := testMongoDB()
+
+	script := BuildMongoDBConfigMap(mdb).Data["readiness-probe.sh"]
+	assert.True(t, strings.HasPrefix(script, "#!/bin/bash\n"))
+	assert.Contains(t, script, "mongodb://127.0.0.1:27017/?appName=member-readiness&authSource=local")
+	assert.Contains(t, script, "hello.isWritablePrimary || hello.secondary || hello.arbiterOnly")
+	// Uninitialized members and members in initial sync stay ready
+	assert.Contains(t, script, "status.code === 94 || status.myState === 5")
+
+	// A standalone mongod has no replica set state and no keyfile
+	mdb.Spec.Mode = ModeStandalone
+	mongod := findContainer(BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers, "mongodb")
+	assert.Equal(t, []string{"mongosh", "--quiet", "--eval", "db.adminCommand('ping')"}, mongod.ReadinessProbe.Exec.Command)
+}
+
+func TestProbeSettings(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{
+		LivenessProbe:  &mongodbv1alpha1.ProbeSpec{FailureThreshold: int32Ptr(10)},
+		ReadinessProbe: &mongodbv1alpha1.ProbeSpec{PeriodSeconds: int32Ptr(30), TimeoutSeconds: int32Ptr(15)},
+	}
+
+	mongod := findContainer(BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers, "mongodb")
+	assert.Equal(t, int32(10), mongod.LivenessProbe.FailureThreshold)
+	assert.Equal(t, int32(30), mongod.LivenessProbe.InitialDelaySeconds)
+	assert.Equal(t, int32(30), mongod.ReadinessProbe.PeriodSeconds)
+	assert.Equal(t, int32(15), mongod.ReadinessProbe.TimeoutSeconds)
+	assert.Equal(t, int32(5), mongod.ReadinessProbe.InitialDelaySeconds)
+
+	// mongos takes the settings of spec.mongos.pod, unset fields keep their defaults
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.Pod = &mongodbv1alpha1.PodSpec{
+		ReadinessProbe: &mongodbv1alpha1.ProbeSpec{TimeoutSeconds: int32Ptr(8)},
+	}
+	mongos := findContainer(BuildMongosDeployment(mdbsh).Spec.Template.Spec.Containers, "mongos")
+	assert.Equal(t, int32(8), mongos.ReadinessProbe.TimeoutSeconds)
+	assert.Equal(t, int32(6), mongos.LivenessProbe.FailureThreshold)
+}
+
+func TestStartupProbe(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Port = 27100
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{
+		StartupProbe: &mongodbv1alpha1.ProbeSpec{FailureThreshold: int32Ptr(360)},
+	}
+
+	mongod := findContainer(BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Containers, "mongodb")
+	require.NotNil(t, mongod.StartupProbe)
+	assert.Equal(t, pingCommand(27100), mongod.StartupProbe.Exec.Command)
+	assert.Equal(t, int32(360), mongod.StartupProbe.FailureThreshold)
+	assert.Equal(t, int32(10), mongod.StartupProbe.PeriodSeconds)
+
+	mongos := findContainer(BuildMongosDeployment(testMongoDBSharded()).Spec.Template.Spec.Containers, "mongos")
+	require.NotNil(t, mongos.StartupProbe)
+	assert.Equal(t, int32(30), mongos.StartupProbe.FailureThreshold)
+}
+
+func TestShardedMemberProbes(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards.Pod = &mongodbv1alpha1.PodSpec{
+		StartupProbe: &mongodbv1alpha1.ProbeSpec{PeriodSeconds: int32Ptr(20)},
+	}
+
+	cfg := findContainer(BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.Containers, "mongodb")
+	assert.Equal(t, pingCommand(configServerPort), cfg.LivenessProbe.Exec.Command)
+	assert.Contains(t, cfg.ReadinessProbe.Exec.Command[2], "mongodb://127.0.0.1:27019/?appName=member-readiness")
+	assert.Equal(t, int32(10), cfg.StartupProbe.PeriodSeconds)
+	// Members get an hour to recover their data before the liveness probe runs
+	assert.Equal(t, int32(360), cfg.StartupProbe.FailureThreshold)
+
+	shard := findContainer(BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Containers, "mongodb")
+	assert.Contains(t, shard.ReadinessProbe.Exec.Command[2], "mongodb://127.0.0.1:27018/?appName=member-readiness")
+	assert.Equal(t, int32(20), shard.StartupProbe.PeriodSeconds)
+}
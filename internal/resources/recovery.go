@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// ForceReconfigAnnotation requests a forced reconfiguration keeping only the listed pods, a comma
+	// separated list of the members that survived the loss of the majority. Changing its value after
+	// a handled request starts another one.
+	ForceReconfigAnnotation = "mongodb.keiailab.com/force-reconfig"
+
+	// Force reconfiguration outcomes
+	ForceReconfigCompleted = "Completed"
+	ForceReconfigRejected  = "Rejected"
+)
+
+// ParseSurvivingMembers returns the pod names listed in the force-reconfig annotation value. Every
+// pod must be a member of the cluster and at least one must be listed.
+func ParseSurvivingMembers(mdb *mongodbv1alpha1.MongoDB, value string) ([]string, error) {
+	var survivors []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(survivors, name) {
+			continue
+		}
+		if !slices.Contains(memberPodNames(mdb), name) {
+			return nil, fmt.Errorf("%s is not a member of %s", name, mdb.Name)
+		}
+		survivors = append(survivors, name)
+	}
+	if len(survivors) == 0 {
+		return nil, fmt.Errorf("no surviving member listed in the %s annotation", ForceReconfigAnnotation)
+	}
+	return survivors, nil
+}
+
+// LostMembers returns the members a forced reconfiguration keeping the survivors removes
+func LostMembers(mdb *mongodbv1alpha1.MongoDB, survivors []string) []string {
+	var lost []string
+	for _, name := range memberPodNames(mdb) {
+		if !slices.Contains(survivors, name) {
+			lost = append(lost, name)
+		}
+	}
+	return lost
+}
+
+func memberPodNames(mdb *mongodbv1alpha1.MongoDB) []string {
+	names := make([]string, 0, mdb.Spec.Members)
+	for i := int32(0); i < mdb.Spec.Members; i++ {
+		names = append(names, fmt.Sprintf("%s-%d", mdb.Name, i))
+	}
+	return names
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSurvivingMembers(t *testing.T) {
+	mdb := testMongoDB()
+
+	survivors, err := ParseSurvivingMembers(mdb, " my-mongodb-2, my-mongodb-2,")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"my-mongodb-2"}, survivors)
+	assert.Equal(t, []string{"my-mongodb-0", "my-mongodb-1"}, LostMembers(mdb, survivors))
+
+	_, err = ParseSurvivingMembers(mdb, "my-mongodb-3")
+	assert.Error(t, err)
+
+	_, err = ParseSurvivingMembers(mdb, " , ")
+	assert.Error(t, err)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"net"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// ReplicaOfEnabled reports whether the members join a remote replica set as a disaster recovery cluster
+func ReplicaOfEnabled(mdb *mongodbv1alpha1.MongoDB) bool {
+	return mdb.Spec.ReplicaOf != nil
+}
+
+// ValidateReplicaOf checks the remote replica set of a disaster recovery cluster. The members
+// authenticate to the remote members with the keyfile and the remote replica set holds the users,
+// so both must be referenced secrets holding the values of the remote cluster.
+func ValidateReplicaOf(mdb *mongodbv1alpha1.MongoDB) error {
+	spec := mdb.Spec.ReplicaOf
+	if spec == nil {
+		return nil
+	}
+	if Standalone(mdb) {
+		return fmt.Errorf("a standalone mongod can not join a remote replica set")
+	}
+	if MultiClusterEnabled(mdb) {
+		return fmt.Errorf("replicaOf and multiCluster are mutually exclusive")
+	}
+	if ArbiterEnabled(mdb) {
+		return fmt.Errorf("arbiters are not supported with replicaOf, the members do not vote")
+	}
+	if len(mdb.Spec.InitScripts) > 0 {
+		return fmt.Errorf("initScripts are not supported with replicaOf, the data comes from the remote replica set")
+	}
+	for _, host := range spec.Hosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			return fmt.Errorf("replicaOf host %q is not host:port: %w", host, err)
+		}
+	}
+
+	if IsKeyfileSecretGenerated(mdb.Spec.Auth) {
+		return fmt.Errorf("replicaOf requires auth.keyfileSecretRef holding the keyfile of the remote replica set")
+	}
+	if IsAdminSecretGenerated(mdb.Spec.Auth) {
+		return fmt.Errorf("replicaOf requires auth.adminCredentialsSecretRef holding the admin credentials of the remote replica set")
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateReplicaOf(t *testing.T) {
+	valid := testMongoDB()
+	valid.Spec.Auth = testProvidedAuth()
+	valid.Spec.ReplicaOf = &mongodbv1alpha1.ReplicaOfSpec{Hosts: []string{"prod-0.prod.example.com:27017"}}
+	require.NoError(t, ValidateReplicaOf(valid))
+	require.NoError(t, ValidateReplicaOf(&mongodbv1alpha1.MongoDB{}))
+
+	tests := []struct {
+		name   string
+		mutate func(*mongodbv1alpha1.MongoDB)
+		err    string
+	}{
+		{"standalone", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Mode = ModeStandalone }, "standalone"},
+		{"multi-cluster", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.MultiCluster = &mongodbv1alpha1.MultiClusterSpec{} }, "mutually exclusive"},
+		{"arbiter", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Arbiter = &mongodbv1alpha1.ArbiterSpec{Enabled: true} }, "arbiters"},
+		{"init scripts", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.InitScripts = testInitScripts() }, "initScripts"},
+		{"host without port", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.ReplicaOf.Hosts = []string{"prod-0"} }, "host:port"},
+		{"generated keyfile", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Auth.KeyfileSecretRef = nil }, "keyfileSecretRef"},
+		{"generated admin", func(mdb *mongodbv1alpha1.MongoDB) { mdb.Spec.Auth.AdminCredentialsSecretRef.Name = "" }, "adminCredentialsSecretRef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb := valid.DeepCopy()
+			tt.mutate(mdb)
+			assert.ErrorContains(t, ValidateReplicaOf(mdb), tt.err)
+		})
+	}
+}
+
+func TestReplicaOfMemberHosts(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Name = "dr"
+	mdb.Spec.Members = 2
+	mdb.Spec.ReplicaOf = &mongodbv1alpha1.ReplicaOfSpec{
+		Hosts:  []string{"prod-0.prod.example.com:27017"},
+		Domain: "dr.example.com",
+	}
+	assert.Equal(t, []string{"dr-0.dr.example.com:27017", "dr-1.dr.example.com:27017"}, MemberHosts(mdb))
+	assert.Equal(t, []string{"dr-0", "dr-1"}, LocalMemberPods(mdb))
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// Resource profiles of spec.resourceProfile
+const (
+	ResourceProfileSmall  = "small"
+	ResourceProfileMedium = "medium"
+	ResourceProfileLarge  = "large"
+	ResourceProfileCustom = "custom"
+
+	cacheSizeKey = "storage.wiredTiger.engineConfig.cacheSizeGB"
+
+	// minCacheSizeGB is the smallest WiredTiger cache mongod accepts by default
+	minCacheSizeGB = 0.25
+)
+
+// resourceProfile holds the resources of the containers of a profile
+type resourceProfile struct {
+	mongod   mongodbv1alpha1.ResourcesSpec
+	mongos   mongodbv1alpha1.ResourcesSpec
+	exporter mongodbv1alpha1.ResourcesSpec
+}
+
+// resourceProfiles are the sizes of the small, medium and large profiles. mongod gets as much
+// memory requested as its limit, so the WiredTiger cache sized from the limit is never reclaimed.
+var resourceProfiles = map[string]resourceProfile{
+	ResourceProfileSmall: {
+		mongod:   profileResources("500m", "1Gi", "1", "1Gi"),
+		mongos:   profileResources("250m", "512Mi", "1", "512Mi"),
+		exporter: profileResources("50m", "64Mi", "200m", "256Mi"),
+	},
+	ResourceProfileMedium: {
+		mongod:   profileResources("1", "4Gi", "2", "4Gi"),
+		mongos:   profileResources("500m", "1Gi", "2", "1Gi"),
+		exporter: profileResources("100m", "128Mi", "500m", "256Mi"),
+	},
+	ResourceProfileLarge: {
+		mongod:   profileResources("4", "16Gi", "8", "16Gi"),
+		mongos:   profileResources("2", "4Gi", "4", "4Gi"),
+		exporter: profileResources("200m", "256Mi", "1", "512Mi"),
+	},
+}
+
+func profileResources(cpuRequest, memoryRequest, cpuLimit, memoryLimit string) mongodbv1alpha1.ResourcesSpec {
+	return mongodbv1alpha1.ResourcesSpec{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpuRequest),
+			corev1.ResourceMemory: resource.MustParse(memoryRequest),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpuLimit),
+			corev1.ResourceMemory: resource.MustParse(memoryLimit),
+		},
+	}
+}
+
+// mongodResources returns the resources of a mongod container: the ones of the spec, of the
+// resource profile, or of the operator config
+func mongodResources(spec mongodbv1alpha1.ResourcesSpec, profile string) mongodbv1alpha1.ResourcesSpec {
+	if p, ok := resourceProfiles[profile]; ok {
+		spec = resourcesOrDefault(spec, p.mongod)
+	}
+	return resourcesOrDefault(spec, OperatorConfig().Resources.Mongod)
+}
+
+// mongosResources returns the resources of the mongos container: the ones of the spec, of the
+// resource profile, or of the operator config
+func mongosResources(spec mongodbv1alpha1.ResourcesSpec, profile string) mongodbv1alpha1.ResourcesSpec {
+	if p, ok := resourceProfiles[profile]; ok {
+		spec = resourcesOrDefault(spec, p.mongos)
+	}
+	return resourcesOrDefault(spec, OperatorConfig().Resources.Mongos)
+}
+
+// exporterProfileResources returns the exporter resources of a resource profile, none for
+// custom or no profile
+func exporterProfileResources(profile string) mongodbv1alpha1.ResourcesSpec {
+	return resourceProfiles[profile].exporter
+}
+
+// ValidateResourceProfile checks that the data-bearing components of a custom profile set a
+// memory limit, which the WiredTiger cache size is derived from
+func ValidateResourceProfile(profile string, components map[string]mongodbv1alpha1.ResourcesSpec) error {
+	if profile != ResourceProfileCustom {
+		return nil
+	}
+	for _, component := range slices.Sorted(maps.Keys(components)) {
+		if _, ok := components[component].Limits[corev1.ResourceMemory]; !ok {
+			return fmt.Errorf("resourceProfile custom requires a memory limit in the %s resources", component)
+		}
+	}
+	return nil
+}
+
+// WiredTigerCacheSizeGB returns the WiredTiger cache size of a mongod with the given resources:
+// the mongod default of half the memory above 1GB, at least 0.25GB, computed from the container
+// memory limit instead of the node memory. It is 0 without a memory limit.
+func WiredTigerCacheSizeGB(spec mongodbv1alpha1.ResourcesSpec) float64 {
+	limit, ok := spec.Limits[corev1.ResourceMemory]
+	if !ok || limit.IsZero() {
+		return 0
+	}
+	gb := float64(limit.Value()) / (1 << 30)
+	return math.Max(minCacheSizeGB, math.Floor((gb-1)/2*100)/100)
+}
+
+// applyCacheSizeConfig sizes the WiredTiger cache of mongod from its memory limit when a resource
+// profile is set. additionalConfig can still override it.
+func applyCacheSizeConfig(config map[string]interface{}, profile string, spec mongodbv1alpha1.ResourcesSpec) {
+	if profile == "" {
+		return
+	}
+	if size := WiredTigerCacheSizeGB(spec); size > 0 {
+		setConfigValue(config, cacheSizeKey, size)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testMemoryLimit(limit string) mongodbv1alpha1.ResourcesSpec {
+	return mongodbv1alpha1.ResourcesSpec{
+		Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)},
+	}
+}
+
+func TestWiredTigerCacheSizeGB(t *testing.T) {
+	assert.Zero(t, WiredTigerCacheSizeGB(mongodbv1alpha1.ResourcesSpec{}))
+	assert.Equal(t, 0.25, WiredTigerCacheSizeGB(testMemoryLimit("512Mi")))
+	assert.Equal(t, 0.25, WiredTigerCacheSizeGB(testMemoryLimit("1Gi")))
+	assert.Equal(t, 1.5, WiredTigerCacheSizeGB(testMemoryLimit("4Gi")))
+	assert.Equal(t, 2.75, WiredTigerCacheSizeGB(testMemoryLimit("6.5Gi")))
+	assert.Equal(t, 7.5, WiredTigerCacheSizeGB(testMemoryLimit("16Gi")))
+}
+
+func TestValidateResourceProfile(t *testing.T) {
+	components := map[string]mongodbv1alpha1.ResourcesSpec{
+		ComponentConfigServer: testMemoryLimit("2Gi"),
+		ComponentShards:       {},
+	}
+	assert.NoError(t, ValidateResourceProfile("", components))
+	assert.NoError(t, ValidateResourceProfile(ResourceProfileMedium, components))
+	assert.EqualError(t, ValidateResourceProfile(ResourceProfileCustom, components),
+		"resourceProfile custom requires a memory limit in the Shards resources")
+
+	components[ComponentShards] = testMemoryLimit("8Gi")
+	assert.NoError(t, ValidateResourceProfile(ResourceProfileCustom, components))
+}
+
+func TestReplicaSetResourceProfile(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Monitoring = &mongodbv1alpha1.MonitoringSpec{Enabled: true}
+	sts := BuildReplicaSetStatefulSet(mdb)
+	assert.Empty(t, findContainer(sts.Spec.Template.Spec.Containers, "mongodb").Resources.Limits)
+	assert.NotContains(t, BuildReplicaSetConfig(mdb), "cacheSizeGB")
+
+	mdb.Spec.ResourceProfile = ResourceProfileMedium
+	sts = BuildReplicaSetStatefulSet(mdb)
+	mongod := findContainer(sts.Spec.Template.Spec.Containers, "mongodb").Resources
+	assert.Equal(t, "4Gi", mongod.Limits.Memory().String())
+	assert.Equal(t, "4Gi", mongod.Requests.Memory().String())
+	assert.Equal(t, "2", mongod.Limits.Cpu().String())
+	exporter := findContainer(sts.Spec.Template.Spec.Containers, "exporter").Resources
+	assert.Equal(t, "128Mi", exporter.Requests.Memory().String())
+	assert.Contains(t, BuildReplicaSetConfig(mdb), "cacheSizeGB: 1.5")
+
+	// Resources of the spec win over the profile, the cache size follows them
+	mdb.Spec.Resources = testMemoryLimit("8Gi")
+	sts = BuildReplicaSetStatefulSet(mdb)
+	mongod = findContainer(sts.Spec.Template.Spec.Containers, "mongodb").Resources
+	assert.Equal(t, "8Gi", mongod.Limits.Memory().String())
+	assert.Empty(t, mongod.Requests)
+	assert.Contains(t, BuildReplicaSetConfig(mdb), "cacheSizeGB: 3.5")
+
+	// Custom keeps the resources of the spec and the exporter defaults
+	mdb.Spec.ResourceProfile = ResourceProfileCustom
+	sts = BuildReplicaSetStatefulSet(mdb)
+	exporter = findContainer(sts.Spec.Template.Spec.Containers, "exporter").Resources
+	assert.Equal(t, "64Mi", exporter.Requests.Memory().String())
+	assert.Contains(t, BuildReplicaSetConfig(mdb), "cacheSizeGB: 3.5")
+
+	// additionalConfig overrides the cache size
+	mdb.Spec.AdditionalConfig = map[string]string{"storage.wiredTiger.engineConfig.cacheSizeGB": "2"}
+	assert.Contains(t, BuildReplicaSetConfig(mdb), "cacheSizeGB: 2\n")
+}
+
+func TestShardedResourceProfile(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.ResourceProfile = ResourceProfileSmall
+	mdbsh.Spec.Shards.Resources = testMemoryLimit("4Gi")
+
+	cfg := BuildConfigServerStatefulSet(mdbsh)
+	assert.Equal(t, "1Gi", findContainer(cfg.Spec.Template.Spec.Containers, "mongodb").Resources.Limits.Memory().String())
+	assert.Contains(t, BuildConfigServerConfig(mdbsh), "cacheSizeGB: 0.25")
+
+	shard := BuildShardStatefulSet(mdbsh, 0)
+	assert.Equal(t, "4Gi", findContainer(shard.Spec.Template.Spec.Containers, "mongodb").Resources.Limits.Memory().String())
+	assert.Contains(t, BuildShardConfig(mdbsh, 0), "cacheSizeGB: 1.5")
+
+	mongos := BuildMongosDeployment(mdbsh)
+	assert.Equal(t, "512Mi", findContainer(mongos.Spec.Template.Spec.Containers, "mongos").Resources.Limits.Memory().String())
+	assert.NotContains(t, BuildMongosConfig(mdbsh), "cacheSizeGB")
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// BuildRestoreJob creates a Job restoring an archive written by an S3 backup into the cluster of
+// the backup, connecting with the admin URI of its connection secret. archive is the object key
+// below the prefix of the backup storage. Collections present in the archive are dropped before
+// they are restored.
+func BuildRestoreJob(name string, backup *mongodbv1alpha1.MongoDBBackup, archive string) (*batchv1.Job, error) {
+	if err := checkS3Backup(backup); err != nil {
+		return nil, err
+	}
+	if archive == "" {
+		return nil, fmt.Errorf("the archive to restore is required")
+	}
+	return buildRestoreJob(name, backup, []corev1.EnvVar{{Name: "ARCHIVE", Value: archive}}, buildRestoreScript(backup)), nil
+}
+
+// BuildRestoreChainJob creates a Job restoring an incremental backup. chain starts with the full
+// backup and ends with the backup to restore, each backup the parent of the next. The full backup
+// is restored first, dropping the collections present in its archive, then the oplog of every
+// incremental backup is replayed in order. The backups must share their S3 storage.
+func BuildRestoreChainJob(name string, chain []*mongodbv1alpha1.MongoDBBackup) (*batchv1.Job, error) {
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("the backup chain to restore is empty")
+	}
+	last := chain[len(chain)-1]
+	if err := checkS3Backup(last); err != nil {
+		return nil, err
+	}
+	if chain[0].Spec.Type == mongodbv1alpha1.BackupTypeIncremental {
+		return nil, fmt.Errorf("the backup chain of %s does not start with a full backup", last.Name)
+	}
+	for i, backup := range chain {
+		if backup.Status.Archive == "" {
+			return nil, fmt.Errorf("backup %s does not record its archive", backup.Name)
+		}
+		if !reflect.DeepEqual(backup.Spec.Storage, last.Spec.Storage) {
+			return nil, fmt.Errorf("backup %s is not stored with %s, the chain must share its S3 storage", backup.Name, last.Name)
+		}
+		if i > 0 && (backup.Spec.Type != mongodbv1alpha1.BackupTypeIncremental || backup.Status.ParentBackup != chain[i-1].Name) {
+			return nil, fmt.Errorf("backup %s does not follow %s in the chain", backup.Name, chain[i-1].Name)
+		}
+	}
+	return buildRestoreJob(name, last, nil, buildRestoreChainScript(chain)), nil
+}
+
+// checkS3Backup checks that a backup is stored in S3, the restore Jobs download from there
+func checkS3Backup(backup *mongodbv1alpha1.MongoDBBackup) error {
+	if backup.Spec.Storage.Type != "s3" || backup.Spec.Storage.S3 == nil {
+		return fmt.Errorf("backup %s is not stored in S3, only S3 backups can be restored", backup.Name)
+	}
+	return nil
+}
+
+// buildRestoreJob creates the Job running a restore script against the cluster of the backup,
+// with the S3 storage of the backup
+func buildRestoreJob(name string, backup *mongodbv1alpha1.MongoDBBackup, envVars []corev1.EnvVar, script string) *batchv1.Job {
+	labels := buildLabels(name, "restore")
+	backoff := int32(0)
+	ttl := int32(86400)
+
+	envVars = append(envVars, buildS3EnvVars(backup.Spec.Storage.S3)...)
+	files := append([]credentialFile{{
+		secret: ConnectionSecretName(backup.Spec.ClusterRef.Name),
+		key:    "uri",
+		path:   credentialFileMongoDBURI,
+	}}, s3CredentialFiles(backup.Spec.Storage.S3)...)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: backup.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit:            &backoff,
+			TTLSecondsAfterFinished: &ttl,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:      "restore",
+							Image:     defaultBackupImage(),
+							Command:   []string{"/bin/bash", "-c"},
+							Args:      []string{script},
+							Env:       envVars,
+							Resources: buildBackupResources(),
+						},
+					},
+				},
+			},
+		},
+	}
+	addJobCredentials(&job.Spec.Template.Spec, files)
+	return job
+}
+
+// buildRestoreScript streams the archive from S3 through the decompressor matching its extension
+// into mongorestore
+func buildRestoreScript(backup *mongodbv1alpha1.MongoDBBackup) string {
+	var cases strings.Builder
+	for _, compressionType := range slices.Sorted(maps.Keys(backupCompressors)) {
+		compressor := backupCompressors[compressionType]
+		if compressor.extension == ".gz" {
+			continue
+		}
+		fmt.Fprintf(&cases, "    *.archive%s) DECOMPRESS=%q; PACKAGES=%q ;;\n",
+			compressor.extension, compressor.decompress, strings.Join(compressor.packages, " "))
+	}
+
+	// Earlier backups wrote plain mongodump archives named .archive.gz, with their collections
+	// gzipped by mongodump unless the compression type was zstd
+	legacyFlag := "--gzip"
+	if backup.Spec.CompressionType == "zstd" {
+		legacyFlag = ""
+	}
+
+	return fmt.Sprintf(`
+set -eo pipefail
+%s
+echo "Starting restore: ${S3_PREFIX}${ARCHIVE}"
+
+DECOMPRESS="cat"
+PACKAGES=""
+RESTORE_FLAGS=""
+case "${ARCHIVE}" in
+%s    *.archive.gz) DECOMPRESS="gzip -dc" ;;
+esac
+
+# Install the AWS CLI and the decompressor
+apt-get update && apt-get install -y awscli ${PACKAGES}
+
+s3_credentials
+# Archives of earlier backups are plain mongodump archives named .archive.gz
+if [[ "${ARCHIVE}" == *.archive.gz ]]; then
+    aws s3api get-object --bucket "${S3_BUCKET}" --key "${S3_PREFIX}${ARCHIVE}" --range bytes=0-1 \
+        --endpoint-url="${S3_ENDPOINT}" /tmp/magic > /dev/null
+    if [ "$(od -An -tx1 /tmp/magic | tr -d ' \n')" != "1f8b" ]; then
+        DECOMPRESS="cat"
+        RESTORE_FLAGS="%s"
+    fi
+fi
+
+aws s3 cp "s3://${S3_BUCKET}/${S3_PREFIX}${ARCHIVE}" - --endpoint-url="${S3_ENDPOINT}" | \
+    ${DECOMPRESS} | \
+    mongorestore --config="$(mongo_config uri %s)" --archive ${RESTORE_FLAGS} --drop
+
+echo "Restore completed: ${S3_PREFIX}${ARCHIVE}"
+`, jobCredentialsScript, cases.String(), legacyFlag, credentialFileMongoDBURI)
+}
+
+// buildRestoreChainScript restores the archive of the full backup of a chain with the oplog it
+// holds, then replays the oplog slices of the incremental backups one after the other
+func buildRestoreChainScript(chain []*mongodbv1alpha1.MongoDBBackup) string {
+	packages := []string{"awscli"}
+	var steps strings.Builder
+	for i, backup := range chain {
+		compressor := compressorFor(backup.Spec)
+		for _, pkg := range compressor.packages {
+			if !slices.Contains(packages, pkg) {
+				packages = append(packages, pkg)
+			}
+		}
+
+		if i == 0 {
+			fmt.Fprintf(&steps, `
+echo "Restoring full backup %s"
+fetch %q | %s | \
+    mongorestore --config="$(mongo_config uri %s)" --archive --oplogReplay --drop
+`, backup.Name, backup.Status.Archive, compressor.decompress, credentialFileMongoDBURI)
+			continue
+		}
+		// mongorestore replays the oplog.bson at the top of a dump directory
+		fmt.Fprintf(&steps, `
+echo "Replaying incremental backup %s"
+rm -rf /tmp/replay && mkdir -p /tmp/replay
+fetch %q | %s > /tmp/replay/oplog.bson
+mongorestore --config="$(mongo_config uri %s)" --oplogReplay --dir=/tmp/replay
+`, backup.Name, backup.Status.Archive, compressor.decompress, credentialFileMongoDBURI)
+	}
+
+	last := chain[len(chain)-1]
+	return fmt.Sprintf(`
+set -eo pipefail
+%s
+echo "Starting restore: %s"
+
+# Install the AWS CLI and the decompressors
+apt-get update && apt-get install -y %s
+
+s3_credentials
+# fetch streams an archive from S3 to stdout
+fetch() {
+    aws s3 cp "s3://${S3_BUCKET}/${S3_PREFIX}$1" - --endpoint-url="${S3_ENDPOINT}"
+}
+%s
+echo "Restore completed: %s"
+`, jobCredentialsScript, last.Name, strings.Join(packages, " "), steps.String(), last.Name)
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testS3Backup() *mongodbv1alpha1.MongoDBBackup {
+	return &mongodbv1alpha1.MongoDBBackup{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBBackupSpec{
+			ClusterRef: mongodbv1alpha1.ClusterReference{Name: "my-mongodb"},
+			Storage: mongodbv1alpha1.BackupStorageSpec{
+				Type: "s3",
+				S3: &mongodbv1alpha1.S3StorageSpec{
+					Bucket:         "backups",
+					Prefix:         "mongodb/",
+					CredentialsRef: corev1.LocalObjectReference{Name: "s3-credentials"},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildRestoreJob(t *testing.T) {
+	backup := testS3Backup()
+
+	job, err := BuildRestoreJob("nightly-restore", backup, "my-mongodb-20241001.archive.gz")
+	require.NoError(t, err)
+	assert.Equal(t, "nightly-restore", job.Name)
+	assert.Equal(t, "default", job.Namespace)
+	assert.Equal(t, "restore", job.Labels["app.kubernetes.io/component"])
+
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "mongo:8.2", container.Image)
+	assert.Contains(t, container.Args[0], `mongorestore --config="$(mongo_config uri mongodb-uri)" --archive ${RESTORE_FLAGS} --drop`)
+	assert.Contains(t, container.Args[0], `*.archive.zst) DECOMPRESS="zstd -dc"; PACKAGES="zstd" ;;`)
+	assert.Contains(t, container.Args[0], `*.archive.sz) DECOMPRESS="snzip -dc"; PACKAGES="snzip" ;;`)
+	assert.Contains(t, container.Args[0], `RESTORE_FLAGS="--gzip"`, "legacy archives gzipped by mongodump")
+
+	env := make(map[string]corev1.EnvVar)
+	for _, e := range container.Env {
+		env[e.Name] = e
+	}
+	assert.Equal(t, "my-mongodb-20241001.archive.gz", env["ARCHIVE"].Value)
+	assert.Equal(t, "backups", env["S3_BUCKET"].Value)
+	assert.Equal(t, "mongodb/", env["S3_PREFIX"].Value)
+	assert.NotContains(t, env, "MONGODB_URI")
+	assert.NotContains(t, env, "AWS_ACCESS_KEY_ID")
+
+	// The URI and the S3 keys are mounted from their secrets
+	volumes := job.Spec.Template.Spec.Volumes
+	require.Len(t, volumes, 1)
+	require.NotNil(t, volumes[0].Projected)
+	sources := volumes[0].Projected.Sources
+	require.Len(t, sources, 3)
+	assert.Equal(t, "my-mongodb-connection", sources[0].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "uri", Path: "mongodb-uri"}}, sources[0].Secret.Items)
+	assert.Equal(t, "s3-credentials", sources[1].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "access-key", Path: "s3-access-key"}}, sources[1].Secret.Items)
+	assert.Equal(t, []corev1.VolumeMount{{Name: "credentials", MountPath: JobCredentialsMountPath, ReadOnly: true}}, container.VolumeMounts)
+
+	// Legacy archives of zstd backups were written without compression
+	backup.Spec.CompressionType = "zstd"
+	job, err = BuildRestoreJob("nightly-restore", backup, "my-mongodb-20241001.archive.gz")
+	require.NoError(t, err)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Args[0], `RESTORE_FLAGS=""`)
+	assert.NotContains(t, job.Spec.Template.Spec.Containers[0].Args[0], `RESTORE_FLAGS="--gzip"`)
+}
+
+func TestBuildRestoreJobRequiresS3(t *testing.T) {
+	_, err := BuildRestoreJob("nightly-restore", testS3Backup(), "")
+	assert.EqualError(t, err, "the archive to restore is required")
+
+	backup := testS3Backup()
+	backup.Spec.Storage = mongodbv1alpha1.BackupStorageSpec{Type: "pvc"}
+	_, err = BuildRestoreJob("nightly-restore", backup, "archive.gz")
+	assert.EqualError(t, err, "backup nightly is not stored in S3, only S3 backups can be restored")
+}
+
+func testBackupChain() []*mongodbv1alpha1.MongoDBBackup {
+	full := testS3Backup()
+	full.Name = "nightly"
+	full.Spec.Compression = true
+	full.Status.Archive = "my-mongodb-20240101-000000.archive.zst"
+
+	hourly := testS3Backup()
+	hourly.Name = "hourly-1"
+	hourly.Spec.Type = mongodbv1alpha1.BackupTypeIncremental
+	hourly.Spec.Compression = true
+	hourly.Spec.CompressionType = "snappy"
+	hourly.Status.ParentBackup = "nightly"
+	hourly.Status.Archive = "my-mongodb-20240101-010000.oplog.bson.sz"
+
+	last := hourly.DeepCopy()
+	last.Name = "hourly-2"
+	last.Status.ParentBackup = "hourly-1"
+	last.Status.Archive = "my-mongodb-20240101-020000.oplog.bson.sz"
+	return []*mongodbv1alpha1.MongoDBBackup{full, hourly, last}
+}
+
+func TestBuildRestoreChainJob(t *testing.T) {
+	job, err := BuildRestoreChainJob("hourly-2-restore", testBackupChain())
+	require.NoError(t, err)
+	assert.Equal(t, "hourly-2-restore", job.Name)
+	assert.Equal(t, "restore", job.Labels["app.kubernetes.io/component"])
+
+	script := job.Spec.Template.Spec.Containers[0].Args[0]
+	assert.Contains(t, script, "apt-get install -y awscli zstd snzip\n")
+	assert.Contains(t, script, `fetch "my-mongodb-20240101-000000.archive.zst" | zstd -dc | \
+    mongorestore --config="$(mongo_config uri mongodb-uri)" --archive --oplogReplay --drop`)
+	assert.Contains(t, script, `fetch "my-mongodb-20240101-010000.oplog.bson.sz" | snzip -dc > /tmp/replay/oplog.bson`)
+	assert.Contains(t, script, `mongorestore --config="$(mongo_config uri mongodb-uri)" --oplogReplay --dir=/tmp/replay`)
+	assert.Less(t, strings.Index(script, "20240101-010000"), strings.Index(script, "20240101-020000"), "the chain is replayed in order")
+
+	env := make(map[string]corev1.EnvVar)
+	for _, e := range job.Spec.Template.Spec.Containers[0].Env {
+		env[e.Name] = e
+	}
+	assert.Equal(t, "backups", env["S3_BUCKET"].Value)
+	assert.NotContains(t, env, "ARCHIVE")
+}
+
+func TestBuildRestoreChainJobValidation(t *testing.T) {
+	chain := testBackupChain()
+	_, err := BuildRestoreChainJob("restore", chain[1:])
+	assert.EqualError(t, err, "the backup chain of hourly-2 does not start with a full backup")
+
+	chain = testBackupChain()
+	chain[2].Status.ParentBackup = "nightly"
+	_, err = BuildRestoreChainJob("restore", chain)
+	assert.EqualError(t, err, "backup hourly-2 does not follow hourly-1 in the chain")
+
+	chain = testBackupChain()
+	chain[1].Status.Archive = ""
+	_, err = BuildRestoreChainJob("restore", chain)
+	assert.EqualError(t, err, "backup hourly-1 does not record its archive")
+
+	chain = testBackupChain()
+	chain[0].Spec.Storage.S3.Bucket = "other"
+	_, err = BuildRestoreChainJob("restore", chain)
+	assert.EqualError(t, err, "backup nightly is not stored with hourly-2, the chain must share its S3 storage")
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// ResyncCondition reports whether a member is resyncing from an empty dbPath
+	ResyncCondition = "MemberResync"
+
+	// CrashLoopBackOff is the stale member reason of a member whose mongod keeps exiting on a
+	// corrupt or incompatible dbPath
+	CrashLoopBackOff = "CrashLoopBackOff"
+
+	defaultStaleAfterSeconds = 900
+)
+
+// dataFileExitCodes are the exit codes of mongod refusing its dbPath: EXIT_NEED_UPGRADE,
+// EXIT_POSSIBLE_CORRUPTION and EXIT_NEED_DOWNGRADE
+var dataFileExitCodes = []int32{4, 60, 62}
+
+// dataFileErrors are the log messages of mongod stopping on corrupt data files, found in the
+// termination message the kubelet fills with the end of the log
+var dataFileErrors = []string{"WT_PANIC", "WT_TRY_SALVAGE", "DataCorruptionDetected", "UPGRADE PROBLEM"}
+
+// staleMemberStates are the replica set states a member does not leave without an initial sync
+// once it fell off the oplog of the other members
+var staleMemberStates = []string{"RECOVERING", "ROLLBACK"}
+
+// StaleMemberState reports whether a member in the given replica set state is stuck
+func StaleMemberState(state string) bool {
+	return slices.Contains(staleMemberStates, state)
+}
+
+// AutoResyncEnabled reports whether the data of stale members is wiped automatically
+func AutoResyncEnabled(spec *mongodbv1alpha1.AutoResyncSpec) bool {
+	return spec != nil && spec.Enabled
+}
+
+// StaleAfter returns how long a member must be stuck before it is resynced
+func StaleAfter(spec *mongodbv1alpha1.AutoResyncSpec) time.Duration {
+	if spec != nil && spec.StaleAfterSeconds > 0 {
+		return time.Duration(spec.StaleAfterSeconds) * time.Second
+	}
+	return defaultStaleAfterSeconds * time.Second
+}
+
+// CrashLooping reports whether the given container of the pod is backing off after repeated crashes
+func CrashLooping(pod *corev1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container && status.State.Waiting != nil {
+			return status.State.Waiting.Reason == CrashLoopBackOff
+		}
+	}
+	return false
+}
+
+// DataFilesUnusable reports whether the last exit of the given container of the pod shows that
+// mongod can not use its dbPath: an exit code of mongod refusing its data files, or a corruption
+// error at the end of its log. Crashes on a bad configuration or image do not match, wiping the
+// data would not help them.
+func DataFilesUnusable(pod *corev1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != container || status.LastTerminationState.Terminated == nil {
+			continue
+		}
+		terminated := status.LastTerminationState.Terminated
+		if slices.Contains(dataFileExitCodes, terminated.ExitCode) {
+			return true
+		}
+		for _, message := range dataFileErrors {
+			if strings.Contains(terminated.Message, message) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// UpdateStaleMembers merges the members found stuck in this reconcile, pod name to reason, into the
+// stale members of the status. Members keep the time they were first seen stuck. A member that
+// recovered by itself is dropped, a member being resynced is kept until it is healthy again, as it
+// runs an initial sync in between. It returns the stale members, sorted by name, and the members
+// whose resync completed.
+func UpdateStaleMembers(current []mongodbv1alpha1.StaleMemberStatus, stuck map[string]string, healthy map[string]bool, now metav1.Time) ([]mongodbv1alpha1.StaleMemberStatus, []string) {
+	var stale []mongodbv1alpha1.StaleMemberStatus
+	var resynced []string
+	known := map[string]bool{}
+
+	for _, m := range current {
+		known[m.Name] = true
+		reason, isStuck := stuck[m.Name]
+		switch {
+		case m.ResyncStartedAt != nil && healthy[m.Name]:
+			resynced = append(resynced, m.Name)
+		case m.ResyncStartedAt != nil:
+			stale = append(stale, m)
+		case isStuck:
+			m.Reason = reason
+			stale = append(stale, m)
+		}
+	}
+
+	for name, reason := range stuck {
+		if !known[name] {
+			stale = append(stale, mongodbv1alpha1.StaleMemberStatus{Name: name, Reason: reason, Since: now})
+		}
+	}
+
+	slices.SortFunc(stale, func(a, b mongodbv1alpha1.StaleMemberStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return stale, resynced
+}
+
+// NextResync returns the stale member to resync, the first one stuck for longer than staleAfter.
+// Members are resynced one at a time so the replica set never loses more than one member to an
+// initial sync; it returns "" while a resync is in progress.
+func NextResync(stale []mongodbv1alpha1.StaleMemberStatus, staleAfter time.Duration, now time.Time) string {
+	for _, m := range stale {
+		if m.ResyncStartedAt != nil {
+			return ""
+		}
+	}
+	for _, m := range stale {
+		if now.Sub(m.Since.Time) >= staleAfter {
+			return m.Name
+		}
+	}
+	return ""
+}
+
+// BuildResyncCondition builds the MemberResync condition from the stale members of the status
+func BuildResyncCondition(stale []mongodbv1alpha1.StaleMemberStatus, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ResyncCondition,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: generation,
+		Reason:             "NoStaleMembers",
+		Message:            "No member is stuck or resyncing",
+	}
+
+	var resyncing, stuck []string
+	for _, m := range stale {
+		if m.ResyncStartedAt != nil {
+			resyncing = append(resyncing, m.Name)
+		} else {
+			stuck = append(stuck, fmt.Sprintf("%s (%s)", m.Name, m.Reason))
+		}
+	}
+
+	switch {
+	case len(resyncing) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ResyncInProgress"
+		condition.Message = fmt.Sprintf("Members are running an initial sync from an empty dbPath: %s", strings.Join(resyncing, ", "))
+	case len(stuck) > 0:
+		condition.Reason = "StaleMembers"
+		condition.Message = fmt.Sprintf("Members are stuck: %s", strings.Join(stuck, ", "))
+	}
+
+	return condition
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestCrashLooping(t *testing.T) {
+	pod := &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+		{Name: "mongodb-exporter", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+		{Name: "mongodb", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: CrashLoopBackOff}}},
+	}}}
+
+	assert.True(t, CrashLooping(pod, "mongodb"))
+	assert.False(t, CrashLooping(pod, "mongodb-exporter"))
+	assert.False(t, CrashLooping(&corev1.Pod{}, "mongodb"))
+}
+
+func TestDataFilesUnusable(t *testing.T) {
+	terminated := func(exitCode int32, message string) *corev1.Pod {
+		return &corev1.Pod{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:                 "mongodb",
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: CrashLoopBackOff}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: exitCode, Message: message}},
+		}}}}
+	}
+
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		unusable bool
+	}{
+		{"never terminated", &corev1.Pod{}, false},
+		{"possible corruption", terminated(60, ""), true},
+		{"needs downgrade", terminated(62, ""), true},
+		{"WiredTiger panic", terminated(134, `{"msg":"WiredTiger error message","attr":{"error":-31804,"message":"WT_PANIC"}}`), true},
+		{"bad options", terminated(2, "Unrecognized option: storage.foo"), false},
+		{"image without mongod", terminated(127, "exec: mongod: not found"), false},
+		{"abort without corruption", terminated(134, "Fatal assertion"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.unusable, DataFilesUnusable(tt.pod, "mongodb"))
+			assert.False(t, DataFilesUnusable(tt.pod, "exporter"))
+		})
+	}
+}
+
+func TestUpdateStaleMembers(t *testing.T) {
+	before := metav1.NewTime(time.Now().Add(-time.Hour))
+	now := metav1.Now()
+	current := []mongodbv1alpha1.StaleMemberStatus{
+		{Name: "my-mongodb-1", Reason: "RECOVERING", Since: before},
+		{Name: "my-mongodb-2", Reason: "ROLLBACK", Since: before, ResyncStartedAt: &before},
+		{Name: "my-mongodb-3", Reason: "RECOVERING", Since: before},
+	}
+
+	stale, resynced := UpdateStaleMembers(current,
+		map[string]string{"my-mongodb-0": CrashLoopBackOff, "my-mongodb-1": "ROLLBACK"},
+		map[string]bool{"my-mongodb-3": true}, now)
+
+	require.Len(t, stale, 3)
+	assert.Equal(t, mongodbv1alpha1.StaleMemberStatus{Name: "my-mongodb-0", Reason: CrashLoopBackOff, Since: now}, stale[0])
+	assert.Equal(t, "ROLLBACK", stale[1].Reason)
+	assert.Equal(t, before, stale[1].Since)
+	// Still running its initial sync
+	assert.Equal(t, "my-mongodb-2", stale[2].Name)
+	assert.Empty(t, resynced)
+
+	stale, resynced = UpdateStaleMembers(stale, nil, map[string]bool{"my-mongodb-2": true}, now)
+	assert.Empty(t, stale)
+	assert.Equal(t, []string{"my-mongodb-2"}, resynced)
+}
+
+func TestNextResync(t *testing.T) {
+	now := time.Now()
+	stale := []mongodbv1alpha1.StaleMemberStatus{
+		{Name: "my-mongodb-0", Since: metav1.NewTime(now.Add(-time.Minute))},
+		{Name: "my-mongodb-1", Since: metav1.NewTime(now.Add(-time.Hour))},
+	}
+
+	assert.Equal(t, "my-mongodb-1", NextResync(stale, StaleAfter(nil), now))
+	assert.Equal(t, "my-mongodb-0", NextResync(stale, StaleAfter(&mongodbv1alpha1.AutoResyncSpec{StaleAfterSeconds: 60}), now))
+
+	started := metav1.NewTime(now)
+	stale[0].ResyncStartedAt = &started
+	assert.Empty(t, NextResync(stale, StaleAfter(nil), now))
+}
+
+func TestBuildResyncCondition(t *testing.T) {
+	condition := BuildResyncCondition(nil, 2)
+	assert.Equal(t, ResyncCondition, condition.Type)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+
+	stale := []mongodbv1alpha1.StaleMemberStatus{{Name: "my-mongodb-1", Reason: "RECOVERING"}}
+	condition = BuildResyncCondition(stale, 2)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "StaleMembers", condition.Reason)
+	assert.Contains(t, condition.Message, "my-mongodb-1 (RECOVERING)")
+
+	started := metav1.Now()
+	stale[0].ResyncStartedAt = &started
+	condition = BuildResyncCondition(stale, 2)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "ResyncInProgress", condition.Reason)
+}
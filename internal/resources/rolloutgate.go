@@ -0,0 +1,143 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// MembersUpdatingCondition reports the config server and shard members a health-gated rollout
+	// waits for
+	MembersUpdatingCondition = "MembersUpdating"
+
+	defaultMaxRolloutLagSeconds = 10
+)
+
+// MemberHealthGate reports whether the config server and shard rollouts wait for the member
+// updated last to be a healthy member of its replica set
+func MemberHealthGate(mdbsh *mongodbv1alpha1.MongoDBSharded) bool {
+	return mdbsh.Spec.UpdateStrategy != nil && mdbsh.Spec.UpdateStrategy.MemberHealthGate
+}
+
+// MaxRolloutLag returns the replication lag under which an updated secondary counts as caught up
+func MaxRolloutLag(spec *mongodbv1alpha1.ShardedUpdateStrategySpec) time.Duration {
+	if spec == nil || spec.MaxLagSeconds == nil {
+		return defaultMaxRolloutLagSeconds * time.Second
+	}
+	return time.Duration(*spec.MaxLagSeconds) * time.Second
+}
+
+// RolloutInProgress reports whether some pods of a StatefulSet do not run its latest revision yet
+func RolloutInProgress(sts *appsv1.StatefulSet) bool {
+	return sts.Status.UpdateRevision != "" && sts.Status.UpdateRevision != sts.Status.CurrentRevision
+}
+
+// partition returns the partition of a StatefulSet, nil when it rolls out every pod
+func partition(sts *appsv1.StatefulSet) *int32 {
+	if sts.Spec.UpdateStrategy.RollingUpdate == nil {
+		return nil
+	}
+	return sts.Spec.UpdateStrategy.RollingUpdate.Partition
+}
+
+// ApplyGatedPartition sets the partition of a config server or shard StatefulSet about to be
+// written so the StatefulSet controller updates one member at a time, and only once the members
+// above it run the latest revision and are caught up. Without a rollout the partition stays at
+// the highest ordinal, so the next template change only reaches that member. revisions holds the
+// revision of every pod and caughtUp the pods of the replica set that are caught up, nil when the
+// replica set could not be asked. A rollout never moves backwards.
+func ApplyGatedPartition(sts, existing *appsv1.StatefulSet, revisions map[string]string, caughtUp map[string]bool) {
+	if existing == nil || sts.Spec.Replicas == nil {
+		return
+	}
+	members := *sts.Spec.Replicas
+	current := partition(existing)
+
+	var next int32
+	switch {
+	case existing.Status.ObservedGeneration != existing.Generation || (RolloutInProgress(existing) && caughtUp == nil):
+		// The StatefulSet controller has not seen the last change or the members are unknown
+		if current == nil {
+			return
+		}
+		next = *current
+	case !RolloutInProgress(existing):
+		next = max(members-1, 0)
+	default:
+		// The members from the highest ordinal down that are updated and caught up
+		healthy := members
+		for ordinal := members - 1; ordinal >= 0; ordinal-- {
+			pod := fmt.Sprintf("%s-%d", existing.Name, ordinal)
+			if revisions[pod] != existing.Status.UpdateRevision || !caughtUp[pod] {
+				break
+			}
+			healthy = ordinal
+		}
+		next = max(healthy-1, 0)
+		if current != nil && *current < next {
+			next = *current
+		}
+	}
+
+	sts.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	if sts.Spec.UpdateStrategy.RollingUpdate == nil {
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+	}
+	sts.Spec.UpdateStrategy.RollingUpdate.Partition = int32Ptr(next)
+}
+
+// WaitingMember returns the member a health-gated rollout of a StatefulSet waits for, "" without
+// a rollout
+func WaitingMember(sts *appsv1.StatefulSet) string {
+	if !RolloutInProgress(sts) {
+		return ""
+	}
+	ordinal := int32(0)
+	if p := partition(sts); p != nil {
+		ordinal = *p
+	}
+	return fmt.Sprintf("%s-%d", sts.Name, ordinal)
+}
+
+// BuildMembersUpdatingCondition builds the MembersUpdating condition from the members the
+// health-gated rollouts wait for
+func BuildMembersUpdatingCondition(waiting []string, generation int64) metav1.Condition {
+	if len(waiting) == 0 {
+		return metav1.Condition{
+			Type:               MembersUpdatingCondition,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "Updated",
+			Message:            "Every config server and shard member runs the latest revision",
+		}
+	}
+	return metav1.Condition{
+		Type:               MembersUpdatingCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "WaitingForMember",
+		Message:            fmt.Sprintf("Waiting for %s to be updated and caught up", strings.Join(waiting, ", ")),
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testGatedStatefulSet(partition *int32, current, update string) *appsv1.StatefulSet {
+	sts := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sharded-shard-0", Generation: 2},
+		Spec:       appsv1.StatefulSetSpec{Replicas: int32Ptr(3)},
+		Status: appsv1.StatefulSetStatus{
+			ObservedGeneration: 2,
+			CurrentRevision:    current,
+			UpdateRevision:     update,
+		},
+	}
+	if partition != nil {
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{Partition: partition}
+	}
+	return sts
+}
+
+func TestMemberHealthGate(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	assert.False(t, MemberHealthGate(mdbsh))
+	assert.Equal(t, 10*time.Second, MaxRolloutLag(mdbsh.Spec.UpdateStrategy))
+
+	mdbsh.Spec.UpdateStrategy = &mongodbv1alpha1.ShardedUpdateStrategySpec{MemberHealthGate: true, MaxLagSeconds: int32Ptr(30)}
+	assert.True(t, MemberHealthGate(mdbsh))
+	assert.Equal(t, 30*time.Second, MaxRolloutLag(mdbsh.Spec.UpdateStrategy))
+}
+
+func TestApplyGatedPartition(t *testing.T) {
+	updated := map[string]string{"my-sharded-shard-0-0": "old", "my-sharded-shard-0-1": "new", "my-sharded-shard-0-2": "new"}
+	caughtUp := map[string]bool{"my-sharded-shard-0-0": true, "my-sharded-shard-0-1": true, "my-sharded-shard-0-2": true}
+
+	tests := []struct {
+		name      string
+		existing  *appsv1.StatefulSet
+		revisions map[string]string
+		caughtUp  map[string]bool
+		want      *int32
+	}{
+		{"not created", nil, nil, nil, nil},
+		{"no rollout", testGatedStatefulSet(nil, "new", "new"), nil, nil, int32Ptr(2)},
+		{"rollout started", testGatedStatefulSet(int32Ptr(2), "old", "new"), map[string]string{}, caughtUp, int32Ptr(2)},
+		{"updated members caught up", testGatedStatefulSet(int32Ptr(1), "old", "new"), updated, caughtUp, int32Ptr(0)},
+		{"updated member catching up", testGatedStatefulSet(int32Ptr(1), "old", "new"), updated,
+			map[string]bool{"my-sharded-shard-0-0": true, "my-sharded-shard-0-2": true}, int32Ptr(1)},
+		{"never moves backwards", testGatedStatefulSet(int32Ptr(0), "old", "new"), updated, map[string]bool{}, int32Ptr(0)},
+		{"replica set unknown", testGatedStatefulSet(int32Ptr(1), "old", "new"), updated, nil, int32Ptr(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sts := BuildShardStatefulSet(testMongoDBSharded(), 0)
+			ApplyGatedPartition(sts, tt.existing, tt.revisions, tt.caughtUp)
+			if tt.want == nil {
+				assert.Nil(t, sts.Spec.UpdateStrategy.RollingUpdate)
+				return
+			}
+			require.NotNil(t, sts.Spec.UpdateStrategy.RollingUpdate)
+			assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, sts.Spec.UpdateStrategy.Type)
+			assert.Equal(t, *tt.want, *sts.Spec.UpdateStrategy.RollingUpdate.Partition)
+		})
+	}
+
+	// The partition is kept until the StatefulSet controller saw the last change
+	existing := testGatedStatefulSet(int32Ptr(1), "old", "new")
+	existing.Generation = 3
+	sts := BuildShardStatefulSet(testMongoDBSharded(), 0)
+	ApplyGatedPartition(sts, existing, updated, caughtUp)
+	assert.Equal(t, int32(1), *sts.Spec.UpdateStrategy.RollingUpdate.Partition)
+}
+
+func TestWaitingMember(t *testing.T) {
+	assert.Empty(t, WaitingMember(testGatedStatefulSet(int32Ptr(2), "new", "new")))
+	assert.Equal(t, "my-sharded-shard-0-1", WaitingMember(testGatedStatefulSet(int32Ptr(1), "old", "new")))
+}
+
+func TestBuildMembersUpdatingCondition(t *testing.T) {
+	condition := BuildMembersUpdatingCondition(nil, 2)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, int64(2), condition.ObservedGeneration)
+
+	condition = BuildMembersUpdatingCondition([]string{"my-sharded-cfg-2", "my-sharded-shard-1-0"}, 3)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "WaitingForMember", condition.Reason)
+	assert.Contains(t, condition.Message, "my-sharded-cfg-2, my-sharded-shard-1-0")
+}
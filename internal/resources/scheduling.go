@@ -0,0 +1,230 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// AntiAffinityPreferred avoids scheduling members of a replica set on the same node
+	AntiAffinityPreferred = "Preferred"
+	// AntiAffinityRequired refuses to schedule members of a replica set on the same node
+	AntiAffinityRequired = "Required"
+)
+
+// CPU architectures of spec.architecture
+const (
+	ArchitectureAMD64 = "amd64"
+	ArchitectureARM64 = "arm64"
+)
+
+const (
+	hostnameTopologyKey = "kubernetes.io/hostname"
+	zoneTopologyKey     = "topology.kubernetes.io/zone"
+	archLabel           = "kubernetes.io/arch"
+)
+
+// imageArchitectures are the architectures the official mongo images are built for
+var imageArchitectures = []string{ArchitectureAMD64, ArchitectureARM64}
+
+// applyMemberSpreading spreads the pods matching selector, the members of one replica set,
+// across nodes and zones. The default preferred anti-affinity covers the whole cluster, the
+// required one only the replica set so sharded clusters do not need a node per pod.
+func applyMemberSpreading(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec, selector map[string]string) {
+	if pod == nil {
+		return
+	}
+
+	if pod.AntiAffinityMode == AntiAffinityRequired {
+		if podSpec.Affinity == nil {
+			podSpec.Affinity = &corev1.Affinity{}
+		}
+		if podSpec.Affinity.PodAntiAffinity == nil {
+			podSpec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		antiAffinity := podSpec.Affinity.PodAntiAffinity
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution,
+			corev1.PodAffinityTerm{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: maps.Clone(selector)},
+				TopologyKey:   hostnameTopologyKey,
+			})
+	}
+
+	if pod.ZoneSpread != nil && pod.ZoneSpread.Enabled {
+		maxSkew := pod.ZoneSpread.MaxSkew
+		if maxSkew < 1 {
+			maxSkew = 1
+		}
+		whenUnsatisfiable := corev1.DoNotSchedule
+		if pod.ZoneSpread.WhenUnsatisfiable != "" {
+			whenUnsatisfiable = corev1.UnsatisfiableConstraintAction(pod.ZoneSpread.WhenUnsatisfiable)
+		}
+		podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           maxSkew,
+			TopologyKey:       zoneTopologyKey,
+			WhenUnsatisfiable: whenUnsatisfiable,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: maps.Clone(selector)},
+		})
+	}
+}
+
+// applyShardIsolation keeps a config server or shard member off the nodes of the members of the
+// other shards, and of the config servers when they are included. The pods to avoid are selected
+// by excluding the components of the cluster that are not isolated, so adding shards does not
+// change the template of the existing ones.
+func applyShardIsolation(podSpec *corev1.PodSpec, isolation *mongodbv1alpha1.ShardIsolationSpec, clusterName, component string) {
+	if isolation == nil {
+		return
+	}
+	excluded := []string{component, "mongos"}
+	if component == "configsvr" {
+		if !isolation.ConfigServers {
+			return
+		}
+	} else if !isolation.ConfigServers {
+		excluded = append(excluded, "configsvr")
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{"app.kubernetes.io/instance": clusterName},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "app.kubernetes.io/component", Operator: metav1.LabelSelectorOpNotIn, Values: excluded},
+			},
+		},
+		TopologyKey: hostnameTopologyKey,
+	}
+
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.PodAntiAffinity == nil {
+		podSpec.Affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+	antiAffinity := podSpec.Affinity.PodAntiAffinity
+	if isolation.Mode == AntiAffinityRequired {
+		antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+		return
+	}
+	antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(antiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+		corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+}
+
+// applyPodScheduling applies the security contexts and scheduling constraints of the pod spec.
+// The security contexts replace the operator defaults, the container one only applies to the
+// database container. Each part of the affinity replaces the matching default part, so setting
+// a node affinity keeps the default pod anti-affinity.
+func applyPodScheduling(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec, container string) {
+	if pod == nil {
+		return
+	}
+
+	if pod.SecurityContext != nil {
+		podSpec.SecurityContext = pod.SecurityContext
+	}
+	if pod.ContainerSecurityContext != nil {
+		for i := range podSpec.Containers {
+			if podSpec.Containers[i].Name == container {
+				podSpec.Containers[i].SecurityContext = pod.ContainerSecurityContext
+			}
+		}
+	}
+
+	mergeAffinity(podSpec, pod.Affinity)
+	podSpec.NodeSelector = mergeStringMaps(podSpec.NodeSelector, pod.NodeSelector)
+	podSpec.Tolerations = append(podSpec.Tolerations, pod.Tolerations...)
+	podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, pod.TopologySpreadConstraints...)
+
+	if pod.PriorityClassName != "" {
+		podSpec.PriorityClassName = pod.PriorityClassName
+	}
+}
+
+// applyArchitecture requires the nodes to run one of the architectures the image is built for,
+// or the one set in architecture. Every required node selector term gets the requirement unless
+// it already selects an architecture. A node selector on the arch label takes precedence.
+func applyArchitecture(podSpec *corev1.PodSpec, architecture string) {
+	if _, ok := podSpec.NodeSelector[archLabel]; ok {
+		return
+	}
+
+	archs := imageArchitectures
+	if architecture != "" {
+		archs = []string{architecture}
+	}
+	requirement := corev1.NodeSelectorRequirement{Key: archLabel, Operator: corev1.NodeSelectorOpIn, Values: archs}
+
+	// The node affinity may be the one of the custom resource, copy it before changing it
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	} else {
+		podSpec.Affinity = podSpec.Affinity.DeepCopy()
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	required := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(required.NodeSelectorTerms) == 0 {
+		required.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	for i := range required.NodeSelectorTerms {
+		term := &required.NodeSelectorTerms[i]
+		if !selectsArchitecture(term.MatchExpressions) {
+			term.MatchExpressions = append(term.MatchExpressions, requirement)
+		}
+	}
+}
+
+func selectsArchitecture(requirements []corev1.NodeSelectorRequirement) bool {
+	for _, r := range requirements {
+		if r.Key == archLabel {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeAffinity replaces the node affinity, pod affinity and pod anti-affinity of the pod
+// with the ones set in affinity
+func mergeAffinity(podSpec *corev1.PodSpec, affinity *corev1.Affinity) {
+	if affinity == nil {
+		return
+	}
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if affinity.NodeAffinity != nil {
+		podSpec.Affinity.NodeAffinity = affinity.NodeAffinity
+	}
+	if affinity.PodAffinity != nil {
+		podSpec.Affinity.PodAffinity = affinity.PodAffinity
+	}
+	if affinity.PodAntiAffinity != nil {
+		podSpec.Affinity.PodAntiAffinity = affinity.PodAntiAffinity
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testSchedulingPodSpec() *mongodbv1alpha1.PodSpec {
+	return &mongodbv1alpha1.PodSpec{
+		SecurityContext:          &corev1.PodSecurityContext{RunAsUser: int64Ptr(1001)},
+		ContainerSecurityContext: &corev1.SecurityContext{RunAsUser: int64Ptr(1001)},
+		Affinity: &corev1.Affinity{
+			NodeAffinity: &corev1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "node-role", Operator: corev1.NodeSelectorOpIn, Values: []string{"database"}},
+						},
+					}},
+				},
+			},
+		},
+		Tolerations:        []corev1.Toleration{{Key: "dedicated", Value: "database", Effect: corev1.TaintEffectNoSchedule}},
+		NodeSelector:       map[string]string{"disktype": "ssd"},
+		PriorityClassName:  "database-critical",
+		ServiceAccountName: "mongodb",
+		TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+			{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.ScheduleAnyway},
+		},
+	}
+}
+
+func assertScheduling(t *testing.T, pod corev1.PodSpec, container string) {
+	t.Helper()
+
+	assert.Equal(t, int64(1001), *pod.SecurityContext.RunAsUser)
+	for _, c := range pod.Containers {
+		if c.Name == container {
+			assert.Equal(t, int64(1001), *c.SecurityContext.RunAsUser)
+		}
+	}
+	require.NotNil(t, pod.Affinity)
+	assert.NotNil(t, pod.Affinity.NodeAffinity)
+	assert.NotNil(t, pod.Affinity.PodAntiAffinity, "default anti-affinity is kept")
+	assert.Equal(t, "ssd", pod.NodeSelector["disktype"])
+	assert.Len(t, pod.Tolerations, 1)
+	assert.Equal(t, "database-critical", pod.PriorityClassName)
+	assert.Equal(t, "mongodb", pod.ServiceAccountName)
+	assert.Len(t, pod.TopologySpreadConstraints, 1)
+}
+
+func TestApplyPodSchedulingReplicaSet(t *testing.T) {
+	mdb := testMongoDB()
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	assert.Empty(t, pod.NodeSelector)
+	assert.Empty(t, pod.PriorityClassName)
+
+	mdb.Spec.Pod = testSchedulingPodSpec()
+	assertScheduling(t, BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec, "mongodb")
+}
+
+func TestApplyPodSchedulingSharded(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.ConfigServer.Pod = testSchedulingPodSpec()
+	mdbsh.Spec.Shards.Pod = testSchedulingPodSpec()
+	mdbsh.Spec.Mongos.Pod = testSchedulingPodSpec()
+
+	assertScheduling(t, BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec, "mongodb")
+	assertScheduling(t, BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec, "mongodb")
+	assertScheduling(t, BuildMongosDeployment(mdbsh).Spec.Template.Spec, "mongos")
+}
+
+func TestApplyPodSchedulingShardPlacement(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards.Pod = testSchedulingPodSpec()
+	mdbsh.Spec.Shards.Placement = []mongodbv1alpha1.ShardPlacement{
+		{Shard: 1, NodeSelector: map[string]string{"disktype": "nvme"}},
+	}
+
+	assert.Equal(t, "ssd", BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.NodeSelector["disktype"])
+	assert.Equal(t, "nvme", BuildShardStatefulSet(mdbsh, 1).Spec.Template.Spec.NodeSelector["disktype"],
+		"per-shard placement wins")
+}
+
+func TestApplyMemberSpreading(t *testing.T) {
+	mdb := testMongoDB()
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	assert.Empty(t, pod.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	assert.Empty(t, pod.TopologySpreadConstraints)
+
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{
+		AntiAffinityMode: AntiAffinityRequired,
+		ZoneSpread:       &mongodbv1alpha1.ZoneSpreadSpec{Enabled: true},
+	}
+	sts := BuildReplicaSetStatefulSet(mdb)
+	pod = sts.Spec.Template.Spec
+
+	required := pod.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, required, 1)
+	assert.Equal(t, "kubernetes.io/hostname", required[0].TopologyKey)
+	assert.Equal(t, sts.Spec.Selector.MatchLabels, required[0].LabelSelector.MatchLabels)
+	assert.NotEmpty(t, pod.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution)
+
+	require.Len(t, pod.TopologySpreadConstraints, 1)
+	spread := pod.TopologySpreadConstraints[0]
+	assert.Equal(t, "topology.kubernetes.io/zone", spread.TopologyKey)
+	assert.Equal(t, int32(1), spread.MaxSkew)
+	assert.Equal(t, corev1.DoNotSchedule, spread.WhenUnsatisfiable)
+	assert.Equal(t, sts.Spec.Selector.MatchLabels, spread.LabelSelector.MatchLabels)
+
+	mdb.Spec.Pod.ZoneSpread.WhenUnsatisfiable = string(corev1.ScheduleAnyway)
+	mdb.Spec.Pod.ZoneSpread.MaxSkew = 2
+	spread = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.TopologySpreadConstraints[0]
+	assert.Equal(t, corev1.ScheduleAnyway, spread.WhenUnsatisfiable)
+	assert.Equal(t, int32(2), spread.MaxSkew)
+}
+
+func TestApplyMemberSpreadingShards(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards.Pod = &mongodbv1alpha1.PodSpec{AntiAffinityMode: AntiAffinityRequired}
+
+	// Members of different shards may share a node
+	for i := int32(0); i < 2; i++ {
+		sts := BuildShardStatefulSet(mdbsh, i)
+		required := sts.Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		require.Len(t, required, 1)
+		assert.Equal(t, sts.Spec.Selector.MatchLabels, required[0].LabelSelector.MatchLabels)
+	}
+}
+
+func TestApplyShardIsolation(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	shard0 := BuildShardStatefulSet(mdbsh, 0).Spec.Template
+	shard1 := BuildShardStatefulSet(mdbsh, 1).Spec.Template
+	cfg := BuildConfigServerStatefulSet(mdbsh).Spec.Template
+	mongos := BuildMongosDeployment(mdbsh).Spec.Template
+	assert.Len(t, shard0.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+
+	matches := func(term corev1.PodAffinityTerm, pod corev1.PodTemplateSpec) bool {
+		selector, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+		require.NoError(t, err)
+		return selector.Matches(labels.Set(pod.Labels))
+	}
+
+	mdbsh.Spec.Shards.Isolation = &mongodbv1alpha1.ShardIsolationSpec{Mode: AntiAffinityPreferred}
+	preferred := BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, preferred, 2)
+	term := preferred[1].PodAffinityTerm
+	assert.Equal(t, "kubernetes.io/hostname", term.TopologyKey)
+	assert.True(t, matches(term, shard1))
+	assert.False(t, matches(term, shard0))
+	assert.False(t, matches(term, cfg))
+	assert.False(t, matches(term, mongos))
+	assert.Len(t, BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.Affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+
+	mdbsh.Spec.Shards.Isolation = &mongodbv1alpha1.ShardIsolationSpec{Mode: AntiAffinityRequired, ConfigServers: true}
+	required := BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, required, 1)
+	assert.True(t, matches(required[0], shard1))
+	assert.True(t, matches(required[0], cfg))
+	assert.False(t, matches(required[0], mongos))
+
+	required = BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	require.Len(t, required, 1)
+	assert.True(t, matches(required[0], shard0))
+	assert.False(t, matches(required[0], cfg))
+	assert.False(t, matches(required[0], mongos))
+}
+
+func TestApplyArchitecture(t *testing.T) {
+	mdb := testMongoDB()
+
+	terms := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Len(t, terms, 1)
+	assert.Equal(t, []corev1.NodeSelectorRequirement{
+		{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64", "arm64"}},
+	}, terms[0].MatchExpressions)
+
+	// The requirement is added to the node affinity of the spec without changing the spec
+	mdb.Spec.Architecture = ArchitectureARM64
+	mdb.Spec.Pod = testSchedulingPodSpec()
+	terms = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Len(t, terms, 1)
+	require.Len(t, terms[0].MatchExpressions, 2)
+	assert.Equal(t, "node-role", terms[0].MatchExpressions[0].Key)
+	assert.Equal(t, []string{"arm64"}, terms[0].MatchExpressions[1].Values)
+	assert.Len(t, mdb.Spec.Pod.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions, 1)
+
+	// A term selecting an architecture itself is kept as is
+	mdb.Spec.Pod.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions = []corev1.NodeSelectorRequirement{
+		{Key: "kubernetes.io/arch", Operator: corev1.NodeSelectorOpIn, Values: []string{"amd64"}},
+	}
+	terms = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	require.Len(t, terms[0].MatchExpressions, 1)
+	assert.Equal(t, []string{"amd64"}, terms[0].MatchExpressions[0].Values)
+
+	// A node selector on the arch label takes precedence
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/arch": "amd64"}}
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	assert.Nil(t, pod.Affinity.NodeAffinity)
+}
+
+func TestApplyArchitectureSharded(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Architecture = ArchitectureAMD64
+
+	pods := []corev1.PodSpec{
+		BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec,
+		BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec,
+		BuildMongosDeployment(mdbsh).Spec.Template.Spec,
+	}
+	for _, pod := range pods {
+		terms := pod.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		require.Len(t, terms, 1)
+		assert.Equal(t, []string{"amd64"}, terms[0].MatchExpressions[0].Values)
+	}
+}
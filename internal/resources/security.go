@@ -0,0 +1,71 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// mongoDBUID is the UID and GID of the mongodb user of the official images
+	mongoDBUID = 999
+
+	tmpVolumeName         = "tmp"
+	mongoshHomeVolumeName = "mongosh-home"
+	mongoshHomePath       = "/home/mongodb"
+)
+
+// OpenShiftCompatibility leaves RunAsUser, RunAsGroup and FSGroup unset so the restricted SCC of
+// OpenShift can assign the UID and fsGroup from the namespace range, set from the operator
+// --openshift flag.
+var OpenShiftCompatibility bool
+
+// readOnlyRootFilesystem reports whether the operator containers run with a read-only root filesystem
+func readOnlyRootFilesystem(pod *mongodbv1alpha1.PodSpec) bool {
+	return pod == nil || pod.ReadOnlyRootFilesystem == nil || *pod.ReadOnlyRootFilesystem
+}
+
+// applyRootFilesystem makes the root filesystem of the operator containers read-only or writable.
+// With a read-only root the database container gets emptyDir volumes for /tmp, where mongod and
+// mongos create their unix socket, and for the home directory mongosh writes its history and logs
+// to; the exporter writes nothing to disk. It runs before the user containers are added, which keep
+// their own security context.
+func applyRootFilesystem(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec, container string) {
+	readOnly := readOnlyRootFilesystem(pod)
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.SecurityContext != nil {
+			c.SecurityContext.ReadOnlyRootFilesystem = boolPtr(readOnly)
+		}
+		if readOnly && c.Name == container {
+			c.VolumeMounts = append(c.VolumeMounts,
+				corev1.VolumeMount{Name: tmpVolumeName, MountPath: "/tmp"},
+				corev1.VolumeMount{Name: mongoshHomeVolumeName, MountPath: mongoshHomePath},
+			)
+			c.Env = append(c.Env, corev1.EnvVar{Name: "HOME", Value: mongoshHomePath})
+		}
+	}
+
+	if readOnly {
+		podSpec.Volumes = append(podSpec.Volumes,
+			corev1.Volume{Name: tmpVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+			corev1.Volume{Name: mongoshHomeVolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		)
+	}
+}
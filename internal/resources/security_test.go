@@ -0,0 +1,105 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func volumeNames(volumes []corev1.Volume) []string {
+	names := make([]string, 0, len(volumes))
+	for _, v := range volumes {
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+func TestApplyRootFilesystemReadOnly(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Monitoring = &mongodbv1alpha1.MonitoringSpec{Enabled: true}
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+
+	for _, c := range pod.Containers {
+		require.NotNil(t, c.SecurityContext, c.Name)
+		assert.True(t, *c.SecurityContext.ReadOnlyRootFilesystem, c.Name)
+	}
+	assert.Contains(t, volumeNames(pod.Volumes), "tmp")
+	assert.Contains(t, volumeNames(pod.Volumes), "mongosh-home")
+
+	mongod := findContainer(pod.Containers, "mongodb")
+	assert.Contains(t, mongod.VolumeMounts, corev1.VolumeMount{Name: "tmp", MountPath: "/tmp"})
+	assert.Contains(t, mongod.VolumeMounts, corev1.VolumeMount{Name: "mongosh-home", MountPath: "/home/mongodb"})
+	assert.Contains(t, mongod.Env, corev1.EnvVar{Name: "HOME", Value: "/home/mongodb"})
+}
+
+func TestApplyRootFilesystemWritable(t *testing.T) {
+	writable := false
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Mongos.Pod = &mongodbv1alpha1.PodSpec{ReadOnlyRootFilesystem: &writable}
+
+	pod := BuildMongosDeployment(mdbsh).Spec.Template.Spec
+	mongos := findContainer(pod.Containers, "mongos")
+	assert.False(t, *mongos.SecurityContext.ReadOnlyRootFilesystem)
+	assert.NotContains(t, volumeNames(pod.Volumes), "tmp")
+
+	// Other components keep the read-only default
+	shard := findContainer(BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Containers, "mongodb")
+	assert.True(t, *shard.SecurityContext.ReadOnlyRootFilesystem)
+	assert.Contains(t, shard.VolumeMounts, corev1.VolumeMount{Name: "tmp", MountPath: "/tmp"})
+}
+
+func TestDefaultSecurityContext(t *testing.T) {
+	pod := BuildReplicaSetStatefulSet(testMongoDB()).Spec.Template.Spec
+
+	assert.Equal(t, int64(999), *pod.SecurityContext.RunAsUser)
+	assert.Equal(t, int64(999), *pod.SecurityContext.FSGroup)
+	assert.Equal(t, corev1.FSGroupChangeOnRootMismatch, *pod.SecurityContext.FSGroupChangePolicy)
+	assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, pod.SecurityContext.SeccompProfile.Type)
+	assert.Equal(t, int64(999), *findContainer(pod.Containers, "mongodb").SecurityContext.RunAsUser)
+	assert.Equal(t, int64(999), *pod.InitContainers[0].SecurityContext.RunAsUser)
+}
+
+func TestOpenShiftCompatibility(t *testing.T) {
+	defer func(enabled bool) { OpenShiftCompatibility = enabled }(OpenShiftCompatibility)
+	OpenShiftCompatibility = true
+
+	mdbsh := testMongoDBSharded()
+	for _, pod := range []corev1.PodSpec{
+		BuildReplicaSetStatefulSet(testMongoDB()).Spec.Template.Spec,
+		BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec,
+		BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec,
+		BuildMongosDeployment(mdbsh).Spec.Template.Spec,
+	} {
+		assert.Nil(t, pod.SecurityContext.RunAsUser)
+		assert.Nil(t, pod.SecurityContext.RunAsGroup)
+		assert.Nil(t, pod.SecurityContext.FSGroup)
+		assert.True(t, *pod.SecurityContext.RunAsNonRoot)
+		assert.Equal(t, corev1.SeccompProfileTypeRuntimeDefault, pod.SecurityContext.SeccompProfile.Type)
+		for _, c := range append(pod.InitContainers, pod.Containers...) {
+			require.NotNil(t, c.SecurityContext, c.Name)
+			assert.Nil(t, c.SecurityContext.RunAsUser, c.Name)
+			assert.Nil(t, c.SecurityContext.RunAsGroup, c.Name)
+		}
+	}
+}
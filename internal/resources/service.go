@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ManagedAnnotationsAnnotation lists the Service annotations set by the operator, so annotations
+// removed from the spec are removed again while those set by others are kept
+const ManagedAnnotationsAnnotation = "mongodb.keiailab.com/managed-annotations"
+
+// PreserveServiceFields carries the fields of an existing Service that the operator does not own
+// over to its desired state before an update: the allocated cluster IPs and node ports, the
+// load balancer class, the finalizers and the annotations set by cloud controllers or users.
+// Replacing them on every reconcile makes LoadBalancer Services flap.
+func PreserveServiceFields(desired, existing *corev1.Service) {
+	previous := strings.Split(existing.Annotations[ManagedAnnotationsAnnotation], ",")
+	managed := slices.Sorted(maps.Keys(desired.Annotations))
+	// The desired annotations may be the map of the cluster spec, never write into it
+	annotations := maps.Clone(desired.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range existing.Annotations {
+		if _, ok := annotations[key]; ok || key == ManagedAnnotationsAnnotation || slices.Contains(previous, key) {
+			continue
+		}
+		annotations[key] = value
+	}
+	if len(managed) > 0 {
+		annotations[ManagedAnnotationsAnnotation] = strings.Join(managed, ",")
+	}
+	desired.Annotations = annotations
+	desired.Finalizers = existing.Finalizers
+
+	spec, current := &desired.Spec, &existing.Spec
+	if spec.ClusterIP == "" {
+		spec.ClusterIP = current.ClusterIP
+		spec.ClusterIPs = current.ClusterIPs
+	}
+	if spec.IPFamilies == nil {
+		spec.IPFamilies = current.IPFamilies
+	}
+	if spec.IPFamilyPolicy == nil {
+		spec.IPFamilyPolicy = current.IPFamilyPolicy
+	}
+	if spec.LoadBalancerClass == nil {
+		spec.LoadBalancerClass = current.LoadBalancerClass
+	}
+	if spec.AllocateLoadBalancerNodePorts == nil {
+		spec.AllocateLoadBalancerNodePorts = current.AllocateLoadBalancerNodePorts
+	}
+	if spec.HealthCheckNodePort == 0 && spec.ExternalTrafficPolicy == current.ExternalTrafficPolicy {
+		spec.HealthCheckNodePort = current.HealthCheckNodePort
+	}
+	if spec.Type == corev1.ServiceTypeClusterIP {
+		return
+	}
+	for i := range spec.Ports {
+		port := &spec.Ports[i]
+		if port.NodePort != 0 {
+			continue
+		}
+		for _, allocated := range current.Ports {
+			if allocated.Name == port.Name && allocated.Port == port.Port && servicePortProtocol(allocated) == servicePortProtocol(*port) {
+				port.NodePort = allocated.NodePort
+			}
+		}
+	}
+}
+
+// servicePortProtocol returns the protocol of a port, the API server defaults it to TCP
+func servicePortProtocol(port corev1.ServicePort) corev1.Protocol {
+	if port.Protocol == "" {
+		return corev1.ProtocolTCP
+	}
+	return port.Protocol
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestPreserveServiceFields(t *testing.T) {
+	mdbsh := &mongodbv1alpha1.MongoDBSharded{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sharded", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBShardedSpec{
+			Mongos: mongodbv1alpha1.MongosSpec{
+				Replicas: 2,
+				Service: &mongodbv1alpha1.MongosServiceSpec{
+					Type:        "LoadBalancer",
+					Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+				},
+			},
+		},
+	}
+
+	lbClass := "service.k8s.aws/nlb"
+	existing := BuildMongosService(mdbsh)
+	existing.Annotations = maps.Clone(existing.Annotations)
+	existing.Finalizers = []string{"service.kubernetes.io/load-balancer-cleanup"}
+	existing.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"] = "internal"
+	existing.Spec.ClusterIP = "10.0.0.10"
+	existing.Spec.ClusterIPs = []string{"10.0.0.10"}
+	existing.Spec.LoadBalancerClass = &lbClass
+	existing.Spec.Ports[0].NodePort = 31017
+	existing.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+	existing.Spec.Ports[1].NodePort = 31216
+
+	desired := BuildMongosService(mdbsh)
+	PreserveServiceFields(desired, existing)
+
+	assert.Equal(t, "10.0.0.10", desired.Spec.ClusterIP)
+	assert.Equal(t, []string{"10.0.0.10"}, desired.Spec.ClusterIPs)
+	assert.Equal(t, &lbClass, desired.Spec.LoadBalancerClass)
+	assert.Equal(t, int32(31017), desired.Spec.Ports[0].NodePort)
+	assert.Equal(t, int32(31216), desired.Spec.Ports[1].NodePort)
+	assert.Equal(t, existing.Finalizers, desired.Finalizers)
+	assert.Equal(t, "true", desired.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"])
+	assert.Equal(t, "internal", desired.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"], "annotations set by others are kept")
+	assert.Equal(t, "service.beta.kubernetes.io/aws-load-balancer-internal", desired.Annotations[ManagedAnnotationsAnnotation])
+
+	// Annotations removed from the spec are removed from the Service
+	mdbsh.Spec.Mongos.Service.Annotations = nil
+	next := BuildMongosService(mdbsh)
+	PreserveServiceFields(next, desired)
+	assert.NotContains(t, next.Annotations, "service.beta.kubernetes.io/aws-load-balancer-internal")
+	assert.NotContains(t, next.Annotations, ManagedAnnotationsAnnotation)
+	assert.Equal(t, "internal", next.Annotations["service.beta.kubernetes.io/aws-load-balancer-scheme"])
+}
+
+func TestPreserveServiceFieldsClusterIP(t *testing.T) {
+	mdb := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members: 3,
+			Version: mongodbv1alpha1.MongoDBVersion{Version: "7.0"},
+		},
+	}
+
+	existing := BuildClientService(mdb)
+	existing.Spec.ClusterIP = "10.0.0.20"
+	desired := BuildClientService(mdb)
+	PreserveServiceFields(desired, existing)
+
+	assert.Equal(t, "10.0.0.20", desired.Spec.ClusterIP)
+	assert.Zero(t, desired.Spec.Ports[0].NodePort)
+
+	// A Service switched to ClusterIP drops its node ports
+	existing.Spec.Type = corev1.ServiceTypeNodePort
+	existing.Spec.Ports[0].NodePort = 30017
+	desired = BuildClientService(mdb)
+	PreserveServiceFields(desired, existing)
+	assert.Zero(t, desired.Spec.Ports[0].NodePort)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// BuildServiceAccount creates the ServiceAccount the database pods of a cluster run under.
+// The pods never talk to the Kubernetes API, so no token is mounted.
+func BuildServiceAccount(clusterName, namespace string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      clusterName,
+			Namespace: namespace,
+			Labels:    buildLabels(clusterName, "serviceaccount"),
+		},
+		AutomountServiceAccountToken: boolPtr(false),
+	}
+}
+
+// UsesClusterServiceAccount reports whether any of the pod specs runs under the ServiceAccount
+// created for the cluster, rather than one named in spec.pod.serviceAccountName
+func UsesClusterServiceAccount(pods ...*mongodbv1alpha1.PodSpec) bool {
+	for _, pod := range pods {
+		if pod == nil || pod.ServiceAccountName == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// applyServiceAccount runs the pods under the cluster ServiceAccount and disables the token mount.
+// spec.pod.serviceAccountName and spec.pod.automountServiceAccountToken override both.
+func applyServiceAccount(podSpec *corev1.PodSpec, clusterName string, pod *mongodbv1alpha1.PodSpec) {
+	podSpec.ServiceAccountName = clusterName
+	podSpec.AutomountServiceAccountToken = boolPtr(false)
+	if pod == nil {
+		return
+	}
+	if pod.ServiceAccountName != "" {
+		podSpec.ServiceAccountName = pod.ServiceAccountName
+	}
+	if pod.AutomountServiceAccountToken != nil {
+		podSpec.AutomountServiceAccountToken = boolPtr(*pod.AutomountServiceAccountToken)
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestBuildServiceAccount(t *testing.T) {
+	sa := BuildServiceAccount("my-mongodb", "default")
+
+	assert.Equal(t, "my-mongodb", sa.Name)
+	assert.Equal(t, "default", sa.Namespace)
+	assert.Equal(t, "my-mongodb", sa.Labels["app.kubernetes.io/instance"])
+	assert.False(t, *sa.AutomountServiceAccountToken)
+}
+
+func TestApplyServiceAccount(t *testing.T) {
+	mdb := testMongoDB()
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	assert.Equal(t, "my-mongodb", pod.ServiceAccountName)
+	assert.False(t, *pod.AutomountServiceAccountToken)
+
+	automount := true
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{ServiceAccountName: "mongodb", AutomountServiceAccountToken: &automount}
+	pod = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	assert.Equal(t, "mongodb", pod.ServiceAccountName)
+	assert.True(t, *pod.AutomountServiceAccountToken)
+
+	mdbsh := testMongoDBSharded()
+	for _, pod := range []corev1.PodSpec{
+		BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec,
+		BuildShardStatefulSet(mdbsh, 1).Spec.Template.Spec,
+		BuildMongosDeployment(mdbsh).Spec.Template.Spec,
+	} {
+		assert.Equal(t, "my-sharded", pod.ServiceAccountName)
+		assert.False(t, *pod.AutomountServiceAccountToken)
+	}
+}
+
+func TestUsesClusterServiceAccount(t *testing.T) {
+	own := &mongodbv1alpha1.PodSpec{ServiceAccountName: "mongodb"}
+
+	assert.True(t, UsesClusterServiceAccount(nil))
+	assert.False(t, UsesClusterServiceAccount(own))
+	assert.True(t, UsesClusterServiceAccount(own, &mongodbv1alpha1.PodSpec{}, own))
+	assert.False(t, UsesClusterServiceAccount(own, own, own))
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ShardHostsCondition reports whether the shards registered on the config servers match the
+// shard members
+const ShardHostsCondition = "ShardHostsInSync"
+
+// ShardHostDrifted reports whether the config.shards host of a shard is stale. Hosts have the
+// form "replicaSet/host1:port,host2:port". MongoDB keeps the entry up to date itself while it can
+// reach a member and leaves out hidden members, so only a different replica set name or hosts
+// that are not members of the shard, e.g. after a namespace migration or a port change, count.
+func ShardHostDrifted(registered, desired string) bool {
+	regSet, regHosts, _ := strings.Cut(registered, "/")
+	desSet, desHosts, _ := strings.Cut(desired, "/")
+	if regSet != desSet || regHosts == "" {
+		return true
+	}
+
+	members := strings.Split(desHosts, ",")
+	for _, host := range strings.Split(regHosts, ",") {
+		if !slices.Contains(members, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildShardHostsCondition builds the ShardHostsInSync condition from the shards whose host was
+// repaired and the shards missing from config.shards
+func BuildShardHostsCondition(repaired, missing []string, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               ShardHostsCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "InSync",
+		Message:            "The shards registered on the config servers match the shard members",
+	}
+
+	switch {
+	case len(missing) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "ShardsMissing"
+		condition.Message = fmt.Sprintf("Shards are missing from config.shards and are added again: %s", strings.Join(missing, ", "))
+	case len(repaired) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "HostsRepaired"
+		condition.Message = fmt.Sprintf("Stale config.shards hosts were repaired: %s", strings.Join(repaired, ", "))
+	}
+
+	return condition
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShardHostDrifted(t *testing.T) {
+	desired := "rs0/a:27018,b:27018,c:27018"
+
+	assert.False(t, ShardHostDrifted("rs0/a:27018,b:27018,c:27018", desired))
+	assert.False(t, ShardHostDrifted("rs0/b:27018,a:27018", desired), "hidden members are left out")
+	assert.True(t, ShardHostDrifted("rs0/a:27018,old:27018", desired))
+	assert.True(t, ShardHostDrifted("rs1/a:27018,b:27018,c:27018", desired))
+	assert.True(t, ShardHostDrifted("rs0/", desired))
+	assert.True(t, ShardHostDrifted("a:27018", desired))
+}
+
+func TestBuildShardHostsCondition(t *testing.T) {
+	condition := BuildShardHostsCondition(nil, nil, 3)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, "InSync", condition.Reason)
+	assert.Equal(t, int64(3), condition.ObservedGeneration)
+
+	condition = BuildShardHostsCondition([]string{"db-shard-0"}, nil, 3)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "HostsRepaired", condition.Reason)
+	assert.Contains(t, condition.Message, "db-shard-0")
+
+	condition = BuildShardHostsCondition([]string{"db-shard-0"}, []string{"db-shard-1"}, 3)
+	assert.Equal(t, "ShardsMissing", condition.Reason)
+	assert.Contains(t, condition.Message, "db-shard-1")
+}
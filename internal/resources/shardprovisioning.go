@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// ShardName returns the name of a shard, used for its replica set, StatefulSet and config.shards id
+func ShardName(clusterName string, index int32) string {
+	return fmt.Sprintf("%s-shard-%d", clusterName, index)
+}
+
+// ShardIndex returns the index of a shard of the cluster from its name
+func ShardIndex(clusterName, shardName string) (int32, bool) {
+	suffix, ok := strings.CutPrefix(shardName, clusterName+"-shard-")
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.ParseInt(suffix, 10, 32)
+	if err != nil || index < 0 || strconv.FormatInt(index, 10) != suffix {
+		return 0, false
+	}
+	return int32(index), true
+}
+
+// ShardInitialized reports whether the replica set of a shard was last seen initiated
+func ShardInitialized(status *mongodbv1alpha1.MongoDBShardedStatus, shardName string) bool {
+	return status.ShardProvisioning[shardName].Initialized
+}
+
+// ShardAdded reports whether a shard was last seen registered in the cluster
+func ShardAdded(status *mongodbv1alpha1.MongoDBShardedStatus, shardName string) bool {
+	return status.ShardProvisioning[shardName].Added
+}
+
+// SetShardInitialized records that the replica set of a shard is initiated
+func SetShardInitialized(status *mongodbv1alpha1.MongoDBShardedStatus, shardName string) {
+	setShardProvisioning(status, shardName, func(s *mongodbv1alpha1.ShardProvisioningStatus) {
+		s.Initialized = true
+	})
+}
+
+// SetShardAdded records whether a shard is registered in the cluster. A registered shard is
+// initiated as well.
+func SetShardAdded(status *mongodbv1alpha1.MongoDBShardedStatus, shardName string, added bool) {
+	setShardProvisioning(status, shardName, func(s *mongodbv1alpha1.ShardProvisioningStatus) {
+		s.Added = added
+		s.Initialized = s.Initialized || added
+	})
+}
+
+// RemovedShards returns the indexes at or above count of the shards the cluster still tracks,
+// highest first
+func RemovedShards(clusterName string, status *mongodbv1alpha1.MongoDBShardedStatus, count int32) []int32 {
+	var removed []int32
+	for name := range status.ShardProvisioning {
+		if index, ok := ShardIndex(clusterName, name); ok && index >= count {
+			removed = append(removed, index)
+		}
+	}
+	slices.Sort(removed)
+	slices.Reverse(removed)
+	return removed
+}
+
+func setShardProvisioning(status *mongodbv1alpha1.MongoDBShardedStatus, shardName string, update func(*mongodbv1alpha1.ShardProvisioningStatus)) {
+	if status.ShardProvisioning == nil {
+		status.ShardProvisioning = map[string]mongodbv1alpha1.ShardProvisioningStatus{}
+	}
+	s := status.ShardProvisioning[shardName]
+	update(&s)
+	status.ShardProvisioning[shardName] = s
+}
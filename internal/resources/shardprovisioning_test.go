@@ -0,0 +1,65 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestShardIndex(t *testing.T) {
+	assert.Equal(t, "my-cluster-shard-3", ShardName("my-cluster", 3))
+
+	index, ok := ShardIndex("my-cluster", "my-cluster-shard-12")
+	assert.True(t, ok)
+	assert.Equal(t, int32(12), index)
+
+	for _, name := range []string{"other-shard-1", "my-cluster-shard-", "my-cluster-shard-01", "my-cluster-shard--1", "my-cluster-shard-1a"} {
+		_, ok := ShardIndex("my-cluster", name)
+		assert.False(t, ok, name)
+	}
+}
+
+func TestShardProvisioning(t *testing.T) {
+	status := &mongodbv1alpha1.MongoDBShardedStatus{}
+	assert.False(t, ShardInitialized(status, "c-shard-0"))
+	assert.False(t, ShardAdded(status, "c-shard-0"))
+
+	SetShardInitialized(status, "c-shard-0")
+	assert.True(t, ShardInitialized(status, "c-shard-0"))
+	assert.False(t, ShardAdded(status, "c-shard-0"))
+
+	// A registered shard is initialized, unregistering it keeps the replica set initialized
+	SetShardAdded(status, "c-shard-1", true)
+	assert.True(t, ShardInitialized(status, "c-shard-1"))
+	SetShardAdded(status, "c-shard-1", false)
+	assert.False(t, ShardAdded(status, "c-shard-1"))
+	assert.True(t, ShardInitialized(status, "c-shard-1"))
+}
+
+func TestRemovedShards(t *testing.T) {
+	status := &mongodbv1alpha1.MongoDBShardedStatus{}
+	for _, name := range []string{"c-shard-0", "c-shard-1", "c-shard-2", "c-shard-10", "other-shard-5"} {
+		SetShardInitialized(status, name)
+	}
+
+	assert.Equal(t, []int32{10, 2}, RemovedShards("c", status, 2))
+	assert.Empty(t, RemovedShards("c", status, 11))
+}
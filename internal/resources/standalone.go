@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// ModeReplicaSet deploys the members as a replica set
+	ModeReplicaSet = "ReplicaSet"
+	// ModeStandalone deploys a single mongod without replication
+	ModeStandalone = "Standalone"
+)
+
+// Standalone reports whether the MongoDB runs as a single standalone mongod
+func Standalone(mdb *mongodbv1alpha1.MongoDB) bool {
+	return mdb.Spec.Mode == ModeStandalone
+}
+
+// ValidateMode checks that a standalone deployment has a single member and no replica set features
+func ValidateMode(mdb *mongodbv1alpha1.MongoDB) error {
+	if !Standalone(mdb) {
+		return nil
+	}
+	if mdb.Spec.Members != 1 {
+		return fmt.Errorf("standalone mode runs a single mongod, members must be 1 (got %d)", mdb.Spec.Members)
+	}
+	if ArbiterEnabled(mdb) {
+		return fmt.Errorf("standalone mode has no replica set to add an arbiter to")
+	}
+	if JobAuthBootstrap(mdb.Spec.Auth) {
+		return fmt.Errorf("standalone mode has no keyfile for the admin bootstrap Job to authenticate with, spec.auth.bootstrap must be %s", AuthBootstrapExec)
+	}
+	return nil
+}
+
+// applyStandalone drops the keyfile volumes and the init container copying the keyfile, which a
+// mongod without replication does not use, and makes readiness a plain ping as there is no
+// replica set state to wait for
+func applyStandalone(podSpec *corev1.PodSpec, container string, pingCommand []string) {
+	isKeyfileVolume := func(name string) bool { return name == "keyfile" || name == "keyfile-secret" }
+
+	podSpec.InitContainers = slices.DeleteFunc(podSpec.InitContainers, func(c corev1.Container) bool {
+		return c.Name == "copy-keyfile"
+	})
+	podSpec.Volumes = slices.DeleteFunc(podSpec.Volumes, func(v corev1.Volume) bool {
+		return isKeyfileVolume(v.Name)
+	})
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		c.VolumeMounts = slices.DeleteFunc(c.VolumeMounts, func(m corev1.VolumeMount) bool {
+			return isKeyfileVolume(m.Name)
+		})
+		if c.ReadinessProbe != nil {
+			c.ReadinessProbe.Exec = &corev1.ExecAction{Command: pingCommand}
+		}
+	}
+}
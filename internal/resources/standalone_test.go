@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestValidateMode(t *testing.T) {
+	mdb := testMongoDB()
+	require.NoError(t, ValidateMode(mdb))
+
+	mdb.Spec.Mode = ModeStandalone
+	assert.Error(t, ValidateMode(mdb), "three members")
+
+	mdb.Spec.Members = 1
+	require.NoError(t, ValidateMode(mdb))
+
+	mdb.Spec.Auth.Bootstrap = AuthBootstrapJob
+	assert.Error(t, ValidateMode(mdb), "no keyfile to bootstrap the admin user with")
+	mdb.Spec.Auth.Bootstrap = ""
+
+	mdb.Spec.Arbiter = &mongodbv1alpha1.ArbiterSpec{Enabled: true}
+	assert.Error(t, ValidateMode(mdb))
+}
+
+func TestBuildStandaloneStatefulSet(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Mode = ModeStandalone
+	mdb.Spec.Members = 1
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	mongod := findContainer(pod.Containers, "mongodb")
+
+	config := BuildReplicaSetConfig(mdb)
+	assert.NotContains(t, config, "replication")
+	assert.NotContains(t, config, "keyFile")
+	assert.Empty(t, pod.InitContainers)
+	assert.NotContains(t, volumeNames(pod.Volumes), "keyfile")
+	assert.NotContains(t, volumeNames(pod.Volumes), "keyfile-secret")
+	for _, m := range mongod.VolumeMounts {
+		assert.NotEqual(t, "keyfile", m.Name)
+	}
+	assert.Equal(t, mongod.LivenessProbe.Exec.Command, mongod.ReadinessProbe.Exec.Command)
+}
+
+func TestBuildReplicaSetStatefulSetKeepsKeyfile(t *testing.T) {
+	pod := BuildReplicaSetStatefulSet(testMongoDB()).Spec.Template.Spec
+	mongod := findContainer(pod.Containers, "mongodb")
+
+	config := BuildReplicaSetConfig(testMongoDB())
+	assert.Contains(t, config, "replSetName:")
+	assert.Contains(t, config, "keyFile: /etc/mongodb-keyfile/keyfile")
+	assert.Contains(t, volumeNames(pod.Volumes), "keyfile")
+	assert.Equal(t, []string{"/scripts/readiness-probe.sh"}, mongod.ReadinessProbe.Exec.Command)
+}
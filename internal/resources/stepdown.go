@@ -0,0 +1,79 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// stepDownSeconds keeps a stepped down primary from being elected again while it shuts down
+	stepDownSeconds = 60
+
+	// stepDownCatchUpSeconds is how long the primary waits for an electable secondary to catch up
+	stepDownCatchUpSeconds = 15
+
+	// memberTerminationGracePeriodSeconds covers the step-down and a clean shutdown of mongod
+	memberTerminationGracePeriodSeconds = 60
+)
+
+// keyfileKeyScript reads the first key of the keyfile into $KEY, hooks authenticate as the internal
+// __system user with it. Whitespace is not part of a key, user-provided keyfiles may wrap it over
+// several lines.
+const keyfileKeyScript = `KEYFILE=/etc/mongodb-keyfile/keyfile
+if head -c 2 "$KEYFILE" | grep -q -- '- '; then
+  KEY="$(head -n 1 "$KEYFILE" | cut -c 3-)"
+else
+  KEY="$(cat "$KEYFILE")"
+fi
+KEY="$(printf '%s' "$KEY" | tr -d '[:space:]')"
+`
+
+// stepDownScript steps the member down when it is the primary. Failures are ignored, mongod still
+// steps down on SIGTERM.
+func stepDownScript(port int32) string {
+	return keyfileKeyScript + fmt.Sprintf(`mongosh --quiet --port %d -u __system -p "$KEY" --authenticationDatabase local --eval '
+if (db.hello().isWritablePrimary) {
+  try {
+    rs.stepDown(%d, %d);
+  } catch (e) {
+    print("step down failed: " + e);
+  }
+}' || true
+`, port, stepDownSeconds, stepDownCatchUpSeconds)
+}
+
+// applyPrimaryStepDown adds a preStop hook to the database container handing the primary role to
+// a caught up secondary before the pod is stopped, so rolling updates and node drains trigger a
+// clean election instead of clients losing the primary mid-write. The grace period leaves room for
+// the catch-up and the shutdown of mongod.
+func applyPrimaryStepDown(podSpec *corev1.PodSpec, container string, port int32) {
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		c.Lifecycle = &corev1.Lifecycle{
+			PreStop: &corev1.LifecycleHandler{
+				Exec: &corev1.ExecAction{Command: []string{"bash", "-c", stepDownScript(port)}},
+			},
+		}
+	}
+	podSpec.TerminationGracePeriodSeconds = int64Ptr(memberTerminationGracePeriodSeconds)
+}
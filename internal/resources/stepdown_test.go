@@ -0,0 +1,51 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPrimaryStepDown(t *testing.T) {
+	pod := BuildReplicaSetStatefulSet(testMongoDB()).Spec.Template.Spec
+
+	mongod := findContainer(pod.Containers, "mongodb")
+	require.NotNil(t, mongod.Lifecycle)
+	require.NotNil(t, mongod.Lifecycle.PreStop)
+	script := mongod.Lifecycle.PreStop.Exec.Command[2]
+	assert.Contains(t, script, "--port 27017")
+	assert.Contains(t, script, "rs.stepDown(60, 15)")
+	assert.Equal(t, int64(60), *pod.TerminationGracePeriodSeconds)
+
+	mdbsh := testMongoDBSharded()
+	shard := findContainer(BuildShardStatefulSet(mdbsh, 0).Spec.Template.Spec.Containers, "mongodb")
+	assert.Contains(t, shard.Lifecycle.PreStop.Exec.Command[2], "--port 27018")
+	cfg := findContainer(BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.Containers, "mongodb")
+	assert.Contains(t, cfg.Lifecycle.PreStop.Exec.Command[2], "--port 27019")
+}
+
+func TestApplyPrimaryStepDownStandalone(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Members = 1
+	mdb.Spec.Mode = ModeStandalone
+
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+	assert.Nil(t, findContainer(pod.Containers, "mongodb").Lifecycle)
+}
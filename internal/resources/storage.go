@@ -0,0 +1,134 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// StorageHealthyCondition reports whether every member has disk space left
+	StorageHealthyCondition = "StorageHealthy"
+
+	defaultStorageWarningPercent = 80
+
+	// StorageTypeEphemeral keeps the data of the members in an emptyDir instead of a PVC
+	StorageTypeEphemeral = "ephemeral"
+
+	// ephemeralProbeDelaySeconds is the probe delay of members starting from an empty dbPath
+	ephemeralProbeDelaySeconds = 5
+)
+
+// EphemeralStorage reports whether the data of the members is lost with their pods
+func EphemeralStorage(spec mongodbv1alpha1.StorageSpec) bool {
+	return spec.Type == StorageTypeEphemeral
+}
+
+// applyEphemeralStorage replaces the data volume claim template with an emptyDir limited to the
+// storage size. A member starting from an empty dbPath is up in seconds, so the probes of the
+// database container start early, and the default anti-affinity is dropped so the members of a
+// CI cluster may share a node. antiAffinityMode and spec.pod.affinity still apply.
+func applyEphemeralStorage(sts *appsv1.StatefulSet, spec mongodbv1alpha1.StorageSpec, container string) {
+	if !EphemeralStorage(spec) {
+		return
+	}
+
+	emptyDir := &corev1.EmptyDirVolumeSource{}
+	if !spec.Size.IsZero() {
+		size := spec.Size.DeepCopy()
+		emptyDir.SizeLimit = &size
+	}
+	sts.Spec.VolumeClaimTemplates = nil
+
+	podSpec := &sts.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name:         "data",
+		VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir},
+	})
+	if podSpec.Affinity != nil {
+		podSpec.Affinity.PodAntiAffinity = nil
+	}
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		for _, probe := range []*corev1.Probe{c.LivenessProbe, c.ReadinessProbe} {
+			if probe != nil {
+				probe.InitialDelaySeconds = ephemeralProbeDelaySeconds
+				probe.PeriodSeconds = ephemeralProbeDelaySeconds
+			}
+		}
+	}
+}
+
+// StorageWarningPercent returns the dbPath usage, in percent, at which a member is reported as running out of disk
+func StorageWarningPercent(spec mongodbv1alpha1.StorageSpec) int32 {
+	if spec.UsageWarningPercent > 0 {
+		return spec.UsageWarningPercent
+	}
+	return defaultStorageWarningPercent
+}
+
+// MembersLowOnStorage returns the members whose dbPath usage reached the warning threshold of their storage
+func MembersLowOnStorage(members []mongodbv1alpha1.MemberStorageStatus, spec mongodbv1alpha1.StorageSpec) []mongodbv1alpha1.MemberStorageStatus {
+	threshold := StorageWarningPercent(spec)
+	var low []mongodbv1alpha1.MemberStorageStatus
+	for _, m := range members {
+		if m.UsedPercent >= threshold {
+			low = append(low, m)
+		}
+	}
+	return low
+}
+
+// BuildStorageHealthyCondition builds the StorageHealthy condition from the collected member usage
+// and the members that reached their warning threshold
+func BuildStorageHealthyCondition(members, low []mongodbv1alpha1.MemberStorageStatus, generation int64) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               StorageHealthyCondition,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             "SufficientSpace",
+		Message:            fmt.Sprintf("Disk usage of %d members is below the warning threshold", len(members)),
+	}
+
+	switch {
+	case len(low) > 0:
+		usage := make([]string, 0, len(low))
+		for _, m := range low {
+			usage = append(usage, fmt.Sprintf("%s (%d%%)", m.Name, m.UsedPercent))
+		}
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DiskUsageHigh"
+		condition.Message = fmt.Sprintf("Members are running out of disk space: %s", strings.Join(usage, ", "))
+	case len(members) == 0:
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "NotCollected"
+		condition.Message = "Disk usage of the members has not been collected"
+	}
+
+	return condition
+}
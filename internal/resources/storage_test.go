@@ -0,0 +1,96 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestMembersLowOnStorage(t *testing.T) {
+	members := []mongodbv1alpha1.MemberStorageStatus{
+		{Name: "my-mongodb-0", UsedPercent: 42},
+		{Name: "my-mongodb-1", UsedPercent: 80},
+		{Name: "my-mongodb-2", UsedPercent: 91},
+	}
+
+	low := MembersLowOnStorage(members, mongodbv1alpha1.StorageSpec{})
+	require.Len(t, low, 2)
+	assert.Equal(t, "my-mongodb-1", low[0].Name)
+
+	low = MembersLowOnStorage(members, mongodbv1alpha1.StorageSpec{UsageWarningPercent: 90})
+	require.Len(t, low, 1)
+	assert.Equal(t, "my-mongodb-2", low[0].Name)
+}
+
+func TestBuildStorageHealthyCondition(t *testing.T) {
+	members := []mongodbv1alpha1.MemberStorageStatus{
+		{Name: "my-mongodb-0", UsedPercent: 42},
+		{Name: "my-mongodb-1", UsedPercent: 91},
+	}
+
+	condition := BuildStorageHealthyCondition(members, nil, 3)
+	assert.Equal(t, StorageHealthyCondition, condition.Type)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, int64(3), condition.ObservedGeneration)
+
+	condition = BuildStorageHealthyCondition(members, members[1:], 3)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "DiskUsageHigh", condition.Reason)
+	assert.Contains(t, condition.Message, "my-mongodb-1 (91%)")
+
+	condition = BuildStorageHealthyCondition(nil, nil, 3)
+	assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+}
+
+func TestApplyEphemeralStorage(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Storage = mongodbv1alpha1.StorageSpec{Type: StorageTypeEphemeral, Size: resource.MustParse("2Gi"), DataDirPath: "/data/db"}
+
+	sts := BuildReplicaSetStatefulSet(mdb)
+	pod := sts.Spec.Template.Spec
+	assert.Empty(t, sts.Spec.VolumeClaimTemplates)
+	assert.Nil(t, pod.Affinity.PodAntiAffinity)
+
+	var data *resource.Quantity
+	for _, v := range pod.Volumes {
+		if v.Name == "data" {
+			require.NotNil(t, v.EmptyDir)
+			data = v.EmptyDir.SizeLimit
+		}
+	}
+	require.NotNil(t, data)
+	assert.Equal(t, "2Gi", data.String())
+
+	mongod := findContainer(pod.Containers, "mongodb")
+	assert.Equal(t, int32(5), mongod.LivenessProbe.InitialDelaySeconds)
+	assert.Equal(t, int32(5), mongod.ReadinessProbe.PeriodSeconds)
+}
+
+func TestApplyEphemeralStoragePerComponent(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards.Storage.Type = StorageTypeEphemeral
+
+	assert.Empty(t, BuildShardStatefulSet(mdbsh, 0).Spec.VolumeClaimTemplates)
+	assert.Len(t, BuildConfigServerStatefulSet(mdbsh).Spec.VolumeClaimTemplates, 1)
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// DefaultBlockCompressor is the collection compressor of mongod when none is configured
+	DefaultBlockCompressor = "snappy"
+
+	blockCompressorKey   = "storage.wiredTiger.collectionConfig.blockCompressor"
+	directoryPerDBKey    = "storage.directoryPerDB"
+	journalCommitKey     = "storage.journal.commitIntervalMs"
+	journalCompressorKey = "storage.wiredTiger.engineConfig.journalCompressor"
+)
+
+// storageEngineConfigKeys returns the mongod.conf settings set by the storage engine spec
+func storageEngineConfigKeys(spec *mongodbv1alpha1.StorageEngineSpec) []string {
+	if spec == nil {
+		return nil
+	}
+
+	var keys []string
+	if spec.BlockCompressor != "" {
+		keys = append(keys, blockCompressorKey)
+	}
+	if spec.DirectoryPerDB {
+		keys = append(keys, directoryPerDBKey)
+	}
+	if spec.Journal != nil {
+		if spec.Journal.CommitIntervalMs != nil {
+			keys = append(keys, journalCommitKey)
+		}
+		if spec.Journal.Compressor != "" {
+			keys = append(keys, journalCompressorKey)
+		}
+	}
+	return keys
+}
+
+// ValidateStorageEngine checks that additionalConfig does not also set the settings of the
+// storage engine spec
+func ValidateStorageEngine(spec *mongodbv1alpha1.StorageEngineSpec, additional map[string]string) error {
+	for _, managed := range storageEngineConfigKeys(spec) {
+		for key := range additional {
+			if configKeysOverlap(key, managed) {
+				return fmt.Errorf("additionalConfig key %q overrides %s, which is set by storageEngine", key, managed)
+			}
+		}
+	}
+	return nil
+}
+
+// applyStorageEngineConfig writes the storage engine settings into mongod.conf
+func applyStorageEngineConfig(config map[string]interface{}, spec *mongodbv1alpha1.StorageEngineSpec) {
+	if spec == nil {
+		return
+	}
+
+	if spec.BlockCompressor != "" {
+		setConfigValue(config, blockCompressorKey, spec.BlockCompressor)
+	}
+	if spec.DirectoryPerDB {
+		setConfigValue(config, directoryPerDBKey, true)
+	}
+	if spec.Journal != nil {
+		if spec.Journal.CommitIntervalMs != nil {
+			setConfigValue(config, journalCommitKey, *spec.Journal.CommitIntervalMs)
+		}
+		if spec.Journal.Compressor != "" {
+			setConfigValue(config, journalCompressorKey, spec.Journal.Compressor)
+		}
+	}
+}
+
+// addStorageLayout records the block compressor and directory layout the data files are created
+// with, from the storage engine spec or additionalConfig. Nothing is recorded when no component
+// keeps its volumes, members on ephemeral storage start on new data files.
+func addStorageLayout(settings *mongodbv1alpha1.AppliedSettings, spec *mongodbv1alpha1.StorageEngineSpec, additional map[string]string) {
+	if len(settings.StorageSizes) == 0 {
+		return
+	}
+
+	settings.BlockCompressor = DefaultBlockCompressor
+	directoryPerDB := false
+	if compressor := additionalConfigValue(additional, blockCompressorKey); compressor != nil {
+		settings.BlockCompressor = fmt.Sprint(compressor)
+	}
+	if enabled, ok := additionalConfigValue(additional, directoryPerDBKey).(bool); ok {
+		directoryPerDB = enabled
+	}
+	if spec != nil {
+		if spec.BlockCompressor != "" {
+			settings.BlockCompressor = spec.BlockCompressor
+		}
+		directoryPerDB = directoryPerDB || spec.DirectoryPerDB
+	}
+	settings.DirectoryPerDB = &directoryPerDB
+}
+
+// additionalConfigValue returns the parsed value of an additionalConfig setting, nil when it is
+// not set or does not parse
+func additionalConfigValue(additional map[string]string, key string) interface{} {
+	raw, ok := additional[key]
+	if !ok {
+		return nil
+	}
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+		return nil
+	}
+	return value
+}
+
+// storageLayoutChanges returns the changes of the data file layout that existing volumes can not
+// take. Settings applied before the layout was recorded are not compared.
+func storageLayoutChanges(applied, desired *mongodbv1alpha1.AppliedSettings) []string {
+	var changes []string
+	if applied.BlockCompressor != "" && desired.BlockCompressor != "" && desired.BlockCompressor != applied.BlockCompressor {
+		changes = append(changes, fmt.Sprintf("block compressor %s replaces %s, existing volumes keep their collections compressed with %s",
+			desired.BlockCompressor, applied.BlockCompressor, applied.BlockCompressor))
+	}
+	if applied.DirectoryPerDB != nil && desired.DirectoryPerDB != nil && *desired.DirectoryPerDB != *applied.DirectoryPerDB {
+		changes = append(changes, fmt.Sprintf("directoryPerDB %t replaces %t, mongod does not start on existing volumes with another layout",
+			*desired.DirectoryPerDB, *applied.DirectoryPerDB))
+	}
+	return changes
+}
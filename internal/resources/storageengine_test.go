@@ -0,0 +1,96 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func testStorageEngine() *mongodbv1alpha1.StorageEngineSpec {
+	return &mongodbv1alpha1.StorageEngineSpec{
+		BlockCompressor: "zstd",
+		DirectoryPerDB:  true,
+		Journal:         &mongodbv1alpha1.JournalSpec{CommitIntervalMs: int32Ptr(50), Compressor: "zlib"},
+	}
+}
+
+func TestValidateStorageEngine(t *testing.T) {
+	assert.NoError(t, ValidateStorageEngine(nil, map[string]string{"storage.directoryPerDB": "true"}))
+	assert.NoError(t, ValidateStorageEngine(testStorageEngine(), map[string]string{"storage.syncPeriodSecs": "30"}))
+	assert.NoError(t, ValidateStorageEngine(&mongodbv1alpha1.StorageEngineSpec{BlockCompressor: "zstd"},
+		map[string]string{"storage.directoryPerDB": "true"}))
+
+	err := ValidateStorageEngine(testStorageEngine(), map[string]string{"storage.directoryPerDB": "false"})
+	assert.ErrorContains(t, err, "overrides storage.directoryPerDB, which is set by storageEngine")
+	assert.Error(t, ValidateStorageEngine(testStorageEngine(), map[string]string{"storage.wiredTiger": "{}"}))
+	assert.Error(t, ValidateStorageEngine(testStorageEngine(), map[string]string{"storage.journal.commitIntervalMs": "100"}))
+}
+
+func TestStorageEngineConfig(t *testing.T) {
+	mdb := testMongoDB()
+	assert.NotContains(t, BuildReplicaSetConfig(mdb), "wiredTiger")
+
+	mdb.Spec.StorageEngine = testStorageEngine()
+	config := BuildReplicaSetConfig(mdb)
+	assert.Contains(t, config, "directoryPerDB: true")
+	assert.Contains(t, config, "blockCompressor: zstd")
+	assert.Contains(t, config, "journalCompressor: zlib")
+	assert.Contains(t, config, "commitIntervalMs: 50")
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.StorageEngine = testStorageEngine()
+	assert.Contains(t, BuildConfigServerConfig(mdbsh), "blockCompressor: zstd")
+	assert.Contains(t, BuildShardConfig(mdbsh, 0), "blockCompressor: zstd")
+	assert.NotContains(t, BuildMongosConfig(mdbsh), "blockCompressor")
+}
+
+func TestStorageLayoutSettings(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Storage.Size = resource.MustParse("10Gi")
+	settings := ReplicaSetSettings(mdb)
+	assert.Equal(t, DefaultBlockCompressor, settings.BlockCompressor)
+	require.NotNil(t, settings.DirectoryPerDB)
+	assert.False(t, *settings.DirectoryPerDB)
+
+	mdb.Spec.AdditionalConfig = map[string]string{
+		"storage.wiredTiger.collectionConfig.blockCompressor": "zlib",
+		"storage.directoryPerDB":                              "true",
+	}
+	settings = ReplicaSetSettings(mdb)
+	assert.Equal(t, "zlib", settings.BlockCompressor)
+	assert.True(t, *settings.DirectoryPerDB)
+
+	// Settings applied before the layout was recorded accept any layout
+	legacy := ReplicaSetSettings(mdb)
+	legacy.BlockCompressor = ""
+	legacy.DirectoryPerDB = nil
+	mdb.Spec.AdditionalConfig = nil
+	mdb.Spec.StorageEngine = testStorageEngine()
+	assert.NoError(t, ValidateSettingsChange(legacy, ReplicaSetSettings(mdb)))
+
+	// Members on ephemeral storage start on new data files
+	mdb.Spec.Storage.Type = StorageTypeEphemeral
+	settings = ReplicaSetSettings(mdb)
+	assert.Empty(t, settings.BlockCompressor)
+	assert.Nil(t, settings.DirectoryPerDB)
+}
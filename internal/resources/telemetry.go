@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// MongoshConfigKey is the ConfigMap key of the global mongosh configuration
+	MongoshConfigKey = "mongosh.conf"
+
+	// mongoshConfigPath is where mongosh reads its global configuration on Linux
+	mongoshConfigPath = "/etc/mongosh.conf"
+
+	// mongoshConfig turns off the usage data mongosh sends and its telemetry notice
+	mongoshConfig = "mongosh:\n  enableTelemetry: false\n"
+)
+
+// TelemetryDisabled reports whether free monitoring and mongosh telemetry are turned off
+func TelemetryDisabled(spec *mongodbv1alpha1.TelemetrySpec) bool {
+	return spec != nil && !spec.Enabled
+}
+
+// applyFreeMonitoringConfig turns off free monitoring in mongod.conf. MongoDB 7.0 removed free
+// monitoring along with its setting, so newer versions are left alone.
+func applyFreeMonitoringConfig(config map[string]interface{}, version string, telemetry *mongodbv1alpha1.TelemetrySpec) {
+	if TelemetryDisabled(telemetry) && !versionAtLeast(version, 7, 0) {
+		setConfigValue(config, "cloud.monitoring.free.state", "off")
+	}
+}
+
+// addMongoshConfig adds the global mongosh configuration to a ConfigMap when telemetry is turned off
+func addMongoshConfig(data map[string]string, telemetry *mongodbv1alpha1.TelemetrySpec) {
+	if TelemetryDisabled(telemetry) {
+		data[MongoshConfigKey] = mongoshConfig
+	}
+}
+
+// applyMongoshConfig mounts the global mongosh configuration of the config volume into a container,
+// so the shells the operator and users start there send no telemetry
+func applyMongoshConfig(template *corev1.PodTemplateSpec, container string, telemetry *mongodbv1alpha1.TelemetrySpec) {
+	if !TelemetryDisabled(telemetry) {
+		return
+	}
+
+	spec := &template.Spec
+	for i := range spec.Volumes {
+		if v := &spec.Volumes[i]; v.Name == configVolumeName && v.ConfigMap != nil {
+			v.ConfigMap.Items = append(v.ConfigMap.Items, corev1.KeyToPath{Key: MongoshConfigKey, Path: MongoshConfigKey})
+		}
+	}
+	for i := range spec.Containers {
+		c := &spec.Containers[i]
+		if c.Name != container {
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, corev1.VolumeMount{
+			Name:      configVolumeName,
+			MountPath: mongoshConfigPath,
+			SubPath:   MongoshConfigKey,
+			ReadOnly:  true,
+		})
+	}
+}
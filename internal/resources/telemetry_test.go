@@ -0,0 +1,86 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func mongoshConfigMounted(template corev1.PodTemplateSpec, container string) bool {
+	for _, m := range findContainer(template.Spec.Containers, container).VolumeMounts {
+		if m.MountPath == mongoshConfigPath && m.SubPath == MongoshConfigKey {
+			return true
+		}
+	}
+	return false
+}
+
+func TestTelemetryDisabled(t *testing.T) {
+	assert.False(t, TelemetryDisabled(nil))
+	assert.False(t, TelemetryDisabled(&mongodbv1alpha1.TelemetrySpec{Enabled: true}))
+	assert.True(t, TelemetryDisabled(&mongodbv1alpha1.TelemetrySpec{Enabled: false}))
+}
+
+func TestFreeMonitoringConfig(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Version.Version = "6.0.14"
+	assert.NotContains(t, BuildReplicaSetConfig(mdb), "cloud")
+
+	mdb.Spec.Telemetry = &mongodbv1alpha1.TelemetrySpec{Enabled: false}
+	assert.Contains(t, BuildReplicaSetConfig(mdb), `cloud:
+  monitoring:
+    free:
+      state: "off"
+`)
+
+	// MongoDB 7.0 removed free monitoring and its setting
+	mdb.Spec.Version.Version = "7.0"
+	assert.NotContains(t, BuildReplicaSetConfig(mdb), "cloud")
+
+	assert.Error(t, ValidateAdditionalConfig(map[string]string{"cloud.monitoring.free.state": "runtime"}))
+}
+
+func TestMongoshTelemetryConfig(t *testing.T) {
+	mdb := testMongoDB()
+	assert.False(t, mongoshConfigMounted(BuildReplicaSetStatefulSet(mdb).Spec.Template, "mongodb"))
+	assert.NotContains(t, BuildMongodConfigMap(mdb.Name, mdb.Name, mdb.Namespace, "", nil).Data, MongoshConfigKey)
+
+	mdb.Spec.Telemetry = &mongodbv1alpha1.TelemetrySpec{Enabled: false}
+	template := BuildReplicaSetStatefulSet(mdb).Spec.Template
+	assert.True(t, mongoshConfigMounted(template, "mongodb"))
+	for _, v := range template.Spec.Volumes {
+		if v.Name == configVolumeName {
+			require.NotNil(t, v.ConfigMap)
+			assert.Contains(t, v.ConfigMap.Items, corev1.KeyToPath{Key: MongoshConfigKey, Path: MongoshConfigKey})
+		}
+	}
+	cm := BuildMongodConfigMap(mdb.Name, mdb.Name, mdb.Namespace, "", mdb.Spec.Telemetry)
+	assert.Equal(t, "mongosh:\n  enableTelemetry: false\n", cm.Data[MongoshConfigKey])
+
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Telemetry = &mongodbv1alpha1.TelemetrySpec{Enabled: false}
+	assert.True(t, mongoshConfigMounted(BuildConfigServerStatefulSet(mdbsh).Spec.Template, "mongodb"))
+	assert.True(t, mongoshConfigMounted(BuildShardStatefulSet(mdbsh, 0).Spec.Template, "mongodb"))
+	assert.True(t, mongoshConfigMounted(BuildMongosDeployment(mdbsh).Spec.Template, "mongos"))
+	assert.Contains(t, BuildMongosConfigMap(mdbsh).Data, MongoshConfigKey)
+}
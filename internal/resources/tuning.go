@@ -0,0 +1,67 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	nodeTuningContainerName = "node-tuning"
+	nodeTuningImage         = "busybox:1.36"
+	defaultMaxMapCount      = 262144
+)
+
+// applyNodeTuning adds a privileged init container disabling transparent hugepages and raising
+// vm.max_map_count on the node, as recommended by the MongoDB production notes. Both settings are
+// node wide, /sys is writable in a privileged container so no host path is needed.
+func applyNodeTuning(podSpec *corev1.PodSpec, pod *mongodbv1alpha1.PodSpec) {
+	if pod == nil || pod.NodeTuning == nil || !pod.NodeTuning.Enabled {
+		return
+	}
+
+	image := pod.NodeTuning.Image
+	if image == "" {
+		image = nodeTuningImage
+	}
+	maxMapCount := pod.NodeTuning.MaxMapCount
+	if maxMapCount == 0 {
+		maxMapCount = defaultMaxMapCount
+	}
+
+	script := fmt.Sprintf(`set -e
+for f in /sys/kernel/mm/transparent_hugepage/enabled /sys/kernel/mm/transparent_hugepage/defrag; do
+  if [ -w "$f" ]; then echo never > "$f"; fi
+done
+sysctl -w vm.max_map_count=%d`, maxMapCount)
+
+	// Tuning runs first so mongod never starts on an untuned node
+	podSpec.InitContainers = append([]corev1.Container{{
+		Name:    nodeTuningContainerName,
+		Image:   image,
+		Command: []string{"sh", "-c", script},
+		SecurityContext: &corev1.SecurityContext{
+			Privileged:   boolPtr(true),
+			RunAsUser:    int64Ptr(0),
+			RunAsNonRoot: boolPtr(false),
+		},
+	}}, podSpec.InitContainers...)
+}
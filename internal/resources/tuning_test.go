@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestApplyNodeTuning(t *testing.T) {
+	mdb := testMongoDB()
+	assert.Equal(t, []string{"copy-keyfile"}, containerNames(BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.InitContainers))
+
+	mdb.Spec.Pod = &mongodbv1alpha1.PodSpec{NodeTuning: &mongodbv1alpha1.NodeTuningSpec{Enabled: true}}
+	pod := BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec
+
+	assert.Equal(t, []string{"node-tuning", "copy-keyfile"}, containerNames(pod.InitContainers))
+	tuning := pod.InitContainers[0]
+	assert.Equal(t, "busybox:1.36", tuning.Image)
+	assert.True(t, *tuning.SecurityContext.Privileged)
+	assert.Equal(t, int64(0), *tuning.SecurityContext.RunAsUser)
+	require.Len(t, tuning.Command, 3)
+	assert.Contains(t, tuning.Command[2], "transparent_hugepage/enabled")
+	assert.Contains(t, tuning.Command[2], "vm.max_map_count=262144")
+
+	mdb.Spec.Pod.NodeTuning.MaxMapCount = 1048576
+	mdb.Spec.Pod.NodeTuning.Image = "registry.example.com/busybox:1.36"
+	tuning = BuildReplicaSetStatefulSet(mdb).Spec.Template.Spec.InitContainers[0]
+	assert.Equal(t, "registry.example.com/busybox:1.36", tuning.Image)
+	assert.Contains(t, tuning.Command[2], "vm.max_map_count=1048576")
+}
+
+func TestApplyNodeTuningSharded(t *testing.T) {
+	tuned := &mongodbv1alpha1.PodSpec{NodeTuning: &mongodbv1alpha1.NodeTuningSpec{Enabled: true}}
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.ConfigServer.Pod = tuned
+	mdbsh.Spec.Shards.Pod = tuned
+	mdbsh.Spec.Mongos.Pod = tuned
+
+	assert.Equal(t, "node-tuning", BuildConfigServerStatefulSet(mdbsh).Spec.Template.Spec.InitContainers[0].Name)
+	assert.Equal(t, "node-tuning", BuildShardStatefulSet(mdbsh, 1).Spec.Template.Spec.InitContainers[0].Name)
+	assert.NotContains(t, containerNames(BuildMongosDeployment(mdbsh).Spec.Template.Spec.InitContainers), "node-tuning")
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+const (
+	// UpdateStrategyRollingUpdate replaces the members one after the other
+	UpdateStrategyRollingUpdate = "RollingUpdate"
+	// UpdateStrategyCanary replaces one member first and the others once it soaked or was approved
+	UpdateStrategyCanary = "Canary"
+
+	// ApproveUpdateAnnotation set to the revision in status.rollout releases a canary update held
+	// for approval. Approvals of earlier revisions do not release later ones.
+	ApproveUpdateAnnotation = "mongodb.keiailab.com/approve-update"
+
+	// Rollout phases of a canary update
+	RolloutCanary           = "Canary"
+	RolloutSoaking          = "Soaking"
+	RolloutAwaitingApproval = "AwaitingApproval"
+	RolloutUpdating         = "Updating"
+	RolloutCompleted        = "Completed"
+
+	defaultCanarySoakSeconds = 600
+
+	// revisionLabel is the label the StatefulSet controller sets to the revision of a pod
+	revisionLabel = "controller-revision-hash"
+)
+
+// CanaryUpdate reports whether template changes are rolled out to a canary member first. A
+// standalone mongod or a single local member has nothing to hold back.
+func CanaryUpdate(mdb *mongodbv1alpha1.MongoDB) bool {
+	spec := mdb.Spec.UpdateStrategy
+	if spec == nil || spec.Type != UpdateStrategyCanary || Standalone(mdb) {
+		return false
+	}
+	_, members := LocalMembers(mdb)
+	return members > 1
+}
+
+// CanarySoak returns how long the canary has to stay ready before the other members are updated
+func CanarySoak(spec *mongodbv1alpha1.UpdateStrategySpec) time.Duration {
+	if spec == nil || spec.Canary == nil || spec.Canary.SoakSeconds == nil {
+		return defaultCanarySoakSeconds * time.Second
+	}
+	return time.Duration(*spec.Canary.SoakSeconds) * time.Second
+}
+
+// ValidateUpdateStrategy checks that replacing maxUnavailable members at once leaves a majority of
+// the voting members up, the replica set has no primary otherwise
+func ValidateUpdateStrategy(mdb *mongodbv1alpha1.MongoDB) error {
+	spec := mdb.Spec.UpdateStrategy
+	if spec == nil || spec.MaxUnavailable == nil || *spec.MaxUnavailable <= 1 || Standalone(mdb) {
+		return nil
+	}
+	votes := VotingMembers(mdb)
+	if allowed := (votes - 1) / 2; *spec.MaxUnavailable > allowed {
+		return fmt.Errorf("updateStrategy maxUnavailable %d leaves no majority of the %d voting members, at most %d may be unavailable",
+			*spec.MaxUnavailable, votes, max(allowed, 1))
+	}
+	return nil
+}
+
+// applyUpdateStrategy sets maxUnavailable and, during a canary update, the partition holding the
+// members below the highest ordinal on their revision until the rollout is released. The partition
+// counts the pods of the StatefulSet, not their ordinals.
+func applyUpdateStrategy(sts *appsv1.StatefulSet, mdb *mongodbv1alpha1.MongoDB) {
+	spec := mdb.Spec.UpdateStrategy
+	if spec == nil {
+		return
+	}
+
+	rolling := &appsv1.RollingUpdateStatefulSetStrategy{}
+	if spec.MaxUnavailable != nil {
+		maxUnavailable := intstr.FromInt32(*spec.MaxUnavailable)
+		rolling.MaxUnavailable = &maxUnavailable
+	}
+	if CanaryUpdate(mdb) && (mdb.Status.Rollout == nil || mdb.Status.Rollout.Phase != RolloutUpdating) {
+		_, members := LocalMembers(mdb)
+		rolling.Partition = int32Ptr(members - 1)
+	}
+	if rolling.MaxUnavailable != nil || rolling.Partition != nil {
+		sts.Spec.UpdateStrategy.RollingUpdate = rolling
+	}
+}
+
+// AdvanceRollout moves a canary update on from the state of the StatefulSet and of the canary, the
+// member with the highest ordinal. A new revision starts over with the canary, the canary turning
+// unready on its way sends the rollout back to waiting for it.
+func AdvanceRollout(current *mongodbv1alpha1.RolloutStatus, spec *mongodbv1alpha1.UpdateStrategySpec, sts *appsv1.StatefulSet,
+	canary *corev1.Pod, annotations map[string]string, now time.Time) *mongodbv1alpha1.RolloutStatus {
+	if sts == nil || sts.Status.UpdateRevision == "" {
+		return current
+	}
+
+	revision := sts.Status.UpdateRevision
+	if revision == sts.Status.CurrentRevision {
+		if current == nil || current.Phase == RolloutCompleted {
+			return current
+		}
+		completed := current.DeepCopy()
+		completed.Phase = RolloutCompleted
+		return completed
+	}
+
+	rollout := current.DeepCopy()
+	if rollout == nil || rollout.Revision != revision {
+		started := metav1.NewTime(now)
+		rollout = &mongodbv1alpha1.RolloutStatus{Revision: revision, Phase: RolloutCanary, StartedAt: &started}
+	}
+	if rollout.Phase == RolloutUpdating || rollout.Phase == RolloutCompleted {
+		return rollout
+	}
+
+	if !canaryUpdated(canary, revision) {
+		rollout.Phase = RolloutCanary
+		rollout.CanaryReadyAt = nil
+		return rollout
+	}
+	if rollout.CanaryReadyAt == nil {
+		ready := metav1.NewTime(now)
+		rollout.CanaryReadyAt = &ready
+	}
+
+	switch {
+	case spec != nil && spec.Canary != nil && spec.Canary.ManualApproval:
+		rollout.Phase = RolloutAwaitingApproval
+		if annotations[ApproveUpdateAnnotation] == revision {
+			rollout.Phase = RolloutUpdating
+		}
+	case now.Sub(rollout.CanaryReadyAt.Time) >= CanarySoak(spec):
+		rollout.Phase = RolloutUpdating
+	default:
+		rollout.Phase = RolloutSoaking
+	}
+	return rollout
+}
+
+// canaryUpdated reports whether the canary runs the revision and is ready
+func canaryUpdated(canary *corev1.Pod, revision string) bool {
+	return canary != nil && canary.DeletionTimestamp == nil && canary.Labels[revisionLabel] == revision && PodReady(canary)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+func TestUpdateStrategyRollingUpdate(t *testing.T) {
+	mdb := testMongoDB()
+	assert.Nil(t, BuildReplicaSetStatefulSet(mdb).Spec.UpdateStrategy.RollingUpdate)
+
+	mdb.Spec.Members = 5
+	mdb.Spec.UpdateStrategy = &mongodbv1alpha1.UpdateStrategySpec{Type: UpdateStrategyRollingUpdate, MaxUnavailable: int32Ptr(2)}
+	rolling := BuildReplicaSetStatefulSet(mdb).Spec.UpdateStrategy.RollingUpdate
+	assert.Equal(t, intstr.FromInt32(2), *rolling.MaxUnavailable)
+	assert.Nil(t, rolling.Partition)
+	assert.NoError(t, ValidateUpdateStrategy(mdb))
+
+	mdb.Spec.Members = 3
+	assert.ErrorContains(t, ValidateUpdateStrategy(mdb), "at most 1 may be unavailable")
+}
+
+func TestUpdateStrategyCanaryPartition(t *testing.T) {
+	mdb := testMongoDB()
+	mdb.Spec.Members = 3
+	mdb.Spec.UpdateStrategy = &mongodbv1alpha1.UpdateStrategySpec{Type: UpdateStrategyCanary}
+
+	// Only the highest ordinal member gets a new revision until the rollout is released
+	assert.Equal(t, int32(2), *BuildReplicaSetStatefulSet(mdb).Spec.UpdateStrategy.RollingUpdate.Partition)
+
+	mdb.Status.Rollout = &mongodbv1alpha1.RolloutStatus{Phase: RolloutSoaking}
+	assert.Equal(t, int32(2), *BuildReplicaSetStatefulSet(mdb).Spec.UpdateStrategy.RollingUpdate.Partition)
+
+	mdb.Status.Rollout.Phase = RolloutUpdating
+	assert.Nil(t, BuildReplicaSetStatefulSet(mdb).Spec.UpdateStrategy.RollingUpdate)
+
+	// A single member has no canary
+	mdb.Status.Rollout = nil
+	mdb.Spec.Members = 1
+	assert.False(t, CanaryUpdate(mdb))
+}
+
+func canaryStatefulSet(current, update string) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{Status: appsv1.StatefulSetStatus{CurrentRevision: current, UpdateRevision: update}}
+}
+
+func canaryPod(revision string, ready bool) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{revisionLabel: revision}},
+		Status:     corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}},
+	}
+}
+
+func TestAdvanceRolloutSoak(t *testing.T) {
+	spec := &mongodbv1alpha1.UpdateStrategySpec{Type: UpdateStrategyCanary,
+		Canary: &mongodbv1alpha1.CanaryUpdateSpec{SoakSeconds: int32Ptr(300)}}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Nothing to roll out
+	assert.Nil(t, AdvanceRollout(nil, spec, canaryStatefulSet("rev-1", "rev-1"), canaryPod("rev-1", true), nil, now))
+
+	sts := canaryStatefulSet("rev-1", "rev-2")
+	rollout := AdvanceRollout(nil, spec, sts, canaryPod("rev-1", true), nil, now)
+	assert.Equal(t, "rev-2", rollout.Revision)
+	assert.Equal(t, RolloutCanary, rollout.Phase)
+
+	rollout = AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", false), nil, now)
+	assert.Equal(t, RolloutCanary, rollout.Phase)
+
+	rollout = AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", true), nil, now)
+	assert.Equal(t, RolloutSoaking, rollout.Phase)
+	assert.Equal(t, now, rollout.CanaryReadyAt.Time)
+
+	rollout = AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", true), nil, now.Add(4*time.Minute))
+	assert.Equal(t, RolloutSoaking, rollout.Phase)
+
+	// The canary failing during the soak starts it over
+	failed := AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", false), nil, now.Add(4*time.Minute))
+	assert.Equal(t, RolloutCanary, failed.Phase)
+	assert.Nil(t, failed.CanaryReadyAt)
+
+	rollout = AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", true), nil, now.Add(5*time.Minute))
+	assert.Equal(t, RolloutUpdating, rollout.Phase)
+
+	// The other members being replaced take the canary down, the rollout goes on
+	rollout = AdvanceRollout(rollout, spec, sts, nil, nil, now.Add(6*time.Minute))
+	assert.Equal(t, RolloutUpdating, rollout.Phase)
+
+	rollout = AdvanceRollout(rollout, spec, canaryStatefulSet("rev-2", "rev-2"), canaryPod("rev-2", true), nil, now.Add(10*time.Minute))
+	assert.Equal(t, RolloutCompleted, rollout.Phase)
+
+	// The next revision starts over with the canary
+	rollout = AdvanceRollout(rollout, spec, canaryStatefulSet("rev-2", "rev-3"), canaryPod("rev-2", true), nil, now.Add(time.Hour))
+	assert.Equal(t, "rev-3", rollout.Revision)
+	assert.Equal(t, RolloutCanary, rollout.Phase)
+}
+
+func TestAdvanceRolloutManualApproval(t *testing.T) {
+	spec := &mongodbv1alpha1.UpdateStrategySpec{Type: UpdateStrategyCanary,
+		Canary: &mongodbv1alpha1.CanaryUpdateSpec{SoakSeconds: int32Ptr(0), ManualApproval: true}}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sts := canaryStatefulSet("rev-1", "rev-2")
+
+	rollout := AdvanceRollout(nil, spec, sts, canaryPod("rev-2", true), nil, now)
+	assert.Equal(t, RolloutAwaitingApproval, rollout.Phase)
+
+	// An approval of another revision does not release this one
+	rollout = AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", true), map[string]string{ApproveUpdateAnnotation: "rev-1"}, now.Add(time.Hour))
+	assert.Equal(t, RolloutAwaitingApproval, rollout.Phase)
+
+	rollout = AdvanceRollout(rollout, spec, sts, canaryPod("rev-2", true), map[string]string{ApproveUpdateAnnotation: "rev-2"}, now.Add(time.Hour))
+	assert.Equal(t, RolloutUpdating, rollout.Phase)
+}
+
+func TestCanarySoak(t *testing.T) {
+	assert.Equal(t, 10*time.Minute, CanarySoak(nil))
+	assert.Equal(t, time.Minute, CanarySoak(&mongodbv1alpha1.UpdateStrategySpec{
+		Canary: &mongodbv1alpha1.CanaryUpdateSpec{SoakSeconds: int32Ptr(60)}}))
+}
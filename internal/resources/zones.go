@@ -0,0 +1,123 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// ZoneLabel is the pod label carrying the zone of a shard
+const ZoneLabel = "mongodb.keiailab.com/zone"
+
+// ValidateShardZones checks the zone and placement configuration of the shards
+func ValidateShardZones(spec mongodbv1alpha1.ShardSpec) error {
+	zoneOf := make(map[int32]string)
+	names := make(map[string]bool, len(spec.Zones))
+	for _, zone := range spec.Zones {
+		if names[zone.Name] {
+			return fmt.Errorf("duplicate zone %q", zone.Name)
+		}
+		names[zone.Name] = true
+
+		for _, shard := range zone.Shards {
+			if shard < 0 || shard >= spec.Count {
+				return fmt.Errorf("zone %q references shard %d, but only %d shards exist", zone.Name, shard, spec.Count)
+			}
+			if other, ok := zoneOf[shard]; ok {
+				return fmt.Errorf("shard %d is assigned to both zone %q and zone %q", shard, other, zone.Name)
+			}
+			zoneOf[shard] = zone.Name
+		}
+
+		for _, r := range zone.Ranges {
+			if r.Namespace == "" {
+				return fmt.Errorf("zone %q range requires a namespace", zone.Name)
+			}
+			if !json.Valid([]byte(r.Min)) || !json.Valid([]byte(r.Max)) {
+				return fmt.Errorf("zone %q range bounds must be valid Extended JSON documents", zone.Name)
+			}
+		}
+	}
+
+	seen := make(map[int32]bool, len(spec.Placement))
+	for _, p := range spec.Placement {
+		if p.Shard >= spec.Count {
+			return fmt.Errorf("placement references shard %d, but only %d shards exist", p.Shard, spec.Count)
+		}
+		if seen[p.Shard] {
+			return fmt.Errorf("duplicate placement for shard %d", p.Shard)
+		}
+		seen[p.Shard] = true
+	}
+
+	return nil
+}
+
+// ShardZone returns the zone a shard is assigned to, or nil
+func ShardZone(spec mongodbv1alpha1.ShardSpec, shardIndex int32) *mongodbv1alpha1.ShardZone {
+	for i := range spec.Zones {
+		for _, shard := range spec.Zones[i].Shards {
+			if shard == shardIndex {
+				return &spec.Zones[i]
+			}
+		}
+	}
+	return nil
+}
+
+// applyShardPlacement applies the zone and per-shard placement of a shard to its pod template.
+// The per-shard placement wins over the zone for node selector keys and affinity rules.
+func applyShardPlacement(template *corev1.PodTemplateSpec, spec mongodbv1alpha1.ShardSpec, shardIndex int32) {
+	pod := &template.Spec
+
+	if zone := ShardZone(spec, shardIndex); zone != nil {
+		// Only the template gets the label, the StatefulSet selector is immutable
+		template.Labels = maps.Clone(template.Labels)
+		template.Labels[ZoneLabel] = zone.Name
+
+		pod.NodeSelector = mergeStringMaps(pod.NodeSelector, zone.NodeSelector)
+		pod.Tolerations = append(pod.Tolerations, zone.Tolerations...)
+	}
+
+	for _, p := range spec.Placement {
+		if p.Shard != shardIndex {
+			continue
+		}
+
+		pod.NodeSelector = mergeStringMaps(pod.NodeSelector, p.NodeSelector)
+		pod.Tolerations = append(pod.Tolerations, p.Tolerations...)
+		mergeAffinity(pod, p.Affinity)
+	}
+}
+
+func mergeStringMaps(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = make(map[string]string, len(overrides))
+	}
+	maps.Copy(merged, overrides)
+	return merged
+}
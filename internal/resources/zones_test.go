@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+)
+
+// testZonedShards returns 3 shards in the EU and US zones, shard 1 with its own placement
+func testZonedShards() mongodbv1alpha1.ShardSpec {
+	return mongodbv1alpha1.ShardSpec{
+		Count:           3,
+		MembersPerShard: 3,
+		Zones: []mongodbv1alpha1.ShardZone{
+			{
+				Name:         "EU",
+				Shards:       []int32{0, 1},
+				NodeSelector: map[string]string{"topology.kubernetes.io/region": "eu-west-1"},
+				Ranges: []mongodbv1alpha1.ZoneKeyRange{{
+					Namespace: "app.users",
+					Min:       `{"region": "EU"}`,
+					Max:       `{"region": "EU~"}`,
+				}},
+			},
+			{
+				Name:         "US",
+				Shards:       []int32{2},
+				NodeSelector: map[string]string{"topology.kubernetes.io/region": "us-east-1"},
+			},
+		},
+		Placement: []mongodbv1alpha1.ShardPlacement{{
+			Shard:        1,
+			NodeSelector: map[string]string{"topology.kubernetes.io/zone": "eu-west-1b"},
+			Tolerations:  []corev1.Toleration{{Key: "dedicated", Value: "mongodb", Effect: corev1.TaintEffectNoSchedule}},
+		}},
+	}
+}
+
+func TestValidateShardZones(t *testing.T) {
+	assert.NoError(t, ValidateShardZones(testZonedShards()))
+
+	outOfRange := testZonedShards()
+	outOfRange.Zones[1].Shards = []int32{3}
+	assert.Error(t, ValidateShardZones(outOfRange))
+
+	twoZones := testZonedShards()
+	twoZones.Zones[1].Shards = []int32{1}
+	assert.Error(t, ValidateShardZones(twoZones))
+
+	badRange := testZonedShards()
+	badRange.Zones[0].Ranges[0].Min = "region: EU"
+	assert.Error(t, ValidateShardZones(badRange))
+
+	badPlacement := testZonedShards()
+	badPlacement.Placement[0].Shard = 5
+	assert.Error(t, ValidateShardZones(badPlacement))
+}
+
+func TestBuildShardStatefulSetPlacement(t *testing.T) {
+	mdbsh := testMongoDBSharded()
+	mdbsh.Spec.Shards = testZonedShards()
+
+	sts := BuildShardStatefulSet(mdbsh, 0)
+	pod := sts.Spec.Template.Spec
+	assert.Equal(t, "EU", sts.Spec.Template.Labels[ZoneLabel])
+	assert.NotContains(t, sts.Spec.Selector.MatchLabels, ZoneLabel)
+	assert.Equal(t, map[string]string{"topology.kubernetes.io/region": "eu-west-1"}, pod.NodeSelector)
+	assert.Empty(t, pod.Tolerations)
+
+	// Per-shard placement is merged over the zone
+	sts = BuildShardStatefulSet(mdbsh, 1)
+	pod = sts.Spec.Template.Spec
+	assert.Equal(t, map[string]string{
+		"topology.kubernetes.io/region": "eu-west-1",
+		"topology.kubernetes.io/zone":   "eu-west-1b",
+	}, pod.NodeSelector)
+	require.Len(t, pod.Tolerations, 1)
+	assert.Equal(t, "dedicated", pod.Tolerations[0].Key)
+	require.NotNil(t, pod.Affinity)
+	assert.NotNil(t, pod.Affinity.PodAntiAffinity)
+
+	sts = BuildShardStatefulSet(mdbsh, 2)
+	assert.Equal(t, "US", sts.Spec.Template.Labels[ZoneLabel])
+
+	// Shards outside any zone are unchanged
+	mdbsh.Spec.Shards.Zones = nil
+	mdbsh.Spec.Shards.Placement = nil
+	sts = BuildShardStatefulSet(mdbsh, 0)
+	assert.NotContains(t, sts.Spec.Template.Labels, ZoneLabel)
+	assert.Nil(t, sts.Spec.Template.Spec.NodeSelector)
+}
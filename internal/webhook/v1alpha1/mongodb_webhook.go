@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// SetupMongoDBWebhookWithManager registers the validating webhook of MongoDB
+func SetupMongoDBWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&mongodbv1alpha1.MongoDB{}).
+		WithValidator(&MongoDBCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-mongodb-keiailab-com-v1alpha1-mongodb,mutating=false,failurePolicy=fail,sideEffects=None,groups=mongodb.keiailab.com,resources=mongodbs,verbs=update,versions=v1alpha1,name=vmongodb-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// MongoDBCustomValidator rejects updates of a MongoDB that can not be rolled out in place, unless
+// the force-update annotation is set. The controller checks the same changes against the applied
+// settings, for clusters updated while the webhook is not installed.
+type MongoDBCustomValidator struct{}
+
+var _ admission.CustomValidator = &MongoDBCustomValidator{}
+
+// ValidateCreate accepts every new MongoDB, the controller validates the spec
+func (v *MongoDBCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	if _, ok := obj.(*mongodbv1alpha1.MongoDB); !ok {
+		return nil, fmt.Errorf("expected a MongoDB object but got %T", obj)
+	}
+	return nil, nil
+}
+
+// ValidateUpdate rejects risky changes of the spec
+func (v *MongoDBCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldMDB, ok := oldObj.(*mongodbv1alpha1.MongoDB)
+	if !ok {
+		return nil, fmt.Errorf("expected a MongoDB object for the old object but got %T", oldObj)
+	}
+	mdb, ok := newObj.(*mongodbv1alpha1.MongoDB)
+	if !ok {
+		return nil, fmt.Errorf("expected a MongoDB object for the new object but got %T", newObj)
+	}
+
+	return validateSettingsChange(resources.ReplicaSetSettings(oldMDB), resources.ReplicaSetSettings(mdb), mdb.Annotations)
+}
+
+// ValidateDelete accepts every deletion
+func (v *MongoDBCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateSettingsChange rejects a risky change, or only warns about it when it is forced
+func validateSettingsChange(old, desired *mongodbv1alpha1.AppliedSettings, annotations map[string]string) (admission.Warnings, error) {
+	err := resources.ValidateSettingsChange(old, desired)
+	if err == nil {
+		return nil, nil
+	}
+	if resources.ForceUpdate(annotations) {
+		return admission.Warnings{fmt.Sprintf("forced by the %s annotation: %v", resources.ForceUpdateAnnotation, err)}, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+func TestMongoDBValidateUpdate(t *testing.T) {
+	old := &mongodbv1alpha1.MongoDB{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-mongodb", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBSpec{
+			Members:        3,
+			Version:        mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+			ReplicaSetName: "rs0",
+		},
+	}
+	validator := &MongoDBCustomValidator{}
+
+	scaled := old.DeepCopy()
+	scaled.Spec.Members = 5
+	warnings, err := validator.ValidateUpdate(context.Background(), old, scaled)
+	assert.NoError(t, err)
+	assert.Empty(t, warnings)
+
+	renamed := old.DeepCopy()
+	renamed.Spec.ReplicaSetName = "rs1"
+	_, err = validator.ValidateUpdate(context.Background(), old, renamed)
+	assert.ErrorContains(t, err, "replicaSetName rs1 replaces rs0")
+
+	renamed.Annotations = map[string]string{resources.ForceUpdateAnnotation: "true"}
+	warnings, err = validator.ValidateUpdate(context.Background(), old, renamed)
+	assert.NoError(t, err)
+	assert.Len(t, warnings, 1)
+}
+
+func TestMongoDBShardedValidateUpdate(t *testing.T) {
+	old := &mongodbv1alpha1.MongoDBSharded{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-sharded", Namespace: "default"},
+		Spec: mongodbv1alpha1.MongoDBShardedSpec{
+			Version: mongodbv1alpha1.MongoDBVersion{Version: "8.0"},
+		},
+	}
+	old.Spec.ConfigServer.Storage.Size = resource.MustParse("10Gi")
+	validator := &MongoDBShardedCustomValidator{}
+
+	downgraded := old.DeepCopy()
+	downgraded.Spec.Version.Version = "7.0"
+	downgraded.Spec.ConfigServer.Storage.Size = resource.MustParse("5Gi")
+	_, err := validator.ValidateUpdate(context.Background(), old, downgraded)
+	assert.ErrorContains(t, err, "version 7.0 downgrades 8.0")
+	assert.ErrorContains(t, err, "ConfigServer storage size 5Gi is smaller than 10Gi")
+
+	_, err = validator.ValidateUpdate(context.Background(), old, &mongodbv1alpha1.MongoDB{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	mongodbv1alpha1 "github.com/keiailab/mongodb-operator/api/v1alpha1"
+	"github.com/keiailab/mongodb-operator/internal/resources"
+)
+
+// SetupMongoDBShardedWebhookWithManager registers the validating webhook of MongoDBSharded
+func SetupMongoDBShardedWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&mongodbv1alpha1.MongoDBSharded{}).
+		WithValidator(&MongoDBShardedCustomValidator{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/validate-mongodb-keiailab-com-v1alpha1-mongodbsharded,mutating=false,failurePolicy=fail,sideEffects=None,groups=mongodb.keiailab.com,resources=mongodbshardeds,verbs=update,versions=v1alpha1,name=vmongodbsharded-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// MongoDBShardedCustomValidator rejects updates of a MongoDBSharded that can not be rolled out in
+// place, unless the force-update annotation is set
+type MongoDBShardedCustomValidator struct{}
+
+var _ admission.CustomValidator = &MongoDBShardedCustomValidator{}
+
+// ValidateCreate accepts every new MongoDBSharded, the controller validates the spec
+func (v *MongoDBShardedCustomValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	if _, ok := obj.(*mongodbv1alpha1.MongoDBSharded); !ok {
+		return nil, fmt.Errorf("expected a MongoDBSharded object but got %T", obj)
+	}
+	return nil, nil
+}
+
+// ValidateUpdate rejects risky changes of the spec
+func (v *MongoDBShardedCustomValidator) ValidateUpdate(_ context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldMDBSH, ok := oldObj.(*mongodbv1alpha1.MongoDBSharded)
+	if !ok {
+		return nil, fmt.Errorf("expected a MongoDBSharded object for the old object but got %T", oldObj)
+	}
+	mdbsh, ok := newObj.(*mongodbv1alpha1.MongoDBSharded)
+	if !ok {
+		return nil, fmt.Errorf("expected a MongoDBSharded object for the new object but got %T", newObj)
+	}
+
+	return validateSettingsChange(resources.ShardedSettings(oldMDBSH), resources.ShardedSettings(mdbsh), mdbsh.Annotations)
+}
+
+// ValidateDelete accepts every deletion
+func (v *MongoDBShardedCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package connstring builds the MongoDB connection strings of the operator: the URIs of the
+// connection secrets, the status of the clusters and the backup Jobs.
+//
+//	uri := connstring.ConnString{
+//		Hosts:      []string{"db-0.db-headless:27017", "db-1.db-headless:27017"},
+//		Username:   "admin",
+//		Password:   password,
+//		AuthSource: "admin",
+//		ReplicaSet: "rs0",
+//		TLS:        true,
+//	}.String()
+package connstring
+
+import (
+	"net/url"
+	"strings"
+)
+
+const (
+	// Scheme is the scheme of URIs listing their hosts
+	Scheme = "mongodb"
+	// SRVScheme is the scheme of URIs whose hosts are looked up in a DNS SRV record
+	SRVScheme = "mongodb+srv"
+)
+
+// Read preferences of the readPreference option
+const (
+	ReadPreferencePrimary            = "primary"
+	ReadPreferencePrimaryPreferred   = "primaryPreferred"
+	ReadPreferenceSecondary          = "secondary"
+	ReadPreferenceSecondaryPreferred = "secondaryPreferred"
+	ReadPreferenceNearest            = "nearest"
+)
+
+// ConnString describes a MongoDB connection string. Empty fields leave their option out.
+type ConnString struct {
+	// SRV builds a mongodb+srv:// URI, Hosts then holds the DNS name of the SRV record without a port
+	SRV bool
+	// Hosts lists the host:port of the members or routers to seed drivers with
+	Hosts []string
+
+	Username string
+	Password string
+
+	// Database is the default database of the client, the path of the URI
+	Database string
+	// AuthSource is the database holding the credentials of the user
+	AuthSource string
+	// ReplicaSet is the name of the replica set, empty for mongos and standalone servers
+	ReplicaSet string
+	// TLS requires TLS connections, TLSCAFile is the CA bundle verifying the servers
+	TLS       bool
+	TLSCAFile string
+	// ExplicitTLS writes tls=false when TLS is off. Drivers default mongodb+srv:// URIs to TLS,
+	// so they need it to reach servers without TLS.
+	ExplicitTLS bool
+	// ReadPreference is one of the ReadPreference constants
+	ReadPreference string
+	// DirectConnection connects to the single host only instead of discovering the topology
+	DirectConnection bool
+}
+
+// String returns the URI. Credentials are percent-encoded, so passwords may contain any
+// character, including ':', '@' and '/'.
+func (c ConnString) String() string {
+	var b strings.Builder
+	if c.SRV {
+		b.WriteString(SRVScheme)
+	} else {
+		b.WriteString(Scheme)
+	}
+	b.WriteString("://")
+
+	if c.Username != "" {
+		b.WriteString(escape(c.Username))
+		if c.Password != "" {
+			b.WriteString(":")
+			b.WriteString(escape(c.Password))
+		}
+		b.WriteString("@")
+	}
+
+	b.WriteString(strings.Join(c.Hosts, ","))
+	b.WriteString("/")
+	b.WriteString(url.PathEscape(c.Database))
+
+	if options := c.options().Encode(); options != "" {
+		b.WriteString("?")
+		b.WriteString(options)
+	}
+	return b.String()
+}
+
+// options returns the URI options, url.Values encodes them sorted by name
+func (c ConnString) options() url.Values {
+	options := url.Values{}
+	if c.AuthSource != "" {
+		options.Set("authSource", c.AuthSource)
+	}
+	if c.ReplicaSet != "" {
+		options.Set("replicaSet", c.ReplicaSet)
+	}
+	if c.TLS {
+		options.Set("tls", "true")
+	} else if c.ExplicitTLS {
+		options.Set("tls", "false")
+	}
+	if c.TLSCAFile != "" {
+		options.Set("tlsCAFile", c.TLSCAFile)
+	}
+	if c.ReadPreference != "" {
+		options.Set("readPreference", c.ReadPreference)
+	}
+	if c.DirectConnection {
+		options.Set("directConnection", "true")
+	}
+	return options
+}
+
+// escape percent-encodes every character of a user name or password but the unreserved ones
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
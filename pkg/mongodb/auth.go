@@ -0,0 +1,381 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// UserRole represents a MongoDB role assignment
+type UserRole struct {
+	Role string `json:"role"`
+	DB   string `json:"db"`
+}
+
+// MongoUser represents a MongoDB user
+type MongoUser struct {
+	Username string     `json:"user"`
+	Password string     `json:"pwd"`
+	Database string     `json:"db"` // Database where user is defined
+	Roles    []UserRole `json:"roles"`
+}
+
+// AuthManager manages MongoDB authentication
+type AuthManager interface {
+	CreateAdminUserInContainer(ctx context.Context, podName, namespace, container, username, password string, port int) error
+	CreateUser(ctx context.Context, podName, namespace, adminUser, adminPassword string, user MongoUser, port int) error
+	UserExistsInContainer(ctx context.Context, podName, namespace, container, username, database string, port int) (bool, error)
+	UserExistsWithAuth(ctx context.Context, podName, namespace, adminUser, adminPassword, username, database string, port int) (bool, error)
+	UpdatePassword(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB, newPassword string, port int) error
+	UpdatePasswordWithKeyfile(ctx context.Context, podName, namespace, container, keyfile, targetUser, targetDB, newPassword string, port int) error
+	UpsertUserWithKeyfile(ctx context.Context, podName, namespace, container, keyfile string, user MongoUser, port int) error
+	UpsertUserWithAuth(ctx context.Context, podName, namespace, container, adminUser, adminPassword, authDB string, user MongoUser, port int) error
+	GrantRoles(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB string, roles []UserRole, port int) error
+	RevokeRoles(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB string, roles []UserRole, port int) error
+	DropUser(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB string, port int) error
+	Authenticate(ctx context.Context, podName, namespace, username, password, authDB string, port int) error
+	AuthenticateInContainer(ctx context.Context, podName, namespace, container, username, password, authDB string, port int) error
+}
+
+// authManager is the AuthManager running mongosh through an Executor
+type authManager struct {
+	executor Executor
+}
+
+// NewAuthManagerWithExecutor creates a new auth manager with provided executor
+func NewAuthManagerWithExecutor(exec Executor) AuthManager {
+	return &authManager{executor: exec}
+}
+
+// CreateAdminUserInContainer creates the initial admin user in a specified container
+func (a *authManager) CreateAdminUserInContainer(ctx context.Context, podName, namespace, container, username, password string, port int) error {
+	roles := []UserRole{
+		{Role: "root", DB: "admin"},
+	}
+
+	rolesJSON, err := json.Marshal(roles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	// Use localhost exception for first user creation
+	command := fmt.Sprintf(`
+		db.getSiblingDB('admin').createUser({
+			user: '%s',
+			pwd: '%s',
+			roles: %s
+		})
+	`, username, password, string(rolesJSON))
+
+	result, err := a.executor.ExecuteMongoshInContainer(ctx, podName, namespace, container, command, port)
+	if err != nil {
+		return fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	// Check if user already exists
+	if strings.Contains(result.Stderr, "already exists") {
+		return nil // User already exists, not an error
+	}
+
+	if result.ExitCode != 0 && !strings.Contains(result.Stdout, "ok") {
+		return commandFailed(result, "createUser failed: stdout=%s, stderr=%s", result.Stdout, result.Stderr)
+	}
+
+	return nil
+}
+
+// CreateUser creates a new MongoDB user (requires authentication)
+func (a *authManager) CreateUser(ctx context.Context, podName, namespace, adminUser, adminPassword string, user MongoUser, port int) error {
+	rolesJSON, err := json.Marshal(user.Roles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		db.getSiblingDB('%s').createUser({
+			user: '%s',
+			pwd: '%s',
+			roles: %s
+		})
+	`, user.Database, user.Username, user.Password, string(rolesJSON))
+
+	result, err := a.executor.ExecuteMongoshWithAuthAndPort(ctx, podName, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	// Check if user already exists
+	if strings.Contains(result.Stderr, "already exists") {
+		return nil
+	}
+
+	if result.ExitCode != 0 && !strings.Contains(result.Stdout, "ok") {
+		return commandFailed(result, "createUser failed: stdout=%s, stderr=%s", result.Stdout, result.Stderr)
+	}
+
+	return nil
+}
+
+// UserExistsInContainer checks if a user exists in a specified container
+func (a *authManager) UserExistsInContainer(ctx context.Context, podName, namespace, container, username, database string, port int) (bool, error) {
+	command := fmt.Sprintf(`
+		const user = db.getSiblingDB('%s').getUser('%s');
+		user !== null
+	`, database, username)
+
+	result, err := a.executor.QueryMongoshInContainer(ctx, podName, namespace, container, command, port)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+
+	return strings.TrimSpace(result.Stdout) == "true", nil
+}
+
+// UserExistsWithAuth checks if a user exists (with authentication)
+func (a *authManager) UserExistsWithAuth(ctx context.Context, podName, namespace, adminUser, adminPassword, username, database string, port int) (bool, error) {
+	command := fmt.Sprintf(`
+		const user = db.getSiblingDB('%s').getUser('%s');
+		user !== null
+	`, database, username)
+
+	result, err := a.executor.QueryMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+
+	return strings.TrimSpace(result.Stdout) == "true", nil
+}
+
+// UpdatePassword updates a user's password
+func (a *authManager) UpdatePassword(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB, newPassword string, port int) error {
+	command := fmt.Sprintf(`
+		db.getSiblingDB(%s).changeUserPassword(%s, %s)
+	`, jsString(targetDB), jsString(targetUser), jsString(newPassword))
+
+	result, err := a.executor.ExecuteMongoshWithAuthAndPort(ctx, podName, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "changeUserPassword failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// UpdatePasswordWithKeyfile updates a user's password authenticating as the internal __system user.
+// This is used for rotation, when the previous admin password is no longer known.
+func (a *authManager) UpdatePasswordWithKeyfile(ctx context.Context, podName, namespace, container, keyfile, targetUser, targetDB, newPassword string, port int) error {
+	command := fmt.Sprintf(`
+		db.getSiblingDB(%s).changeUserPassword(%s, %s)
+	`, jsString(targetDB), jsString(targetUser), jsString(newPassword))
+
+	result, err := a.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, "__system", strings.TrimSpace(keyfile), "local", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "changeUserPassword failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// UpsertUserWithKeyfile creates a user or resets its password and roles, authenticating as the
+// internal __system user. This is used for operator-managed users on members without an admin user.
+func (a *authManager) UpsertUserWithKeyfile(ctx context.Context, podName, namespace, container, keyfile string, user MongoUser, port int) error {
+	return a.UpsertUserWithAuth(ctx, podName, namespace, container, "__system", strings.TrimSpace(keyfile), "local", user, port)
+}
+
+// UpsertUserWithAuth creates a user or resets its password and roles, authenticating with the given credentials
+func (a *authManager) UpsertUserWithAuth(ctx context.Context, podName, namespace, container, adminUser, adminPassword, authDB string, user MongoUser, port int) error {
+	rolesJSON, err := json.Marshal(user.Roles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		const target = db.getSiblingDB('%s');
+		if (target.getUser('%s') === null) {
+			target.createUser({user: '%s', pwd: '%s', roles: %s});
+		} else {
+			target.updateUser('%s', {pwd: '%s', roles: %s});
+		}
+	`, user.Database, user.Username, user.Username, user.Password, string(rolesJSON),
+		user.Username, user.Password, string(rolesJSON))
+
+	result, err := a.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, authDB, command, port)
+	if err != nil {
+		return fmt.Errorf("failed to upsert user: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "upsert of user %s failed: %s", user.Username, result.Stderr)
+	}
+
+	return nil
+}
+
+// GrantRoles grants additional roles to a user
+func (a *authManager) GrantRoles(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB string, roles []UserRole, port int) error {
+	rolesJSON, err := json.Marshal(roles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		db.getSiblingDB('%s').grantRolesToUser('%s', %s)
+	`, targetDB, targetUser, string(rolesJSON))
+
+	result, err := a.executor.ExecuteMongoshWithAuthAndPort(ctx, podName, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to grant roles: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "grantRolesToUser failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// RevokeRoles revokes roles from a user
+func (a *authManager) RevokeRoles(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB string, roles []UserRole, port int) error {
+	rolesJSON, err := json.Marshal(roles)
+	if err != nil {
+		return fmt.Errorf("failed to marshal roles: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		db.getSiblingDB('%s').revokeRolesFromUser('%s', %s)
+	`, targetDB, targetUser, string(rolesJSON))
+
+	result, err := a.executor.ExecuteMongoshWithAuthAndPort(ctx, podName, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to revoke roles: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "revokeRolesFromUser failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// DropUser removes a user
+func (a *authManager) DropUser(ctx context.Context, podName, namespace, adminUser, adminPassword, targetUser, targetDB string, port int) error {
+	command := fmt.Sprintf(`
+		db.getSiblingDB('%s').dropUser('%s')
+	`, targetDB, targetUser)
+
+	result, err := a.executor.ExecuteMongoshWithAuthAndPort(ctx, podName, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to drop user: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "dropUser failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// Authenticate tests authentication with given credentials
+func (a *authManager) Authenticate(ctx context.Context, podName, namespace, username, password, authDB string, port int) error {
+	command := "db.adminCommand('ping')"
+	result, err := a.executor.ExecuteMongoshWithAuthAndPort(ctx, podName, namespace, username, password, authDB, command, port)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "authentication failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// AuthenticateInContainer tests authentication with given credentials against a specific container and port
+func (a *authManager) AuthenticateInContainer(ctx context.Context, podName, namespace, container, username, password, authDB string, port int) error {
+	command := "db.adminCommand('ping')"
+	result, err := a.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, username, password, authDB, command, port)
+	if err != nil {
+		return fmt.Errorf("authentication failed: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "authentication failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// DefaultAdminUser returns the default admin user configuration
+func DefaultAdminUser(password string) MongoUser {
+	return MongoUser{
+		Username: "admin",
+		Password: password,
+		Database: "admin",
+		Roles: []UserRole{
+			{Role: "root", DB: "admin"},
+		},
+	}
+}
+
+// ClusterAdminUser returns a cluster admin user configuration
+func ClusterAdminUser(username, password string) MongoUser {
+	return MongoUser{
+		Username: username,
+		Password: password,
+		Database: "admin",
+		Roles: []UserRole{
+			{Role: "clusterAdmin", DB: "admin"},
+			{Role: "userAdminAnyDatabase", DB: "admin"},
+			{Role: "readWriteAnyDatabase", DB: "admin"},
+		},
+	}
+}
+
+// ClusterMonitorUser returns the read-only user configuration used by metrics exporters
+func ClusterMonitorUser(username, password string) MongoUser {
+	return MongoUser{
+		Username: username,
+		Password: password,
+		Database: "admin",
+		Roles: []UserRole{
+			{Role: "clusterMonitor", DB: "admin"},
+			{Role: "read", DB: "local"},
+		},
+	}
+}
+
+// ReadWriteUser returns a read-write user configuration for a specific database
+func ReadWriteUser(username, password, database string) MongoUser {
+	return MongoUser{
+		Username: username,
+		Password: password,
+		Database: database,
+		Roles: []UserRole{
+			{Role: "readWrite", DB: database},
+		},
+	}
+}
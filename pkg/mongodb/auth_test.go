@@ -0,0 +1,38 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdatePasswordWithKeyfile(t *testing.T) {
+	recorder := &commandRecorder{}
+	auth := NewAuthManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, auth.UpdatePasswordWithKeyfile(context.Background(), "db-0", "default", "mongodb",
+		"key\n", "admin", "admin", `it's a \ "pass'); db.dropDatabase('app`, 27017))
+
+	command := recorder.commands[0]
+	assert.Contains(t, command[len(command)-1],
+		`db.getSiblingDB("admin").changeUserPassword("admin", "it's a \\ \"pass'); db.dropDatabase('app")`,
+		"the password stays a single string literal")
+	assert.Contains(t, command, "__system")
+}
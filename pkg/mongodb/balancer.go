@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+)
+
+// BalancerWindow is the daily time window the balancer is allowed to run in
+type BalancerWindow struct {
+	Start string `json:"start"`
+	Stop  string `json:"stop"`
+}
+
+// ChunkMigration is a chunk migration in progress
+type ChunkMigration struct {
+	Namespace string `json:"ns"`
+	FromShard string `json:"fromShard"`
+	ToShard   string `json:"toShard"`
+}
+
+// BalancerState is the balancer state of a sharded cluster
+type BalancerState struct {
+	// Mode is full or off
+	Mode             string           `json:"mode"`
+	InBalancerRound  bool             `json:"inBalancerRound"`
+	ActiveWindow     *BalancerWindow  `json:"activeWindow"`
+	Migrations       []ChunkMigration `json:"migrations"`
+	FailedMigrations int32            `json:"failedMigrations"`
+	JumboChunks      int32            `json:"jumboChunks"`
+}
+
+// Enabled reports whether the balancer is turned on
+func (b *BalancerState) Enabled() bool {
+	return b.Mode != "off"
+}
+
+// GetBalancerStateInContainer returns the balancer mode, its window, the chunk migrations in progress,
+// the failed ones recorded in the capped config.changelog and the jumbo chunks
+func (s *shardManager) GetBalancerStateInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) (*BalancerState, error) {
+	command := `
+		const status = db.adminCommand({ balancerStatus: 1 });
+		const config = db.getSiblingDB('config');
+		const settings = config.settings.findOne({ _id: 'balancer' }) || {};
+		const migrations = config.migrations.find().toArray().map(m => ({
+			ns: m.ns, fromShard: m.fromShard, toShard: m.toShard
+		}));
+		JSON.stringify({
+			mode: status.mode,
+			inBalancerRound: !!status.inBalancerRound,
+			activeWindow: settings.activeWindow || null,
+			migrations: migrations,
+			failedMigrations: config.changelog.countDocuments({ what: 'moveChunk.error' }),
+			jumboChunks: config.chunks.countDocuments({ jumbo: true })
+		})
+	`
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balancer state: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "get balancer state failed: %s", result.Stderr)
+	}
+
+	var state BalancerState
+	if err := decodeOutput(result.Stdout, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse balancer state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// SetBalancerEnabledInContainer starts or stops the balancer.
+// Stopping waits for the current balancing round to finish.
+func (s *shardManager) SetBalancerEnabledInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, enabled bool, port int) error {
+	command := "sh.stopBalancer()"
+	if enabled {
+		command = "sh.startBalancer()"
+	}
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to set balancer state: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "%s failed: %s", command, result.Stderr)
+	}
+
+	return nil
+}
+
+// SetBalancerWindowInContainer configures the balancing window. A nil window removes it.
+func (s *shardManager) SetBalancerWindowInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, window *BalancerWindow, port int) error {
+	update := "{ $unset: { activeWindow: true } }"
+	if window != nil {
+		update = fmt.Sprintf("{ $set: { activeWindow: { start: %s, stop: %s } } }", jsString(window.Start), jsString(window.Stop))
+	}
+
+	command := fmt.Sprintf("db.getSiblingDB('config').settings.updateOne({ _id: 'balancer' }, %s, { upsert: true })", update)
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to set balancer window: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "set balancer window failed: %s", result.Stderr)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalancerState(t *testing.T) {
+	out := `{"mode":"full","inBalancerRound":true,"activeWindow":{"start":"23:00","stop":"06:00"},` +
+		`"migrations":[{"ns":"app.users","fromShard":"my-sharded-shard-0","toShard":"my-sharded-shard-1"}],` +
+		`"failedMigrations":2,"jumboChunks":1}`
+
+	var state BalancerState
+	require.NoError(t, json.Unmarshal([]byte(out), &state))
+
+	assert.True(t, state.Enabled())
+	assert.True(t, state.InBalancerRound)
+	assert.Equal(t, &BalancerWindow{Start: "23:00", Stop: "06:00"}, state.ActiveWindow)
+	require.Len(t, state.Migrations, 1)
+	assert.Equal(t, "app.users", state.Migrations[0].Namespace)
+	assert.Equal(t, "my-sharded-shard-1", state.Migrations[0].ToShard)
+	assert.Equal(t, int32(2), state.FailedMigrations)
+	assert.Equal(t, int32(1), state.JumboChunks)
+
+	var off BalancerState
+	require.NoError(t, json.Unmarshal([]byte(`{"mode":"off","inBalancerRound":false,"activeWindow":null,"migrations":[],"failedMigrations":0,"jumboChunks":0}`), &off))
+	assert.False(t, off.Enabled())
+	assert.Nil(t, off.ActiveWindow)
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+)
+
+// BuildInfo is the build of the mongod or mongos binary a pod runs
+type BuildInfo struct {
+	Version    string `json:"version"`
+	GitVersion string `json:"gitVersion"`
+}
+
+// GetBuildInfoWithAuth returns the buildInfo of a member, authenticating with the given
+// credentials. buildInfo needs no privileges, the credentials only get the connection through.
+func (r *replicaSetManager) GetBuildInfoWithAuth(ctx context.Context, podName, namespace, username, password, authDB string) (*BuildInfo, error) {
+	command := `
+		const info = db.adminCommand({ buildInfo: 1 });
+		JSON.stringify({ version: info.version, gitVersion: info.gitVersion })
+	`
+
+	result, err := r.executor.QueryMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", username, password, authDB, command, r.port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get build info: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "buildInfo failed: %s", result.Stderr)
+	}
+
+	var info BuildInfo
+	if err := decodeOutput(result.Stdout, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse build info: %w", err)
+	}
+
+	return &info, nil
+}
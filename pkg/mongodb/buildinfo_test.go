@@ -0,0 +1,43 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBuildInfo(t *testing.T) {
+	var pod string
+	exec := NewExecutorWithRunner(runnerFunc(func(podName string) (*ExecResult, error) {
+		pod = podName
+		return &ExecResult{Stdout: "Current Mongosh Log ID: 1\n" + `{"version":"8.0.4","gitVersion":"bc35ab4305d9920d9d4b1c6f8f8e1cd0d4b0e8f2"}`}, nil
+	}), DefaultExecutorOptions())
+	info, err := NewReplicaSetManagerWithExecutorAndPort(exec, 27017).GetBuildInfoWithAuth(context.Background(), "db-0", "default", "__system", "keyfile", "local")
+	require.NoError(t, err)
+	assert.Equal(t, "8.0.4", info.Version)
+	assert.Equal(t, "db-0", pod)
+
+	exec = NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{ExitCode: 1, Stderr: "MongoServerError: Authentication failed."}, nil
+	}), DefaultExecutorOptions())
+	_, err = NewReplicaSetManagerWithExecutorAndPort(exec, 27017).GetBuildInfoWithAuth(context.Background(), "db-0", "default", "__system", "keyfile", "local")
+	assert.ErrorContains(t, err, "Authentication failed")
+}
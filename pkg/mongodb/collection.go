@@ -0,0 +1,228 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChunkCount is the number of chunks of a collection held by a shard
+type ChunkCount struct {
+	Shard  string `json:"_id"`
+	Chunks int32  `json:"count"`
+}
+
+// jsString quotes a value as a JavaScript string literal
+func jsString(value string) string {
+	out, _ := json.Marshal(value)
+	return string(out)
+}
+
+// GetShardKeyInContainer returns the shard key of a collection as JSON, or "" if it is not sharded
+func (s *shardManager) GetShardKeyInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection string, port int) (string, error) {
+	command := fmt.Sprintf(`
+		const coll = db.getSiblingDB('config').collections.findOne({ _id: %s, dropped: { $ne: true } });
+		coll ? JSON.stringify(coll.key) : ''
+	`, jsString(collection))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return "", fmt.Errorf("failed to get shard key: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return "", commandFailed(result, "get shard key failed: %s", result.Stderr)
+	}
+
+	return strings.TrimSpace(result.Stdout), nil
+}
+
+// ListShardedCollectionsInContainer returns the namespaces of the sharded collections, sorted. The
+// collections of the config database, sharded by MongoDB itself, are left out.
+func (s *shardManager) ListShardedCollectionsInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) ([]string, error) {
+	command := `
+		const colls = db.getSiblingDB('config').collections.find(
+			{ _id: { $not: /^config\./ }, dropped: { $ne: true }, unsplittable: { $ne: true } },
+			{ _id: 1 }
+		).sort({ _id: 1 }).toArray();
+		JSON.stringify(colls.map(c => c._id))
+	`
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sharded collections: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "list sharded collections failed: %s", result.Stderr)
+	}
+
+	var collections []string
+	if err := decodeOutput(result.Stdout, &collections); err != nil {
+		return nil, fmt.Errorf("failed to parse sharded collections: %w", err)
+	}
+
+	return collections, nil
+}
+
+// ShardCollectionInContainer enables sharding on the database and shards a collection with the given key
+// and options documents
+func (s *shardManager) ShardCollectionInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection, key string, unique bool, options string, port int) error {
+	database := strings.SplitN(collection, ".", 2)[0]
+	command := fmt.Sprintf(`
+		sh.enableSharding(%s);
+		sh.shardCollection(%s, %s, %t, %s)
+	`, jsString(database), jsString(collection), key, unique, options)
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to shard collection: %w", err)
+	}
+
+	// Check if already sharded
+	if strings.Contains(result.Stderr, "already sharded") || strings.Contains(result.Stdout, "already sharded") {
+		return nil
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "shardCollection failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// SplitChunksInContainer splits the chunks of a sharded collection at the given shard key values,
+// Extended JSON documents. Values already bounding a chunk are skipped.
+func (s *shardManager) SplitChunksInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection string, points []string, port int) error {
+	pointsJSON, err := json.Marshal(points)
+	if err != nil {
+		return fmt.Errorf("failed to marshal split points: %w", err)
+	}
+
+	// Since MongoDB 5.0 chunks reference the collection by uuid instead of ns
+	command := fmt.Sprintf(`
+		const config = db.getSiblingDB('config');
+		const coll = config.collections.findOne({ _id: %s });
+		const match = coll && coll.timestamp ? { uuid: coll.uuid } : { ns: %s };
+		for (const point of %s) {
+			const middle = EJSON.parse(point);
+			if (!config.chunks.findOne(Object.assign({ min: middle }, match))) {
+				sh.splitAt(%s, middle);
+			}
+		}
+	`, jsString(collection), jsString(collection), string(pointsJSON), jsString(collection))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to split chunks: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "splitAt failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// AddShardToZoneInContainer associates a shard with a zone
+func (s *shardManager) AddShardToZoneInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardName, zone string, port int) error {
+	command := fmt.Sprintf("sh.addShardToZone(%s, %s)", jsString(shardName), jsString(zone))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to add shard to zone: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "addShardToZone failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// RemoveShardFromZoneInContainer removes the association between a shard and a zone
+func (s *shardManager) RemoveShardFromZoneInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardName, zone string, port int) error {
+	command := fmt.Sprintf("sh.removeShardFromZone(%s, %s)", jsString(shardName), jsString(zone))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to remove shard from zone: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "removeShardFromZone failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// UpdateZoneKeyRangeInContainer assigns a shard key range to a zone.
+// An empty zone removes the range. Bounds are Extended JSON documents.
+func (s *shardManager) UpdateZoneKeyRangeInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection, min, max, zone string, port int) error {
+	zoneArg := "null"
+	if zone != "" {
+		zoneArg = jsString(zone)
+	}
+
+	command := fmt.Sprintf("sh.updateZoneKeyRange(%s, EJSON.parse(%s), EJSON.parse(%s), %s)",
+		jsString(collection), jsString(min), jsString(max), zoneArg)
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to update zone key range: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "updateZoneKeyRange failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// GetChunkDistributionInContainer returns the number of chunks of a collection per shard
+func (s *shardManager) GetChunkDistributionInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection string, port int) ([]ChunkCount, error) {
+	// Since MongoDB 5.0 chunks reference the collection by uuid instead of ns
+	command := fmt.Sprintf(`
+		const config = db.getSiblingDB('config');
+		const coll = config.collections.findOne({ _id: %s });
+		const match = coll && coll.timestamp ? { uuid: coll.uuid } : { ns: %s };
+		JSON.stringify(config.chunks.aggregate([
+			{ $match: match },
+			{ $group: { _id: '$shard', count: { $sum: 1 } } },
+			{ $sort: { _id: 1 } }
+		]).toArray())
+	`, jsString(collection), jsString(collection))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chunk distribution: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "get chunk distribution failed: %s", result.Stderr)
+	}
+
+	var counts []ChunkCount
+	if err := decodeOutput(result.Stdout, &counts); err != nil {
+		return nil, fmt.Errorf("failed to parse chunk distribution: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardCollectionInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	shards := NewShardManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, shards.ShardCollectionInContainer(context.Background(), "mongos-0", "default", "mongos",
+		"admin", "secret", "app.users", `{"_id":"hashed"}`, false, `{"numInitialChunks":8}`, 27017))
+
+	command := recorder.commands[0]
+	assert.Contains(t, command[len(command)-1], `sh.enableSharding("app");`)
+	assert.Contains(t, command[len(command)-1], `sh.shardCollection("app.users", {"_id":"hashed"}, false, {"numInitialChunks":8})`)
+}
+
+func TestSplitChunksInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	shards := NewShardManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, shards.SplitChunksInContainer(context.Background(), "mongos-0", "default", "mongos",
+		"admin", "secret", "app.users", []string{`{"region": "EU"}`, `{"region": "US"}`}, 27017))
+
+	require.Len(t, recorder.commands, 1, "all the points are split in one call")
+	command := recorder.commands[0][len(recorder.commands[0])-1]
+	assert.Contains(t, command, `for (const point of ["{\"region\": \"EU\"}","{\"region\": \"US\"}"])`)
+	assert.Contains(t, command, `sh.splitAt("app.users", middle)`)
+}
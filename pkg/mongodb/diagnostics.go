@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// SetDiagnosticsWithAuth sets the profiler of every database of a member and its log verbosity.
+// profiling holds the level, slowms and sampleRate, verbosity the logComponentVerbosity server
+// parameter. Components left out of verbosity inherit the default verbosity again.
+func (r *replicaSetManager) SetDiagnosticsWithAuth(ctx context.Context, podName, namespace, username, password, authDB string, profiling, verbosity map[string]any) error {
+	profilingJSON, err := json.Marshal(profiling)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiling settings: %w", err)
+	}
+	verbosityJSON, err := json.Marshal(verbosity)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log verbosity: %w", err)
+	}
+
+	// The profiler level is per database, slowms and sampleRate are global
+	command := fmt.Sprintf(`
+		const profiling = %s;
+		db.adminCommand({ listDatabases: 1, nameOnly: true }).databases.forEach(d => {
+			if (d.name !== 'local') {
+				db.getSiblingDB(d.name).setProfilingLevel(profiling.level, { slowms: profiling.slowms, sampleRate: profiling.sampleRate });
+			}
+		});
+
+		const inherit = (o) => {
+			for (const k of Object.keys(o)) {
+				if (k === 'verbosity') { o[k] = -1; } else if (typeof o[k] === 'object') { inherit(o[k]); }
+			}
+			return o;
+		};
+		const merge = (into, from) => {
+			for (const k of Object.keys(from)) {
+				into[k] = typeof from[k] === 'object' ? merge(into[k] || {}, from[k]) : from[k];
+			}
+			return into;
+		};
+		const current = db.adminCommand({ getParameter: 1, logComponentVerbosity: 1 }).logComponentVerbosity;
+		const res = db.adminCommand({ setParameter: 1, logComponentVerbosity: merge(inherit(current), %s) });
+		if (!res.ok) {
+			throw new Error(res.errmsg);
+		}
+	`, profilingJSON, verbosityJSON)
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", username, password, authDB, command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to set diagnostics: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "set diagnostics failed: %s", result.Stderr)
+	}
+
+	return nil
+}
@@ -0,0 +1,31 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package mongodb administers the MongoDB deployments of the operator by running mongosh in
+// their pods: replica set configuration (ReplicaSetManager), users and roles (AuthManager),
+// shards, zones and the balancer (ShardManager) and indexes (IndexManager).
+//
+// The managers are interfaces, created on the Executor they run their commands through.
+// NewExecutor execs into the pods through the API server, NewExecutorWithRunner takes any
+// CommandRunner, such as the runner of the fake package for tests. ExecutorOptions set the rate
+// limit and the timeouts of the exec calls:
+//
+//	runner := fake.NewRunner()
+//	runner.On("rs.status()", `{"set":"rs0","members":[{"name":"db-0:27017","stateStr":"PRIMARY"}]}`)
+//	exec := mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions())
+//	rs := mongodb.NewReplicaSetManagerWithExecutorAndPort(exec, 27017)
+//	primary, err := rs.GetPrimaryPod(ctx, "db-0", "default")
+package mongodb
@@ -0,0 +1,126 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// CommandError is a command that ran in the pod and exited non-zero
+type CommandError struct {
+	ExitCode int
+	Stdout   string
+	Stderr   string
+
+	message string
+}
+
+func (e *CommandError) Error() string {
+	return e.message
+}
+
+// commandFailed returns the CommandError of a result, formatting its message like fmt.Errorf
+func commandFailed(result *ExecResult, format string, args ...any) error {
+	return &CommandError{
+		ExitCode: result.ExitCode,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+		message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// terminalMarkers appear in the output of commands failing for a reason retrying does not fix
+var terminalMarkers = []string{
+	"Authentication failed",
+	"AuthenticationFailed",
+	"Unauthorized",
+	"not authorized",
+	"requires authentication",
+	"SyntaxError",
+	"ReferenceError",
+	"TypeError",
+	"BadValue",
+	"FailedToParse",
+	"InvalidOptions",
+	"InvalidReplicaSetConfig",
+	"NewReplicaSetConfigurationIncompatible",
+}
+
+// retryableMarkers appear in the output of commands failing while the deployment is in
+// transition: elections, startup, shutdown or unreachable members
+var retryableMarkers = []string{
+	"NotWritablePrimary",
+	"NotPrimary",
+	"not master",
+	"NotYetInitialized",
+	"no replset config has been received",
+	"PrimarySteppedDown",
+	"InterruptedDueToReplStateChange",
+	"ShutdownInProgress",
+	"HostUnreachable",
+	"HostNotFound",
+	"NetworkTimeout",
+	"ExceededTimeLimit",
+	"LockBusy",
+	"ConflictingOperationInProgress",
+	"ECONNREFUSED",
+	"ECONNRESET",
+	"MongoNetworkError",
+	"MongoServerSelectionError",
+}
+
+// IsRetryable reports whether an operation of the managers that failed with err may succeed when
+// it is retried later. These are exec calls that did not complete or ran out of their timeout,
+// except those the API server refused, commands killed by a signal, and MongoDB errors of deployments in transition such as
+// NotWritablePrimary. Authentication failures, invalid commands, other MongoDB errors and a
+// cancelled context are terminal.
+func IsRetryable(err error) bool {
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var cmdErr *CommandError
+	if !errors.As(err, &cmdErr) {
+		// The exec call failed: the API server or the pod could not be reached
+		return !apierrors.IsForbidden(err) && !apierrors.IsUnauthorized(err) && !apierrors.IsBadRequest(err)
+	}
+
+	output := cmdErr.Stderr + "\n" + cmdErr.Stdout
+	for _, marker := range terminalMarkers {
+		if strings.Contains(output, marker) {
+			return false
+		}
+	}
+	// Killed by SIGKILL or SIGTERM, e.g. the container stopped
+	if cmdErr.ExitCode == 137 || cmdErr.ExitCode == 143 {
+		return true
+	}
+	for _, marker := range retryableMarkers {
+		if strings.Contains(output, marker) {
+			return true
+		}
+	}
+	return false
+}
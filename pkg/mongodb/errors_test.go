@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// runnerFunc adapts a function to a CommandRunner
+type runnerFunc func(podName string) (*ExecResult, error)
+
+func (f runnerFunc) Run(_ context.Context, podName, _, _ string, _ []string) (*ExecResult, error) {
+	return f(podName)
+}
+
+func TestCommandError(t *testing.T) {
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{ExitCode: 1, Stderr: "MongoServerError: not primary and secondaryOk=false - NotPrimaryNoSecondaryOk"}, nil
+	}), DefaultExecutorOptions())
+	rs := NewReplicaSetManagerWithExecutorAndPort(exec, 27017)
+
+	_, err := rs.GetStatus(context.Background(), "db-0", "default")
+	require.Error(t, err)
+	assert.Equal(t, "rs.status() failed: MongoServerError: not primary and secondaryOk=false - NotPrimaryNoSecondaryOk", err.Error())
+
+	var cmdErr *CommandError
+	require.ErrorAs(t, err, &cmdErr)
+	assert.Equal(t, 1, cmdErr.ExitCode)
+	assert.True(t, IsRetryable(err))
+	assert.True(t, IsRetryable(fmt.Errorf("wrapped: %w", err)))
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "no error", err: nil, expected: false},
+		{name: "cancelled", err: fmt.Errorf("exec rate limit: %w", context.Canceled), expected: false},
+		{name: "exec failure", err: errors.New("failed to execute command: error dialing backend: EOF"), expected: true},
+		{name: "pod not found", err: apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, "db-0"), expected: true},
+		{name: "exec forbidden", err: apierrors.NewForbidden(schema.GroupResource{Resource: "pods/exec"}, "db-0", errors.New("RBAC")), expected: false},
+		{name: "authentication failed", err: &CommandError{ExitCode: 1, Stderr: "MongoServerError: Authentication failed."}, expected: false},
+		{name: "syntax error", err: &CommandError{ExitCode: 1, Stderr: "SyntaxError: Unexpected token"}, expected: false},
+		{name: "not writable primary", err: &CommandError{ExitCode: 1, Stderr: "MongoServerError: NotWritablePrimary"}, expected: true},
+		{name: "connection refused", err: &CommandError{ExitCode: 1, Stderr: "MongoNetworkError: connect ECONNREFUSED 127.0.0.1:27017"}, expected: true},
+		{name: "killed", err: &CommandError{ExitCode: 137}, expected: true},
+		{name: "other server error", err: &CommandError{ExitCode: 1, Stderr: "MongoServerError: ns not found"}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestRetryWithBackoffStopsOnTerminalErrors(t *testing.T) {
+	config := RetryConfig{InitialDelay: 1, MaxDelay: 1, Factor: 1, MaxRetries: 5}
+
+	attempts := 0
+	err := RetryWithBackoff(context.Background(), config, func() error {
+		attempts++
+		return &CommandError{ExitCode: 1, Stderr: "Authentication failed", message: "authentication failed"}
+	})
+	assert.EqualError(t, err, "authentication failed")
+	assert.Equal(t, 1, attempts)
+
+	attempts = 0
+	err = RetryWithBackoff(context.Background(), config, func() error {
+		attempts++
+		if attempts < 3 {
+			return &CommandError{ExitCode: 1, Stderr: "NotYetInitialized", message: "rs.status() failed"}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/client-go/util/exec"
+	"sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+// CommandRunner runs a command in a container of a pod. It is the seam between the managers of
+// this package and the cluster: the runner of NewExecutor execs into the pod through the API
+// server, tests create their executor on the runner of the fake package.
+type CommandRunner interface {
+	// Run runs command to completion. A command exiting non-zero is not an error, its
+	// ExecResult carries the exit code.
+	Run(ctx context.Context, podName, namespace, container string, command []string) (*ExecResult, error)
+}
+
+// Executor runs commands in the containers of MongoDB pods, mongosh among them. Its exec calls
+// are rate limited per cluster and bounded by the timeouts of its ExecutorOptions.
+type Executor interface {
+	ExecuteCommand(ctx context.Context, podName, namespace, container string, command []string) (*ExecResult, error)
+	ExecuteMongoshWithPort(ctx context.Context, podName, namespace, command string, port int) (*ExecResult, error)
+	ExecuteMongoshInContainer(ctx context.Context, podName, namespace, container, command string, port int) (*ExecResult, error)
+	QueryMongoshInContainer(ctx context.Context, podName, namespace, container, command string, port int) (*ExecResult, error)
+	ExecuteMongoshWithAuthAndPort(ctx context.Context, podName, namespace, username, password, authDB, command string, port int) (*ExecResult, error)
+	ExecuteMongoshWithAuthInContainer(ctx context.Context, podName, namespace, container, username, password, authDB, command string, port int) (*ExecResult, error)
+	QueryMongoshWithAuthInContainer(ctx context.Context, podName, namespace, container, username, password, authDB, command string, port int) (*ExecResult, error)
+	ExecuteMongoshOnReplicaSetWithAuth(ctx context.Context, podName, namespace, replicaSet string, seeds []string, username, password, authDB, command string) (*ExecResult, error)
+	ExecuteMongoshJSONWithPort(ctx context.Context, podName, namespace, command string, port int) (*ExecResult, error)
+	ExecuteMongoshOnPrimary(ctx context.Context, podName, namespace, command string, port int) (*ExecResult, error)
+	Ping(ctx context.Context, podName, namespace string, port int) error
+	RunScriptInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, script string, port int) error
+	InsertDocumentsInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, documents string, port int) error
+
+	// ForgetCluster drops the rate limiter of a deleted cluster
+	ForgetCluster(namespace, name string)
+}
+
+// ExecutorOptions limit the exec calls of an executor. The operator sets them from its flags.
+type ExecutorOptions struct {
+	// QPS and Burst limit the exec calls made against the pods of one cluster. A QPS of 0
+	// disables the limit.
+	QPS   float32
+	Burst int
+
+	// QueryTimeout, CommandTimeout and LongCommandTimeout bound a single exec call, so a hung
+	// mongosh or a stuck exec stream cannot stall the reconcile worker waiting on it. They apply
+	// to read-only queries, to the other commands and to the commands copying or rewriting data
+	// such as compact and movePrimary. 0 disables a timeout.
+	QueryTimeout       time.Duration
+	CommandTimeout     time.Duration
+	LongCommandTimeout time.Duration
+}
+
+// DefaultExecutorOptions returns the options of an executor when the operator flags are not set
+func DefaultExecutorOptions() ExecutorOptions {
+	return ExecutorOptions{
+		QPS:                5,
+		Burst:              10,
+		QueryTimeout:       30 * time.Second,
+		CommandTimeout:     2 * time.Minute,
+		LongCommandTimeout: 30 * time.Minute,
+	}
+}
+
+// executor handles executing commands in MongoDB pods through a CommandRunner
+type executor struct {
+	runner  CommandRunner
+	options ExecutorOptions
+
+	// limiters holds the rate limiter of every cluster, keyed by namespace/name
+	limiters sync.Map
+}
+
+// NewExecutor creates a new MongoDB command executor
+func NewExecutor(options ExecutorOptions) (Executor, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes config: %w", err)
+	}
+	return NewExecutorForConfig(cfg, options)
+}
+
+// NewExecutorForConfig creates a MongoDB command executor talking to the API server of cfg
+func NewExecutorForConfig(cfg *rest.Config, options ExecutorOptions) (Executor, error) {
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	}
+
+	return NewExecutorWithRunner(&podExecRunner{
+		clientset: clientset,
+		config:    cfg,
+	}, options), nil
+}
+
+// NewExecutorWithRunner creates a MongoDB command executor running its commands with runner
+func NewExecutorWithRunner(runner CommandRunner, options ExecutorOptions) Executor {
+	return &executor{runner: runner, options: options}
+}
+
+// ExitCodeUnknown is the exit code of a command whose exec call failed before it completed
+const ExitCodeUnknown = -1
+
+// ExecResult contains the result of a command execution
+type ExecResult struct {
+	Stdout string
+	Stderr string
+	// ExitCode is the exit status of the command, ExitCodeUnknown when the exec call failed
+	ExitCode int
+}
+
+// ExecuteCommand executes a command in a pod container. The command may change the topology,
+// so the query results cached for the reconcile are dropped.
+func (e *executor) ExecuteCommand(ctx context.Context, podName, namespace, container string, command []string) (*ExecResult, error) {
+	scope := scopeFrom(ctx)
+	scope.invalidate()
+	return e.execute(ctx, scope, e.commandTimeout(ctx), podName, namespace, container, command)
+}
+
+// executeQuery executes a read-only command. Within a cluster scope its successful result is
+// reused until a command that may change the topology runs.
+func (e *executor) executeQuery(ctx context.Context, podName, namespace, container string, command []string) (*ExecResult, error) {
+	scope := scopeFrom(ctx)
+	key := queryKey(podName, namespace, container, command)
+	if result, ok := scope.cached(key); ok {
+		return result, nil
+	}
+
+	result, err := e.execute(ctx, scope, e.options.QueryTimeout, podName, namespace, container, command)
+	if err == nil && result.ExitCode == 0 {
+		scope.store(key, result)
+	}
+	return result, err
+}
+
+// execute runs command once the rate limit of the cluster allows it, bounded by timeout
+func (e *executor) execute(ctx context.Context, scope *clusterScope, timeout time.Duration, podName, namespace, container string, command []string) (*ExecResult, error) {
+	if limiter := e.limiter(scope); limiter != nil {
+		if err := limiter.Wait(ctx); err != nil {
+			return nil, fmt.Errorf("exec rate limit: %w", err)
+		}
+	}
+	return runWithTimeout(ctx, timeout, func(ctx context.Context) (*ExecResult, error) {
+		return e.runner.Run(ctx, podName, namespace, container, command)
+	})
+}
+
+// podExecRunner runs commands through the exec subresource of the pods
+type podExecRunner struct {
+	clientset *kubernetes.Clientset
+	config    *rest.Config
+}
+
+func (r *podExecRunner) Run(ctx context.Context, podName, namespace, container string, command []string) (*ExecResult, error) {
+	req := r.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(podName).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     false,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       false,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(r.config, "POST", req.URL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create executor: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+
+	result := &ExecResult{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: 0,
+	}
+
+	if err != nil {
+		// A command that ran and exited non-zero is not an exec failure, the caller reads the
+		// exit code of the result
+		var exitErr utilexec.ExitError
+		if errors.As(err, &exitErr) && exitErr.Exited() {
+			result.ExitCode = exitErr.ExitStatus()
+			return result, nil
+		}
+		result.ExitCode = ExitCodeUnknown
+		return result, fmt.Errorf("failed to execute command: %w", err)
+	}
+
+	return result, nil
+}
+
+// ExecuteMongoshWithPort executes a mongosh command with specified port
+func (e *executor) ExecuteMongoshWithPort(ctx context.Context, podName, namespace, command string, port int) (*ExecResult, error) {
+	return e.ExecuteMongoshInContainer(ctx, podName, namespace, "mongodb", command, port)
+}
+
+// ExecuteMongoshInContainer executes a mongosh command in a specified container
+func (e *executor) ExecuteMongoshInContainer(ctx context.Context, podName, namespace, container, command string, port int) (*ExecResult, error) {
+	return e.ExecuteCommand(ctx, podName, namespace, container, mongoshCommand(command, port))
+}
+
+// QueryMongoshInContainer executes a read-only mongosh command in a specified container. Within
+// a cluster scope the result is reused, see WithClusterScope.
+func (e *executor) QueryMongoshInContainer(ctx context.Context, podName, namespace, container, command string, port int) (*ExecResult, error) {
+	return e.executeQuery(ctx, podName, namespace, container, mongoshCommand(command, port))
+}
+
+// ExecuteMongoshWithAuthAndPort executes a mongosh command with authentication and specified port
+func (e *executor) ExecuteMongoshWithAuthAndPort(ctx context.Context, podName, namespace, username, password, authDB, command string, port int) (*ExecResult, error) {
+	return e.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", username, password, authDB, command, port)
+}
+
+// ExecuteMongoshWithAuthInContainer executes a mongosh command with authentication in a specified container
+func (e *executor) ExecuteMongoshWithAuthInContainer(ctx context.Context, podName, namespace, container, username, password, authDB, command string, port int) (*ExecResult, error) {
+	return e.ExecuteCommand(ctx, podName, namespace, container, mongoshAuthCommand(username, password, authDB, command, port))
+}
+
+// QueryMongoshWithAuthInContainer executes a read-only mongosh command with authentication in a
+// specified container. Within a cluster scope the result is reused, see WithClusterScope.
+func (e *executor) QueryMongoshWithAuthInContainer(ctx context.Context, podName, namespace, container, username, password, authDB, command string, port int) (*ExecResult, error) {
+	return e.executeQuery(ctx, podName, namespace, container, mongoshAuthCommand(username, password, authDB, command, port))
+}
+
+// ExecuteMongoshOnReplicaSetWithAuth executes a mongosh command with authentication from podName
+// against the primary of the replica set replicaSet, found through the seed hosts, instead of the
+// local member
+func (e *executor) ExecuteMongoshOnReplicaSetWithAuth(ctx context.Context, podName, namespace, replicaSet string, seeds []string, username, password, authDB, command string) (*ExecResult, error) {
+	return e.ExecuteCommand(ctx, podName, namespace, "mongodb", []string{
+		"mongosh",
+		"--quiet",
+		"--host", replicaSet + "/" + strings.Join(seeds, ","),
+		"-u", username,
+		"-p", password,
+		"--authenticationDatabase", authDB,
+		"--eval",
+		command,
+	})
+}
+
+func mongoshCommand(command string, port int) []string {
+	return []string{
+		"mongosh",
+		"--quiet",
+		"--port", fmt.Sprintf("%d", port),
+		"--eval",
+		command,
+	}
+}
+
+func mongoshAuthCommand(username, password, authDB, command string, port int) []string {
+	return []string{
+		"mongosh",
+		"--quiet",
+		"--port", fmt.Sprintf("%d", port),
+		"-u", username,
+		"-p", password,
+		"--authenticationDatabase", authDB,
+		"--eval",
+		command,
+	}
+}
+
+// ExecuteMongoshJSONWithPort executes a mongosh command with specified port and expects JSON output
+func (e *executor) ExecuteMongoshJSONWithPort(ctx context.Context, podName, namespace, command string, port int) (*ExecResult, error) {
+	// Wrap command to output JSON
+	jsonCommand := ejsonStringify(command)
+	return e.ExecuteMongoshWithPort(ctx, podName, namespace, jsonCommand, port)
+}
+
+// ExecuteMongoshOnPrimary executes a command on the primary member
+func (e *executor) ExecuteMongoshOnPrimary(ctx context.Context, podName, namespace, command string, port int) (*ExecResult, error) {
+	// First check if this pod is primary
+	checkPrimary := `
+		const status = rs.status();
+		const myState = status.myState;
+		if (myState !== 1) {
+			throw new Error("Not primary");
+		}
+	`
+	result, err := e.ExecuteMongoshWithPort(ctx, podName, namespace, checkPrimary, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check primary status: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "pod is not primary: %s", result.Stderr)
+	}
+
+	// Execute the actual command
+	return e.ExecuteMongoshWithPort(ctx, podName, namespace, command, port)
+}
+
+// Ping checks if MongoDB is responding
+func (e *executor) Ping(ctx context.Context, podName, namespace string, port int) error {
+	result, err := e.ExecuteMongoshWithPort(ctx, podName, namespace, "db.adminCommand('ping')", port)
+	if err != nil {
+		return fmt.Errorf("ping failed: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return commandFailed(result, "ping failed: %s", result.Stderr)
+	}
+	return nil
+}
+
+// GetPodFQDN returns the fully qualified domain name for a pod. The cluster domain is the one the
+// operator resolved for the cluster, e.g. cluster.local, there is no default.
+func GetPodFQDN(podName, serviceName, namespace, clusterDomain string, port int) string {
+	return fmt.Sprintf("%s.%s.%s.svc.%s:%d", podName, serviceName, namespace, clusterDomain, port)
+}
+
+// GetPodsFQDN returns FQDNs for multiple pods
+func GetPodsFQDN(baseName, serviceName, namespace, clusterDomain string, replicas int, port int) []string {
+	fqdns := make([]string, replicas)
+	for i := 0; i < replicas; i++ {
+		podName := fmt.Sprintf("%s-%d", baseName, i)
+		fqdns[i] = GetPodFQDN(podName, serviceName, namespace, clusterDomain, port)
+	}
+	return fqdns
+}
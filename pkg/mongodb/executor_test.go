@@ -24,42 +24,55 @@ import (
 
 func TestGetPodFQDN(t *testing.T) {
 	tests := []struct {
-		name        string
-		podName     string
-		serviceName string
-		namespace   string
-		port        int
-		expected    string
+		name          string
+		podName       string
+		serviceName   string
+		namespace     string
+		clusterDomain string
+		port          int
+		expected      string
 	}{
 		{
-			name:        "standard pod FQDN",
-			podName:     "my-mongodb-0",
-			serviceName: "my-mongodb-headless",
-			namespace:   "default",
-			port:        27017,
-			expected:    "my-mongodb-0.my-mongodb-headless.default.svc.cluster.local:27017",
+			name:          "standard pod FQDN",
+			podName:       "my-mongodb-0",
+			serviceName:   "my-mongodb-headless",
+			namespace:     "default",
+			clusterDomain: "cluster.local",
+			port:          27017,
+			expected:      "my-mongodb-0.my-mongodb-headless.default.svc.cluster.local:27017",
 		},
 		{
-			name:        "different namespace",
-			podName:     "test-pod-1",
-			serviceName: "test-svc",
-			namespace:   "mongodb",
-			port:        27018,
-			expected:    "test-pod-1.test-svc.mongodb.svc.cluster.local:27018",
+			name:          "different namespace",
+			podName:       "test-pod-1",
+			serviceName:   "test-svc",
+			namespace:     "mongodb",
+			clusterDomain: "cluster.local",
+			port:          27018,
+			expected:      "test-pod-1.test-svc.mongodb.svc.cluster.local:27018",
 		},
 		{
-			name:        "config server pod",
-			podName:     "sharded-cfg-0",
-			serviceName: "sharded-cfg-headless",
-			namespace:   "production",
-			port:        27019,
-			expected:    "sharded-cfg-0.sharded-cfg-headless.production.svc.cluster.local:27019",
+			name:          "config server pod",
+			podName:       "sharded-cfg-0",
+			serviceName:   "sharded-cfg-headless",
+			namespace:     "production",
+			clusterDomain: "cluster.local",
+			port:          27019,
+			expected:      "sharded-cfg-0.sharded-cfg-headless.production.svc.cluster.local:27019",
+		},
+		{
+			name:          "custom cluster domain",
+			podName:       "my-mongodb-0",
+			serviceName:   "my-mongodb-headless",
+			namespace:     "default",
+			clusterDomain: "corp.example",
+			port:          27017,
+			expected:      "my-mongodb-0.my-mongodb-headless.default.svc.corp.example:27017",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GetPodFQDN(tt.podName, tt.serviceName, tt.namespace, tt.port)
+			result := GetPodFQDN(tt.podName, tt.serviceName, tt.namespace, tt.clusterDomain, tt.port)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
@@ -118,7 +131,7 @@ func TestGetPodsFQDN(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := GetPodsFQDN(tt.baseName, tt.serviceName, tt.namespace, tt.replicas, tt.port)
+			result := GetPodsFQDN(tt.baseName, tt.serviceName, tt.namespace, "cluster.local", tt.replicas, tt.port)
 			assert.Equal(t, tt.expected, result)
 			assert.Len(t, result, tt.replicas)
 		})
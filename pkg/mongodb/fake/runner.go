@@ -0,0 +1,140 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides a mongodb.CommandRunner answering from canned responses, to test code
+// built on the mongodb package without a cluster.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+// Response is the canned result of the commands it matches
+type Response struct {
+	// Pod restricts the response to one pod, empty matches every pod
+	Pod string
+	// Contains is a substring of the mongosh --eval script, or of the command line for other
+	// commands. Empty matches every command.
+	Contains string
+
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	// Err fails the exec call itself, as an unreachable pod does
+	Err error
+}
+
+// Call is a command run by the Runner
+type Call struct {
+	Pod       string
+	Namespace string
+	Container string
+	Command   []string
+}
+
+// Eval returns the --eval script of a mongosh call, "" for other commands
+func (c Call) Eval() string {
+	if i := slices.Index(c.Command, "--eval"); i >= 0 && i+1 < len(c.Command) {
+		return c.Command[i+1]
+	}
+	return ""
+}
+
+// Runner is a mongodb.CommandRunner answering every command with the most recently added
+// matching Response. Commands without a matching response fail. It is safe for concurrent use.
+type Runner struct {
+	mu        sync.Mutex
+	responses []Response
+	calls     []Call
+}
+
+var _ mongodb.CommandRunner = &Runner{}
+
+// NewRunner returns a Runner without responses
+func NewRunner() *Runner {
+	return &Runner{}
+}
+
+// Add adds responses, which take precedence over the ones added before
+func (r *Runner) Add(responses ...Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, responses...)
+}
+
+// On answers the commands containing contains on every pod with stdout
+func (r *Runner) On(contains, stdout string) {
+	r.Add(Response{Contains: contains, Stdout: stdout})
+}
+
+// Run records the call and returns the matching response
+func (r *Runner) Run(_ context.Context, podName, namespace, container string, command []string) (*mongodb.ExecResult, error) {
+	call := Call{Pod: podName, Namespace: namespace, Container: container, Command: slices.Clone(command)}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+
+	script := call.Eval()
+	if script == "" {
+		script = strings.Join(command, " ")
+	}
+	for i := len(r.responses) - 1; i >= 0; i-- {
+		response := r.responses[i]
+		if response.Pod != "" && response.Pod != podName {
+			continue
+		}
+		if !strings.Contains(script, response.Contains) {
+			continue
+		}
+		result := &mongodb.ExecResult{Stdout: response.Stdout, Stderr: response.Stderr, ExitCode: response.ExitCode}
+		return result, response.Err
+	}
+	return nil, fmt.Errorf("fake: no response for %q on pod %s", script, podName)
+}
+
+// Calls returns the calls run so far, in order
+func (r *Runner) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Scripts returns the --eval scripts of the mongosh calls run so far, in order
+func (r *Runner) Scripts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var scripts []string
+	for _, call := range r.calls {
+		if script := call.Eval(); script != "" {
+			scripts = append(scripts, script)
+		}
+	}
+	return scripts
+}
+
+// Reset drops the recorded calls, the responses stay
+func (r *Runner) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
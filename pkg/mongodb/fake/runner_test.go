@@ -0,0 +1,87 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package fake
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/keiailab/mongodb-operator/pkg/mongodb"
+)
+
+const testStatus = `{"set":"rs0","ok":1,"members":[
+	{"_id":0,"name":"db-0.db-headless.default.svc.cluster.local:27017","stateStr":"SECONDARY","health":1},
+	{"_id":1,"name":"db-1.db-headless.default.svc.cluster.local:27017","stateStr":"PRIMARY","health":1}]}`
+
+func TestRunnerAnswersManagers(t *testing.T) {
+	runner := NewRunner()
+	runner.On("rs.status()", testStatus)
+	rs := mongodb.NewReplicaSetManagerWithExecutorAndPort(mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions()), 27017)
+
+	primary, err := rs.GetPrimaryPod(context.Background(), "db-0", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "db-1", primary)
+
+	calls := runner.Calls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, "db-0", calls[0].Pod)
+	assert.Equal(t, "default", calls[0].Namespace)
+	assert.Equal(t, "mongodb", calls[0].Container)
+	assert.Equal(t, []string{"EJSON.stringify(rs.status(), null, 0, {relaxed: true})"}, runner.Scripts())
+
+	runner.Reset()
+	assert.Empty(t, runner.Calls())
+}
+
+func TestRunnerResponses(t *testing.T) {
+	ctx := context.Background()
+	runner := NewRunner()
+	runner.On("rs.status()", testStatus)
+	runner.Add(Response{Pod: "db-2", Err: errors.New("pod db-2 not found")})
+	runner.Add(Response{Pod: "db-3", Contains: "rs.status()", ExitCode: 1, Stderr: "no replset config has been received"})
+	rs := mongodb.NewReplicaSetManagerWithExecutorAndPort(mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions()), 27017)
+
+	_, err := rs.GetStatus(ctx, "db-0", "default")
+	require.NoError(t, err)
+
+	_, err = rs.GetStatus(ctx, "db-2", "default")
+	assert.ErrorContains(t, err, "pod db-2 not found")
+
+	_, err = rs.GetStatus(ctx, "db-3", "default")
+	assert.EqualError(t, err, "rs.status() failed: no replset config has been received")
+
+	_, err = rs.GetConfig(ctx, "db-0", "default")
+	assert.ErrorContains(t, err, "fake: no response")
+}
+
+func TestRunnerWithinClusterScope(t *testing.T) {
+	runner := NewRunner()
+	runner.On("rs.status()", testStatus)
+	rs := mongodb.NewReplicaSetManagerWithExecutorAndPort(mongodb.NewExecutorWithRunner(runner, mongodb.DefaultExecutorOptions()), 27017)
+
+	// Read-only queries are answered once per scope
+	ctx := mongodb.WithClusterScope(context.Background(), "default", "db")
+	for range 3 {
+		_, err := rs.GetStatus(ctx, "db-0", "default")
+		require.NoError(t, err)
+	}
+	assert.Len(t, runner.Calls(), 1)
+}
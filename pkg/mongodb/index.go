@@ -0,0 +1,363 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// IndexManager manages collections and their indexes
+type IndexManager interface {
+	GetCollectionInfoInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, port int) (*CollectionInfo, error)
+	CreateCollectionInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, options string, port int) error
+	GetCollectionSizeInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, port int) (int64, error)
+	ListIndexesInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, definitions []string, port int) ([]Index, error)
+	GetIndexBuildsInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, port int) ([]IndexBuild, error)
+	StartIndexBuildInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, definition, commitQuorum string, port int) error
+	SetIndexTTLInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, name string, expireAfterSeconds int32, port int) error
+	DropIndexInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, name string, port int) error
+	GetSetNameInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword string, port int) (string, error)
+	RestartInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, marker string, port int) error
+}
+
+// indexManager is the IndexManager running mongosh through an Executor
+type indexManager struct {
+	executor Executor
+}
+
+// NewIndexManagerWithExecutor creates a new index manager with provided executor
+func NewIndexManagerWithExecutor(exec Executor) IndexManager {
+	return &indexManager{executor: exec}
+}
+
+// IndexBuild reports the progress of an in-progress index build
+type IndexBuild struct {
+	Name  string `json:"name"`
+	Done  int64  `json:"done"`
+	Total int64  `json:"total"`
+}
+
+// Index is an existing index of a collection. Changed reports that its key or options differ from
+// the definition of the same name, TTLChanged that only its expireAfterSeconds does.
+type Index struct {
+	Name       string `json:"name"`
+	Changed    bool   `json:"changed,omitempty"`
+	TTLChanged bool   `json:"ttlChanged,omitempty"`
+}
+
+// CollectionInfo describes an existing collection as reported by listCollections
+type CollectionInfo struct {
+	Exists bool `json:"exists"`
+	// Type is collection, timeseries or view
+	Type   string `json:"type"`
+	Capped bool   `json:"capped"`
+}
+
+// GetCollectionInfoInContainer returns the type of a collection, nil when it does not exist
+func (m *indexManager) GetCollectionInfoInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, port int) (*CollectionInfo, error) {
+	command := fmt.Sprintf(`
+		const infos = db.getSiblingDB(%s).getCollectionInfos({ name: %s });
+		JSON.stringify(infos.length
+			? { exists: true, type: infos[0].type, capped: !!(infos[0].options || {}).capped }
+			: { exists: false })
+	`, jsString(database), jsString(collection))
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get collection info: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "get collection info failed: %s", result.Stderr)
+	}
+
+	var info CollectionInfo
+	if err := decodeOutput(result.Stdout, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse collection info: %w", err)
+	}
+	if !info.Exists {
+		return nil, nil
+	}
+
+	return &info, nil
+}
+
+// CreateCollectionInContainer creates a collection with the given options document
+func (m *indexManager) CreateCollectionInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, options string, port int) error {
+	command := fmt.Sprintf("db.getSiblingDB(%s).createCollection(%s, %s)", jsString(database), jsString(collection), options)
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "createCollection failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// GetCollectionSizeInContainer returns the uncompressed size of the documents of a collection,
+// 0 when it does not exist
+func (m *indexManager) GetCollectionSizeInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, port int) (int64, error) {
+	command := fmt.Sprintf(`
+		const stats = db.getSiblingDB(%s).getCollection(%s).aggregate([{ $collStats: { storageStats: {} } }]).toArray();
+		JSON.stringify({ size: stats.reduce((size, s) => size + Number(s.storageStats.size), 0) })
+	`, jsString(database), jsString(collection))
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get collection size: %w", err)
+	}
+
+	if strings.Contains(result.Stderr, "NamespaceNotFound") || strings.Contains(result.Stdout, "NamespaceNotFound") {
+		return 0, nil
+	}
+
+	if result.ExitCode != 0 {
+		return 0, commandFailed(result, "get collection size failed: %s", result.Stderr)
+	}
+
+	var stats struct {
+		Size int64 `json:"size"`
+	}
+	if err := decodeOutput(result.Stdout, &stats); err != nil {
+		return 0, fmt.Errorf("failed to parse collection size: %w", err)
+	}
+
+	return stats.Size, nil
+}
+
+// ListIndexesInContainer returns the indexes that finished building on a collection, compared with
+// the index definitions of the same name. Definitions are JavaScript index documents as built by
+// resources.BuildIndexDefinition. Keys are compared in order, with the fields of a text index
+// compared through its weights; unique, sparse, partialFilterExpression and expireAfterSeconds
+// are compared as relaxed Extended JSON.
+func (m *indexManager) ListIndexesInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, definitions []string, port int) ([]Index, error) {
+	command := fmt.Sprintf(`
+		const desired = [%s];
+		const shape = ix => {
+			const key = [], text = Object.keys(ix.weights || {});
+			for (const [field, type] of Object.entries(ix.key)) {
+				if (field === '_fts' || field === '_ftsx') { continue; }
+				if (type === 'text') { text.push(field); } else { key.push([field, type]); }
+			}
+			return EJSON.stringify({
+				key: key, text: text.sort(), unique: !!ix.unique, sparse: !!ix.sparse,
+				partialFilterExpression: ix.partialFilterExpression || null, ttl: ix.expireAfterSeconds !== undefined
+			}, { relaxed: true });
+		};
+		const indexes = db.getSiblingDB(%s).getCollection(%s).getIndexes().map(ix => {
+			const def = desired.find(d => d.name === ix.name);
+			if (!def) { return { name: ix.name }; }
+			const changed = shape(ix) !== shape(def);
+			return {
+				name: ix.name,
+				changed: changed,
+				ttlChanged: !changed && ix.expireAfterSeconds !== undefined && Number(ix.expireAfterSeconds) !== def.expireAfterSeconds
+			};
+		});
+		JSON.stringify(indexes)
+	`, strings.Join(definitions, ","), jsString(database), jsString(collection))
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+
+	// A collection that does not exist yet has no indexes
+	if strings.Contains(result.Stderr, "NamespaceNotFound") || strings.Contains(result.Stdout, "NamespaceNotFound") {
+		return nil, nil
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "list indexes failed: %s", result.Stderr)
+	}
+
+	var indexes []Index
+	if err := decodeOutput(result.Stdout, &indexes); err != nil {
+		return nil, fmt.Errorf("failed to parse indexes: %w", err)
+	}
+
+	return indexes, nil
+}
+
+// GetIndexBuildsInContainer returns the index builds in progress on a collection.
+// On mongos the builds of every shard are reported; progress is summed per index.
+func (m *indexManager) GetIndexBuildsInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection string, port int) ([]IndexBuild, error) {
+	command := fmt.Sprintf(`
+		const builds = {};
+		db.getSiblingDB('admin').aggregate([
+			{ $currentOp: { allUsers: true, idleConnections: false } },
+			{ $match: { 'command.createIndexes': %s, ns: { $regex: '^' + %s + '\\.' } } }
+		]).forEach(op => {
+			(op.command.indexes || []).forEach(idx => {
+				const b = builds[idx.name] || { name: idx.name, done: 0, total: 0 };
+				if (op.progress) {
+					b.done += Number(op.progress.done);
+					b.total += Number(op.progress.total);
+				}
+				builds[idx.name] = b;
+			});
+		});
+		JSON.stringify(Object.values(builds))
+	`, jsString(collection), jsString(database))
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get index builds: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "get index builds failed: %s", result.Stderr)
+	}
+
+	var builds []IndexBuild
+	if err := decodeOutput(result.Stdout, &builds); err != nil {
+		return nil, fmt.Errorf("failed to parse index builds: %w", err)
+	}
+
+	return builds, nil
+}
+
+// StartIndexBuildInContainer starts building an index in the background and returns immediately.
+// The definition is a JavaScript index document as built by resources.BuildIndexDefinition.
+// An empty commitQuorum leaves it out, as a standalone mongod requires.
+// Build errors are written to the container log; progress is observed with GetIndexBuildsInContainer.
+func (m *indexManager) StartIndexBuildInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, definition, commitQuorum string, port int) error {
+	quorum := ""
+	if commitQuorum != "" {
+		quorum = ", commitQuorum: " + jsString(commitQuorum)
+		if n, err := strconv.Atoi(commitQuorum); err == nil {
+			quorum = ", commitQuorum: " + strconv.Itoa(n)
+		}
+	}
+
+	script := fmt.Sprintf(`
+		const res = db.getSiblingDB(%s).runCommand({
+			createIndexes: %s,
+			indexes: [%s]%s
+		});
+		if (!res.ok) { print('createIndexes ' + %s + ' failed: ' + res.errmsg); }
+	`, jsString(database), jsString(collection), definition, quorum, jsString(database+"."+collection))
+
+	// Arguments are passed positionally so neither the credentials nor the script need shell quoting
+	command := []string{
+		"sh", "-c",
+		`nohup mongosh --quiet --port "$1" -u "$2" -p "$3" --authenticationDatabase admin --eval "$4" >/proc/1/fd/1 2>&1 &`,
+		"sh", strconv.Itoa(port), adminUser, adminPassword, script,
+	}
+
+	result, err := m.executor.ExecuteCommand(ctx, podName, namespace, container, command)
+	if err != nil {
+		return fmt.Errorf("failed to start index build: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "start index build failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// SetIndexTTLInContainer changes the expireAfterSeconds of a TTL index in place
+func (m *indexManager) SetIndexTTLInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, name string, expireAfterSeconds int32, port int) error {
+	command := fmt.Sprintf("db.getSiblingDB(%s).runCommand({ collMod: %s, index: { name: %s, expireAfterSeconds: %d } })",
+		jsString(database), jsString(collection), jsString(name), expireAfterSeconds)
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to set index TTL: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "collMod failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// DropIndexInContainer drops an index by name. Dropping a missing index is not an error.
+func (m *indexManager) DropIndexInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, name string, port int) error {
+	command := fmt.Sprintf("db.getSiblingDB(%s).getCollection(%s).dropIndex(%s)",
+		jsString(database), jsString(collection), jsString(name))
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to drop index: %w", err)
+	}
+
+	if strings.Contains(result.Stderr, "IndexNotFound") || strings.Contains(result.Stderr, "index not found") ||
+		strings.Contains(result.Stderr, "NamespaceNotFound") {
+		return nil
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "dropIndex failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// GetSetNameInContainer returns the name of the replica set the mongod of a pod runs in, "" when
+// it runs as a standalone
+func (m *indexManager) GetSetNameInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword string, port int) (string, error) {
+	command := "JSON.stringify({ setName: db.hello().setName || '' })"
+
+	result, err := m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return "", fmt.Errorf("failed to get replica set name: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return "", commandFailed(result, "hello failed: %s", result.Stderr)
+	}
+
+	var hello struct {
+		SetName string `json:"setName"`
+	}
+	if err := decodeOutput(result.Stdout, &hello); err != nil {
+		return "", fmt.Errorf("failed to parse replica set name: %w", err)
+	}
+
+	return hello.SetName, nil
+}
+
+// RestartInContainer shuts the mongod of a pod down for the container to start it again. A marker
+// path is created first, for the container entrypoint to start mongod differently once.
+func (m *indexManager) RestartInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, marker string, port int) error {
+	if marker != "" {
+		result, err := m.executor.ExecuteCommand(ctx, podName, namespace, container, []string{"touch", marker})
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", marker, err)
+		}
+		if result.ExitCode != 0 {
+			return commandFailed(result, "create %s failed: %s", marker, result.Stderr)
+		}
+	}
+
+	// The connection drops as mongod exits, whether the shell reports it or not is of no use. The
+	// caller observes the restart instead.
+	_, _ = m.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, container, adminUser, adminPassword, "admin",
+		"db.adminCommand({ shutdown: 1 })", port)
+
+	return nil
+}
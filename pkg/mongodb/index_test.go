@@ -0,0 +1,113 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCollectionInfoInContainer(t *testing.T) {
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: `{"exists":true,"type":"timeseries","capped":false}`}, nil
+	}), DefaultExecutorOptions())
+	info, err := NewIndexManagerWithExecutor(exec).GetCollectionInfoInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "metrics", "cpu", 27017)
+	require.NoError(t, err)
+	assert.Equal(t, &CollectionInfo{Exists: true, Type: "timeseries"}, info)
+
+	exec = NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: `{"exists":false}`}, nil
+	}), DefaultExecutorOptions())
+	info, err = NewIndexManagerWithExecutor(exec).GetCollectionInfoInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "metrics", "cpu", 27017)
+	require.NoError(t, err)
+	assert.Nil(t, info)
+}
+
+func TestCreateCollectionInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	indexes := NewIndexManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, indexes.CreateCollectionInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "metrics", "cpu", `{"timeseries":{"timeField":"ts"}}`, 27017))
+
+	command := recorder.commands[0]
+	assert.Equal(t, `db.getSiblingDB("metrics").createCollection("cpu", {"timeseries":{"timeField":"ts"}})`, command[len(command)-1])
+}
+
+func TestListIndexesInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: `[{"name":"_id_"},{"name":"ttl","ttlChanged":true},{"name":"email","changed":true}]`}, nil
+	}), DefaultExecutorOptions())
+	indexes, err := NewIndexManagerWithExecutor(exec).ListIndexesInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "app", "users", []string{`{"key":{"email":1},"name":"email"}`}, 27017)
+	require.NoError(t, err)
+	assert.Equal(t, []Index{{Name: "_id_"}, {Name: "ttl", TTLChanged: true}, {Name: "email", Changed: true}}, indexes)
+
+	_, err = NewIndexManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions())).ListIndexesInContainer(context.Background(),
+		"db-0", "default", "mongodb", "admin", "secret", "app", "users", []string{`{"key":{"a":1},"name":"a"}`, `{"key":{"b":1},"name":"b"}`}, 27017)
+	require.Error(t, err, "no output to parse")
+	command := recorder.commands[0]
+	assert.Contains(t, command[len(command)-1], `const desired = [{"key":{"a":1},"name":"a"},{"key":{"b":1},"name":"b"}];`)
+}
+
+func TestStartIndexBuildInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	indexes := NewIndexManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, indexes.StartIndexBuildInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "app", "users", `{"key":{"email":1},"name":"email"}`, "2", 27017))
+	require.NoError(t, indexes.StartIndexBuildInContainer(context.Background(), "db-1", "default", "mongodb",
+		"admin", "secret", "app", "users", `{"key":{"email":1},"name":"email"}`, "", 27017))
+
+	assert.Contains(t, recorder.commands[0][len(recorder.commands[0])-1], "commitQuorum: 2")
+	assert.NotContains(t, recorder.commands[1][len(recorder.commands[1])-1], "commitQuorum", "a standalone mongod has no quorum")
+}
+
+func TestSetIndexTTLInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	indexes := NewIndexManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, indexes.SetIndexTTLInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "app", "sessions", "expires", 3600, 27017))
+
+	command := recorder.commands[0]
+	assert.Equal(t, `db.getSiblingDB("app").runCommand({ collMod: "sessions", index: { name: "expires", expireAfterSeconds: 3600 } })`,
+		command[len(command)-1])
+}
+
+func TestRestartInContainer(t *testing.T) {
+	var calls int
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		calls++
+		if calls == 2 {
+			return &ExecResult{ExitCode: 1, Stderr: "MongoNetworkError: connection closed"}, nil
+		}
+		return &ExecResult{}, nil
+	}), DefaultExecutorOptions())
+	require.NoError(t, NewIndexManagerWithExecutor(exec).RestartInContainer(context.Background(), "db-1", "default", "mongodb",
+		"admin", "secret", "/data/db/.maintenance", 27017), "the shutdown drops the connection")
+	assert.Equal(t, 2, calls)
+
+	recorder := &commandRecorder{}
+	indexes := NewIndexManagerWithExecutor(NewExecutorWithRunner(recorder, DefaultExecutorOptions()))
+	require.NoError(t, indexes.RestartInContainer(context.Background(), "db-1", "default", "mongodb", "admin", "secret", "", 27017))
+	require.Len(t, recorder.commands, 1, "no marker to create")
+	assert.Contains(t, recorder.commands[0][len(recorder.commands[0])-1], "shutdown")
+}
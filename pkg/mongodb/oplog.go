@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// OplogTimestamp is the ts of an oplog entry: seconds since the epoch and the ordinal of the
+// operation within that second
+type OplogTimestamp struct {
+	T uint32 `json:"t"`
+	I uint32 `json:"i"`
+}
+
+// Before reports whether t is older than other
+func (t OplogTimestamp) Before(other OplogTimestamp) bool {
+	return t.T < other.T || (t.T == other.T && t.I < other.I)
+}
+
+// OplogWindow is the range of operations the oplog of a member still holds
+type OplogWindow struct {
+	First OplogTimestamp `json:"first"`
+	Last  OplogTimestamp `json:"last"`
+}
+
+// Covers reports whether the oplog still holds every operation after ts
+func (w *OplogWindow) Covers(ts OplogTimestamp) bool {
+	return !ts.Before(w.First)
+}
+
+// GetOplogWindowWithKeyfile returns the first and the last oplog entry of podName,
+// authenticating as the internal __system user
+func (r *replicaSetManager) GetOplogWindowWithKeyfile(ctx context.Context, podName, namespace, keyfile string) (*OplogWindow, error) {
+	// The high and low bits of a BSON timestamp are its seconds and ordinal
+	command := `
+		const oplog = db.getSiblingDB('local').oplog.rs;
+		const ts = order => {
+			const t = oplog.find({}, { ts: 1 }).sort({ $natural: order }).limit(1).next().ts;
+			return { t: t.getHighBits() >>> 0, i: t.getLowBits() >>> 0 };
+		};
+		JSON.stringify({ first: ts(1), last: ts(-1) })
+	`
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oplog window: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "oplog query failed: %s", result.Stderr)
+	}
+
+	var window OplogWindow
+	if err := decodeOutput(result.Stdout, &window); err != nil {
+		return nil, fmt.Errorf("failed to parse oplog window: %w", err)
+	}
+
+	return &window, nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetOplogWindow(t *testing.T) {
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: `{"first":{"t":1704067200,"i":1},"last":{"t":1704153600,"i":7}}`}, nil
+	}), DefaultExecutorOptions())
+	window, err := NewReplicaSetManagerWithExecutorAndPort(exec, 27017).GetOplogWindowWithKeyfile(context.Background(), "db-0", "default", "keyfile")
+	require.NoError(t, err)
+	assert.Equal(t, OplogTimestamp{T: 1704067200, I: 1}, window.First)
+	assert.Equal(t, OplogTimestamp{T: 1704153600, I: 7}, window.Last)
+
+	assert.True(t, window.Covers(OplogTimestamp{T: 1704067200, I: 1}))
+	assert.True(t, window.Covers(OplogTimestamp{T: 1704100000}))
+	assert.False(t, window.Covers(OplogTimestamp{T: 1704067200}), "the oplog rolled over past the timestamp")
+}
+
+func TestOplogTimestampBefore(t *testing.T) {
+	assert.True(t, OplogTimestamp{T: 1, I: 9}.Before(OplogTimestamp{T: 2}))
+	assert.True(t, OplogTimestamp{T: 2, I: 1}.Before(OplogTimestamp{T: 2, I: 2}))
+	assert.False(t, OplogTimestamp{T: 2, I: 2}.Before(OplogTimestamp{T: 2, I: 2}))
+	assert.False(t, OplogTimestamp{T: 3}.Before(OplogTimestamp{T: 2, I: 5}))
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ejsonStringify wraps a mongosh expression so that it prints its result as relaxed Extended
+// JSON. Unlike JSON.stringify it keeps Longs as numbers and dates and Timestamps as $date and
+// $timestamp documents, rather than mangling them into objects of their internal fields.
+func ejsonStringify(expression string) string {
+	return fmt.Sprintf("EJSON.stringify(%s, null, 0, {relaxed: true})", expression)
+}
+
+// decodeOutput decodes the JSON document mongosh printed into v. Lines around the document,
+// such as startup warnings or deprecation notices, are skipped.
+func decodeOutput(stdout string, v any) error {
+	lines := strings.Split(stdout, "\n")
+	err := fmt.Errorf("no JSON document in the output %q", truncate(stdout, 200))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+			continue
+		}
+		// The document may span several lines, whatever follows it is ignored
+		decoder := json.NewDecoder(bytes.NewBufferString(strings.Join(lines[i:], "\n")))
+		if err = decoder.Decode(v); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// truncate shortens s to at most n bytes for error messages
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeOutput(t *testing.T) {
+	var doc struct {
+		OK int `json:"ok"`
+	}
+
+	require.NoError(t, decodeOutput(`{"ok":1}`, &doc))
+	assert.Equal(t, 1, doc.OK)
+
+	// Warning banners before and notices after the document are skipped
+	stdout := "Warning: Could not access file: ENOENT: no such file or directory, mkdir '/.mongodb'\n" +
+		"{ \"ok\": 1,\n  \"note\": \"spans lines\" }\n" +
+		"(node:1) DeprecationWarning: something\n"
+	require.NoError(t, decodeOutput(stdout, &doc))
+	assert.Equal(t, 1, doc.OK)
+
+	// A line starting like JSON that is not the document
+	require.NoError(t, decodeOutput("[mongosh] banner\n{\"ok\":1}\n", &doc))
+
+	var names []string
+	require.NoError(t, decodeOutput("\n[\"_id_\",\"status_1\"]\n", &names))
+	assert.Equal(t, []string{"_id_", "status_1"}, names)
+
+	assert.ErrorContains(t, decodeOutput("MongoServerError: not primary", &doc), "no JSON document in the output")
+}
+
+func TestGetStatusParsesExtendedJSON(t *testing.T) {
+	// Relaxed Extended JSON of rs.status(), with dates, Timestamps and Longs
+	stdout := `Warning: Could not access file: ENOENT
+{"set":"rs0","date":{"$date":"2024-01-01T00:00:00Z"},"myState":1,"term":3,"ok":1,
+"members":[{"_id":0,"name":"db-0.db-headless.default.svc.cluster.local:27017","health":1,"state":1,"stateStr":"PRIMARY","uptime":3600,
+"optime":{"ts":{"$timestamp":{"t":1704067200,"i":1}},"t":3},"optimeDate":{"$date":"2024-01-01T00:00:00Z"},"self":true}]}`
+
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: stdout}, nil
+	}), DefaultExecutorOptions())
+	status, err := NewReplicaSetManagerWithExecutorAndPort(exec, 27017).GetStatus(context.Background(), "db-0", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "rs0", status.Set)
+	require.Len(t, status.Members, 1)
+	assert.Equal(t, int64(3600), status.Members[0].Uptime)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), status.Members[0].OptimeDate.Date)
+	assert.Equal(t, "db-0", status.PrimaryPod())
+}
+
+func TestGetConfigParsesExtendedJSON(t *testing.T) {
+	stdout := `{"_id":"rs0","version":4,"term":3,"members":[{"_id":0,"host":"db-0:27017","priority":1,"votes":1,"secondaryDelaySecs":0}],
+"settings":{"replicaSetId":{"$oid":"65920e800000000000000000"}}}`
+
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: stdout}, nil
+	}), DefaultExecutorOptions())
+	config, err := NewReplicaSetManagerWithExecutorAndPort(exec, 27017).GetConfig(context.Background(), "db-0", "default")
+	require.NoError(t, err)
+	assert.Equal(t, "rs0", config.ID)
+	assert.Equal(t, 4, config.Version)
+	require.Len(t, config.Members, 1)
+	assert.Equal(t, "db-0:27017", config.Members[0].Host)
+}
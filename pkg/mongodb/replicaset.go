@@ -0,0 +1,720 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// primaryPollInterval is how often WaitForPrimary asks a member for the primary
+const primaryPollInterval = 2 * time.Second
+
+// ReplicaSetConfig represents a MongoDB replica set configuration
+type ReplicaSetConfig struct {
+	ID      string             `json:"_id"`
+	Members []ReplicaSetMember `json:"members"`
+	Version int                `json:"version,omitempty"`
+}
+
+// ReplicaSetMember represents a member in a replica set
+type ReplicaSetMember struct {
+	ID          int     `json:"_id"`
+	Host        string  `json:"host"`
+	Priority    float64 `json:"priority,omitempty"`
+	Votes       int     `json:"votes,omitempty"`
+	ArbiterOnly bool    `json:"arbiterOnly,omitempty"`
+	Hidden      bool    `json:"hidden,omitempty"`
+	// SecondaryDelaySecs delays replication to the member, MongoDB 5.0 and later
+	SecondaryDelaySecs int               `json:"secondaryDelaySecs,omitempty"`
+	Tags               map[string]string `json:"tags,omitempty"`
+	// Horizons maps split horizon names to the host:port the member advertises in that horizon
+	Horizons map[string]string `json:"horizons,omitempty"`
+	// NonVoting sends votes and priority 0, which omitempty would leave to the defaults of 1, e.g.
+	// for the members beyond the seventh voter when initiating the replica set
+	NonVoting bool `json:"-"`
+}
+
+// MarshalJSON encodes the member, with votes and priority 0 for a non-voting member
+func (m ReplicaSetMember) MarshalJSON() ([]byte, error) {
+	type member ReplicaSetMember
+	if !m.NonVoting {
+		return json.Marshal(member(m))
+	}
+	return json.Marshal(struct {
+		member
+		Priority float64 `json:"priority"`
+		Votes    int     `json:"votes"`
+	}{member: member(m)})
+}
+
+// ReplicaSetStatus represents the status of a replica set
+type ReplicaSetStatus struct {
+	Set     string                   `json:"set"`
+	MyState int                      `json:"myState"`
+	Members []ReplicaSetMemberStatus `json:"members"`
+	OK      int                      `json:"ok"`
+}
+
+// ReplicaSetMemberStatus represents the status of a replica set member
+type ReplicaSetMemberStatus struct {
+	ID       int    `json:"_id"`
+	Name     string `json:"name"`
+	Health   int    `json:"health"`
+	State    int    `json:"state"`
+	StateStr string `json:"stateStr"`
+	Uptime   int64  `json:"uptime"`
+	Self     bool   `json:"self,omitempty"`
+
+	// OptimeDate is the time of the last operation the member applied
+	OptimeDate EJSONDate `json:"optimeDate"`
+}
+
+// EJSONDate is a date in relaxed Extended JSON
+type EJSONDate struct {
+	Date time.Time `json:"$date"`
+}
+
+// PrimaryPod returns the pod name of the primary, or "" without a primary
+func (s *ReplicaSetStatus) PrimaryPod() string {
+	for _, member := range s.Members {
+		if member.StateStr == "PRIMARY" {
+			// Extract pod name from host (e.g., "my-mongodb-0.my-mongodb-headless.ns.svc.cluster.local:27017")
+			return strings.Split(member.Name, ".")[0]
+		}
+	}
+	return ""
+}
+
+// CaughtUpPods returns the pods of the primary and of the secondaries whose last applied operation
+// is at most maxLag behind the one of the primary. Without a primary no member is caught up.
+func (s *ReplicaSetStatus) CaughtUpPods(maxLag time.Duration) map[string]bool {
+	var primary *ReplicaSetMemberStatus
+	for i := range s.Members {
+		if s.Members[i].StateStr == "PRIMARY" {
+			primary = &s.Members[i]
+		}
+	}
+	if primary == nil {
+		return map[string]bool{}
+	}
+
+	pods := make(map[string]bool, len(s.Members))
+	for _, member := range s.Members {
+		switch member.StateStr {
+		case "PRIMARY":
+		case "SECONDARY":
+			if primary.OptimeDate.Date.Sub(member.OptimeDate.Date) > maxLag {
+				continue
+			}
+		default:
+			continue
+		}
+		pods[strings.Split(member.Name, ".")[0]] = true
+	}
+	return pods
+}
+
+// ReplicaSetManager manages MongoDB replica set operations
+type ReplicaSetManager interface {
+	IsInitialized(ctx context.Context, podName, namespace string) (bool, error)
+	Initiate(ctx context.Context, podName, namespace string, config ReplicaSetConfig) error
+	GetStatus(ctx context.Context, podName, namespace string) (*ReplicaSetStatus, error)
+	GetStatusWithKeyfile(ctx context.Context, podName, namespace, keyfile string) (*ReplicaSetStatus, error)
+	GetPrimaryPod(ctx context.Context, podName, namespace string) (string, error)
+	HasPrimary(ctx context.Context, podName, namespace string) (bool, error)
+	WaitForPrimary(ctx context.Context, podName, namespace string) error
+	AddMember(ctx context.Context, podName, namespace, newHost string, arbiterOnly bool) error
+	AddMemberWithKeyfile(ctx context.Context, podName, namespace, keyfile, host string, horizons map[string]string) error
+	AddMembersWithKeyfile(ctx context.Context, podName, namespace, keyfile string, hosts []string) error
+	JoinReplicaSetWithKeyfile(ctx context.Context, podName, namespace, keyfile, replicaSet string, seeds, hosts []string) error
+	ForceReconfigWithKeyfile(ctx context.Context, podName, namespace, keyfile string, survivors []string) error
+	StepDownWithKeyfile(ctx context.Context, podName, namespace, keyfile string, stepDownSeconds, catchUpSeconds int) error
+	CompactWithKeyfile(ctx context.Context, podName, namespace, keyfile, database, collection string) error
+	RemoveMember(ctx context.Context, podName, namespace, hostToRemove string) error
+	Reconfigure(ctx context.Context, podName, namespace string, config ReplicaSetConfig, force bool) error
+	SetHorizonsWithKeyfile(ctx context.Context, podName, namespace, keyfile string, horizons map[string]map[string]string) error
+	SetMemberSettingsWithKeyfile(ctx context.Context, podName, namespace, keyfile string, settings map[string]MemberSettings) error
+	SetSettingsWithKeyfile(ctx context.Context, podName, namespace, keyfile string, settings map[string]any) error
+	GetConfig(ctx context.Context, podName, namespace string) (*ReplicaSetConfig, error)
+	GetBuildInfoWithAuth(ctx context.Context, podName, namespace, username, password, authDB string) (*BuildInfo, error)
+	SetDiagnosticsWithAuth(ctx context.Context, podName, namespace, username, password, authDB string, profiling, verbosity map[string]any) error
+	GetOplogWindowWithKeyfile(ctx context.Context, podName, namespace, keyfile string) (*OplogWindow, error)
+	GetStorageStatsWithKeyfile(ctx context.Context, podName, namespace, keyfile string) (*StorageStats, error)
+	GetStorageStatsWithAuth(ctx context.Context, podName, namespace, username, password, authDB string) (*StorageStats, error)
+}
+
+// replicaSetManager is the ReplicaSetManager running mongosh through an Executor
+type replicaSetManager struct {
+	executor Executor
+	port     int
+}
+
+// NewReplicaSetManagerWithExecutorAndPort creates a new replica set manager with provided executor and port
+func NewReplicaSetManagerWithExecutorAndPort(exec Executor, port int) ReplicaSetManager {
+	return &replicaSetManager{executor: exec, port: port}
+}
+
+// IsInitialized checks if the replica set is already initialized
+func (r *replicaSetManager) IsInitialized(ctx context.Context, podName, namespace string) (bool, error) {
+	result, err := r.executor.QueryMongoshInContainer(ctx, podName, namespace, "mongodb", "rs.status().ok", r.port)
+	if err != nil {
+		return false, nil // Not initialized or error
+	}
+
+	// If we get "1" back, it's initialized
+	if strings.TrimSpace(result.Stdout) == "1" {
+		return true, nil
+	}
+
+	// Check stderr for "no replset config" which means not initialized
+	if strings.Contains(result.Stderr, "no replset config") ||
+		strings.Contains(result.Stderr, "NotYetInitialized") {
+		return false, nil
+	}
+
+	return false, nil
+}
+
+// Initiate initializes a new replica set
+func (r *replicaSetManager) Initiate(ctx context.Context, podName, namespace string, config ReplicaSetConfig) error {
+	// Check if already initialized
+	initialized, err := r.IsInitialized(ctx, podName, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to check initialization status: %w", err)
+	}
+	if initialized {
+		return nil // Already initialized
+	}
+
+	// Build the rs.initiate() command
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	command := fmt.Sprintf("rs.initiate(%s)", string(configJSON))
+	result, err := r.executor.ExecuteMongoshWithPort(ctx, podName, namespace, command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to initiate replica set: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.initiate failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// GetStatus returns the current replica set status
+func (r *replicaSetManager) GetStatus(ctx context.Context, podName, namespace string) (*ReplicaSetStatus, error) {
+	result, err := r.executor.QueryMongoshInContainer(ctx, podName, namespace, "mongodb", ejsonStringify("rs.status()"), r.port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replica set status: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "rs.status() failed: %s", result.Stderr)
+	}
+
+	var status ReplicaSetStatus
+	if err := decodeOutput(result.Stdout, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse replica set status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// GetStatusWithKeyfile returns the replica set status as seen by podName, authenticating as the
+// internal __system user
+func (r *replicaSetManager) GetStatusWithKeyfile(ctx context.Context, podName, namespace, keyfile string) (*ReplicaSetStatus, error) {
+	result, err := r.executor.QueryMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", ejsonStringify("rs.status()"), r.port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replica set status: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "rs.status() failed: %s", result.Stderr)
+	}
+
+	var status ReplicaSetStatus
+	if err := decodeOutput(result.Stdout, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse replica set status: %w", err)
+	}
+
+	return &status, nil
+}
+
+// GetPrimaryPod returns the name of the primary pod
+func (r *replicaSetManager) GetPrimaryPod(ctx context.Context, podName, namespace string) (string, error) {
+	status, err := r.GetStatus(ctx, podName, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	if primary := status.PrimaryPod(); primary != "" {
+		return primary, nil
+	}
+
+	return "", fmt.Errorf("no primary found")
+}
+
+// HasPrimary checks if the replica set has an elected primary
+func (r *replicaSetManager) HasPrimary(ctx context.Context, podName, namespace string) (bool, error) {
+	status, err := r.GetStatus(ctx, podName, namespace)
+	if err != nil {
+		return false, err
+	}
+
+	for _, member := range status.Members {
+		if member.StateStr == "PRIMARY" && member.Health == 1 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// WaitForPrimary polls podName every primaryPollInterval until a primary is elected or ctx is done
+func (r *replicaSetManager) WaitForPrimary(ctx context.Context, podName, namespace string) error {
+	return WaitForCondition(ctx, primaryPollInterval, func() (bool, error) {
+		// Poll the member again instead of reusing the status of the cluster scope
+		scopeFrom(ctx).invalidate()
+		hasPrimary, err := r.HasPrimary(ctx, podName, namespace)
+		// Keep waiting while the member cannot be reached
+		return err == nil && hasPrimary, nil
+	})
+}
+
+// AddMember adds a new member to the replica set
+func (r *replicaSetManager) AddMember(ctx context.Context, podName, namespace, newHost string, arbiterOnly bool) error {
+	var command string
+	if arbiterOnly {
+		command = fmt.Sprintf("rs.addArb('%s')", newHost)
+	} else {
+		command = fmt.Sprintf("rs.add('%s')", newHost)
+	}
+
+	result, err := r.executor.ExecuteMongoshWithPort(ctx, podName, namespace, command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.add failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// AddMemberWithKeyfile adds the member of podName back to the replica set, authenticating as the
+// internal __system user, unless the configuration already has it. Horizons must match the horizon
+// names of the other members, nil when the replica set has none.
+func (r *replicaSetManager) AddMemberWithKeyfile(ctx context.Context, podName, namespace, keyfile, host string, horizons map[string]string) error {
+	member, err := json.Marshal(ReplicaSetMember{Host: host, Horizons: horizons})
+	if err != nil {
+		return fmt.Errorf("failed to marshal member: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		const member = %s;
+		delete member._id;
+		if (!rs.conf().members.some(m => m.host === member.host)) {
+			rs.add(member);
+		}
+	`, string(member))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to add member: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.add failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// AddMembersWithKeyfile adds the hosts missing from the replica set configuration one at a time,
+// authenticating as the internal __system user. It runs on podName, which must be the primary.
+func (r *replicaSetManager) AddMembersWithKeyfile(ctx context.Context, podName, namespace, keyfile string, hosts []string) error {
+	hostsJSON, err := json.Marshal(hosts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal members: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		const hosts = %s;
+		for (const host of hosts) {
+			if (!rs.conf().members.some(m => m.host === host)) {
+				rs.add({host: host});
+			}
+		}
+	`, string(hostsJSON))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to add members: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.add failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// JoinReplicaSetWithKeyfile adds the hosts missing from the remote replica set replicaSet as
+// read-only members, with priority 0 and no vote, authenticating as the internal __system user.
+// It runs from podName, connected to the primary found through the seed hosts.
+func (r *replicaSetManager) JoinReplicaSetWithKeyfile(ctx context.Context, podName, namespace, keyfile, replicaSet string, seeds, hosts []string) error {
+	hostsJSON, err := json.Marshal(hosts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal members: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		const hosts = %s;
+		for (const host of hosts) {
+			if (!rs.conf().members.some(m => m.host === host)) {
+				rs.add({host: host, priority: 0, votes: 0});
+			}
+		}
+	`, string(hostsJSON))
+
+	result, err := r.executor.ExecuteMongoshOnReplicaSetWithAuth(ctx, podName, namespace, replicaSet, seeds, "__system", strings.TrimSpace(keyfile), "local", command)
+	if err != nil {
+		return fmt.Errorf("failed to join the remote replica set: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.add on the remote replica set failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// ForceReconfigWithKeyfile forces a configuration keeping only the members of the surviving pods,
+// authenticating as the internal __system user. It runs on podName, which must be one of them, and
+// is only meant for a replica set that permanently lost the majority of its voting members: writes
+// acknowledged by the lost members only may be rolled back.
+func (r *replicaSetManager) ForceReconfigWithKeyfile(ctx context.Context, podName, namespace, keyfile string, survivors []string) error {
+	survivorsJSON, err := json.Marshal(survivors)
+	if err != nil {
+		return fmt.Errorf("failed to marshal surviving members: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		const survivors = %s;
+		const cfg = rs.conf();
+		cfg.members = cfg.members.filter(m => survivors.includes(m.host.split('.')[0]));
+		if (cfg.members.length !== survivors.length) {
+			throw new Error("surviving members are not all in the replica set configuration");
+		}
+		rs.reconfig(cfg, {force: true});
+	`, string(survivorsJSON))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to force replica set reconfiguration: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.reconfig failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// StepDownWithKeyfile steps the primary down so a caught up secondary is elected, authenticating
+// as the internal __system user. podName may be any member of the replica set, the command is sent
+// to the primary found in its replica set status.
+func (r *replicaSetManager) StepDownWithKeyfile(ctx context.Context, podName, namespace, keyfile string, stepDownSeconds, catchUpSeconds int) error {
+	status, err := r.GetStatusWithKeyfile(ctx, podName, namespace, keyfile)
+	if err != nil {
+		return err
+	}
+
+	primary := ""
+	for _, member := range status.Members {
+		if member.StateStr == "PRIMARY" {
+			primary = strings.Split(member.Name, ".")[0]
+		}
+	}
+	if primary == "" {
+		return fmt.Errorf("no primary found")
+	}
+
+	command := fmt.Sprintf("rs.stepDown(%d, %d)", stepDownSeconds, catchUpSeconds)
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, primary, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to step down primary: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.stepDown failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// CompactWithKeyfile compacts a collection on podName, authenticating as the internal __system user.
+// The command only runs on that member, compacting the primary requires force.
+func (r *replicaSetManager) CompactWithKeyfile(ctx context.Context, podName, namespace, keyfile, database, collection string) error {
+	command := fmt.Sprintf("const res = db.getSiblingDB(%s).runCommand({compact: %s, force: true}); if (!res.ok) { throw new Error(res.errmsg); }",
+		jsString(database), jsString(collection))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(longRunning(ctx), podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to compact collection: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "compact failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// RemoveMember removes a member from the replica set
+func (r *replicaSetManager) RemoveMember(ctx context.Context, podName, namespace, hostToRemove string) error {
+	command := fmt.Sprintf("rs.remove('%s')", hostToRemove)
+	result, err := r.executor.ExecuteMongoshWithPort(ctx, podName, namespace, command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to remove member: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.remove failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// Reconfigure updates the replica set configuration
+func (r *replicaSetManager) Reconfigure(ctx context.Context, podName, namespace string, config ReplicaSetConfig, force bool) error {
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	command := fmt.Sprintf("rs.reconfig(%s, {force: %t})", string(configJSON), force)
+	result, err := r.executor.ExecuteMongoshWithPort(ctx, podName, namespace, command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to reconfigure replica set: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.reconfig failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// SetHorizonsWithKeyfile sets the split horizons of the replica set members, authenticating as the
+// internal __system user. horizons maps pod names to the horizons of that member; an empty map
+// removes the horizons of every member. The configuration is only changed when it differs.
+func (r *replicaSetManager) SetHorizonsWithKeyfile(ctx context.Context, podName, namespace, keyfile string, horizons map[string]map[string]string) error {
+	horizonsJSON, err := json.Marshal(horizons)
+	if err != nil {
+		return fmt.Errorf("failed to marshal horizons: %w", err)
+	}
+
+	// Horizons must be defined on every member or on none, so members are matched on their pod name
+	command := fmt.Sprintf(`
+		const horizons = %s;
+		const cfg = rs.conf();
+		let changed = false;
+		cfg.members.forEach(m => {
+			const pod = m.host.split('.')[0];
+			const want = horizons[pod];
+			if (Object.keys(horizons).length > 0 && !want) {
+				throw new Error('no horizons for member ' + m.host);
+			}
+			if (JSON.stringify(m.horizons || null) === JSON.stringify(want || null)) {
+				return;
+			}
+			changed = true;
+			if (want) {
+				m.horizons = want;
+			} else {
+				delete m.horizons;
+			}
+		});
+		if (changed) {
+			rs.reconfig(cfg);
+		}
+	`, string(horizonsJSON))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to set replica set horizons: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.reconfig failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// MemberSettings are the settings of a replica set member managed through member overrides
+type MemberSettings struct {
+	Priority           float64           `json:"priority"`
+	Votes              int               `json:"votes"`
+	Hidden             bool              `json:"hidden"`
+	SecondaryDelaySecs int               `json:"secondaryDelaySecs"`
+	Tags               map[string]string `json:"tags"`
+}
+
+// SetMemberSettingsWithKeyfile applies the priority, votes, visibility, delay and tags of the
+// replica set members, authenticating as the internal __system user. settings maps pod names to
+// the settings of that member, members without an entry are left untouched. Each changed member
+// is reconfigured on its own, as a reconfig may change the votes of a single member at a time.
+func (r *replicaSetManager) SetMemberSettingsWithKeyfile(ctx context.Context, podName, namespace, keyfile string, settings map[string]MemberSettings) error {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal member settings: %w", err)
+	}
+
+	// Members are matched on their pod name, the configuration is read again after every reconfig
+	command := fmt.Sprintf(`
+		const settings = %s;
+		const pods = rs.conf().members.map(m => m.host.split('.')[0]);
+		pods.forEach(pod => {
+			const want = settings[pod];
+			if (!want) {
+				return;
+			}
+			const cfg = rs.conf();
+			const m = cfg.members.find(m => m.host.split('.')[0] === pod);
+			const same = m.priority === want.priority && m.votes === want.votes &&
+				(m.hidden || false) === want.hidden &&
+				Number(m.secondaryDelaySecs || 0) === want.secondaryDelaySecs &&
+				JSON.stringify(m.tags || {}) === JSON.stringify(want.tags || {});
+			if (same) {
+				return;
+			}
+			m.priority = want.priority;
+			m.votes = want.votes;
+			m.hidden = want.hidden;
+			m.secondaryDelaySecs = want.secondaryDelaySecs;
+			m.tags = want.tags || {};
+			rs.reconfig(cfg);
+		});
+	`, string(settingsJSON))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to set replica set member settings: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.reconfig failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// SetSettingsWithKeyfile merges settings into the settings document of the replica set
+// configuration, authenticating as the internal __system user. Settings not in the map keep their
+// value. The configuration is only changed when a value differs.
+func (r *replicaSetManager) SetSettingsWithKeyfile(ctx context.Context, podName, namespace, keyfile string, settings map[string]any) error {
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replica set settings: %w", err)
+	}
+
+	command := fmt.Sprintf(`
+		const settings = %s;
+		const cfg = rs.conf();
+		cfg.settings = cfg.settings || {};
+		let changed = false;
+		Object.keys(settings).forEach(k => {
+			if (cfg.settings[k] !== settings[k]) {
+				cfg.settings[k] = settings[k];
+				changed = true;
+			}
+		});
+		if (changed) {
+			rs.reconfig(cfg);
+		}
+	`, string(settingsJSON))
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, r.port)
+	if err != nil {
+		return fmt.Errorf("failed to set replica set settings: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "rs.reconfig failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// GetConfig returns the current replica set configuration
+func (r *replicaSetManager) GetConfig(ctx context.Context, podName, namespace string) (*ReplicaSetConfig, error) {
+	result, err := r.executor.QueryMongoshInContainer(ctx, podName, namespace, "mongodb", ejsonStringify("rs.conf()"), r.port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get replica set config: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "rs.conf() failed: %s", result.Stderr)
+	}
+
+	var config ReplicaSetConfig
+	if err := decodeOutput(result.Stdout, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse replica set config: %w", err)
+	}
+
+	return &config, nil
+}
+
+// BuildReplicaSetConfig builds a replica set configuration for initialization
+func BuildReplicaSetConfig(rsName, baseName, serviceName, namespace, clusterDomain string, members int, port int) ReplicaSetConfig {
+	config := ReplicaSetConfig{
+		ID:      rsName,
+		Members: make([]ReplicaSetMember, members),
+	}
+
+	for i := 0; i < members; i++ {
+		podName := fmt.Sprintf("%s-%d", baseName, i)
+		host := GetPodFQDN(podName, serviceName, namespace, clusterDomain, port)
+		config.Members[i] = ReplicaSetMember{
+			ID:   i,
+			Host: host,
+		}
+	}
+
+	return config
+}
+
+// BuildConfigServerReplicaSetConfig builds a config server replica set configuration
+func BuildConfigServerReplicaSetConfig(rsName, baseName, serviceName, namespace, clusterDomain string, members int, port int) ReplicaSetConfig {
+	return BuildReplicaSetConfig(rsName, baseName, serviceName, namespace, clusterDomain, members, port)
+}
+
+// BuildShardReplicaSetConfig builds a shard replica set configuration
+func BuildShardReplicaSetConfig(shardName, baseName, serviceName, namespace, clusterDomain string, members int, port int) ReplicaSetConfig {
+	return BuildReplicaSetConfig(shardName, baseName, serviceName, namespace, clusterDomain, members, port)
+}
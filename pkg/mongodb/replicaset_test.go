@@ -17,9 +17,13 @@ limitations under the License.
 package mongodb
 
 import (
+	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBuildReplicaSetConfig(t *testing.T) {
@@ -63,7 +67,7 @@ func TestBuildReplicaSetConfig(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			config := BuildReplicaSetConfig(tt.rsName, tt.baseName, tt.serviceName, tt.namespace, tt.members, tt.port)
+			config := BuildReplicaSetConfig(tt.rsName, tt.baseName, tt.serviceName, tt.namespace, "cluster.local", tt.members, tt.port)
 
 			assert.Equal(t, tt.rsName, config.ID)
 			assert.Len(t, config.Members, tt.members)
@@ -74,6 +78,7 @@ func TestBuildReplicaSetConfig(t *testing.T) {
 					config.Members[i].Host[:len(config.Members[i].Host)-len(":27017")-len("."+tt.serviceName+"."+tt.namespace+".svc.cluster.local")],
 					tt.serviceName,
 					tt.namespace,
+					"cluster.local",
 					tt.port,
 				)
 				assert.Contains(t, config.Members[i].Host, tt.serviceName)
@@ -85,7 +90,7 @@ func TestBuildReplicaSetConfig(t *testing.T) {
 }
 
 func TestBuildConfigServerReplicaSetConfig(t *testing.T) {
-	config := BuildConfigServerReplicaSetConfig("configReplSet", "my-cfg", "my-cfg-headless", "default", 3, 27019)
+	config := BuildConfigServerReplicaSetConfig("configReplSet", "my-cfg", "my-cfg-headless", "default", "", 3, 27019)
 
 	assert.Equal(t, "configReplSet", config.ID)
 	assert.Len(t, config.Members, 3)
@@ -98,7 +103,7 @@ func TestBuildConfigServerReplicaSetConfig(t *testing.T) {
 }
 
 func TestBuildShardReplicaSetConfig(t *testing.T) {
-	config := BuildShardReplicaSetConfig("shard0", "my-shard-0", "my-shard-0-headless", "default", 3, 27018)
+	config := BuildShardReplicaSetConfig("shard0", "my-shard-0", "my-shard-0-headless", "default", "cluster.local", 3, 27018)
 
 	assert.Equal(t, "shard0", config.ID)
 	assert.Len(t, config.Members, 3)
@@ -110,6 +115,13 @@ func TestBuildShardReplicaSetConfig(t *testing.T) {
 	}
 }
 
+func TestBuildReplicaSetConfigClusterDomain(t *testing.T) {
+	config := BuildReplicaSetConfig("rs0", "my-mongodb", "my-mongodb-headless", "default", "corp.example", 2, 27017)
+
+	assert.Equal(t, "my-mongodb-0.my-mongodb-headless.default.svc.corp.example:27017", config.Members[0].Host)
+	assert.Equal(t, "my-mongodb-1.my-mongodb-headless.default.svc.corp.example:27017", config.Members[1].Host)
+}
+
 func TestReplicaSetConfig(t *testing.T) {
 	config := ReplicaSetConfig{
 		ID: "rs0",
@@ -144,6 +156,42 @@ func TestReplicaSetMember(t *testing.T) {
 	assert.False(t, member.Hidden)
 }
 
+func TestReplicaSetMemberHorizons(t *testing.T) {
+	member := ReplicaSetMember{
+		ID:   1,
+		Host: "mongo-1.mongo-headless.default.svc.cluster.local:27017",
+	}
+
+	data, err := json.Marshal(member)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "horizons")
+
+	member.Horizons = map[string]string{"external": "mongo-1.example.com:27017"}
+	data, err = json.Marshal(member)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"horizons":{"external":"mongo-1.example.com:27017"}`)
+
+	var parsed ReplicaSetMember
+	require.NoError(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, member.Horizons, parsed.Horizons)
+}
+
+func TestReplicaSetMemberNonVoting(t *testing.T) {
+	member := ReplicaSetMember{ID: 7, Host: "mongo-7.mongo-headless.default.svc.cluster.local:27017"}
+	data, err := json.Marshal(member)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"votes"`)
+	assert.NotContains(t, string(data), `"priority"`)
+
+	member.NonVoting = true
+	data, err = json.Marshal(member)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"votes":0`)
+	assert.Contains(t, string(data), `"priority":0`)
+	assert.Contains(t, string(data), `"host":"mongo-7.mongo-headless.default.svc.cluster.local:27017"`)
+	assert.NotContains(t, string(data), "NonVoting")
+}
+
 func TestReplicaSetMemberArbiter(t *testing.T) {
 	member := ReplicaSetMember{
 		ID:          2,
@@ -187,6 +235,43 @@ func TestReplicaSetStatus(t *testing.T) {
 	}
 }
 
+func TestReplicaSetStatusPrimaryPod(t *testing.T) {
+	status := ReplicaSetStatus{
+		Members: []ReplicaSetMemberStatus{
+			{ID: 0, Name: "my-sharded-shard-0-0.my-sharded-shard-0-headless.default.svc.cluster.local:27018", StateStr: "SECONDARY"},
+			{ID: 1, Name: "my-sharded-shard-0-1.my-sharded-shard-0-headless.default.svc.cluster.local:27018", StateStr: "PRIMARY"},
+		},
+	}
+	assert.Equal(t, "my-sharded-shard-0-1", status.PrimaryPod())
+
+	status.Members[1].StateStr = "SECONDARY"
+	assert.Empty(t, status.PrimaryPod())
+}
+
+func TestReplicaSetStatusCaughtUpPods(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	member := func(pod, state string, behind time.Duration) ReplicaSetMemberStatus {
+		return ReplicaSetMemberStatus{
+			Name:       pod + ".cfg-headless.default.svc.cluster.local:27019",
+			StateStr:   state,
+			OptimeDate: EJSONDate{Date: now.Add(-behind)},
+		}
+	}
+	status := ReplicaSetStatus{
+		Members: []ReplicaSetMemberStatus{
+			member("cfg-0", "PRIMARY", 0),
+			member("cfg-1", "SECONDARY", 5*time.Second),
+			member("cfg-2", "SECONDARY", time.Minute),
+			member("cfg-3", "STARTUP2", 0),
+		},
+	}
+	assert.Equal(t, map[string]bool{"cfg-0": true, "cfg-1": true}, status.CaughtUpPods(10*time.Second))
+	assert.Len(t, status.CaughtUpPods(2*time.Minute), 3)
+
+	status.Members[0].StateStr = "SECONDARY"
+	assert.Empty(t, status.CaughtUpPods(time.Hour))
+}
+
 func TestReplicaSetMemberStatus(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -234,8 +319,45 @@ func TestReplicaSetMemberStatus(t *testing.T) {
 	}
 }
 
-func TestNewReplicaSetManagerWithExecutor(t *testing.T) {
+// commandRecorder records the commands it runs
+type commandRecorder struct {
+	commands [][]string
+}
+
+func (c *commandRecorder) Run(_ context.Context, _, _, _ string, command []string) (*ExecResult, error) {
+	c.commands = append(c.commands, command)
+	return &ExecResult{}, nil
+}
+
+func TestAddMembersWithKeyfile(t *testing.T) {
+	recorder := &commandRecorder{}
+	rs := NewReplicaSetManagerWithExecutorAndPort(NewExecutorWithRunner(recorder, DefaultExecutorOptions()), 27017)
+	hosts := []string{"db-2.east.example.com:27017", "db-3.east.example.com:27017"}
+	require.NoError(t, rs.AddMembersWithKeyfile(context.Background(), "db-0", "default", "keyfile\n", hosts))
+
+	require.Len(t, recorder.commands, 1, "all the hosts are added in one call")
+	command := recorder.commands[0]
+	assert.Contains(t, command, "__system")
+	assert.Contains(t, command, "keyfile")
+	assert.Contains(t, command[len(command)-1], `const hosts = ["db-2.east.example.com:27017","db-3.east.example.com:27017"];`)
+}
+
+func TestJoinReplicaSetWithKeyfile(t *testing.T) {
+	recorder := &commandRecorder{}
+	rs := NewReplicaSetManagerWithExecutorAndPort(NewExecutorWithRunner(recorder, DefaultExecutorOptions()), 27017)
+	seeds := []string{"prod-0.prod.example.com:27017", "prod-1.prod.example.com:27017"}
+	hosts := []string{"dr-0.dr.example.com:27017"}
+	require.NoError(t, rs.JoinReplicaSetWithKeyfile(context.Background(), "dr-0", "default", "keyfile", "rs0", seeds, hosts))
+
+	require.Len(t, recorder.commands, 1)
+	command := recorder.commands[0]
+	assert.Contains(t, command, "rs0/prod-0.prod.example.com:27017,prod-1.prod.example.com:27017", "connected to the remote replica set")
+	assert.NotContains(t, command, "--port")
+	assert.Contains(t, command[len(command)-1], "rs.add({host: host, priority: 0, votes: 0})")
+}
+
+func TestNewReplicaSetManagerWithExecutorAndPort(t *testing.T) {
 	// Create a manager with nil executor for testing
-	manager := NewReplicaSetManagerWithExecutor(nil)
+	manager := NewReplicaSetManagerWithExecutorAndPort(nil, 27017)
 	assert.NotNil(t, manager)
 }
@@ -70,7 +70,8 @@ func LongRetryConfig() RetryConfig {
 	}
 }
 
-// RetryWithBackoff retries a function with exponential backoff
+// RetryWithBackoff retries a function with exponential backoff. Errors IsRetryable reports as
+// terminal are returned at once.
 func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error) error {
 	backoff := wait.Backoff{
 		Duration: config.InitialDelay,
@@ -84,6 +85,9 @@ func RetryWithBackoff(ctx context.Context, config RetryConfig, fn func() error)
 	err := wait.ExponentialBackoffWithContext(ctx, backoff, func(ctx context.Context) (bool, error) {
 		if err := fn(); err != nil {
 			lastErr = err
+			if !IsRetryable(err) {
+				return false, err
+			}
 			return false, nil // Continue retrying
 		}
 		return true, nil // Success
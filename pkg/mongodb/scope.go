@@ -0,0 +1,101 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+type scopeKey struct{}
+
+// clusterScope is attached to the context of one reconcile of a cluster
+type clusterScope struct {
+	// cluster is the namespace/name of the cluster
+	cluster string
+
+	mu      sync.Mutex
+	results map[string]*ExecResult
+}
+
+// WithClusterScope returns the context of one reconcile of a cluster. Exec calls made with it
+// share the rate limit of the cluster, and read-only topology queries such as rs.status() are
+// answered once until a command that may change the topology runs.
+func WithClusterScope(ctx context.Context, namespace, name string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, &clusterScope{
+		cluster: namespace + "/" + name,
+		results: map[string]*ExecResult{},
+	})
+}
+
+// ForgetCluster drops the rate limiter of a deleted cluster
+func (e *executor) ForgetCluster(namespace, name string) {
+	e.limiters.Delete(namespace + "/" + name)
+}
+
+// limiter returns the rate limiter of the cluster of scope, nil without a scope or a limit
+func (e *executor) limiter(scope *clusterScope) flowcontrol.RateLimiter {
+	if scope == nil || e.options.QPS <= 0 {
+		return nil
+	}
+	if limiter, ok := e.limiters.Load(scope.cluster); ok {
+		return limiter.(flowcontrol.RateLimiter)
+	}
+	limiter, _ := e.limiters.LoadOrStore(scope.cluster, flowcontrol.NewTokenBucketRateLimiter(e.options.QPS, e.options.Burst))
+	return limiter.(flowcontrol.RateLimiter)
+}
+
+func scopeFrom(ctx context.Context) *clusterScope {
+	scope, _ := ctx.Value(scopeKey{}).(*clusterScope)
+	return scope
+}
+
+func (s *clusterScope) cached(key string) (*ExecResult, bool) {
+	if s == nil {
+		return nil, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[key]
+	return result, ok
+}
+
+func (s *clusterScope) store(key string, result *ExecResult) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[key] = result
+}
+
+// invalidate forgets the query results, a command may have changed the topology
+func (s *clusterScope) invalidate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.results)
+}
+
+func queryKey(podName, namespace, container string, command []string) string {
+	return strings.Join(append([]string{namespace, podName, container}, command...), "\x00")
+}
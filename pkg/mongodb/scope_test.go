@@ -0,0 +1,86 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterScopeCache(t *testing.T) {
+	scope := scopeFrom(WithClusterScope(context.Background(), "default", "cache"))
+	key := queryKey("cache-0", "default", "mongodb", mongoshCommand("JSON.stringify(rs.status())", 27017))
+
+	_, ok := scope.cached(key)
+	assert.False(t, ok)
+
+	result := &ExecResult{Stdout: "{}"}
+	scope.store(key, result)
+	cached, ok := scope.cached(key)
+	assert.True(t, ok)
+	assert.Same(t, result, cached)
+
+	// Another member or port is a different query
+	_, ok = scope.cached(queryKey("cache-1", "default", "mongodb", mongoshCommand("JSON.stringify(rs.status())", 27017)))
+	assert.False(t, ok)
+	_, ok = scope.cached(queryKey("cache-0", "default", "mongodb", mongoshCommand("JSON.stringify(rs.status())", 27018)))
+	assert.False(t, ok)
+
+	scope.invalidate()
+	_, ok = scope.cached(key)
+	assert.False(t, ok)
+}
+
+func TestClusterScopeWithoutScope(t *testing.T) {
+	ctx := context.Background()
+	scope := scopeFrom(ctx)
+	assert.Nil(t, scope)
+
+	scope.store("key", &ExecResult{})
+	_, ok := scope.cached("key")
+	assert.False(t, ok)
+	scope.invalidate()
+	exec := NewExecutorWithRunner(nil, DefaultExecutorOptions()).(*executor)
+	assert.Nil(t, exec.limiter(scope))
+}
+
+func TestClusterScopeLimiter(t *testing.T) {
+	exec := NewExecutorWithRunner(nil, DefaultExecutorOptions()).(*executor)
+	first := scopeFrom(WithClusterScope(context.Background(), "default", "limited"))
+	second := scopeFrom(WithClusterScope(context.Background(), "default", "limited"))
+	other := scopeFrom(WithClusterScope(context.Background(), "other", "limited"))
+
+	// Reconciles of one cluster share its rate limit, the cache is per reconcile
+	limiter := exec.limiter(first)
+	assert.NotNil(t, limiter)
+	assert.Same(t, limiter, exec.limiter(second))
+	assert.NotSame(t, limiter, exec.limiter(other))
+	first.store("key", &ExecResult{})
+	_, ok := second.cached("key")
+	assert.False(t, ok)
+
+	// Another executor has limiters of its own
+	assert.NotSame(t, limiter, NewExecutorWithRunner(nil, DefaultExecutorOptions()).(*executor).limiter(first))
+
+	exec.ForgetCluster("default", "limited")
+	assert.NotSame(t, limiter, exec.limiter(first))
+
+	unlimited := NewExecutorWithRunner(nil, ExecutorOptions{}).(*executor)
+	assert.Nil(t, unlimited.limiter(first))
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+)
+
+// MaxScriptSize bounds the scripts run through mongosh --eval, a single argument of the exec call
+// is limited to 128 KiB by Linux
+const MaxScriptSize = 100 * 1024
+
+// RunScriptInContainer runs a JavaScript script authenticated as an admin user, with db pointing
+// to database. The script loads fixture data, so it may run as long as the commands copying data.
+func (e *executor) RunScriptInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, script string, port int) error {
+	if len(script) > MaxScriptSize {
+		return fmt.Errorf("script of %d bytes exceeds the limit of %d bytes", len(script), MaxScriptSize)
+	}
+	command := fmt.Sprintf("db = db.getSiblingDB(%s);\n%s", jsString(database), script)
+
+	result, err := e.ExecuteMongoshWithAuthInContainer(longRunning(ctx), podName, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to run script: %w", err)
+	}
+	if result.ExitCode != 0 {
+		return commandFailed(result, "script failed: %s", result.Stderr)
+	}
+	return nil
+}
+
+// InsertDocumentsInContainer inserts the documents of an extended JSON file, one document or an
+// array of them, into a collection, authenticated as an admin user
+func (e *executor) InsertDocumentsInContainer(ctx context.Context, podName, namespace, container, adminUser, adminPassword, database, collection, documents string, port int) error {
+	script := fmt.Sprintf(`
+		const docs = [].concat(EJSON.parse(%s));
+		if (docs.length > 0) {
+			db.getCollection(%s).insertMany(docs);
+		}
+	`, jsString(documents), jsString(collection))
+	return e.RunScriptInContainer(ctx, podName, namespace, container, adminUser, adminPassword, database, script, port)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScriptInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	exec := NewExecutorWithRunner(recorder, DefaultExecutorOptions())
+	require.NoError(t, exec.RunScriptInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "app", `db.createRole({role: "reader", privileges: [], roles: ["read"]})`, 27017))
+
+	require.Len(t, recorder.commands, 1)
+	command := recorder.commands[0]
+	assert.Contains(t, command, "admin")
+	assert.Equal(t, "db = db.getSiblingDB(\"app\");\ndb.createRole({role: \"reader\", privileges: [], roles: [\"read\"]})", command[len(command)-1])
+
+	err := exec.RunScriptInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "app", strings.Repeat("x", MaxScriptSize+1), 27017)
+	assert.ErrorContains(t, err, "exceeds the limit")
+	assert.Len(t, recorder.commands, 1, "an oversized script is not run")
+}
+
+func TestInsertDocumentsInContainer(t *testing.T) {
+	recorder := &commandRecorder{}
+	exec := NewExecutorWithRunner(recorder, DefaultExecutorOptions())
+	require.NoError(t, exec.InsertDocumentsInContainer(context.Background(), "db-0", "default", "mongodb",
+		"admin", "secret", "app", "products", `[{"sku": "a-1", "price": {"$numberDecimal": "9.99"}}]`, 27017))
+
+	command := recorder.commands[0][len(recorder.commands[0])-1]
+	assert.Contains(t, command, `db = db.getSiblingDB("app");`)
+	assert.Contains(t, command, `EJSON.parse("[{\"sku\": \"a-1\", \"price\": {\"$numberDecimal\": \"9.99\"}}]")`)
+	assert.Contains(t, command, `db.getCollection("products").insertMany(docs)`)
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ShardStatus represents the status of a shard
+type ShardStatus struct {
+	ID    string `json:"_id"`
+	Host  string `json:"host"`
+	State int    `json:"state"`
+}
+
+// ShardingStatus represents the sharding status of the cluster
+type ShardingStatus struct {
+	Shards []ShardStatus `json:"shards"`
+	OK     int           `json:"ok"`
+}
+
+// ShardManager manages MongoDB sharding operations
+type ShardManager interface {
+	AddShardInContainer(ctx context.Context, mongosPod, namespace, container, shardConnectionString string, port int) error
+	AddShardWithAuthInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardConnectionString string, port int) error
+	RemoveShard(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, shardName string, port int) error
+	RemoveShardInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardName string, port int) (*RemoveShardResult, error)
+	MovePrimaryInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, database, toShard string, port int) error
+	FlushRouterConfigInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) error
+	ListShardsInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) ([]ShardStatus, error)
+	SetShardHostWithKeyfile(ctx context.Context, configPod, namespace, keyfile, shardName, host string, port int) error
+	ListShards(ctx context.Context, mongosPod, namespace string, port int) ([]ShardStatus, error)
+	ListShardsWithAuth(ctx context.Context, mongosPod, namespace, adminUser, adminPassword string, port int) ([]ShardStatus, error)
+	IsShardAdded(ctx context.Context, mongosPod, namespace, shardName string, port int) (bool, error)
+	IsShardAddedWithAuth(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, shardName string, port int) (bool, error)
+	GetShardingStatus(ctx context.Context, mongosPod, namespace string, port int) (string, error)
+	EnableSharding(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, database string, port int) error
+	ShardCollection(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, collection string, key map[string]interface{}, port int) error
+	GetBalancerStateInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) (*BalancerState, error)
+	SetBalancerEnabledInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, enabled bool, port int) error
+	SetBalancerWindowInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, window *BalancerWindow, port int) error
+	GetShardKeyInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection string, port int) (string, error)
+	ListShardedCollectionsInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) ([]string, error)
+	ShardCollectionInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection, key string, unique bool, options string, port int) error
+	SplitChunksInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection string, points []string, port int) error
+	AddShardToZoneInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardName, zone string, port int) error
+	RemoveShardFromZoneInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardName, zone string, port int) error
+	UpdateZoneKeyRangeInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection, min, max, zone string, port int) error
+	GetChunkDistributionInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, collection string, port int) ([]ChunkCount, error)
+}
+
+// shardManager is the ShardManager running mongosh through an Executor
+type shardManager struct {
+	executor Executor
+}
+
+// NewShardManagerWithExecutor creates a new shard manager with provided executor
+func NewShardManagerWithExecutor(exec Executor) ShardManager {
+	return &shardManager{executor: exec}
+}
+
+// AddShardInContainer adds a shard to the cluster via mongos in a specified container
+func (s *shardManager) AddShardInContainer(ctx context.Context, mongosPod, namespace, container, shardConnectionString string, port int) error {
+	command := fmt.Sprintf("sh.addShard('%s')", shardConnectionString)
+	result, err := s.executor.ExecuteMongoshInContainer(ctx, mongosPod, namespace, container, command, port)
+	if err != nil {
+		return fmt.Errorf("failed to add shard: %w", err)
+	}
+
+	// Check if shard already exists (not an error)
+	if strings.Contains(result.Stderr, "already exists") || strings.Contains(result.Stdout, "already exists") {
+		return nil
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "sh.addShard failed: stdout=%s, stderr=%s", result.Stdout, result.Stderr)
+	}
+
+	return nil
+}
+
+// AddShardWithAuthInContainer adds a shard with auth in a specified container
+func (s *shardManager) AddShardWithAuthInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardConnectionString string, port int) error {
+	command := fmt.Sprintf("sh.addShard('%s')", shardConnectionString)
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to add shard: %w", err)
+	}
+
+	// Check if shard already exists (not an error)
+	if strings.Contains(result.Stderr, "already exists") || strings.Contains(result.Stdout, "already exists") {
+		return nil
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "sh.addShard failed: stdout=%s, stderr=%s", result.Stdout, result.Stderr)
+	}
+
+	return nil
+}
+
+// RemoveShard removes a shard from the cluster
+func (s *shardManager) RemoveShard(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, shardName string, port int) error {
+	command := fmt.Sprintf("db.adminCommand({ removeShard: '%s' })", shardName)
+	result, err := s.executor.ExecuteMongoshWithAuthAndPort(ctx, mongosPod, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to remove shard: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "removeShard failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// RemoveShardResult is the progress reported by removeShard
+type RemoveShardResult struct {
+	// State is started, ongoing or completed
+	State string `json:"state"`
+	// RemainingChunks is the number of chunks still to be migrated off the shard
+	RemainingChunks int64 `json:"chunks"`
+	// JumboChunks is the number of remaining chunks too large to be migrated
+	JumboChunks int64 `json:"jumboChunks"`
+	// DBsToMove lists the databases whose primary shard is the shard being removed
+	DBsToMove []string `json:"dbsToMove"`
+}
+
+// RemoveShardInContainer starts or continues draining a shard and returns the progress.
+// It is called repeatedly until the state is completed; a shard that is already gone reports completed.
+func (s *shardManager) RemoveShardInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, shardName string, port int) (*RemoveShardResult, error) {
+	command := fmt.Sprintf(`
+		let out;
+		try {
+			const res = db.adminCommand({ removeShard: %s });
+			const remaining = res.remaining || {};
+			out = {
+				state: res.state,
+				chunks: Number(remaining.chunks || 0),
+				jumboChunks: Number(remaining.jumboChunks || 0),
+				dbsToMove: res.dbsToMove || []
+			};
+		} catch (e) {
+			if (e.codeName !== 'ShardNotFound') { throw e; }
+			out = { state: 'completed', chunks: 0, jumboChunks: 0, dbsToMove: [] };
+		}
+		JSON.stringify(out)
+	`, jsString(shardName))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove shard: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "removeShard failed: %s", result.Stderr)
+	}
+
+	var res RemoveShardResult
+	if err := decodeOutput(result.Stdout, &res); err != nil {
+		return nil, fmt.Errorf("failed to parse removeShard result: %w", err)
+	}
+
+	return &res, nil
+}
+
+// MovePrimaryInContainer moves the primary shard of a database to another shard
+func (s *shardManager) MovePrimaryInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword, database, toShard string, port int) error {
+	command := fmt.Sprintf("db.adminCommand({ movePrimary: %s, to: %s })", jsString(database), jsString(toShard))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(longRunning(ctx), mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to move primary: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "movePrimary failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// FlushRouterConfigInContainer marks the cached routing table of a mongos as stale, so it is
+// reloaded from the config servers on the next request
+func (s *shardManager) FlushRouterConfigInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) error {
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", "db.adminCommand({ flushRouterConfig: 1 })", port)
+	if err != nil {
+		return fmt.Errorf("failed to flush router config: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "flushRouterConfig failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// ListShardsInContainer returns the shards registered in config.shards, read through mongos
+func (s *shardManager) ListShardsInContainer(ctx context.Context, mongosPod, namespace, container, adminUser, adminPassword string, port int) ([]ShardStatus, error) {
+	command := ejsonStringify("db.adminCommand({ listShards: 1 })")
+	result, err := s.executor.QueryMongoshWithAuthInContainer(ctx, mongosPod, namespace, container, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shards: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "listShards failed: %s", result.Stderr)
+	}
+
+	var status ShardingStatus
+	if err := decodeOutput(result.Stdout, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse sharding status: %w", err)
+	}
+
+	return status.Shards, nil
+}
+
+// SetShardHostWithKeyfile rewrites the host of a shard in config.shards. It runs on the config
+// server primary, authenticating as the internal __system user, and waits for a majority of the
+// config servers. Routers pick the new host up once their routing table is flushed.
+func (s *shardManager) SetShardHostWithKeyfile(ctx context.Context, configPod, namespace, keyfile, shardName, host string, port int) error {
+	command := fmt.Sprintf(`
+		const res = db.getSiblingDB('config').shards.updateOne(
+			{ _id: %s },
+			{ $set: { host: %s } },
+			{ writeConcern: { w: 'majority', wtimeout: 30000 } }
+		);
+		if (res.matchedCount !== 1) {
+			throw new Error('shard not found in config.shards');
+		}
+	`, jsString(shardName), jsString(host))
+
+	result, err := s.executor.ExecuteMongoshWithAuthInContainer(ctx, configPod, namespace, "mongodb", "__system", strings.TrimSpace(keyfile), "local", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to set shard host: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "set shard host failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// ListShards returns the list of shards in the cluster
+func (s *shardManager) ListShards(ctx context.Context, mongosPod, namespace string, port int) ([]ShardStatus, error) {
+	result, err := s.executor.ExecuteMongoshJSONWithPort(ctx, mongosPod, namespace, "db.adminCommand({ listShards: 1 })", port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shards: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "listShards failed: %s", result.Stderr)
+	}
+
+	var status ShardingStatus
+	if err := decodeOutput(result.Stdout, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse sharding status: %w", err)
+	}
+
+	return status.Shards, nil
+}
+
+// ListShardsWithAuth returns the list of shards with authentication
+func (s *shardManager) ListShardsWithAuth(ctx context.Context, mongosPod, namespace, adminUser, adminPassword string, port int) ([]ShardStatus, error) {
+	command := ejsonStringify("db.adminCommand({ listShards: 1 })")
+	result, err := s.executor.QueryMongoshWithAuthInContainer(ctx, mongosPod, namespace, "mongodb", adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list shards: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "listShards failed: %s", result.Stderr)
+	}
+
+	var status ShardingStatus
+	if err := decodeOutput(result.Stdout, &status); err != nil {
+		return nil, fmt.Errorf("failed to parse sharding status: %w", err)
+	}
+
+	return status.Shards, nil
+}
+
+// IsShardAdded checks if a shard is already added to the cluster
+func (s *shardManager) IsShardAdded(ctx context.Context, mongosPod, namespace, shardName string, port int) (bool, error) {
+	shards, err := s.ListShards(ctx, mongosPod, namespace, port)
+	if err != nil {
+		return false, err
+	}
+
+	for _, shard := range shards {
+		if shard.ID == shardName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// IsShardAddedWithAuth checks if a shard is already added to the cluster (with auth)
+func (s *shardManager) IsShardAddedWithAuth(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, shardName string, port int) (bool, error) {
+	shards, err := s.ListShardsWithAuth(ctx, mongosPod, namespace, adminUser, adminPassword, port)
+	if err != nil {
+		return false, err
+	}
+
+	for _, shard := range shards {
+		if shard.ID == shardName {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// GetShardingStatus returns the full sharding status
+func (s *shardManager) GetShardingStatus(ctx context.Context, mongosPod, namespace string, port int) (string, error) {
+	result, err := s.executor.ExecuteMongoshWithPort(ctx, mongosPod, namespace, "sh.status()", port)
+	if err != nil {
+		return "", fmt.Errorf("failed to get sharding status: %w", err)
+	}
+
+	return result.Stdout, nil
+}
+
+// EnableSharding enables sharding on a database
+func (s *shardManager) EnableSharding(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, database string, port int) error {
+	command := fmt.Sprintf("sh.enableSharding('%s')", database)
+	result, err := s.executor.ExecuteMongoshWithAuthAndPort(ctx, mongosPod, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to enable sharding: %w", err)
+	}
+
+	// Check if already enabled
+	if strings.Contains(result.Stderr, "already enabled") || strings.Contains(result.Stdout, "already enabled") {
+		return nil
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "enableSharding failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// ShardCollection shards a collection
+func (s *shardManager) ShardCollection(ctx context.Context, mongosPod, namespace, adminUser, adminPassword, collection string, key map[string]interface{}, port int) error {
+	keyJSON, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("failed to marshal shard key: %w", err)
+	}
+
+	command := fmt.Sprintf("sh.shardCollection('%s', %s)", collection, string(keyJSON))
+	result, err := s.executor.ExecuteMongoshWithAuthAndPort(ctx, mongosPod, namespace, adminUser, adminPassword, "admin", command, port)
+	if err != nil {
+		return fmt.Errorf("failed to shard collection: %w", err)
+	}
+
+	// Check if already sharded
+	if strings.Contains(result.Stderr, "already sharded") || strings.Contains(result.Stdout, "already sharded") {
+		return nil
+	}
+
+	if result.ExitCode != 0 {
+		return commandFailed(result, "shardCollection failed: %s", result.Stderr)
+	}
+
+	return nil
+}
+
+// BuildShardConnectionString builds a connection string for adding a shard
+// Format: shardName/host1:port,host2:port,host3:port
+func BuildShardConnectionString(shardName, baseName, serviceName, namespace, clusterDomain string, members int, port int) string {
+	hosts := make([]string, members)
+	for i := 0; i < members; i++ {
+		podName := fmt.Sprintf("%s-%d", baseName, i)
+		hosts[i] = GetPodFQDN(podName, serviceName, namespace, clusterDomain, port)
+	}
+	return fmt.Sprintf("%s/%s", shardName, strings.Join(hosts, ","))
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// slowQueryMessage is the msg of the structured log entries mongod and mongos write for
+// operations slower than slowms
+const slowQueryMessage = "Slow query"
+
+// SlowQuery is a slow operation logged by mongod or mongos
+type SlowQuery struct {
+	Time           time.Time
+	Type           string
+	Namespace      string
+	DurationMillis int64
+	PlanSummary    string
+	KeysExamined   int64
+	DocsExamined   int64
+	NReturned      int64
+	// Command is the logged command, as JSON
+	Command json.RawMessage
+}
+
+type logEntry struct {
+	T struct {
+		Date time.Time `json:"$date"`
+	} `json:"t"`
+	Msg  string `json:"msg"`
+	Attr struct {
+		Type           string          `json:"type"`
+		NS             string          `json:"ns"`
+		DurationMillis int64           `json:"durationMillis"`
+		PlanSummary    string          `json:"planSummary"`
+		KeysExamined   int64           `json:"keysExamined"`
+		DocsExamined   int64           `json:"docsExamined"`
+		NReturned      int64           `json:"nreturned"`
+		Command        json.RawMessage `json:"command"`
+	} `json:"attr"`
+}
+
+// ParseSlowQuery parses a line of the structured log of mongod or mongos. ok is false for
+// lines that are not slow operations.
+func ParseSlowQuery(line string) (query SlowQuery, ok bool) {
+	// Skip the other entries before decoding them
+	if !strings.Contains(line, `"msg":"`+slowQueryMessage+`"`) {
+		return SlowQuery{}, false
+	}
+
+	var entry logEntry
+	if err := json.Unmarshal([]byte(line), &entry); err != nil || entry.Msg != slowQueryMessage {
+		return SlowQuery{}, false
+	}
+
+	return SlowQuery{
+		Time:           entry.T.Date,
+		Type:           entry.Attr.Type,
+		Namespace:      entry.Attr.NS,
+		DurationMillis: entry.Attr.DurationMillis,
+		PlanSummary:    entry.Attr.PlanSummary,
+		KeysExamined:   entry.Attr.KeysExamined,
+		DocsExamined:   entry.Attr.DocsExamined,
+		NReturned:      entry.Attr.NReturned,
+		Command:        entry.Attr.Command,
+	}, true
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlowQuery(t *testing.T) {
+	line := `{"t":{"$date":"2024-06-01T02:00:00.123+00:00"},"s":"I","c":"COMMAND","id":51803,"ctx":"conn12",` +
+		`"msg":"Slow query","attr":{"type":"command","ns":"app.orders","command":{"find":"orders","filter":{"status":"open"}},` +
+		`"planSummary":"COLLSCAN","keysExamined":0,"docsExamined":10000,"nreturned":3,"durationMillis":152}}`
+
+	query, ok := ParseSlowQuery(line)
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2024, 6, 1, 2, 0, 0, 123000000, time.UTC), query.Time.UTC())
+	assert.Equal(t, "command", query.Type)
+	assert.Equal(t, "app.orders", query.Namespace)
+	assert.Equal(t, int64(152), query.DurationMillis)
+	assert.Equal(t, "COLLSCAN", query.PlanSummary)
+	assert.Equal(t, int64(10000), query.DocsExamined)
+	assert.Equal(t, int64(3), query.NReturned)
+	assert.JSONEq(t, `{"find":"orders","filter":{"status":"open"}}`, string(query.Command))
+
+	_, ok = ParseSlowQuery(`{"t":{"$date":"2024-06-01T02:00:00.123+00:00"},"s":"I","c":"NETWORK","msg":"Connection accepted"}`)
+	assert.False(t, ok)
+	_, ok = ParseSlowQuery(`not json "msg":"Slow query"`)
+	assert.False(t, ok)
+}
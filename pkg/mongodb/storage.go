@@ -0,0 +1,81 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StorageStats is the disk usage of a member, in bytes
+type StorageStats struct {
+	// DataSize and StorageSize are summed over all databases of the member
+	DataSize    int64 `json:"dataSize"`
+	StorageSize int64 `json:"storageSize"`
+	IndexSize   int64 `json:"indexSize"`
+	// FsUsedSize and FsTotalSize describe the filesystem holding the dbPath
+	FsUsedSize  int64 `json:"fsUsedSize"`
+	FsTotalSize int64 `json:"fsTotalSize"`
+}
+
+// UsedPercent returns the filesystem usage of the dbPath in percent, rounded up
+func (s *StorageStats) UsedPercent() int32 {
+	if s.FsTotalSize <= 0 {
+		return 0
+	}
+	return int32((s.FsUsedSize*100 + s.FsTotalSize - 1) / s.FsTotalSize)
+}
+
+// GetStorageStatsWithKeyfile returns the db.stats() figures of a member, authenticating as the
+// internal __system user so the member is queried directly, whatever its replica set state
+func (r *replicaSetManager) GetStorageStatsWithKeyfile(ctx context.Context, podName, namespace, keyfile string) (*StorageStats, error) {
+	return r.GetStorageStatsWithAuth(ctx, podName, namespace, "__system", strings.TrimSpace(keyfile), "local")
+}
+
+// GetStorageStatsWithAuth returns the db.stats() figures of a member, authenticating with the given credentials
+func (r *replicaSetManager) GetStorageStatsWithAuth(ctx context.Context, podName, namespace, username, password, authDB string) (*StorageStats, error) {
+	// Every database reports the same filesystem figures, the sizes are per database
+	command := `
+		const totals = { dataSize: 0, storageSize: 0, indexSize: 0, fsUsedSize: 0, fsTotalSize: 0 };
+		db.adminCommand({ listDatabases: 1, nameOnly: true }).databases.forEach(d => {
+			const s = db.getSiblingDB(d.name).stats();
+			totals.dataSize += Number(s.dataSize || 0);
+			totals.storageSize += Number(s.storageSize || 0);
+			totals.indexSize += Number(s.indexSize || 0);
+			totals.fsUsedSize = Number(s.fsUsedSize || totals.fsUsedSize);
+			totals.fsTotalSize = Number(s.fsTotalSize || totals.fsTotalSize);
+		});
+		JSON.stringify(totals)
+	`
+
+	result, err := r.executor.ExecuteMongoshWithAuthInContainer(ctx, podName, namespace, "mongodb", username, password, authDB, command, r.port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage stats: %w", err)
+	}
+
+	if result.ExitCode != 0 {
+		return nil, commandFailed(result, "db.stats() failed: %s", result.Stderr)
+	}
+
+	var stats StorageStats
+	if err := decodeOutput(result.Stdout, &stats); err != nil {
+		return nil, fmt.Errorf("failed to parse storage stats: %w", err)
+	}
+
+	return &stats, nil
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageStats(t *testing.T) {
+	out := `{"dataSize":1048576,"storageSize":524288,"indexSize":65536,"fsUsedSize":8500000000,"fsTotalSize":10000000000}`
+
+	var stats StorageStats
+	require.NoError(t, json.Unmarshal([]byte(out), &stats))
+
+	assert.Equal(t, int64(1048576), stats.DataSize)
+	assert.Equal(t, int64(10000000000), stats.FsTotalSize)
+	assert.Equal(t, int32(85), stats.UsedPercent())
+
+	stats.FsUsedSize = 8500000001
+	assert.Equal(t, int32(86), stats.UsedPercent(), "usage is rounded up")
+
+	assert.Equal(t, int32(0), (&StorageStats{}).UsedPercent())
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is wrapped by the errors of exec calls that ran out of their timeout. Unlike the
+// deadline of the caller's context it is retryable.
+var ErrTimeout = errors.New("exec call timed out")
+
+type longRunningKey struct{}
+
+// longRunning marks the commands run with ctx as copying or rewriting data, bounded by the
+// LongCommandTimeout of the executor instead of its CommandTimeout
+func longRunning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, longRunningKey{}, true)
+}
+
+// commandTimeout returns the timeout of a command that is not a read-only query
+func (e *executor) commandTimeout(ctx context.Context) time.Duration {
+	if long, _ := ctx.Value(longRunningKey{}).(bool); long {
+		return e.options.LongCommandTimeout
+	}
+	return e.options.CommandTimeout
+}
+
+// runWithTimeout runs fn with ctx bounded by timeout. When the timeout rather than ctx ends the
+// call, the error wraps ErrTimeout.
+func runWithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (*ExecResult, error)) (*ExecResult, error) {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := fn(callCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(callCtx.Err(), context.DeadlineExceeded) {
+		return result, fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	}
+	return result, err
+}
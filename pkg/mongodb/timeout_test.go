@@ -0,0 +1,95 @@
+/*
+Copyright 2024 Keiailab.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mongodb
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingRunner blocks every command until its context is done, like a hung mongosh
+type hangingRunner struct{}
+
+func (hangingRunner) Run(ctx context.Context, _, _, _ string, _ []string) (*ExecResult, error) {
+	<-ctx.Done()
+	return &ExecResult{ExitCode: ExitCodeUnknown}, ctx.Err()
+}
+
+func testTimeouts(query, command, long time.Duration) ExecutorOptions {
+	return ExecutorOptions{QueryTimeout: query, CommandTimeout: command, LongCommandTimeout: long}
+}
+
+func TestExecTimeout(t *testing.T) {
+	rs := NewReplicaSetManagerWithExecutorAndPort(NewExecutorWithRunner(hangingRunner{}, testTimeouts(10*time.Millisecond, 20*time.Millisecond, time.Hour)), 27017)
+
+	_, err := rs.GetStatus(context.Background(), "db-0", "default")
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.Contains(t, err.Error(), "after 10ms")
+	assert.True(t, IsRetryable(err))
+
+	err = rs.AddMember(context.Background(), "db-0", "default", "db-3.db-svc:27017", false)
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.Contains(t, err.Error(), "after 20ms")
+}
+
+func TestExecTimeoutLongRunning(t *testing.T) {
+	rs := NewReplicaSetManagerWithExecutorAndPort(NewExecutorWithRunner(hangingRunner{}, testTimeouts(time.Hour, time.Hour, 10*time.Millisecond)), 27017)
+
+	err := rs.CompactWithKeyfile(context.Background(), "db-0", "default", "keyfile", "app", "orders")
+	require.ErrorIs(t, err, ErrTimeout)
+	assert.Contains(t, err.Error(), "after 10ms")
+}
+
+func TestExecTimeoutCallerDeadline(t *testing.T) {
+	rs := NewReplicaSetManagerWithExecutorAndPort(NewExecutorWithRunner(hangingRunner{}, testTimeouts(time.Hour, time.Hour, time.Hour)), 27017)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := rs.GetStatus(ctx, "db-0", "default")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, ErrTimeout)
+	assert.False(t, IsRetryable(err))
+}
+
+func TestExecTimeoutDisabled(t *testing.T) {
+	exec := NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		return &ExecResult{Stdout: "1"}, nil
+	}), testTimeouts(0, 0, 0))
+
+	result, err := exec.ExecuteCommand(context.Background(), "db-0", "default", "mongodb", []string{"true"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", result.Stdout)
+}
+
+func TestWaitForPrimaryPolls(t *testing.T) {
+	var calls atomic.Int32
+	rs := NewReplicaSetManagerWithExecutorAndPort(NewExecutorWithRunner(runnerFunc(func(string) (*ExecResult, error) {
+		calls.Add(1)
+		return &ExecResult{ExitCode: 1, Stderr: "MongoNetworkError: connect ECONNREFUSED"}, nil
+	}), DefaultExecutorOptions()), 27017)
+
+	ctx, cancel := context.WithTimeout(context.Background(), primaryPollInterval/2)
+	defer cancel()
+	err := rs.WaitForPrimary(ctx, "db-0", "default")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), calls.Load(), "the member is polled once per interval")
+}